@@ -4,9 +4,11 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -20,6 +22,7 @@ import (
 	"github.com/werf/kubedog/pkg/trackers/follow"
 	"github.com/werf/kubedog/pkg/trackers/rollout"
 	"github.com/werf/kubedog/pkg/trackers/rollout/multitrack"
+	"github.com/werf/kubedog/pkg/utils"
 )
 
 func main() {
@@ -32,6 +35,9 @@ func main() {
 	var namespace string
 	var timeoutSeconds int
 	var statusProgressPeriodSeconds int64
+	var statusProgressOnPhaseChange bool
+	var specsFile string
+	var verbosity string
 	var logsSince string
 	var kubeContext string
 	var kubeConfig string
@@ -74,18 +80,24 @@ func main() {
 			os.Exit(1)
 		}
 
-		logboek.Streams().EnableStyle()
+		// Colors are enabled for the terminal only, KUBEDOG_NO_COLOR overrides the detection in both directions
+		if utils.IsTerminal(os.Stdout) {
+			logboek.Streams().EnableStyle()
+		} else {
+			logboek.Streams().DisableStyle()
+		}
 		if noColorVal := os.Getenv("KUBEDOG_NO_COLOR"); noColorVal != "" {
-			noColor := false
 			for _, val := range []string{"1", "on", "true"} {
 				if noColorVal == val {
-					noColor = true
+					logboek.Streams().DisableStyle()
 					break
 				}
 			}
-
-			if noColor {
-				logboek.Streams().DisableStyle()
+			for _, val := range []string{"0", "off", "false"} {
+				if noColorVal == val {
+					logboek.Streams().EnableStyle()
+					break
+				}
 			}
 		}
 
@@ -118,9 +130,10 @@ func main() {
 	rootCmd.AddCommand(versionCmd)
 
 	multitrackCmd := &cobra.Command{
-		Use:     "multitrack",
-		Short:   "Track multiple resources using multitrack tracker",
-		Example: `echo '{"Deployments":[{"ResourceName":"mydeploy","Namespace":"myns"},{"ResourceName":"myresource","Namespace":"myns","FailMode":"HopeUntilEndOfDeployProcess","AllowFailuresCount":3,"SkipLogsForContainers":["two", "three"]}], "StatefulSets":[{"ResourceName":"mysts","Namespace":"myns"}]}' | kubedog multitrack`,
+		Use:   "multitrack",
+		Short: "Track multiple resources using multitrack tracker",
+		Example: `kubedog multitrack -f specs.yaml
+echo '{"Deployments":[{"ResourceName":"mydeploy","Namespace":"myns"},{"ResourceName":"myresource","Namespace":"myns","FailMode":"HopeUntilEndOfDeployProcess","AllowFailuresCount":3,"SkipLogsForContainers":["two", "three"]}], "StatefulSets":[{"ResourceName":"mysts","Namespace":"myns"}]}' | kubedog multitrack`,
 		Run: func(cmd *cobra.Command, args []string) {
 			init()
 
@@ -128,31 +141,64 @@ func main() {
 				logboek.Streams().SetPrefix(outputPrefix)
 			}
 
-			specsInput, err := ioutil.ReadAll(os.Stdin)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error reading stdin: %s\n", err)
-				os.Exit(1)
-			}
+			var specs multitrack.MultitrackSpecs
+			if specsFile != "" {
+				specsReader := io.Reader(os.Stdin)
+				if specsFile != "-" {
+					f, err := os.Open(specsFile)
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "Error opening specs file: %s\n", err)
+						os.Exit(1)
+					}
+					defer f.Close()
+					specsReader = f
+				}
 
-			specs := multitrack.MultitrackSpecs{}
-			err = json.Unmarshal(specsInput, &specs)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error parsing MultitrackSpecs json: %s\n", err)
-				os.Exit(1)
+				var err error
+				specs, err = multitrack.ParseSpecs(specsReader)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error parsing specs file %s: %s\n", specsFile, err)
+					os.Exit(1)
+				}
+			} else {
+				specsInput, err := ioutil.ReadAll(os.Stdin)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error reading stdin: %s\n", err)
+					os.Exit(1)
+				}
+
+				err = json.Unmarshal(specsInput, &specs)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error parsing MultitrackSpecs json: %s\n", err)
+					os.Exit(1)
+				}
 			}
 
 			multitrackOptions := multitrack.MultitrackOptions{
 				StatusProgressPeriod: time.Second * time.Duration(statusProgressPeriodSeconds),
 				Options:              makeTrackerOptions("track"),
+				RestConfig:           kube.Config,
+				DynamicClient:        kube.DynamicClient,
+				APIUsage:             kube.APIRequests,
+				Verbosity:            multitrack.Verbosity(strings.Title(verbosity)),
 			}
-			err = multitrack.Multitrack(kube.Kubernetes, specs, multitrackOptions)
-			if err != nil {
+			if statusProgressOnPhaseChange {
+				multitrackOptions.StatusProgressMode = multitrack.StatusProgressOnPhaseChange
+				// The default period is not used, the explicit one is rejected by Multitrack
+				if !cmd.Flags().Changed("status-progress-period") {
+					multitrackOptions.StatusProgressPeriod = 0
+				}
+			}
+			if err := multitrack.Multitrack(kube.Kubernetes, specs, multitrackOptions); err != nil {
 				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
 		},
 	}
+	multitrackCmd.PersistentFlags().StringVarP(&specsFile, "filename", "f", "", "YAML or JSON file with multitrack specs, '-' to read it from stdin. MultitrackSpecs json is read from stdin by default.")
+	multitrackCmd.PersistentFlags().StringVarP(&verbosity, "verbosity", "", "normal", "Output verbosity: quiet (final status progress and errors only), normal or verbose (with traces of the watched resources).")
 	multitrackCmd.PersistentFlags().Int64VarP(&statusProgressPeriodSeconds, "status-progress-period", "", 5, "Status progress period in seconds. Set -1 to stop showing status progress.")
+	multitrackCmd.PersistentFlags().BoolVarP(&statusProgressOnPhaseChange, "status-progress-on-phase-change", "", false, "Show status progress only when resources phases change instead of the periodic status progress.")
 
 	rootCmd.AddCommand(multitrackCmd)
 
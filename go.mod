@@ -3,6 +3,9 @@ module github.com/werf/kubedog
 require (
 	github.com/acarl005/stripansi v0.0.0-20180116102854-5a71ef0e047d
 	github.com/fatih/color v1.9.0
+	github.com/prometheus/client_golang v1.7.1
+	github.com/prometheus/client_model v0.2.0
+	github.com/prometheus/common v0.10.0
 	github.com/spf13/cobra v1.0.0
 	github.com/werf/logboek v0.4.3
 	golang.org/x/crypto v0.0.0-20200220183623-bac4c82f6975
@@ -10,6 +13,7 @@ require (
 	k8s.io/apimachinery v0.18.6
 	k8s.io/client-go v0.18.6
 	k8s.io/klog v1.0.0
+	sigs.k8s.io/yaml v1.2.0
 )
 
 go 1.14
@@ -11,8 +11,7 @@ var (
 	Out io.Writer = os.Stdout
 	Err io.Writer = os.Stderr
 
-	mutex            = &sync.Mutex{}
-	currentLogHeader = ""
+	defaultDisplay = &Display{}
 )
 
 func SetOut(out io.Writer) {
@@ -28,46 +27,138 @@ type LogLine struct {
 	Message   string
 }
 
-func fWriteF(stream io.Writer, format string, args ...interface{}) (n int, err error) {
-	mutex.Lock()
-	defer mutex.Unlock()
+// Display is the output of a tracking call, e.g. set into tracker.Options.Display to capture the output of the call
+// into a buffer or to keep the outputs of concurrent calls separated. Writes of the Display are serialized.
+// Nil Display writes into the global Out and Err.
+type Display struct {
+	out io.Writer
+	err io.Writer
+
+	mutex            sync.Mutex
+	currentLogHeader string
+}
+
+// NewDisplay returns Display writing into out and err, nil err means out.
+func NewDisplay(out, err io.Writer) *Display {
+	if err == nil {
+		err = out
+	}
+	return &Display{out: out, err: err}
+}
+
+func (d *Display) resolve() *Display {
+	if d == nil {
+		return defaultDisplay
+	}
+	return d
+}
+
+func (d *Display) outStream() io.Writer {
+	if d.out == nil {
+		return Out
+	}
+	return d.out
+}
+
+func (d *Display) errStream() io.Writer {
+	if d.err == nil {
+		return Err
+	}
+	return d.err
+}
+
+// Out returns the writer of the standard output of the Display, each write is serialized with other writes of the Display.
+func (d *Display) Out() io.Writer {
+	d = d.resolve()
+	return &lockedWriter{display: d, stream: d.outStream}
+}
+
+// Err returns the writer of the error output of the Display, each write is serialized with other writes of the Display.
+func (d *Display) Err() io.Writer {
+	d = d.resolve()
+	return &lockedWriter{display: d, stream: d.errStream}
+}
+
+func (d *Display) fWriteF(stream io.Writer, format string, args ...interface{}) (n int, err error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
 	return fmt.Fprintf(stream, format, args...)
 }
 
-func OutF(format string, args ...interface{}) (n int, err error) {
-	return fWriteF(Out, format, args...)
+func (d *Display) OutF(format string, args ...interface{}) (n int, err error) {
+	d = d.resolve()
+	return d.fWriteF(d.outStream(), format, args...)
 }
 
-func ErrF(format string, args ...interface{}) (n int, err error) {
-	return fWriteF(Err, format, args...)
+func (d *Display) ErrF(format string, args ...interface{}) (n int, err error) {
+	d = d.resolve()
+	return d.fWriteF(d.errStream(), format, args...)
 }
 
-func SetLogHeader(logHeader string) {
-	mutex.Lock()
-	defer mutex.Unlock()
+func (d *Display) SetLogHeader(logHeader string) {
+	d = d.resolve()
 
-	if currentLogHeader != logHeader {
-		if currentLogHeader != "" {
-			fmt.Fprintln(Out)
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.setLogHeader(logHeader)
+}
+
+func (d *Display) setLogHeader(logHeader string) {
+	if d.currentLogHeader != logHeader {
+		if d.currentLogHeader != "" {
+			fmt.Fprintln(d.outStream())
 		}
-		fmt.Fprintf(Out, ">> %s\n", logHeader)
-		currentLogHeader = logHeader
+		fmt.Fprintf(d.outStream(), ">> %s\n", logHeader)
+		d.currentLogHeader = logHeader
 	}
 }
 
-func OutputLogLines(header string, logLines []LogLine) {
+func (d *Display) OutputLogLines(header string, logLines []LogLine) {
+	d = d.resolve()
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
 	if inline() {
 		for _, line := range logLines {
-			fmt.Fprintf(Out, ">> %s: %s\n", header, line.Message)
+			fmt.Fprintf(d.outStream(), ">> %s: %s\n", header, line.Message)
 		}
 	} else {
-		SetLogHeader(header)
+		d.setLogHeader(header)
 		for _, line := range logLines {
-			fmt.Fprintln(Out, line.Message)
+			fmt.Fprintln(d.outStream(), line.Message)
 		}
 	}
 }
 
+type lockedWriter struct {
+	display *Display
+	stream  func() io.Writer
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.display.mutex.Lock()
+	defer w.display.mutex.Unlock()
+	return w.stream().Write(p)
+}
+
+func OutF(format string, args ...interface{}) (n int, err error) {
+	return defaultDisplay.OutF(format, args...)
+}
+
+func ErrF(format string, args ...interface{}) (n int, err error) {
+	return defaultDisplay.ErrF(format, args...)
+}
+
+func SetLogHeader(logHeader string) {
+	defaultDisplay.SetLogHeader(logHeader)
+}
+
+func OutputLogLines(header string, logLines []LogLine) {
+	defaultDisplay.OutputLogLines(header, logLines)
+}
+
 func inline() bool {
 	return os.Getenv("KUBEDOG_LOG_INLINE") == "1"
 }
@@ -0,0 +1,213 @@
+package kube
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/rest"
+
+	"github.com/werf/kubedog"
+)
+
+// UserAgent is set for the clients constructed by kubedog, so that the load produced by kubedog
+// can be attributed in the apiserver audit log.
+var UserAgent = fmt.Sprintf("kubedog/%s", kubedog.Version)
+
+// APIUsage counts Kubernetes API requests by verb and resource.
+type APIUsage struct {
+	mux sync.Mutex
+
+	byVerb     map[string]int
+	byResource map[string]int
+	total      int
+
+	currentSecond      int64
+	currentSecondCount int
+	peakRPS            int
+}
+
+func NewAPIUsage() *APIUsage {
+	return &APIUsage{
+		byVerb:     make(map[string]int),
+		byResource: make(map[string]int),
+	}
+}
+
+// WrapConfig returns a copy of the config which counts requests of the clients created from it.
+// Use it to count requests of the clientset passed to kubedog:
+//
+//	clientset, err := kubernetes.NewForConfig(kube.WrapConfig(config, usage))
+func WrapConfig(config *rest.Config, usage *APIUsage) *rest.Config {
+	config = rest.CopyConfig(config)
+	config.Wrap(usage.WrapTransport)
+	return config
+}
+
+// WrapTransport returns RoundTripper which counts requests passed to rt.
+func (u *APIUsage) WrapTransport(rt http.RoundTripper) http.RoundTripper {
+	return &apiUsageRoundTripper{usage: u, rt: rt}
+}
+
+func (u *APIUsage) register(verb, resource string) {
+	u.mux.Lock()
+	defer u.mux.Unlock()
+
+	u.total++
+	u.byVerb[verb]++
+	u.byResource[resource]++
+
+	now := time.Now().Unix()
+	if now != u.currentSecond {
+		u.currentSecond = now
+		u.currentSecondCount = 0
+	}
+	u.currentSecondCount++
+	if u.currentSecondCount > u.peakRPS {
+		u.peakRPS = u.currentSecondCount
+	}
+}
+
+func (u *APIUsage) Stats() APIUsageStats {
+	u.mux.Lock()
+	defer u.mux.Unlock()
+
+	stats := APIUsageStats{
+		Total:      u.total,
+		ByVerb:     make(map[string]int, len(u.byVerb)),
+		ByResource: make(map[string]int, len(u.byResource)),
+		PeakRPS:    u.peakRPS,
+	}
+	for verb, count := range u.byVerb {
+		stats.ByVerb[verb] = count
+	}
+	for resource, count := range u.byResource {
+		stats.ByResource[resource] = count
+	}
+
+	return stats
+}
+
+type APIUsageStats struct {
+	Total int
+	// ByVerb is keyed by GET, LIST, WATCH, CREATE, UPDATE, PATCH or DELETE.
+	ByVerb map[string]int
+	// ByResource is keyed by resource with the subresource if any, e.g. "pods" or "pods/log".
+	ByResource map[string]int
+	PeakRPS    int
+}
+
+var apiVerbsOrder = []string{"GET", "LIST", "WATCH", "CREATE", "UPDATE", "PATCH", "DELETE"}
+
+// String returns the summary, e.g. "API usage: 312 GET, 48 LIST, 61 WATCH, peak 9 rps".
+func (s APIUsageStats) String() string {
+	var parts []string
+	for _, verb := range apiVerbsOrder {
+		if count := s.ByVerb[verb]; count > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", count, verb))
+		}
+	}
+	for _, verb := range sortedCountsKeys(s.ByVerb) {
+		if !isKnownAPIVerb(verb) {
+			parts = append(parts, fmt.Sprintf("%d %s", s.ByVerb[verb], verb))
+		}
+	}
+	parts = append(parts, fmt.Sprintf("peak %d rps", s.PeakRPS))
+
+	return fmt.Sprintf("API usage: %s", strings.Join(parts, ", "))
+}
+
+// ResourcesString returns requests count by resource, e.g. "pods: 120, events: 48".
+func (s APIUsageStats) ResourcesString() string {
+	var parts []string
+	for _, resource := range sortedCountsKeys(s.ByResource) {
+		parts = append(parts, fmt.Sprintf("%s: %d", resource, s.ByResource[resource]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func isKnownAPIVerb(verb string) bool {
+	for _, v := range apiVerbsOrder {
+		if v == verb {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedCountsKeys returns keys ordered by count descending, then by key.
+func sortedCountsKeys(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+type apiUsageRoundTripper struct {
+	usage *APIUsage
+	rt    http.RoundTripper
+}
+
+func (rt *apiUsageRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	verb, resource := parseAPIRequest(req)
+	rt.usage.register(verb, resource)
+	return rt.rt.RoundTrip(req)
+}
+
+// parseAPIRequest returns verb and resource of the request to the path like
+// /api/v1/namespaces/NS/pods/NAME/log or /apis/apps/v1/namespaces/NS/deployments.
+func parseAPIRequest(req *http.Request) (string, string) {
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+
+	switch {
+	case len(parts) >= 2 && parts[0] == "api":
+		parts = parts[2:]
+	case len(parts) >= 3 && parts[0] == "apis":
+		parts = parts[3:]
+	default:
+		return strings.ToUpper(req.Method), "other"
+	}
+
+	if len(parts) >= 1 && parts[0] == "watch" {
+		parts = parts[1:]
+	}
+	if len(parts) >= 3 && parts[0] == "namespaces" {
+		parts = parts[2:]
+	}
+
+	resource := "other"
+	if len(parts) >= 1 {
+		resource = parts[0]
+	}
+	if len(parts) >= 3 {
+		resource = fmt.Sprintf("%s/%s", resource, parts[2])
+	}
+	hasName := len(parts) >= 2
+
+	switch req.Method {
+	case http.MethodGet:
+		if req.URL.Query().Get("watch") == "true" || req.URL.Query().Get("watch") == "1" || strings.Contains(req.URL.Path, "/watch/") {
+			return "WATCH", resource
+		}
+		if !hasName {
+			return "LIST", resource
+		}
+		return "GET", resource
+	case http.MethodPost:
+		return "CREATE", resource
+	case http.MethodPut:
+		return "UPDATE", resource
+	default:
+		return strings.ToUpper(req.Method), resource
+	}
+}
@@ -0,0 +1,155 @@
+package kube
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"k8s.io/client-go/rest"
+)
+
+// CredentialsRefresher is the transport of the clients, which rebuilds the underlying transport from the config
+// returned by the refresh function when the API server starts rejecting the credentials accepted before,
+// e.g. after rotation of the projected ServiceAccount token or expiration of the client certificate.
+// The rejected request is retried once with the refreshed credentials, so the clients and their watches survive the rotation.
+type CredentialsRefresher struct {
+	refresh   func() (*rest.Config, error)
+	onRefresh func()
+
+	mux        sync.Mutex
+	rt         http.RoundTripper
+	generation int
+	isAccepted bool
+	refreshErr error
+}
+
+// NewRefreshableConfig returns the config of the clients which refresh credentials with the refresh function,
+// see CredentialsRefresher. Refreshed configs are used only to build the transport. onRefresh is called after
+// each successful refresh, optional.
+func NewRefreshableConfig(config *rest.Config, refresh func() (*rest.Config, error), onRefresh func()) (*rest.Config, *CredentialsRefresher, error) {
+	rt, err := rest.TransportFor(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create transport: %s", err)
+	}
+
+	refresher := &CredentialsRefresher{refresh: refresh, onRefresh: onRefresh, rt: rt}
+
+	return &rest.Config{
+		Host:          config.Host,
+		APIPath:       config.APIPath,
+		ContentConfig: config.ContentConfig,
+		UserAgent:     config.UserAgent,
+		QPS:           config.QPS,
+		Burst:         config.Burst,
+		RateLimiter:   config.RateLimiter,
+		Timeout:       config.Timeout,
+		Transport:     refresher,
+	}, refresher, nil
+}
+
+// Err returns the error of the last refresh, nil when the last refresh succeeded or there was no refresh.
+func (r *CredentialsRefresher) Err() error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	return r.refreshErr
+}
+
+func (r *CredentialsRefresher) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt, generation := r.currentTransport()
+
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		r.setAccepted(generation)
+		return resp, nil
+	}
+
+	// The request with a body which can not be sent again is not retried, the client gets 401 as is
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+
+	newRT, isRefreshed := r.refreshTransport(generation)
+	if !isRefreshed {
+		return resp, nil
+	}
+
+	retryReq := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		retryReq.Body = body
+	}
+	resp.Body.Close()
+
+	return newRT.RoundTrip(retryReq)
+}
+
+func (r *CredentialsRefresher) currentTransport() (http.RoundTripper, int) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	return r.rt, r.generation
+}
+
+func (r *CredentialsRefresher) setAccepted(generation int) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if r.generation == generation {
+		r.isAccepted = true
+	}
+}
+
+// refreshTransport returns the transport to retry the request rejected by the transport of the generation.
+// Credentials are refreshed only when they were accepted before: the credentials rejected from the start
+// are misconfigured, and refreshed credentials rejected again are not refreshed in a loop.
+func (r *CredentialsRefresher) refreshTransport(generation int) (http.RoundTripper, bool) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	// Refreshed by a concurrent request
+	if r.generation != generation {
+		return r.rt, true
+	}
+	if !r.isAccepted {
+		return nil, false
+	}
+
+	rt, err := r.refreshedTransport()
+	if err != nil {
+		r.refreshErr = err
+		r.isAccepted = false
+		return nil, false
+	}
+
+	r.rt = rt
+	r.generation++
+	r.isAccepted = false
+	r.refreshErr = nil
+
+	if r.onRefresh != nil {
+		r.onRefresh()
+	}
+
+	return r.rt, true
+}
+
+func (r *CredentialsRefresher) refreshedTransport() (http.RoundTripper, error) {
+	config, err := r.refresh()
+	if err != nil {
+		return nil, err
+	}
+
+	rt, err := rest.TransportFor(config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create transport: %s", err)
+	}
+
+	return rt, nil
+}
@@ -29,8 +29,12 @@ const (
 var (
 	Kubernetes, Client kubernetes.Interface
 	DynamicClient      dynamic.Interface
+	Config             *rest.Config
 	DefaultNamespace   string
 	Context            string
+
+	// APIRequests counts requests of the clients constructed by kubedog.
+	APIRequests = NewAPIUsage()
 )
 
 type InitOptions struct {
@@ -44,12 +48,15 @@ func Init(opts InitOptions) error {
 	}
 
 	if config != nil {
+		setupConfig(config.Config)
+
 		clientset, err := kubernetes.NewForConfig(config.Config)
 		if err != nil {
 			return err
 		}
 		Kubernetes = clientset
 		Client = clientset
+		Config = config.Config
 
 		dynamicClient, err := dynamic.NewForConfig(config.Config)
 		if err != nil {
@@ -249,6 +256,8 @@ func getOutOfClusterContextsClients(configPath string) ([]*ContextClient, error)
 			return nil, makeOutOfClusterClientConfigError(configPath, contextName, err)
 		}
 
+		setupConfig(config)
+
 		clientset, err := kubernetes.NewForConfig(config)
 		if err != nil {
 			return nil, err
@@ -288,6 +297,8 @@ func getInClusterContextClient() (*ContextClient, error) {
 		return nil, err
 	}
 
+	setupConfig(kubeConfig.Config)
+
 	clientset, err := kubernetes.NewForConfig(kubeConfig.Config)
 	if err != nil {
 		return nil, err
@@ -300,6 +311,12 @@ func getInClusterContextClient() (*ContextClient, error) {
 	}, nil
 }
 
+// setupConfig sets kubedog user agent and counting of the requests into APIRequests.
+func setupConfig(config *rest.Config) {
+	config.UserAgent = UserAgent
+	config.Wrap(APIRequests.WrapTransport)
+}
+
 func GroupVersionResourceByKind(client kubernetes.Interface, kind string) (schema.GroupVersionResource, error) {
 	lists, err := client.Discovery().ServerPreferredResources()
 	if err != nil {
@@ -0,0 +1,283 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+)
+
+const (
+	defaultMaxConsecutiveConnectionFailures = 10
+
+	connectionRetryInitialDelay = 500 * time.Millisecond
+	connectionRetryMaxDelay     = 30 * time.Second
+)
+
+// ConnectionRetry controls the reconnections of the tracked resource informer to the API server.
+type ConnectionRetry struct {
+	// MaxConsecutiveFailures of the list and watch requests after which the tracker returns *ConnectionLostError,
+	// 0 means 10 failures, negative value means no limit.
+	MaxConsecutiveFailures int
+	// Observer is notified about failures and reconnections, optional.
+	Observer ConnectionObserver
+}
+
+// ConnectionStats of the tracked resource informer.
+type ConnectionStats struct {
+	// Reconnects is the number of the watches dropped before their timeout, which were opened again.
+	Reconnects int
+	// ConsecutiveFailures of the list and watch requests since the last successful one.
+	ConsecutiveFailures int
+	// LastError is the error of the last failed request or of the last dropped watch.
+	LastError string
+}
+
+// ConnectionObserver receives the connection stats of the tracked resource informer on each failure, reconnection and recovery.
+type ConnectionObserver func(stats ConnectionStats)
+
+// ConnectionLostError is returned by the tracker when the list and watch requests of the resource
+// failed ConnectionRetry.MaxConsecutiveFailures times in a row.
+type ConnectionLostError struct {
+	Failures  int
+	LastError string
+}
+
+func (e *ConnectionLostError) Error() string {
+	return fmt.Sprintf("connection lost after %d consecutive list and watch failures: %s", e.Failures, e.LastError)
+}
+
+// UntilWithSync is watchtools.UntilWithSync, which informer connection is retried according to ConnectionRetry.
+// The informer lists the resource again to resync its state after a failed watch (a fresh list after 410 Gone)
+// and opens the watch from the latest resourceVersion, failed requests are retried with exponential backoff.
+func UntilWithSync(ctx context.Context, lw *cache.ListWatch, objType runtime.Object, retry ConnectionRetry, conditions ...watchtools.ConditionFunc) (*watch.Event, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	c := &connection{retry: retry, ctx: ctx, lost: cancel}
+	if c.retry.MaxConsecutiveFailures == 0 {
+		c.retry.MaxConsecutiveFailures = defaultMaxConsecutiveConnectionFailures
+	}
+
+	event, err := watchtools.UntilWithSync(ctx, &cache.ListWatch{
+		ListFunc:        c.wrapList(lw.ListFunc),
+		WatchFunc:       c.wrapWatch(lw.WatchFunc),
+		DisableChunking: lw.DisableChunking,
+	}, objType, nil, conditions...)
+
+	if lostErr := c.lostError(); lostErr != nil {
+		return event, lostErr
+	}
+
+	return event, err
+}
+
+type connection struct {
+	retry ConnectionRetry
+	ctx   context.Context
+	lost  context.CancelFunc
+
+	mux     sync.Mutex
+	stats   ConnectionStats
+	lostErr *ConnectionLostError
+}
+
+func (c *connection) wrapList(listFunc cache.ListFunc) cache.ListFunc {
+	return func(options metav1.ListOptions) (runtime.Object, error) {
+		if err := c.backoff(); err != nil {
+			return nil, err
+		}
+
+		object, err := listFunc(options)
+		if err != nil {
+			c.failed(err)
+			return object, err
+		}
+		c.succeeded()
+
+		return object, nil
+	}
+}
+
+func (c *connection) wrapWatch(watchFunc cache.WatchFunc) cache.WatchFunc {
+	return func(options metav1.ListOptions) (watch.Interface, error) {
+		if err := c.backoff(); err != nil {
+			return nil, err
+		}
+
+		w, err := watchFunc(options)
+		if err != nil {
+			c.failed(err)
+			return w, err
+		}
+		c.succeeded()
+
+		var timeout time.Duration
+		if options.TimeoutSeconds != nil {
+			timeout = time.Duration(*options.TimeoutSeconds) * time.Second
+		}
+
+		return newObservedWatch(w, c, timeout), nil
+	}
+}
+
+// backoff waits before the request retried after failures.
+func (c *connection) backoff() error {
+	c.mux.Lock()
+	failures := c.stats.ConsecutiveFailures
+	c.mux.Unlock()
+
+	if failures == 0 {
+		return nil
+	}
+
+	delay := connectionRetryInitialDelay
+	for i := 1; i < failures && delay < connectionRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > connectionRetryMaxDelay {
+		delay = connectionRetryMaxDelay
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-c.ctx.Done():
+		return c.ctx.Err()
+	}
+}
+
+func (c *connection) failed(err error) {
+	if c.ctx.Err() != nil {
+		return
+	}
+
+	c.mux.Lock()
+	c.stats.ConsecutiveFailures++
+	c.stats.LastError = err.Error()
+	stats := c.stats
+
+	if c.retry.MaxConsecutiveFailures > 0 && stats.ConsecutiveFailures >= c.retry.MaxConsecutiveFailures && c.lostErr == nil {
+		c.lostErr = &ConnectionLostError{Failures: stats.ConsecutiveFailures, LastError: stats.LastError}
+		c.lost()
+	}
+	c.mux.Unlock()
+
+	c.observe(stats)
+}
+
+func (c *connection) succeeded() {
+	c.mux.Lock()
+	if c.stats.ConsecutiveFailures == 0 {
+		c.mux.Unlock()
+		return
+	}
+	c.stats.ConsecutiveFailures = 0
+	stats := c.stats
+	c.mux.Unlock()
+
+	c.observe(stats)
+}
+
+func (c *connection) dropped(err error) {
+	if c.ctx.Err() != nil {
+		return
+	}
+
+	c.mux.Lock()
+	c.stats.Reconnects++
+	c.stats.LastError = err.Error()
+	stats := c.stats
+	c.mux.Unlock()
+
+	c.observe(stats)
+}
+
+func (c *connection) observe(stats ConnectionStats) {
+	if c.retry.Observer != nil {
+		c.retry.Observer(stats)
+	}
+}
+
+func (c *connection) lostError() error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.lostErr == nil {
+		return nil
+	}
+	return c.lostErr
+}
+
+// observedWatch proxies the events of the watch and reports the watch errors and the watch dropped before its timeout.
+type observedWatch struct {
+	watch.Interface
+
+	result   chan watch.Event
+	stopOnce sync.Once
+	stopped  chan struct{}
+}
+
+func newObservedWatch(w watch.Interface, c *connection, timeout time.Duration) *observedWatch {
+	ow := &observedWatch{
+		Interface: w,
+		result:    make(chan watch.Event),
+		stopped:   make(chan struct{}),
+	}
+
+	go func() {
+		defer close(ow.result)
+
+		openedAt := time.Now()
+		for {
+			select {
+			case event, ok := <-w.ResultChan():
+				if !ok {
+					// The server closes the watch once its timeout is over, earlier close is a dropped connection
+					if timeout <= 0 || time.Since(openedAt) < timeout-time.Second {
+						c.dropped(fmt.Errorf("watch closed after %s", time.Since(openedAt).Truncate(time.Second)))
+					}
+					return
+				}
+
+				if event.Type == watch.Error {
+					// 410 Gone is not a failure: the informer lists the resource anew
+					if err := apierrors.FromObject(event.Object); !apierrors.IsGone(err) && !apierrors.IsResourceExpired(err) {
+						c.failed(err)
+					}
+				}
+
+				select {
+				case ow.result <- event:
+				case <-ow.stopped:
+					return
+				}
+			case <-ow.stopped:
+				return
+			}
+		}
+	}()
+
+	return ow
+}
+
+func (ow *observedWatch) ResultChan() <-chan watch.Event {
+	return ow.result
+}
+
+func (ow *observedWatch) Stop() {
+	ow.stopOnce.Do(func() {
+		close(ow.stopped)
+		ow.Interface.Stop()
+	})
+}
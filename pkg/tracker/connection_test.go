@@ -0,0 +1,120 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// TestConnectionLost fails all list and watch requests after the first list:
+// the tracker returns *ConnectionLostError once MaxConsecutiveFailures is reached, whether the watch is shared or not.
+func TestConnectionLost(t *testing.T) {
+	for _, isShared := range []bool{false, true} {
+		name := "own watch"
+		if isShared {
+			name = "shared watch"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			kube := fake.NewSimpleClientset(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: testSharedNamespace}})
+
+			var lists int32
+			kube.PrependReactor("list", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+				if atomic.AddInt32(&lists, 1) == 1 {
+					return false, nil, nil
+				}
+				return true, nil, apierrors.NewServiceUnavailable("apiserver is shutting down")
+			})
+			kube.PrependWatchReactor("pods", func(k8stesting.Action) (bool, watch.Interface, error) {
+				return true, nil, apierrors.NewServiceUnavailable("apiserver is shutting down")
+			})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			var shared *SharedInformers
+			if isShared {
+				shared = NewSharedInformers(ctx, kube)
+			}
+
+			s := runTestSubscriber(ctx, shared, kube, "app", 3)
+
+			var err error
+			select {
+			case err = <-s.done:
+			case <-ctx.Done():
+				t.Fatalf("timed out waiting for the connection to be lost")
+			}
+
+			var lostErr *ConnectionLostError
+			if !errors.As(err, &lostErr) {
+				t.Fatalf("expected *ConnectionLostError, got %v", err)
+			}
+			if lostErr.Failures != 3 || lostErr.LastError != "apiserver is shutting down" {
+				t.Errorf("expected 3 failures with the last error %q, got %d failures with %q", "apiserver is shutting down", lostErr.Failures, lostErr.LastError)
+			}
+
+			for failures := 1; failures <= 3; failures++ {
+				if !s.hasStats(func(stats ConnectionStats) bool { return stats.ConsecutiveFailures == failures }) {
+					t.Errorf("expected the observer notified about %d consecutive failures, got %+v", failures, s.stats)
+				}
+			}
+		})
+	}
+}
+
+// TestConnectionReconnect closes the first watch once the Pod is received: the tracker counts the reconnection
+// and keeps tracking, whether the watch is shared or not.
+func TestConnectionReconnect(t *testing.T) {
+	for _, isShared := range []bool{false, true} {
+		name := "own watch"
+		if isShared {
+			name = "shared watch"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			kube, _, watches, firstWatcher := newCountingClientset([]string{"app"})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var shared *SharedInformers
+			if isShared {
+				shared = NewSharedInformers(ctx, kube)
+			}
+
+			s := runTestSubscriber(ctx, shared, kube, "app", 3)
+
+			select {
+			case <-s.synced:
+			case <-time.After(10 * time.Second):
+				t.Fatalf("timed out waiting for Pod app")
+			}
+			waitForTestCondition(t, "watch opened", func() bool { return atomic.LoadInt32(watches) > 0 })
+			firstWatcher.Stop()
+
+			waitForTestCondition(t, "reconnection", func() bool {
+				return s.hasStats(func(stats ConnectionStats) bool {
+					return stats.Reconnects == 1 && stats.ConsecutiveFailures == 0 && stats.LastError != ""
+				})
+			})
+			waitForTestCondition(t, "watch opened again", func() bool { return atomic.LoadInt32(watches) > 1 })
+
+			cancel()
+			if err := <-s.done; err != nil && !errors.Is(err, context.Canceled) && err != wait.ErrWaitTimeout {
+				t.Errorf("unexpected error: %s", err)
+			}
+		})
+	}
+}
@@ -6,6 +6,8 @@ type ControllerFeed interface {
 	OnAdded(func(ready bool) error)
 	OnReady(func() error)
 	OnFailed(func(reason string) error)
+	// OnDeleted is called when the tracked resource is deleted, OnStatus of the feed receives the empty status then if it is not set.
+	OnDeleted(func() error)
 	OnEventMsg(func(msg string) error) // Pulling: pull alpine:3.6....
 	OnAddedReplicaSet(func(replicaset.ReplicaSet) error)
 	OnAddedPod(func(replicaset.ReplicaSetPod) error)
@@ -17,6 +19,7 @@ type CommonControllerFeed struct {
 	OnAddedFunc           func(bool) error
 	OnReadyFunc           func() error
 	OnFailedFunc          func(reason string) error
+	OnDeletedFunc         func() error
 	OnEventMsgFunc        func(msg string) error
 	OnAddedReplicaSetFunc func(replicaset.ReplicaSet) error
 	OnAddedPodFunc        func(replicaset.ReplicaSetPod) error
@@ -33,6 +36,9 @@ func (f *CommonControllerFeed) OnReady(function func() error) {
 func (f *CommonControllerFeed) OnFailed(function func(string) error) {
 	f.OnFailedFunc = function
 }
+func (f *CommonControllerFeed) OnDeleted(function func() error) {
+	f.OnDeletedFunc = function
+}
 func (f *CommonControllerFeed) OnEventMsg(function func(string) error) {
 	f.OnEventMsgFunc = function
 }
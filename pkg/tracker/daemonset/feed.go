@@ -53,6 +53,12 @@ func (f *feed) Track(name, namespace string, kube kubernetes.Interface, opts tra
 	daemonSetTracker := NewTracker(name, namespace, kube, opts)
 
 	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				errorChan <- tracker.NewPanicError(recovered, opts.PanicsAreFatal)
+			}
+		}()
+
 		if debug.Debug() {
 			fmt.Printf("  goroutine: start DaemonSet/%s tracker\n", name)
 		}
@@ -165,6 +171,27 @@ func (f *feed) Track(name, namespace string, kube kubernetes.Interface, opts tra
 				}
 			}
 
+		case status := <-daemonSetTracker.Deleted:
+			f.setStatus(status)
+
+			if f.OnDeletedFunc != nil {
+				err := f.OnDeletedFunc()
+				if err == tracker.StopTrack {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			} else if f.OnStatusFunc != nil {
+				err := f.OnStatusFunc(status)
+				if err == tracker.StopTrack {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+
 		case status := <-daemonSetTracker.Status:
 			f.setStatus(status)
 
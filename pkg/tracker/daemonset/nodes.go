@@ -0,0 +1,84 @@
+package daemonset
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/werf/kubedog/pkg/tracker/debug"
+	"github.com/werf/kubedog/pkg/tracker/pod"
+)
+
+// nodeSkipReasonTTL is the time the state of the node is cached, nodes are requested only for not ready Pods.
+const nodeSkipReasonTTL = 30 * time.Second
+
+type cachedNodeSkipReason struct {
+	reason    string
+	fetchedAt time.Time
+}
+
+// nodesCache keeps the reasons to skip the Pods on the cordoned and NotReady nodes.
+type nodesCache struct {
+	kube    kubernetes.Interface
+	reasons map[string]cachedNodeSkipReason
+}
+
+func newNodesCache(kube kubernetes.Interface) *nodesCache {
+	return &nodesCache{kube: kube, reasons: make(map[string]cachedNodeSkipReason)}
+}
+
+// skipReason returns e.g. "node cordoned" for the node which Pods are not awaited, empty string for the healthy node.
+// Nodes which cannot be requested, e.g. without RBAC permissions to get nodes, are considered healthy.
+func (c *nodesCache) skipReason(ctx context.Context, nodeName string) string {
+	if cached, hasKey := c.reasons[nodeName]; hasKey && time.Since(cached.fetchedAt) < nodeSkipReasonTTL {
+		return cached.reason
+	}
+
+	var reason string
+	if node, err := c.kube.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{}); err != nil {
+		if debug.Debug() {
+			fmt.Fprintf(os.Stderr, "unable to get node/%s: %s\n", nodeName, err)
+		}
+	} else {
+		reason = nodeSkipReason(node)
+	}
+
+	c.reasons[nodeName] = cachedNodeSkipReason{reason: reason, fetchedAt: time.Now()}
+	return reason
+}
+
+func nodeSkipReason(node *corev1.Node) string {
+	if node.Spec.Unschedulable {
+		return "node cordoned"
+	}
+
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady && cond.Status != corev1.ConditionTrue {
+			return "node NotReady"
+		}
+	}
+
+	return ""
+}
+
+// skippedPods returns the reasons to skip the not ready Pods scheduled to the cordoned or NotReady nodes.
+func (c *nodesCache) skippedPods(ctx context.Context, podsStatuses map[string]pod.PodStatus) map[string]string {
+	res := make(map[string]string)
+
+	for podName, podStatus := range podsStatuses {
+		if podStatus.IsDeleted || podStatus.IsReady || podStatus.NodeName == "" {
+			continue
+		}
+
+		if reason := c.skipReason(ctx, podStatus.NodeName); reason != "" {
+			res[podName] = reason
+		}
+	}
+
+	return res
+}
@@ -2,10 +2,12 @@ package daemonset
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/werf/kubedog/pkg/tracker/indicators"
 	"github.com/werf/kubedog/pkg/tracker/pod"
 	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 type DaemonSetStatus struct {
@@ -19,6 +21,21 @@ type DaemonSetStatus struct {
 
 	WaitingForMessages []string
 
+	// RequiredReadyPodsMessage describes readiness of the DaemonSet
+	// which needs only some of its Pods to be ready, e.g. "ready 1/5 (required 1)"
+	RequiredReadyPodsMessage string
+
+	// MaxUnavailable is the number of the desired Pods which may be not available when the DaemonSet is ready,
+	// 0 with StrictDaemonSetAvailability.
+	MaxUnavailable int32
+	// SkippedPods are the not ready Pods on the cordoned or NotReady nodes by names, e.g. {"node-exporter-x2x8f": "node cordoned"},
+	// they are not awaited and their errors are not reported.
+	SkippedPods map[string]string
+
+	// RolloutStatusMessage is the status phrased as by kubectl rollout status,
+	// e.g. "daemon set \"node-exporter\" successfully rolled out"
+	RolloutStatusMessage string
+
 	IsReady      bool
 	IsFailed     bool
 	FailedReason string
@@ -27,7 +44,9 @@ type DaemonSetStatus struct {
 	NewPodsNames []string
 }
 
-func NewDaemonSetStatus(object *appsv1.DaemonSet, statusGeneration uint64, isTrackerFailed bool, trackerFailedReason string, podsStatuses map[string]pod.PodStatus, newPodsNames []string) DaemonSetStatus {
+// NewDaemonSetStatus computes readiness as kubectl rollout status does, but tolerates maxUnavailable of the rolling update
+// and skippedPods unless strictAvailability is set.
+func NewDaemonSetStatus(object *appsv1.DaemonSet, statusGeneration uint64, isTrackerFailed bool, trackerFailedReason string, podsStatuses map[string]pod.PodStatus, newPodsNames []string, requiredReadyPodsCount int32, skippedPods map[string]string, strictAvailability bool) DaemonSetStatus {
 	res := DaemonSetStatus{
 		StatusGeneration: statusGeneration,
 		DaemonSetStatus:  object.Status,
@@ -35,8 +54,22 @@ func NewDaemonSetStatus(object *appsv1.DaemonSet, statusGeneration uint64, isTra
 		NewPodsNames:     newPodsNames,
 	}
 
+	if !strictAvailability {
+		res.MaxUnavailable = getMaxUnavailable(object)
+		if len(skippedPods) > 0 {
+			res.SkippedPods = skippedPods
+		}
+	}
+
+	if msg, _, err := DaemonSetRolloutStatus(object); err != nil {
+		res.RolloutStatusMessage = fmt.Sprintf("error: %s", err)
+	} else {
+		res.RolloutStatusMessage = strings.TrimSuffix(msg, "\n")
+	}
+
 processingPodsStatuses:
 	for k, v := range podsStatuses {
+		v.SkippedReason = res.SkippedPods[k]
 		res.Pods[k] = v
 
 		for _, newPodName := range newPodsNames {
@@ -79,13 +112,32 @@ processingPodsStatuses:
 
 		res.IsReady = true
 
-		if object.Status.UpdatedNumberScheduled != object.Status.DesiredNumberScheduled {
+		// Skipped Pods are excluded from the desired ones, the old skipped Pods are not awaited to be updated
+		var skippedOldPodsCount int32
+		for podName := range res.SkippedPods {
+			if !isNewPod(podName, newPodsNames) {
+				skippedOldPodsCount++
+			}
+		}
+		updatedTarget := object.Status.DesiredNumberScheduled - skippedOldPodsCount
+		availableTarget := object.Status.DesiredNumberScheduled - int32(len(res.SkippedPods)) - res.MaxUnavailable
+
+		if object.Status.UpdatedNumberScheduled < updatedTarget {
 			res.IsReady = false
-			res.WaitingForMessages = append(res.WaitingForMessages, fmt.Sprintf("up-to-date %d->%d", object.Status.UpdatedNumberScheduled, object.Status.DesiredNumberScheduled))
+			res.WaitingForMessages = append(res.WaitingForMessages, fmt.Sprintf("up-to-date %d->%d", object.Status.UpdatedNumberScheduled, updatedTarget))
 		}
-		if object.Status.NumberAvailable != object.Status.DesiredNumberScheduled {
+		if object.Status.NumberAvailable < availableTarget {
 			res.IsReady = false
-			res.WaitingForMessages = append(res.WaitingForMessages, fmt.Sprintf("available %d->%d", object.Status.NumberAvailable, object.Status.DesiredNumberScheduled))
+			res.WaitingForMessages = append(res.WaitingForMessages, fmt.Sprintf("available %d->%d", object.Status.NumberAvailable, availableTarget))
+		}
+
+		if isReady, msg, isApplicable := pod.CheckRequiredReadyPods(res.Pods, newPodsNames, requiredReadyPodsCount, object.Status.DesiredNumberScheduled); isApplicable {
+			res.IsReady = isReady
+			res.RequiredReadyPodsMessage = msg
+			res.WaitingForMessages = nil
+			if !isReady {
+				res.WaitingForMessages = []string{msg}
+			}
 		}
 	} else {
 		res.WaitingForMessages = append(res.WaitingForMessages, fmt.Sprintf("observed generation %d should be >= %d", object.Status.ObservedGeneration, object.Generation))
@@ -99,6 +151,29 @@ processingPodsStatuses:
 	return res
 }
 
+// getMaxUnavailable returns maxUnavailable of the rolling update resolved against the desired Pods, 1 when not set.
+func getMaxUnavailable(object *appsv1.DaemonSet) int32 {
+	maxUnavailable := intstr.FromInt(1)
+	if object.Spec.UpdateStrategy.RollingUpdate != nil && object.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable != nil {
+		maxUnavailable = *object.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable
+	}
+
+	res, err := intstr.GetValueFromIntOrPercent(&maxUnavailable, int(object.Status.DesiredNumberScheduled), true)
+	if err != nil || res < 0 {
+		return 0
+	}
+	return int32(res)
+}
+
+func isNewPod(podName string, newPodsNames []string) bool {
+	for _, newPodName := range newPodsNames {
+		if newPodName == podName {
+			return true
+		}
+	}
+	return false
+}
+
 // Status returns a message describing daemon set status, and a bool value indicating if the status is considered done.
 func DaemonSetRolloutStatus(daemon *appsv1.DaemonSet) (string, bool, error) {
 	if daemon.Spec.UpdateStrategy.Type != appsv1.RollingUpdateDaemonSetStrategyType {
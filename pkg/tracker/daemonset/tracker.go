@@ -3,6 +3,7 @@ package daemonset
 import (
 	"context"
 	"fmt"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -12,7 +13,6 @@ import (
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
-	watchtools "k8s.io/client-go/tools/watch"
 
 	"github.com/werf/kubedog/pkg/tracker"
 	"github.com/werf/kubedog/pkg/tracker/debug"
@@ -43,20 +43,31 @@ type Tracker struct {
 	TrackedPodsNames []string
 	Conditions       []string
 
-	Added  chan DaemonSetStatus
-	Ready  chan DaemonSetStatus
-	Failed chan DaemonSetStatus
-	Status chan DaemonSetStatus
+	Added   chan DaemonSetStatus
+	Ready   chan DaemonSetStatus
+	Failed  chan DaemonSetStatus
+	Deleted chan DaemonSetStatus
+	Status  chan DaemonSetStatus
 
 	EventMsg    chan string
 	AddedPod    chan PodAddedReport
 	PodLogChunk chan *replicaset.ReplicaSetPodLogChunk
 	PodError    chan PodErrorReport
 
-	lastObject     *appsv1.DaemonSet
-	failedReason   string
-	podStatuses    map[string]pod.PodStatus
-	podGenerations map[string]string
+	lastObject                 *appsv1.DaemonSet
+	failedReason               string
+	podStatuses                map[string]pod.PodStatus
+	deletedPodsHistory         *pod.DeletedPodsHistory
+	stringsInterner            *utils.StringsInterner
+	initContainersStuckTimeout time.Duration
+	unschedulableTimeout       time.Duration
+	requiredReadyPodsCount     int32
+	listObserver               tracker.ListObserver
+	connectionRetry            tracker.ConnectionRetry
+	panicsAreFatal             bool
+	podGenerations             map[string]string
+	strictAvailability         bool
+	nodes                      *nodesCache
 
 	resourceAdded    chan *appsv1.DaemonSet
 	resourceModified chan *appsv1.DaemonSet
@@ -75,17 +86,35 @@ func NewTracker(name, namespace string, kube kubernetes.Interface, opts tracker.
 		Tracker: tracker.Tracker{
 			Kube:             kube,
 			Namespace:        namespace,
-			FullResourceName: fmt.Sprintf("ds/%s", name),
+			FullResourceName: tracker.FormatResourceID("ds", namespace, name),
 			ResourceName:     name,
 			LogsFromTime:     opts.LogsFromTime,
-		},
 
-		podStatuses:    make(map[string]pod.PodStatus),
-		podGenerations: make(map[string]string),
+			EventSeverityOverrides:  opts.EventSeverityOverrides,
+			LogsLimits:              opts.LogsLimits,
+			ContainerFailureReasons: opts.ContainerFailureReasons,
+			Suppressions:            opts.Suppressions,
+			LogStreamsBudget:        opts.LogStreamsBudget,
+			SharedInformers:         opts.SharedInformers,
+		},
 
-		Added:  make(chan DaemonSetStatus, 1),
-		Ready:  make(chan DaemonSetStatus, 0),
-		Failed: make(chan DaemonSetStatus, 0),
+		podStatuses:                make(map[string]pod.PodStatus),
+		deletedPodsHistory:         pod.NewDeletedPodsHistory(opts.DeletedPodsHistoryLimit),
+		stringsInterner:            opts.StringsInterner,
+		initContainersStuckTimeout: opts.InitContainersStuckTimeout,
+		unschedulableTimeout:       opts.UnschedulableTimeout,
+		requiredReadyPodsCount:     int32(opts.RequiredReadyPodsCount),
+		listObserver:               opts.ListObserver,
+		connectionRetry:            opts.ConnectionRetry,
+		panicsAreFatal:             opts.PanicsAreFatal,
+		podGenerations:             make(map[string]string),
+		strictAvailability:         opts.StrictDaemonSetAvailability,
+		nodes:                      newNodesCache(kube),
+
+		Added:   make(chan DaemonSetStatus, 1),
+		Ready:   make(chan DaemonSetStatus, 0),
+		Failed:  make(chan DaemonSetStatus, 0),
+		Deleted: make(chan DaemonSetStatus, 0),
 
 		EventMsg:    make(chan string, 1),
 		AddedPod:    make(chan PodAddedReport, 10),
@@ -132,9 +161,10 @@ func (d *Tracker) Track(ctx context.Context) error {
 			d.State = tracker.ResourceDeleted
 			d.lastObject = nil
 			d.TrackedPodsNames = nil
+			d.deletedPodsHistory.Reset()
 			d.podStatuses = make(map[string]pod.PodStatus)
 			d.podGenerations = make(map[string]string)
-			d.Status <- DaemonSetStatus{}
+			d.Deleted <- DaemonSetStatus{}
 
 		case reason := <-d.resourceFailed:
 			d.State = tracker.ResourceFailed
@@ -143,18 +173,20 @@ func (d *Tracker) Track(ctx context.Context) error {
 			var status DaemonSetStatus
 			if d.lastObject != nil {
 				d.StatusGeneration++
-				status = NewDaemonSetStatus(d.lastObject, d.StatusGeneration, (d.State == tracker.ResourceFailed), d.failedReason, d.podStatuses, d.getNewPodsNames())
+				status = d.newStatus(ctx, d.lastObject)
 			} else {
 				status = DaemonSetStatus{IsFailed: true, FailedReason: reason}
 			}
 			d.Failed <- status
 
 		case pod := <-d.podAddedRelay:
+			d.deletedPodsHistory.Forget(pod.Name)
+
 			d.podGenerations[pod.Name] = pod.Labels["pod-template-generation"]
 
 			if d.lastObject != nil {
 				d.StatusGeneration++
-				status := NewDaemonSetStatus(d.lastObject, d.StatusGeneration, (d.State == tracker.ResourceFailed), d.failedReason, d.podStatuses, d.getNewPodsNames())
+				status := d.newStatus(ctx, d.lastObject)
 				d.AddedPod <- PodAddedReport{
 					Pod: replicaset.ReplicaSetPod{
 						Name:       pod.Name,
@@ -178,10 +210,14 @@ func (d *Tracker) Track(ctx context.Context) error {
 					if name == donePodName {
 						// This Pod is no more tracked,
 						// but we need to update final
-						// Pod's status
-						if _, hasKey := d.podStatuses[name]; hasKey {
+						// Pod's status, the Pod deleted before
+						// its status is relayed is shown as well
+						if _, hasKey := d.podStatuses[name]; hasKey || status.IsDeleted {
 							d.podStatuses[name] = status
 						}
+						if status.IsDeleted {
+							d.deletedPodsHistory.Add(name)
+						}
 						continue trackedPodsIteration
 					}
 				}
@@ -190,15 +226,20 @@ func (d *Tracker) Track(ctx context.Context) error {
 			}
 			d.TrackedPodsNames = trackedPodsNames
 
+			// Deleted Pods are pruned after the status with them as terminated has been reported
 			if d.lastObject != nil {
 				if err := d.handleDaemonSetState(ctx, d.lastObject); err != nil {
 					return err
 				}
 			}
 
+			d.deletedPodsHistory.Prune(d.podStatuses)
+
 		case podStatuses := <-d.podStatusesRelay:
 			for podName, podStatus := range podStatuses {
-				d.podStatuses[podName] = podStatus
+				if pod.IsTrackedPod(d.TrackedPodsNames, podName) {
+					d.podStatuses[podName] = podStatus
+				}
 			}
 			if d.lastObject != nil {
 				if err := d.handleDaemonSetState(ctx, d.lastObject); err != nil {
@@ -208,13 +249,19 @@ func (d *Tracker) Track(ctx context.Context) error {
 
 		case podContainerErrors := <-d.podContainerErrorsRelay:
 			for podName, containerError := range podContainerErrors {
-				d.podStatuses[podName] = containerError.PodStatus
+				if pod.IsTrackedPod(d.TrackedPodsNames, podName) {
+					d.podStatuses[podName] = containerError.PodStatus
+				}
 			}
 			if d.lastObject != nil {
 				d.StatusGeneration++
-				status := NewDaemonSetStatus(d.lastObject, d.StatusGeneration, (d.State == tracker.ResourceFailed), d.failedReason, d.podStatuses, d.getNewPodsNames())
+				status := d.newStatus(ctx, d.lastObject)
 
 				for podName, containerError := range podContainerErrors {
+					if _, isSkipped := status.SkippedPods[podName]; isSkipped {
+						continue
+					}
+
 					d.PodError <- PodErrorReport{
 						PodError: replicaset.ReplicaSetPodError{
 							PodError: pod.PodError{
@@ -239,6 +286,17 @@ func (d *Tracker) Track(ctx context.Context) error {
 	}
 }
 
+// newStatus returns the status of the object, the Pods on the cordoned or NotReady nodes are skipped
+// unless StrictDaemonSetAvailability is set.
+func (d *Tracker) newStatus(ctx context.Context, object *appsv1.DaemonSet) DaemonSetStatus {
+	var skippedPods map[string]string
+	if !d.strictAvailability {
+		skippedPods = d.nodes.skippedPods(ctx, d.podStatuses)
+	}
+
+	return NewDaemonSetStatus(object, d.StatusGeneration, (d.State == tracker.ResourceFailed), d.failedReason, d.podStatuses, d.getNewPodsNames(), d.requiredReadyPodsCount, skippedPods, d.strictAvailability)
+}
+
 func (d *Tracker) getNewPodsNames() []string {
 	res := []string{}
 
@@ -263,17 +321,18 @@ func (d *Tracker) runDaemonSetInformer(ctx context.Context) {
 		options.FieldSelector = fields.OneTermEqualSelector("metadata.name", d.ResourceName).String()
 		return options
 	}
-	lw := &cache.ListWatch{
+	lw := d.SharedInformers.ListWatch(d.Namespace, &appsv1.DaemonSet{}, tweakListOptions, &cache.ListWatch{
 		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
 			return client.AppsV1().DaemonSets(d.Namespace).List(ctx, tweakListOptions(options))
 		},
 		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
 			return client.AppsV1().DaemonSets(d.Namespace).Watch(ctx, tweakListOptions(options))
 		},
-	}
+	})
+	lw.ListFunc = tracker.ObserveList(lw.ListFunc, d.listObserver)
 
 	go func() {
-		_, err := watchtools.UntilWithSync(ctx, lw, &appsv1.DaemonSet{}, nil, func(e watch.Event) (bool, error) {
+		_, err := tracker.UntilWithSync(ctx, lw, &appsv1.DaemonSet{}, d.connectionRetry, func(e watch.Event) (bool, error) {
 			if debug.Debug() {
 				fmt.Printf("    Daemonset/%s event: %#v\n", d.ResourceName, e.Type)
 			}
@@ -305,7 +364,10 @@ func (d *Tracker) runDaemonSetInformer(ctx context.Context) {
 		})
 
 		if err := tracker.AdaptInformerError(err); err != nil {
-			d.errors <- err
+			select {
+			case d.errors <- err:
+			case <-ctx.Done():
+			}
 		}
 
 		if debug.Debug() {
@@ -332,9 +394,24 @@ func (d *Tracker) runPodTracker(ctx context.Context, podName string) error {
 	if !d.LogsFromTime.IsZero() {
 		podTracker.LogsFromTime = d.LogsFromTime
 	}
+	podTracker.StringsInterner = d.stringsInterner
+	podTracker.InitContainersStuckTimeout = d.initContainersStuckTimeout
+	podTracker.UnschedulableTimeout = d.unschedulableTimeout
+	podTracker.EventSeverityOverrides = d.EventSeverityOverrides
+	podTracker.LogsLimits = d.LogsLimits
+	podTracker.ContainerFailureReasons = d.ContainerFailureReasons
+	podTracker.Suppressions = d.Suppressions
+	podTracker.LogStreamsBudget = d.LogStreamsBudget
+	podTracker.SharedInformers = d.SharedInformers
 	d.TrackedPodsNames = append(d.TrackedPodsNames, podName)
 
 	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				errorChan <- tracker.NewPanicError(recovered, d.panicsAreFatal)
+			}
+		}()
+
 		if debug.Debug() {
 			fmt.Printf("Starting DaemonSet's `%s` Pod `%s` tracker. pod state: %v\n", d.ResourceName, podTracker.ResourceName, podTracker.State)
 		}
@@ -370,7 +447,7 @@ func (d *Tracker) runPodTracker(ctx context.Context, podName string) error {
 				d.podStatusesRelay <- map[string]pod.PodStatus{podTracker.ResourceName: status}
 
 			case msg := <-podTracker.EventMsg:
-				d.EventMsg <- fmt.Sprintf("po/%s %s", podTracker.ResourceName, msg)
+				d.EventMsg <- fmt.Sprintf("%s %s", tracker.FormatResourceID("po", podTracker.Namespace, podTracker.ResourceName), msg)
 			case chunk := <-podTracker.ContainerLogChunk:
 				rsChunk := &replicaset.ReplicaSetPodLogChunk{
 					PodLogChunk: &pod.PodLogChunk{
@@ -401,7 +478,7 @@ func (d *Tracker) handleDaemonSetState(ctx context.Context, object *appsv1.Daemo
 	d.lastObject = object
 	d.StatusGeneration++
 
-	status := NewDaemonSetStatus(object, d.StatusGeneration, (d.State == tracker.ResourceFailed), d.failedReason, d.podStatuses, d.getNewPodsNames())
+	status := d.newStatus(ctx, object)
 
 	switch d.State {
 	case tracker.Initial:
@@ -428,7 +505,7 @@ func (d *Tracker) handleDaemonSetState(ctx context.Context, object *appsv1.Daemo
 		} else {
 			d.Status <- status
 		}
-	case tracker.ResourceSucceeded:
+	case tracker.ResourceReady, tracker.ResourceSucceeded:
 		d.Status <- status
 	case tracker.ResourceDeleted:
 		if status.IsFailed {
@@ -17,6 +17,8 @@ type Feed interface {
 	controller.ControllerFeed
 
 	OnStatus(func(DeploymentStatus) error)
+	// OnScaleEvents receives the whole scale timeline of the Deployment each time it changes.
+	OnScaleEvents(func([]ScaleEvent) error)
 
 	GetStatus() DeploymentStatus
 	Track(name, namespace string, kube kubernetes.Interface, opts tracker.Options) error
@@ -29,7 +31,8 @@ func NewFeed() Feed {
 type feed struct {
 	controller.CommonControllerFeed
 
-	OnStatusFunc func(DeploymentStatus) error
+	OnStatusFunc      func(DeploymentStatus) error
+	OnScaleEventsFunc func([]ScaleEvent) error
 
 	statusMux sync.Mutex
 	status    DeploymentStatus
@@ -39,6 +42,10 @@ func (f *feed) OnStatus(function func(DeploymentStatus) error) {
 	f.OnStatusFunc = function
 }
 
+func (f *feed) OnScaleEvents(function func([]ScaleEvent) error) {
+	f.OnScaleEventsFunc = function
+}
+
 func (f *feed) Track(name, namespace string, kube kubernetes.Interface, opts tracker.Options) error {
 	errorChan := make(chan error, 0)
 	doneChan := make(chan bool, 0)
@@ -53,6 +60,12 @@ func (f *feed) Track(name, namespace string, kube kubernetes.Interface, opts tra
 	deploymentTracker := NewTracker(name, namespace, kube, opts)
 
 	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				errorChan <- tracker.NewPanicError(recovered, opts.PanicsAreFatal)
+			}
+		}()
+
 		if debug.Debug() {
 			fmt.Printf("  goroutine: start deploy/%s tracker\n", name)
 		}
@@ -177,6 +190,27 @@ func (f *feed) Track(name, namespace string, kube kubernetes.Interface, opts tra
 				}
 			}
 
+		case status := <-deploymentTracker.Deleted:
+			f.setStatus(status)
+
+			if f.OnDeletedFunc != nil {
+				err := f.OnDeletedFunc()
+				if err == tracker.StopTrack {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			} else if f.OnStatusFunc != nil {
+				err := f.OnStatusFunc(status)
+				if err == tracker.StopTrack {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+
 		case status := <-deploymentTracker.Status:
 			f.setStatus(status)
 
@@ -190,6 +224,17 @@ func (f *feed) Track(name, namespace string, kube kubernetes.Interface, opts tra
 				}
 			}
 
+		case events := <-deploymentTracker.ScaleEvents:
+			if f.OnScaleEventsFunc != nil {
+				err := f.OnScaleEventsFunc(events)
+				if err == tracker.StopTrack {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+
 		case err := <-errorChan:
 			return err
 		case <-doneChan:
@@ -0,0 +1,98 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+
+	"github.com/werf/kubedog/pkg/tracker"
+	"github.com/werf/kubedog/pkg/tracker/debug"
+)
+
+// runHPAEventsInformer watches SuccessfulRescale events of HorizontalPodAutoscalers targeting the Deployment.
+// Scale timeline is optional context, so failures of the informer (e.g. no access to HorizontalPodAutoscalers) do not fail tracking.
+func (d *Tracker) runHPAEventsInformer(ctx context.Context) {
+	client := d.Kube
+
+	tweakListOptions := func(options metav1.ListOptions) metav1.ListOptions {
+		options.FieldSelector = fields.Set{
+			"involvedObject.kind": "HorizontalPodAutoscaler",
+			"reason":              "SuccessfulRescale",
+		}.String()
+		return options
+	}
+	lw := d.SharedInformers.ListWatch(d.Namespace, &corev1.Event{}, tweakListOptions, &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return client.CoreV1().Events(d.Namespace).List(ctx, tweakListOptions(options))
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return client.CoreV1().Events(d.Namespace).Watch(ctx, tweakListOptions(options))
+		},
+	})
+
+	isTargetByHPAName := make(map[string]bool)
+	isTarget := func(hpaName string) bool {
+		if res, hasKey := isTargetByHPAName[hpaName]; hasKey {
+			return res
+		}
+
+		hpa, err := client.AutoscalingV1().HorizontalPodAutoscalers(d.Namespace).Get(ctx, hpaName, metav1.GetOptions{})
+		if err != nil {
+			if debug.Debug() {
+				fmt.Printf("%s: unable to get hpa/%s: %s\n", d.FullResourceName, hpaName, err)
+			}
+			return false
+		}
+
+		res := hpa.Spec.ScaleTargetRef.Kind == "Deployment" && hpa.Spec.ScaleTargetRef.Name == d.ResourceName
+		isTargetByHPAName[hpaName] = res
+		return res
+	}
+
+	go func() {
+		_, err := watchtools.UntilWithSync(ctx, lw, &corev1.Event{}, nil, func(e watch.Event) (bool, error) {
+			if e.Type != watch.Added && e.Type != watch.Modified {
+				return false, nil
+			}
+
+			object, ok := e.Object.(*corev1.Event)
+			if !ok {
+				return true, fmt.Errorf("expected %s to be a *corev1.Event, got %T", d.ResourceName, e.Object)
+			}
+
+			// Rescales before tracking has started are not a part of the timeline
+			if hpaEventTime(object).Before(d.startedAt) || !isTarget(object.InvolvedObject.Name) {
+				return false, nil
+			}
+
+			select {
+			case d.hpaRescales <- object:
+				return false, nil
+			case <-ctx.Done():
+				return true, nil
+			}
+		})
+
+		if err := tracker.AdaptInformerError(err); err != nil && debug.Debug() {
+			fmt.Printf("%s: hpa events informer failed: %s\n", d.FullResourceName, err)
+		}
+	}()
+}
+
+func hpaEventTime(event *corev1.Event) time.Time {
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp.Time
+	}
+	if !event.EventTime.IsZero() {
+		return event.EventTime.Time
+	}
+	return event.CreationTimestamp.Time
+}
@@ -0,0 +1,136 @@
+package deployment
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// scaleCorrelationWindow is the maximum time between the change of Deployment replicas
+	// and the HorizontalPodAutoscaler event about this rescale.
+	scaleCorrelationWindow = 2 * time.Minute
+	// maxPendingScaleReasons limits HorizontalPodAutoscaler reasons kept until the change of replicas is observed.
+	maxPendingScaleReasons = 10
+)
+
+var (
+	hpaRescaleMessageRegex    = regexp.MustCompile(`^New size: (\d+); reason: (.*)$`)
+	hpaUtilizationReasonRegex = regexp.MustCompile(`^(\S+) (?:resource )?utilization .*(above|below) target$`)
+)
+
+// ScaleEvent is a change of the Deployment desired replicas, e.g. by HorizontalPodAutoscaler.
+type ScaleEvent struct {
+	Time         time.Time
+	FromReplicas int32
+	ToReplicas   int32
+	// Reason is the reason reported by HorizontalPodAutoscaler, e.g. "cpu resource utilization (percentage of request) above target",
+	// empty when the Deployment is scaled by other means.
+	Reason string
+}
+
+type scaleReason struct {
+	Time   time.Time
+	Size   int32
+	Reason string
+}
+
+// ScaleTimeline correlates the changes of the Deployment replicas with HorizontalPodAutoscaler rescale events.
+// The timeline is built from .spec.replicas of the Deployment, so the scaling of ReplicaSets during the rollout
+// (ScalingReplicaSet events of the surge) is not mistaken for the scaling of the Deployment.
+type ScaleTimeline struct {
+	replicas       *int32
+	events         []ScaleEvent
+	pendingReasons []scaleReason
+}
+
+// ObserveReplicas registers desired replicas of the Deployment, returns true when the timeline changed.
+// The first observed value is the initial one and is not an event.
+func (t *ScaleTimeline) ObserveReplicas(replicas int32, at time.Time) bool {
+	if t.replicas == nil {
+		t.replicas = &replicas
+		return false
+	}
+	if *t.replicas == replicas {
+		return false
+	}
+
+	event := ScaleEvent{Time: at, FromReplicas: *t.replicas, ToReplicas: replicas}
+	t.replicas = &replicas
+
+	for i, reason := range t.pendingReasons {
+		if reason.Size == replicas && isWithinScaleCorrelationWindow(reason.Time, at) {
+			event.Reason = reason.Reason
+			t.pendingReasons = append(t.pendingReasons[:i], t.pendingReasons[i+1:]...)
+			break
+		}
+	}
+
+	t.events = append(t.events, event)
+
+	return true
+}
+
+// ObserveHPARescale registers the message of HorizontalPodAutoscaler SuccessfulRescale event, e.g.
+// "New size: 5; reason: cpu resource utilization (percentage of request) above target", returns true when the timeline changed.
+// The reason is attached to the scale event to the same size, or kept until such event is observed.
+func (t *ScaleTimeline) ObserveHPARescale(message string, at time.Time) bool {
+	match := hpaRescaleMessageRegex.FindStringSubmatch(message)
+	if match == nil {
+		return false
+	}
+	size, err := strconv.ParseInt(match[1], 10, 32)
+	if err != nil {
+		return false
+	}
+
+	for i := len(t.events) - 1; i >= 0; i-- {
+		event := &t.events[i]
+		if event.ToReplicas == int32(size) && event.Reason == "" && isWithinScaleCorrelationWindow(event.Time, at) {
+			event.Reason = match[2]
+			return true
+		}
+	}
+
+	t.pendingReasons = append(t.pendingReasons, scaleReason{Time: at, Size: int32(size), Reason: match[2]})
+	if len(t.pendingReasons) > maxPendingScaleReasons {
+		t.pendingReasons = t.pendingReasons[1:]
+	}
+
+	return false
+}
+
+// Events returns a copy of the scale events in the order of observation.
+func (t *ScaleTimeline) Events() []ScaleEvent {
+	return append([]ScaleEvent(nil), t.events...)
+}
+
+func isWithinScaleCorrelationWindow(a, b time.Time) bool {
+	d := a.Sub(b)
+	if d < 0 {
+		d = -d
+	}
+	return d <= scaleCorrelationWindow
+}
+
+// FormatScaleEvents renders the scale events in one line, e.g. "12:01 scaled 3→5 (cpu above target), 12:07 scaled 5→8".
+func FormatScaleEvents(events []ScaleEvent) string {
+	var parts []string
+	for _, event := range events {
+		part := fmt.Sprintf("%s scaled %d→%d", event.Time.Local().Format("15:04"), event.FromReplicas, event.ToReplicas)
+		if event.Reason != "" {
+			part = fmt.Sprintf("%s (%s)", part, shortScaleReason(event.Reason))
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func shortScaleReason(reason string) string {
+	if match := hpaUtilizationReasonRegex.FindStringSubmatch(reason); match != nil {
+		return fmt.Sprintf("%s %s target", match[1], match[2])
+	}
+	return reason
+}
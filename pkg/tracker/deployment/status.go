@@ -2,12 +2,14 @@ package deployment
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/werf/kubedog/pkg/tracker/indicators"
 	"github.com/werf/kubedog/pkg/tracker/pod"
 	"github.com/werf/kubedog/pkg/utils"
 
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 )
 
 type DeploymentStatus struct {
@@ -21,9 +23,20 @@ type DeploymentStatus struct {
 
 	WaitingForMessages []string
 
+	// RequiredReadyPodsMessage describes readiness of the Deployment
+	// which needs only some of its Pods to be ready, e.g. "ready 1/5 (required 1)"
+	RequiredReadyPodsMessage string
+
+	// RolloutStatusMessage is the status phrased as by kubectl rollout status,
+	// e.g. "Waiting for deployment \"api\" rollout to finish: 1 of 3 updated replicas are available..."
+	RolloutStatusMessage string
+
 	IsReady      bool
 	IsFailed     bool
 	FailedReason string
+	// IsProgressDeadlineExceeded is set while the Deployment has Progressing=False condition with ProgressDeadlineExceeded reason,
+	// the message of the condition is the FailedReason.
+	IsProgressDeadlineExceeded bool
 
 	Pods map[string]pod.PodStatus
 	// New Pod belongs to the new ReplicaSet of the Deployment,
@@ -31,7 +44,7 @@ type DeploymentStatus struct {
 	NewPodsNames []string
 }
 
-func NewDeploymentStatus(object *appsv1.Deployment, statusGeneration uint64, isTrackerFailed bool, trackerFailedReason string, podsStatuses map[string]pod.PodStatus, newPodsNames []string) DeploymentStatus {
+func NewDeploymentStatus(object *appsv1.Deployment, statusGeneration uint64, isTrackerFailed bool, trackerFailedReason string, podsStatuses map[string]pod.PodStatus, newPodsNames []string, requiredReadyPodsCount int32) DeploymentStatus {
 	res := DeploymentStatus{
 		StatusGeneration: statusGeneration,
 		DeploymentStatus: object.Status,
@@ -39,6 +52,12 @@ func NewDeploymentStatus(object *appsv1.Deployment, statusGeneration uint64, isT
 		NewPodsNames:     newPodsNames,
 	}
 
+	if msg, _, err := DeploymentRolloutStatus(object, 0); err != nil {
+		res.RolloutStatusMessage = fmt.Sprintf("error: %s", err)
+	} else {
+		res.RolloutStatusMessage = strings.TrimSuffix(msg, "\n")
+	}
+
 processingPodsStatuses:
 	for k, v := range podsStatuses {
 		res.Pods[k] = v
@@ -79,6 +98,8 @@ processingPodsStatuses:
 			TargetValue: *object.Spec.Replicas,
 		}
 
+		// Mirrors kubectl rollout status: the current generation is observed, all replicas are updated, ready and available,
+		// so the ready Pods of the previous revision do not make the Deployment ready
 		res.IsReady = true
 		if object.Status.UpdatedReplicas != *object.Spec.Replicas {
 			res.IsReady = false
@@ -92,10 +113,29 @@ processingPodsStatuses:
 			res.IsReady = false
 			res.WaitingForMessages = append(res.WaitingForMessages, fmt.Sprintf("available %d->%d", object.Status.AvailableReplicas, *object.Spec.Replicas))
 		}
+		if object.Status.ReadyReplicas != *object.Spec.Replicas {
+			res.IsReady = false
+			res.WaitingForMessages = append(res.WaitingForMessages, fmt.Sprintf("ready %d->%d", object.Status.ReadyReplicas, *object.Spec.Replicas))
+		}
+
+		if isReady, msg, isApplicable := pod.CheckRequiredReadyPods(res.Pods, newPodsNames, requiredReadyPodsCount, *object.Spec.Replicas); isApplicable {
+			res.IsReady = isReady
+			res.RequiredReadyPodsMessage = msg
+			res.WaitingForMessages = nil
+			if !isReady {
+				res.WaitingForMessages = []string{msg}
+			}
+		}
 	} else {
 		res.WaitingForMessages = append(res.WaitingForMessages, fmt.Sprintf("observed generation %d should be >= %d", object.Status.ObservedGeneration, object.Generation))
 	}
 
+	if cond := progressDeadlineExceededCondition(object); cond != nil && !res.IsReady {
+		res.IsFailed = true
+		res.IsProgressDeadlineExceeded = true
+		res.FailedReason = fmt.Sprintf("%s: %s", cond.Reason, cond.Message)
+	}
+
 	if !res.IsReady && !res.IsFailed {
 		res.IsFailed = isTrackerFailed
 		res.FailedReason = trackerFailedReason
@@ -104,6 +144,20 @@ processingPodsStatuses:
 	return res
 }
 
+// progressDeadlineExceededCondition returns Progressing condition of the Deployment when the rollout of the current generation
+// has not progressed during progressDeadlineSeconds, the message of the condition names the stuck ReplicaSet.
+func progressDeadlineExceededCondition(object *appsv1.Deployment) *appsv1.DeploymentCondition {
+	if object.Status.ObservedGeneration < object.Generation {
+		return nil
+	}
+
+	cond := utils.GetDeploymentCondition(object.Status, appsv1.DeploymentProgressing)
+	if cond == nil || cond.Status != corev1.ConditionFalse || cond.Reason != utils.TimedOutReason {
+		return nil
+	}
+	return cond
+}
+
 // Status returns a message describing deployment status, and a bool value indicating if the status is considered done.
 func DeploymentRolloutStatus(deployment *appsv1.Deployment, revision int64) (string, bool, error) {
 	if revision > 0 {
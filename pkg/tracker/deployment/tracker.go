@@ -3,6 +3,8 @@ package deployment
 import (
 	"context"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/werf/kubedog/pkg/tracker"
 	"github.com/werf/kubedog/pkg/tracker/debug"
@@ -19,7 +21,6 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	watchtools "k8s.io/client-go/tools/watch"
 )
 
 type ReplicaSetAddedReport struct {
@@ -44,24 +45,43 @@ type Tracker struct {
 	Conditions        []string
 	NewReplicaSetName string
 
-	knownReplicaSets map[string]*appsv1.ReplicaSet
-	lastObject       *appsv1.Deployment
-	failedReason     string
-	podStatuses      map[string]pod.PodStatus
-	rsNameByPod      map[string]string
+	knownReplicaSets           map[string]*appsv1.ReplicaSet
+	lastObject                 *appsv1.Deployment
+	failedReason               string
+	podStatuses                map[string]pod.PodStatus
+	deletedPodsHistory         *pod.DeletedPodsHistory
+	stringsInterner            *utils.StringsInterner
+	initContainersStuckTimeout time.Duration
+	unschedulableTimeout       time.Duration
+	requiredReadyPodsCount     int32
+	listObserver               tracker.ListObserver
+	connectionRetry            tracker.ConnectionRetry
+	panicsAreFatal             bool
+	rsNameByPod                map[string]string
+	scaleTimeline              ScaleTimeline
+	startedAt                  time.Time
+	// progressDeadlineReported is set once ProgressDeadlineExceeded is reported as the failure,
+	// so that it is reported again only after the rollout progresses and stalls again.
+	progressDeadlineReported bool
+	// pendingPods are not tracked until they are known to belong to the new ReplicaSet of the current revision,
+	// the Pods of the previous revisions stay pending.
+	pendingPods map[string]*corev1.Pod
 
 	TrackedPodsNames []string
 
-	Added  chan DeploymentStatus
-	Ready  chan DeploymentStatus
-	Failed chan DeploymentStatus
-	Status chan DeploymentStatus
+	Added   chan DeploymentStatus
+	Ready   chan DeploymentStatus
+	Failed  chan DeploymentStatus
+	Deleted chan DeploymentStatus
+	Status  chan DeploymentStatus
 
 	EventMsg        chan string
 	AddedReplicaSet chan ReplicaSetAddedReport
 	AddedPod        chan PodAddedReport
 	PodLogChunk     chan *replicaset.ReplicaSetPodLogChunk
 	PodError        chan PodErrorReport
+	// ScaleEvents receives the whole scale timeline each time it changes.
+	ScaleEvents chan []ScaleEvent
 
 	resourceAdded      chan *appsv1.Deployment
 	resourceModified   chan *appsv1.Deployment
@@ -70,9 +90,11 @@ type Tracker struct {
 	replicaSetAdded    chan *appsv1.ReplicaSet
 	replicaSetModified chan *appsv1.ReplicaSet
 	replicaSetDeleted  chan *appsv1.ReplicaSet
+	hpaRescales        chan *corev1.Event
 	errors             chan error
 
 	podAddedRelay           chan *corev1.Pod
+	podDeletedRelay         chan *corev1.Pod
 	podStatusesRelay        chan map[string]pod.PodStatus
 	podLogChunksRelay       chan map[string]*pod.ContainerLogChunk
 	podContainerErrorsRelay chan map[string]pod.ContainerErrorReport
@@ -84,25 +106,44 @@ func NewTracker(name, namespace string, kube kubernetes.Interface, opts tracker.
 		Tracker: tracker.Tracker{
 			Kube:             kube,
 			Namespace:        namespace,
-			FullResourceName: fmt.Sprintf("deploy/%s", name),
+			FullResourceName: tracker.FormatResourceID("deploy", namespace, name),
 			ResourceName:     name,
 			LogsFromTime:     opts.LogsFromTime,
+
+			EventSeverityOverrides:  opts.EventSeverityOverrides,
+			LogsLimits:              opts.LogsLimits,
+			ContainerFailureReasons: opts.ContainerFailureReasons,
+			Suppressions:            opts.Suppressions,
+			LogStreamsBudget:        opts.LogStreamsBudget,
+			SharedInformers:         opts.SharedInformers,
 		},
 
-		Added:  make(chan DeploymentStatus, 1),
-		Ready:  make(chan DeploymentStatus, 0),
-		Failed: make(chan DeploymentStatus, 0),
-		Status: make(chan DeploymentStatus, 100),
+		Added:   make(chan DeploymentStatus, 1),
+		Ready:   make(chan DeploymentStatus, 0),
+		Failed:  make(chan DeploymentStatus, 0),
+		Deleted: make(chan DeploymentStatus, 0),
+		Status:  make(chan DeploymentStatus, 100),
 
 		EventMsg:        make(chan string, 1),
 		AddedReplicaSet: make(chan ReplicaSetAddedReport, 10),
 		AddedPod:        make(chan PodAddedReport, 10),
 		PodLogChunk:     make(chan *replicaset.ReplicaSetPodLogChunk, 1000),
 		PodError:        make(chan PodErrorReport, 0),
-
-		knownReplicaSets: make(map[string]*appsv1.ReplicaSet),
-		podStatuses:      make(map[string]pod.PodStatus),
-		rsNameByPod:      make(map[string]string),
+		ScaleEvents:     make(chan []ScaleEvent, 10),
+
+		knownReplicaSets:           make(map[string]*appsv1.ReplicaSet),
+		podStatuses:                make(map[string]pod.PodStatus),
+		deletedPodsHistory:         pod.NewDeletedPodsHistory(opts.DeletedPodsHistoryLimit),
+		stringsInterner:            opts.StringsInterner,
+		initContainersStuckTimeout: opts.InitContainersStuckTimeout,
+		unschedulableTimeout:       opts.UnschedulableTimeout,
+		requiredReadyPodsCount:     int32(opts.RequiredReadyPodsCount),
+		listObserver:               opts.ListObserver,
+		connectionRetry:            opts.ConnectionRetry,
+		panicsAreFatal:             opts.PanicsAreFatal,
+		rsNameByPod:                make(map[string]string),
+		pendingPods:                make(map[string]*corev1.Pod),
+		startedAt:                  time.Now(),
 
 		errors:             make(chan error, 0),
 		resourceAdded:      make(chan *appsv1.Deployment, 1),
@@ -112,8 +153,10 @@ func NewTracker(name, namespace string, kube kubernetes.Interface, opts tracker.
 		replicaSetAdded:    make(chan *appsv1.ReplicaSet, 1),
 		replicaSetModified: make(chan *appsv1.ReplicaSet, 1),
 		replicaSetDeleted:  make(chan *appsv1.ReplicaSet, 1),
+		hpaRescales:        make(chan *corev1.Event, 1),
 
 		podAddedRelay:           make(chan *corev1.Pod, 1),
+		podDeletedRelay:         make(chan *corev1.Pod, 0),
 		podStatusesRelay:        make(chan map[string]pod.PodStatus, 10),
 		podLogChunksRelay:       make(chan map[string]*pod.ContainerLogChunk, 10),
 		podContainerErrorsRelay: make(chan map[string]pod.ContainerErrorReport, 10),
@@ -149,8 +192,10 @@ func (d *Tracker) Track(ctx context.Context) (err error) {
 			d.knownReplicaSets = make(map[string]*appsv1.ReplicaSet)
 			d.podStatuses = make(map[string]pod.PodStatus)
 			d.rsNameByPod = make(map[string]string)
+			d.pendingPods = make(map[string]*corev1.Pod)
 			d.TrackedPodsNames = nil
-			d.Status <- DeploymentStatus{}
+			d.deletedPodsHistory.Reset()
+			d.Deleted <- DeploymentStatus{}
 
 		case reason := <-d.resourceFailed:
 			d.State = tracker.ResourceFailed
@@ -163,7 +208,7 @@ func (d *Tracker) Track(ctx context.Context) (err error) {
 				if err != nil {
 					return err
 				}
-				status = NewDeploymentStatus(d.lastObject, d.StatusGeneration, (d.State == tracker.ResourceFailed), d.failedReason, d.podStatuses, newPodsNames)
+				status = NewDeploymentStatus(d.lastObject, d.StatusGeneration, (d.State == tracker.ResourceFailed), d.failedReason, d.podStatuses, newPodsNames, d.requiredReadyPodsCount)
 			} else {
 				status = DeploymentStatus{IsFailed: true, FailedReason: reason}
 			}
@@ -183,7 +228,7 @@ func (d *Tracker) Track(ctx context.Context) (err error) {
 				if err != nil {
 					return err
 				}
-				status := NewDeploymentStatus(d.lastObject, d.StatusGeneration, (d.State == tracker.ResourceFailed), d.failedReason, d.podStatuses, newPodsNames)
+				status := NewDeploymentStatus(d.lastObject, d.StatusGeneration, (d.State == tracker.ResourceFailed), d.failedReason, d.podStatuses, newPodsNames, d.requiredReadyPodsCount)
 
 				d.AddedReplicaSet <- ReplicaSetAddedReport{
 					ReplicaSet: replicaset.ReplicaSet{
@@ -192,6 +237,10 @@ func (d *Tracker) Track(ctx context.Context) (err error) {
 					},
 					DeploymentStatus: status,
 				}
+
+				if err := d.trackNewPods(ctx); err != nil {
+					return err
+				}
 			}
 
 		case rs := <-d.replicaSetModified:
@@ -200,36 +249,27 @@ func (d *Tracker) Track(ctx context.Context) (err error) {
 		case rs := <-d.replicaSetDeleted:
 			delete(d.knownReplicaSets, rs.Name)
 
+		case ev := <-d.hpaRescales:
+			if d.scaleTimeline.ObserveHPARescale(ev.Message, hpaEventTime(ev)) {
+				d.ScaleEvents <- d.scaleTimeline.Events()
+			}
+
 		case pod := <-d.podAddedRelay:
-			rsName := utils.GetPodReplicaSetName(pod)
-			d.rsNameByPod[pod.Name] = rsName
+			d.deletedPodsHistory.Forget(pod.Name)
 
-			if d.lastObject != nil {
-				d.StatusGeneration++
-				newPodsNames, err := d.getNewPodsNames()
-				if err != nil {
-					return err
-				}
-				rsNew, err := utils.IsReplicaSetNew(d.lastObject, d.knownReplicaSets, rsName)
-				if err != nil {
-					return err
-				}
-				status := NewDeploymentStatus(d.lastObject, d.StatusGeneration, (d.State == tracker.ResourceFailed), d.failedReason, d.podStatuses, newPodsNames)
+			d.rsNameByPod[pod.Name] = utils.GetPodReplicaSetName(pod)
+			d.pendingPods[pod.Name] = pod
 
-				d.AddedPod <- PodAddedReport{
-					ReplicaSetPod: replicaset.ReplicaSetPod{
-						Name: pod.Name,
-						ReplicaSet: replicaset.ReplicaSet{
-							Name:  rsName,
-							IsNew: rsNew,
-						},
-					},
-					DeploymentStatus: status,
-				}
+			if err := d.trackNewPods(ctx); err != nil {
+				return err
 			}
 
-			if err := d.runPodTracker(ctx, pod.Name, rsName); err != nil {
-				return err
+		case pod := <-d.podDeletedRelay:
+			// Tracked Pods are forgotten by the deleted Pods history when their trackers are done,
+			// pending Pods have no trackers and would stay in the maps until the Deployment is deleted
+			if _, isPending := d.pendingPods[pod.Name]; isPending {
+				delete(d.pendingPods, pod.Name)
+				delete(d.rsNameByPod, pod.Name)
 			}
 
 		case donePods := <-d.donePodsRelay:
@@ -241,10 +281,14 @@ func (d *Tracker) Track(ctx context.Context) (err error) {
 					if name == donePodName {
 						// This Pod is no more tracked,
 						// but we need to update final
-						// Pod's status
-						if _, hasKey := d.podStatuses[name]; hasKey {
+						// Pod's status, the Pod deleted before
+						// its status is relayed is shown as well
+						if _, hasKey := d.podStatuses[name]; hasKey || status.IsDeleted {
 							d.podStatuses[name] = status
 						}
+						if status.IsDeleted {
+							d.deletedPodsHistory.Add(name)
+						}
 						continue trackedPodsIteration
 					}
 				}
@@ -253,15 +297,22 @@ func (d *Tracker) Track(ctx context.Context) (err error) {
 			}
 			d.TrackedPodsNames = trackedPodsNames
 
+			// Deleted Pods are pruned after the status with them as terminated has been reported
 			if d.lastObject != nil {
 				if err := d.handleDeploymentState(ctx, d.lastObject); err != nil {
 					return err
 				}
 			}
 
+			for _, prunedPodName := range d.deletedPodsHistory.Prune(d.podStatuses) {
+				delete(d.rsNameByPod, prunedPodName)
+			}
+
 		case podStatuses := <-d.podStatusesRelay:
 			for podName, podStatus := range podStatuses {
-				d.podStatuses[podName] = podStatus
+				if pod.IsTrackedPod(d.TrackedPodsNames, podName) {
+					d.podStatuses[podName] = podStatus
+				}
 			}
 			if d.lastObject != nil {
 				if err := d.handleDeploymentState(ctx, d.lastObject); err != nil {
@@ -298,7 +349,9 @@ func (d *Tracker) Track(ctx context.Context) (err error) {
 
 		case podContainerErrors := <-d.podContainerErrorsRelay:
 			for podName, containerError := range podContainerErrors {
-				d.podStatuses[podName] = containerError.PodStatus
+				if pod.IsTrackedPod(d.TrackedPodsNames, podName) {
+					d.podStatuses[podName] = containerError.PodStatus
+				}
 			}
 			if d.lastObject != nil {
 				d.StatusGeneration++
@@ -306,7 +359,7 @@ func (d *Tracker) Track(ctx context.Context) (err error) {
 				if err != nil {
 					return err
 				}
-				status := NewDeploymentStatus(d.lastObject, d.StatusGeneration, (d.State == tracker.ResourceFailed), d.failedReason, d.podStatuses, newPodsNames)
+				status := NewDeploymentStatus(d.lastObject, d.StatusGeneration, (d.State == tracker.ResourceFailed), d.failedReason, d.podStatuses, newPodsNames, d.requiredReadyPodsCount)
 
 				for podName, containerError := range podContainerErrors {
 					rsName, hasKey := d.rsNameByPod[podName]
@@ -344,8 +397,60 @@ func (d *Tracker) Track(ctx context.Context) (err error) {
 			return err
 		}
 	}
+}
+
+// trackNewPods starts tracking of the pending Pods of the new ReplicaSet (with the same pod-template-hash), so that
+// the Pods of the previous revisions are not mixed into the statuses and logs. The Pods, which ReplicaSet or revision
+// is not observed yet, are checked again when the Deployment or its ReplicaSets change.
+func (d *Tracker) trackNewPods(ctx context.Context) error {
+	if d.lastObject == nil || len(d.pendingPods) == 0 {
+		return nil
+	}
+
+	var rsList []*appsv1.ReplicaSet
+	for _, rs := range d.knownReplicaSets {
+		rsList = append(rsList, rs)
+	}
+	newRs, err := utils.FindNewReplicaSet(d.lastObject, rsList)
+	if err != nil || newRs == nil {
+		return err
+	}
+
+	var podsNames []string
+	for podName, pod := range d.pendingPods {
+		if utils.IsPodOfReplicaSet(pod, newRs) {
+			podsNames = append(podsNames, podName)
+		}
+	}
+	sort.Strings(podsNames)
+
+	for _, podName := range podsNames {
+		delete(d.pendingPods, podName)
+
+		d.StatusGeneration++
+		newPodsNames, err := d.getNewPodsNames()
+		if err != nil {
+			return err
+		}
+		status := NewDeploymentStatus(d.lastObject, d.StatusGeneration, (d.State == tracker.ResourceFailed), d.failedReason, d.podStatuses, newPodsNames, d.requiredReadyPodsCount)
+
+		d.AddedPod <- PodAddedReport{
+			ReplicaSetPod: replicaset.ReplicaSetPod{
+				Name: podName,
+				ReplicaSet: replicaset.ReplicaSet{
+					Name:  newRs.Name,
+					IsNew: true,
+				},
+			},
+			DeploymentStatus: status,
+		}
+
+		if err := d.runPodTracker(ctx, podName, newRs.Name); err != nil {
+			return err
+		}
+	}
 
-	return err
+	return nil
 }
 
 func (d *Tracker) getNewPodsNames() ([]string, error) {
@@ -376,17 +481,18 @@ func (d *Tracker) runDeploymentInformer(ctx context.Context) {
 		options.FieldSelector = fields.OneTermEqualSelector("metadata.name", d.ResourceName).String()
 		return options
 	}
-	lw := &cache.ListWatch{
+	lw := d.SharedInformers.ListWatch(d.Namespace, &appsv1.Deployment{}, tweakListOptions, &cache.ListWatch{
 		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
 			return client.AppsV1().Deployments(d.Namespace).List(ctx, tweakListOptions(options))
 		},
 		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
 			return client.AppsV1().Deployments(d.Namespace).Watch(ctx, tweakListOptions(options))
 		},
-	}
+	})
+	lw.ListFunc = tracker.ObserveList(lw.ListFunc, d.listObserver)
 
 	go func() {
-		_, err := watchtools.UntilWithSync(ctx, lw, &appsv1.Deployment{}, nil, func(e watch.Event) (bool, error) {
+		_, err := tracker.UntilWithSync(ctx, lw, &appsv1.Deployment{}, d.connectionRetry, func(e watch.Event) (bool, error) {
 			if debug.Debug() {
 				fmt.Printf("    deploy/%s event: %#v\n", d.ResourceName, e.Type)
 			}
@@ -418,7 +524,10 @@ func (d *Tracker) runDeploymentInformer(ctx context.Context) {
 		})
 
 		if err := tracker.AdaptInformerError(err); err != nil {
-			d.errors <- err
+			select {
+			case d.errors <- err:
+			case <-ctx.Done():
+			}
 		}
 
 		if debug.Debug() {
@@ -439,7 +548,7 @@ func (d *Tracker) runReplicaSetsInformer(ctx context.Context, object *appsv1.Dep
 // runDeploymentInformer watch for deployment events
 func (d *Tracker) runPodsInformer(ctx context.Context, object *appsv1.Deployment) {
 	podsInformer := pod.NewPodsInformer(&d.Tracker, utils.ControllerAccessor(object))
-	podsInformer.WithChannels(d.podAddedRelay, d.errors)
+	podsInformer.WithChannels(d.podAddedRelay, d.errors).WithDeletedChannel(d.podDeletedRelay)
 	podsInformer.Run(ctx)
 }
 
@@ -452,9 +561,24 @@ func (d *Tracker) runPodTracker(_ctx context.Context, podName, rsName string) er
 	if !d.LogsFromTime.IsZero() {
 		podTracker.LogsFromTime = d.LogsFromTime
 	}
+	podTracker.StringsInterner = d.stringsInterner
+	podTracker.InitContainersStuckTimeout = d.initContainersStuckTimeout
+	podTracker.UnschedulableTimeout = d.unschedulableTimeout
+	podTracker.EventSeverityOverrides = d.EventSeverityOverrides
+	podTracker.LogsLimits = d.LogsLimits
+	podTracker.ContainerFailureReasons = d.ContainerFailureReasons
+	podTracker.Suppressions = d.Suppressions
+	podTracker.LogStreamsBudget = d.LogStreamsBudget
+	podTracker.SharedInformers = d.SharedInformers
 	d.TrackedPodsNames = append(d.TrackedPodsNames, podName)
 
 	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				errorChan <- tracker.NewPanicError(recovered, d.panicsAreFatal)
+			}
+		}()
+
 		if debug.Debug() {
 			fmt.Printf("Starting Deployment's `%s` Pod `%s` tracker. pod state: %v\n", d.ResourceName, podTracker.ResourceName, podTracker.State)
 		}
@@ -490,7 +614,7 @@ func (d *Tracker) runPodTracker(_ctx context.Context, podName, rsName string) er
 				d.podStatusesRelay <- map[string]pod.PodStatus{podTracker.ResourceName: status}
 
 			case msg := <-podTracker.EventMsg:
-				d.EventMsg <- fmt.Sprintf("po/%s %s", podTracker.ResourceName, msg)
+				d.EventMsg <- fmt.Sprintf("%s %s", tracker.FormatResourceID("po", podTracker.Namespace, podTracker.ResourceName), msg)
 			case chunk := <-podTracker.ContainerLogChunk:
 				d.podLogChunksRelay <- map[string]*pod.ContainerLogChunk{podTracker.ResourceName: chunk}
 			case report := <-podTracker.ContainerError:
@@ -509,23 +633,67 @@ func (d *Tracker) runPodTracker(_ctx context.Context, podName, rsName string) er
 	return nil
 }
 
+// suppressDeploymentConditions drops the conditions of the suppressed types from the copy of the Deployment,
+// e.g. so that suppressed Progressing condition does not fail the rollout.
+func suppressDeploymentConditions(object *appsv1.Deployment, suppressions *tracker.Suppressions) *appsv1.Deployment {
+	if !suppressions.HasConditionTypes() {
+		return object
+	}
+
+	res := *object
+	res.Status.Conditions = nil
+	for _, cond := range object.Status.Conditions {
+		if !suppressions.IsConditionSuppressed(string(cond.Type)) {
+			res.Status.Conditions = append(res.Status.Conditions, cond)
+		}
+	}
+
+	return &res
+}
+
 func (d *Tracker) handleDeploymentState(ctx context.Context, object *appsv1.Deployment) error {
+	object = suppressDeploymentConditions(object, d.Suppressions)
 	d.lastObject = object
+
+	if err := d.trackNewPods(ctx); err != nil {
+		return err
+	}
+
 	d.StatusGeneration++
 
+	if object.Spec.Replicas != nil && d.scaleTimeline.ObserveReplicas(*object.Spec.Replicas, time.Now()) {
+		d.ScaleEvents <- d.scaleTimeline.Events()
+	}
+
+	if d.progressDeadlineReported && progressDeadlineExceededCondition(object) == nil {
+		// Progressing condition is True again, e.g. after kubectl rollout restart, so the rollout is not failed anymore
+		d.progressDeadlineReported = false
+		if d.State == tracker.ResourceFailed && d.failedReason == "" {
+			d.State = tracker.ResourceAdded
+		}
+	}
+
 	newPodsNames, err := d.getNewPodsNames()
 	if err != nil {
 		return err
 	}
-	status := NewDeploymentStatus(object, d.StatusGeneration, (d.State == tracker.ResourceFailed), d.failedReason, d.podStatuses, newPodsNames)
+	status := NewDeploymentStatus(object, d.StatusGeneration, (d.State == tracker.ResourceFailed), d.failedReason, d.podStatuses, newPodsNames, d.requiredReadyPodsCount)
+
+	// The exceeded progress deadline is reported once, not on every status update while the condition persists
+	isFailed := status.IsFailed
+	if status.IsProgressDeadlineExceeded {
+		isFailed = !d.progressDeadlineReported || d.failedReason != ""
+		d.progressDeadlineReported = true
+	}
 
 	switch d.State {
 	case tracker.Initial:
 		d.runPodsInformer(ctx, object)
 		d.runReplicaSetsInformer(ctx, object)
 		d.runEventsInformer(ctx, object)
+		d.runHPAEventsInformer(ctx)
 
-		if status.IsFailed {
+		if isFailed {
 			d.State = tracker.ResourceFailed
 			d.Failed <- status
 		} else if status.IsReady {
@@ -536,7 +704,7 @@ func (d *Tracker) handleDeploymentState(ctx context.Context, object *appsv1.Depl
 			d.Added <- status
 		}
 	case tracker.ResourceAdded, tracker.ResourceFailed:
-		if status.IsFailed {
+		if isFailed {
 			d.State = tracker.ResourceFailed
 			d.Failed <- status
 		} else if status.IsReady {
@@ -545,10 +713,10 @@ func (d *Tracker) handleDeploymentState(ctx context.Context, object *appsv1.Depl
 		} else {
 			d.Status <- status
 		}
-	case tracker.ResourceSucceeded:
+	case tracker.ResourceReady, tracker.ResourceSucceeded:
 		d.Status <- status
 	case tracker.ResourceDeleted:
-		if status.IsFailed {
+		if isFailed {
 			d.State = tracker.ResourceFailed
 			d.Failed <- status
 		} else if status.IsReady {
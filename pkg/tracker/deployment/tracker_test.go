@@ -0,0 +1,254 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/werf/kubedog/pkg/tracker"
+	"github.com/werf/kubedog/pkg/tracker/pod"
+)
+
+const testNamespace = "default"
+
+func TestTrackerForgetsDeletedPendingPods(t *testing.T) {
+	kube := fake.NewSimpleClientset(
+		newTestDeployment("app", "v2"),
+		newTestReplicaSet("app-old", "old", "v1"),
+		newTestReplicaSet("app-new", "new", "v2"),
+		newTestPod("app-old-1", "app-old", "old"),
+		newTestPod("app-new-1", "app-new", "new"),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	d := NewTracker("app", testNamespace, kube, tracker.Options{})
+	trackDone := make(chan error, 1)
+	go func() { trackDone <- d.Track(ctx) }()
+	reports := drainTracker(ctx, d)
+
+	// The Pod of the previous revision is added before app-new-1, so it is pending once app-new-1 is reported
+	reports.waitAddedPod(t, "app-new-1")
+
+	if err := kube.CoreV1().Pods(testNamespace).Delete(ctx, "app-old-1", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	// The deletion is relayed before the following Pod is added
+	if _, err := kube.CoreV1().Pods(testNamespace).Create(ctx, newTestPod("app-new-2", "app-new", "new"), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	reports.waitAddedPod(t, "app-new-2")
+
+	cancel()
+	if err := <-trackDone; err != nil {
+		t.Fatalf("unexpected Track error: %s", err)
+	}
+
+	if len(d.pendingPods) != 0 {
+		t.Errorf("expected no pending Pods, got %d", len(d.pendingPods))
+	}
+	if _, hasKey := d.rsNameByPod["app-old-1"]; hasKey {
+		t.Errorf("expected deleted pending Pod to be removed from rsNameByPod, got %v", d.rsNameByPod)
+	}
+	for _, podName := range []string{"app-new-1", "app-new-2"} {
+		if d.rsNameByPod[podName] != "app-new" {
+			t.Errorf("expected tracked Pod %s to belong to app-new, got %v", podName, d.rsNameByPod)
+		}
+	}
+}
+
+func TestTrackerBoundsDeletedPodsStatuses(t *testing.T) {
+	for _, tc := range []struct {
+		historyLimit int
+		retainedPods int
+	}{
+		{historyLimit: 0, retainedPods: pod.DefaultDeletedPodsHistoryLimit},
+		{historyLimit: 3, retainedPods: 3},
+		{historyLimit: -1, retainedPods: 0},
+	} {
+		kube := fake.NewSimpleClientset(newTestDeployment("app", "v2"), newTestReplicaSet("app-new", "new", "v2"))
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		d := NewTracker("app", testNamespace, kube, tracker.Options{DeletedPodsHistoryLimit: tc.historyLimit})
+		trackDone := make(chan error, 1)
+		go func() { trackDone <- d.Track(ctx) }()
+		reports := drainTracker(ctx, d)
+
+		for i := 0; i < 200; i++ {
+			podName := fmt.Sprintf("app-new-%d", i)
+			if _, err := kube.CoreV1().Pods(testNamespace).Create(ctx, newTestPod(podName, "app-new", "new"), metav1.CreateOptions{}); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			// The Pod tracker has to see the Pod before it is deleted, otherwise it waits for the Pod to be created
+			reports.waitReportedPod(t, podName)
+
+			if err := kube.CoreV1().Pods(testNamespace).Delete(ctx, podName, metav1.DeleteOptions{}); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			// Each deleted Pod is reported as terminated before it is pruned, even when no deleted Pods are retained
+			reports.waitTerminatedPod(t, podName)
+		}
+
+		cancel()
+		if err := <-trackDone; err != nil {
+			t.Fatalf("history limit %d: unexpected Track error: %s", tc.historyLimit, err)
+		}
+
+		// The last deleted Pod may still be tracked, its tracker is done after the Pod is reported as terminated
+		maxPods := tc.retainedPods + 1
+		if len(d.podStatuses) > maxPods {
+			t.Errorf("history limit %d: expected at most %d Pods statuses, got %d", tc.historyLimit, maxPods, len(d.podStatuses))
+		}
+		if len(d.rsNameByPod) > maxPods {
+			t.Errorf("history limit %d: expected at most %d Pods ReplicaSets, got %d", tc.historyLimit, maxPods, len(d.rsNameByPod))
+		}
+		if reports.maxPods > maxPods {
+			t.Errorf("history limit %d: expected at most %d Pods in the reported statuses, got %d", tc.historyLimit, maxPods, reports.maxPods)
+		}
+	}
+}
+
+// trackerReports are collected from the report channels of the tracker by drainTracker.
+type trackerReports struct {
+	mux            sync.Mutex
+	addedPods      map[string]bool
+	reportedPods   map[string]bool
+	terminatedPods map[string]bool
+	// maxPods is the maximum number of the Pods in the reported statuses
+	maxPods int
+}
+
+// drainTracker reads all report channels of the tracker until ctx is done.
+func drainTracker(ctx context.Context, d *Tracker) *trackerReports {
+	reports := &trackerReports{addedPods: make(map[string]bool), reportedPods: make(map[string]bool), terminatedPods: make(map[string]bool)}
+
+	observeStatus := func(status DeploymentStatus) {
+		reports.mux.Lock()
+		defer reports.mux.Unlock()
+
+		if len(status.Pods) > reports.maxPods {
+			reports.maxPods = len(status.Pods)
+		}
+		for podName, podStatus := range status.Pods {
+			reports.reportedPods[podName] = true
+			if podStatus.IsDeleted {
+				reports.terminatedPods[podName] = true
+			}
+		}
+	}
+
+	go func() {
+		for {
+			select {
+			case report := <-d.AddedPod:
+				reports.mux.Lock()
+				reports.addedPods[report.ReplicaSetPod.Name] = true
+				reports.mux.Unlock()
+			case status := <-d.Added:
+				observeStatus(status)
+			case status := <-d.Ready:
+				observeStatus(status)
+			case status := <-d.Failed:
+				observeStatus(status)
+			case status := <-d.Status:
+				observeStatus(status)
+			case <-d.Deleted:
+			case <-d.EventMsg:
+			case <-d.AddedReplicaSet:
+			case <-d.PodLogChunk:
+			case <-d.PodError:
+			case <-d.ScaleEvents:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return reports
+}
+
+func (r *trackerReports) wait(t *testing.T, desc string, isDone func() bool) {
+	t.Helper()
+
+	timeout := time.After(10 * time.Second)
+	for {
+		r.mux.Lock()
+		done := isDone()
+		r.mux.Unlock()
+		if done {
+			return
+		}
+
+		select {
+		case <-time.After(time.Millisecond):
+		case <-timeout:
+			t.Fatalf("timeout waiting for %s", desc)
+		}
+	}
+}
+
+func (r *trackerReports) waitAddedPod(t *testing.T, podName string) {
+	t.Helper()
+	r.wait(t, fmt.Sprintf("Pod %s to be added", podName), func() bool { return r.addedPods[podName] })
+}
+
+func (r *trackerReports) waitReportedPod(t *testing.T, podName string) {
+	t.Helper()
+	r.wait(t, fmt.Sprintf("Pod %s to be reported", podName), func() bool { return r.reportedPods[podName] })
+}
+
+func (r *trackerReports) waitTerminatedPod(t *testing.T, podName string) {
+	t.Helper()
+	r.wait(t, fmt.Sprintf("Pod %s to be reported as terminated", podName), func() bool { return r.terminatedPods[podName] })
+}
+
+func newTestDeployment(name, image string) *appsv1.Deployment {
+	replicas := int32(2)
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": name}},
+			Template: newTestPodTemplate(map[string]string{"app": name}, image),
+		},
+	}
+}
+
+func newTestReplicaSet(name, hash, image string) *appsv1.ReplicaSet {
+	labels := map[string]string{"app": "app", appsv1.DefaultDeploymentUniqueLabelKey: hash}
+	return &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace, Labels: labels},
+		Spec: appsv1.ReplicaSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: newTestPodTemplate(labels, image),
+		},
+	}
+}
+
+func newTestPodTemplate(labels map[string]string, image string) corev1.PodTemplateSpec {
+	return corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: labels},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "main", Image: image}}},
+	}
+}
+
+func newTestPod(name, rsName, hash string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       testNamespace,
+			Labels:          map[string]string{"app": "app", appsv1.DefaultDeploymentUniqueLabelKey: hash},
+			OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: rsName}},
+		},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "main", Image: "v2"}}},
+	}
+}
@@ -3,7 +3,6 @@ package event
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -39,6 +38,10 @@ func NewEventInformer(trk *tracker.Tracker, resource interface{}) *EventInformer
 			Kube:             trk.Kube,
 			Namespace:        trk.Namespace,
 			FullResourceName: trk.FullResourceName,
+
+			EventSeverityOverrides: trk.EventSeverityOverrides,
+			Suppressions:           trk.Suppressions,
+			SharedInformers:        trk.SharedInformers,
 		},
 		Resource:         resource,
 		Errors:           make(chan error, 0),
@@ -64,14 +67,14 @@ func (e *EventInformer) Run(ctx context.Context) {
 		return options
 	}
 
-	lwe := &cache.ListWatch{
+	lwe := e.SharedInformers.ListWatch(e.Namespace, &corev1.Event{}, tweakEventListOptions, &cache.ListWatch{
 		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
 			return client.CoreV1().Events(e.Namespace).List(ctx, tweakEventListOptions(options))
 		},
 		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
 			return client.CoreV1().Events(e.Namespace).Watch(ctx, tweakEventListOptions(options))
 		},
-	}
+	})
 
 	go func() {
 		if debug.Debug() {
@@ -144,7 +147,7 @@ func (e *EventInformer) handleInitialEvents(ctx context.Context) {
 	}
 }
 
-// handleEvent sends a message to Messages channel for all events and a message to Failures channel for Failed events
+// handleEvent sends a message to Messages channel for all events and a message to Failures channel for the events of Failure severity
 func (e *EventInformer) handleEvent(event *corev1.Event) {
 	uid := event.UID
 
@@ -158,13 +161,20 @@ func (e *EventInformer) handleEvent(event *corev1.Event) {
 
 	reason := event.Reason
 
+	if e.Suppressions.IsEventSuppressed(reason) {
+		if debug.Debug() {
+			fmt.Printf("  %s SUPPRESSED event: %s %s\n", e.FullResourceName, event.Reason, event.Message)
+		}
+		return
+	}
+
 	if debug.Debug() {
 		fmt.Printf("  %s got normal event: %s %s\n", e.FullResourceName, event.Reason, event.Message)
 	}
 
 	e.Messages <- fmt.Sprintf("%s: %s", reason, event.Message)
 
-	if strings.Contains(reason, "Failed") {
+	if ReasonSeverity(reason, e.EventSeverityOverrides) == tracker.EventSeverityFailure {
 		if debug.Debug() {
 			fmt.Printf("got FAILED EVENT!!! %s %s\n", event.Reason, event.Message)
 		}
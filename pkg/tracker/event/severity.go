@@ -0,0 +1,89 @@
+package event
+
+import (
+	"strings"
+
+	"github.com/werf/kubedog/pkg/tracker"
+)
+
+// KnownReasons are the reasons of the events of Pods and controllers kubedog tracks,
+// the keys of severity overrides are expected to be among them.
+var KnownReasons = []string{
+	// Pods
+	"Scheduled",
+	"FailedScheduling",
+	"Preempted",
+	"Pulling",
+	"Pulled",
+	"Failed",
+	"InspectFailed",
+	"ErrImageNeverPull",
+	"BackOff",
+	"Created",
+	"Started",
+	"Killing",
+	"Unhealthy",
+	"ProbeWarning",
+	"FailedMount",
+	"FailedAttachVolume",
+	"FailedCreatePodSandBox",
+	"FailedKillPod",
+	"FailedSync",
+	"FailedPostStartHook",
+	"FailedPreStopHook",
+	"FailedValidation",
+	"NetworkNotReady",
+	"DNSConfigForming",
+	"Evicted",
+	"OOMKilling",
+
+	// Controllers
+	"SuccessfulCreate",
+	"SuccessfulDelete",
+	"FailedCreate",
+	"FailedDelete",
+	"ScalingReplicaSet",
+	"SuccessfulRescale",
+	"FailedGetResourceMetric",
+	"FailedComputeMetricsReplicas",
+	"DeadlineExceeded",
+	"BackoffLimitExceeded",
+	"Completed",
+	"SawCompletedJob",
+	"RecreatingFailedPod",
+	"FailedBinding",
+}
+
+// IsKnownReason returns true when the reason is among KnownReasons.
+func IsKnownReason(reason string) bool {
+	for _, knownReason := range KnownReasons {
+		if reason == knownReason {
+			return true
+		}
+	}
+	return false
+}
+
+// builtinSeverities are the built-in severities of the reasons, which are not derived from the reason.
+// FailedScheduling is handled as the failure by the Pod tracker once the Pod stays unschedulable during UnschedulableTimeout.
+var builtinSeverities = map[string]tracker.EventSeverity{
+	"FailedScheduling": tracker.EventSeverityWarning,
+}
+
+// ReasonSeverity returns the severity of the events with the reason: the override when there is one,
+// otherwise the built-in severity, which is failure for the reasons containing "Failed" apart from builtinSeverities.
+func ReasonSeverity(reason string, overrides map[string]tracker.EventSeverity) tracker.EventSeverity {
+	if severity, hasKey := overrides[reason]; hasKey {
+		return severity
+	}
+
+	if severity, hasKey := builtinSeverities[reason]; hasKey {
+		return severity
+	}
+
+	if strings.Contains(reason, "Failed") {
+		return tracker.EventSeverityFailure
+	}
+
+	return tracker.EventSeverityIgnore
+}
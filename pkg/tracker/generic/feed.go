@@ -0,0 +1,203 @@
+package generic
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/werf/kubedog/pkg/tracker"
+	"github.com/werf/kubedog/pkg/tracker/debug"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	watchtools "k8s.io/client-go/tools/watch"
+)
+
+type Feed interface {
+	OnAdded(func(ready bool) error)
+	OnReady(func() error)
+	OnFailed(func(reason string) error)
+	OnEventMsg(func(msg string) error)
+	OnDeleted(func() error)
+	OnStatus(func(GenericStatus) error)
+
+	GetStatus() GenericStatus
+	Track(name, namespace string, gvr schema.GroupVersionResource, conditions Conditions, kube kubernetes.Interface, dynamicClient dynamic.Interface, opts tracker.Options) error
+}
+
+func NewFeed() Feed {
+	return &feed{}
+}
+
+type feed struct {
+	OnAddedFunc    func(bool) error
+	OnReadyFunc    func() error
+	OnFailedFunc   func(reason string) error
+	OnEventMsgFunc func(msg string) error
+	OnDeletedFunc  func() error
+	OnStatusFunc   func(GenericStatus) error
+
+	statusMux sync.Mutex
+	status    GenericStatus
+}
+
+func (f *feed) OnAdded(function func(bool) error) {
+	f.OnAddedFunc = function
+}
+func (f *feed) OnReady(function func() error) {
+	f.OnReadyFunc = function
+}
+func (f *feed) OnFailed(function func(string) error) {
+	f.OnFailedFunc = function
+}
+func (f *feed) OnEventMsg(function func(string) error) {
+	f.OnEventMsgFunc = function
+}
+func (f *feed) OnDeleted(function func() error) {
+	f.OnDeletedFunc = function
+}
+func (f *feed) OnStatus(function func(GenericStatus) error) {
+	f.OnStatusFunc = function
+}
+
+func (f *feed) Track(name, namespace string, gvr schema.GroupVersionResource, conditions Conditions, kube kubernetes.Interface, dynamicClient dynamic.Interface, opts tracker.Options) error {
+	errorChan := make(chan error, 0)
+	doneChan := make(chan bool, 0)
+
+	parentContext := opts.ParentContext
+	if parentContext == nil {
+		parentContext = context.Background()
+	}
+	ctx, cancel := watchtools.ContextWithOptionalTimeout(parentContext, opts.Timeout)
+	defer cancel()
+
+	genericTracker := NewTracker(name, namespace, gvr, conditions, kube, dynamicClient, opts)
+
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				errorChan <- tracker.NewPanicError(recovered, opts.PanicsAreFatal)
+			}
+		}()
+
+		if debug.Debug() {
+			fmt.Printf("  goroutine: start %s tracker\n", genericTracker.FullResourceName)
+		}
+		err := genericTracker.Track(ctx)
+		if err != nil {
+			errorChan <- err
+		} else {
+			doneChan <- true
+		}
+	}()
+
+	if debug.Debug() {
+		fmt.Printf("  %s: for-select GenericTracker channels\n", genericTracker.FullResourceName)
+	}
+
+	for {
+		select {
+		case status := <-genericTracker.Added:
+			f.setStatus(status)
+
+			if f.OnAddedFunc != nil {
+				err := f.OnAddedFunc(status.IsReady)
+				if err == tracker.StopTrack {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+		case status := <-genericTracker.Ready:
+			f.setStatus(status)
+
+			if f.OnReadyFunc != nil {
+				err := f.OnReadyFunc()
+				if err == tracker.StopTrack {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+		case status := <-genericTracker.Failed:
+			f.setStatus(status)
+
+			if f.OnFailedFunc != nil {
+				err := f.OnFailedFunc(status.FailedReason)
+				if err == tracker.StopTrack {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+		case msg := <-genericTracker.EventMsg:
+			if f.OnEventMsgFunc != nil {
+				err := f.OnEventMsgFunc(msg)
+				if err == tracker.StopTrack {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+		case status := <-genericTracker.Deleted:
+			f.setStatus(status)
+
+			if f.OnDeletedFunc != nil {
+				err := f.OnDeletedFunc()
+				if err == tracker.StopTrack {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			} else if f.OnStatusFunc != nil {
+				err := f.OnStatusFunc(status)
+				if err == tracker.StopTrack {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+		case status := <-genericTracker.Status:
+			f.setStatus(status)
+
+			if f.OnStatusFunc != nil {
+				err := f.OnStatusFunc(status)
+				if err == tracker.StopTrack {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+		case err := <-errorChan:
+			return err
+		case <-doneChan:
+			return nil
+		}
+	}
+}
+
+func (f *feed) setStatus(status GenericStatus) {
+	f.statusMux.Lock()
+	defer f.statusMux.Unlock()
+	f.status = status
+}
+
+func (f *feed) GetStatus() GenericStatus {
+	f.statusMux.Lock()
+	defer f.statusMux.Unlock()
+	return f.status
+}
@@ -0,0 +1,123 @@
+package generic
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/werf/kubedog/pkg/tracker"
+)
+
+// ConditionMatch matches the condition of the resource status by its type and status, e.g. Ready=True.
+type ConditionMatch struct {
+	Type   string
+	Status string
+}
+
+func (m ConditionMatch) String() string {
+	return fmt.Sprintf("%s=%s", m.Type, m.Status)
+}
+
+// Conditions define which status conditions of the resource mean readiness and failure.
+type Conditions struct {
+	Ready ConditionMatch
+	// Failed is optional, the resource does not fail by its conditions when not set.
+	Failed *ConditionMatch
+}
+
+// Condition is the condition from status.conditions of the resource.
+type Condition struct {
+	Type    string
+	Status  string
+	Reason  string
+	Message string
+}
+
+type GenericStatus struct {
+	StatusGeneration uint64
+
+	Conditions []Condition
+	// ReadyCondition is the condition matched against Conditions.Ready, nil when the resource has no such condition.
+	ReadyCondition *Condition
+
+	WaitingForMessages []string
+
+	IsReady      bool
+	IsFailed     bool
+	FailedReason string
+}
+
+// NewGenericStatus returns the status of the resource, the conditions of the types suppressed by suppressions (optional) are dropped.
+func NewGenericStatus(object *unstructured.Unstructured, conditions Conditions, statusGeneration uint64, suppressions *tracker.Suppressions) GenericStatus {
+	res := GenericStatus{
+		StatusGeneration: statusGeneration,
+		Conditions:       parseConditions(object, suppressions),
+	}
+
+	for i := range res.Conditions {
+		if res.Conditions[i].Type == conditions.Ready.Type {
+			res.ReadyCondition = &res.Conditions[i]
+		}
+	}
+
+	observedGeneration, found, _ := unstructured.NestedInt64(object.Object, "status", "observedGeneration")
+	if found && observedGeneration < object.GetGeneration() {
+		res.WaitingForMessages = append(res.WaitingForMessages, fmt.Sprintf("observed generation %d should be >= %d", observedGeneration, object.GetGeneration()))
+	} else {
+		if conditions.Failed != nil {
+			for _, cond := range res.Conditions {
+				if cond.Type == conditions.Failed.Type && cond.Status == conditions.Failed.Status {
+					res.IsFailed = true
+					res.FailedReason = formatConditionFailedReason(cond)
+				}
+			}
+		}
+
+		if !res.IsFailed {
+			if res.ReadyCondition != nil && res.ReadyCondition.Status == conditions.Ready.Status {
+				res.IsReady = true
+			} else {
+				res.WaitingForMessages = append(res.WaitingForMessages, fmt.Sprintf("condition %s", conditions.Ready))
+			}
+		}
+	}
+
+	return res
+}
+
+func parseConditions(object *unstructured.Unstructured, suppressions *tracker.Suppressions) []Condition {
+	items, _, _ := unstructured.NestedSlice(object.Object, "status", "conditions")
+
+	var res []Condition
+	for _, item := range items {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		cond := Condition{}
+		cond.Type, _, _ = unstructured.NestedString(fields, "type")
+		cond.Status, _, _ = unstructured.NestedString(fields, "status")
+		cond.Reason, _, _ = unstructured.NestedString(fields, "reason")
+		cond.Message, _, _ = unstructured.NestedString(fields, "message")
+
+		if cond.Type == "" || suppressions.IsConditionSuppressed(cond.Type) {
+			continue
+		}
+
+		res = append(res, cond)
+	}
+
+	return res
+}
+
+func formatConditionFailedReason(cond Condition) string {
+	res := fmt.Sprintf("condition %s=%s", cond.Type, cond.Status)
+	if cond.Reason != "" {
+		res += fmt.Sprintf(": %s", cond.Reason)
+	}
+	if cond.Message != "" {
+		res += fmt.Sprintf(": %s", cond.Message)
+	}
+	return res
+}
@@ -0,0 +1,322 @@
+package generic
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/werf/kubedog/pkg/tracker"
+	"github.com/werf/kubedog/pkg/tracker/debug"
+	"github.com/werf/kubedog/pkg/tracker/event"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CRDRemovedError is returned by the tracker when the resource type, e.g. the custom resource of the uninstalled operator,
+// is not served by the API server anymore after it has been listed.
+type CRDRemovedError struct {
+	GroupVersionResource schema.GroupVersionResource
+}
+
+// CRDName returns the name of the CustomResourceDefinition, e.g. "certificates.cert-manager.io".
+func (e *CRDRemovedError) CRDName() string {
+	return e.GroupVersionResource.GroupResource().String()
+}
+
+func (e *CRDRemovedError) Error() string {
+	return fmt.Sprintf("CRD %s was removed from the cluster during tracking", e.CRDName())
+}
+
+// Tracker tracks the resource of arbitrary kind, e.g. the custom resource of an operator, by its status conditions.
+type Tracker struct {
+	tracker.Tracker
+
+	State tracker.TrackerState
+
+	GroupVersionResource schema.GroupVersionResource
+	Conditions           Conditions
+	DynamicClient        dynamic.Interface
+
+	listObserver    tracker.ListObserver
+	connectionRetry tracker.ConnectionRetry
+
+	Added   chan GenericStatus
+	Ready   chan GenericStatus
+	Failed  chan GenericStatus
+	Deleted chan GenericStatus
+	Status  chan GenericStatus
+
+	EventMsg chan string
+
+	resourceAdded    chan *unstructured.Unstructured
+	resourceModified chan *unstructured.Unstructured
+	resourceDeleted  chan *unstructured.Unstructured
+	eventFailures    chan string
+	errors           chan error
+}
+
+// NewTracker returns the tracker of the resource, kube is used to watch the resource events and may be nil.
+// Namespace is empty for the cluster-scoped resources.
+func NewTracker(name, namespace string, gvr schema.GroupVersionResource, conditions Conditions, kube kubernetes.Interface, dynamicClient dynamic.Interface, opts tracker.Options) *Tracker {
+	return &Tracker{
+		Tracker: tracker.Tracker{
+			Kube:             kube,
+			Namespace:        namespace,
+			FullResourceName: fmt.Sprintf("%s/%s", gvr.Resource, name),
+			ResourceName:     name,
+			LogsFromTime:     opts.LogsFromTime,
+
+			EventSeverityOverrides: opts.EventSeverityOverrides,
+			Suppressions:           opts.Suppressions,
+			SharedInformers:        opts.SharedInformers,
+		},
+
+		GroupVersionResource: gvr,
+		Conditions:           conditions,
+		DynamicClient:        dynamicClient,
+
+		listObserver:    opts.ListObserver,
+		connectionRetry: opts.ConnectionRetry,
+
+		Added:   make(chan GenericStatus, 1),
+		Ready:   make(chan GenericStatus, 0),
+		Failed:  make(chan GenericStatus, 0),
+		Deleted: make(chan GenericStatus, 0),
+		Status:  make(chan GenericStatus, 100),
+
+		EventMsg: make(chan string, 1),
+
+		resourceAdded:    make(chan *unstructured.Unstructured, 1),
+		resourceModified: make(chan *unstructured.Unstructured, 1),
+		resourceDeleted:  make(chan *unstructured.Unstructured, 1),
+		eventFailures:    make(chan string, 1),
+		errors:           make(chan error, 0),
+	}
+}
+
+// Track starts tracking of the resource with name g.ResourceName within the namespace g.Namespace.
+// Tracker waits for the resource creation and reports its status conditions evaluated against g.Conditions.
+func (g *Tracker) Track(ctx context.Context) error {
+	g.runInformer(ctx)
+
+	for {
+		select {
+		case object := <-g.resourceAdded:
+			g.handleState(ctx, object)
+
+		case object := <-g.resourceModified:
+			g.handleState(ctx, object)
+
+		case <-g.resourceDeleted:
+			g.State = tracker.ResourceDeleted
+			g.Deleted <- GenericStatus{}
+
+		case <-g.eventFailures:
+			// Failures of the resource are determined only by its conditions,
+			// "Failed" events of operators are often transient and retried
+
+		case <-ctx.Done():
+			if ctx.Err() == context.Canceled {
+				return nil
+			}
+			return ctx.Err()
+		case err := <-g.errors:
+			return err
+		}
+	}
+}
+
+// runInformer watch for the resource events
+func (g *Tracker) runInformer(ctx context.Context) {
+	client := g.DynamicClient.Resource(g.GroupVersionResource).Namespace(g.Namespace)
+
+	informerCtx, cancelInformer := context.WithCancel(ctx)
+	removedChecker := &crdRemovedChecker{tracker: g, cancel: cancelInformer}
+
+	tweakListOptions := func(options metav1.ListOptions) metav1.ListOptions {
+		options.FieldSelector = fields.OneTermEqualSelector("metadata.name", g.ResourceName).String()
+		return options
+	}
+	lw := &cache.ListWatch{
+		ListFunc: tracker.ObserveList(func(options metav1.ListOptions) (runtime.Object, error) {
+			object, err := client.List(ctx, tweakListOptions(options))
+			removedChecker.observeList(err)
+			return object, err
+		}, g.listObserver),
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return client.Watch(ctx, tweakListOptions(options))
+		},
+	}
+
+	go func() {
+		defer cancelInformer()
+
+		_, err := tracker.UntilWithSync(informerCtx, lw, &unstructured.Unstructured{}, g.connectionRetry, func(e watch.Event) (bool, error) {
+			if debug.Debug() {
+				fmt.Printf("    %s event: %#v\n", g.FullResourceName, e.Type)
+			}
+
+			var object *unstructured.Unstructured
+
+			if e.Type != watch.Error {
+				var ok bool
+				object, ok = e.Object.(*unstructured.Unstructured)
+				if !ok {
+					return true, fmt.Errorf("expected %s to be a *unstructured.Unstructured, got %T", g.FullResourceName, e.Object)
+				}
+			}
+
+			switch e.Type {
+			case watch.Added:
+				g.resourceAdded <- object
+			case watch.Modified:
+				g.resourceModified <- object
+			case watch.Deleted:
+				g.resourceDeleted <- object
+			case watch.Error:
+				return true, fmt.Errorf("%s error: %v", g.GroupVersionResource.Resource, e.Object)
+			}
+
+			return false, nil
+		})
+
+		if removedErr := removedChecker.removedError(); removedErr != nil {
+			err = removedErr
+		}
+
+		if err := tracker.AdaptInformerError(err); err != nil {
+			select {
+			case g.errors <- err:
+			case <-ctx.Done():
+			}
+		}
+
+		if debug.Debug() {
+			fmt.Printf("      %s informer DONE\n", g.FullResourceName)
+		}
+	}()
+}
+
+// crdRemovedChecker stops the informer once the list request of the resource type, which has been listed before,
+// fails with NotFound and the discovery confirms that the resource type is not served anymore.
+type crdRemovedChecker struct {
+	tracker *Tracker
+	cancel  context.CancelFunc
+
+	mux       sync.Mutex
+	hasListed bool
+	err       *CRDRemovedError
+}
+
+func (c *crdRemovedChecker) observeList(err error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if err == nil {
+		c.hasListed = true
+		return
+	}
+
+	// The resource type which has never been listed may be installed later, e.g. with the operator deployed by the same release
+	if !c.hasListed || c.err != nil || !apierrors.IsNotFound(err) {
+		return
+	}
+
+	if c.tracker.Kube != nil && isResourceServed(c.tracker.Kube.Discovery(), c.tracker.GroupVersionResource) {
+		return
+	}
+
+	c.err = &CRDRemovedError{GroupVersionResource: c.tracker.GroupVersionResource}
+	c.cancel()
+}
+
+func (c *crdRemovedChecker) removedError() error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.err == nil {
+		return nil
+	}
+	return c.err
+}
+
+// isResourceServed returns false only when the discovery does not list the resource, discovery errors are treated as served.
+func isResourceServed(discoveryClient discovery.DiscoveryInterface, gvr schema.GroupVersionResource) bool {
+	resources, err := discoveryClient.ServerResourcesForGroupVersion(gvr.GroupVersion().String())
+	if apierrors.IsNotFound(err) {
+		return false
+	} else if err != nil {
+		return true
+	}
+
+	for _, resource := range resources.APIResources {
+		if resource.Name == gvr.Resource {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Tracker) handleState(ctx context.Context, object *unstructured.Unstructured) {
+	g.StatusGeneration++
+
+	status := NewGenericStatus(object, g.Conditions, g.StatusGeneration, g.Suppressions)
+
+	switch g.State {
+	case tracker.Initial:
+		if g.Kube != nil {
+			g.runEventsInformer(ctx, object)
+		}
+
+		if status.IsFailed {
+			g.State = tracker.ResourceFailed
+			g.Failed <- status
+		} else if status.IsReady {
+			g.State = tracker.ResourceReady
+			g.Ready <- status
+		} else {
+			g.State = tracker.ResourceAdded
+			g.Added <- status
+		}
+	case tracker.ResourceAdded, tracker.ResourceFailed:
+		if status.IsFailed {
+			g.State = tracker.ResourceFailed
+			g.Failed <- status
+		} else if status.IsReady {
+			g.State = tracker.ResourceReady
+			g.Ready <- status
+		} else {
+			g.Status <- status
+		}
+	case tracker.ResourceReady:
+		g.Status <- status
+	case tracker.ResourceDeleted:
+		if status.IsFailed {
+			g.State = tracker.ResourceFailed
+			g.Failed <- status
+		} else if status.IsReady {
+			g.State = tracker.ResourceReady
+			g.Ready <- status
+		} else {
+			g.State = tracker.ResourceAdded
+			g.Added <- status
+		}
+	}
+}
+
+// runEventsInformer watch for the resource events
+func (g *Tracker) runEventsInformer(ctx context.Context, resource interface{}) {
+	eventInformer := event.NewEventInformer(&g.Tracker, resource)
+	eventInformer.WithChannels(g.EventMsg, g.eventFailures, g.errors)
+	eventInformer.Run(ctx)
+}
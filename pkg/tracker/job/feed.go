@@ -21,6 +21,7 @@ type Feed interface {
 	OnAddedPod(func(podName string) error)
 	OnPodLogChunk(func(*pod.PodLogChunk) error)
 	OnPodError(func(pod.PodError) error)
+	OnDeleted(func() error)
 	OnStatus(func(JobStatus) error)
 
 	GetStatus() JobStatus
@@ -39,6 +40,7 @@ type feed struct {
 	OnAddedPodFunc    func(string) error
 	OnPodLogChunkFunc func(*pod.PodLogChunk) error
 	OnPodErrorFunc    func(pod.PodError) error
+	OnDeletedFunc     func() error
 	OnStatusFunc      func(JobStatus) error
 
 	statusMux sync.Mutex
@@ -66,6 +68,9 @@ func (f *feed) OnPodLogChunk(function func(*pod.PodLogChunk) error) {
 func (f *feed) OnPodError(function func(pod.PodError) error) {
 	f.OnPodErrorFunc = function
 }
+func (f *feed) OnDeleted(function func() error) {
+	f.OnDeletedFunc = function
+}
 func (f *feed) OnStatus(function func(JobStatus) error) {
 	f.OnStatusFunc = function
 }
@@ -84,6 +89,12 @@ func (f *feed) Track(name, namespace string, kube kubernetes.Interface, opts tra
 	job := NewTracker(name, namespace, kube, opts)
 
 	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				errorChan <- tracker.NewPanicError(recovered, opts.PanicsAreFatal)
+			}
+		}()
+
 		err := job.Track(ctx)
 		if err != nil {
 			errorChan <- err
@@ -192,6 +203,27 @@ func (f *feed) Track(name, namespace string, kube kubernetes.Interface, opts tra
 				}
 			}
 
+		case status := <-job.Deleted:
+			f.setStatus(status)
+
+			if f.OnDeletedFunc != nil {
+				err := f.OnDeletedFunc()
+				if err == tracker.StopTrack {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			} else if f.OnStatusFunc != nil {
+				err := f.OnStatusFunc(status)
+				if err == tracker.StopTrack {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+
 		case status := <-job.Status:
 			f.setStatus(status)
 
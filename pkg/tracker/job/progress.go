@@ -0,0 +1,149 @@
+package job
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	"github.com/werf/kubedog/pkg/tracker/pod"
+)
+
+// completionIndexAnnotation is set by the Job controller on the Pods of Indexed Job.
+const completionIndexAnnotation = "batch.kubernetes.io/job-completion-index"
+
+// JobProgress is the progress of the Job towards spec.completions.
+type JobProgress struct {
+	Succeeded   int32
+	Completions int32
+	// EstimatedRemaining is extrapolated from the rate of the succeeded completions since the Job started, 0 when unknown.
+	EstimatedRemaining time.Duration
+	// FailedIndexes are the completion indexes of Indexed Job which Pods failed and which have not succeeded since.
+	FailedIndexes []int
+}
+
+// newJobProgress returns nil for the Job without completions or with a single completion.
+func newJobProgress(object *batchv1.Job, now time.Time) *JobProgress {
+	if object.Spec.Completions == nil || *object.Spec.Completions <= 1 {
+		return nil
+	}
+
+	res := &JobProgress{
+		Succeeded:   object.Status.Succeeded,
+		Completions: *object.Spec.Completions,
+	}
+
+	if res.Succeeded > 0 && res.Succeeded < res.Completions && object.Status.StartTime != nil && object.Status.CompletionTime == nil {
+		elapsed := now.Sub(object.Status.StartTime.Time)
+		res.EstimatedRemaining = elapsed * time.Duration(res.Completions-res.Succeeded) / time.Duration(res.Succeeded)
+	}
+
+	return res
+}
+
+// Percent of the succeeded completions.
+func (p JobProgress) Percent() int {
+	if p.Succeeded >= p.Completions {
+		return 100
+	}
+	return int(int64(p.Succeeded) * 100 / int64(p.Completions))
+}
+
+// String describes the progress, e.g. "37%, ~5m left, failed indexes 2,5-7".
+func (p JobProgress) String() string {
+	items := []string{fmt.Sprintf("%d%%", p.Percent())}
+	if p.EstimatedRemaining > 0 {
+		items = append(items, fmt.Sprintf("~%s left", duration.HumanDuration(p.EstimatedRemaining)))
+	}
+	if len(p.FailedIndexes) > 0 {
+		items = append(items, fmt.Sprintf("failed indexes %s", formatIndexes(p.FailedIndexes)))
+	}
+	return strings.Join(items, ", ")
+}
+
+// getCompletionIndex returns the completion index of the Pod of Indexed Job.
+func getCompletionIndex(object *corev1.Pod) (int, bool) {
+	value, hasKey := object.Annotations[completionIndexAnnotation]
+	if !hasKey {
+		return 0, false
+	}
+
+	index, err := strconv.Atoi(value)
+	if err != nil || index < 0 {
+		return 0, false
+	}
+	return index, true
+}
+
+// applyFailedIndexes sets FailedIndexes of the progress of Indexed Job, completionIndexes are the indexes by Pod name.
+func applyFailedIndexes(status *JobStatus, podsStatuses map[string]pod.PodStatus, completionIndexes map[string]int) {
+	if status.Progress == nil || len(completionIndexes) == 0 {
+		return
+	}
+
+	failed := make(map[int]bool)
+	succeeded := make(map[int]bool)
+	for podName, index := range completionIndexes {
+		podStatus, hasKey := podsStatuses[podName]
+		switch {
+		case !hasKey:
+		case podStatus.IsSucceeded:
+			succeeded[index] = true
+		case podStatus.IsFailed:
+			failed[index] = true
+		}
+	}
+
+	for index := range failed {
+		if !succeeded[index] {
+			status.Progress.FailedIndexes = append(status.Progress.FailedIndexes, index)
+		}
+	}
+	sort.Ints(status.Progress.FailedIndexes)
+}
+
+// formatIndexes formats the sorted indexes with ranges, e.g. "1,4-6".
+func formatIndexes(indexes []int) string {
+	var parts []string
+	for i := 0; i < len(indexes); {
+		j := i
+		for j+1 < len(indexes) && indexes[j+1] == indexes[j]+1 {
+			j++
+		}
+
+		if j == i {
+			parts = append(parts, strconv.Itoa(indexes[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", indexes[i], indexes[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
+
+// countCompleted returns the completions of the Job counting the Pods which main containers exited successfully.
+// Completions of Indexed Job are the distinct completed indexes, as several Pods may complete the same index.
+func countCompleted(object *batchv1.Job, podsStatuses map[string]pod.PodStatus, exitedPodsNames []string, completionIndexes map[string]int) int32 {
+	if len(completionIndexes) == 0 {
+		return object.Status.Succeeded + int32(len(exitedPodsNames))
+	}
+
+	completed := make(map[int]bool)
+	for podName, index := range completionIndexes {
+		if podStatus, hasKey := podsStatuses[podName]; hasKey && podStatus.IsSucceeded {
+			completed[index] = true
+		}
+	}
+	for _, podName := range exitedPodsNames {
+		if index, hasKey := completionIndexes[podName]; hasKey {
+			completed[index] = true
+		}
+	}
+
+	return int32(len(completed))
+}
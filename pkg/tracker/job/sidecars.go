@@ -0,0 +1,154 @@
+package job
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/werf/kubedog/pkg/tracker"
+	"github.com/werf/kubedog/pkg/tracker/pod"
+)
+
+// KnownSidecarContainersPatterns match names of the containers injected into Job Pods by service meshes and agents,
+// such containers keep running after the main containers exited and prevent the Pod from completion.
+var KnownSidecarContainersPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^istio-proxy$`),
+	regexp.MustCompile(`^linkerd-proxy$`),
+	regexp.MustCompile(`^consul-(connect|dataplane)`),
+	regexp.MustCompile(`^envoy(-sidecar)?$`),
+	regexp.MustCompile(`^vault-agent$`),
+	regexp.MustCompile(`^cloud-?sql-proxy$`),
+	regexp.MustCompile(`^kuma-sidecar$`),
+}
+
+func isKnownSidecarContainer(name string) bool {
+	for _, pattern := range KnownSidecarContainersPatterns {
+		if pattern.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// getMainContainers returns mainContainers if set, otherwise the containers of the Job template which are not known sidecars.
+func getMainContainers(object *batchv1.Job, mainContainers []string) []string {
+	if len(mainContainers) > 0 {
+		return mainContainers
+	}
+
+	var res []string
+	for _, container := range object.Spec.Template.Spec.Containers {
+		if !isKnownSidecarContainer(container.Name) {
+			res = append(res, container.Name)
+		}
+	}
+	return res
+}
+
+type mainContainersExit struct {
+	// RunningSidecars are the containers keeping the Pod running after all main containers terminated.
+	RunningSidecars []string
+	// FailedContainer is the first main container exited with non-zero code.
+	FailedContainer string
+	ExitCode        int32
+}
+
+// getMainContainersExit returns nil until all main containers of the Pod are terminated while other containers are still running.
+func getMainContainersExit(podStatus pod.PodStatus, mainContainers []string) *mainContainersExit {
+	if len(mainContainers) == 0 || podStatus.Phase != corev1.PodRunning {
+		return nil
+	}
+
+	res := &mainContainersExit{}
+	terminatedMainContainers := 0
+
+	for _, cs := range podStatus.ContainerStatuses {
+		isMain := false
+		for _, name := range mainContainers {
+			if name == cs.Name {
+				isMain = true
+				break
+			}
+		}
+
+		switch {
+		case isMain && cs.State.Terminated != nil:
+			terminatedMainContainers++
+			if cs.State.Terminated.ExitCode != 0 && res.FailedContainer == "" {
+				res.FailedContainer = cs.Name
+				res.ExitCode = cs.State.Terminated.ExitCode
+			}
+		case isMain:
+			return nil
+		case cs.State.Terminated == nil:
+			res.RunningSidecars = append(res.RunningSidecars, cs.Name)
+		}
+	}
+
+	if terminatedMainContainers < len(mainContainers) || len(res.RunningSidecars) == 0 {
+		return nil
+	}
+
+	sort.Strings(res.RunningSidecars)
+
+	return res
+}
+
+// applyMainContainersExit warns about the Pods held open by sidecars after the main containers exited.
+// When treatAsCompletion is set, exit codes of the main containers decide the Job success or failure.
+func applyMainContainersExit(status *JobStatus, object *batchv1.Job, podsStatuses map[string]pod.PodStatus, trackedPodsNames []string, completionIndexes map[string]int, mainContainers []string, treatAsCompletion bool) {
+	if status.IsSucceeded || status.IsFailed {
+		return
+	}
+
+	mainContainers = getMainContainers(object, mainContainers)
+
+	var exitedPodsNames []string
+	var failedReason string
+
+	podsNames := append([]string{}, trackedPodsNames...)
+	sort.Strings(podsNames)
+
+	for _, podName := range podsNames {
+		exit := getMainContainersExit(podsStatuses[podName], mainContainers)
+		if exit == nil {
+			continue
+		}
+
+		if exit.FailedContainer != "" {
+			status.WarningMessages = append(status.WarningMessages, fmt.Sprintf("%s main container %s exited with code %d, pod is kept running by sidecars %s", tracker.FormatResourceID("po", object.Namespace, podName), exit.FailedContainer, exit.ExitCode, strings.Join(exit.RunningSidecars, ", ")))
+			if failedReason == "" {
+				failedReason = fmt.Sprintf("%s main container %s exited with code %d", tracker.FormatResourceID("po", object.Namespace, podName), exit.FailedContainer, exit.ExitCode)
+			}
+			continue
+		}
+
+		status.WarningMessages = append(status.WarningMessages, fmt.Sprintf("%s main containers completed, pod is kept running by sidecars %s", tracker.FormatResourceID("po", object.Namespace, podName), strings.Join(exit.RunningSidecars, ", ")))
+		exitedPodsNames = append(exitedPodsNames, podName)
+	}
+
+	if !treatAsCompletion {
+		return
+	}
+
+	// Failed container of the Pod with OnFailure restart policy will be restarted, the Job is not failed yet.
+	if failedReason != "" && object.Spec.Template.Spec.RestartPolicy == corev1.RestartPolicyNever {
+		status.IsFailed = true
+		status.FailedReason = failedReason
+		return
+	}
+
+	completions := int32(1)
+	if object.Spec.Completions != nil {
+		completions = *object.Spec.Completions
+	}
+
+	if countCompleted(object, podsStatuses, exitedPodsNames, completionIndexes) >= completions {
+		status.IsSucceeded = true
+		status.WaitingForMessages = nil
+	}
+}
@@ -2,10 +2,12 @@ package job
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/werf/kubedog/pkg/utils"
 
+	"github.com/werf/kubedog/pkg/tracker"
 	"github.com/werf/kubedog/pkg/tracker/indicators"
 	"github.com/werf/kubedog/pkg/tracker/pod"
 
@@ -14,6 +16,9 @@ import (
 	"k8s.io/apimachinery/pkg/util/duration"
 )
 
+// defaultBackoffLimit is the backoffLimit of the Job when it is not set.
+const defaultBackoffLimit = 6
+
 type JobStatus struct {
 	batchv1.JobStatus
 
@@ -22,8 +27,13 @@ type JobStatus struct {
 	SucceededIndicator *indicators.Int32EqualConditionIndicator
 	Duration           string
 	Age                string
+	// Progress of the Job with several completions, nil otherwise.
+	Progress *JobProgress
 
 	WaitingForMessages []string
+	// WarningMessages describe failures of the Pods retried within backoffLimit of the Job
+	// and Pods kept running by sidecars after the main containers exited.
+	WarningMessages []string
 
 	IsSucceeded  bool
 	IsFailed     bool
@@ -55,6 +65,23 @@ func NewJobStatus(object *batchv1.Job, statusGeneration uint64, isTrackerFailed
 		res.Duration = duration.HumanDuration(res.CompletionTime.Sub(res.StartTime.Time))
 	}
 
+	// The Job fails only by its own Failed condition (BackoffLimitExceeded, DeadlineExceeded), which is not delayed
+	// by the Pods still being terminated, failures of the Pods retried within backoffLimit are warnings
+	for _, c := range object.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+			res.IsFailed = true
+			res.FailedReason = c.Reason
+			if c.Message != "" {
+				res.FailedReason = fmt.Sprintf("%s: %s", c.Reason, c.Message)
+			}
+			break
+		}
+	}
+
+	if !res.IsFailed {
+		res.WarningMessages = append(res.WarningMessages, getRetriedPodsFailures(object, podsStatuses)...)
+	}
+
 	doCheckJobConditions := true
 	for _, trackedPodName := range trackedPodsNames {
 		podStatus := podsStatuses[trackedPodName]
@@ -66,19 +93,8 @@ func NewJobStatus(object *batchv1.Job, statusGeneration uint64, isTrackerFailed
 
 	if doCheckJobConditions {
 		for _, c := range object.Status.Conditions {
-			switch c.Type {
-			case batchv1.JobComplete:
-				if c.Status == corev1.ConditionTrue {
-					res.IsSucceeded = true
-				}
-
-			case batchv1.JobFailed:
-				if c.Status == corev1.ConditionTrue {
-					if !res.IsFailed {
-						res.IsFailed = true
-						res.FailedReason = c.Reason
-					}
-				}
+			if c.Type == batchv1.JobComplete && c.Status == corev1.ConditionTrue && !res.IsFailed {
+				res.IsSucceeded = true
 			}
 		}
 
@@ -89,13 +105,17 @@ func NewJobStatus(object *batchv1.Job, statusGeneration uint64, isTrackerFailed
 		res.WaitingForMessages = append(res.WaitingForMessages, "pods should be terminated")
 	}
 
+	res.Progress = newJobProgress(object, time.Now())
+
 	res.SucceededIndicator = &indicators.Int32EqualConditionIndicator{}
 	res.SucceededIndicator.Value = object.Status.Succeeded
 
 	if object.Spec.Completions != nil {
 		res.SucceededIndicator.TargetValue = *object.Spec.Completions
 
-		if !res.SucceededIndicator.IsReady() {
+		if !res.SucceededIndicator.IsReady() && res.Progress != nil {
+			res.WaitingForMessages = append(res.WaitingForMessages, fmt.Sprintf("succeeded %d->%d (%s)", res.SucceededIndicator.Value, res.SucceededIndicator.TargetValue, res.Progress))
+		} else if !res.SucceededIndicator.IsReady() {
 			res.WaitingForMessages = append(res.WaitingForMessages, fmt.Sprintf("succeeded %d->%d", res.SucceededIndicator.Value, res.SucceededIndicator.TargetValue))
 		}
 	} else {
@@ -121,3 +141,38 @@ func NewJobStatus(object *batchv1.Job, statusGeneration uint64, isTrackerFailed
 
 	return res
 }
+
+// getRetriedPodsFailures describes the failed Pods and the containers restarted after non-zero exit,
+// e.g. "po/ns/migrate-x2x8f failed: Error: container main exited with code 1 (failures 2/6 of backoffLimit)".
+func getRetriedPodsFailures(object *batchv1.Job, podsStatuses map[string]pod.PodStatus) []string {
+	backoffLimit := int32(defaultBackoffLimit)
+	if object.Spec.BackoffLimit != nil {
+		backoffLimit = *object.Spec.BackoffLimit
+	}
+	backoffProgress := fmt.Sprintf("failures %d/%d of backoffLimit", object.Status.Failed, backoffLimit)
+
+	podsNames := make([]string, 0, len(podsStatuses))
+	for podName := range podsStatuses {
+		podsNames = append(podsNames, podName)
+	}
+	sort.Strings(podsNames)
+
+	var res []string
+	for _, podName := range podsNames {
+		podStatus := podsStatuses[podName]
+		podID := tracker.FormatResourceID("po", object.Namespace, podName)
+
+		if podStatus.IsFailed {
+			res = append(res, fmt.Sprintf("%s failed: %s (%s)", podID, podStatus.FailedReason, backoffProgress))
+			continue
+		}
+
+		for _, cs := range podStatus.ContainerStatuses {
+			if cs.LastTerminationState.Terminated != nil && cs.LastTerminationState.Terminated.ExitCode != 0 {
+				res = append(res, fmt.Sprintf("%s container %s exited with code %d, restarted %d times (%s)", podID, cs.Name, cs.LastTerminationState.Terminated.ExitCode, cs.RestartCount, backoffProgress))
+			}
+		}
+	}
+
+	return res
+}
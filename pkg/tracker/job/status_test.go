@@ -0,0 +1,118 @@
+package job
+
+import (
+	"reflect"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/werf/kubedog/pkg/tracker/pod"
+)
+
+func TestNewJobStatusFailures(t *testing.T) {
+	backoffLimit := int32(2)
+	failedCondition := batchv1.JobCondition{
+		Type:    batchv1.JobFailed,
+		Status:  corev1.ConditionTrue,
+		Reason:  "BackoffLimitExceeded",
+		Message: "Job has reached the specified backoff limit",
+	}
+	failedPod := pod.PodStatus{IsFailed: true, FailedReason: "Error"}
+	restartedPod := pod.PodStatus{PodStatus: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+		Name:                 "main",
+		RestartCount:         2,
+		LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1}},
+	}}}}
+
+	for _, tc := range []struct {
+		name             string
+		backoffLimit     *int32
+		status           batchv1.JobStatus
+		podsStatuses     map[string]pod.PodStatus
+		isTrackerFailed  bool
+		expectedFailed   bool
+		expectedReason   string
+		expectedWarnings []string
+	}{
+		{
+			name:           "failed condition",
+			backoffLimit:   &backoffLimit,
+			status:         batchv1.JobStatus{Failed: 3, Conditions: []batchv1.JobCondition{failedCondition}},
+			podsStatuses:   map[string]pod.PodStatus{"migrate-a": failedPod, "migrate-b": failedPod},
+			expectedFailed: true,
+			expectedReason: "BackoffLimitExceeded: Job has reached the specified backoff limit",
+		},
+		{
+			// The Job fails without waiting for its Pods being terminated
+			name:           "failed condition with running Pods",
+			backoffLimit:   &backoffLimit,
+			status:         batchv1.JobStatus{Failed: 3, Active: 1, Conditions: []batchv1.JobCondition{failedCondition}},
+			podsStatuses:   map[string]pod.PodStatus{"migrate-a": failedPod, "migrate-b": {}},
+			expectedFailed: true,
+			expectedReason: "BackoffLimitExceeded: Job has reached the specified backoff limit",
+		},
+		{
+			name:         "false failed condition",
+			backoffLimit: &backoffLimit,
+			status: batchv1.JobStatus{Active: 1, Conditions: []batchv1.JobCondition{
+				{Type: batchv1.JobFailed, Status: corev1.ConditionFalse, Reason: "BackoffLimitExceeded"},
+			}},
+			podsStatuses: map[string]pod.PodStatus{"migrate-a": {}},
+		},
+		{
+			name:             "retried Pod failure",
+			backoffLimit:     &backoffLimit,
+			status:           batchv1.JobStatus{Failed: 1, Active: 1},
+			podsStatuses:     map[string]pod.PodStatus{"migrate-a": failedPod, "migrate-b": {}},
+			expectedWarnings: []string{"po/ns/migrate-a failed: Error (failures 1/2 of backoffLimit)"},
+		},
+		{
+			name:             "restarted container with default backoffLimit",
+			status:           batchv1.JobStatus{Active: 1},
+			podsStatuses:     map[string]pod.PodStatus{"migrate-a": restartedPod},
+			expectedWarnings: []string{"po/ns/migrate-a container main exited with code 1, restarted 2 times (failures 0/6 of backoffLimit)"},
+		},
+		{
+			name:             "tracker failure",
+			backoffLimit:     &backoffLimit,
+			status:           batchv1.JobStatus{Failed: 1},
+			podsStatuses:     map[string]pod.PodStatus{"migrate-a": failedPod},
+			isTrackerFailed:  true,
+			expectedFailed:   true,
+			expectedReason:   "deadline exceeded",
+			expectedWarnings: []string{"po/ns/migrate-a failed: Error (failures 1/2 of backoffLimit)"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			object := &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "ns"},
+				Spec:       batchv1.JobSpec{BackoffLimit: tc.backoffLimit},
+				Status:     tc.status,
+			}
+
+			var trackedPodsNames []string
+			for podName := range tc.podsStatuses {
+				trackedPodsNames = append(trackedPodsNames, podName)
+			}
+
+			var trackerFailedReason string
+			if tc.isTrackerFailed {
+				trackerFailedReason = "deadline exceeded"
+			}
+
+			status := NewJobStatus(object, 1, tc.isTrackerFailed, trackerFailedReason, tc.podsStatuses, trackedPodsNames)
+
+			if status.IsFailed != tc.expectedFailed || status.FailedReason != tc.expectedReason {
+				t.Errorf("expected failed %v with reason %q, got %v with reason %q", tc.expectedFailed, tc.expectedReason, status.IsFailed, status.FailedReason)
+			}
+			if status.IsSucceeded {
+				t.Errorf("expected not succeeded Job")
+			}
+			if !reflect.DeepEqual(status.WarningMessages, tc.expectedWarnings) {
+				t.Errorf("expected warnings %q, got %q", tc.expectedWarnings, status.WarningMessages)
+			}
+		})
+	}
+}
@@ -15,7 +15,6 @@ import (
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
-	watchtools "k8s.io/client-go/tools/watch"
 
 	"github.com/werf/kubedog/pkg/tracker"
 	"github.com/werf/kubedog/pkg/tracker/debug"
@@ -45,6 +44,7 @@ type Tracker struct {
 	Added     chan JobStatus
 	Succeeded chan JobStatus
 	Failed    chan JobStatus
+	Deleted   chan JobStatus
 	Status    chan JobStatus
 
 	EventMsg    chan string
@@ -58,6 +58,18 @@ type Tracker struct {
 	lastObject   *batchv1.Job
 	failedReason string
 	podStatuses  map[string]pod.PodStatus
+	// completionIndexes are the completion indexes of the Pods of Indexed Job by Pod name.
+	completionIndexes          map[string]int
+	deletedPodsHistory         *pod.DeletedPodsHistory
+	stringsInterner            *utils.StringsInterner
+	initContainersStuckTimeout time.Duration
+	unschedulableTimeout       time.Duration
+	listObserver               tracker.ListObserver
+	connectionRetry            tracker.ConnectionRetry
+	panicsAreFatal             bool
+
+	mainContainers                        []string
+	treatMainContainerExitAsJobCompletion bool
 
 	objectAdded    chan *batchv1.Job
 	objectModified chan *batchv1.Job
@@ -78,14 +90,22 @@ func NewTracker(name, namespace string, kube kubernetes.Interface, opts tracker.
 		Tracker: tracker.Tracker{
 			Kube:             kube,
 			Namespace:        namespace,
-			FullResourceName: fmt.Sprintf("job/%s", name),
+			FullResourceName: tracker.FormatResourceID("job", namespace, name),
 			ResourceName:     name,
 			LogsFromTime:     opts.LogsFromTime,
+
+			EventSeverityOverrides:  opts.EventSeverityOverrides,
+			LogsLimits:              opts.LogsLimits,
+			ContainerFailureReasons: opts.ContainerFailureReasons,
+			Suppressions:            opts.Suppressions,
+			LogStreamsBudget:        opts.LogStreamsBudget,
+			SharedInformers:         opts.SharedInformers,
 		},
 
 		Added:     make(chan JobStatus, 1),
 		Succeeded: make(chan JobStatus, 0),
 		Failed:    make(chan JobStatus, 0),
+		Deleted:   make(chan JobStatus, 0),
 		Status:    make(chan JobStatus, 100),
 
 		EventMsg:    make(chan string, 1),
@@ -93,7 +113,18 @@ func NewTracker(name, namespace string, kube kubernetes.Interface, opts tracker.
 		PodLogChunk: make(chan *pod.PodLogChunk, 1000),
 		PodError:    make(chan PodErrorReport, 0),
 
-		podStatuses: make(map[string]pod.PodStatus),
+		podStatuses:                make(map[string]pod.PodStatus),
+		completionIndexes:          make(map[string]int),
+		deletedPodsHistory:         pod.NewDeletedPodsHistory(opts.DeletedPodsHistoryLimit),
+		stringsInterner:            opts.StringsInterner,
+		initContainersStuckTimeout: opts.InitContainersStuckTimeout,
+		unschedulableTimeout:       opts.UnschedulableTimeout,
+		listObserver:               opts.ListObserver,
+		connectionRetry:            opts.ConnectionRetry,
+		panicsAreFatal:             opts.PanicsAreFatal,
+
+		mainContainers:                        opts.MainContainers,
+		treatMainContainerExitAsJobCompletion: opts.TreatMainContainerExitAsJobCompletion,
 
 		State: tracker.Initial,
 
@@ -137,7 +168,7 @@ func (job *Tracker) Track(ctx context.Context) error {
 			var status JobStatus
 			if job.lastObject != nil {
 				job.StatusGeneration++
-				status = NewJobStatus(job.lastObject, job.StatusGeneration, (job.State == tracker.ResourceFailed), job.failedReason, job.podStatuses, job.TrackedPodsNames)
+				status = job.newJobStatus(job.lastObject)
 			} else {
 				status = JobStatus{IsFailed: true, FailedReason: reason}
 			}
@@ -147,12 +178,18 @@ func (job *Tracker) Track(ctx context.Context) error {
 			job.State = tracker.ResourceDeleted
 			job.lastObject = nil
 			job.TrackedPodsNames = nil
-			job.Status <- JobStatus{}
+			job.deletedPodsHistory.Reset()
+			job.Deleted <- JobStatus{}
 
 		case pod := <-job.podAddedRelay:
+			job.deletedPodsHistory.Forget(pod.Name)
+			if index, isIndexed := getCompletionIndex(pod); isIndexed {
+				job.completionIndexes[pod.Name] = index
+			}
+
 			if job.lastObject != nil {
 				job.StatusGeneration++
-				status := NewJobStatus(job.lastObject, job.StatusGeneration, (job.State == tracker.ResourceFailed), job.failedReason, job.podStatuses, job.TrackedPodsNames)
+				status := job.newJobStatus(job.lastObject)
 				job.AddedPod <- PodAddedReport{
 					PodName:   pod.Name,
 					JobStatus: status,
@@ -172,10 +209,14 @@ func (job *Tracker) Track(ctx context.Context) error {
 					if name == donePodName {
 						// This Pod is no more tracked,
 						// but we need to update final
-						// Pod's status
-						if _, hasKey := job.podStatuses[name]; hasKey {
+						// Pod's status, the Pod deleted before
+						// its status is relayed is shown as well
+						if _, hasKey := job.podStatuses[name]; hasKey || status.IsDeleted {
 							job.podStatuses[name] = status
 						}
+						if status.IsDeleted {
+							job.deletedPodsHistory.Add(name)
+						}
 						continue trackedPodsIteration
 					}
 				}
@@ -184,15 +225,20 @@ func (job *Tracker) Track(ctx context.Context) error {
 			}
 			job.TrackedPodsNames = trackedPodsNames
 
+			// Deleted Pods are pruned after the status with them as terminated has been reported
 			if job.lastObject != nil {
 				if err := job.handleJobState(ctx, job.lastObject); err != nil {
 					return err
 				}
 			}
 
+			job.deletedPodsHistory.Prune(job.podStatuses)
+
 		case podStatuses := <-job.podStatusesRelay:
 			for podName, podStatus := range podStatuses {
-				job.podStatuses[podName] = podStatus
+				if pod.IsTrackedPod(job.TrackedPodsNames, podName) {
+					job.podStatuses[podName] = podStatus
+				}
 			}
 			if job.lastObject != nil {
 				if err := job.handleJobState(ctx, job.lastObject); err != nil {
@@ -202,13 +248,19 @@ func (job *Tracker) Track(ctx context.Context) error {
 
 		case podContainerErrors := <-job.podContainerErrorsRelay:
 			for podName, containerError := range podContainerErrors {
-				job.podStatuses[podName] = containerError.PodStatus
+				if pod.IsTrackedPod(job.TrackedPodsNames, podName) {
+					job.podStatuses[podName] = containerError.PodStatus
+				}
 			}
 			if job.lastObject != nil {
 				job.StatusGeneration++
-				status := NewJobStatus(job.lastObject, job.StatusGeneration, (job.State == tracker.ResourceFailed), job.failedReason, job.podStatuses, job.TrackedPodsNames)
+				status := job.newJobStatus(job.lastObject)
 
 				for podName, containerError := range podContainerErrors {
+					if isRetriedContainerError(containerError) {
+						continue
+					}
+
 					job.PodError <- PodErrorReport{
 						PodError: pod.PodError{
 							ContainerError: containerError.ContainerError,
@@ -235,17 +287,18 @@ func (job *Tracker) runInformer(ctx context.Context) error {
 		options.FieldSelector = fields.OneTermEqualSelector("metadata.name", job.ResourceName).String()
 		return options
 	}
-	lw := &cache.ListWatch{
+	lw := job.SharedInformers.ListWatch(job.Namespace, &batchv1.Job{}, tweakListOptions, &cache.ListWatch{
 		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
 			return job.Kube.BatchV1().Jobs(job.Namespace).List(ctx, tweakListOptions(options))
 		},
 		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
 			return job.Kube.BatchV1().Jobs(job.Namespace).Watch(ctx, tweakListOptions(options))
 		},
-	}
+	})
+	lw.ListFunc = tracker.ObserveList(lw.ListFunc, job.listObserver)
 
 	go func() {
-		_, err := watchtools.UntilWithSync(ctx, lw, &batchv1.Job{}, nil, func(e watch.Event) (bool, error) {
+		_, err := tracker.UntilWithSync(ctx, lw, &batchv1.Job{}, job.connectionRetry, func(e watch.Event) (bool, error) {
 			if debug.Debug() {
 				fmt.Printf("Job `%s` informer event: %#v\n", job.ResourceName, e.Type)
 			}
@@ -271,8 +324,11 @@ func (job *Tracker) runInformer(ctx context.Context) error {
 			return false, nil
 		})
 
-		if err != tracker.AdaptInformerError(err) {
-			job.errors <- fmt.Errorf("job informer error: %s", err)
+		if err := tracker.AdaptInformerError(err); err != nil {
+			select {
+			case job.errors <- fmt.Errorf("job informer error: %w", err):
+			case <-ctx.Done():
+			}
 		}
 
 		if debug.Debug() {
@@ -283,11 +339,29 @@ func (job *Tracker) runInformer(ctx context.Context) error {
 	return nil
 }
 
+// suppressJobConditions drops the conditions of the suppressed types from the copy of the Job.
+func suppressJobConditions(object *batchv1.Job, suppressions *tracker.Suppressions) *batchv1.Job {
+	if !suppressions.HasConditionTypes() {
+		return object
+	}
+
+	res := *object
+	res.Status.Conditions = nil
+	for _, cond := range object.Status.Conditions {
+		if !suppressions.IsConditionSuppressed(string(cond.Type)) {
+			res.Status.Conditions = append(res.Status.Conditions, cond)
+		}
+	}
+
+	return &res
+}
+
 func (job *Tracker) handleJobState(ctx context.Context, object *batchv1.Job) error {
+	object = suppressJobConditions(object, job.Suppressions)
 	job.lastObject = object
 	job.StatusGeneration++
 
-	status := NewJobStatus(object, job.StatusGeneration, job.State == tracker.ResourceFailed, job.failedReason, job.podStatuses, job.TrackedPodsNames)
+	status := job.newJobStatus(object)
 
 	switch job.State {
 	case tracker.Initial:
@@ -332,6 +406,32 @@ func (job *Tracker) handleJobState(ctx context.Context, object *batchv1.Job) err
 	return nil
 }
 
+func (job *Tracker) newJobStatus(object *batchv1.Job) JobStatus {
+	status := NewJobStatus(object, job.StatusGeneration, job.State == tracker.ResourceFailed, job.failedReason, job.podStatuses, job.TrackedPodsNames)
+	applyFailedIndexes(&status, job.podStatuses, job.completionIndexes)
+	applyMainContainersExit(&status, object, job.podStatuses, job.TrackedPodsNames, job.completionIndexes, job.mainContainers, job.treatMainContainerExitAsJobCompletion)
+	return status
+}
+
+// isRetriedContainerError returns true for the crash-looping container of the Job Pod with OnFailure restart policy:
+// its restarts are counted by backoffLimit of the Job, so the Job fails by its Failed condition and the restarts
+// are shown as warnings.
+func isRetriedContainerError(report pod.ContainerErrorReport) bool {
+	if report.ContainerName == "" {
+		return false
+	}
+
+	for _, statuses := range [][]corev1.ContainerStatus{report.PodStatus.InitContainerStatuses, report.PodStatus.ContainerStatuses} {
+		for _, cs := range statuses {
+			if cs.Name == report.ContainerName {
+				return cs.State.Waiting != nil && cs.State.Waiting.Reason == "CrashLoopBackOff"
+			}
+		}
+	}
+
+	return false
+}
+
 func (job *Tracker) runPodsInformer(ctx context.Context, object *batchv1.Job) {
 	podsInformer := pod.NewPodsInformer(&job.Tracker, utils.ControllerAccessor(object))
 	podsInformer.WithChannels(job.podAddedRelay, job.errors)
@@ -347,9 +447,24 @@ func (job *Tracker) runPodTracker(_ctx context.Context, podName string) error {
 	if !job.LogsFromTime.IsZero() {
 		podTracker.LogsFromTime = job.LogsFromTime
 	}
+	podTracker.StringsInterner = job.stringsInterner
+	podTracker.InitContainersStuckTimeout = job.initContainersStuckTimeout
+	podTracker.UnschedulableTimeout = job.unschedulableTimeout
+	podTracker.EventSeverityOverrides = job.EventSeverityOverrides
+	podTracker.LogsLimits = job.LogsLimits
+	podTracker.ContainerFailureReasons = job.ContainerFailureReasons
+	podTracker.Suppressions = job.Suppressions
+	podTracker.LogStreamsBudget = job.LogStreamsBudget
+	podTracker.SharedInformers = job.SharedInformers
 	job.TrackedPodsNames = append(job.TrackedPodsNames, podName)
 
 	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				errorChan <- tracker.NewPanicError(recovered, job.panicsAreFatal)
+			}
+		}()
+
 		if debug.Debug() {
 			fmt.Printf("Starting Job's `%s` Pod `%s` tracker\n", job.ResourceName, podTracker.ResourceName)
 		}
@@ -384,7 +499,7 @@ func (job *Tracker) runPodTracker(_ctx context.Context, podName string) error {
 				job.podStatusesRelay <- map[string]pod.PodStatus{podTracker.ResourceName: status}
 
 			case msg := <-podTracker.EventMsg:
-				job.EventMsg <- fmt.Sprintf("po/%s %s", podTracker.ResourceName, msg)
+				job.EventMsg <- fmt.Sprintf("%s %s", tracker.FormatResourceID("po", podTracker.Namespace, podTracker.ResourceName), msg)
 			case chunk := <-podTracker.ContainerLogChunk:
 				podChunk := &pod.PodLogChunk{ContainerLogChunk: chunk, PodName: podTracker.ResourceName}
 				job.PodLogChunk <- podChunk
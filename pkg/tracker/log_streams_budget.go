@@ -0,0 +1,169 @@
+package tracker
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LogStreamPriority orders the containers log streams competing for LogStreamsBudget:
+// streams of failing containers go first, then streams of the recently started containers.
+type LogStreamPriority struct {
+	Failing   bool
+	StartedAt time.Time
+}
+
+func (p LogStreamPriority) higherThan(other LogStreamPriority) bool {
+	if p.Failing != other.Failing {
+		return p.Failing
+	}
+	return p.StartedAt.After(other.StartedAt)
+}
+
+// LogStreamsBudget limits the number of the containers log streams open at once by all trackers sharing it.
+// When the budget is exceeded, the streams with the lowest priority are detached, they are attached again
+// once there are free slots. Nil LogStreamsBudget limits nothing.
+type LogStreamsBudget struct {
+	limit int
+
+	mux        sync.Mutex
+	seq        uint64
+	priorities map[string]LogStreamPriority
+	active     map[*LogStreamSlot]bool
+	waiting    map[*LogStreamSlot]bool
+}
+
+// LogStreamSlot is the permission of a single log stream to be open, see LogStreamsBudget.Acquire.
+type LogStreamSlot struct {
+	budget  *LogStreamsBudget
+	key     string
+	seq     uint64
+	granted chan struct{}
+	revoked chan struct{}
+}
+
+// NewLogStreamsBudget returns nil when limit is 0 or negative.
+func NewLogStreamsBudget(limit int) *LogStreamsBudget {
+	if limit <= 0 {
+		return nil
+	}
+
+	return &LogStreamsBudget{
+		limit:      limit,
+		priorities: make(map[string]LogStreamPriority),
+		active:     make(map[*LogStreamSlot]bool),
+		waiting:    make(map[*LogStreamSlot]bool),
+	}
+}
+
+// SetPriority sets the priority of the streams with the key, e.g. "ns/pod/container", and reschedules the streams.
+func (b *LogStreamsBudget) SetPriority(key string, priority LogStreamPriority) {
+	if b == nil {
+		return
+	}
+
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	if prev, hasKey := b.priorities[key]; hasKey && prev == priority {
+		return
+	}
+	b.priorities[key] = priority
+	b.schedule()
+}
+
+// Forget drops the priority of the key, when the stream is done for good.
+func (b *LogStreamsBudget) Forget(key string) {
+	if b == nil {
+		return
+	}
+
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	delete(b.priorities, key)
+}
+
+// Acquire waits for a slot of the stream with the key. False is returned when ctx is done before the slot is granted.
+// Nil budget grants nil slot right away, all methods of nil slot are no-op.
+func (b *LogStreamsBudget) Acquire(ctx context.Context, key string) (*LogStreamSlot, bool) {
+	if b == nil {
+		return nil, true
+	}
+
+	b.mux.Lock()
+	b.seq++
+	slot := &LogStreamSlot{budget: b, key: key, seq: b.seq, granted: make(chan struct{}), revoked: make(chan struct{})}
+	b.waiting[slot] = true
+	b.schedule()
+	b.mux.Unlock()
+
+	select {
+	case <-slot.granted:
+		return slot, true
+	case <-ctx.Done():
+		slot.Release()
+		return nil, false
+	}
+}
+
+// Revoked is closed when the slot is taken by the stream with a higher priority, the stream should be closed then.
+func (s *LogStreamSlot) Revoked() <-chan struct{} {
+	if s == nil {
+		return nil
+	}
+	return s.revoked
+}
+
+// Release frees the slot of the closed stream, so that the waiting streams are attached.
+func (s *LogStreamSlot) Release() {
+	if s == nil {
+		return
+	}
+
+	b := s.budget
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	delete(b.waiting, s)
+	if b.active[s] {
+		delete(b.active, s)
+		b.schedule()
+	}
+}
+
+// schedule grants the slots to the streams with the highest priorities, active streams win ties with the waiting ones,
+// then the streams which acquired earlier.
+func (b *LogStreamsBudget) schedule() {
+	var slots []*LogStreamSlot
+	for slot := range b.active {
+		slots = append(slots, slot)
+	}
+	for slot := range b.waiting {
+		slots = append(slots, slot)
+	}
+
+	sort.Slice(slots, func(i, j int) bool {
+		pi, pj := b.priorities[slots[i].key], b.priorities[slots[j].key]
+		if pi.higherThan(pj) || pj.higherThan(pi) {
+			return pi.higherThan(pj)
+		}
+		if b.active[slots[i]] != b.active[slots[j]] {
+			return b.active[slots[i]]
+		}
+		return slots[i].seq < slots[j].seq
+	})
+
+	for i, slot := range slots {
+		switch {
+		case i < b.limit && b.waiting[slot]:
+			delete(b.waiting, slot)
+			b.active[slot] = true
+			close(slot.granted)
+		case i >= b.limit && b.active[slot]:
+			delete(b.active, slot)
+			close(slot.revoked)
+		}
+	}
+}
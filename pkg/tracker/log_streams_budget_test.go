@@ -0,0 +1,161 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func acquireTestSlot(t *testing.T, budget *LogStreamsBudget, key string) <-chan *LogStreamSlot {
+	t.Helper()
+
+	res := make(chan *LogStreamSlot, 1)
+	go func() {
+		slot, ok := budget.Acquire(context.Background(), key)
+		if !ok {
+			t.Errorf("%s: slot is not granted", key)
+		}
+		res <- slot
+	}()
+	return res
+}
+
+func receiveTestSlot(t *testing.T, key string, slotChan <-chan *LogStreamSlot) *LogStreamSlot {
+	t.Helper()
+
+	select {
+	case slot := <-slotChan:
+		return slot
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%s: slot is not granted within 5s", key)
+	}
+	return nil
+}
+
+func isTestSlotRevoked(slot *LogStreamSlot) bool {
+	select {
+	case <-slot.Revoked():
+		return true
+	default:
+		return false
+	}
+}
+
+// TestLogStreamsBudget fills the budget of 2 streams: the failing container takes the slot of the stream acquired last,
+// which is attached again once the stream of the failing container is done.
+func TestLogStreamsBudget(t *testing.T) {
+	budget := NewLogStreamsBudget(2)
+
+	first := receiveTestSlot(t, "ns/po/first", acquireTestSlot(t, budget, "ns/po/first"))
+	second := receiveTestSlot(t, "ns/po/second", acquireTestSlot(t, budget, "ns/po/second"))
+
+	failingChan := acquireTestSlot(t, budget, "ns/po/failing")
+	select {
+	case <-failingChan:
+		t.Fatalf("unexpected slot granted over the budget")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	budget.SetPriority("ns/po/failing", LogStreamPriority{Failing: true})
+	failing := receiveTestSlot(t, "ns/po/failing", failingChan)
+
+	if isTestSlotRevoked(first) || !isTestSlotRevoked(second) || isTestSlotRevoked(failing) {
+		t.Fatalf("expected the slot of the second stream revoked")
+	}
+
+	// The revoked stream is closed and waits for the slot again
+	second.Release()
+	secondChan := acquireTestSlot(t, budget, "ns/po/second")
+
+	failing.Release()
+	budget.Forget("ns/po/failing")
+	second = receiveTestSlot(t, "ns/po/second", secondChan)
+
+	// Streams of the started containers go before the stream without priority, the recently started first
+	budget.SetPriority("ns/po/second", LogStreamPriority{StartedAt: time.Now()})
+	budget.SetPriority("ns/po/third", LogStreamPriority{StartedAt: time.Now().Add(-time.Minute)})
+	thirdChan := acquireTestSlot(t, budget, "ns/po/third")
+	third := receiveTestSlot(t, "ns/po/third", thirdChan)
+	if !isTestSlotRevoked(first) || isTestSlotRevoked(second) || isTestSlotRevoked(third) {
+		t.Errorf("expected the slot of the first stream without priority revoked")
+	}
+}
+
+func TestLogStreamsBudgetAcquireCancelled(t *testing.T) {
+	budget := NewLogStreamsBudget(1)
+	active, _ := budget.Acquire(context.Background(), "ns/po/active")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if slot, ok := budget.Acquire(ctx, "ns/po/waiting"); ok || slot != nil {
+		t.Fatalf("expected no slot granted before ctx is done")
+	}
+
+	active.Release()
+	if _, ok := budget.Acquire(context.Background(), "ns/po/next"); !ok {
+		t.Errorf("expected the slot granted after the cancelled stream")
+	}
+}
+
+func TestLogStreamsBudgetUnlimited(t *testing.T) {
+	for _, limit := range []int{0, -1} {
+		budget := NewLogStreamsBudget(limit)
+		if budget != nil {
+			t.Fatalf("limit %d: expected nil budget", limit)
+		}
+
+		budget.SetPriority("ns/po/c", LogStreamPriority{Failing: true})
+		budget.Forget("ns/po/c")
+		slot, ok := budget.Acquire(context.Background(), "ns/po/c")
+		if !ok || slot != nil || slot.Revoked() != nil {
+			t.Errorf("limit %d: expected nil slot granted right away", limit)
+		}
+		slot.Release()
+	}
+}
+
+// TestLogStreamsBudgetRandomOperations acquires, releases and reprioritizes the streams in random order:
+// the budget never has more active streams than the limit and never keeps streams waiting while there are free slots.
+func TestLogStreamsBudgetRandomOperations(t *testing.T) {
+	for seed := int64(0); seed < 10; seed++ {
+		rnd := rand.New(rand.NewSource(seed))
+		budget := NewLogStreamsBudget(1 + rnd.Intn(3))
+
+		var slots []*LogStreamSlot
+		for step := 0; step < 200; step++ {
+			key := fmt.Sprintf("ns/po/c%d", rnd.Intn(6))
+
+			switch op := rnd.Intn(3); {
+			case op == 0:
+				ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+				if slot, ok := budget.Acquire(ctx, key); ok {
+					slots = append(slots, slot)
+				}
+				cancel()
+			case op == 1 && len(slots) > 0:
+				i := rnd.Intn(len(slots))
+				slots[i].Release()
+				slots = append(slots[:i], slots[i+1:]...)
+			default:
+				budget.SetPriority(key, LogStreamPriority{Failing: rnd.Intn(4) == 0, StartedAt: time.Unix(int64(rnd.Intn(5)), 0)})
+			}
+
+			budget.mux.Lock()
+			active, waiting := len(budget.active), len(budget.waiting)
+			budget.mux.Unlock()
+
+			if active > budget.limit || (waiting > 0 && active < budget.limit) {
+				t.Fatalf("seed %d step %d: %d active and %d waiting streams with limit %d", seed, step, active, waiting, budget.limit)
+			}
+		}
+
+		for _, slot := range slots {
+			slot.Release()
+		}
+		if len(budget.active) != 0 || len(budget.waiting) != 0 {
+			t.Errorf("seed %d: expected no streams after all released, got %d active and %d waiting", seed, len(budget.active), len(budget.waiting))
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package tracker
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is the internal error of the tracker goroutine which panicked.
+type PanicError struct {
+	Value interface{}
+	// Stack is the stack of the panicking goroutine.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("internal error: panic: %v", e.Value)
+}
+
+// NewPanicError converts the value returned by recover() into *PanicError, it should be called by the deferred function,
+// so that the stack of the panic is captured. When panicsAreFatal is set, it panics again with the same value instead.
+func NewPanicError(recovered interface{}, panicsAreFatal bool) *PanicError {
+	if panicsAreFatal {
+		panic(recovered)
+	}
+	return &PanicError{Value: recovered, Stack: debug.Stack()}
+}
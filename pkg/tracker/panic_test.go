@@ -0,0 +1,59 @@
+package tracker
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func panickingTestTracker() {
+	panic("nil status")
+}
+
+func recoverTestPanic(panicsAreFatal bool, f func()) (err error, fatal interface{}) {
+	defer func() {
+		fatal = recover()
+	}()
+
+	func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err = NewPanicError(recovered, panicsAreFatal)
+			}
+		}()
+		f()
+	}()
+
+	return err, nil
+}
+
+func TestNewPanicError(t *testing.T) {
+	err, fatal := recoverTestPanic(false, panickingTestTracker)
+	if fatal != nil {
+		t.Fatalf("unexpected panic %v", fatal)
+	}
+
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("expected *PanicError, got %v", err)
+	}
+	if err.Error() != "internal error: panic: nil status" {
+		t.Errorf("unexpected error %q", err)
+	}
+	if panicErr.Value != "nil status" {
+		t.Errorf("expected the recovered value, got %v", panicErr.Value)
+	}
+	if !strings.Contains(string(panicErr.Stack), "tracker.panickingTestTracker") {
+		t.Errorf("expected the stack of the panicking goroutine, got:\n%s", panicErr.Stack)
+	}
+}
+
+func TestNewPanicErrorFatal(t *testing.T) {
+	err, fatal := recoverTestPanic(true, panickingTestTracker)
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if fatal != "nil status" {
+		t.Errorf("expected panic with the recovered value, got %v", fatal)
+	}
+}
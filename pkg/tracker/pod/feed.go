@@ -20,6 +20,7 @@ type Feed interface {
 	OnEventMsg(func(msg string) error)
 	OnContainerLogChunk(func(*ContainerLogChunk) error)
 	OnContainerError(func(ContainerError) error)
+	OnDeleted(func() error)
 	OnStatus(func(PodStatus) error)
 
 	GetStatus() PodStatus
@@ -38,6 +39,7 @@ type feed struct {
 	OnReadyFunc             func() error
 	OnContainerLogChunkFunc func(*ContainerLogChunk) error
 	OnContainerErrorFunc    func(ContainerError) error
+	OnDeletedFunc           func() error
 	OnStatusFunc            func(PodStatus) error
 
 	statusMux sync.Mutex
@@ -65,6 +67,9 @@ func (f *feed) OnContainerLogChunk(function func(*ContainerLogChunk) error) {
 func (f *feed) OnContainerError(function func(ContainerError) error) {
 	f.OnContainerErrorFunc = function
 }
+func (f *feed) OnDeleted(function func() error) {
+	f.OnDeletedFunc = function
+}
 func (f *feed) OnStatus(function func(PodStatus) error) {
 	f.OnStatusFunc = function
 }
@@ -81,8 +86,24 @@ func (f *feed) Track(name, namespace string, kube kubernetes.Interface, opts tra
 	defer cancel()
 
 	pod := NewTracker(name, namespace, kube)
+	pod.InitContainersStuckTimeout = opts.InitContainersStuckTimeout
+	pod.UnschedulableTimeout = opts.UnschedulableTimeout
+	pod.EventSeverityOverrides = opts.EventSeverityOverrides
+	pod.LogsLimits = opts.LogsLimits
+	pod.ContainerFailureReasons = opts.ContainerFailureReasons
+	pod.Suppressions = opts.Suppressions
+	pod.LogStreamsBudget = opts.LogStreamsBudget
+	pod.SharedInformers = opts.SharedInformers
+	pod.ListObserver = opts.ListObserver
+	pod.ConnectionRetry = opts.ConnectionRetry
 
 	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				errorChan <- tracker.NewPanicError(recovered, opts.PanicsAreFatal)
+			}
+		}()
+
 		err := pod.Start(ctx)
 		if err != nil {
 			errorChan <- err
@@ -191,6 +212,27 @@ func (f *feed) Track(name, namespace string, kube kubernetes.Interface, opts tra
 				}
 			}
 
+		case status := <-pod.Deleted:
+			f.setStatus(status)
+
+			if f.OnDeletedFunc != nil {
+				err := f.OnDeletedFunc()
+				if err == tracker.StopTrack {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			} else if f.OnStatusFunc != nil {
+				err := f.OnStatusFunc(status)
+				if err == tracker.StopTrack {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+
 		case status := <-pod.Status:
 			f.setStatus(status)
 
@@ -0,0 +1,71 @@
+package pod
+
+const DefaultDeletedPodsHistoryLimit = 10
+
+// DeletedPodsHistory keeps statuses of the deleted Pods of a controller for a while,
+// so that each deleted Pod is shown in the status report as terminated at least once.
+// Statuses of the oldest deleted Pods are pruned when the history limit is exceeded.
+type DeletedPodsHistory struct {
+	limit     int
+	podsNames []string
+}
+
+// NewDeletedPodsHistory creates history which retains at most limit deleted Pods,
+// 0 limit means DefaultDeletedPodsHistoryLimit, negative limit means do not retain deleted Pods at all.
+func NewDeletedPodsHistory(limit int) *DeletedPodsHistory {
+	if limit == 0 {
+		limit = DefaultDeletedPodsHistoryLimit
+	} else if limit < 0 {
+		limit = 0
+	}
+
+	return &DeletedPodsHistory{limit: limit}
+}
+
+// Add registers deleted Pod, its status is kept until Prune finds the history limit exceeded.
+func (h *DeletedPodsHistory) Add(podName string) {
+	h.Forget(podName)
+	h.podsNames = append(h.podsNames, podName)
+}
+
+// Prune removes statuses of the oldest deleted Pods exceeding the limit from podsStatuses. It should be called after
+// the status with the added Pods has been reported, so that each deleted Pod is shown as terminated once even with no retained Pods.
+// Names of the pruned Pods are returned so that the caller could clean up other bookkeeping.
+func (h *DeletedPodsHistory) Prune(podsStatuses map[string]PodStatus) []string {
+	var prunedPodsNames []string
+	for len(h.podsNames) > h.limit {
+		prunedPodName := h.podsNames[0]
+		h.podsNames = h.podsNames[1:]
+
+		delete(podsStatuses, prunedPodName)
+		prunedPodsNames = append(prunedPodsNames, prunedPodName)
+	}
+
+	return prunedPodsNames
+}
+
+// Forget removes Pod from the history, should be called when a Pod with the same name appears again
+// (StatefulSet Pods are recreated with the same names).
+func (h *DeletedPodsHistory) Forget(podName string) {
+	for i, name := range h.podsNames {
+		if name == podName {
+			h.podsNames = append(h.podsNames[:i], h.podsNames[i+1:]...)
+			return
+		}
+	}
+}
+
+func (h *DeletedPodsHistory) Reset() {
+	h.podsNames = nil
+}
+
+// IsTrackedPod returns true when the tracker of the Pod is still running. Statuses relayed by the Pod tracker may be received
+// by the controller tracker after the tracker is done, they are dropped so that the statuses of the pruned Pods do not come back.
+func IsTrackedPod(trackedPodsNames []string, podName string) bool {
+	for _, name := range trackedPodsNames {
+		if name == podName {
+			return true
+		}
+	}
+	return false
+}
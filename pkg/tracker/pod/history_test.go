@@ -0,0 +1,61 @@
+package pod
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestDeletedPodsHistoryPrune(t *testing.T) {
+	for _, tc := range []struct {
+		limit        int
+		retainedPods []string
+	}{
+		{limit: 0, retainedPods: []string{"pod-190", "pod-191", "pod-192", "pod-193", "pod-194", "pod-195", "pod-196", "pod-197", "pod-198", "pod-199"}},
+		{limit: 2, retainedPods: []string{"pod-198", "pod-199"}},
+		{limit: -1, retainedPods: nil},
+	} {
+		history := NewDeletedPodsHistory(tc.limit)
+		podsStatuses := make(map[string]PodStatus)
+
+		for i := 0; i < 200; i++ {
+			podName := fmt.Sprintf("pod-%d", i)
+			podsStatuses[podName] = PodStatus{IsDeleted: true}
+			history.Add(podName)
+
+			// The status is not pruned until it is reported
+			if _, hasKey := podsStatuses[podName]; !hasKey {
+				t.Fatalf("limit %d: status of %s pruned before Prune", tc.limit, podName)
+			}
+
+			history.Prune(podsStatuses)
+		}
+
+		var retainedPods []string
+		for i := 0; i < 200; i++ {
+			if _, hasKey := podsStatuses[fmt.Sprintf("pod-%d", i)]; hasKey {
+				retainedPods = append(retainedPods, fmt.Sprintf("pod-%d", i))
+			}
+		}
+		if !reflect.DeepEqual(retainedPods, tc.retainedPods) {
+			t.Errorf("limit %d: retained %v, expected %v", tc.limit, retainedPods, tc.retainedPods)
+		}
+	}
+}
+
+func TestDeletedPodsHistoryForget(t *testing.T) {
+	history := NewDeletedPodsHistory(1)
+	podsStatuses := map[string]PodStatus{"web-0": {}, "web-1": {}}
+
+	history.Add("web-0")
+	// StatefulSet Pod is created again with the same name
+	history.Forget("web-0")
+	history.Add("web-1")
+
+	if pruned := history.Prune(podsStatuses); len(pruned) != 0 {
+		t.Errorf("expected no pruned Pods, got %v", pruned)
+	}
+	if _, hasKey := podsStatuses["web-0"]; !hasKey {
+		t.Errorf("expected status of the recreated Pod to be kept")
+	}
+}
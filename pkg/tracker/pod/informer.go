@@ -22,6 +22,8 @@ type PodsInformer struct {
 	Controller utils.ControllerMetadata
 	PodAdded   chan *corev1.Pod
 	Errors     chan error
+	// PodDeleted receives the deleted Pods when set with WithDeletedChannel, deletions are not reported by default.
+	PodDeleted chan *corev1.Pod
 }
 
 func NewPodsInformer(trk *tracker.Tracker, controller utils.ControllerMetadata) *PodsInformer {
@@ -30,6 +32,7 @@ func NewPodsInformer(trk *tracker.Tracker, controller utils.ControllerMetadata)
 			Kube:             trk.Kube,
 			Namespace:        trk.Namespace,
 			FullResourceName: trk.FullResourceName,
+			SharedInformers:  trk.SharedInformers,
 		},
 		Controller: controller,
 		PodAdded:   make(chan *corev1.Pod, 1),
@@ -43,6 +46,11 @@ func (p *PodsInformer) WithChannels(added chan *corev1.Pod, errors chan error) *
 	return p
 }
 
+func (p *PodsInformer) WithDeletedChannel(deleted chan *corev1.Pod) *PodsInformer {
+	p.PodDeleted = deleted
+	return p
+}
+
 func (p *PodsInformer) Run(ctx context.Context) {
 	if debug.Debug() {
 		fmt.Printf("> PodsInformer.Run\n")
@@ -60,14 +68,14 @@ func (p *PodsInformer) Run(ctx context.Context) {
 		options.LabelSelector = selector.String()
 		return options
 	}
-	lw := &cache.ListWatch{
+	lw := p.SharedInformers.ListWatch(p.Namespace, &corev1.Pod{}, tweakListOptions, &cache.ListWatch{
 		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
 			return client.CoreV1().Pods(p.Namespace).List(ctx, tweakListOptions(options))
 		},
 		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
 			return client.CoreV1().Pods(p.Namespace).Watch(ctx, tweakListOptions(options))
 		},
-	}
+	})
 
 	go func() {
 		_, err := watchtools.UntilWithSync(ctx, lw, &corev1.Pod{}, nil, func(e watch.Event) (bool, error) {
@@ -88,10 +96,10 @@ func (p *PodsInformer) Run(ctx context.Context) {
 			switch e.Type {
 			case watch.Added:
 				p.PodAdded <- object
-				// case watch.Modified:
-				// 	d.resourceModified <- object
-				// case watch.Deleted:
-				// 	d.resourceDeleted <- object
+			case watch.Deleted:
+				if p.PodDeleted != nil {
+					p.PodDeleted <- object
+				}
 			}
 
 			return false, nil
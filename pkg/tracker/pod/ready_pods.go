@@ -0,0 +1,22 @@
+package pod
+
+import "fmt"
+
+// CheckRequiredReadyPods checks readiness of the controller which needs only required of its desired Pods
+// of the current revision to be ready, e.g. for canary rollouts. Returned message describes the readiness: "ready 1/5 (required 1)".
+// The requirement is not applicable when required is not positive or is not less than desired,
+// the controller should be fully ready then.
+func CheckRequiredReadyPods(podsStatuses map[string]PodStatus, newPodsNames []string, required, desired int32) (isReady bool, msg string, isApplicable bool) {
+	if required <= 0 || required >= desired {
+		return false, "", false
+	}
+
+	var readyPodsCount int32
+	for _, podName := range newPodsNames {
+		if podStatus, hasKey := podsStatuses[podName]; hasKey && podStatus.IsReady && !podStatus.IsDeleted {
+			readyPodsCount++
+		}
+	}
+
+	return readyPodsCount >= required, fmt.Sprintf("ready %d/%d (required %d)", readyPodsCount, desired, required), true
+}
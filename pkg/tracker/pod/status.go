@@ -2,6 +2,8 @@ package pod
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/werf/kubedog/pkg/tracker/indicators"
 	"github.com/werf/kubedog/pkg/utils"
@@ -20,27 +22,97 @@ type PodStatus struct {
 	ReadyContainers int32
 	TotalContainers int32
 
+	ContainersNames     []string
+	InitContainersNames []string
+
+	// NodeName is the node the Pod is scheduled to, empty until scheduled.
+	NodeName string
+	// UnschedulableMessage is the message of the scheduler (e.g. "0/12 nodes are available: 12 Insufficient cpu.")
+	// while PodScheduled condition is False with Unschedulable reason, UnschedulableSince is the transition time of the condition.
+	UnschedulableMessage string
+	UnschedulableSince   time.Time
+	// SkippedReason is set by the controller tracker for the Pod which is not awaited, e.g. "node cordoned".
+	SkippedReason string
+
+	// InitContainersDone is the number of successfully completed init containers.
+	// CurrentInitContainer is the init container the Pod initialization is waiting for, empty when the Pod is initialized,
+	// CurrentInitContainerStartedAt is zero until this init container is running. CurrentInitContainerFailure describes
+	// the failure of this init container, e.g. "CrashLoopBackOff, exit code 1", empty while it is not failing.
+	InitContainersDone            int
+	CurrentInitContainer          string
+	CurrentInitContainerStartedAt time.Time
+	CurrentInitContainerFailure   string
+
 	IsReady      bool
 	IsFailed     bool
 	IsSucceeded  bool
+	IsDeleted    bool
 	FailedReason string
 
 	ContainersErrors map[string]string
+
+	// ContainersTerminations contains details of terminated containers (including init containers) by container name,
+	// both for successfully completed and failed containers.
+	ContainersTerminations map[string]ContainerTermination
+}
+
+type ContainerTermination struct {
+	ExitCode   int32
+	StartedAt  time.Time
+	FinishedAt time.Time
 }
 
-func NewPodStatus(pod *corev1.Pod, statusGeneration uint64, trackedContainers []string, isTrackerFailed bool, trackerFailedReason string) PodStatus {
+func (t ContainerTermination) Duration() time.Duration {
+	return t.FinishedAt.Sub(t.StartedAt)
+}
+
+// DefaultContainerFailureReasons are the waiting reasons of the containers reported as ContainersErrors by default.
+var DefaultContainerFailureReasons = []string{
+	"ImagePullBackOff",
+	"ErrImagePull",
+	"InvalidImageName",
+	"CrashLoopBackOff",
+	"CreateContainerConfigError",
+	"RunContainerError",
+}
+
+// NewPodStatus returns the status of the Pod, containers waiting with one of containerFailureReasons are reported
+// as ContainersErrors, nil containerFailureReasons means DefaultContainerFailureReasons.
+func NewPodStatus(pod *corev1.Pod, statusGeneration uint64, trackedContainers []string, isTrackerFailed bool, trackerFailedReason string, containerFailureReasons []string) PodStatus {
 	res := PodStatus{
 		PodStatus:        pod.Status,
 		TotalContainers:  int32(len(pod.Spec.Containers)),
 		Age:              utils.TranslateTimestampSince(pod.CreationTimestamp),
 		StatusIndicator:  &indicators.StringEqualConditionIndicator{},
 		StatusGeneration: statusGeneration,
+		NodeName:         pod.Spec.NodeName,
+	}
+
+	for _, container := range pod.Spec.Containers {
+		res.ContainersNames = append(res.ContainersNames, container.Name)
+	}
+	for _, container := range pod.Spec.InitContainers {
+		res.InitContainersNames = append(res.InitContainersNames, container.Name)
 	}
 
 	for _, cond := range pod.Status.Conditions {
-		if cond.Type == corev1.PodReady && cond.Status == corev1.ConditionTrue {
-			res.IsReady = true
-			break
+		if cond.Type == corev1.PodScheduled && cond.Status == corev1.ConditionFalse && cond.Reason == corev1.PodReasonUnschedulable {
+			res.UnschedulableMessage = cond.Message
+			res.UnschedulableSince = cond.LastTransitionTime.Time
+			if res.UnschedulableSince.IsZero() {
+				res.UnschedulableSince = pod.CreationTimestamp.Time
+			}
+		}
+	}
+
+	// Ready condition makes sense only for Pods expected to keep running,
+	// Pods with Never and OnFailure restart policies are done when terminated.
+	if IsPodExpectedToKeepRunning(pod) {
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type == corev1.PodReady && cond.Status == corev1.ConditionTrue {
+				res.IsReady = true
+				break
+			}
 		}
 	}
 
@@ -57,6 +129,7 @@ func NewPodStatus(pod *corev1.Pod, statusGeneration uint64, trackedContainers []
 		restarts += container.RestartCount
 		switch {
 		case container.State.Terminated != nil && container.State.Terminated.ExitCode == 0:
+			res.InitContainersDone++
 			continue
 		case container.State.Terminated != nil:
 			// initialization is failed
@@ -69,12 +142,25 @@ func NewPodStatus(pod *corev1.Pod, statusGeneration uint64, trackedContainers []
 			} else {
 				reason = "Init:" + container.State.Terminated.Reason
 			}
+			res.CurrentInitContainer = container.Name
+			res.CurrentInitContainerFailure = fmt.Sprintf("exit code %d", container.State.Terminated.ExitCode)
 			initializing = true
 		case container.State.Waiting != nil && len(container.State.Waiting.Reason) > 0 && container.State.Waiting.Reason != "PodInitializing":
 			reason = "Init:" + container.State.Waiting.Reason
+			res.CurrentInitContainer = container.Name
+			if isContainerFailureReason(DefaultContainerFailureReasons, container.State.Waiting.Reason) {
+				res.CurrentInitContainerFailure = container.State.Waiting.Reason
+				if lastExitCode, hasLastExitCode := lastContainerExitCode(container); hasLastExitCode {
+					res.CurrentInitContainerFailure = fmt.Sprintf("%s, exit code %d", res.CurrentInitContainerFailure, lastExitCode)
+				}
+			}
 			initializing = true
 		default:
 			reason = fmt.Sprintf("Init:%d/%d", i, len(pod.Spec.InitContainers))
+			res.CurrentInitContainer = container.Name
+			if container.State.Running != nil {
+				res.CurrentInitContainerStartedAt = container.State.Running.StartedAt.Time
+			}
 			initializing = true
 		}
 		break
@@ -127,6 +213,9 @@ func NewPodStatus(pod *corev1.Pod, statusGeneration uint64, trackedContainers []
 		case corev1.PodFailed:
 			res.IsFailed = true
 			res.FailedReason = reason
+			if exitDetails := formatFailedContainersExitDetails(pod); exitDetails != "" {
+				res.FailedReason = fmt.Sprintf("%s: %s", reason, exitDetails)
+			}
 		}
 	}
 
@@ -135,12 +224,55 @@ func NewPodStatus(pod *corev1.Pod, statusGeneration uint64, trackedContainers []
 		res.FailedReason = trackerFailedReason
 	}
 
-	setContainersStatusesToPodStatus(&res, pod)
+	setContainersStatusesToPodStatus(&res, pod, containerFailureReasons)
+	setContainersTerminationsToPodStatus(&res, pod)
 
 	return res
 }
 
-func setContainersStatusesToPodStatus(status *PodStatus, pod *corev1.Pod) {
+// IsPodExpectedToKeepRunning returns true for Pods with Always restart policy, which are considered done when ready.
+// Pods with Never and OnFailure restart policies (bare Pods running to completion, Job Pods) are done
+// when Succeeded or Failed phase is reached.
+func IsPodExpectedToKeepRunning(pod *corev1.Pod) bool {
+	return pod.Spec.RestartPolicy == "" || pod.Spec.RestartPolicy == corev1.RestartPolicyAlways
+}
+
+func formatFailedContainersExitDetails(pod *corev1.Pod) string {
+	var details []string
+	for _, containerStatuses := range [][]corev1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+		for _, cs := range containerStatuses {
+			if cs.State.Terminated == nil || cs.State.Terminated.ExitCode == 0 {
+				continue
+			}
+
+			detail := fmt.Sprintf("%s %s exited with code %d", containerNoun(pod, cs.Name), cs.Name, cs.State.Terminated.ExitCode)
+			if cs.State.Terminated.Message != "" {
+				detail = fmt.Sprintf("%s: %s", detail, cs.State.Terminated.Message)
+			}
+			details = append(details, detail)
+		}
+	}
+
+	return strings.Join(details, ", ")
+}
+
+// NewDeletedPodStatus returns the last known status of the deleted Pod marked as terminated.
+func NewDeletedPodStatus(lastStatus PodStatus, statusGeneration uint64) PodStatus {
+	res := lastStatus
+	res.StatusGeneration = statusGeneration
+	res.StatusIndicator = &indicators.StringEqualConditionIndicator{Value: "Terminated"}
+	res.ReadyContainers = 0
+	res.IsReady = false
+	res.IsDeleted = true
+	res.ContainersErrors = nil
+	return res
+}
+
+func setContainersStatusesToPodStatus(status *PodStatus, pod *corev1.Pod, containerFailureReasons []string) {
+	if containerFailureReasons == nil {
+		containerFailureReasons = DefaultContainerFailureReasons
+	}
+
 	allContainerStatuses := make([]corev1.ContainerStatus, 0)
 	for _, cs := range pod.Status.InitContainerStatuses {
 		allContainerStatuses = append(allContainerStatuses, cs)
@@ -149,16 +281,146 @@ func setContainersStatusesToPodStatus(status *PodStatus, pod *corev1.Pod) {
 		allContainerStatuses = append(allContainerStatuses, cs)
 	}
 
-	for _, cs := range allContainerStatuses {
-		if cs.State.Waiting != nil {
-			switch cs.State.Waiting.Reason {
-			case "ImagePullBackOff", "ErrImagePull", "CrashLoopBackOff":
-				if status.ContainersErrors == nil {
-					status.ContainersErrors = make(map[string]string)
-				}
+	for i, cs := range allContainerStatuses {
+		if cs.State.Waiting == nil || !isContainerFailureReason(containerFailureReasons, cs.State.Waiting.Reason) {
+			continue
+		}
+
+		if status.ContainersErrors == nil {
+			status.ContainersErrors = make(map[string]string)
+		}
+
+		// The failing init container blocks the Pod, its exit code is the only hint before its log is read
+		if i < len(pod.Status.InitContainerStatuses) {
+			msg := fmt.Sprintf("init container %d/%d %s (image %s)", i+1, len(pod.Spec.InitContainers), cs.State.Waiting.Reason, cs.Image)
+			if lastExitCode, hasLastExitCode := lastContainerExitCode(cs); hasLastExitCode {
+				msg = fmt.Sprintf("%s, exit code %d", msg, lastExitCode)
+			}
+			status.ContainersErrors[cs.Name] = fmt.Sprintf("%s: %s", msg, cs.State.Waiting.Message)
+			continue
+		}
+
+		status.ContainersErrors[cs.Name] = fmt.Sprintf("%s (image %s): %s", cs.State.Waiting.Reason, cs.Image, cs.State.Waiting.Message)
+	}
+}
+
+// lastContainerExitCode returns the exit code of the previous instance of the restarted container.
+func lastContainerExitCode(cs corev1.ContainerStatus) (int32, bool) {
+	if cs.LastTerminationState.Terminated == nil {
+		return 0, false
+	}
+	return cs.LastTerminationState.Terminated.ExitCode, true
+}
+
+// isInitContainer returns true when containerName is the name of the init container of the Pod.
+func isInitContainer(pod *corev1.Pod, containerName string) bool {
+	for _, container := range pod.Spec.InitContainers {
+		if container.Name == containerName {
+			return true
+		}
+	}
+	return false
+}
+
+func containerNoun(pod *corev1.Pod, containerName string) string {
+	if isInitContainer(pod, containerName) {
+		return "init container"
+	}
+	return "container"
+}
+
+func isContainerFailureReason(containerFailureReasons []string, reason string) bool {
+	for _, r := range containerFailureReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+func setContainersTerminationsToPodStatus(status *PodStatus, pod *corev1.Pod) {
+	for _, containerStatuses := range [][]corev1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+		for _, cs := range containerStatuses {
+			if cs.State.Terminated == nil {
+				continue
+			}
+
+			if status.ContainersTerminations == nil {
+				status.ContainersTerminations = make(map[string]ContainerTermination)
+			}
+
+			status.ContainersTerminations[cs.Name] = ContainerTermination{
+				ExitCode:   cs.State.Terminated.ExitCode,
+				StartedAt:  cs.State.Terminated.StartedAt.Time,
+				FinishedAt: cs.State.Terminated.FinishedAt.Time,
+			}
+		}
+	}
+}
+
+// internPodStatusStrings replaces repeated strings of the status with the interned ones.
+// Slices of the embedded corev1.PodStatus are shared with the informer object, so they are copied before modification.
+func internPodStatusStrings(status *PodStatus, interner *utils.StringsInterner) {
+	if interner == nil {
+		return
+	}
+
+	status.Reason = interner.Intern(status.Reason)
+	status.Message = interner.Intern(status.Message)
+	status.HostIP = interner.Intern(status.HostIP)
+	status.NominatedNodeName = interner.Intern(status.NominatedNodeName)
+	status.FailedReason = interner.Intern(status.FailedReason)
+	status.CurrentInitContainer = interner.Intern(status.CurrentInitContainer)
+	status.CurrentInitContainerFailure = interner.Intern(status.CurrentInitContainerFailure)
+	status.UnschedulableMessage = interner.Intern(status.UnschedulableMessage)
+
+	conditions := make([]corev1.PodCondition, len(status.Conditions))
+	copy(conditions, status.Conditions)
+	for i := range conditions {
+		conditions[i].Reason = interner.Intern(conditions[i].Reason)
+		conditions[i].Message = interner.Intern(conditions[i].Message)
+	}
+	status.Conditions = conditions
 
-				status.ContainersErrors[cs.Name] = fmt.Sprintf("%s: %s", cs.State.Waiting.Reason, cs.State.Waiting.Message)
+	status.InitContainerStatuses = internContainerStatusesStrings(status.InitContainerStatuses, interner)
+	status.ContainerStatuses = internContainerStatusesStrings(status.ContainerStatuses, interner)
+
+	for i := range status.ContainersNames {
+		status.ContainersNames[i] = interner.Intern(status.ContainersNames[i])
+	}
+	for i := range status.InitContainersNames {
+		status.InitContainersNames[i] = interner.Intern(status.InitContainersNames[i])
+	}
+	for containerName, containerError := range status.ContainersErrors {
+		status.ContainersErrors[containerName] = interner.Intern(containerError)
+	}
+}
+
+func internContainerStatusesStrings(containerStatuses []corev1.ContainerStatus, interner *utils.StringsInterner) []corev1.ContainerStatus {
+	res := make([]corev1.ContainerStatus, len(containerStatuses))
+	copy(res, containerStatuses)
+
+	for i := range res {
+		cs := &res[i]
+		cs.Name = interner.Intern(cs.Name)
+		cs.Image = interner.Intern(cs.Image)
+		cs.ImageID = interner.Intern(cs.ImageID)
+
+		for _, state := range []*corev1.ContainerState{&cs.State, &cs.LastTerminationState} {
+			if state.Waiting != nil {
+				waiting := *state.Waiting
+				waiting.Reason = interner.Intern(waiting.Reason)
+				waiting.Message = interner.Intern(waiting.Message)
+				state.Waiting = &waiting
+			}
+			if state.Terminated != nil {
+				terminated := *state.Terminated
+				terminated.Reason = interner.Intern(terminated.Reason)
+				terminated.Message = interner.Intern(terminated.Message)
+				state.Terminated = &terminated
 			}
 		}
 	}
+
+	return res
 }
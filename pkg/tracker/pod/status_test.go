@@ -0,0 +1,208 @@
+package pod
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/werf/kubedog/pkg/utils"
+)
+
+func TestNewPodStatusContainersNames(t *testing.T) {
+	pod := &corev1.Pod{Spec: corev1.PodSpec{
+		InitContainers: []corev1.Container{{Name: "init-db"}},
+		Containers:     []corev1.Container{{Name: "main"}, {Name: "istio-proxy"}},
+	}}
+
+	status := NewPodStatus(pod, 1, nil, false, "", nil)
+	if !reflect.DeepEqual(status.ContainersNames, []string{"main", "istio-proxy"}) {
+		t.Errorf("unexpected containers names %q", status.ContainersNames)
+	}
+	if !reflect.DeepEqual(status.InitContainersNames, []string{"init-db"}) {
+		t.Errorf("unexpected init containers names %q", status.InitContainersNames)
+	}
+}
+
+func TestNewPodStatusTermination(t *testing.T) {
+	terminated := func(name string, exitCode int32, message string) corev1.ContainerStatus {
+		return corev1.ContainerStatus{Name: name, State: corev1.ContainerState{
+			Terminated: &corev1.ContainerStateTerminated{ExitCode: exitCode, Reason: "Error", Message: message},
+		}}
+	}
+	readyCondition := []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+
+	tests := []struct {
+		name              string
+		restartPolicy     corev1.RestartPolicy
+		phase             corev1.PodPhase
+		conditions        []corev1.PodCondition
+		containerStatuses []corev1.ContainerStatus
+		trackedContainers []string
+
+		isReady      bool
+		isSucceeded  bool
+		isFailed     bool
+		failedReason string
+	}{
+		{
+			name:       "ready Pod with default restart policy",
+			phase:      corev1.PodRunning,
+			conditions: readyCondition,
+			isReady:    true,
+		},
+		{
+			name:          "ready Pod with Always restart policy",
+			restartPolicy: corev1.RestartPolicyAlways,
+			phase:         corev1.PodRunning,
+			conditions:    readyCondition,
+			isReady:       true,
+		},
+		{
+			name:          "running Pod to completion is not done when ready",
+			restartPolicy: corev1.RestartPolicyNever,
+			phase:         corev1.PodRunning,
+			conditions:    readyCondition,
+		},
+		{
+			name:          "running Pod restarted on failure is not done when ready",
+			restartPolicy: corev1.RestartPolicyOnFailure,
+			phase:         corev1.PodRunning,
+			conditions:    readyCondition,
+		},
+		{
+			name:          "completed Pod",
+			restartPolicy: corev1.RestartPolicyNever,
+			phase:         corev1.PodSucceeded,
+			isSucceeded:   true,
+		},
+		{
+			name:              "completed Pod with tracked containers is left to containers trackers",
+			restartPolicy:     corev1.RestartPolicyNever,
+			phase:             corev1.PodSucceeded,
+			trackedContainers: []string{"main"},
+		},
+		{
+			name:              "failed Pod",
+			restartPolicy:     corev1.RestartPolicyNever,
+			phase:             corev1.PodFailed,
+			containerStatuses: []corev1.ContainerStatus{terminated("main", 1, "")},
+			isFailed:          true,
+			failedReason:      "Error: container main exited with code 1",
+		},
+		{
+			name:              "failed Pod with several failed containers",
+			restartPolicy:     corev1.RestartPolicyOnFailure,
+			phase:             corev1.PodFailed,
+			containerStatuses: []corev1.ContainerStatus{terminated("main", 2, "no such file"), terminated("sidecar", 0, ""), terminated("proxy", 137, "")},
+			isFailed:          true,
+			failedReason:      "Error: container main exited with code 2: no such file, container proxy exited with code 137",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				Spec: corev1.PodSpec{
+					RestartPolicy: tt.restartPolicy,
+					Containers:    []corev1.Container{{Name: "main"}},
+				},
+				Status: corev1.PodStatus{
+					Phase:             tt.phase,
+					Conditions:        tt.conditions,
+					ContainerStatuses: tt.containerStatuses,
+				},
+			}
+
+			status := NewPodStatus(pod, 1, tt.trackedContainers, false, "", nil)
+			if status.IsReady != tt.isReady || status.IsSucceeded != tt.isSucceeded || status.IsFailed != tt.isFailed {
+				t.Errorf("expected ready=%t succeeded=%t failed=%t, got ready=%t succeeded=%t failed=%t",
+					tt.isReady, tt.isSucceeded, tt.isFailed, status.IsReady, status.IsSucceeded, status.IsFailed)
+			}
+			if status.FailedReason != tt.failedReason {
+				t.Errorf("expected failed reason %q, got %q", tt.failedReason, status.FailedReason)
+			}
+		})
+	}
+}
+
+// newBenchmarkString returns the copy of s, which does not share memory with other copies.
+func newBenchmarkString(s string) string {
+	return string([]byte(s))
+}
+
+// newBenchmarkPod returns the Pod of the crashing Deployment replica, its strings are allocated anew as when decoded from the API.
+func newBenchmarkPod(i int) *corev1.Pod {
+	waiting := &corev1.ContainerStateWaiting{
+		Reason:  newBenchmarkString("CrashLoopBackOff"),
+		Message: fmt.Sprintf("back-off %s restarting failed container=%s pod=%s", "5m0s", "main", "web-5d9c7b"),
+	}
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("web-5d9c7b-%d", i), Namespace: "default"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: newBenchmarkString("main")}, {Name: newBenchmarkString("sidecar")}},
+		},
+		Status: corev1.PodStatus{
+			Phase:  corev1.PodRunning,
+			HostIP: fmt.Sprintf("10.0.0.%d", i%3),
+			Conditions: []corev1.PodCondition{{
+				Type: corev1.ContainersReady, Status: corev1.ConditionFalse,
+				Reason: newBenchmarkString("ContainersNotReady"), Message: fmt.Sprintf("containers with unready status: [%s]", "main"),
+			}},
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: newBenchmarkString("main"), Image: fmt.Sprintf("registry.example.com/web:%s", "v1.2.3"), State: corev1.ContainerState{Waiting: waiting}},
+				{Name: newBenchmarkString("sidecar"), Image: fmt.Sprintf("registry.example.com/proxy:%s", "v0.9.0"), Ready: true},
+			},
+		},
+	}
+}
+
+// BenchmarkPodsStatusesInterning keeps the statuses of many Pods as the trackers of their controller do
+// and reports the heap retained by the statuses with and without StringsInterner.
+func BenchmarkPodsStatusesInterning(b *testing.B) {
+	const podsCount = 1000
+
+	for _, isInterned := range []bool{false, true} {
+		b.Run(fmt.Sprintf("Interned=%v", isInterned), func(b *testing.B) {
+			b.ReportAllocs()
+
+			var retained uint64
+			for n := 0; n < b.N; n++ {
+				var interner *utils.StringsInterner
+				if isInterned {
+					interner = utils.NewStringsInterner(0)
+				}
+
+				b.StopTimer()
+				var before runtime.MemStats
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+				b.StartTimer()
+
+				statuses := make([]PodStatus, 0, podsCount)
+				for i := 0; i < podsCount; i++ {
+					status := NewPodStatus(newBenchmarkPod(i), 1, []string{"main", "sidecar"}, false, "", nil)
+					internPodStatusStrings(&status, interner)
+					statuses = append(statuses, status)
+				}
+
+				b.StopTimer()
+				var after runtime.MemStats
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				if after.HeapAlloc > before.HeapAlloc {
+					retained += after.HeapAlloc - before.HeapAlloc
+				}
+				runtime.KeepAlive(statuses)
+				runtime.KeepAlive(interner)
+				b.StartTimer()
+			}
+
+			b.ReportMetric(float64(retained)/float64(b.N)/podsCount, "retained-B/pod")
+		})
+	}
+}
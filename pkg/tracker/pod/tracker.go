@@ -6,23 +6,37 @@ import (
 	"io"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/duration"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
-	watchtools "k8s.io/client-go/tools/watch"
 
 	"github.com/werf/kubedog/pkg/display"
 	"github.com/werf/kubedog/pkg/tracker"
 	"github.com/werf/kubedog/pkg/tracker/debug"
 	"github.com/werf/kubedog/pkg/tracker/event"
+	"github.com/werf/kubedog/pkg/utils"
 )
 
+const DefaultInitContainersStuckTimeout = 5 * time.Minute
+
+const DefaultUnschedulableTimeout = 60 * time.Second
+
+// LogOutputTruncatedMessage is the last log line of the container when the log reached tracker.LogsLimits.
+const LogOutputTruncatedMessage = "… log output truncated (limit reached)"
+
+// LogStreamPausedMessage is the log line of the container when its log stream is detached by tracker.LogStreamsBudget,
+// the log continues from this point when the stream is attached again.
+const LogStreamPausedMessage = "log stream paused (stream budget)"
+
+// ContainerError is the error of the Pod container, ContainerName is empty for the errors of the Pod itself, e.g. unschedulable Pod.
 type ContainerError struct {
 	Message       string
 	ContainerName string
@@ -71,22 +85,49 @@ type Tracker struct {
 	// LastStatus contains latest known and actual resource status.
 	LastStatus PodStatus
 
-	State                           tracker.TrackerState
-	ContainerTrackerStates          map[string]tracker.TrackerState
+	State                  tracker.TrackerState
+	ContainerTrackerStates map[string]tracker.TrackerState
+	// containerTrackerStatesMux guards ContainerTrackerStates, which the containers trackers read from their goroutines.
+	containerTrackerStatesMux       sync.Mutex
 	ProcessedContainerLogTimestamps map[string]time.Time
 	TrackedContainers               []string
 	LogsFromTime                    time.Time
+	StringsInterner                 *utils.StringsInterner
+
+	// InitContainersStuckTimeout is the period without init containers progress after which the current init container
+	// is reported as a container error, 0 means DefaultInitContainersStuckTimeout, negative value disables the check.
+	InitContainersStuckTimeout time.Duration
+	// UnschedulableTimeout is the period the Pod stays unschedulable after which it is reported as a Pod error (ContainerError
+	// with empty ContainerName), 0 means DefaultUnschedulableTimeout, negative value disables the check.
+	UnschedulableTimeout time.Duration
+	// ListObserver is notified about results of the Pod informer list requests, optional.
+	ListObserver tracker.ListObserver
+	// ConnectionRetry of the Pod informer.
+	ConnectionRetry tracker.ConnectionRetry
 
 	lastObject   *corev1.Pod
 	failedReason string
 
+	initContainersProgress      string
+	initContainersProgressAt    time.Time
+	initContainersStuckReported bool
+
+	unschedulableReported bool
+
+	// containersLogStreams are cancel functions of the running containers trackers, which follow the containers logs.
+	containersLogStreams map[string]context.CancelFunc
+	// truncatedLogsRestartCounts are restart counts of the containers which logs streaming stopped by LogsLimits,
+	// the streaming is resumed when the container restarts.
+	truncatedLogsRestartCounts map[string]int32
+
 	objectAdded    chan *corev1.Pod
 	objectModified chan *corev1.Pod
 	objectDeleted  chan *corev1.Pod
 	objectFailed   chan string
 
-	containerDone chan string
-	errors        chan error
+	containerDone          chan string
+	containerLogsTruncated chan string
+	errors                 chan error
 }
 
 func NewTracker(name, namespace string, kube kubernetes.Interface) *Tracker {
@@ -94,7 +135,7 @@ func NewTracker(name, namespace string, kube kubernetes.Interface) *Tracker {
 		Tracker: tracker.Tracker{
 			Kube:             kube,
 			Namespace:        namespace,
-			FullResourceName: fmt.Sprintf("po/%s", name),
+			FullResourceName: tracker.FormatResourceID("po", namespace, name),
 			ResourceName:     name,
 		},
 
@@ -113,6 +154,8 @@ func NewTracker(name, namespace string, kube kubernetes.Interface) *Tracker {
 		ContainerTrackerStates:          make(map[string]tracker.TrackerState),
 		ProcessedContainerLogTimestamps: make(map[string]time.Time),
 		LogsFromTime:                    time.Time{},
+		containersLogStreams:            make(map[string]context.CancelFunc),
+		truncatedLogsRestartCounts:      make(map[string]int32),
 
 		objectAdded:    make(chan *corev1.Pod, 0),
 		objectModified: make(chan *corev1.Pod, 0),
@@ -120,6 +163,8 @@ func NewTracker(name, namespace string, kube kubernetes.Interface) *Tracker {
 		objectFailed:   make(chan string, 1),
 		errors:         make(chan error, 0),
 		containerDone:  make(chan string, 10),
+
+		containerLogsTruncated: make(chan string, 10),
 	}
 }
 
@@ -129,8 +174,15 @@ func (pod *Tracker) Start(ctx context.Context) error {
 		return err
 	}
 
+	initContainersTicker := time.NewTicker(time.Second)
+	defer initContainersTicker.Stop()
+
 	for {
 		select {
+		case <-initContainersTicker.C:
+			pod.checkInitContainersStuck()
+			pod.checkUnschedulable()
+
 		case object := <-pod.objectAdded:
 			if err := pod.handlePodState(ctx, object); err != nil {
 				return err
@@ -142,20 +194,23 @@ func (pod *Tracker) Start(ctx context.Context) error {
 			}
 
 		case <-pod.objectDeleted:
+			// Logs streams of the deleted Pod (e.g. force-deleted with grace period 0) are not guaranteed
+			// to be closed by the API server, so they are closed right away
+			pod.closeContainersLogStreams()
+			pod.truncatedLogsRestartCounts = make(map[string]int32)
+
 			pod.State = tracker.ResourceDeleted
 			pod.lastObject = nil
-			pod.ContainerTrackerStates = make(map[string]tracker.TrackerState)
 			pod.ProcessedContainerLogTimestamps = make(map[string]time.Time)
-			status := PodStatus{}
+			pod.StatusGeneration++
+			status := NewDeletedPodStatus(pod.LastStatus, pod.StatusGeneration)
 			pod.LastStatus = status
 
-			keys := []string{}
+			pod.containerTrackerStatesMux.Lock()
 			for k := range pod.ContainerTrackerStates {
-				keys = append(keys, k)
-			}
-			for _, k := range keys {
 				pod.ContainerTrackerStates[k] = tracker.ContainerTrackerDone
 			}
+			pod.containerTrackerStatesMux.Unlock()
 
 			pod.Deleted <- status
 
@@ -166,7 +221,8 @@ func (pod *Tracker) Start(ctx context.Context) error {
 			var status PodStatus
 			if pod.lastObject != nil {
 				pod.StatusGeneration++
-				status = NewPodStatus(pod.lastObject, pod.StatusGeneration, pod.TrackedContainers, pod.State == tracker.ResourceFailed, pod.failedReason)
+				status = NewPodStatus(pod.lastObject, pod.StatusGeneration, pod.TrackedContainers, pod.State == tracker.ResourceFailed, pod.failedReason, pod.ContainerFailureReasons)
+				internPodStatusStrings(&status, pod.StringsInterner)
 			} else {
 				status = PodStatus{IsFailed: true, FailedReason: reason}
 			}
@@ -174,7 +230,19 @@ func (pod *Tracker) Start(ctx context.Context) error {
 			pod.LastStatus = status
 			pod.Failed <- FailedReport{PodStatus: status, FailedReason: reason}
 
+		case containerName := <-pod.containerLogsTruncated:
+			var restartCount int32
+			if cs := findContainerStatus(pod.lastObject, containerName); cs != nil {
+				restartCount = cs.RestartCount
+			}
+			pod.truncatedLogsRestartCounts[containerName] = restartCount
+
 		case containerName := <-pod.containerDone:
+			if cancel, hasKey := pod.containersLogStreams[containerName]; hasKey {
+				cancel()
+				delete(pod.containersLogStreams, containerName)
+			}
+
 			trackedContainers := make([]string, 0)
 			for _, name := range pod.TrackedContainers {
 				if name != containerName {
@@ -201,17 +269,47 @@ func (pod *Tracker) Start(ctx context.Context) error {
 
 }
 
+// suppressPodConditions returns the shallow copy of the object without the status conditions of the suppressed types,
+// the object of the informer cache is not modified.
+func suppressPodConditions(object *corev1.Pod, suppressions *tracker.Suppressions) *corev1.Pod {
+	if !suppressions.HasConditionTypes() {
+		return object
+	}
+
+	res := *object
+	res.Status.Conditions = nil
+	for _, cond := range object.Status.Conditions {
+		if !suppressions.IsConditionSuppressed(string(cond.Type)) {
+			res.Status.Conditions = append(res.Status.Conditions, cond)
+		}
+	}
+
+	return &res
+}
+
 func (pod *Tracker) handlePodState(ctx context.Context, object *corev1.Pod) error {
+	object = suppressPodConditions(object, pod.Suppressions)
 	pod.lastObject = object
 	pod.StatusGeneration++
 
-	status := NewPodStatus(object, pod.StatusGeneration, pod.TrackedContainers, pod.State == tracker.ResourceFailed, pod.failedReason)
+	status := NewPodStatus(object, pod.StatusGeneration, pod.TrackedContainers, pod.State == tracker.ResourceFailed, pod.failedReason, pod.ContainerFailureReasons)
+	internPodStatusStrings(&status, pod.StringsInterner)
 	pod.LastStatus = status
 
+	// The reason of the Pod failed by its phase is computed only until its containers are tracked,
+	// the following statuses of the failed Pod keep this reason
+	if status.IsFailed && status.FailedReason != "" {
+		pod.failedReason = status.FailedReason
+	}
+
 	if err := pod.handleContainersState(object); err != nil {
 		return fmt.Errorf("unable to handle pod containers state: %s", err)
 	}
 
+	pod.handleInitContainersProgress(object)
+	pod.resumeTruncatedContainersLogs(ctx, object)
+	pod.prioritizeContainersLogStreams(object, status)
+
 	for containerName, msg := range status.ContainersErrors {
 		pod.ContainerError <- ContainerErrorReport{
 			ContainerError: ContainerError{
@@ -288,6 +386,9 @@ func (pod *Tracker) handlePodState(ctx context.Context, object *corev1.Pod) erro
 }
 
 func (pod *Tracker) handleContainersState(object *corev1.Pod) error {
+	pod.containerTrackerStatesMux.Lock()
+	defer pod.containerTrackerStatesMux.Unlock()
+
 	allContainerStatuses := make([]corev1.ContainerStatus, 0)
 	for _, cs := range object.Status.InitContainerStatuses {
 		allContainerStatuses = append(allContainerStatuses, cs)
@@ -310,33 +411,222 @@ func (pod *Tracker) handleContainersState(object *corev1.Pod) error {
 		}
 	}
 
+	// Containers which have not been started until the Pod is terminated will never start
+	// (e.g. main containers after failed init container), so stop waiting for them.
+	if object.Status.Phase == corev1.PodSucceeded || object.Status.Phase == corev1.PodFailed {
+		for containerName, state := range pod.ContainerTrackerStates {
+			if state == tracker.Initial {
+				pod.ContainerTrackerStates[containerName] = tracker.ContainerTrackerDone
+			}
+		}
+	}
+
 	return nil
 }
 
+// handleInitContainersProgress remembers when init containers made progress last time,
+// which is any state transition of any init container.
+func (pod *Tracker) handleInitContainersProgress(object *corev1.Pod) {
+	var parts []string
+	for _, cs := range object.Status.InitContainerStatuses {
+		state := "waiting"
+		if cs.State.Running != nil {
+			state = "running"
+		} else if cs.State.Terminated != nil {
+			state = "terminated"
+		}
+		parts = append(parts, fmt.Sprintf("%s:%s:%d", cs.Name, state, cs.RestartCount))
+	}
+	progress := strings.Join(parts, ",")
+
+	if pod.initContainersProgressAt.IsZero() || progress != pod.initContainersProgress {
+		pod.initContainersProgress = progress
+		pod.initContainersProgressAt = time.Now()
+		pod.initContainersStuckReported = false
+	}
+}
+
+// checkInitContainersStuck reports a container error once when the Pod initialization
+// has not made any progress during InitContainersStuckTimeout.
+func (pod *Tracker) checkInitContainersStuck() {
+	timeout := pod.InitContainersStuckTimeout
+	if timeout == 0 {
+		timeout = DefaultInitContainersStuckTimeout
+	}
+
+	status := pod.LastStatus
+	if timeout < 0 || pod.lastObject == nil || pod.initContainersStuckReported || status.CurrentInitContainer == "" || status.IsFailed {
+		return
+	}
+
+	if time.Since(pod.initContainersProgressAt) < timeout {
+		return
+	}
+
+	pod.initContainersStuckReported = true
+
+	pod.ContainerError <- ContainerErrorReport{
+		ContainerError: ContainerError{
+			ContainerName: status.CurrentInitContainer,
+			Message:       fmt.Sprintf("init container %s has not completed after %s", status.CurrentInitContainer, duration.HumanDuration(timeout)),
+		},
+		PodStatus: status,
+	}
+}
+
+// checkUnschedulable reports a Pod error once when the Pod has been unschedulable during UnschedulableTimeout,
+// the Pod is reported again if it becomes unschedulable after being scheduled.
+func (pod *Tracker) checkUnschedulable() {
+	timeout := pod.UnschedulableTimeout
+	if timeout == 0 {
+		timeout = DefaultUnschedulableTimeout
+	}
+
+	status := pod.LastStatus
+	if status.UnschedulableMessage == "" {
+		pod.unschedulableReported = false
+		return
+	}
+
+	if timeout < 0 || pod.unschedulableReported || status.IsFailed || time.Since(status.UnschedulableSince) < timeout {
+		return
+	}
+
+	pod.unschedulableReported = true
+
+	pod.ContainerError <- ContainerErrorReport{
+		ContainerError: ContainerError{
+			Message: fmt.Sprintf("unschedulable for %s: %s", duration.HumanDuration(time.Since(status.UnschedulableSince)), status.UnschedulableMessage),
+		},
+		PodStatus: status,
+	}
+}
+
+// followContainerLogs streams the container log within tracker.LogStreamsBudget. The stream detached in favour
+// of the streams with a higher priority is attached again from the last received line once there is a free slot.
 func (pod *Tracker) followContainerLogs(ctx context.Context, containerName string) error {
 	logOpts := &corev1.PodLogOptions{
 		Container:  containerName,
 		Timestamps: true,
 		Follow:     true,
+		TailLines:  pod.LogsLimits.TailLines,
 	}
 	if !pod.LogsFromTime.IsZero() {
 		logOpts.SinceTime = &metav1.Time{
 			Time: pod.LogsFromTime,
 		}
 	}
+
+	budgetKey := pod.logStreamKey(containerName)
+	defer pod.LogStreamsBudget.Forget(budgetKey)
+
+	// Limits are counted by the stream, so the logs of the restarted container are limited anew
+	stream := &containerLogStream{}
+
+	for {
+		slot, ok := pod.LogStreamsBudget.Acquire(ctx, budgetKey)
+		if !ok {
+			return nil
+		}
+
+		paused, err := pod.streamContainerLogs(ctx, containerName, logOpts, slot, stream)
+		slot.Release()
+		if err != nil || !paused {
+			return err
+		}
+
+		pausedLine := display.LogLine{Timestamp: time.Now().UTC().Format(time.RFC3339Nano), Message: LogStreamPausedMessage}
+		select {
+		case pod.ContainerLogChunk <- &ContainerLogChunk{ContainerName: containerName, LogLines: []display.LogLine{pausedLine}}:
+		case <-ctx.Done():
+			return nil
+		}
+
+		// SinceTime has seconds precision, the lines already shown are skipped by timestamps
+		if !stream.lastLineTime.IsZero() {
+			logOpts.TailLines = nil
+			logOpts.SinceTime = &metav1.Time{Time: stream.lastLineTime}
+			stream.skipUntil = stream.lastLineTime
+		}
+	}
+}
+
+// containerLogStream is the progress of the container log streaming kept between the stream reattachments.
+type containerLogStream struct {
+	linesCount   int
+	bytesCount   int64
+	lastLineTime time.Time
+	// skipUntil drops the lines which were already shown before the stream was detached
+	skipUntil time.Time
+}
+
+func (s *containerLogStream) isLimitReached(limits tracker.LogsLimits, logLine display.LogLine) bool {
+	s.linesCount++
+	s.bytesCount += int64(len(logLine.Message)) + 1
+	return (limits.MaxLines > 0 && s.linesCount > limits.MaxLines) ||
+		(limits.MaxBytes > 0 && s.bytesCount > limits.MaxBytes)
+}
+
+// observe returns false for the line which was already shown.
+func (s *containerLogStream) observe(logLine display.LogLine) bool {
+	lineTime, err := time.Parse(time.RFC3339Nano, logLine.Timestamp)
+	if err != nil {
+		return true
+	}
+
+	if !s.skipUntil.IsZero() {
+		if !lineTime.After(s.skipUntil) {
+			return false
+		}
+		s.skipUntil = time.Time{}
+	}
+
+	s.lastLineTime = lineTime
+	return true
+}
+
+// streamContainerLogs returns true when the stream is detached because the budget slot is revoked.
+func (pod *Tracker) streamContainerLogs(ctx context.Context, containerName string, logOpts *corev1.PodLogOptions, slot *tracker.LogStreamSlot, stream *containerLogStream) (bool, error) {
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		select {
+		case <-slot.Revoked():
+			cancel()
+		case <-streamCtx.Done():
+		}
+	}()
+
+	isPaused := func() bool {
+		return ctx.Err() == nil && streamCtx.Err() != nil
+	}
+
 	req := pod.Kube.CoreV1().
 		Pods(pod.Namespace).
 		GetLogs(pod.ResourceName, logOpts)
 
-	readCloser, err := req.Stream(ctx)
+	readCloser, err := req.Stream(streamCtx)
 	if err != nil {
-		return err
+		if streamCtx.Err() != nil {
+			return isPaused(), nil
+		}
+		return false, err
 	}
 	defer readCloser.Close()
 
 	chunkBuf := make([]byte, 1024*64)
 	lineBuf := make([]byte, 0, 1024*4)
 
+	sendChunk := func(logLines []display.LogLine) bool {
+		select {
+		case pod.ContainerLogChunk <- &ContainerLogChunk{ContainerName: containerName, LogLines: logLines}:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
 	for {
 		n, err := readCloser.Read(chunkBuf)
 
@@ -346,13 +636,13 @@ func (pod *Tracker) followContainerLogs(ctx context.Context, containerName strin
 				bt := chunkBuf[i]
 
 				if bt == '\n' {
-					line := string(lineBuf)
-					lineBuf = lineBuf[:0]
-
-					lineParts := strings.SplitN(line, " ", 2)
-					if len(lineParts) == 2 {
-						chunkLines = append(chunkLines, display.LogLine{Timestamp: lineParts[0], Message: lineParts[1]})
+					if logLine, ok := parseLogLine(lineBuf); ok && stream.observe(logLine) {
+						if stream.isLimitReached(pod.LogsLimits, logLine) {
+							return false, pod.truncateContainerLogs(ctx, containerName, chunkLines, logLine.Timestamp)
+						}
+						chunkLines = append(chunkLines, logLine)
 					}
+					lineBuf = lineBuf[:0]
 
 					continue
 				}
@@ -360,30 +650,72 @@ func (pod *Tracker) followContainerLogs(ctx context.Context, containerName strin
 				lineBuf = append(lineBuf, bt)
 			}
 
-			pod.ContainerLogChunk <- &ContainerLogChunk{
-				ContainerName: containerName,
-				LogLines:      chunkLines,
+			if !sendChunk(chunkLines) {
+				return false, nil
 			}
 		}
 
 		if err == io.EOF {
+			// The last line of the finished stream may have no trailing newline
+			if logLine, ok := parseLogLine(lineBuf); ok && stream.observe(logLine) {
+				if stream.isLimitReached(pod.LogsLimits, logLine) {
+					return false, pod.truncateContainerLogs(ctx, containerName, nil, logLine.Timestamp)
+				}
+				sendChunk([]display.LogLine{logLine})
+			}
 			break
 		}
 
+		// The stream closed because of cancellation fails with errors like "http2: response body closed", which are expected
+		if err != nil && streamCtx.Err() != nil {
+			return isPaused(), nil
+		}
 		if err != nil {
-			return err
+			return false, err
 		}
 
 		select {
-		case <-ctx.Done():
-			return ctx.Err()
+		case <-streamCtx.Done():
+			if isPaused() {
+				return true, nil
+			}
+			return false, ctx.Err()
 		default:
 		}
 	}
 
+	return false, nil
+}
+
+// truncateContainerLogs sends the last lines of the container log which fit into LogsLimits followed by LogOutputTruncatedMessage,
+// the caller stops streaming the log.
+func (pod *Tracker) truncateContainerLogs(ctx context.Context, containerName string, logLines []display.LogLine, timestamp string) error {
+	logLines = append(logLines, display.LogLine{Timestamp: timestamp, Message: LogOutputTruncatedMessage})
+
+	select {
+	case pod.ContainerLogChunk <- &ContainerLogChunk{ContainerName: containerName, LogLines: logLines}:
+	case <-ctx.Done():
+		return nil
+	}
+
+	select {
+	case pod.containerLogsTruncated <- containerName:
+	case <-ctx.Done():
+	}
+
 	return nil
 }
 
+// parseLogLine splits the line of the logs stream requested with timestamps into the timestamp and the message.
+// Lines are split by bytes, so multibyte characters split between reads of the stream are kept intact.
+func parseLogLine(line []byte) (display.LogLine, bool) {
+	lineParts := strings.SplitN(string(line), " ", 2)
+	if len(lineParts) != 2 {
+		return display.LogLine{}, false
+	}
+	return display.LogLine{Timestamp: lineParts[0], Message: lineParts[1]}, true
+}
+
 func (pod *Tracker) trackContainer(ctx context.Context, containerName string) error {
 	ticker := time.NewTicker(200 * time.Millisecond)
 	defer ticker.Stop()
@@ -391,7 +723,9 @@ func (pod *Tracker) trackContainer(ctx context.Context, containerName string) er
 	for {
 		select {
 		case <-ticker.C:
-			state := pod.ContainerTrackerStates[containerName] // PANIC HERE
+			pod.containerTrackerStatesMux.Lock()
+			state := pod.ContainerTrackerStates[containerName]
+			pod.containerTrackerStatesMux.Unlock()
 
 			switch state {
 			case tracker.FollowingContainerLogs:
@@ -426,28 +760,109 @@ func (pod *Tracker) runContainersTrackers(ctx context.Context, object *corev1.Po
 	for i := range allContainersNames {
 		containerName := allContainersNames[i]
 
+		pod.containerTrackerStatesMux.Lock()
 		pod.ContainerTrackerStates[containerName] = tracker.Initial
-		pod.TrackedContainers = append(pod.TrackedContainers, containerName)
+		pod.containerTrackerStatesMux.Unlock()
+		pod.runContainerTracker(ctx, containerName)
+	}
 
-		newCtx, _ := context.WithCancel(ctx)
+	return nil
+}
 
-		go func(ctx context.Context) {
-			if debug.Debug() {
-				fmt.Printf("Starting to track Pod's `%s` container `%s`\n", pod.ResourceName, containerName)
+func (pod *Tracker) runContainerTracker(ctx context.Context, containerName string) {
+	pod.TrackedContainers = append(pod.TrackedContainers, containerName)
+
+	containerCtx, cancel := context.WithCancel(ctx)
+	pod.containersLogStreams[containerName] = cancel
+
+	go func() {
+		if debug.Debug() {
+			fmt.Printf("Starting to track Pod's `%s` container `%s`\n", pod.ResourceName, containerName)
+		}
+
+		if err := pod.trackContainer(containerCtx, containerName); err != nil && containerCtx.Err() == nil {
+			select {
+			case pod.errors <- err:
+			case <-containerCtx.Done():
 			}
+		}
+
+		if debug.Debug() {
+			fmt.Printf("Done tracking Pod's `%s` container `%s`\n", pod.ResourceName, containerName)
+		}
+
+		select {
+		case pod.containerDone <- containerName:
+		case <-ctx.Done():
+		}
+	}()
+}
+
+func (pod *Tracker) logStreamKey(containerName string) string {
+	return fmt.Sprintf("%s/%s/%s", pod.Namespace, pod.ResourceName, containerName)
+}
 
-			if err := pod.trackContainer(ctx, containerName); err != nil {
-				pod.errors <- err
+// prioritizeContainersLogStreams updates priorities of the containers log streams in tracker.LogStreamsBudget:
+// streams of the failing containers go first, then streams of the recently started containers.
+func (pod *Tracker) prioritizeContainersLogStreams(object *corev1.Pod, status PodStatus) {
+	if pod.LogStreamsBudget == nil {
+		return
+	}
+
+	for _, statuses := range [][]corev1.ContainerStatus{object.Status.InitContainerStatuses, object.Status.ContainerStatuses} {
+		for _, cs := range statuses {
+			var priority tracker.LogStreamPriority
+
+			_, priority.Failing = status.ContainersErrors[cs.Name]
+			if cs.State.Terminated != nil && cs.State.Terminated.ExitCode != 0 {
+				priority.Failing = true
+			}
+			if cs.LastTerminationState.Terminated != nil && cs.LastTerminationState.Terminated.ExitCode != 0 {
+				priority.Failing = true
 			}
 
-			if debug.Debug() {
-				fmt.Printf("Done tracking Pod's `%s` container `%s`\n", pod.ResourceName, containerName)
+			switch {
+			case cs.State.Running != nil:
+				priority.StartedAt = cs.State.Running.StartedAt.Time
+			case cs.State.Terminated != nil:
+				priority.StartedAt = cs.State.Terminated.StartedAt.Time
 			}
 
-			pod.containerDone <- containerName
-		}(newCtx)
+			pod.LogStreamsBudget.SetPriority(pod.logStreamKey(cs.Name), priority)
+		}
 	}
+}
+
+// resumeTruncatedContainersLogs restarts the trackers of the restarted containers which logs were truncated by LogsLimits,
+// the tracker is restarted after the previous one is done.
+func (pod *Tracker) resumeTruncatedContainersLogs(ctx context.Context, object *corev1.Pod) {
+	for containerName, restartCount := range pod.truncatedLogsRestartCounts {
+		if _, hasKey := pod.containersLogStreams[containerName]; hasKey {
+			continue
+		}
+		// The log of the waiting container, e.g. in CrashLoopBackOff, cannot be streamed yet
+		cs := findContainerStatus(object, containerName)
+		if cs == nil || cs.RestartCount <= restartCount || (cs.State.Running == nil && cs.State.Terminated == nil) {
+			continue
+		}
 
+		delete(pod.truncatedLogsRestartCounts, containerName)
+		pod.runContainerTracker(ctx, containerName)
+	}
+}
+
+func findContainerStatus(object *corev1.Pod, containerName string) *corev1.ContainerStatus {
+	if object == nil {
+		return nil
+	}
+
+	for _, statuses := range [][]corev1.ContainerStatus{object.Status.InitContainerStatuses, object.Status.ContainerStatuses} {
+		for i := range statuses {
+			if statuses[i].Name == containerName {
+				return &statuses[i]
+			}
+		}
+	}
 	return nil
 }
 
@@ -456,17 +871,18 @@ func (pod *Tracker) runInformer(ctx context.Context) error {
 		options.FieldSelector = fields.OneTermEqualSelector("metadata.name", pod.ResourceName).String()
 		return options
 	}
-	lw := &cache.ListWatch{
+	lw := pod.SharedInformers.ListWatch(pod.Namespace, &corev1.Pod{}, tweakListOptions, &cache.ListWatch{
 		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
 			return pod.Kube.CoreV1().Pods(pod.Namespace).List(ctx, tweakListOptions(options))
 		},
 		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
 			return pod.Kube.CoreV1().Pods(pod.Namespace).Watch(ctx, tweakListOptions(options))
 		},
-	}
+	})
+	lw.ListFunc = tracker.ObserveList(lw.ListFunc, pod.ListObserver)
 
 	go func() {
-		_, err := watchtools.UntilWithSync(ctx, lw, &corev1.Pod{}, nil, func(e watch.Event) (bool, error) {
+		_, err := tracker.UntilWithSync(ctx, lw, &corev1.Pod{}, pod.ConnectionRetry, func(e watch.Event) (bool, error) {
 			if debug.Debug() {
 				fmt.Printf("Pod `%s` informer event: %#v\n", pod.ResourceName, e.Type)
 			}
@@ -495,7 +911,10 @@ func (pod *Tracker) runInformer(ctx context.Context) error {
 		})
 
 		if err := tracker.AdaptInformerError(err); err != nil {
-			pod.errors <- fmt.Errorf("pod/%s informer error: %s", pod.ResourceName, err)
+			select {
+			case pod.errors <- fmt.Errorf("pod/%s informer error: %w", pod.ResourceName, err):
+			case <-ctx.Done():
+			}
 		}
 
 		if debug.Debug() {
@@ -506,6 +925,18 @@ func (pod *Tracker) runInformer(ctx context.Context) error {
 	return nil
 }
 
+// closeContainersLogStreams stops all containers trackers, their logs streams are closed.
+func (pod *Tracker) closeContainersLogStreams() {
+	for containerName, cancel := range pod.containersLogStreams {
+		if debug.Debug() {
+			fmt.Printf("Closing Pod's `%s` container `%s` logs stream\n", pod.ResourceName, containerName)
+		}
+
+		cancel()
+		delete(pod.containersLogStreams, containerName)
+	}
+}
+
 // runEventsInformer watch for DaemonSet events
 func (pod *Tracker) runEventsInformer(ctx context.Context) {
 	eventInformer := event.NewEventInformer(&pod.Tracker, pod.lastObject)
@@ -0,0 +1,239 @@
+package pod
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// testLogsAPIServer serves the single running Pod with two containers, its logs streams are kept open
+// until the client closes them. The watch of the Pod sends the Modified event, which starts the logs streaming,
+// and the Deleted event once deleted is closed.
+type testLogsAPIServer struct {
+	*httptest.Server
+
+	pod     *corev1.Pod
+	deleted chan struct{}
+
+	mux           sync.Mutex
+	openedStreams map[string]bool
+	closedStreams map[string]bool
+}
+
+func newTestLogsAPIServer(t *testing.T) *testLogsAPIServer {
+	s := &testLogsAPIServer{
+		pod: &corev1.Pod{
+			TypeMeta:   metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test", UID: "app-uid", ResourceVersion: "1"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "main"}, {Name: "sidecar"}}},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{Name: "main", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+					{Name: "sidecar", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+				},
+			},
+		},
+		deleted:       make(chan struct{}),
+		openedStreams: make(map[string]bool),
+		closedStreams: make(map[string]bool),
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		isWatch := r.URL.Query().Get("watch") == "true"
+
+		switch {
+		case r.URL.Path == "/api/v1/namespaces/test/pods/app/log":
+			s.streamLogs(w, r)
+		case r.URL.Path == "/api/v1/namespaces/test/pods" && isWatch:
+			s.watchPods(w, r)
+		case r.URL.Path == "/api/v1/namespaces/test/pods":
+			writeTestJSON(t, w, &corev1.PodList{
+				TypeMeta: metav1.TypeMeta{Kind: "PodList", APIVersion: "v1"},
+				ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+				Items:    []corev1.Pod{*s.pod},
+			})
+		case r.URL.Path == "/api/v1/namespaces/test/events" && isWatch:
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		case r.URL.Path == "/api/v1/namespaces/test/events":
+			writeTestJSON(t, w, &corev1.EventList{
+				TypeMeta: metav1.TypeMeta{Kind: "EventList", APIVersion: "v1"},
+				ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	return s
+}
+
+func writeTestJSON(t *testing.T, w http.ResponseWriter, obj interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(obj); err != nil {
+		t.Errorf("unable to encode response: %s", err)
+	}
+}
+
+func (s *testLogsAPIServer) streamLogs(w http.ResponseWriter, r *http.Request) {
+	container := r.URL.Query().Get("container")
+
+	s.mux.Lock()
+	s.openedStreams[container] = true
+	s.mux.Unlock()
+
+	defer func() {
+		s.mux.Lock()
+		s.closedStreams[container] = true
+		s.mux.Unlock()
+	}()
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(time.Now().UTC().Format(time.RFC3339Nano) + " started\n"))
+	w.(http.Flusher).Flush()
+
+	<-r.Context().Done()
+}
+
+func (s *testLogsAPIServer) watchPods(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	sendEvent := func(eventType, resourceVersion string) {
+		pod := s.pod.DeepCopy()
+		pod.ResourceVersion = resourceVersion
+		json.NewEncoder(w).Encode(map[string]interface{}{"type": eventType, "object": pod})
+		w.(http.Flusher).Flush()
+	}
+
+	sendEvent("MODIFIED", "2")
+
+	select {
+	case <-s.deleted:
+		sendEvent("DELETED", "3")
+	case <-r.Context().Done():
+		return
+	}
+
+	<-r.Context().Done()
+}
+
+func (s *testLogsAPIServer) streams() (opened, closed int) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return len(s.openedStreams), len(s.closedStreams)
+}
+
+// testTrackerGoroutines returns the stacks of the goroutines running in the functions with the prefix.
+func testTrackerGoroutines(funcPrefix string) []string {
+	buf := make([]byte, 1<<20)
+	buf = buf[:runtime.Stack(buf, true)]
+
+	var res []string
+	for _, stack := range strings.Split(string(buf), "\n\n") {
+		if strings.Contains(stack, funcPrefix) {
+			res = append(res, stack)
+		}
+	}
+	return res
+}
+
+func waitForTestTracker(t *testing.T, desc string, isDone func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for !isDone() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", desc)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestTrackerDeletedPodLogStreams deletes the Pod while the logs of its containers are streamed:
+// all logs streams are closed on the Deleted event and the containers trackers are done, not waiting for the tracking to stop.
+func TestTrackerDeletedPodLogStreams(t *testing.T) {
+	server := newTestLogsAPIServer(t)
+	defer server.Close()
+
+	kube, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatalf("unable to create clientset: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pod := NewTracker("app", "test", kube)
+
+	done := make(chan error, 1)
+	go func() { done <- pod.Start(ctx) }()
+
+	isDeleted := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-pod.Added:
+			case <-pod.Ready:
+			case <-pod.Succeeded:
+			case <-pod.Failed:
+			case <-pod.Status:
+			case <-pod.EventMsg:
+			case <-pod.ContainerError:
+			case <-pod.ContainerLogChunk:
+			case <-pod.Deleted:
+				close(isDeleted)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	waitForTestTracker(t, "logs streams of both containers", func() bool {
+		opened, _ := server.streams()
+		return opened == 2
+	})
+
+	close(server.deleted)
+
+	select {
+	case <-isDeleted:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("timed out waiting for the Deleted status")
+	}
+
+	waitForTestTracker(t, "logs streams closed", func() bool {
+		_, closed := server.streams()
+		return closed == 2
+	})
+	waitForTestTracker(t, "containers trackers done", func() bool {
+		return len(testTrackerGoroutines("pod.(*Tracker).trackContainer")) == 0 &&
+			len(testTrackerGoroutines("pod.(*Tracker).runContainerTracker")) == 0
+	})
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("timed out waiting for the tracker to stop")
+	}
+
+	waitForTestTracker(t, "tracker goroutines done", func() bool {
+		return len(testTrackerGoroutines("kubedog/pkg/tracker/pod.(*Tracker)")) == 0
+	})
+}
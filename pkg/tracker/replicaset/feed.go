@@ -0,0 +1,262 @@
+package replicaset
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/werf/kubedog/pkg/tracker"
+	"github.com/werf/kubedog/pkg/tracker/debug"
+	"k8s.io/client-go/kubernetes"
+
+	watchtools "k8s.io/client-go/tools/watch"
+)
+
+// Feed has the same callbacks as controller.ControllerFeed except OnAddedReplicaSet,
+// the controller package cannot be imported here because it depends on this package.
+type Feed interface {
+	OnAdded(func(ready bool) error)
+	OnReady(func() error)
+	OnFailed(func(reason string) error)
+	OnDeleted(func() error)
+	OnEventMsg(func(msg string) error)
+	OnAddedPod(func(ReplicaSetPod) error)
+	OnPodLogChunk(func(*ReplicaSetPodLogChunk) error)
+	OnPodError(func(ReplicaSetPodError) error)
+	OnStatus(func(ReplicaSetStatus) error)
+
+	GetStatus() ReplicaSetStatus
+	Track(name, namespace string, kube kubernetes.Interface, opts tracker.Options) error
+}
+
+func NewFeed() Feed {
+	return &feed{}
+}
+
+type feed struct {
+	OnAddedFunc       func(bool) error
+	OnReadyFunc       func() error
+	OnFailedFunc      func(reason string) error
+	OnDeletedFunc     func() error
+	OnEventMsgFunc    func(msg string) error
+	OnAddedPodFunc    func(ReplicaSetPod) error
+	OnPodLogChunkFunc func(*ReplicaSetPodLogChunk) error
+	OnPodErrorFunc    func(ReplicaSetPodError) error
+	OnStatusFunc      func(ReplicaSetStatus) error
+
+	statusMux sync.Mutex
+	status    ReplicaSetStatus
+}
+
+func (f *feed) OnAdded(function func(bool) error) {
+	f.OnAddedFunc = function
+}
+func (f *feed) OnReady(function func() error) {
+	f.OnReadyFunc = function
+}
+func (f *feed) OnFailed(function func(string) error) {
+	f.OnFailedFunc = function
+}
+func (f *feed) OnDeleted(function func() error) {
+	f.OnDeletedFunc = function
+}
+func (f *feed) OnEventMsg(function func(string) error) {
+	f.OnEventMsgFunc = function
+}
+func (f *feed) OnAddedPod(function func(ReplicaSetPod) error) {
+	f.OnAddedPodFunc = function
+}
+func (f *feed) OnPodLogChunk(function func(*ReplicaSetPodLogChunk) error) {
+	f.OnPodLogChunkFunc = function
+}
+func (f *feed) OnPodError(function func(ReplicaSetPodError) error) {
+	f.OnPodErrorFunc = function
+}
+func (f *feed) OnStatus(function func(ReplicaSetStatus) error) {
+	f.OnStatusFunc = function
+}
+
+func (f *feed) Track(name, namespace string, kube kubernetes.Interface, opts tracker.Options) error {
+	errorChan := make(chan error, 0)
+	doneChan := make(chan bool, 0)
+
+	parentContext := opts.ParentContext
+	if parentContext == nil {
+		parentContext = context.Background()
+	}
+	ctx, cancel := watchtools.ContextWithOptionalTimeout(parentContext, opts.Timeout)
+	defer cancel()
+
+	replicaSetTracker := NewTracker(name, namespace, kube, opts)
+
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				errorChan <- tracker.NewPanicError(recovered, opts.PanicsAreFatal)
+			}
+		}()
+
+		if debug.Debug() {
+			fmt.Printf("  goroutine: start rs/%s tracker\n", name)
+		}
+		err := replicaSetTracker.Track(ctx)
+		if err != nil {
+			errorChan <- err
+		} else {
+			doneChan <- true
+		}
+	}()
+
+	if debug.Debug() {
+		fmt.Printf("  rs/%s: for-select ReplicaSetTracker channels\n", name)
+	}
+
+	for {
+		select {
+		case status := <-replicaSetTracker.Added:
+			f.setStatus(status)
+
+			if f.OnAddedFunc != nil {
+				err := f.OnAddedFunc(status.IsReady)
+				if err == tracker.StopTrack {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+		case status := <-replicaSetTracker.Ready:
+			f.setStatus(status)
+
+			if f.OnReadyFunc != nil {
+				err := f.OnReadyFunc()
+				if err == tracker.StopTrack {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+		case status := <-replicaSetTracker.Failed:
+			f.setStatus(status)
+
+			if f.OnFailedFunc != nil {
+				err := f.OnFailedFunc(status.FailedReason)
+				if err == tracker.StopTrack {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+		case msg := <-replicaSetTracker.EventMsg:
+			if f.OnEventMsgFunc != nil {
+				err := f.OnEventMsgFunc(msg)
+				if err == tracker.StopTrack {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+		case report := <-replicaSetTracker.AddedPod:
+			f.setStatus(report.ReplicaSetStatus)
+
+			if f.OnAddedPodFunc != nil {
+				err := f.OnAddedPodFunc(report.ReplicaSetPod)
+				if err == tracker.StopTrack {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+		case chunk := <-replicaSetTracker.PodLogChunk:
+			if debug.Debug() {
+				fmt.Printf("    rs/%s pod `%s` log chunk\n", replicaSetTracker.ResourceName, chunk.PodName)
+				for _, line := range chunk.LogLines {
+					fmt.Printf("po/%s [%s] %s\n", chunk.PodName, line.Timestamp, line.Message)
+				}
+			}
+
+			if f.OnPodLogChunkFunc != nil {
+				err := f.OnPodLogChunkFunc(chunk)
+				if err == tracker.StopTrack {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+		case report := <-replicaSetTracker.PodError:
+			f.setStatus(report.ReplicaSetStatus)
+
+			if f.OnPodErrorFunc != nil {
+				err := f.OnPodErrorFunc(report.ReplicaSetPodError)
+				if err == tracker.StopTrack {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+		case status := <-replicaSetTracker.Deleted:
+			f.setStatus(status)
+
+			if f.OnDeletedFunc != nil {
+				err := f.OnDeletedFunc()
+				if err == tracker.StopTrack {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			} else if f.OnStatusFunc != nil {
+				err := f.OnStatusFunc(status)
+				if err == tracker.StopTrack {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+		case status := <-replicaSetTracker.Status:
+			f.setStatus(status)
+
+			if f.OnStatusFunc != nil {
+				err := f.OnStatusFunc(status)
+				if err == tracker.StopTrack {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+		case err := <-errorChan:
+			return err
+		case <-doneChan:
+			return nil
+		}
+	}
+}
+
+func (f *feed) setStatus(status ReplicaSetStatus) {
+	f.statusMux.Lock()
+	defer f.statusMux.Unlock()
+	f.status = status
+}
+
+func (f *feed) GetStatus() ReplicaSetStatus {
+	f.statusMux.Lock()
+	defer f.statusMux.Unlock()
+	return f.status
+}
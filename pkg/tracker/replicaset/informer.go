@@ -57,6 +57,7 @@ func NewReplicaSetInformer(trk *tracker.Tracker, controller utils.ControllerMeta
 			Kube:             trk.Kube,
 			Namespace:        trk.Namespace,
 			FullResourceName: trk.FullResourceName,
+			SharedInformers:  trk.SharedInformers,
 		},
 		Controller:         controller,
 		ReplicaSetAdded:    make(chan *appsv1.ReplicaSet, 1),
@@ -90,14 +91,14 @@ func (r *ReplicaSetInformer) Run(ctx context.Context) {
 		options.LabelSelector = selector.String()
 		return options
 	}
-	lw := &cache.ListWatch{
+	lw := r.SharedInformers.ListWatch(r.Namespace, &appsv1.ReplicaSet{}, tweakListOptions, &cache.ListWatch{
 		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
 			return client.AppsV1().ReplicaSets(r.Namespace).List(ctx, tweakListOptions(options))
 		},
 		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
 			return client.AppsV1().ReplicaSets(r.Namespace).Watch(ctx, tweakListOptions(options))
 		},
-	}
+	})
 
 	go func() {
 		_, err := watchtools.UntilWithSync(ctx, lw, &appsv1.ReplicaSet{}, nil, func(e watch.Event) (bool, error) {
@@ -0,0 +1,84 @@
+package replicaset
+
+import (
+	"fmt"
+
+	"github.com/werf/kubedog/pkg/tracker/indicators"
+	"github.com/werf/kubedog/pkg/tracker/pod"
+
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+type ReplicaSetStatus struct {
+	appsv1.ReplicaSetStatus
+
+	StatusGeneration uint64
+
+	ReplicasIndicator  *indicators.Int32EqualConditionIndicator
+	ReadyIndicator     *indicators.Int32EqualConditionIndicator
+	AvailableIndicator *indicators.Int32EqualConditionIndicator
+
+	WaitingForMessages []string
+
+	IsReady      bool
+	IsFailed     bool
+	FailedReason string
+
+	Pods map[string]pod.PodStatus
+	// All Pods of the ReplicaSet are new, there are no revisions
+	NewPodsNames []string
+}
+
+func NewReplicaSetStatus(object *appsv1.ReplicaSet, statusGeneration uint64, isTrackerFailed bool, trackerFailedReason string, podsStatuses map[string]pod.PodStatus) ReplicaSetStatus {
+	res := ReplicaSetStatus{
+		StatusGeneration: statusGeneration,
+		ReplicaSetStatus: object.Status,
+		Pods:             make(map[string]pod.PodStatus),
+		NewPodsNames:     []string{},
+	}
+
+	for k, v := range podsStatuses {
+		res.Pods[k] = v
+		res.NewPodsNames = append(res.NewPodsNames, k)
+
+		if v.StatusIndicator != nil {
+			v.StatusIndicator.TargetValue = "Running"
+		}
+	}
+
+	res.IsReady = false
+
+	if object.Status.ObservedGeneration >= object.Generation {
+		if object.Spec.Replicas == nil {
+			return res
+		}
+
+		res.ReplicasIndicator = &indicators.Int32EqualConditionIndicator{
+			Value:       object.Status.Replicas,
+			TargetValue: *object.Spec.Replicas,
+		}
+		res.ReadyIndicator = &indicators.Int32EqualConditionIndicator{
+			Value:       object.Status.ReadyReplicas,
+			TargetValue: *object.Spec.Replicas,
+		}
+		res.AvailableIndicator = &indicators.Int32EqualConditionIndicator{
+			Value:       object.Status.AvailableReplicas,
+			TargetValue: *object.Spec.Replicas,
+		}
+
+		res.IsReady = true
+		if object.Status.ReadyReplicas != *object.Spec.Replicas {
+			res.IsReady = false
+			res.WaitingForMessages = append(res.WaitingForMessages, fmt.Sprintf("ready %d->%d", object.Status.ReadyReplicas, *object.Spec.Replicas))
+		}
+	} else {
+		res.WaitingForMessages = append(res.WaitingForMessages, fmt.Sprintf("observed generation %d should be >= %d", object.Status.ObservedGeneration, object.Generation))
+	}
+
+	if !res.IsReady && !res.IsFailed {
+		res.IsFailed = isTrackerFailed
+		res.FailedReason = trackerFailedReason
+	}
+
+	return res
+}
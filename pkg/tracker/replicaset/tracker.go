@@ -0,0 +1,491 @@
+package replicaset
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/werf/kubedog/pkg/tracker"
+	"github.com/werf/kubedog/pkg/tracker/debug"
+	"github.com/werf/kubedog/pkg/tracker/event"
+	"github.com/werf/kubedog/pkg/tracker/pod"
+	"github.com/werf/kubedog/pkg/utils"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type PodAddedReport struct {
+	ReplicaSetPod    ReplicaSetPod
+	ReplicaSetStatus ReplicaSetStatus
+}
+
+type PodErrorReport struct {
+	ReplicaSetPodError ReplicaSetPodError
+	ReplicaSetStatus   ReplicaSetStatus
+}
+
+// Tracker tracks the ReplicaSet which is not managed by a Deployment, e.g. created by an operator.
+type Tracker struct {
+	tracker.Tracker
+
+	State tracker.TrackerState
+
+	lastObject                 *appsv1.ReplicaSet
+	failedReason               string
+	podStatuses                map[string]pod.PodStatus
+	deletedPodsHistory         *pod.DeletedPodsHistory
+	stringsInterner            *utils.StringsInterner
+	initContainersStuckTimeout time.Duration
+	unschedulableTimeout       time.Duration
+	listObserver               tracker.ListObserver
+	connectionRetry            tracker.ConnectionRetry
+	panicsAreFatal             bool
+
+	TrackedPodsNames []string
+
+	Added   chan ReplicaSetStatus
+	Ready   chan ReplicaSetStatus
+	Failed  chan ReplicaSetStatus
+	Deleted chan ReplicaSetStatus
+	Status  chan ReplicaSetStatus
+
+	EventMsg    chan string
+	AddedPod    chan PodAddedReport
+	PodLogChunk chan *ReplicaSetPodLogChunk
+	PodError    chan PodErrorReport
+
+	resourceAdded    chan *appsv1.ReplicaSet
+	resourceModified chan *appsv1.ReplicaSet
+	resourceDeleted  chan *appsv1.ReplicaSet
+	resourceFailed   chan string
+	errors           chan error
+
+	podAddedRelay           chan *corev1.Pod
+	podStatusesRelay        chan map[string]pod.PodStatus
+	podLogChunksRelay       chan map[string]*pod.ContainerLogChunk
+	podContainerErrorsRelay chan map[string]pod.ContainerErrorReport
+	donePodsRelay           chan map[string]pod.PodStatus
+}
+
+func NewTracker(name, namespace string, kube kubernetes.Interface, opts tracker.Options) *Tracker {
+	return &Tracker{
+		Tracker: tracker.Tracker{
+			Kube:             kube,
+			Namespace:        namespace,
+			FullResourceName: tracker.FormatResourceID("rs", namespace, name),
+			ResourceName:     name,
+			LogsFromTime:     opts.LogsFromTime,
+
+			EventSeverityOverrides:  opts.EventSeverityOverrides,
+			LogsLimits:              opts.LogsLimits,
+			ContainerFailureReasons: opts.ContainerFailureReasons,
+			Suppressions:            opts.Suppressions,
+			LogStreamsBudget:        opts.LogStreamsBudget,
+			SharedInformers:         opts.SharedInformers,
+		},
+
+		Added:   make(chan ReplicaSetStatus, 1),
+		Ready:   make(chan ReplicaSetStatus, 0),
+		Failed:  make(chan ReplicaSetStatus, 0),
+		Deleted: make(chan ReplicaSetStatus, 0),
+		Status:  make(chan ReplicaSetStatus, 100),
+
+		EventMsg:    make(chan string, 1),
+		AddedPod:    make(chan PodAddedReport, 10),
+		PodLogChunk: make(chan *ReplicaSetPodLogChunk, 1000),
+		PodError:    make(chan PodErrorReport, 0),
+
+		podStatuses:                make(map[string]pod.PodStatus),
+		deletedPodsHistory:         pod.NewDeletedPodsHistory(opts.DeletedPodsHistoryLimit),
+		stringsInterner:            opts.StringsInterner,
+		initContainersStuckTimeout: opts.InitContainersStuckTimeout,
+		unschedulableTimeout:       opts.UnschedulableTimeout,
+		listObserver:               opts.ListObserver,
+		connectionRetry:            opts.ConnectionRetry,
+		panicsAreFatal:             opts.PanicsAreFatal,
+
+		errors:           make(chan error, 0),
+		resourceAdded:    make(chan *appsv1.ReplicaSet, 1),
+		resourceModified: make(chan *appsv1.ReplicaSet, 1),
+		resourceDeleted:  make(chan *appsv1.ReplicaSet, 1),
+		resourceFailed:   make(chan string, 1),
+
+		podAddedRelay:           make(chan *corev1.Pod, 1),
+		podStatusesRelay:        make(chan map[string]pod.PodStatus, 10),
+		podLogChunksRelay:       make(chan map[string]*pod.ContainerLogChunk, 10),
+		podContainerErrorsRelay: make(chan map[string]pod.ContainerErrorReport, 10),
+		donePodsRelay:           make(chan map[string]pod.PodStatus, 10),
+	}
+}
+
+// Track starts tracking of the ReplicaSet with name r.ResourceName within the namespace r.Namespace.
+// Tracker waits for the ReplicaSet creation and reports its Pods, their logs and errors.
+// All Pods owned by the ReplicaSet are considered new.
+func (r *Tracker) Track(ctx context.Context) error {
+	r.runReplicaSetInformer(ctx)
+
+	for {
+		select {
+		case object := <-r.resourceAdded:
+			if err := r.handleReplicaSetState(ctx, object); err != nil {
+				return err
+			}
+
+		case object := <-r.resourceModified:
+			if err := r.handleReplicaSetState(ctx, object); err != nil {
+				return err
+			}
+
+		case <-r.resourceDeleted:
+			r.State = tracker.ResourceDeleted
+			r.lastObject = nil
+			r.podStatuses = make(map[string]pod.PodStatus)
+			r.TrackedPodsNames = nil
+			r.deletedPodsHistory.Reset()
+			r.Deleted <- ReplicaSetStatus{}
+
+		case reason := <-r.resourceFailed:
+			r.State = tracker.ResourceFailed
+			r.failedReason = reason
+
+			var status ReplicaSetStatus
+			if r.lastObject != nil {
+				r.StatusGeneration++
+				status = NewReplicaSetStatus(r.lastObject, r.StatusGeneration, (r.State == tracker.ResourceFailed), r.failedReason, r.podStatuses)
+			} else {
+				status = ReplicaSetStatus{IsFailed: true, FailedReason: reason}
+			}
+			r.Failed <- status
+
+		case pod := <-r.podAddedRelay:
+			// Pods matching the selector may be owned by another ReplicaSet
+			if utils.GetPodReplicaSetName(pod) != r.ResourceName {
+				continue
+			}
+
+			r.deletedPodsHistory.Forget(pod.Name)
+
+			if r.lastObject != nil {
+				r.StatusGeneration++
+				status := NewReplicaSetStatus(r.lastObject, r.StatusGeneration, (r.State == tracker.ResourceFailed), r.failedReason, r.podStatuses)
+
+				r.AddedPod <- PodAddedReport{
+					ReplicaSetPod: ReplicaSetPod{
+						Name:       pod.Name,
+						ReplicaSet: r.replicaSet(),
+					},
+					ReplicaSetStatus: status,
+				}
+			}
+
+			if err := r.runPodTracker(ctx, pod.Name); err != nil {
+				return err
+			}
+
+		case donePods := <-r.donePodsRelay:
+			var trackedPodsNames []string
+
+		trackedPodsIteration:
+			for _, name := range r.TrackedPodsNames {
+				for donePodName, status := range donePods {
+					if name == donePodName {
+						// This Pod is no more tracked,
+						// but we need to update final
+						// Pod's status, the Pod deleted before
+						// its status is relayed is shown as well
+						if _, hasKey := r.podStatuses[name]; hasKey || status.IsDeleted {
+							r.podStatuses[name] = status
+						}
+						if status.IsDeleted {
+							r.deletedPodsHistory.Add(name)
+						}
+						continue trackedPodsIteration
+					}
+				}
+
+				trackedPodsNames = append(trackedPodsNames, name)
+			}
+			r.TrackedPodsNames = trackedPodsNames
+
+			// Deleted Pods are pruned after the status with them as terminated has been reported
+			if r.lastObject != nil {
+				if err := r.handleReplicaSetState(ctx, r.lastObject); err != nil {
+					return err
+				}
+			}
+
+			r.deletedPodsHistory.Prune(r.podStatuses)
+
+		case podStatuses := <-r.podStatusesRelay:
+			for podName, podStatus := range podStatuses {
+				if pod.IsTrackedPod(r.TrackedPodsNames, podName) {
+					r.podStatuses[podName] = podStatus
+				}
+			}
+			if r.lastObject != nil {
+				if err := r.handleReplicaSetState(ctx, r.lastObject); err != nil {
+					return err
+				}
+			}
+
+		case podLogChunks := <-r.podLogChunksRelay:
+			for podName, chunk := range podLogChunks {
+				r.PodLogChunk <- &ReplicaSetPodLogChunk{
+					PodLogChunk: &pod.PodLogChunk{
+						ContainerLogChunk: chunk,
+						PodName:           podName,
+					},
+					ReplicaSet: r.replicaSet(),
+				}
+			}
+
+		case podContainerErrors := <-r.podContainerErrorsRelay:
+			for podName, containerError := range podContainerErrors {
+				if pod.IsTrackedPod(r.TrackedPodsNames, podName) {
+					r.podStatuses[podName] = containerError.PodStatus
+				}
+			}
+			if r.lastObject != nil {
+				r.StatusGeneration++
+				status := NewReplicaSetStatus(r.lastObject, r.StatusGeneration, (r.State == tracker.ResourceFailed), r.failedReason, r.podStatuses)
+
+				for podName, containerError := range podContainerErrors {
+					r.PodError <- PodErrorReport{
+						ReplicaSetPodError: ReplicaSetPodError{
+							PodError: pod.PodError{
+								ContainerError: containerError.ContainerError,
+								PodName:        podName,
+							},
+							ReplicaSet: r.replicaSet(),
+						},
+						ReplicaSetStatus: status,
+					}
+				}
+			}
+
+		case <-ctx.Done():
+			if ctx.Err() == context.Canceled {
+				return nil
+			}
+			return ctx.Err()
+		case err := <-r.errors:
+			return err
+		}
+	}
+}
+
+func (r *Tracker) replicaSet() ReplicaSet {
+	return ReplicaSet{Name: r.ResourceName, IsNew: true}
+}
+
+// runReplicaSetInformer watch for ReplicaSet events
+func (r *Tracker) runReplicaSetInformer(ctx context.Context) {
+	client := r.Kube
+
+	tweakListOptions := func(options metav1.ListOptions) metav1.ListOptions {
+		options.FieldSelector = fields.OneTermEqualSelector("metadata.name", r.ResourceName).String()
+		return options
+	}
+	lw := r.SharedInformers.ListWatch(r.Namespace, &appsv1.ReplicaSet{}, tweakListOptions, &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return client.AppsV1().ReplicaSets(r.Namespace).List(ctx, tweakListOptions(options))
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return client.AppsV1().ReplicaSets(r.Namespace).Watch(ctx, tweakListOptions(options))
+		},
+	})
+	lw.ListFunc = tracker.ObserveList(lw.ListFunc, r.listObserver)
+
+	go func() {
+		_, err := tracker.UntilWithSync(ctx, lw, &appsv1.ReplicaSet{}, r.connectionRetry, func(e watch.Event) (bool, error) {
+			if debug.Debug() {
+				fmt.Printf("    rs/%s event: %#v\n", r.ResourceName, e.Type)
+			}
+
+			var object *appsv1.ReplicaSet
+
+			if e.Type != watch.Error {
+				var ok bool
+				object, ok = e.Object.(*appsv1.ReplicaSet)
+				if !ok {
+					return true, fmt.Errorf("expected %s to be a *appsv1.ReplicaSet, got %T", r.ResourceName, e.Object)
+				}
+			}
+
+			switch e.Type {
+			case watch.Added:
+				r.resourceAdded <- object
+			case watch.Modified:
+				r.resourceModified <- object
+			case watch.Deleted:
+				r.resourceDeleted <- object
+			case watch.Error:
+				return true, fmt.Errorf("replicaset error: %v", e.Object)
+			}
+
+			return false, nil
+		})
+
+		if err := tracker.AdaptInformerError(err); err != nil {
+			select {
+			case r.errors <- err:
+			case <-ctx.Done():
+			}
+		}
+
+		if debug.Debug() {
+			fmt.Printf("      rs/%s informer DONE\n", r.ResourceName)
+		}
+	}()
+}
+
+// runPodsInformer watch for ReplicaSet Pods events
+func (r *Tracker) runPodsInformer(ctx context.Context, object *appsv1.ReplicaSet) {
+	podsInformer := pod.NewPodsInformer(&r.Tracker, utils.ControllerAccessor(object))
+	podsInformer.WithChannels(r.podAddedRelay, r.errors)
+	podsInformer.Run(ctx)
+}
+
+func (r *Tracker) runPodTracker(_ctx context.Context, podName string) error {
+	errorChan := make(chan error, 0)
+	doneChan := make(chan struct{}, 0)
+
+	newCtx, cancelPodCtx := context.WithCancel(_ctx)
+	podTracker := pod.NewTracker(podName, r.Namespace, r.Kube)
+	if !r.LogsFromTime.IsZero() {
+		podTracker.LogsFromTime = r.LogsFromTime
+	}
+	podTracker.StringsInterner = r.stringsInterner
+	podTracker.InitContainersStuckTimeout = r.initContainersStuckTimeout
+	podTracker.UnschedulableTimeout = r.unschedulableTimeout
+	podTracker.EventSeverityOverrides = r.EventSeverityOverrides
+	podTracker.LogsLimits = r.LogsLimits
+	podTracker.ContainerFailureReasons = r.ContainerFailureReasons
+	podTracker.Suppressions = r.Suppressions
+	podTracker.LogStreamsBudget = r.LogStreamsBudget
+	podTracker.SharedInformers = r.SharedInformers
+	r.TrackedPodsNames = append(r.TrackedPodsNames, podName)
+
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				errorChan <- tracker.NewPanicError(recovered, r.panicsAreFatal)
+			}
+		}()
+
+		if debug.Debug() {
+			fmt.Printf("Starting ReplicaSet's `%s` Pod `%s` tracker. pod state: %v\n", r.ResourceName, podTracker.ResourceName, podTracker.State)
+		}
+
+		err := podTracker.Start(newCtx)
+		if err != nil {
+			errorChan <- err
+		} else {
+			doneChan <- struct{}{}
+		}
+
+		if debug.Debug() {
+			fmt.Printf("Done ReplicaSet's `%s` Pod `%s` tracker\n", r.ResourceName, podTracker.ResourceName)
+		}
+	}()
+
+	go func() {
+		for {
+			select {
+			case status := <-podTracker.Added:
+				r.podStatusesRelay <- map[string]pod.PodStatus{podTracker.ResourceName: status}
+			case status := <-podTracker.Succeeded:
+				r.podStatusesRelay <- map[string]pod.PodStatus{podTracker.ResourceName: status}
+				cancelPodCtx()
+			case status := <-podTracker.Deleted:
+				r.podStatusesRelay <- map[string]pod.PodStatus{podTracker.ResourceName: status}
+				cancelPodCtx()
+			case report := <-podTracker.Failed:
+				r.podStatusesRelay <- map[string]pod.PodStatus{podTracker.ResourceName: report.PodStatus}
+			case status := <-podTracker.Ready:
+				r.podStatusesRelay <- map[string]pod.PodStatus{podTracker.ResourceName: status}
+			case status := <-podTracker.Status:
+				r.podStatusesRelay <- map[string]pod.PodStatus{podTracker.ResourceName: status}
+
+			case msg := <-podTracker.EventMsg:
+				r.EventMsg <- fmt.Sprintf("%s %s", tracker.FormatResourceID("po", podTracker.Namespace, podTracker.ResourceName), msg)
+			case chunk := <-podTracker.ContainerLogChunk:
+				r.podLogChunksRelay <- map[string]*pod.ContainerLogChunk{podTracker.ResourceName: chunk}
+			case report := <-podTracker.ContainerError:
+				r.podContainerErrorsRelay <- map[string]pod.ContainerErrorReport{podTracker.ResourceName: report}
+
+			case err := <-errorChan:
+				r.errors <- err
+				return
+			case <-doneChan:
+				r.donePodsRelay <- map[string]pod.PodStatus{podTracker.ResourceName: podTracker.LastStatus}
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (r *Tracker) handleReplicaSetState(ctx context.Context, object *appsv1.ReplicaSet) error {
+	r.lastObject = object
+	r.StatusGeneration++
+
+	status := NewReplicaSetStatus(object, r.StatusGeneration, (r.State == tracker.ResourceFailed), r.failedReason, r.podStatuses)
+
+	switch r.State {
+	case tracker.Initial:
+		r.runPodsInformer(ctx, object)
+		r.runEventsInformer(ctx, object)
+
+		if status.IsFailed {
+			r.State = tracker.ResourceFailed
+			r.Failed <- status
+		} else if status.IsReady {
+			r.State = tracker.ResourceReady
+			r.Ready <- status
+		} else {
+			r.State = tracker.ResourceAdded
+			r.Added <- status
+		}
+	case tracker.ResourceAdded, tracker.ResourceFailed:
+		if status.IsFailed {
+			r.State = tracker.ResourceFailed
+			r.Failed <- status
+		} else if status.IsReady {
+			r.State = tracker.ResourceReady
+			r.Ready <- status
+		} else {
+			r.Status <- status
+		}
+	case tracker.ResourceReady, tracker.ResourceSucceeded:
+		r.Status <- status
+	case tracker.ResourceDeleted:
+		if status.IsFailed {
+			r.State = tracker.ResourceFailed
+			r.Failed <- status
+		} else if status.IsReady {
+			r.State = tracker.ResourceReady
+			r.Ready <- status
+		} else {
+			r.State = tracker.ResourceAdded
+			r.Added <- status
+		}
+	}
+
+	return nil
+}
+
+// runEventsInformer watch for ReplicaSet events
+func (r *Tracker) runEventsInformer(ctx context.Context, resource interface{}) {
+	eventInformer := event.NewEventInformer(&r.Tracker, resource)
+	eventInformer.WithChannels(r.EventMsg, r.resourceFailed, r.errors)
+	eventInformer.Run(ctx)
+}
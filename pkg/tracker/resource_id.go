@@ -0,0 +1,61 @@
+package tracker
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ResourceKindsAliases map the accepted names of the kinds of the tracked resources to the short kinds used in resources identifiers.
+var ResourceKindsAliases = map[string]string{
+	"po":   "po",
+	"pod":  "po",
+	"pods": "po",
+
+	"deploy":      "deploy",
+	"deployment":  "deploy",
+	"deployments": "deploy",
+
+	"rs":          "rs",
+	"replicaset":  "rs",
+	"replicasets": "rs",
+
+	"sts":          "sts",
+	"statefulset":  "sts",
+	"statefulsets": "sts",
+
+	"ds":         "ds",
+	"daemonset":  "ds",
+	"daemonsets": "ds",
+
+	"job":  "job",
+	"jobs": "job",
+
+	"generic": "generic",
+}
+
+// FormatResourceID returns the canonical identifier of the resource "kind/namespace/name", e.g. "deploy/prod/web",
+// with the short kind (po, deploy, rs, sts, ds, job or generic). Namespace is empty for cluster-scoped resources: "generic//x".
+func FormatResourceID(kind, namespace, name string) string {
+	if shortKind, hasKey := ResourceKindsAliases[strings.ToLower(kind)]; hasKey {
+		kind = shortKind
+	}
+	return fmt.Sprintf("%s/%s/%s", kind, namespace, name)
+}
+
+// ParseResourceID parses the identifier formatted by FormatResourceID, any alias of ResourceKindsAliases is accepted as the kind.
+func ParseResourceID(id string) (kind, namespace, name string, err error) {
+	parts := strings.Split(id, "/")
+	if len(parts) != 3 {
+		return "", "", "", fmt.Errorf("bad resource id %q: expected kind/namespace/name", id)
+	}
+
+	kind, hasKey := ResourceKindsAliases[strings.ToLower(parts[0])]
+	if !hasKey {
+		return "", "", "", fmt.Errorf("bad resource id %q: unknown kind %q", id, parts[0])
+	}
+	if parts[2] == "" {
+		return "", "", "", fmt.Errorf("bad resource id %q: empty name", id)
+	}
+
+	return kind, parts[1], parts[2], nil
+}
@@ -0,0 +1,68 @@
+package tracker
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestResourceIDRoundTrip formats the identifier with each alias of the kinds and in upper case:
+// the parsed identifier has the short kind and the same namespace and name.
+func TestResourceIDRoundTrip(t *testing.T) {
+	for alias, shortKind := range ResourceKindsAliases {
+		for _, kind := range []string{alias, strings.ToUpper(alias)} {
+			for _, namespace := range []string{"prod", ""} {
+				id := FormatResourceID(kind, namespace, "web")
+				if expected := shortKind + "/" + namespace + "/web"; id != expected {
+					t.Errorf("expected id %q for kind %q, got %q", expected, kind, id)
+				}
+
+				parsedKind, parsedNamespace, parsedName, err := ParseResourceID(id)
+				if err != nil {
+					t.Errorf("%s: unexpected error: %s", id, err)
+					continue
+				}
+				if parsedKind != shortKind || parsedNamespace != namespace || parsedName != "web" {
+					t.Errorf("%s: unexpected parsed %s %s %s", id, parsedKind, parsedNamespace, parsedName)
+				}
+			}
+		}
+	}
+}
+
+func TestFormatResourceIDUnknownKind(t *testing.T) {
+	if id := FormatResourceID("CronJob", "prod", "backup"); id != "CronJob/prod/backup" {
+		t.Errorf("expected unknown kind kept as is, got %q", id)
+	}
+}
+
+func TestParseResourceID(t *testing.T) {
+	tests := []struct {
+		id          string
+		expected    string
+		expectedErr string
+	}{
+		{id: "Deployment/prod/web", expected: "deploy prod web"},
+		{id: "pods/default/worker-7f9c", expected: "po default worker-7f9c"},
+		{id: "generic//cluster-issuer", expected: "generic  cluster-issuer"},
+		{id: "deploy/web", expectedErr: `bad resource id "deploy/web": expected kind/namespace/name`},
+		{id: "deploy/prod/web/x", expectedErr: `bad resource id "deploy/prod/web/x": expected kind/namespace/name`},
+		{id: "cronjob/prod/backup", expectedErr: `bad resource id "cronjob/prod/backup": unknown kind "cronjob"`},
+		{id: "deploy/prod/", expectedErr: `bad resource id "deploy/prod/": empty name`},
+	}
+
+	for _, tt := range tests {
+		kind, namespace, name, err := ParseResourceID(tt.id)
+		if tt.expectedErr != "" {
+			if err == nil || err.Error() != tt.expectedErr {
+				t.Errorf("%s: expected error %q, got %v", tt.id, tt.expectedErr, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tt.id, err)
+		} else if res := kind + " " + namespace + " " + name; res != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.id, tt.expected, res)
+		}
+	}
+}
@@ -0,0 +1,755 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// sharedSubscriptionQueueLimit is the number of the events queued for the subscriber, which does not read them,
+	// after which the queue is dropped and the subscriber lists the objects again.
+	sharedSubscriptionQueueLimit = 1000
+)
+
+// SharedInformers serve the list and watch requests of the trackers from a single watch per kind and namespace,
+// so that the number of watches open to the API server does not grow with the number of tracked resources and their Pods.
+// Each tracker gets only the objects matching its label and field selectors. Watches are stopped when the context is done.
+// Failed list and watch requests of the shared watch fail the watches of the trackers, so that the trackers retry
+// the connection as if they watched the API server themselves, dropped shared watches drop the watches of the trackers.
+type SharedInformers struct {
+	ctx  context.Context
+	kube kubernetes.Interface
+
+	mux     sync.Mutex
+	sources map[string]*sharedSource
+}
+
+func NewSharedInformers(ctx context.Context, kube kubernetes.Interface) *SharedInformers {
+	return &SharedInformers{
+		ctx:     ctx,
+		kube:    kube,
+		sources: make(map[string]*sharedSource),
+	}
+}
+
+// ListWatch returns the ListWatch of the objects of objType in the namespace selected by tweakListOptions, served
+// by the shared watch. lw is returned as is when s is nil, for unsupported kinds (e.g. Generic resources)
+// and for the field selectors which can not be matched locally.
+func (s *SharedInformers) ListWatch(namespace string, objType runtime.Object, tweakListOptions func(metav1.ListOptions) metav1.ListOptions, lw *cache.ListWatch) *cache.ListWatch {
+	if s == nil {
+		return lw
+	}
+
+	options := tweakListOptions(metav1.ListOptions{})
+
+	labelSelector, err := labels.Parse(options.LabelSelector)
+	if err != nil {
+		return lw
+	}
+	fieldSelector, err := fields.ParseSelector(options.FieldSelector)
+	if err != nil {
+		return lw
+	}
+	for _, requirement := range fieldSelector.Requirements() {
+		if !isSharedInformerField(objType, requirement.Field) {
+			return lw
+		}
+	}
+
+	source := s.source(namespace, objType)
+	if source == nil {
+		return lw
+	}
+
+	match := func(obj runtime.Object) bool {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return false
+		}
+		return labelSelector.Matches(labels.Set(accessor.GetLabels())) && fieldSelector.Matches(objectFields(obj))
+	}
+
+	sw := &sharedListWatch{source: source, match: match}
+
+	return &cache.ListWatch{
+		ListFunc:  sw.list,
+		WatchFunc: sw.watch,
+	}
+}
+
+func (s *SharedInformers) source(namespace string, objType runtime.Object) *sharedSource {
+	var lw *cache.ListWatch
+	var kind string
+
+	ctx := s.ctx
+	switch objType.(type) {
+	case *appsv1.Deployment:
+		kind = "deploy"
+		lw = &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return s.kube.AppsV1().Deployments(namespace).List(ctx, options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return s.kube.AppsV1().Deployments(namespace).Watch(ctx, options)
+			},
+		}
+	case *appsv1.ReplicaSet:
+		kind = "rs"
+		lw = &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return s.kube.AppsV1().ReplicaSets(namespace).List(ctx, options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return s.kube.AppsV1().ReplicaSets(namespace).Watch(ctx, options)
+			},
+		}
+	case *appsv1.StatefulSet:
+		kind = "sts"
+		lw = &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return s.kube.AppsV1().StatefulSets(namespace).List(ctx, options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return s.kube.AppsV1().StatefulSets(namespace).Watch(ctx, options)
+			},
+		}
+	case *appsv1.DaemonSet:
+		kind = "ds"
+		lw = &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return s.kube.AppsV1().DaemonSets(namespace).List(ctx, options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return s.kube.AppsV1().DaemonSets(namespace).Watch(ctx, options)
+			},
+		}
+	case *batchv1.Job:
+		kind = "job"
+		lw = &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return s.kube.BatchV1().Jobs(namespace).List(ctx, options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return s.kube.BatchV1().Jobs(namespace).Watch(ctx, options)
+			},
+		}
+	case *corev1.Pod:
+		kind = "po"
+		lw = &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return s.kube.CoreV1().Pods(namespace).List(ctx, options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return s.kube.CoreV1().Pods(namespace).Watch(ctx, options)
+			},
+		}
+	case *corev1.Event:
+		kind = "ev"
+		lw = &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return s.kube.CoreV1().Events(namespace).List(ctx, options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return s.kube.CoreV1().Events(namespace).Watch(ctx, options)
+			},
+		}
+	default:
+		return nil
+	}
+
+	key := fmt.Sprintf("%s/%s", kind, namespace)
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if source, hasKey := s.sources[key]; hasKey {
+		return source
+	}
+
+	source := newSharedSource(ctx, lw, objType)
+	s.sources[key] = source
+
+	return source
+}
+
+func isSharedInformerField(objType runtime.Object, field string) bool {
+	switch field {
+	case "metadata.name", "metadata.namespace":
+		return true
+	}
+
+	if _, isEvent := objType.(*corev1.Event); isEvent {
+		_, hasField := eventFields(&corev1.Event{})[field]
+		return hasField
+	}
+
+	return false
+}
+
+func objectFields(obj runtime.Object) fields.Set {
+	var set fields.Set
+	if event, isEvent := obj.(*corev1.Event); isEvent {
+		set = eventFields(event)
+	} else {
+		set = fields.Set{}
+	}
+
+	if accessor, err := meta.Accessor(obj); err == nil {
+		set["metadata.name"] = accessor.GetName()
+		set["metadata.namespace"] = accessor.GetNamespace()
+	}
+
+	return set
+}
+
+// eventFields are the same as the fields of the Event field selectors supported by the API server.
+func eventFields(event *corev1.Event) fields.Set {
+	return fields.Set{
+		"involvedObject.kind":            event.InvolvedObject.Kind,
+		"involvedObject.namespace":       event.InvolvedObject.Namespace,
+		"involvedObject.name":            event.InvolvedObject.Name,
+		"involvedObject.uid":             string(event.InvolvedObject.UID),
+		"involvedObject.apiVersion":      event.InvolvedObject.APIVersion,
+		"involvedObject.resourceVersion": event.InvolvedObject.ResourceVersion,
+		"involvedObject.fieldPath":       event.InvolvedObject.FieldPath,
+		"reason":                         event.Reason,
+		"source":                         event.Source.Component,
+		"type":                           event.Type,
+	}
+}
+
+// sharedSource is the informer of all objects of the kind in the namespace, which delivers its events to the subscriptions.
+type sharedSource struct {
+	ctx      context.Context
+	informer cache.Controller
+	store    cache.Store
+
+	mux           sync.Mutex
+	subscriptions map[*sharedSubscription]bool
+	// err is the error of the last failed list or watch request of the informer, the next successful one clears it.
+	err error
+	// changed is closed and replaced on each change of the informer state, which may have synced it, see waitForSync.
+	changed chan struct{}
+}
+
+func newSharedSource(ctx context.Context, lw *cache.ListWatch, objType runtime.Object) *sharedSource {
+	source := &sharedSource{ctx: ctx, subscriptions: make(map[*sharedSubscription]bool), changed: make(chan struct{})}
+
+	listFunc := lw.ListFunc
+	lw.ListFunc = func(options metav1.ListOptions) (runtime.Object, error) {
+		object, err := listFunc(options)
+		source.setErr(err)
+
+		return object, err
+	}
+
+	watchFunc := lw.WatchFunc
+	lw.WatchFunc = func(options metav1.ListOptions) (watch.Interface, error) {
+		// The informer opens the watch once the listed objects are stored, so the empty list is synced by now
+		source.mux.Lock()
+		source.broadcastChanged()
+		source.mux.Unlock()
+
+		w, err := watchFunc(options)
+		source.setErr(err)
+		if err != nil {
+			return w, err
+		}
+
+		var timeout time.Duration
+		if options.TimeoutSeconds != nil {
+			timeout = time.Duration(*options.TimeoutSeconds) * time.Second
+		}
+
+		return newSourceWatch(w, source, timeout), nil
+	}
+
+	// Handlers are called one by one after the store update, so the subscription never gets a version of the object
+	// older than the one in the store at the time it was subscribed.
+	source.store, source.informer = cache.NewInformer(lw, objType, 0, cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			source.notify(obj, false)
+		},
+		UpdateFunc: func(_, newObj interface{}) {
+			source.notify(newObj, false)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			source.notify(obj, true)
+		},
+	})
+
+	go source.informer.Run(ctx.Done())
+
+	return source
+}
+
+func (s *sharedSource) notify(obj interface{}, isDeleted bool) {
+	object, ok := obj.(runtime.Object)
+	if !ok {
+		return
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	for subscription := range s.subscriptions {
+		subscription.handle(object, isDeleted)
+	}
+
+	// The handler of the last listed object is called when the informer is synced
+	s.broadcastChanged()
+}
+
+// broadcastChanged wakes up the subscribers waiting for the sync, it is called under the source mutex.
+func (s *sharedSource) broadcastChanged() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// setErr fails the watches of the subscriptions on the error of the list or watch request, nil error clears the last one.
+func (s *sharedSource) setErr(err error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.err = err
+	s.broadcastChanged()
+	if err == nil || s.ctx.Err() != nil {
+		return
+	}
+
+	for subscription := range s.subscriptions {
+		subscription.fail(err)
+	}
+}
+
+// dropped drops the watches of the subscriptions when the watch of the informer is closed before its timeout.
+func (s *sharedSource) dropped() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.ctx.Err() != nil {
+		return
+	}
+
+	for subscription := range s.subscriptions {
+		subscription.drop()
+	}
+}
+
+// waitForSync returns the error of the last list or watch request of the informer until the request succeeds
+// and the informer is synced, so that the subscriber observes the failures as its own. The subscriber is woken up
+// by the informer events instead of polling, so that the objects already in the desired state are reported immediately.
+func (s *sharedSource) waitForSync() error {
+	for {
+		s.mux.Lock()
+		err, changed := s.err, s.changed
+		s.mux.Unlock()
+
+		if err != nil {
+			return err
+		}
+		if s.informer.HasSynced() {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		}
+	}
+}
+
+// subscribe returns the subscription with the snapshot of the matching objects, events after the snapshot are queued.
+func (s *sharedSource) subscribe(match func(runtime.Object) bool) (*sharedSubscription, []runtime.Object) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	subscription := &sharedSubscription{
+		match:   match,
+		known:   make(map[string]uint64),
+		pending: make(chan struct{}, 1),
+		closed:  make(chan struct{}),
+	}
+
+	var objects []runtime.Object
+	for _, obj := range s.store.List() {
+		object, ok := obj.(runtime.Object)
+		if !ok || !match(object) {
+			continue
+		}
+
+		key, err := cache.MetaNamespaceKeyFunc(object)
+		if err != nil {
+			continue
+		}
+
+		subscription.known[key] = objectResourceVersion(object)
+		objects = append(objects, object.DeepCopyObject())
+	}
+
+	s.subscriptions[subscription] = true
+
+	return subscription, objects
+}
+
+func (s *sharedSource) unsubscribe(subscription *sharedSubscription) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.subscriptions[subscription] {
+		delete(s.subscriptions, subscription)
+		close(subscription.closed)
+	}
+}
+
+func (s *sharedSource) lastSyncResourceVersion() string {
+	return s.informer.LastSyncResourceVersion()
+}
+
+// sharedSubscription is the queue of the events of the objects matching the subscriber selectors.
+// Fields are guarded by the source mutex.
+type sharedSubscription struct {
+	match func(runtime.Object) bool
+
+	// known are the resource versions of the matching objects delivered to the subscriber
+	known   map[string]uint64
+	queue   []watch.Event
+	pending chan struct{}
+	closed  chan struct{}
+
+	// err fails the watch of the subscriber instead of the queued events, the subscriber lists the objects again.
+	err error
+	// isDropped closes the watch of the subscriber after the queued events, the subscriber opens the watch again.
+	isDropped bool
+}
+
+func (s *sharedSubscription) fail(err error) {
+	s.err = err
+	s.queue = nil
+	s.signal()
+}
+
+func (s *sharedSubscription) drop() {
+	s.isDropped = true
+	s.signal()
+}
+
+func (s *sharedSubscription) signal() {
+	select {
+	case s.pending <- struct{}{}:
+	default:
+	}
+}
+
+// handle is called under the source mutex.
+func (s *sharedSubscription) handle(object runtime.Object, isDeleted bool) {
+	if s.err != nil {
+		return
+	}
+
+	key, err := cache.MetaNamespaceKeyFunc(object)
+	if err != nil {
+		return
+	}
+
+	knownVersion, isKnown := s.known[key]
+	isMatched := !isDeleted && s.match(object)
+
+	var eventType watch.EventType
+	switch {
+	case isMatched && isKnown:
+		version := objectResourceVersion(object)
+		if version != 0 && version <= knownVersion {
+			return
+		}
+		s.known[key] = version
+		eventType = watch.Modified
+	case isMatched:
+		s.known[key] = objectResourceVersion(object)
+		eventType = watch.Added
+	case isKnown:
+		delete(s.known, key)
+		eventType = watch.Deleted
+	default:
+		return
+	}
+
+	if len(s.queue) >= sharedSubscriptionQueueLimit {
+		// The subscriber lists the objects anew, 410 Expired is not a connection failure
+		s.fail(apierrors.NewResourceExpired(fmt.Sprintf("more than %d events of the shared watch are not received", sharedSubscriptionQueueLimit)))
+		return
+	}
+
+	s.queue = append(s.queue, watch.Event{Type: eventType, Object: object.DeepCopyObject()})
+	s.signal()
+}
+
+// objectResourceVersion returns 0 when the resource version is not a number.
+func objectResourceVersion(object runtime.Object) uint64 {
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		return 0
+	}
+
+	version, err := strconv.ParseUint(accessor.GetResourceVersion(), 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return version
+}
+
+// sharedListWatch lists the matching objects from the source store and watches the events queued since the last list.
+type sharedListWatch struct {
+	source *sharedSource
+	match  func(runtime.Object) bool
+
+	mux          sync.Mutex
+	subscription *sharedSubscription
+}
+
+func (sw *sharedListWatch) list(_ metav1.ListOptions) (runtime.Object, error) {
+	if err := sw.source.waitForSync(); err != nil {
+		return nil, err
+	}
+
+	subscription, objects := sw.source.subscribe(sw.match)
+
+	sw.mux.Lock()
+	prevSubscription := sw.subscription
+	sw.subscription = subscription
+	sw.mux.Unlock()
+
+	if prevSubscription != nil {
+		sw.source.unsubscribe(prevSubscription)
+	}
+
+	list := &sharedList{Items: objects}
+	list.ResourceVersion = sw.source.lastSyncResourceVersion()
+
+	return list, nil
+}
+
+func (sw *sharedListWatch) watch(_ metav1.ListOptions) (watch.Interface, error) {
+	sw.mux.Lock()
+	subscription := sw.subscription
+	sw.mux.Unlock()
+
+	if subscription == nil {
+		return nil, fmt.Errorf("shared watch requested before list")
+	}
+
+	select {
+	case <-subscription.closed:
+		return nil, fmt.Errorf("shared watch closed")
+	default:
+	}
+
+	return newSharedWatch(sw.source, subscription), nil
+}
+
+// sharedWatch sends the queued events of the subscription, stopping the watch ends the subscription
+// unless the watch is dropped: the subscriber watches the subscription again.
+type sharedWatch struct {
+	source       *sharedSource
+	subscription *sharedSubscription
+
+	result    chan watch.Event
+	stopOnce  sync.Once
+	stopped   chan struct{}
+	isDropped bool
+}
+
+func newSharedWatch(source *sharedSource, subscription *sharedSubscription) *sharedWatch {
+	w := &sharedWatch{
+		source:       source,
+		subscription: subscription,
+		result:       make(chan watch.Event),
+		stopped:      make(chan struct{}),
+	}
+
+	go w.run()
+
+	return w
+}
+
+func (w *sharedWatch) run() {
+	defer close(w.result)
+
+	for {
+		w.source.mux.Lock()
+		queue := w.subscription.queue
+		w.subscription.queue = nil
+		err := w.subscription.err
+		isDropped := w.subscription.isDropped
+		w.subscription.isDropped = false
+		if isDropped && err == nil {
+			w.isDropped = true
+		}
+		w.source.mux.Unlock()
+
+		if err != nil {
+			select {
+			case w.result <- watch.Event{Type: watch.Error, Object: errorStatus(err)}:
+			case <-w.stopped:
+			}
+			return
+		}
+
+		for _, event := range queue {
+			select {
+			case w.result <- event:
+			case <-w.stopped:
+				return
+			}
+		}
+
+		if isDropped {
+			return
+		}
+
+		select {
+		case <-w.subscription.pending:
+		case <-w.subscription.closed:
+			return
+		case <-w.stopped:
+			return
+		}
+	}
+}
+
+func (w *sharedWatch) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+func (w *sharedWatch) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stopped)
+
+		w.source.mux.Lock()
+		isDropped := w.isDropped
+		w.source.mux.Unlock()
+
+		if !isDropped {
+			w.source.unsubscribe(w.subscription)
+		}
+	})
+}
+
+// errorStatus returns the status of the watch error event of the subscriber.
+func errorStatus(err error) *metav1.Status {
+	var apiStatus apierrors.APIStatus
+	if errors.As(err, &apiStatus) {
+		status := apiStatus.Status()
+		return &status
+	}
+
+	return &metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    http.StatusInternalServerError,
+		Reason:  metav1.StatusReasonUnknown,
+		Message: err.Error(),
+	}
+}
+
+// sourceWatch proxies the events of the watch of the informer and reports the watch errors
+// and the watch closed before its timeout to the source.
+type sourceWatch struct {
+	watch.Interface
+
+	result   chan watch.Event
+	stopOnce sync.Once
+	stopped  chan struct{}
+}
+
+func newSourceWatch(w watch.Interface, source *sharedSource, timeout time.Duration) *sourceWatch {
+	sw := &sourceWatch{
+		Interface: w,
+		result:    make(chan watch.Event),
+		stopped:   make(chan struct{}),
+	}
+
+	go func() {
+		defer close(sw.result)
+
+		openedAt := time.Now()
+		for {
+			select {
+			case event, ok := <-w.ResultChan():
+				if !ok {
+					if timeout <= 0 || time.Since(openedAt) < timeout-time.Second {
+						source.dropped()
+					}
+					return
+				}
+
+				if event.Type == watch.Error {
+					// 410 Gone is not a failure: the informer lists the objects anew
+					if err := apierrors.FromObject(event.Object); !apierrors.IsGone(err) && !apierrors.IsResourceExpired(err) {
+						source.setErr(err)
+					}
+				}
+
+				select {
+				case sw.result <- event:
+				case <-sw.stopped:
+					return
+				}
+			case <-sw.stopped:
+				return
+			}
+		}
+	}()
+
+	return sw
+}
+
+func (sw *sourceWatch) ResultChan() <-chan watch.Event {
+	return sw.result
+}
+
+func (sw *sourceWatch) Stop() {
+	sw.stopOnce.Do(func() {
+		close(sw.stopped)
+		sw.Interface.Stop()
+	})
+}
+
+// sharedList is the list of the objects of any kind returned to the informers of the subscribers.
+type sharedList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []runtime.Object
+}
+
+func (l *sharedList) DeepCopyObject() runtime.Object {
+	res := &sharedList{TypeMeta: l.TypeMeta}
+	l.ListMeta.DeepCopyInto(&res.ListMeta)
+	for _, item := range l.Items {
+		res.Items = append(res.Items, item.DeepCopyObject())
+	}
+	return res
+}
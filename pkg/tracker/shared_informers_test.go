@@ -0,0 +1,263 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+)
+
+const testSharedNamespace = "default"
+
+// testSubscriber tracks the Pod through the SharedInformers as the Pod tracker does and keeps its connection stats,
+// nil SharedInformers means the Pod is watched directly.
+type testSubscriber struct {
+	podName string
+	synced  chan struct{}
+	done    chan error
+
+	mux   sync.Mutex
+	stats []ConnectionStats
+}
+
+func runTestSubscriber(ctx context.Context, shared *SharedInformers, kube kubernetes.Interface, podName string, maxConsecutiveFailures int) *testSubscriber {
+	s := &testSubscriber{podName: podName, synced: make(chan struct{}), done: make(chan error, 1)}
+
+	tweakListOptions := func(options metav1.ListOptions) metav1.ListOptions {
+		options.FieldSelector = fields.OneTermEqualSelector("metadata.name", podName).String()
+		return options
+	}
+	lw := shared.ListWatch(testSharedNamespace, &corev1.Pod{}, tweakListOptions, &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return kube.CoreV1().Pods(testSharedNamespace).List(ctx, tweakListOptions(options))
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return kube.CoreV1().Pods(testSharedNamespace).Watch(ctx, tweakListOptions(options))
+		},
+	})
+
+	retry := ConnectionRetry{MaxConsecutiveFailures: maxConsecutiveFailures, Observer: func(stats ConnectionStats) {
+		s.mux.Lock()
+		defer s.mux.Unlock()
+		s.stats = append(s.stats, stats)
+	}}
+
+	var syncOnce sync.Once
+	go func() {
+		_, err := UntilWithSync(ctx, lw, &corev1.Pod{}, retry, func(e watch.Event) (bool, error) {
+			if pod, ok := e.Object.(*corev1.Pod); ok && pod.Name == podName {
+				syncOnce.Do(func() { close(s.synced) })
+			}
+			return false, nil
+		})
+		s.done <- err
+	}()
+
+	return s
+}
+
+// hasStats returns true when the subscriber has been notified about the connection stats matching the filter.
+func (s *testSubscriber) hasStats(filter func(stats ConnectionStats) bool) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	for _, stats := range s.stats {
+		if filter(stats) {
+			return true
+		}
+	}
+	return false
+}
+
+func waitForTestCondition(t *testing.T, desc string, isDone func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for !isDone() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", desc)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// newCountingClientset returns the fake clientset with the Pods, which counts the list and watch requests of Pods.
+// The first watch request is served by the returned fake watcher.
+func newCountingClientset(podsNames []string) (*fake.Clientset, *int32, *int32, *watch.RaceFreeFakeWatcher) {
+	var objects []runtime.Object
+	for _, name := range podsNames {
+		objects = append(objects, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testSharedNamespace, ResourceVersion: "1"}})
+	}
+	kube := fake.NewSimpleClientset(objects...)
+
+	var lists, watches int32
+	firstWatcher := watch.NewRaceFreeFake()
+
+	kube.PrependReactor("list", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+		atomic.AddInt32(&lists, 1)
+		return false, nil, nil
+	})
+	kube.PrependWatchReactor("pods", func(k8stesting.Action) (bool, watch.Interface, error) {
+		if atomic.AddInt32(&watches, 1) == 1 {
+			return true, firstWatcher, nil
+		}
+		return false, nil, nil
+	})
+
+	return kube, &lists, &watches, firstWatcher
+}
+
+func TestSharedInformersSingleListWatch(t *testing.T) {
+	podsNames := []string{"app-0", "app-1", "app-2", "app-3", "app-4"}
+	kube, lists, watches, _ := newCountingClientset(podsNames)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	shared := NewSharedInformers(ctx, kube)
+
+	var subscribers []*testSubscriber
+	for _, name := range podsNames {
+		subscribers = append(subscribers, runTestSubscriber(ctx, shared, kube, name, -1))
+	}
+	for _, s := range subscribers {
+		select {
+		case <-s.synced:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timed out waiting for Pod %s", s.podName)
+		}
+	}
+	waitForTestCondition(t, "shared watch", func() bool { return atomic.LoadInt32(watches) > 0 })
+
+	if n := atomic.LoadInt32(lists); n != 1 {
+		t.Errorf("expected 1 list request for %d trackers, got %d", len(subscribers), n)
+	}
+	if n := atomic.LoadInt32(watches); n != 1 {
+		t.Errorf("expected 1 watch request for %d trackers, got %d", len(subscribers), n)
+	}
+
+	cancel()
+	for _, s := range subscribers {
+		if err := <-s.done; err != nil && err != context.Canceled && err != wait.ErrWaitTimeout {
+			t.Errorf("unexpected error of Pod %s tracker: %s", s.podName, err)
+		}
+	}
+}
+
+// TestSharedInformersWatchErrorAfterSync fails the shared watch once all trackers are synced:
+// each tracker observes the failure as its own connection failure and recovers after the shared informer lists the Pods again.
+func TestSharedInformersWatchErrorAfterSync(t *testing.T) {
+	podsNames := []string{"app-0", "app-1", "app-2"}
+	kube, lists, watches, firstWatcher := newCountingClientset(podsNames)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	shared := NewSharedInformers(ctx, kube)
+
+	var subscribers []*testSubscriber
+	for _, name := range podsNames {
+		subscribers = append(subscribers, runTestSubscriber(ctx, shared, kube, name, -1))
+	}
+	for _, s := range subscribers {
+		select {
+		case <-s.synced:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timed out waiting for Pod %s", s.podName)
+		}
+	}
+	waitForTestCondition(t, "shared watch", func() bool { return atomic.LoadInt32(watches) > 0 })
+
+	firstWatcher.Error(&metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    500,
+		Reason:  metav1.StatusReasonInternalError,
+		Message: "etcd is unavailable",
+	})
+
+	for _, s := range subscribers {
+		waitForTestCondition(t, fmt.Sprintf("failure of Pod %s tracker", s.podName), func() bool {
+			return s.hasStats(func(stats ConnectionStats) bool {
+				return stats.ConsecutiveFailures > 0 && strings.Contains(stats.LastError, "etcd is unavailable")
+			})
+		})
+	}
+	for _, s := range subscribers {
+		waitForTestCondition(t, fmt.Sprintf("recovery of Pod %s tracker", s.podName), func() bool {
+			return s.hasStats(func(stats ConnectionStats) bool { return stats.ConsecutiveFailures == 0 })
+		})
+	}
+
+	// Only the shared informer lists the Pods again
+	if n := atomic.LoadInt32(lists); n != 2 {
+		t.Errorf("expected 2 list requests, got %d", n)
+	}
+}
+
+func TestSharedSubscriptionQueueLimit(t *testing.T) {
+	subscription := &sharedSubscription{
+		match:   func(runtime.Object) bool { return true },
+		known:   make(map[string]uint64),
+		pending: make(chan struct{}, 1),
+		closed:  make(chan struct{}),
+	}
+	source := &sharedSource{ctx: context.Background(), subscriptions: map[*sharedSubscription]bool{subscription: true}}
+
+	newPod := func(i int) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("app-%d", i), Namespace: testSharedNamespace, ResourceVersion: "1"}}
+	}
+
+	for i := 0; i < sharedSubscriptionQueueLimit; i++ {
+		subscription.handle(newPod(i), false)
+	}
+	if subscription.err != nil || len(subscription.queue) != sharedSubscriptionQueueLimit {
+		t.Fatalf("expected %d queued events, got %d with error %v", sharedSubscriptionQueueLimit, len(subscription.queue), subscription.err)
+	}
+
+	subscription.handle(newPod(sharedSubscriptionQueueLimit), false)
+	if !apierrors.IsResourceExpired(subscription.err) || subscription.queue != nil {
+		t.Fatalf("expected the queue dropped with expired error, got %d queued events with error %v", len(subscription.queue), subscription.err)
+	}
+
+	// Events after the overflow are not queued, the subscriber lists the objects anew
+	subscription.handle(newPod(sharedSubscriptionQueueLimit+1), false)
+	if subscription.queue != nil {
+		t.Errorf("expected no queued events after the overflow, got %d", len(subscription.queue))
+	}
+
+	w := newSharedWatch(source, subscription)
+	defer w.Stop()
+
+	select {
+	case event := <-w.ResultChan():
+		if event.Type != watch.Error || !apierrors.IsResourceExpired(apierrors.FromObject(event.Object)) {
+			t.Errorf("expected expired error event, got %s %v", event.Type, event.Object)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("timed out waiting for the watch event")
+	}
+
+	select {
+	case _, ok := <-w.ResultChan():
+		if ok {
+			t.Errorf("expected the watch closed after the error event")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("timed out waiting for the watch to be closed")
+	}
+}
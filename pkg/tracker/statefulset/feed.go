@@ -53,6 +53,12 @@ func (f *feed) Track(name, namespace string, kube kubernetes.Interface, opts tra
 	stsTracker := NewTracker(name, namespace, kube, opts)
 
 	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				errorChan <- tracker.NewPanicError(recovered, opts.PanicsAreFatal)
+			}
+		}()
+
 		if debug.Debug() {
 			fmt.Printf("  goroutine: start statefulset/%s tracker\n", name)
 		}
@@ -164,6 +170,27 @@ func (f *feed) Track(name, namespace string, kube kubernetes.Interface, opts tra
 				}
 			}
 
+		case status := <-stsTracker.Deleted:
+			f.setStatus(status)
+
+			if f.OnDeletedFunc != nil {
+				err := f.OnDeletedFunc()
+				if err == tracker.StopTrack {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			} else if f.OnStatusFunc != nil {
+				err := f.OnStatusFunc(status)
+				if err == tracker.StopTrack {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+
 		case status := <-stsTracker.Status:
 			f.setStatus(status)
 
@@ -0,0 +1,120 @@
+package statefulset
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+
+	"github.com/werf/kubedog/pkg/tracker/pod"
+)
+
+// OrderedRollout is the progress of StatefulSet with OrderedReady Pod management policy, which creates and updates
+// its Pods one ordinal at a time, so a single not ready Pod blocks the rollout of all the following ordinals.
+type OrderedRollout struct {
+	Replicas int32
+
+	// RollingOrdinal is the ordinal of the Pod the rollout is waiting on.
+	RollingOrdinal int32
+	RollingPodName string
+	// RollingSince is the time the rollout started waiting on RollingOrdinal.
+	RollingSince time.Time
+
+	// OldRevisionOrdinals are the ordinals of the Pods still on the old revision, ascending.
+	// The Pods are updated from the highest ordinal to the lowest one.
+	OldRevisionOrdinals []int32
+}
+
+// String returns e.g. "rolling ordinal 4 of 0..9 (pods 0..3 still on old revision)".
+func (r *OrderedRollout) String() string {
+	res := fmt.Sprintf("rolling ordinal %d of %s", r.RollingOrdinal, formatOrdinalsRange(0, r.Replicas-1))
+	switch len(r.OldRevisionOrdinals) {
+	case 0:
+	case 1:
+		res += fmt.Sprintf(" (pod %d still on old revision)", r.OldRevisionOrdinals[0])
+	default:
+		res += fmt.Sprintf(" (pods %s still on old revision)", formatOrdinals(r.OldRevisionOrdinals))
+	}
+	return res
+}
+
+// getOrderedRollout returns the ordinal the rollout of StatefulSet is waiting on: the lowest ordinal which Pod is not ready,
+// or the highest ordinal above the partition which Pod is not updated yet. Nil is returned for Parallel Pod management policy
+// and when there is nothing to wait on.
+func getOrderedRollout(object *appsv1.StatefulSet, pods map[string]pod.PodStatus, newPodsNames []string) *OrderedRollout {
+	if object.Spec.PodManagementPolicy == appsv1.ParallelPodManagement || object.Spec.Replicas == nil || *object.Spec.Replicas == 0 || len(pods) == 0 {
+		return nil
+	}
+
+	isNewPod := make(map[string]bool)
+	for _, podName := range newPodsNames {
+		isNewPod[podName] = true
+	}
+
+	res := &OrderedRollout{Replicas: *object.Spec.Replicas, RollingOrdinal: -1}
+
+	for ordinal := int32(0); ordinal < res.Replicas; ordinal++ {
+		podStatus, hasKey := pods[ordinalPodName(object, ordinal)]
+		if !hasKey || podStatus.IsDeleted || !podStatus.IsReady {
+			res.RollingOrdinal = ordinal
+			break
+		}
+	}
+
+	// Old Pods are updated by the controller only with RollingUpdate strategy, the user deletes them with OnDelete one
+	if object.Spec.UpdateStrategy.Type == appsv1.RollingUpdateStatefulSetStrategyType {
+		var partition int32
+		if object.Spec.UpdateStrategy.RollingUpdate != nil && object.Spec.UpdateStrategy.RollingUpdate.Partition != nil {
+			partition = *object.Spec.UpdateStrategy.RollingUpdate.Partition
+		}
+
+		for ordinal := res.Replicas - 1; ordinal >= partition; ordinal-- {
+			podName := ordinalPodName(object, ordinal)
+			if podStatus, hasKey := pods[podName]; !hasKey || podStatus.IsDeleted || isNewPod[podName] {
+				continue
+			}
+
+			if res.RollingOrdinal == -1 {
+				res.RollingOrdinal = ordinal
+			} else if ordinal != res.RollingOrdinal {
+				res.OldRevisionOrdinals = append(res.OldRevisionOrdinals, ordinal)
+			}
+		}
+	}
+
+	if res.RollingOrdinal == -1 {
+		return nil
+	}
+
+	res.RollingPodName = ordinalPodName(object, res.RollingOrdinal)
+	sort.Slice(res.OldRevisionOrdinals, func(i, j int) bool { return res.OldRevisionOrdinals[i] < res.OldRevisionOrdinals[j] })
+
+	return res
+}
+
+func ordinalPodName(object *appsv1.StatefulSet, ordinal int32) string {
+	return fmt.Sprintf("%s-%d", object.Name, ordinal)
+}
+
+// formatOrdinals returns ascending ordinals with consecutive ones joined into ranges, e.g. "0..3, 5".
+func formatOrdinals(ordinals []int32) string {
+	var parts []string
+	for i := 0; i < len(ordinals); {
+		j := i
+		for j+1 < len(ordinals) && ordinals[j+1] == ordinals[j]+1 {
+			j++
+		}
+		parts = append(parts, formatOrdinalsRange(ordinals[i], ordinals[j]))
+		i = j + 1
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatOrdinalsRange(from, to int32) string {
+	if from == to {
+		return fmt.Sprintf("%d", from)
+	}
+	return fmt.Sprintf("%d..%d", from, to)
+}
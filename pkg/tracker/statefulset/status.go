@@ -2,6 +2,7 @@ package statefulset
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/werf/kubedog/pkg/tracker/indicators"
 	"github.com/werf/kubedog/pkg/tracker/pod"
@@ -21,6 +22,22 @@ type StatefulSetStatus struct {
 	WaitingForMessages []string
 	WarningMessages    []string
 
+	// RequiredReadyPodsMessage describes readiness of the StatefulSet
+	// which needs only some of its Pods to be ready, e.g. "ready 1/5 (required 1)"
+	RequiredReadyPodsMessage string
+
+	// RolloutStatusMessage is the status phrased as by kubectl rollout status,
+	// e.g. "Waiting for 2 pods to be ready..."
+	RolloutStatusMessage string
+
+	// Partition of the RollingUpdate strategy, only the Pods with ordinals not less than the partition are updated.
+	Partition int32
+	// PartitionMessage describes the partitioned rollout, e.g. "updated 3/5 (partition 2)".
+	PartitionMessage string
+
+	// OrderedRollout is set while StatefulSet with OrderedReady Pod management policy is not ready.
+	OrderedRollout *OrderedRollout
+
 	IsReady      bool
 	IsFailed     bool
 	FailedReason string
@@ -29,7 +46,7 @@ type StatefulSetStatus struct {
 	NewPodsNames []string
 }
 
-func NewStatefulSetStatus(object *appsv1.StatefulSet, statusGeneration uint64, isFailed bool, failedReason string, warningMessages []string, podsStatuses map[string]pod.PodStatus, newPodsNames []string) StatefulSetStatus {
+func NewStatefulSetStatus(object *appsv1.StatefulSet, statusGeneration uint64, isFailed bool, failedReason string, warningMessages []string, podsStatuses map[string]pod.PodStatus, newPodsNames []string, requiredReadyPodsCount int32) StatefulSetStatus {
 	res := StatefulSetStatus{
 		StatusGeneration:  statusGeneration,
 		StatefulSetStatus: object.Status,
@@ -41,6 +58,12 @@ func NewStatefulSetStatus(object *appsv1.StatefulSet, statusGeneration uint64, i
 		WarningMessages:   warningMessages,
 	}
 
+	if msg, _, err := StatefulSetRolloutStatus(object); err != nil {
+		res.RolloutStatusMessage = fmt.Sprintf("error: %s", err)
+	} else {
+		res.RolloutStatusMessage = strings.TrimSuffix(msg, "\n")
+	}
+
 	// TODO: share common code from deploy, ds and sts
 processingPodsStatuses:
 	for k, v := range podsStatuses {
@@ -90,16 +113,24 @@ processingPodsStatuses:
 	case appsv1.RollingUpdateStatefulSetStrategyType:
 		if object.Spec.Replicas != nil {
 			if object.Spec.UpdateStrategy.RollingUpdate != nil && object.Spec.UpdateStrategy.RollingUpdate.Partition != nil && *object.Spec.UpdateStrategy.RollingUpdate.Partition > 0 {
-				// Partitioned rollout
+				// Partitioned rollout is complete when the Pods with ordinals >= partition are updated and ready, as by kubectl
+
+				res.Partition = *object.Spec.UpdateStrategy.RollingUpdate.Partition
+				res.PartitionMessage = fmt.Sprintf("updated %d/%d (partition %d)", object.Status.UpdatedReplicas, *object.Spec.Replicas, res.Partition)
+
+				updatedTarget := *object.Spec.Replicas - res.Partition
+				if updatedTarget < 0 {
+					updatedTarget = 0
+				}
 
 				res.UpToDateIndicator = &indicators.Int64GreaterOrEqualConditionIndicator{
 					Value:       int64(object.Status.UpdatedReplicas),
-					TargetValue: int64(*object.Spec.Replicas - *object.Spec.UpdateStrategy.RollingUpdate.Partition),
+					TargetValue: int64(updatedTarget),
 				}
 
-				if object.Status.UpdatedReplicas < (*object.Spec.Replicas - *object.Spec.UpdateStrategy.RollingUpdate.Partition) {
+				if object.Status.UpdatedReplicas < updatedTarget || !isPartitionUpdated(object, res.Pods, newPodsNames, res.Partition) {
 					res.IsReady = false
-					res.WaitingForMessages = append(res.WaitingForMessages, fmt.Sprintf("up-to-date %d->%d (partitioned roll out)", object.Status.UpdatedReplicas, *object.Spec.Replicas-*object.Spec.UpdateStrategy.RollingUpdate.Partition))
+					res.WaitingForMessages = append(res.WaitingForMessages, res.PartitionMessage)
 				}
 			} else {
 				// Not a partitioned rollout
@@ -117,22 +148,15 @@ processingPodsStatuses:
 		}
 
 	case appsv1.OnDeleteStatefulSetStrategyType:
+		// The controller never updates the Pods with OnDelete strategy, so only readiness of the existing Pods is awaited
 		if object.Spec.Replicas != nil {
 			res.UpToDateIndicator = &indicators.Int64GreaterOrEqualConditionIndicator{
 				Value:       int64(object.Status.UpdatedReplicas),
 				TargetValue: int64(*object.Spec.Replicas),
 			}
 
-			// Workaround for old kubernetes (1.10), which does not update UpdateReplicas field
-			isReady := (object.Status.UpdatedReplicas == 0) && (object.Status.UpdateRevision == object.Status.CurrentRevision)
-
-			if !isReady {
-				isReady = object.Status.UpdatedReplicas >= *object.Spec.Replicas
-			}
-
-			if !isReady {
-				res.IsReady = false
-				res.WaitingForMessages = append(res.WaitingForMessages, fmt.Sprintf("up-to-date %d->%d (user should delete old pods manually now!)", object.Status.UpdatedReplicas, *object.Spec.Replicas))
+			if IsOnDeleteUpdatePending(object) {
+				res.WarningMessages = append(res.WarningMessages, fmt.Sprintf("OnDelete update strategy: %d/%d pods updated, old pods are updated only when deleted manually", object.Status.UpdatedReplicas, *object.Spec.Replicas))
 			}
 		}
 
@@ -140,13 +164,63 @@ processingPodsStatuses:
 		panic(fmt.Sprintf("StatefulSet %s UpdateStrategy.Type %#v is not supported", object.Name, object.Spec.UpdateStrategy.Type))
 	}
 
+	if !res.IsReady {
+		if res.OrderedRollout = getOrderedRollout(object, res.Pods, newPodsNames); res.OrderedRollout != nil {
+			res.WaitingForMessages = append(res.WaitingForMessages, res.OrderedRollout.String())
+		}
+	}
+
+	if object.Spec.Replicas != nil && object.Status.ObservedGeneration != 0 && object.Generation <= object.Status.ObservedGeneration {
+		if isReady, msg, isApplicable := pod.CheckRequiredReadyPods(res.Pods, newPodsNames, requiredReadyPodsCount, *object.Spec.Replicas); isApplicable {
+			res.IsReady = isReady
+			res.RequiredReadyPodsMessage = msg
+			res.WaitingForMessages = nil
+			if !isReady {
+				res.WaitingForMessages = []string{msg}
+			}
+		}
+	}
+
 	return res
 }
 
+// IsOnDeleteUpdatePending returns true when StatefulSet with OnDelete update strategy has Pods not updated to its update revision.
+func IsOnDeleteUpdatePending(object *appsv1.StatefulSet) bool {
+	if object.Spec.UpdateStrategy.Type != appsv1.OnDeleteStatefulSetStrategyType || object.Spec.Replicas == nil {
+		return false
+	}
+
+	// Old kubernetes (1.10) does not update UpdatedReplicas field, revisions are equal when there is nothing to update
+	return object.Status.UpdateRevision != object.Status.CurrentRevision && object.Status.UpdatedReplicas < *object.Spec.Replicas
+}
+
+// isPartitionUpdated returns true when the Pods with ordinals not less than the partition are of the update revision and ready.
+// Counters of the StatefulSet status are relied on until the Pods are known.
+func isPartitionUpdated(object *appsv1.StatefulSet, pods map[string]pod.PodStatus, newPodsNames []string, partition int32) bool {
+	if len(pods) == 0 {
+		return true
+	}
+
+	isNewPod := make(map[string]bool)
+	for _, podName := range newPodsNames {
+		isNewPod[podName] = true
+	}
+
+	for ordinal := partition; ordinal < *object.Spec.Replicas; ordinal++ {
+		podName := ordinalPodName(object, ordinal)
+		podStatus, hasKey := pods[podName]
+		if !hasKey || podStatus.IsDeleted || !podStatus.IsReady || !isNewPod[podName] {
+			return false
+		}
+	}
+
+	return true
+}
+
 // Status returns a message describing statefulset status, and a bool value indicating if the status is considered done.
 // A code from kubectl sources. Doesn't work well for OnDelete, downscale and partition: 0 case.
 // https://github.com/kubernetes/kubernetes/issues/72212
-// Now used only for debug purposes
+// Used for kubectl compatible RolloutStatusMessage and debug purposes
 func StatefulSetRolloutStatus(sts *appsv1.StatefulSet) (string, bool, error) {
 	if sts.Spec.UpdateStrategy.Type != appsv1.RollingUpdateStatefulSetStrategyType {
 		return "", true, fmt.Errorf("rollout status is only available for %s strategy type", appsv1.RollingUpdateStatefulSetStrategyType)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -13,7 +14,6 @@ import (
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
-	watchtools "k8s.io/client-go/tools/watch"
 
 	"github.com/werf/kubedog/pkg/tracker"
 	"github.com/werf/kubedog/pkg/tracker/debug"
@@ -39,17 +39,35 @@ type Tracker struct {
 	State      tracker.TrackerState
 	Conditions []string
 
-	lastObject   *appsv1.StatefulSet
-	failedReason string
-	podStatuses  map[string]pod.PodStatus
-	podRevisions map[string]string
+	lastObject                 *appsv1.StatefulSet
+	failedReason               string
+	podStatuses                map[string]pod.PodStatus
+	deletedPodsHistory         *pod.DeletedPodsHistory
+	stringsInterner            *utils.StringsInterner
+	initContainersStuckTimeout time.Duration
+	unschedulableTimeout       time.Duration
+	requiredReadyPodsCount     int32
+	listObserver               tracker.ListObserver
+	connectionRetry            tracker.ConnectionRetry
+	panicsAreFatal             bool
+	podRevisions               map[string]string
+
+	failOnDeleteUpdateStrategy bool
+	// onDeleteUpdateReported is set once the pending OnDelete update is reported as the failure,
+	// it is reset when there is nothing to update anymore.
+	onDeleteUpdateReported bool
+
+	// orderedRolloutPodName is the Pod the ordered rollout waits on since orderedRolloutSince.
+	orderedRolloutPodName string
+	orderedRolloutSince   time.Time
 
 	TrackedPodsNames []string
 
-	Added  chan StatefulSetStatus
-	Ready  chan StatefulSetStatus
-	Failed chan StatefulSetStatus
-	Status chan StatefulSetStatus
+	Added   chan StatefulSetStatus
+	Ready   chan StatefulSetStatus
+	Failed  chan StatefulSetStatus
+	Deleted chan StatefulSetStatus
+	Status  chan StatefulSetStatus
 
 	EventMsg    chan string
 	AddedPod    chan PodAddedReport
@@ -77,23 +95,40 @@ func NewTracker(name, namespace string, kube kubernetes.Interface, opts tracker.
 		Tracker: tracker.Tracker{
 			Kube:             kube,
 			Namespace:        namespace,
-			FullResourceName: fmt.Sprintf("sts/%s", name),
+			FullResourceName: tracker.FormatResourceID("sts", namespace, name),
 			ResourceName:     name,
 			LogsFromTime:     opts.LogsFromTime,
+
+			EventSeverityOverrides:  opts.EventSeverityOverrides,
+			LogsLimits:              opts.LogsLimits,
+			ContainerFailureReasons: opts.ContainerFailureReasons,
+			Suppressions:            opts.Suppressions,
+			LogStreamsBudget:        opts.LogStreamsBudget,
+			SharedInformers:         opts.SharedInformers,
 		},
 
-		Added:  make(chan StatefulSetStatus, 1),
-		Ready:  make(chan StatefulSetStatus, 0),
-		Failed: make(chan StatefulSetStatus, 0),
-		Status: make(chan StatefulSetStatus, 100),
+		Added:   make(chan StatefulSetStatus, 1),
+		Ready:   make(chan StatefulSetStatus, 0),
+		Failed:  make(chan StatefulSetStatus, 0),
+		Deleted: make(chan StatefulSetStatus, 0),
+		Status:  make(chan StatefulSetStatus, 100),
 
 		EventMsg:    make(chan string, 1),
 		AddedPod:    make(chan PodAddedReport, 10),
 		PodLogChunk: make(chan *replicaset.ReplicaSetPodLogChunk, 1000),
 		PodError:    make(chan PodErrorReport, 0),
 
-		podStatuses:  make(map[string]pod.PodStatus),
-		podRevisions: make(map[string]string),
+		podStatuses:                make(map[string]pod.PodStatus),
+		deletedPodsHistory:         pod.NewDeletedPodsHistory(opts.DeletedPodsHistoryLimit),
+		stringsInterner:            opts.StringsInterner,
+		initContainersStuckTimeout: opts.InitContainersStuckTimeout,
+		unschedulableTimeout:       opts.UnschedulableTimeout,
+		requiredReadyPodsCount:     int32(opts.RequiredReadyPodsCount),
+		listObserver:               opts.ListObserver,
+		connectionRetry:            opts.ConnectionRetry,
+		panicsAreFatal:             opts.PanicsAreFatal,
+		podRevisions:               make(map[string]string),
+		failOnDeleteUpdateStrategy: opts.FailOnDeleteUpdateStrategy,
 
 		resourceAdded:    make(chan *appsv1.StatefulSet, 1),
 		resourceModified: make(chan *appsv1.StatefulSet, 1),
@@ -135,9 +170,10 @@ func (d *Tracker) Track(ctx context.Context) (err error) {
 			d.State = tracker.ResourceDeleted
 			d.lastObject = nil
 			d.TrackedPodsNames = nil
+			d.deletedPodsHistory.Reset()
 			d.podStatuses = make(map[string]pod.PodStatus)
 			d.podRevisions = make(map[string]string)
-			d.Status <- StatefulSetStatus{}
+			d.Deleted <- StatefulSetStatus{}
 
 		case reason := <-d.resourceFailed:
 			if strings.Index(reason, "The POST operation against Pod could not be completed at this time, please try again.") != -1 {
@@ -155,7 +191,7 @@ func (d *Tracker) Track(ctx context.Context) (err error) {
 				var status StatefulSetStatus
 				if d.lastObject != nil {
 					d.StatusGeneration++
-					status = NewStatefulSetStatus(d.lastObject, d.StatusGeneration, (d.State == tracker.ResourceFailed), d.failedReason, nil, d.podStatuses, d.getNewPodsNames())
+					status = d.newStatus(nil)
 				} else {
 					status = StatefulSetStatus{IsFailed: true, FailedReason: reason}
 				}
@@ -163,11 +199,13 @@ func (d *Tracker) Track(ctx context.Context) (err error) {
 			}
 
 		case pod := <-d.podAddedRelay:
+			d.deletedPodsHistory.Forget(pod.Name)
+
 			d.podRevisions[pod.Name] = pod.Labels["controller-revision-hash"]
 
 			if d.lastObject != nil {
 				d.StatusGeneration++
-				status := NewStatefulSetStatus(d.lastObject, d.StatusGeneration, (d.State == tracker.ResourceFailed), d.failedReason, nil, d.podStatuses, d.getNewPodsNames())
+				status := d.newStatus(nil)
 
 				d.AddedPod <- PodAddedReport{
 					ReplicaSetPod: replicaset.ReplicaSetPod{
@@ -191,10 +229,14 @@ func (d *Tracker) Track(ctx context.Context) (err error) {
 					if name == donePodName {
 						// This Pod is no more tracked,
 						// but we need to update final
-						// Pod's status
-						if _, hasKey := d.podStatuses[name]; hasKey {
+						// Pod's status, the Pod deleted before
+						// its status is relayed is shown as well
+						if _, hasKey := d.podStatuses[name]; hasKey || status.IsDeleted {
 							d.podStatuses[name] = status
 						}
+						if status.IsDeleted {
+							d.deletedPodsHistory.Add(name)
+						}
 						continue trackedPodsIteration
 					}
 				}
@@ -203,15 +245,22 @@ func (d *Tracker) Track(ctx context.Context) (err error) {
 			}
 			d.TrackedPodsNames = trackedPodsNames
 
+			// Deleted Pods are pruned after the status with them as terminated has been reported
 			if d.lastObject != nil {
 				if err := d.handleStatefulSetState(ctx, d.lastObject, nil); err != nil {
 					return err
 				}
 			}
 
+			for _, prunedPodName := range d.deletedPodsHistory.Prune(d.podStatuses) {
+				delete(d.podRevisions, prunedPodName)
+			}
+
 		case podStatuses := <-d.podStatusesRelay:
 			for podName, podStatus := range podStatuses {
-				d.podStatuses[podName] = podStatus
+				if pod.IsTrackedPod(d.TrackedPodsNames, podName) {
+					d.podStatuses[podName] = podStatus
+				}
 			}
 			if d.lastObject != nil {
 				if err := d.handleStatefulSetState(ctx, d.lastObject, nil); err != nil {
@@ -232,11 +281,13 @@ func (d *Tracker) Track(ctx context.Context) (err error) {
 
 		case podContainerErrors := <-d.podContainerErrorsRelay:
 			for podName, containerError := range podContainerErrors {
-				d.podStatuses[podName] = containerError.PodStatus
+				if pod.IsTrackedPod(d.TrackedPodsNames, podName) {
+					d.podStatuses[podName] = containerError.PodStatus
+				}
 			}
 			if d.lastObject != nil {
 				d.StatusGeneration++
-				status := NewStatefulSetStatus(d.lastObject, d.StatusGeneration, (d.State == tracker.ResourceFailed), d.failedReason, nil, d.podStatuses, d.getNewPodsNames())
+				status := d.newStatus(nil)
 
 				for podName, containerError := range podContainerErrors {
 					d.PodError <- PodErrorReport{
@@ -274,17 +325,18 @@ func (d *Tracker) runStatefulSetInformer(ctx context.Context) {
 		options.FieldSelector = fields.OneTermEqualSelector("metadata.name", d.ResourceName).String()
 		return options
 	}
-	lw := &cache.ListWatch{
+	lw := d.SharedInformers.ListWatch(d.Namespace, &appsv1.StatefulSet{}, tweakListOptions, &cache.ListWatch{
 		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
 			return client.AppsV1().StatefulSets(d.Namespace).List(ctx, tweakListOptions(options))
 		},
 		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
 			return client.AppsV1().StatefulSets(d.Namespace).Watch(ctx, tweakListOptions(options))
 		},
-	}
+	})
+	lw.ListFunc = tracker.ObserveList(lw.ListFunc, d.listObserver)
 
 	go func() {
-		_, err := watchtools.UntilWithSync(ctx, lw, &appsv1.StatefulSet{}, nil, func(e watch.Event) (bool, error) {
+		_, err := tracker.UntilWithSync(ctx, lw, &appsv1.StatefulSet{}, d.connectionRetry, func(e watch.Event) (bool, error) {
 			if debug.Debug() {
 				fmt.Printf("    statefulset/%s event: %#v\n", d.ResourceName, e.Type)
 			}
@@ -316,7 +368,10 @@ func (d *Tracker) runStatefulSetInformer(ctx context.Context) {
 		})
 
 		if err := tracker.AdaptInformerError(err); err != nil {
-			d.errors <- err
+			select {
+			case d.errors <- err:
+			case <-ctx.Done():
+			}
 		}
 
 		if debug.Debug() {
@@ -343,9 +398,24 @@ func (d *Tracker) runPodTracker(_ctx context.Context, podName string) error {
 	if !d.LogsFromTime.IsZero() {
 		podTracker.LogsFromTime = d.LogsFromTime
 	}
+	podTracker.StringsInterner = d.stringsInterner
+	podTracker.InitContainersStuckTimeout = d.initContainersStuckTimeout
+	podTracker.UnschedulableTimeout = d.unschedulableTimeout
+	podTracker.EventSeverityOverrides = d.EventSeverityOverrides
+	podTracker.LogsLimits = d.LogsLimits
+	podTracker.ContainerFailureReasons = d.ContainerFailureReasons
+	podTracker.Suppressions = d.Suppressions
+	podTracker.LogStreamsBudget = d.LogStreamsBudget
+	podTracker.SharedInformers = d.SharedInformers
 	d.TrackedPodsNames = append(d.TrackedPodsNames, podName)
 
 	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				errorChan <- tracker.NewPanicError(recovered, d.panicsAreFatal)
+			}
+		}()
+
 		if debug.Debug() {
 			fmt.Printf("Starting StatefulSet's `%s` Pod `%s` tracker. pod state: %v\n", d.ResourceName, podTracker.ResourceName, podTracker.State)
 		}
@@ -381,7 +451,7 @@ func (d *Tracker) runPodTracker(_ctx context.Context, podName string) error {
 				d.podStatusesRelay <- map[string]pod.PodStatus{podTracker.ResourceName: status}
 
 			case msg := <-podTracker.EventMsg:
-				d.EventMsg <- fmt.Sprintf("po/%s %s", podTracker.ResourceName, msg)
+				d.EventMsg <- fmt.Sprintf("%s %s", tracker.FormatResourceID("po", podTracker.Namespace, podTracker.ResourceName), msg)
 			case chunk := <-podTracker.ContainerLogChunk:
 				d.podLogChunksRelay <- map[string]*pod.ContainerLogChunk{podTracker.ResourceName: chunk}
 			case report := <-podTracker.ContainerError:
@@ -404,14 +474,28 @@ func (d *Tracker) handleStatefulSetState(ctx context.Context, object *appsv1.Sta
 	d.lastObject = object
 	d.StatusGeneration++
 
-	status := NewStatefulSetStatus(object, d.StatusGeneration, (d.State == tracker.ResourceFailed), d.failedReason, warningMessages, d.podStatuses, d.getNewPodsNames())
+	status := d.newStatus(warningMessages)
+
+	// The pending OnDelete update is reported once, not on every status update while the Pods are not deleted
+	isFailed := status.IsFailed
+	if d.failOnDeleteUpdateStrategy && IsOnDeleteUpdatePending(object) {
+		if !status.IsFailed {
+			status.IsReady = false
+			status.IsFailed = true
+			status.FailedReason = fmt.Sprintf("OnDelete update strategy: %d/%d pods updated, old pods are not updated until deleted manually", object.Status.UpdatedReplicas, *object.Spec.Replicas)
+		}
+		isFailed = !d.onDeleteUpdateReported || d.failedReason != ""
+		d.onDeleteUpdateReported = true
+	} else {
+		d.onDeleteUpdateReported = false
+	}
 
 	switch d.State {
 	case tracker.Initial:
 		d.runPodsInformer(ctx, object)
 		d.runEventsInformer(ctx, object)
 
-		if status.IsFailed {
+		if isFailed {
 			d.State = tracker.ResourceFailed
 			d.Failed <- status
 		} else if status.IsReady {
@@ -422,7 +506,7 @@ func (d *Tracker) handleStatefulSetState(ctx context.Context, object *appsv1.Sta
 			d.Added <- status
 		}
 	case tracker.ResourceAdded, tracker.ResourceFailed:
-		if status.IsFailed {
+		if isFailed {
 			d.State = tracker.ResourceFailed
 			d.Failed <- status
 		} else if status.IsReady {
@@ -431,10 +515,10 @@ func (d *Tracker) handleStatefulSetState(ctx context.Context, object *appsv1.Sta
 		} else {
 			d.Status <- status
 		}
-	case tracker.ResourceSucceeded:
+	case tracker.ResourceReady, tracker.ResourceSucceeded:
 		d.Status <- status
 	case tracker.ResourceDeleted:
-		if status.IsFailed {
+		if isFailed {
 			d.State = tracker.ResourceFailed
 			d.Failed <- status
 		} else if status.IsReady {
@@ -456,6 +540,26 @@ func (d *Tracker) runEventsInformer(ctx context.Context, object *appsv1.Stateful
 	eventInformer.Run(ctx)
 }
 
+// newStatus returns the status of the last object, the time the ordered rollout started waiting on the Pod is kept
+// while it waits on the same Pod.
+func (d *Tracker) newStatus(warningMessages []string) StatefulSetStatus {
+	status := NewStatefulSetStatus(d.lastObject, d.StatusGeneration, (d.State == tracker.ResourceFailed), d.failedReason, warningMessages, d.podStatuses, d.getNewPodsNames(), d.requiredReadyPodsCount)
+
+	if status.OrderedRollout == nil {
+		d.orderedRolloutPodName = ""
+		d.orderedRolloutSince = time.Time{}
+		return status
+	}
+
+	if status.OrderedRollout.RollingPodName != d.orderedRolloutPodName {
+		d.orderedRolloutPodName = status.OrderedRollout.RollingPodName
+		d.orderedRolloutSince = time.Now()
+	}
+	status.OrderedRollout.RollingSince = d.orderedRolloutSince
+
+	return status
+}
+
 func (d *Tracker) getNewPodsNames() []string {
 	res := []string{}
 
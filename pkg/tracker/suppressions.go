@@ -0,0 +1,115 @@
+package tracker
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+)
+
+// Suppressions drop the events and the status conditions of the tracked resources by event reasons and condition types
+// before they enter the statuses, e.g. noisy preemption notices of spot nodes. Patterns are case-insensitive globs
+// (e.g. "preempt*"), suppressed events and conditions are counted, see Stats.
+type Suppressions struct {
+	eventReasons   []string
+	conditionTypes []string
+
+	mux              sync.Mutex
+	eventsCounts     map[string]int
+	conditionsCounts map[string]int
+}
+
+// SuppressionStats are the numbers of suppressed events by reason and suppressed conditions by type.
+// Conditions are counted every time the status of the resource is updated.
+type SuppressionStats struct {
+	Events     map[string]int
+	Conditions map[string]int
+}
+
+// NewSuppressions returns nil when there is nothing to suppress.
+func NewSuppressions(eventReasons, conditionTypes []string) (*Suppressions, error) {
+	if len(eventReasons) == 0 && len(conditionTypes) == 0 {
+		return nil, nil
+	}
+
+	s := &Suppressions{
+		eventsCounts:     make(map[string]int),
+		conditionsCounts: make(map[string]int),
+	}
+
+	for _, pattern := range eventReasons {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("bad suppressed event reason pattern %q: %s", pattern, err)
+		}
+		s.eventReasons = append(s.eventReasons, strings.ToLower(pattern))
+	}
+
+	for _, pattern := range conditionTypes {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("bad suppressed condition type pattern %q: %s", pattern, err)
+		}
+		s.conditionTypes = append(s.conditionTypes, strings.ToLower(pattern))
+	}
+
+	return s, nil
+}
+
+// IsEventSuppressed returns true and counts the event when its reason is suppressed. Nil Suppressions suppress nothing.
+func (s *Suppressions) IsEventSuppressed(reason string) bool {
+	if s == nil || !matchesAnyPattern(s.eventReasons, reason) {
+		return false
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.eventsCounts[reason]++
+
+	return true
+}
+
+// IsConditionSuppressed returns true and counts the condition when its type is suppressed. Nil Suppressions suppress nothing.
+func (s *Suppressions) IsConditionSuppressed(conditionType string) bool {
+	if s == nil || !matchesAnyPattern(s.conditionTypes, conditionType) {
+		return false
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.conditionsCounts[conditionType]++
+
+	return true
+}
+
+// HasConditionTypes returns true when some condition types are suppressed, so the conditions should be filtered.
+func (s *Suppressions) HasConditionTypes() bool {
+	return s != nil && len(s.conditionTypes) > 0
+}
+
+func (s *Suppressions) Stats() SuppressionStats {
+	res := SuppressionStats{Events: make(map[string]int), Conditions: make(map[string]int)}
+	if s == nil {
+		return res
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	for reason, count := range s.eventsCounts {
+		res.Events[reason] = count
+	}
+	for conditionType, count := range s.conditionsCounts {
+		res.Conditions[conditionType] = count
+	}
+
+	return res
+}
+
+func matchesAnyPattern(patterns []string, value string) bool {
+	value = strings.ToLower(value)
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, value); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -6,9 +6,16 @@ import (
 	"fmt"
 	"time"
 
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/werf/kubedog/pkg/display"
+	"github.com/werf/kubedog/pkg/utils"
 )
 
 var (
@@ -29,20 +36,123 @@ const (
 
 type TrackerState string
 
+// EventSeverity is how the events of the tracked resource with some reason are treated.
+type EventSeverity string
+
+const (
+	// EventSeverityIgnore events are neither failures nor warnings, their messages are shown only as service messages.
+	EventSeverityIgnore EventSeverity = "Ignore"
+	// EventSeverityWarning events are shown as warnings of the resource, but are not counted as failures.
+	EventSeverityWarning EventSeverity = "Warning"
+	// EventSeverityFailure events are counted as failures of the resource.
+	EventSeverityFailure EventSeverity = "Failure"
+)
+
 type Tracker struct {
 	Kube             kubernetes.Interface
 	Namespace        string
 	ResourceName     string
-	FullResourceName string // full resource name with resource kind (deploy/superapp)
+	FullResourceName string // resource id, see FormatResourceID (deploy/myns/superapp)
 	LogsFromTime     time.Time
 
+	// EventSeverityOverrides replace the built-in severity of the events by their reasons, optional.
+	EventSeverityOverrides map[string]EventSeverity
+	// LogsLimits of the Pods containers logs, optional.
+	LogsLimits LogsLimits
+	// ContainerFailureReasons are the waiting reasons of the Pods containers reported as container errors,
+	// nil means pod.DefaultContainerFailureReasons.
+	ContainerFailureReasons []string
+	// Suppressions of the events and the status conditions, optional.
+	Suppressions *Suppressions
+	// LogStreamsBudget shared by the Pods containers log streams, optional.
+	LogStreamsBudget *LogStreamsBudget
+	// SharedInformers serving the informers of the resource and its Pods, optional.
+	SharedInformers *SharedInformers
+
 	StatusGeneration uint64
 }
 
+// LogsLimits limit the logs streamed from the containers of the tracked Pods.
+type LogsLimits struct {
+	// TailLines is the number of the last lines of the container log shown when the streaming starts, nil means all lines.
+	TailLines *int64
+	// MaxLines and MaxBytes of the log streamed from the container, after which the streaming is stopped
+	// until the container is restarted, 0 or negative value means no limit.
+	MaxLines int
+	MaxBytes int64
+}
+
 type Options struct {
 	ParentContext context.Context
 	Timeout       time.Duration
 	LogsFromTime  time.Time
+
+	// DeletedPodsHistoryLimit is a number of deleted Pods retained in the controller status,
+	// 0 means pod.DefaultDeletedPodsHistoryLimit, negative value means do not retain deleted Pods.
+	DeletedPodsHistoryLimit int
+
+	// StringsInterner deduplicates repeated strings of the Pods statuses, optional.
+	StringsInterner *utils.StringsInterner
+
+	// ListObserver is notified about results of the tracked resource informer list requests, optional.
+	ListObserver ListObserver
+	// ConnectionRetry of the tracked resource informer, the tracker returns *ConnectionLostError when the retries are exhausted.
+	ConnectionRetry ConnectionRetry
+
+	// InitContainersStuckTimeout is the period without init containers progress after which the Pod is reported as failed,
+	// 0 means pod.DefaultInitContainersStuckTimeout, negative value disables the check.
+	InitContainersStuckTimeout time.Duration
+	// UnschedulableTimeout is the period the Pod stays unschedulable after which it is reported as failed, e.g. to let
+	// cluster-autoscaler add nodes, 0 means pod.DefaultUnschedulableTimeout, negative value disables the check.
+	UnschedulableTimeout time.Duration
+
+	// MainContainers of the Job Pods, by default all containers of the Job template except known sidecars.
+	MainContainers []string
+	// TreatMainContainerExitAsJobCompletion makes the Job succeeded (or failed) by exit codes of the main containers
+	// when its Pods are kept running by sidecars.
+	TreatMainContainerExitAsJobCompletion bool
+
+	// FailOnDeleteUpdateStrategy fails the StatefulSet with OnDelete update strategy which Pods are pending the update,
+	// as the controller never updates them. By default the warning is shown and only readiness of the existing Pods is awaited.
+	FailOnDeleteUpdateStrategy bool
+
+	// StrictDaemonSetAvailability makes the DaemonSet ready only when all its desired Pods are available and reports errors
+	// of the Pods on cordoned or NotReady nodes. By default maxUnavailable Pods are tolerated and such Pods are skipped.
+	StrictDaemonSetAvailability bool
+
+	// RequiredReadyPodsCount makes the Deployment, StatefulSet or DaemonSet ready as soon as this number
+	// of its Pods of the current revision are ready, 0 means all desired Pods should be ready.
+	RequiredReadyPodsCount int
+
+	// EventSeverityOverrides replace the built-in severity of the events by their reasons, e.g. {"Unhealthy": EventSeverityFailure},
+	// reasons which are not overridden keep the built-in severity (events with "Failed" in the reason are failures).
+	EventSeverityOverrides map[string]EventSeverity
+
+	// LogsLimits of the Pods containers logs, e.g. to not flood the output with the logs of crash-looping containers.
+	LogsLimits LogsLimits
+
+	// ContainerFailureReasons are the waiting reasons of the Pods containers (ContainerStatuses[].State.Waiting.Reason)
+	// reported as container errors, which are handled as the resource failures. Nil means pod.DefaultContainerFailureReasons,
+	// e.g. exclude CrashLoopBackOff to wait for the crash-looping containers to recover.
+	ContainerFailureReasons []string
+
+	// Suppressions of the events and the status conditions of the tracked resources and their Pods, optional.
+	Suppressions *Suppressions
+
+	// LogStreamsBudget limits the number of the containers log streams open at once, it is shared by all trackers
+	// using it. Nil means no limit.
+	LogStreamsBudget *LogStreamsBudget
+
+	// SharedInformers serve the watches of all trackers using it with a single watch per kind and namespace,
+	// see NewSharedInformers. Nil means each tracker opens its own watches.
+	SharedInformers *SharedInformers
+
+	// Display receives the output of the tracking call, e.g. display.NewDisplay(buf, nil) captures it into a buffer.
+	// Nil means the global display.Out and display.Err.
+	Display *display.Display
+
+	// PanicsAreFatal disables recovering of panics in the tracker goroutines, which are otherwise returned as *PanicError.
+	PanicsAreFatal bool
 }
 
 type ResourceError struct {
@@ -65,3 +175,26 @@ func AdaptInformerError(err error) error {
 	}
 	return err
 }
+
+// ListObserver receives whether the tracked resource exists or the error of the list request.
+// Informer retries failed list requests, so the observer can be called many times.
+type ListObserver func(resourceFound bool, err error)
+
+// ObserveList wraps ListFunc of the tracked resource informer to report list results into observer.
+func ObserveList(listFunc cache.ListFunc, observer ListObserver) cache.ListFunc {
+	if observer == nil {
+		return listFunc
+	}
+
+	return func(options metav1.ListOptions) (runtime.Object, error) {
+		object, err := listFunc(options)
+		if err != nil {
+			observer(false, err)
+			return object, err
+		}
+
+		observer(meta.LenList(object) > 0, nil)
+
+		return object, nil
+	}
+}
@@ -0,0 +1,71 @@
+package tracker
+
+import (
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestObserveList(t *testing.T) {
+	listErr := errors.New("connection refused")
+
+	tests := []struct {
+		name   string
+		object runtime.Object
+		err    error
+
+		expectedFound bool
+		expectedErr   error
+	}{
+		{
+			name:          "resource found",
+			object:        &corev1.PodList{Items: []corev1.Pod{{ObjectMeta: metav1.ObjectMeta{Name: "app"}}}},
+			expectedFound: true,
+		},
+		{
+			name:   "resource not found",
+			object: &corev1.PodList{},
+		},
+		{
+			name:        "list failed",
+			err:         listErr,
+			expectedErr: listErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			listFunc := ObserveList(func(metav1.ListOptions) (runtime.Object, error) {
+				return tt.object, tt.err
+			}, func(resourceFound bool, err error) {
+				calls++
+				if resourceFound != tt.expectedFound || err != tt.expectedErr {
+					t.Errorf("expected observed found=%t err=%v, got found=%t err=%v", tt.expectedFound, tt.expectedErr, resourceFound, err)
+				}
+			})
+
+			object, err := listFunc(metav1.ListOptions{})
+			if object != tt.object || err != tt.err {
+				t.Errorf("expected the list result passed through, got %v, %v", object, err)
+			}
+			if calls != 1 {
+				t.Errorf("expected observer called once, got %d", calls)
+			}
+		})
+	}
+}
+
+func TestObserveListWithoutObserver(t *testing.T) {
+	list := &corev1.PodList{}
+	listFunc := ObserveList(func(metav1.ListOptions) (runtime.Object, error) {
+		return list, nil
+	}, nil)
+
+	if object, err := listFunc(metav1.ListOptions{}); object != list || err != nil {
+		t.Errorf("expected the list result passed through, got %v, %v", object, err)
+	}
+}
@@ -2,6 +2,7 @@ package elimination
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"sync"
@@ -12,14 +13,21 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
 
 	"k8s.io/client-go/dynamic"
-	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+
+	"github.com/werf/kubedog/pkg/display"
 )
 
+const defaultStatusProgressPeriod = 5 * time.Second
+
 type EliminationTrackerSpec struct {
 	ResourceName         string
 	Namespace            string
@@ -38,11 +46,34 @@ func (spec *EliminationTrackerSpec) String() string {
 }
 
 type EliminationTrackerOptions struct {
-	Timeout              time.Duration
+	// Timeout limits the waiting for all resources, e.g. stuck on finalizers, *EliminationTimeoutError is returned when exceeded.
+	Timeout time.Duration
+	// StatusProgressPeriod is the period of reports about the resources which still exist,
+	// 5 seconds by default, negative value disables reports.
 	StatusProgressPeriod time.Duration
+	// Display receives the reports, nil means the default logboek logger.
+	Display *display.Display
+}
+
+// EliminationTimeoutError is returned by TrackUntilEliminated when Timeout exceeded, Resources are the last known
+// statuses of the resources which still exist.
+type EliminationTimeoutError struct {
+	Timeout   time.Duration
+	Resources []ResourceStatus
+}
+
+func (e *EliminationTimeoutError) Error() string {
+	var resources []string
+	for _, status := range e.Resources {
+		resources = append(resources, status.String())
+	}
+	return fmt.Sprintf("resources are not eliminated in %s: %s", e.Timeout, strings.Join(resources, "; "))
 }
 
+// TrackUntilEliminated waits until all resources of the specs are deleted, the resources already absent are not waited for.
+// Resources which still exist are reported every StatusProgressPeriod, e.g. "still terminating: ns/x apps/v1 deployments/x (finalizers: foo)".
 func TrackUntilEliminated(ctx context.Context, kubeDynamicClient dynamic.Interface, specs []*EliminationTrackerSpec, opts EliminationTrackerOptions) error {
+	parentCtx := ctx
 	if opts.Timeout != 0 {
 		_ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
 		defer cancel()
@@ -53,27 +84,39 @@ func TrackUntilEliminated(ctx context.Context, kubeDynamicClient dynamic.Interfa
 		ctx = _ctx
 	}
 
-	var outputMux sync.Mutex
-	errorChan := make(chan error, 0)
+	var statusesMux sync.Mutex
+	statuses := make(map[*EliminationTrackerSpec]ResourceStatus)
+	for _, spec := range specs {
+		statuses[spec] = ResourceStatus{Spec: spec}
+	}
+
+	type trackerResult struct {
+		Spec *EliminationTrackerSpec
+		Err  error
+	}
+	resultChan := make(chan trackerResult, len(specs))
 
 	for _, spec := range specs {
 		tracker := NewEliminationTracker(kubeDynamicClient, spec)
 
-		go func() {
-			errorChan <- tracker.Track(ctx, opts)
-		}()
+		go func(spec *EliminationTrackerSpec) {
+			resultChan <- trackerResult{Spec: spec, Err: tracker.Track(ctx, opts)}
+		}(spec)
 
 		go func() {
 			for {
 				select {
 				case resourceStatus := <-tracker.ResourceStatus:
 					func() {
-						outputMux.Lock()
-						defer outputMux.Unlock()
+						statusesMux.Lock()
+						defer statusesMux.Unlock()
 
-						logboek.Default().LogF("Resource status:\n%s\n---\n", resourceStatus.ManifestJson)
-						logboek.Default().LogOptionalLn()
+						if _, hasKey := statuses[resourceStatus.Spec]; hasKey {
+							statuses[resourceStatus.Spec] = resourceStatus
+						}
 					}()
+
+					logboek.Context(context.Background()).Debug().LogF("Resource status:\n%s\n---\n", resourceStatus.ManifestJson)
 				case <-ctx.Done():
 					return
 				}
@@ -81,35 +124,95 @@ func TrackUntilEliminated(ctx context.Context, kubeDynamicClient dynamic.Interfa
 		}()
 	}
 
+	remainingStatuses := func() []ResourceStatus {
+		statusesMux.Lock()
+		defer statusesMux.Unlock()
+
+		var res []ResourceStatus
+		for _, spec := range specs {
+			if status, hasKey := statuses[spec]; hasKey {
+				res = append(res, status)
+			}
+		}
+		return res
+	}
+
+	var statusProgressChan <-chan time.Time
+	statusProgressPeriod := opts.StatusProgressPeriod
+	if statusProgressPeriod == 0 {
+		statusProgressPeriod = defaultStatusProgressPeriod
+	}
+	if statusProgressPeriod > 0 {
+		ticker := time.NewTicker(statusProgressPeriod)
+		defer ticker.Stop()
+		statusProgressChan = ticker.C
+	}
+
 	var errors []error
 	var pendingJobs = len(specs)
-	for {
+	for pendingJobs > 0 {
 		select {
-		case err := <-errorChan:
+		case res := <-resultChan:
 			pendingJobs--
-			if err != nil {
-				errors = append(errors, err)
+			if res.Err != nil {
+				errors = append(errors, res.Err)
+				continue
 			}
 
-			if pendingJobs == 0 {
-				if len(errors) > 0 {
-					errorMsgs := []string{}
-					for _, err := range errors {
-						errorMsgs = append(errorMsgs, err.Error())
-					}
-					return fmt.Errorf("%s", strings.Join(errorMsgs, "; "))
+			statusesMux.Lock()
+			delete(statuses, res.Spec)
+			statusesMux.Unlock()
+
+		case <-statusProgressChan:
+			for _, status := range remainingStatuses() {
+				if opts.Display != nil {
+					opts.Display.OutF("%s\n", status.formatProgress())
+				} else {
+					logboek.Default().LogF("%s\n", status.formatProgress())
 				}
-				return nil
 			}
+
 		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded && parentCtx.Err() == nil {
+				return &EliminationTimeoutError{Timeout: opts.Timeout, Resources: remainingStatuses()}
+			}
 			return ctx.Err()
 		}
 	}
+
+	if len(errors) > 0 {
+		errorMsgs := []string{}
+		for _, err := range errors {
+			errorMsgs = append(errorMsgs, err.Error())
+		}
+		return fmt.Errorf("%s", strings.Join(errorMsgs, "; "))
+	}
+
+	return nil
 }
 
+// ResourceStatus is the last known state of the resource which still exists.
 type ResourceStatus struct {
 	Spec         *EliminationTrackerSpec
 	ManifestJson []byte
+
+	// IsTerminating is set when the resource deletion has been requested, Finalizers are what it waits for then.
+	IsTerminating bool
+	Finalizers    []string
+}
+
+func (status ResourceStatus) String() string {
+	if len(status.Finalizers) > 0 {
+		return fmt.Sprintf("%s (finalizers: %s)", status.Spec, strings.Join(status.Finalizers, ", "))
+	}
+	return status.Spec.String()
+}
+
+func (status ResourceStatus) formatProgress() string {
+	if status.IsTerminating {
+		return fmt.Sprintf("still terminating: %s", status)
+	}
+	return fmt.Sprintf("waiting for deletion: %s", status)
 }
 
 type EliminationTracker struct {
@@ -127,48 +230,79 @@ func NewEliminationTracker(kubeDynamicClient dynamic.Interface, spec *Eliminatio
 	}
 }
 
+// Track returns when the resource is deleted or is absent already, each change of the existing resource is sent into ResourceStatus.
 func (tracker *EliminationTracker) Track(ctx context.Context, opts EliminationTrackerOptions) error {
-	list, err := tracker.KubeDynamicClient.Resource(tracker.Spec.GroupVersionResource).Namespace(tracker.Spec.Namespace).List(ctx, metav1.ListOptions{})
-	if err != nil {
-		return fmt.Errorf("unable to get list of %q in the namespace %q: %s", tracker.Spec.GroupVersionResource, tracker.Spec.Namespace, err)
-	}
+	client := tracker.KubeDynamicClient.Resource(tracker.Spec.GroupVersionResource).Namespace(tracker.Spec.Namespace)
 
-	var found bool
-	for i := range list.Items {
-		logboek.Context(context.Background()).Debug().LogF("Check resource %q %q against %q!\n", tracker.Spec.GroupVersionResource, list.Items[i].GetName(), tracker.Spec.ResourceName)
-		if list.Items[i].GetName() == tracker.Spec.ResourceName {
-			logboek.Context(context.Background()).Debug().LogF("Found resource %q %q!\n", tracker.Spec.GroupVersionResource, tracker.Spec.ResourceName)
-			found = true
-		}
+	tweakListOptions := func(options metav1.ListOptions) metav1.ListOptions {
+		options.FieldSelector = fields.OneTermEqualSelector("metadata.name", tracker.Spec.ResourceName).String()
+		return options
 	}
-	if !found {
-		return nil
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return client.List(ctx, tweakListOptions(options))
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return client.Watch(ctx, tweakListOptions(options))
+		},
 	}
 
-	informerFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(tracker.KubeDynamicClient, 0, tracker.Spec.Namespace, nil)
-	informer := informerFactory.ForResource(tracker.Spec.GroupVersionResource)
+	// The resource absent when the informer is synced has been deleted already
+	isAbsent := func(store cache.Store) (bool, error) {
+		key := tracker.Spec.ResourceName
+		if tracker.Spec.Namespace != "" {
+			key = fmt.Sprintf("%s/%s", tracker.Spec.Namespace, tracker.Spec.ResourceName)
+		}
 
-	stopCh := make(chan struct{})
+		_, exists, err := store.GetByKey(key)
+		if err != nil {
+			return true, err
+		}
+		if !exists {
+			logboek.Context(context.Background()).Debug().LogF("Resource %s is absent\n", tracker.Spec)
+		}
+		return !exists, nil
+	}
 
-	handlers := cache.ResourceEventHandlerFuncs{
-		DeleteFunc: func(obj interface{}) {
-			u, ok := obj.(*unstructured.Unstructured)
+	_, err := watchtools.UntilWithSync(ctx, lw, &unstructured.Unstructured{}, isAbsent, func(e watch.Event) (bool, error) {
+		if object, ok := e.Object.(*unstructured.Unstructured); ok && object.GetName() != tracker.Spec.ResourceName {
+			return false, nil
+		}
+
+		switch e.Type {
+		case watch.Added, watch.Modified:
+			object, ok := e.Object.(*unstructured.Unstructured)
 			if !ok {
-				return
+				return true, fmt.Errorf("expected %s to be a *unstructured.Unstructured, got %T", tracker.Spec, e.Object)
 			}
 
-			if u.GetName() == tracker.Spec.ResourceName {
-				logboek.Context(context.Background()).Debug().LogF("Stopping informer for %q %q\n", tracker.Spec.GroupVersionResource, tracker.Spec.ResourceName)
-				close(stopCh)
+			select {
+			case tracker.ResourceStatus <- newResourceStatus(tracker.Spec, object):
+			case <-ctx.Done():
 			}
-		},
-	}
+		case watch.Deleted:
+			logboek.Context(context.Background()).Debug().LogF("Resource %s is deleted\n", tracker.Spec)
+			return true, nil
+		case watch.Error:
+			return true, fmt.Errorf("%s error: %v", tracker.Spec, e.Object)
+		}
 
-	informer.Informer().AddEventHandler(handlers)
+		return false, nil
+	})
 
-	logboek.Context(context.Background()).Debug().LogF("Starting informer for %q %q\n", tracker.Spec.GroupVersionResource, tracker.Spec.ResourceName)
+	if err == wait.ErrWaitTimeout {
+		return ctx.Err()
+	}
+	return err
+}
 
-	informer.Informer().Run(stopCh)
+func newResourceStatus(spec *EliminationTrackerSpec, object *unstructured.Unstructured) ResourceStatus {
+	manifestJson, _ := json.Marshal(object.Object)
 
-	return nil
+	return ResourceStatus{
+		Spec:          spec,
+		ManifestJson:  manifestJson,
+		IsTerminating: object.GetDeletionTimestamp() != nil,
+		Finalizers:    object.GetFinalizers(),
+	}
 }
@@ -8,7 +8,6 @@ import (
 
 	"k8s.io/client-go/kubernetes"
 
-	"github.com/werf/kubedog/pkg/display"
 	"github.com/werf/kubedog/pkg/tracker"
 )
 
@@ -17,35 +16,35 @@ func TrackDaemonSet(name, namespace string, kube kubernetes.Interface, opts trac
 
 	feed.OnAdded(func(isReady bool) error {
 		if isReady {
-			fmt.Fprintf(display.Out, "# ds/%s appears to be ready\n", name)
+			opts.Display.OutF("# ds/%s appears to be ready\n", name)
 		} else {
-			fmt.Fprintf(display.Out, "# ds/%s added\n", name)
+			opts.Display.OutF("# ds/%s added\n", name)
 		}
 		return nil
 	})
 	feed.OnReady(func() error {
-		fmt.Fprintf(display.Out, "# ds/%s become READY\n", name)
+		opts.Display.OutF("# ds/%s become READY\n", name)
 		return nil
 	})
 	feed.OnEventMsg(func(msg string) error {
-		fmt.Fprintf(display.Out, "# ds/%s event: %s\n", name, msg)
+		opts.Display.OutF("# ds/%s event: %s\n", name, msg)
 		return nil
 	})
 	feed.OnFailed(func(reason string) error {
-		fmt.Fprintf(display.Out, "# ds/%s FAIL: %s\n", name, reason)
+		opts.Display.OutF("# ds/%s FAIL: %s\n", name, reason)
 		return nil
 	})
 	feed.OnAddedPod(func(pod replicaset.ReplicaSetPod) error {
-		fmt.Fprintf(display.Out, "# ds/%s po/%s added\n", name, pod.Name)
+		opts.Display.OutF("# ds/%s po/%s added\n", name, pod.Name)
 		return nil
 	})
 	feed.OnPodError(func(podError replicaset.ReplicaSetPodError) error {
-		fmt.Fprintf(display.Out, "# ds/%s %s %s error: %s\n", name, podError.PodName, podError.ContainerName, podError.Message)
+		opts.Display.OutF("# ds/%s %s %s error: %s\n", name, podError.PodName, podError.ContainerName, podError.Message)
 		return nil
 	})
 	feed.OnPodLogChunk(func(chunk *replicaset.ReplicaSetPodLogChunk) error {
 		header := fmt.Sprintf("po/%s %s", chunk.PodName, chunk.ContainerName)
-		display.OutputLogLines(header, chunk.LogLines)
+		opts.Display.OutputLogLines(header, chunk.LogLines)
 		return nil
 	})
 
@@ -5,7 +5,6 @@ import (
 
 	"k8s.io/client-go/kubernetes"
 
-	"github.com/werf/kubedog/pkg/display"
 	"github.com/werf/kubedog/pkg/tracker"
 	"github.com/werf/kubedog/pkg/tracker/deployment"
 	"github.com/werf/kubedog/pkg/tracker/replicaset"
@@ -16,46 +15,46 @@ func TrackDeployment(name, namespace string, kube kubernetes.Interface, opts tra
 
 	feed.OnAdded(func(isReady bool) error {
 		if isReady {
-			fmt.Fprintf(display.Out, "# deploy/%s appears to be ready\n", name)
+			opts.Display.OutF("# deploy/%s appears to be ready\n", name)
 		} else {
-			fmt.Fprintf(display.Out, "# deploy/%s added\n", name)
+			opts.Display.OutF("# deploy/%s added\n", name)
 		}
 		return nil
 	})
 	feed.OnReady(func() error {
-		fmt.Fprintf(display.Out, "# deploy/%s become READY\n", name)
+		opts.Display.OutF("# deploy/%s become READY\n", name)
 		return nil
 	})
 	feed.OnFailed(func(reason string) error {
-		fmt.Fprintf(display.Out, "# deploy/%s FAIL: %s\n", name, reason)
+		opts.Display.OutF("# deploy/%s FAIL: %s\n", name, reason)
 		return nil
 	})
 	feed.OnEventMsg(func(msg string) error {
-		fmt.Fprintf(display.Out, "# deploy/%s event: %s\n", name, msg)
+		opts.Display.OutF("# deploy/%s event: %s\n", name, msg)
 		return nil
 	})
 	feed.OnAddedReplicaSet(func(rs replicaset.ReplicaSet) error {
 		if rs.IsNew {
-			fmt.Fprintf(display.Out, "# deploy/%s new rs/%s added\n", name, rs.Name)
+			opts.Display.OutF("# deploy/%s new rs/%s added\n", name, rs.Name)
 		} else {
-			fmt.Fprintf(display.Out, "# deploy/%s rs/%s added\n", name, rs.Name)
+			opts.Display.OutF("# deploy/%s rs/%s added\n", name, rs.Name)
 		}
 
 		return nil
 	})
 	feed.OnAddedPod(func(pod replicaset.ReplicaSetPod) error {
 		if pod.ReplicaSet.IsNew {
-			fmt.Fprintf(display.Out, "# deploy/%s rs/%s(new) po/%s added\n", name, pod.ReplicaSet.Name, pod.Name)
+			opts.Display.OutF("# deploy/%s rs/%s(new) po/%s added\n", name, pod.ReplicaSet.Name, pod.Name)
 		} else {
-			fmt.Fprintf(display.Out, "# deploy/%s rs/%s po/%s added\n", name, pod.ReplicaSet.Name, pod.Name)
+			opts.Display.OutF("# deploy/%s rs/%s po/%s added\n", name, pod.ReplicaSet.Name, pod.Name)
 		}
 		return nil
 	})
 	feed.OnPodError(func(podError replicaset.ReplicaSetPodError) error {
 		if podError.ReplicaSet.IsNew {
-			fmt.Fprintf(display.Out, "# deploy/%s rs/%s(new) po/%s %s error: %s\n", name, podError.ReplicaSet.Name, podError.PodName, podError.ContainerName, podError.Message)
+			opts.Display.OutF("# deploy/%s rs/%s(new) po/%s %s error: %s\n", name, podError.ReplicaSet.Name, podError.PodName, podError.ContainerName, podError.Message)
 		} else {
-			fmt.Fprintf(display.Out, "# deploy/%s rs/%s po/%s %s error: %s\n", name, podError.ReplicaSet.Name, podError.PodName, podError.ContainerName, podError.Message)
+			opts.Display.OutF("# deploy/%s rs/%s po/%s %s error: %s\n", name, podError.ReplicaSet.Name, podError.PodName, podError.ContainerName, podError.Message)
 		}
 		return nil
 	})
@@ -66,7 +65,7 @@ func TrackDeployment(name, namespace string, kube kubernetes.Interface, opts tra
 		} else {
 			header = fmt.Sprintf("deploy/%s rs/%s po/%s %s", name, chunk.ReplicaSet.Name, chunk.PodName, chunk.ContainerName)
 		}
-		display.OutputLogLines(header, chunk.LogLines)
+		opts.Display.OutputLogLines(header, chunk.LogLines)
 		return nil
 	})
 
@@ -8,7 +8,6 @@ import (
 
 	"k8s.io/client-go/kubernetes"
 
-	"github.com/werf/kubedog/pkg/display"
 	"github.com/werf/kubedog/pkg/tracker"
 )
 
@@ -16,32 +15,32 @@ func TrackJob(name, namespace string, kube kubernetes.Interface, opts tracker.Op
 	feed := job.NewFeed()
 
 	feed.OnAdded(func() error {
-		fmt.Fprintf(display.Out, "# job/%s added\n", name)
+		opts.Display.OutF("# job/%s added\n", name)
 		return nil
 	})
 	feed.OnSucceeded(func() error {
-		fmt.Fprintf(display.Out, "# job/%s succeeded\n", name)
+		opts.Display.OutF("# job/%s succeeded\n", name)
 		return nil
 	})
 	feed.OnFailed(func(reason string) error {
-		fmt.Fprintf(display.Out, "# job/%s FAIL: %s\n", name, reason)
+		opts.Display.OutF("# job/%s FAIL: %s\n", name, reason)
 		return nil
 	})
 	feed.OnEventMsg(func(msg string) error {
-		fmt.Fprintf(display.Out, "# job/%s event: %s\n", name, msg)
+		opts.Display.OutF("# job/%s event: %s\n", name, msg)
 		return nil
 	})
 	feed.OnAddedPod(func(podName string) error {
-		fmt.Fprintf(display.Out, "# job/%s po/%s added\n", name, podName)
+		opts.Display.OutF("# job/%s po/%s added\n", name, podName)
 		return nil
 	})
 	feed.OnPodError(func(podError pod.PodError) error {
-		fmt.Fprintf(display.Out, "# job/%s po/%s %s error: %s\n", name, podError.PodName, podError.ContainerName, podError.Message)
+		opts.Display.OutF("# job/%s po/%s %s error: %s\n", name, podError.PodName, podError.ContainerName, podError.Message)
 		return nil
 	})
 	feed.OnPodLogChunk(func(chunk *pod.PodLogChunk) error {
 		header := fmt.Sprintf("po/%s %s", chunk.PodName, chunk.ContainerName)
-		display.OutputLogLines(header, chunk.LogLines)
+		opts.Display.OutputLogLines(header, chunk.LogLines)
 		return nil
 	})
 
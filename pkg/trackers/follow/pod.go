@@ -7,7 +7,6 @@ import (
 
 	"k8s.io/client-go/kubernetes"
 
-	"github.com/werf/kubedog/pkg/display"
 	"github.com/werf/kubedog/pkg/tracker"
 )
 
@@ -15,32 +14,32 @@ func TrackPod(name, namespace string, kube kubernetes.Interface, opts tracker.Op
 	feed := pod.NewFeed()
 
 	feed.OnAdded(func() error {
-		fmt.Fprintf(display.Out, "# po/%s added\n", name)
+		opts.Display.OutF("# po/%s added\n", name)
 		return nil
 	})
 	feed.OnSucceeded(func() error {
-		fmt.Fprintf(display.Out, "# po/%s succeeded\n", name)
+		opts.Display.OutF("# po/%s succeeded\n", name)
 		return nil
 	})
 	feed.OnFailed(func(reason string) error {
-		fmt.Fprintf(display.Out, "# po/%s failed: %s\n", name, reason)
+		opts.Display.OutF("# po/%s failed: %s\n", name, reason)
 		return nil
 	})
 	feed.OnReady(func() error {
-		fmt.Fprintf(display.Out, "# po/%s become READY\n", name)
+		opts.Display.OutF("# po/%s become READY\n", name)
 		return nil
 	})
 	feed.OnEventMsg(func(msg string) error {
-		fmt.Fprintf(display.Out, "# po/%s event: %s\n", name, msg)
+		opts.Display.OutF("# po/%s event: %s\n", name, msg)
 		return nil
 	})
 	feed.OnContainerError(func(containerError pod.ContainerError) error {
-		fmt.Fprintf(display.Out, "# po/%s %s error: %s\n", name, containerError.ContainerName, containerError.Message)
+		opts.Display.OutF("# po/%s %s error: %s\n", name, containerError.ContainerName, containerError.Message)
 		return nil
 	})
 	feed.OnContainerLogChunk(func(chunk *pod.ContainerLogChunk) error {
 		header := fmt.Sprintf("po/%s %s", name, chunk.ContainerName)
-		display.OutputLogLines(header, chunk.LogLines)
+		opts.Display.OutputLogLines(header, chunk.LogLines)
 		return nil
 	})
 
@@ -8,7 +8,6 @@ import (
 
 	"k8s.io/client-go/kubernetes"
 
-	"github.com/werf/kubedog/pkg/display"
 	"github.com/werf/kubedog/pkg/tracker"
 )
 
@@ -17,35 +16,35 @@ func TrackStatefulSet(name, namespace string, kube kubernetes.Interface, opts tr
 
 	feed.OnAdded(func(isReady bool) error {
 		if isReady {
-			fmt.Fprintf(display.Out, "# sts/%s appears to be ready\n", name)
+			opts.Display.OutF("# sts/%s appears to be ready\n", name)
 		} else {
-			fmt.Fprintf(display.Out, "# sts/%s added\n", name)
+			opts.Display.OutF("# sts/%s added\n", name)
 		}
 		return nil
 	})
 	feed.OnReady(func() error {
-		fmt.Fprintf(display.Out, "# sts/%s become READY\n", name)
+		opts.Display.OutF("# sts/%s become READY\n", name)
 		return nil
 	})
 	feed.OnFailed(func(reason string) error {
-		fmt.Fprintf(display.Out, "# sts/%s FAIL: %s\n", name, reason)
+		opts.Display.OutF("# sts/%s FAIL: %s\n", name, reason)
 		return nil
 	})
 	feed.OnEventMsg(func(msg string) error {
-		fmt.Fprintf(display.Out, "# sts/%s event: %s\n", name, msg)
+		opts.Display.OutF("# sts/%s event: %s\n", name, msg)
 		return nil
 	})
 	feed.OnAddedPod(func(pod replicaset.ReplicaSetPod) error {
-		fmt.Fprintf(display.Out, "# sts/%s po/%s added\n", name, pod.Name)
+		opts.Display.OutF("# sts/%s po/%s added\n", name, pod.Name)
 		return nil
 	})
 	feed.OnPodError(func(podError replicaset.ReplicaSetPodError) error {
-		fmt.Fprintf(display.Out, "# sts/%s %s %s error: %s\n", name, podError.PodName, podError.ContainerName, podError.Message)
+		opts.Display.OutF("# sts/%s %s %s error: %s\n", name, podError.PodName, podError.ContainerName, podError.Message)
 		return nil
 	})
 	feed.OnPodLogChunk(func(chunk *replicaset.ReplicaSetPodLogChunk) error {
 		header := fmt.Sprintf("po/%s %s", chunk.PodName, chunk.ContainerName)
-		display.OutputLogLines(header, chunk.LogLines)
+		opts.Display.OutputLogLines(header, chunk.LogLines)
 		return nil
 	})
 
@@ -5,7 +5,6 @@ import (
 
 	"k8s.io/client-go/kubernetes"
 
-	"github.com/werf/kubedog/pkg/display"
 	"github.com/werf/kubedog/pkg/tracker"
 	"github.com/werf/kubedog/pkg/tracker/daemonset"
 	"github.com/werf/kubedog/pkg/tracker/replicaset"
@@ -19,35 +18,35 @@ func TrackDaemonSetTillReady(name, namespace string, kube kubernetes.Interface,
 
 	feed.OnAdded(func(isReady bool) error {
 		if isReady {
-			fmt.Fprintf(display.Out, "# ds/%s appears to be ready. Exit\n", name)
+			opts.Display.OutF("# ds/%s appears to be ready. Exit\n", name)
 			return tracker.StopTrack
 		}
-		fmt.Fprintf(display.Out, "# ds/%s added\n", name)
+		opts.Display.OutF("# ds/%s added\n", name)
 		return nil
 	})
 	feed.OnReady(func() error {
-		fmt.Fprintf(display.Out, "# ds/%s become READY\n", name)
+		opts.Display.OutF("# ds/%s become READY\n", name)
 		return tracker.StopTrack
 	})
 	feed.OnFailed(func(reason string) error {
-		fmt.Fprintf(display.Err, "# ds/%s FAIL: %s\n", name, reason)
+		opts.Display.ErrF("# ds/%s FAIL: %s\n", name, reason)
 		return tracker.ResourceErrorf("ds/%s failed: %s", name, reason)
 	})
 	feed.OnEventMsg(func(msg string) error {
-		fmt.Fprintf(display.Out, "# ds/%s event: %s\n", name, msg)
+		opts.Display.OutF("# ds/%s event: %s\n", name, msg)
 		return nil
 	})
 	feed.OnAddedPod(func(pod replicaset.ReplicaSetPod) error {
-		fmt.Fprintf(display.Out, "# ds/%s po/%s added\n", name, pod.Name)
+		opts.Display.OutF("# ds/%s po/%s added\n", name, pod.Name)
 		return nil
 	})
 	feed.OnPodError(func(podError replicaset.ReplicaSetPodError) error {
-		fmt.Fprintf(display.Err, "# ds/%s %s %s error: %s\n", name, podError.PodName, podError.ContainerName, podError.Message)
+		opts.Display.ErrF("# ds/%s %s %s error: %s\n", name, podError.PodName, podError.ContainerName, podError.Message)
 		return tracker.ResourceErrorf("ds/%s po/%s %s failed: %s", name, podError.PodName, podError.ContainerName, podError.Message)
 	})
 	feed.OnPodLogChunk(func(chunk *replicaset.ReplicaSetPodLogChunk) error {
 		header := fmt.Sprintf("po/%s %s", chunk.PodName, chunk.ContainerName)
-		display.OutputLogLines(header, chunk.LogLines)
+		opts.Display.OutputLogLines(header, chunk.LogLines)
 		return nil
 	})
 
@@ -57,7 +56,7 @@ func TrackDaemonSetTillReady(name, namespace string, kube kubernetes.Interface,
 		case *tracker.ResourceError:
 			return e
 		default:
-			fmt.Fprintf(display.Err, "error tracking ds/%s in ns/%s: %s\n", name, namespace, err)
+			opts.Display.ErrF("error tracking ds/%s in ns/%s: %s\n", name, namespace, err)
 		}
 	}
 	return err
@@ -5,7 +5,6 @@ import (
 
 	"k8s.io/client-go/kubernetes"
 
-	"github.com/werf/kubedog/pkg/display"
 	"github.com/werf/kubedog/pkg/tracker"
 	"github.com/werf/kubedog/pkg/tracker/deployment"
 	"github.com/werf/kubedog/pkg/tracker/replicaset"
@@ -17,43 +16,43 @@ func TrackDeploymentTillReady(name, namespace string, kube kubernetes.Interface,
 
 	feed.OnAdded(func(isReady bool) error {
 		if isReady {
-			fmt.Fprintf(display.Out, "# deploy/%s appears to be ready\n", name)
+			opts.Display.OutF("# deploy/%s appears to be ready\n", name)
 			return tracker.StopTrack
 		}
-		fmt.Fprintf(display.Out, "# deploy/%s added\n", name)
+		opts.Display.OutF("# deploy/%s added\n", name)
 		return nil
 	})
 	feed.OnReady(func() error {
-		fmt.Fprintf(display.Out, "# deploy/%s become READY\n", name)
+		opts.Display.OutF("# deploy/%s become READY\n", name)
 		return tracker.StopTrack
 	})
 	feed.OnFailed(func(reason string) error {
-		fmt.Fprintf(display.Out, "# deploy/%s FAIL: %s\n", name, reason)
+		opts.Display.OutF("# deploy/%s FAIL: %s\n", name, reason)
 		return tracker.ResourceErrorf("failed: %s", reason)
 	})
 	feed.OnEventMsg(func(msg string) error {
-		fmt.Fprintf(display.Out, "# deploy/%s event: %s\n", name, msg)
+		opts.Display.OutF("# deploy/%s event: %s\n", name, msg)
 		return nil
 	})
 	feed.OnAddedReplicaSet(func(rs replicaset.ReplicaSet) error {
 		if !rs.IsNew {
 			return nil
 		}
-		fmt.Fprintf(display.Out, "# deploy/%s rs/%s added\n", name, rs.Name)
+		opts.Display.OutF("# deploy/%s rs/%s added\n", name, rs.Name)
 		return nil
 	})
 	feed.OnAddedPod(func(pod replicaset.ReplicaSetPod) error {
 		if !pod.ReplicaSet.IsNew {
 			return nil
 		}
-		fmt.Fprintf(display.Out, "# deploy/%s po/%s added\n", name, pod.Name)
+		opts.Display.OutF("# deploy/%s po/%s added\n", name, pod.Name)
 		return nil
 	})
 	feed.OnPodError(func(podError replicaset.ReplicaSetPodError) error {
 		if !podError.ReplicaSet.IsNew {
 			return nil
 		}
-		fmt.Fprintf(display.Out, "# deploy/%s po/%s %s error: %s\n", name, podError.PodName, podError.ContainerName, podError.Message)
+		opts.Display.OutF("# deploy/%s po/%s %s error: %s\n", name, podError.PodName, podError.ContainerName, podError.Message)
 		return tracker.ResourceErrorf("deploy/%s po/%s %s failed: %s", name, podError.PodName, podError.ContainerName, podError.Message)
 	})
 	feed.OnPodLogChunk(func(chunk *replicaset.ReplicaSetPodLogChunk) error {
@@ -61,7 +60,7 @@ func TrackDeploymentTillReady(name, namespace string, kube kubernetes.Interface,
 			return nil
 		}
 		header := fmt.Sprintf("po/%s %s", chunk.PodName, chunk.ContainerName)
-		display.OutputLogLines(header, chunk.LogLines)
+		opts.Display.OutputLogLines(header, chunk.LogLines)
 		return nil
 	})
 
@@ -71,7 +70,7 @@ func TrackDeploymentTillReady(name, namespace string, kube kubernetes.Interface,
 		case *tracker.ResourceError:
 			return e
 		default:
-			fmt.Fprintf(display.Err, "error tracking deploy/%s in ns/%s: %s\n", name, namespace, err)
+			opts.Display.ErrF("error tracking deploy/%s in ns/%s: %s\n", name, namespace, err)
 		}
 	}
 	return err
@@ -5,7 +5,6 @@ import (
 
 	"k8s.io/client-go/kubernetes"
 
-	"github.com/werf/kubedog/pkg/display"
 	"github.com/werf/kubedog/pkg/tracker"
 	"github.com/werf/kubedog/pkg/tracker/job"
 	"github.com/werf/kubedog/pkg/tracker/pod"
@@ -16,32 +15,32 @@ func TrackJobTillDone(name, namespace string, kube kubernetes.Interface, opts tr
 	feed := job.NewFeed()
 
 	feed.OnAdded(func() error {
-		fmt.Fprintf(display.Out, "# job/%s added\n", name)
+		opts.Display.OutF("# job/%s added\n", name)
 		return nil
 	})
 	feed.OnSucceeded(func() error {
-		fmt.Fprintf(display.Out, "# job/%s succeeded\n", name)
+		opts.Display.OutF("# job/%s succeeded\n", name)
 		return tracker.StopTrack
 	})
 	feed.OnFailed(func(reason string) error {
-		fmt.Fprintf(display.Out, "# job/%s FAIL: %s\n", name, reason)
+		opts.Display.OutF("# job/%s FAIL: %s\n", name, reason)
 		return tracker.ResourceErrorf("failed: %s", reason)
 	})
 	feed.OnEventMsg(func(msg string) error {
-		fmt.Fprintf(display.Out, "# job/%s event: %s\n", name, msg)
+		opts.Display.OutF("# job/%s event: %s\n", name, msg)
 		return nil
 	})
 	feed.OnAddedPod(func(podName string) error {
-		fmt.Fprintf(display.Out, "# job/%s po/%s added\n", name, podName)
+		opts.Display.OutF("# job/%s po/%s added\n", name, podName)
 		return nil
 	})
 	feed.OnPodLogChunk(func(chunk *pod.PodLogChunk) error {
 		header := fmt.Sprintf("po/%s %s", chunk.PodName, chunk.ContainerName)
-		display.OutputLogLines(header, chunk.LogLines)
+		opts.Display.OutputLogLines(header, chunk.LogLines)
 		return nil
 	})
 	feed.OnPodError(func(podError pod.PodError) error {
-		fmt.Fprintf(display.Out, "# job/%s po/%s %s error: %s\n", name, podError.PodName, podError.ContainerName, podError.Message)
+		opts.Display.OutF("# job/%s po/%s %s error: %s\n", name, podError.PodName, podError.ContainerName, podError.Message)
 		return tracker.ResourceErrorf("job/%s po/%s %s failed: %s", name, podError.PodName, podError.ContainerName, podError.Message)
 	})
 
@@ -51,7 +50,7 @@ func TrackJobTillDone(name, namespace string, kube kubernetes.Interface, opts tr
 		case *tracker.ResourceError:
 			return e
 		default:
-			fmt.Fprintf(display.Err, "error tracking job/%s in ns/%s: %s\n", name, namespace, err)
+			opts.Display.ErrF("error tracking job/%s in ns/%s: %s\n", name, namespace, err)
 		}
 	}
 	return err
@@ -0,0 +1,84 @@
+package multitrack
+
+import (
+	"fmt"
+
+	"github.com/werf/kubedog/pkg/utils"
+)
+
+// desiredReplicas returns the desired replicas of the controller from its last status, false when it is not known yet.
+// It must be called under handlers mutex.
+func (mt *multitracker) desiredReplicas(kind string, spec MultitrackSpec) (int, bool) {
+	key := resourceKey(spec)
+
+	switch kind {
+	case "deploy":
+		if indicator := mt.DeploymentsStatuses[key].ReplicasIndicator; indicator != nil {
+			return int(indicator.TargetValue), true
+		}
+	case "rs":
+		if indicator := mt.ReplicaSetsStatuses[key].ReplicasIndicator; indicator != nil {
+			return int(indicator.TargetValue), true
+		}
+	case "sts":
+		if indicator := mt.StatefulSetsStatuses[key].ReplicasIndicator; indicator != nil {
+			return int(indicator.TargetValue), true
+		}
+	case "ds":
+		if indicator := mt.DaemonSetsStatuses[key].ReplicasIndicator; indicator != nil {
+			return int(indicator.TargetValue), true
+		}
+	}
+
+	return 0, false
+}
+
+// updateAllowFailuresCount recomputes allowed failures count of the resource with AllowFailuresCountPerReplica
+// from the current desired replicas, so that scaling during tracking is taken into account. Resources without replicas
+// and controllers which status is not received yet count as one replica. It must be called under handlers mutex.
+func (mt *multitracker) updateAllowFailuresCount(state *multitrackerResourceState, kind string, spec MultitrackSpec) {
+	if spec.AllowFailuresCount != nil || spec.AllowFailuresCountPerReplica == nil {
+		return
+	}
+
+	replicas, isKnown := mt.desiredReplicas(kind, spec)
+	if !isKnown || replicas < 1 {
+		replicas = 1
+	}
+
+	state.AllowFailuresCount = *spec.AllowFailuresCountPerReplica * replicas
+}
+
+// formatAllowedFailures returns e.g. "failures: 3/50 allowed" for the resource with AllowFailuresCountPerReplica, empty string otherwise.
+// It must be called under handlers mutex.
+func (mt *multitracker) formatAllowedFailures(resourcesStates map[string]*multitrackerResourceState, kind string, spec MultitrackSpec) string {
+	if spec.AllowFailuresCount != nil || spec.AllowFailuresCountPerReplica == nil {
+		return ""
+	}
+
+	state := resourcesStates[resourceKey(spec)]
+	mt.updateAllowFailuresCount(state, kind, spec)
+
+	return fmt.Sprintf("failures: %d/%d allowed", state.FailuresCount, state.AllowFailuresCount)
+}
+
+// appendAllowedFailuresMessage appends allowed failures of the resource to the extra message of its status progress.
+func (mt *multitracker) appendAllowedFailuresMessage(extraMsg string, resourcesStates map[string]*multitrackerResourceState, kind string, spec MultitrackSpec) string {
+	msg := mt.formatAllowedFailures(resourcesStates, kind, spec)
+	if msg == "" {
+		return extraMsg
+	}
+
+	if extraMsg != "" {
+		extraMsg += "\n"
+	}
+	return extraMsg + "---\n" + utils.BlueString("%s", msg)
+}
+
+// formatSpecAllowFailuresCount returns AllowFailuresCount of the spec, or AllowFailuresCountPerReplica when only it is set.
+func formatSpecAllowFailuresCount(spec MultitrackSpec) string {
+	if spec.AllowFailuresCount == nil && spec.AllowFailuresCountPerReplica != nil {
+		return fmt.Sprintf("%d per replica", *spec.AllowFailuresCountPerReplica)
+	}
+	return fmt.Sprintf("%d", *spec.AllowFailuresCount)
+}
@@ -0,0 +1,129 @@
+package multitrack
+
+import (
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/werf/kubedog/pkg/tracker/deployment"
+	"github.com/werf/kubedog/pkg/tracker/indicators"
+)
+
+// TestUpdateAllowFailuresCount scales allowed failures of the Deployment with AllowFailuresCountPerReplica
+// as its desired replicas change during tracking.
+func TestUpdateAllowFailuresCount(t *testing.T) {
+	spec := MultitrackSpec{ResourceName: "api", Namespace: "prod", AllowFailuresCountPerReplica: intPtr(5)}
+	key := resourceKey(spec)
+
+	mt := &multitracker{DeploymentsStatuses: make(map[string]deployment.DeploymentStatus)}
+	state := &multitrackerResourceState{}
+	resourcesStates := map[string]*multitrackerResourceState{key: state}
+
+	setReplicas := func(replicas int32) {
+		mt.DeploymentsStatuses[key] = deployment.DeploymentStatus{
+			ReplicasIndicator: &indicators.Int32EqualConditionIndicator{TargetValue: replicas},
+		}
+	}
+
+	for _, tc := range []struct {
+		name                       string
+		set                        func()
+		expectedAllowFailuresCount int
+	}{
+		{name: "status not received", set: func() {}, expectedAllowFailuresCount: 5},
+		{name: "2 replicas", set: func() { setReplicas(2) }, expectedAllowFailuresCount: 10},
+		{name: "scaled up", set: func() { setReplicas(10) }, expectedAllowFailuresCount: 50},
+		{name: "scaled to zero", set: func() { setReplicas(0) }, expectedAllowFailuresCount: 5},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.set()
+
+			mt.updateAllowFailuresCount(state, "deploy", spec)
+			if state.AllowFailuresCount != tc.expectedAllowFailuresCount {
+				t.Errorf("expected %d allowed failures, got %d", tc.expectedAllowFailuresCount, state.AllowFailuresCount)
+			}
+		})
+	}
+
+	state.FailuresCount = 3
+	setReplicas(10)
+	if msg := mt.formatAllowedFailures(resourcesStates, "deploy", spec); msg != "failures: 3/50 allowed" {
+		t.Errorf("expected %q, got %q", "failures: 3/50 allowed", msg)
+	}
+}
+
+func TestUpdateAllowFailuresCountNotPerReplica(t *testing.T) {
+	for _, tc := range []struct {
+		name                       string
+		kind                       string
+		spec                       MultitrackSpec
+		expectedAllowFailuresCount int
+		expectedSpecAllowFailures  string
+	}{
+		{
+			// Pods count as one replica
+			name:                       "Pod",
+			kind:                       "po",
+			spec:                       MultitrackSpec{ResourceName: "app", AllowFailuresCountPerReplica: intPtr(4)},
+			expectedAllowFailuresCount: 4,
+			expectedSpecAllowFailures:  "4 per replica",
+		},
+		{
+			// AllowFailuresCount takes precedence over AllowFailuresCountPerReplica
+			name:                       "AllowFailuresCount",
+			kind:                       "deploy",
+			spec:                       MultitrackSpec{ResourceName: "api", AllowFailuresCount: intPtr(2), AllowFailuresCountPerReplica: intPtr(4)},
+			expectedAllowFailuresCount: 2,
+			expectedSpecAllowFailures:  "2",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mt := &multitracker{DeploymentsStatuses: map[string]deployment.DeploymentStatus{
+				resourceKey(tc.spec): {ReplicasIndicator: &indicators.Int32EqualConditionIndicator{TargetValue: 10}},
+			}}
+			state := &multitrackerResourceState{}
+			if tc.spec.AllowFailuresCount != nil {
+				state.AllowFailuresCount = *tc.spec.AllowFailuresCount
+			}
+
+			mt.updateAllowFailuresCount(state, tc.kind, tc.spec)
+			if state.AllowFailuresCount != tc.expectedAllowFailuresCount {
+				t.Errorf("expected %d allowed failures, got %d", tc.expectedAllowFailuresCount, state.AllowFailuresCount)
+			}
+			if s := formatSpecAllowFailuresCount(tc.spec); s != tc.expectedSpecAllowFailures {
+				t.Errorf("expected %q, got %q", tc.expectedSpecAllowFailures, s)
+			}
+		})
+	}
+}
+
+// TestMultitrackAllowFailuresCountPerReplica fails the Pod, which counts as one replica, on the failure after the allowed ones.
+func TestMultitrackAllowFailuresCountPerReplica(t *testing.T) {
+	kube := fake.NewSimpleClientset()
+	pods, specs := createTestPods(t, kube, 1)
+	specs[0].AllowFailuresCountPerReplica = intPtr(2)
+
+	stopUpdates := runTestPodsUpdates(t, kube, pods, func(i, step int, pod *corev1.Pod) *corev1.Pod {
+		return pod
+	}, func(i int, pod *corev1.Pod) *corev1.Pod {
+		return setTestPodFailed(pod)
+	})
+
+	result, out, err := runTestMultitrack(t, kube, MultitrackSpecs{Pods: specs}, MultitrackOptions{})
+	stopUpdates()
+
+	var failedErr *FailedResourcesError
+	if !errors.As(err, &failedErr) {
+		t.Fatalf("expected *FailedResourcesError, got %v\n%s", err, out)
+	}
+
+	resource := findTestResourceResult(result, "po", "app-0")
+	if resource == nil {
+		t.Fatalf("no result of Pod app-0")
+	}
+	if resource.Outcome != ResourceOutcomeFailed || resource.FailuresCount != 3 {
+		t.Errorf("expected Failed outcome on 3 failures, got %s on %d failures", resource.Outcome, resource.FailuresCount)
+	}
+}
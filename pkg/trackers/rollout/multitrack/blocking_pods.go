@@ -0,0 +1,186 @@
+package multitrack
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	"github.com/werf/kubedog/pkg/tracker/pod"
+	"github.com/werf/kubedog/pkg/utils"
+)
+
+type StatusVerbosity string
+
+const (
+	// StatusVerbosityNormal shows only the Pods blocking readiness under Deployments and StatefulSets.
+	StatusVerbosityNormal StatusVerbosity = "Normal"
+	// StatusVerbosityDetailed additionally shows all Pods of Deployments and StatefulSets.
+	StatusVerbosityDetailed StatusVerbosity = "Detailed"
+)
+
+var blockingPodsSubTableRatio = []float64{.40, .15, .45}
+
+// failureCodesCauses are short causes of the blocking Pods failures, so that the status report
+// and the failure codes of the returned error agree.
+var failureCodesCauses = map[FailureCode]string{
+	FailureCodeImagePull:           "image pull failing",
+	FailureCodeCrashLoop:           "crash-looping",
+	FailureCodeQuotaExceeded:       "quota exceeded",
+	FailureCodeTimeout:             "timed out",
+	FailureCodeMissingDevicePlugin: "missing device plugin",
+	FailureCodeMissingRuntimeClass: "missing runtime class",
+}
+
+type blockingPod struct {
+	Name  string
+	Since time.Time
+	Cause string
+}
+
+// getBlockingPods returns not ready Pods of the current revision, the longest blocking go first.
+func getBlockingPods(pods map[string]pod.PodStatus, newPodsNames []string) []blockingPod {
+	var res []blockingPod
+
+	for _, podName := range newPodsNames {
+		podStatus, hasKey := pods[podName]
+		if !hasKey || podStatus.IsDeleted || (podStatus.IsReady && !podStatus.IsFailed) {
+			continue
+		}
+
+		res = append(res, blockingPod{
+			Name:  podName,
+			Since: podBlockingSince(podStatus),
+			Cause: podBlockingCause(podStatus),
+		})
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].Since.Equal(res[j].Since) {
+			return res[i].Since.Before(res[j].Since)
+		}
+		return res[i].Name < res[j].Name
+	})
+
+	return res
+}
+
+// podBlockingSince returns the time when the Pod became not ready, or when it was scheduled or started otherwise.
+func podBlockingSince(podStatus pod.PodStatus) time.Time {
+	for _, condType := range []corev1.PodConditionType{corev1.PodReady, corev1.PodScheduled} {
+		for _, cond := range podStatus.Conditions {
+			if cond.Type == condType && !cond.LastTransitionTime.IsZero() {
+				return cond.LastTransitionTime.Time
+			}
+		}
+	}
+
+	if podStatus.StartTime != nil {
+		return podStatus.StartTime.Time
+	}
+
+	return time.Now()
+}
+
+// podBlockingCause returns one-line cause of the Pod not being ready,
+// e.g. "crash-looping", "unschedulable: 0/3 nodes are available..." or "readiness probe failing (container app)".
+func podBlockingCause(podStatus pod.PodStatus) string {
+	if podStatus.IsFailed {
+		return formatFailureCause(podStatus.FailedReason)
+	}
+
+	for _, cond := range podStatus.Conditions {
+		if cond.Type == corev1.PodScheduled && cond.Status == corev1.ConditionFalse {
+			return fmt.Sprintf("unschedulable: %s", firstLine(cond.Message))
+		}
+	}
+
+	if initProgress := formatPodInitProgress(podStatus); initProgress != "" {
+		return initProgress
+	}
+
+	var notReadyContainers []string
+	for _, container := range podStatus.ContainerStatuses {
+		switch {
+		case container.State.Waiting != nil && container.State.Waiting.Reason == "ContainerCreating":
+			if container.Image != "" {
+				return fmt.Sprintf("image pulling (%s)", container.Image)
+			}
+			return "image pulling"
+		case container.State.Waiting != nil && container.State.Waiting.Reason != "":
+			return formatFailureCause(container.State.Waiting.Reason)
+		case container.State.Running != nil && !container.Ready:
+			notReadyContainers = append(notReadyContainers, container.Name)
+		}
+	}
+
+	if len(notReadyContainers) > 0 {
+		return fmt.Sprintf("readiness probe failing (container %s)", strings.Join(notReadyContainers, ", "))
+	}
+
+	if podStatus.StatusIndicator != nil && podStatus.StatusIndicator.Value != "" {
+		return podStatus.StatusIndicator.Value
+	}
+
+	return "not ready"
+}
+
+func formatFailureCause(reason string) string {
+	if cause, hasKey := failureCodesCauses[ClassifyFailedReason(reason)]; hasKey {
+		return fmt.Sprintf("%s: %s", cause, firstLine(reason))
+	}
+	return firstLine(reason)
+}
+
+func firstLine(s string) string {
+	return strings.TrimSpace(strings.SplitN(s, "\n", 2)[0])
+}
+
+// blockingPodsStatusReportTable returns the sub table of the Pods blocking readiness of the controller, false when there are no such Pods.
+func (mt *multitracker) blockingPodsStatusReportTable(pods map[string]pod.PodStatus, newPodsNames []string, disableWarningColors bool) (StatusReportTable, bool) {
+	blockingPods := getBlockingPods(pods, newPodsNames)
+	if len(blockingPods) == 0 {
+		return StatusReportTable{}, false
+	}
+
+	table := StatusReportTable{
+		Columns:      []string{"BLOCKING READINESS", "FOR", "CAUSE"},
+		ColumnsRatio: blockingPodsSubTableRatio,
+	}
+
+	for _, blockingPod := range blockingPods {
+		cause := blockingPod.Cause
+		if !disableWarningColors {
+			cause = utils.YellowString("%s", cause)
+		}
+
+		table.Rows = append(table.Rows, StatusReportRow{
+			Name:  strings.Join(strings.Split(blockingPod.Name, "-")[1:], "-"),
+			Cells: []string{duration.HumanDuration(time.Since(blockingPod.Since)), cause},
+		})
+	}
+
+	return table, true
+}
+
+// controllerPodsStatusReportTables returns the sub tables of the Pods of Deployment or StatefulSet accordingly
+// to the status verbosity, extraMsg is shown next to the last table.
+func (mt *multitracker) controllerPodsStatusReportTables(prevPods map[string]pod.PodStatus, pods map[string]pod.PodStatus, newPodsNames []string, failMode FailMode, showProgress, disableWarningColors bool, extraMsg string) []StatusReportTable {
+	var tables []StatusReportTable
+	if blockingTable, hasBlockingPods := mt.blockingPodsStatusReportTable(pods, newPodsNames, disableWarningColors); hasBlockingPods {
+		tables = append(tables, blockingTable)
+	}
+
+	if mt.statusVerbosity == StatusVerbosityDetailed {
+		tables = append(tables, mt.childPodsStatusReportTable(prevPods, pods, newPodsNames, failMode, showProgress, disableWarningColors))
+	}
+
+	if len(tables) > 0 {
+		tables[len(tables)-1].Footer = extraMsg
+	}
+
+	return tables
+}
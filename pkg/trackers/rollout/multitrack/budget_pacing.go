@@ -0,0 +1,165 @@
+package multitrack
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// budgetPacingThresholds are the consumed parts of the time budget from which the pacing is shown in the status reports.
+var budgetPacingThresholds = []float64{.8, .5}
+
+// pacingSample is the progress of the resource (ready part of the desired replicas or completions) at some time.
+type pacingSample struct {
+	At       time.Time
+	Progress float64
+}
+
+// estimateETA linearly extrapolates the progress from the first sample to the current one until the progress is 1.
+// False is returned when there was no progress since the first sample, so nothing can be estimated.
+func estimateETA(first, current pacingSample) (time.Duration, bool) {
+	if current.Progress >= 1 {
+		return 0, true
+	}
+	if current.Progress <= first.Progress || !current.At.After(first.At) {
+		return 0, false
+	}
+
+	rate := (current.Progress - first.Progress) / float64(current.At.Sub(first.At))
+	return time.Duration((1 - current.Progress) / rate), true
+}
+
+// resourceProgress returns the ready part of the resource, the lowest one of its up-to-date and available (ready) replicas
+// or the succeeded part of Job completions. False is returned for the kinds without countable progress.
+func (mt *multitracker) resourceProgress(kind, key string) (float64, bool) {
+	switch kind {
+	case "deploy":
+		status := mt.DeploymentsStatuses[key]
+		if status.UpToDateIndicator == nil || status.AvailableIndicator == nil {
+			return 0, false
+		}
+		return progressPart(int64(minInt32(status.UpToDateIndicator.Value, status.AvailableIndicator.Value)), int64(status.UpToDateIndicator.TargetValue))
+	case "sts":
+		status := mt.StatefulSetsStatuses[key]
+		if status.UpToDateIndicator == nil || status.ReadyIndicator == nil {
+			return 0, false
+		}
+		value := status.UpToDateIndicator.Value
+		if status.ReadyIndicator.Value < value {
+			value = status.ReadyIndicator.Value
+		}
+		return progressPart(value, status.ReadyIndicator.TargetValue)
+	case "ds":
+		status := mt.DaemonSetsStatuses[key]
+		if status.UpToDateIndicator == nil || status.AvailableIndicator == nil {
+			return 0, false
+		}
+		return progressPart(int64(minInt32(status.UpToDateIndicator.Value, status.AvailableIndicator.Value)), int64(status.UpToDateIndicator.TargetValue))
+	case "rs":
+		status := mt.ReplicaSetsStatuses[key]
+		if status.ReadyIndicator == nil {
+			return 0, false
+		}
+		return progressPart(int64(status.ReadyIndicator.Value), int64(status.ReadyIndicator.TargetValue))
+	case "job":
+		status := mt.JobsStatuses[key]
+		if status.SucceededIndicator == nil {
+			return 0, false
+		}
+		return progressPart(int64(status.SucceededIndicator.Value), int64(status.SucceededIndicator.TargetValue))
+	default:
+		return 0, false
+	}
+}
+
+func progressPart(value, target int64) (float64, bool) {
+	if target <= 0 {
+		return 0, false
+	}
+	if value >= target {
+		return 1, true
+	}
+	return float64(value) / float64(target), true
+}
+
+func minInt32(a, b int32) int32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// formatBudgetPacing returns the pacing line of the status report once the consumed part of the time budget (DeployTimeout,
+// Timeout otherwise) exceeds one of budgetPacingThresholds, e.g.
+// "80% of time budget used (24m0s of 30m0s); sts/prod/kafka ETA ~9m0s (estimate) exceeds remaining 6m0s".
+// Resources without progress since they were first seen are listed as such, as they cannot be estimated.
+func (mt *multitracker) formatBudgetPacing(now time.Time) string {
+	budget := mt.budget
+	if budget <= 0 {
+		return ""
+	}
+
+	used := now.Sub(mt.startedAt)
+	threshold := 0.0
+	for _, t := range budgetPacingThresholds {
+		if float64(used) >= t*float64(budget) {
+			threshold = t
+			break
+		}
+	}
+	if threshold == 0 {
+		return ""
+	}
+
+	remaining := budget - used
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	var lateResources []string
+	for _, kind := range mt.trackedKinds() {
+		for _, key := range sortedSpecsKeys(kind.Specs) {
+			state := kind.States[key]
+			if state.Status == ResourceSucceeded || state.Status == ResourceFailed || !state.StoppedAt.IsZero() || state.FirstPacingSample == nil {
+				continue
+			}
+
+			progress, ok := mt.resourceProgress(kind.Kind, key)
+			if !ok {
+				continue
+			}
+
+			id := mt.resourceID(kind.Kind, kind.Specs[key])
+			if eta, ok := estimateETA(*state.FirstPacingSample, pacingSample{At: now, Progress: progress}); !ok {
+				lateResources = append(lateResources, fmt.Sprintf("%s no progress in %s", id, now.Sub(state.FirstPacingSample.At).Truncate(time.Second)))
+			} else if eta > remaining {
+				lateResources = append(lateResources, fmt.Sprintf("%s ETA ~%s (estimate) exceeds remaining %s", id, eta.Truncate(time.Second), remaining.Truncate(time.Second)))
+			}
+		}
+	}
+
+	res := fmt.Sprintf("%d%% of time budget used (%s of %s)", int(threshold*100), used.Truncate(time.Second), budget)
+	if len(lateResources) == 0 {
+		return res + "; estimated ETAs fit remaining " + remaining.Truncate(time.Second).String()
+	}
+	return res + "; " + strings.Join(lateResources, "; ")
+}
+
+// samplePacing remembers the first progress of the resources, so that ETAs are estimated since the start of the tracking
+// rather than since the budget threshold is reached.
+func (mt *multitracker) samplePacing(now time.Time) {
+	if mt.budget <= 0 {
+		return
+	}
+
+	for _, kind := range mt.trackedKinds() {
+		for key, state := range kind.States {
+			if state.FirstPacingSample != nil {
+				continue
+			}
+			if progress, ok := mt.resourceProgress(kind.Kind, key); ok {
+				state.FirstPacingSample = &pacingSample{At: now, Progress: progress}
+			}
+		}
+	}
+}
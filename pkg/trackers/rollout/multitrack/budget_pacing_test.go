@@ -0,0 +1,138 @@
+package multitrack
+
+import (
+	"testing"
+	"time"
+
+	"github.com/werf/kubedog/pkg/tracker/deployment"
+	"github.com/werf/kubedog/pkg/tracker/indicators"
+	"github.com/werf/kubedog/pkg/tracker/job"
+	"github.com/werf/kubedog/pkg/tracker/statefulset"
+)
+
+func TestEstimateETA(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		first      pacingSample
+		current    pacingSample
+		expected   time.Duration
+		expectedOk bool
+	}{
+		{name: "half in 10m", first: pacingSample{At: start}, current: pacingSample{At: start.Add(10 * time.Minute), Progress: .5}, expected: 10 * time.Minute, expectedOk: true},
+		{name: "from a quarter", first: pacingSample{At: start, Progress: .25}, current: pacingSample{At: start.Add(time.Minute), Progress: .5}, expected: 2 * time.Minute, expectedOk: true},
+		{name: "done", first: pacingSample{At: start}, current: pacingSample{At: start, Progress: 1}, expected: 0, expectedOk: true},
+		{name: "no progress", first: pacingSample{At: start, Progress: .5}, current: pacingSample{At: start.Add(time.Hour), Progress: .5}, expectedOk: false},
+		{name: "progress lost", first: pacingSample{At: start, Progress: .5}, current: pacingSample{At: start.Add(time.Minute), Progress: .25}, expectedOk: false},
+		{name: "same time", first: pacingSample{At: start}, current: pacingSample{At: start, Progress: .5}, expectedOk: false},
+	}
+
+	for _, tt := range tests {
+		eta, ok := estimateETA(tt.first, tt.current)
+		if ok != tt.expectedOk || eta != tt.expected {
+			t.Errorf("%s: expected %s %t, got %s %t", tt.name, tt.expected, tt.expectedOk, eta, ok)
+		}
+	}
+}
+
+// TestFormatBudgetPacing samples the progress of the resources during the 30m budget the way the status report does:
+// the Deployment progresses slower than the budget allows and then catches up, the Job shows up at 5m and makes
+// no progress until it succeeds, the StatefulSet is ready from the start.
+func TestFormatBudgetPacing(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mt := newTestMultitracker()
+	mt.budget = 30 * time.Minute
+	mt.startedAt = start
+	mt.DeploymentsStatuses = make(map[string]deployment.DeploymentStatus)
+	mt.StatefulSetsStatuses = make(map[string]statefulset.StatefulSetStatus)
+	mt.JobsStatuses = make(map[string]job.JobStatus)
+
+	api := MultitrackSpec{ResourceName: "api", Namespace: "prod"}
+	kafka := MultitrackSpec{ResourceName: "kafka", Namespace: "prod"}
+	migrate := MultitrackSpec{ResourceName: "migrate", Namespace: "prod"}
+	for _, resource := range []struct {
+		kind string
+		spec MultitrackSpec
+	}{{"deploy", api}, {"sts", kafka}, {"job", migrate}, {"generic", MultitrackSpec{ResourceName: "cert", Namespace: "prod"}}} {
+		for _, k := range mt.trackedKinds() {
+			if k.Kind == resource.kind {
+				k.Specs[resourceKey(resource.spec)] = resource.spec
+				k.States[resourceKey(resource.spec)] = newMultitrackerResourceState(resource.spec)
+			}
+		}
+	}
+
+	setAPIReady := func(ready int32) {
+		mt.DeploymentsStatuses[resourceKey(api)] = deployment.DeploymentStatus{
+			UpToDateIndicator:  &indicators.Int32EqualConditionIndicator{Value: 3, TargetValue: 3},
+			AvailableIndicator: &indicators.Int32EqualConditionIndicator{Value: ready, TargetValue: 3},
+		}
+	}
+	setMigrateSucceeded := func(succeeded int32) {
+		mt.JobsStatuses[resourceKey(migrate)] = job.JobStatus{
+			SucceededIndicator: &indicators.Int32EqualConditionIndicator{Value: succeeded, TargetValue: 1},
+		}
+	}
+
+	setAPIReady(0)
+	mt.StatefulSetsStatuses[resourceKey(kafka)] = statefulset.StatefulSetStatus{
+		UpToDateIndicator: &indicators.Int64GreaterOrEqualConditionIndicator{Value: 2, TargetValue: 2},
+		ReadyIndicator:    &indicators.Int64GreaterOrEqualConditionIndicator{Value: 2, TargetValue: 2},
+	}
+
+	steps := []struct {
+		at       time.Duration
+		change   func()
+		expected string
+	}{
+		{at: 0, expected: ""},
+		{at: 5 * time.Minute, change: func() { setMigrateSucceeded(0) }, expected: ""},
+		{at: 10 * time.Minute, change: func() { setAPIReady(1) }, expected: ""},
+		{
+			at:       15 * time.Minute,
+			expected: "50% of time budget used (15m0s of 30m0s); deploy/prod/api ETA ~30m0s (estimate) exceeds remaining 15m0s; job/prod/migrate no progress in 10m0s",
+		},
+		{
+			at: 24 * time.Minute,
+			change: func() {
+				setAPIReady(2)
+				setMigrateSucceeded(1)
+				mt.TrackingJobs[resourceKey(migrate)].Status = ResourceSucceeded
+			},
+			expected: "80% of time budget used (24m0s of 30m0s); deploy/prod/api ETA ~12m0s (estimate) exceeds remaining 6m0s",
+		},
+		{
+			at:       27 * time.Minute,
+			change:   func() { setAPIReady(3) },
+			expected: "80% of time budget used (27m0s of 30m0s); estimated ETAs fit remaining 3m0s",
+		},
+		{
+			at:       31 * time.Minute,
+			expected: "80% of time budget used (31m0s of 30m0s); estimated ETAs fit remaining 0s",
+		},
+	}
+
+	for _, step := range steps {
+		if step.change != nil {
+			step.change()
+		}
+
+		now := start.Add(step.at)
+		mt.samplePacing(now)
+		if res := mt.formatBudgetPacing(now); res != step.expected {
+			t.Errorf("%s: expected pacing %q, got %q", step.at, step.expected, res)
+		}
+	}
+}
+
+func TestFormatBudgetPacingWithoutBudget(t *testing.T) {
+	mt := newTestMultitracker()
+	mt.startedAt = time.Now().Add(-time.Hour)
+	mt.samplePacing(time.Now())
+
+	if res := mt.formatBudgetPacing(time.Now()); res != "" {
+		t.Errorf("expected no pacing without budget, got %q", res)
+	}
+}
@@ -0,0 +1,220 @@
+package multitrack
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	clusterCapacityRefreshPeriod = time.Minute
+	// clusterCapacityPressure is the requested share of the allocatable cpu or memory of the schedulable nodes
+	// from which the unschedulable Pods are likely blocked on the cluster capacity.
+	clusterCapacityPressure = 0.9
+)
+
+// clusterCapacity collects the requested share of the allocatable resources of the schedulable nodes and the pending Pods
+// cluster-wide, so that the stall caused by the full cluster is explained. Collection is rate-limited by clusterCapacityRefreshPeriod
+// and is disabled for the rest of the run when nodes or Pods cannot be listed with the client permissions.
+type clusterCapacity struct {
+	mux         sync.Mutex
+	refreshedAt time.Time
+	isForbidden bool
+	summary     *clusterCapacitySummary
+}
+
+type clusterCapacitySummary struct {
+	CPURequested    float64
+	MemoryRequested float64
+	// PendingPods are "namespace/name" of not yet scheduled Pods.
+	PendingPods []string
+}
+
+// get returns the summary collected within clusterCapacityRefreshPeriod or collects a new one, nil when the summary is not available.
+func (c *clusterCapacity) get(ctx context.Context, kube kubernetes.Interface) *clusterCapacitySummary {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.isForbidden || time.Since(c.refreshedAt) < clusterCapacityRefreshPeriod {
+		return c.summary
+	}
+	c.refreshedAt = time.Now()
+
+	summary, err := collectClusterCapacity(ctx, kube)
+	if apierrors.IsForbidden(err) {
+		c.isForbidden = true
+	}
+	c.summary = summary
+
+	return c.summary
+}
+
+func collectClusterCapacity(ctx context.Context, kube kubernetes.Interface) (*clusterCapacitySummary, error) {
+	nodes, err := kube.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := kube.CoreV1().Pods("").List(ctx, metav1.ListOptions{
+		FieldSelector: fields.AndSelectors(
+			fields.OneTermNotEqualSelector("status.phase", string(corev1.PodSucceeded)),
+			fields.OneTermNotEqualSelector("status.phase", string(corev1.PodFailed)),
+		).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	schedulableNodes := make(map[string]bool)
+	var allocatableCPU, allocatableMemory int64
+	for _, node := range nodes.Items {
+		if !isNodeSchedulable(node) {
+			continue
+		}
+		schedulableNodes[node.Name] = true
+		allocatableCPU += node.Status.Allocatable.Cpu().MilliValue()
+		allocatableMemory += node.Status.Allocatable.Memory().Value()
+	}
+
+	summary := &clusterCapacitySummary{}
+	var requestedCPU, requestedMemory int64
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+
+		if pod.Spec.NodeName == "" {
+			summary.PendingPods = append(summary.PendingPods, fmt.Sprintf("%s/%s", pod.Namespace, pod.Name))
+			continue
+		}
+
+		if schedulableNodes[pod.Spec.NodeName] {
+			requests := podRequests(pod)
+			requestedCPU += requests.Cpu().MilliValue()
+			requestedMemory += requests.Memory().Value()
+		}
+	}
+
+	if allocatableCPU > 0 {
+		summary.CPURequested = float64(requestedCPU) / float64(allocatableCPU)
+	}
+	if allocatableMemory > 0 {
+		summary.MemoryRequested = float64(requestedMemory) / float64(allocatableMemory)
+	}
+
+	return summary, nil
+}
+
+func isNodeSchedulable(node corev1.Node) bool {
+	if node.Spec.Unschedulable {
+		return false
+	}
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// podRequests returns the requests the scheduler accounts for the Pod: the sum of the containers requests,
+// but not less than the request of any init container, plus the Pod overhead.
+func podRequests(pod corev1.Pod) corev1.ResourceList {
+	res := corev1.ResourceList{}
+
+	for _, container := range pod.Spec.Containers {
+		for name, quantity := range container.Resources.Requests {
+			sum := res[name]
+			sum.Add(quantity)
+			res[name] = sum
+		}
+	}
+
+	for _, container := range pod.Spec.InitContainers {
+		for name, quantity := range container.Resources.Requests {
+			if current, hasKey := res[name]; !hasKey || quantity.Cmp(current) > 0 {
+				res[name] = quantity.DeepCopy()
+			}
+		}
+	}
+
+	for name, quantity := range pod.Spec.Overhead {
+		sum := res[name]
+		sum.Add(quantity)
+		res[name] = sum
+	}
+
+	return res
+}
+
+// formatClusterCapacity describes the summary, e.g. "cluster capacity: cpu 97% requested, memory 60% requested, 14 other pending pods —
+// rollout likely blocked on capacity", the pending Pods of the tracked resources are not counted. It must be called under handlers mutex.
+func (mt *multitracker) formatClusterCapacity(summary *clusterCapacitySummary) string {
+	if summary == nil {
+		return ""
+	}
+
+	trackedPods := mt.trackedPodsIDs()
+	otherPendingPods := 0
+	for _, id := range summary.PendingPods {
+		if !trackedPods[id] {
+			otherPendingPods++
+		}
+	}
+
+	items := []string{
+		fmt.Sprintf("cpu %d%% requested", int(summary.CPURequested*100)),
+		fmt.Sprintf("memory %d%% requested", int(summary.MemoryRequested*100)),
+		fmt.Sprintf("%d other pending pods", otherPendingPods),
+	}
+
+	res := fmt.Sprintf("cluster capacity: %s", strings.Join(items, ", "))
+	if summary.CPURequested >= clusterCapacityPressure || summary.MemoryRequested >= clusterCapacityPressure {
+		res += " — rollout likely blocked on capacity"
+	}
+	return res
+}
+
+// trackedPodsIDs returns "namespace/name" of the Pods of all tracked resources.
+func (mt *multitracker) trackedPodsIDs() map[string]bool {
+	res := make(map[string]bool)
+
+	for key, status := range mt.DeploymentsStatuses {
+		for podName := range status.Pods {
+			res[fmt.Sprintf("%s/%s", mt.DeploymentsSpecs[key].Namespace, podName)] = true
+		}
+	}
+	for key, status := range mt.ReplicaSetsStatuses {
+		for podName := range status.Pods {
+			res[fmt.Sprintf("%s/%s", mt.ReplicaSetsSpecs[key].Namespace, podName)] = true
+		}
+	}
+	for key, status := range mt.StatefulSetsStatuses {
+		for podName := range status.Pods {
+			res[fmt.Sprintf("%s/%s", mt.StatefulSetsSpecs[key].Namespace, podName)] = true
+		}
+	}
+	for key, status := range mt.DaemonSetsStatuses {
+		for podName := range status.Pods {
+			res[fmt.Sprintf("%s/%s", mt.DaemonSetsSpecs[key].Namespace, podName)] = true
+		}
+	}
+	for key, status := range mt.JobsStatuses {
+		for podName := range status.Pods {
+			res[fmt.Sprintf("%s/%s", mt.JobsSpecs[key].Namespace, podName)] = true
+		}
+	}
+	for key := range mt.PodsStatuses {
+		res[fmt.Sprintf("%s/%s", mt.PodsSpecs[key].Namespace, mt.PodsSpecs[key].ResourceName)] = true
+	}
+
+	return res
+}
@@ -0,0 +1,50 @@
+package multitrack
+
+// maybeFinish is the only place which signals the result of the trackers into doneChan or errorChan.
+// It must be called under handlers mutex after each change of the trackers state, so that the completion is evaluated
+// atomically: when the last trackers return simultaneously, the last one to take the mutex signals the result.
+// The result is signalled once, later calls do nothing.
+func (mt *multitracker) maybeFinish() {
+	if mt.finishErr == nil && mt.runningTrackers > 0 {
+		return
+	}
+
+	mt.finishOnce.Do(func() {
+		err := mt.finishErr
+
+		if err == nil {
+			err = mt.displayStatusProgress(false)
+		}
+
+		if err == nil && mt.hasFailedTrackingResources() {
+			mt.displayFailedTrackingResourcesServiceMessages()
+			err = mt.formatFailedTrackingResourcesError()
+		}
+
+		// Signal without holding the mutex, nobody may wait for the result anymore.
+		go mt.signalFinish(err)
+	})
+}
+
+// fail sets the error of the whole process unless it is already set and signals the result.
+func (mt *multitracker) fail(err error) {
+	if mt.finishErr == nil {
+		mt.finishErr = err
+	}
+	mt.maybeFinish()
+}
+
+func (mt *multitracker) signalFinish(err error) {
+	if err != nil {
+		select {
+		case mt.errorChan <- err:
+		case <-mt.stoppedChan:
+		}
+		return
+	}
+
+	select {
+	case mt.doneChan <- struct{}{}:
+	case <-mt.stoppedChan:
+	}
+}
@@ -0,0 +1,82 @@
+package multitrack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/werf/kubedog/pkg/tracker"
+)
+
+func (mt *multitracker) newResourceConnectionObserver(resourcesStates map[string]*multitrackerResourceState, kind string, spec MultitrackSpec, observer tracker.ConnectionObserver) tracker.ConnectionObserver {
+	return func(stats tracker.ConnectionStats) {
+		func() {
+			mt.mux.Lock()
+			defer mt.mux.Unlock()
+
+			state := resourcesStates[resourceKey(spec)]
+			mt.recordReconnectsMetrics(kind, spec, stats.Reconnects-state.Connection.Reconnects)
+			state.Connection = stats
+		}()
+
+		if observer != nil {
+			observer(stats)
+		}
+	}
+}
+
+// failOnConnectionLost handles the tracker which gave up reconnecting as the non-retryable failure of the resource.
+// It must be called under handlers mutex.
+func (mt *multitracker) failOnConnectionLost(kind string, spec MultitrackSpec, connectionLostErr *tracker.ConnectionLostError) {
+	reason := connectionLostErr.Error()
+	state := mt.resourcesStatesByKind(kind)[resourceKey(spec)]
+
+	mt.displayResourceErrorF(kind, spec, "%s", reason)
+
+	res := state.HandleNonRetryableFailure(reason)
+	mt.runResourceFailedHooks(kind, spec, reason)
+
+	if res.Decision == FailureIgnored {
+		mt.displayMultitrackServiceMessageF("%d errors occurred for %s\n", state.FailuresCount, mt.resourceID(kind, spec))
+
+		if err := mt.applyTrackTerminationMode(); err != nil {
+			mt.fail(fmt.Errorf("unable to apply termination mode: %s", err))
+			return
+		}
+		mt.maybeFinish()
+		return
+	}
+
+	if mt.finishErr == nil {
+		mt.displayFailedTrackingResourcesServiceMessages()
+	}
+	mt.fail(mt.formatFailedTrackingResourcesError())
+}
+
+// formatConnectionsRetries returns e.g. "Reconnects: sts/prod/kafka 3 (last error: unexpected EOF)" for the resources
+// which watches have been dropped or which requests are failing, so that flapping connections are visible.
+func (mt *multitracker) formatConnectionsRetries() string {
+	var items []string
+
+	for _, kind := range mt.trackedKinds() {
+		for _, key := range sortedSpecsKeys(kind.Specs) {
+			stats := kind.States[key].Connection
+			if stats.Reconnects == 0 && stats.ConsecutiveFailures == 0 {
+				continue
+			}
+
+			item := fmt.Sprintf("%s %d", mt.resourceID(kind.Kind, kind.Specs[key]), stats.Reconnects)
+			if stats.ConsecutiveFailures > 0 {
+				item += fmt.Sprintf(", %d failed requests in a row", stats.ConsecutiveFailures)
+			}
+			item += fmt.Sprintf(" (last error: %s)", stats.LastError)
+
+			items = append(items, item)
+		}
+	}
+
+	if len(items) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("Reconnects: %s", strings.Join(items, "; "))
+}
@@ -0,0 +1,66 @@
+package multitrack
+
+import (
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/werf/kubedog/pkg/kube"
+)
+
+// ErrCredentialsExpired is returned by Multitrack when the API server rejects the client credentials accepted before
+// and RefreshRESTConfig option is not set.
+var ErrCredentialsExpired = errors.New("client credentials expired and no refresh hook provided")
+
+func validateRefreshRESTConfig(opts MultitrackOptions) error {
+	if opts.RefreshRESTConfig != nil && opts.RestConfig == nil {
+		return fmt.Errorf("RestConfig option is required to refresh client credentials")
+	}
+	return nil
+}
+
+// newRefreshableClients returns the clients built from RestConfig, which refresh credentials with RefreshRESTConfig,
+// DynamicClient is rebuilt only when it is set.
+func (mt *multitracker) newRefreshableClients(opts MultitrackOptions) (kubernetes.Interface, dynamic.Interface, error) {
+	config, refresher, err := kube.NewRefreshableConfig(withClientRateLimits(opts.RestConfig, opts), opts.RefreshRESTConfig, func() {
+		mt.displayMultitrackServiceMessageF("Client credentials rejected by the API server have been refreshed\n")
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	mt.credentialsRefresher = refresher
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create kubernetes client: %s", err)
+	}
+
+	if opts.DynamicClient == nil {
+		return clientset, nil, nil
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create dynamic client: %s", err)
+	}
+
+	return clientset, dynamicClient, nil
+}
+
+// isCredentialsExpiredError returns true for Unauthorized error of the resource list request which succeeded before.
+func isCredentialsExpiredError(state *multitrackerResourceState, err error) bool {
+	return state.HasListSucceeded && apierrors.IsUnauthorized(err)
+}
+
+func (mt *multitracker) formatCredentialsExpiredError() error {
+	if mt.credentialsRefresher == nil {
+		return ErrCredentialsExpired
+	}
+	if err := mt.credentialsRefresher.Err(); err != nil {
+		return fmt.Errorf("client credentials expired and refresh failed: %s", err)
+	}
+	return fmt.Errorf("client credentials expired and refreshed credentials are rejected")
+}
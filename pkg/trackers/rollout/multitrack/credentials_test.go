@@ -0,0 +1,85 @@
+package multitrack
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// TestMultitrackCredentialsExpired rejects the credentials once the status of the Pod is received:
+// the watch of the Pods fails with 401 Unauthorized and so does the following list.
+func TestMultitrackCredentialsExpired(t *testing.T) {
+	for _, tc := range []struct {
+		name                   string
+		disableSharedInformers bool
+	}{
+		{name: "shared informers"},
+		{name: "own informers", disableSharedInformers: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			kube := fake.NewSimpleClientset()
+			_, specs := createTestPods(t, kube, 1)
+
+			var mux sync.Mutex
+			var isExpired bool
+			var watchers []*watch.RaceFreeFakeWatcher
+
+			unauthorized := apierrors.NewUnauthorized("token has expired")
+			kube.PrependReactor("list", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+				mux.Lock()
+				defer mux.Unlock()
+				return isExpired, nil, unauthorized
+			})
+			kube.PrependWatchReactor("pods", func(k8stesting.Action) (bool, watch.Interface, error) {
+				mux.Lock()
+				defer mux.Unlock()
+
+				if isExpired {
+					return true, nil, unauthorized
+				}
+				w := watch.NewRaceFreeFake()
+				watchers = append(watchers, w)
+				return true, w, nil
+			})
+
+			hooks := &MultitrackHooks{OnStatusReport: func(snapshot MultitrackSnapshot) error {
+				if len(snapshot.Resources) == 0 || snapshot.Resources[0].Phase != ResourcePhaseProgressing {
+					return nil
+				}
+
+				mux.Lock()
+				defer mux.Unlock()
+
+				if !isExpired {
+					isExpired = true
+					status := unauthorized.Status()
+					for _, w := range watchers {
+						w.Error(&status)
+					}
+				}
+				return nil
+			}}
+
+			_, out, err := runTestMultitrack(t, kube, MultitrackSpecs{Pods: specs}, MultitrackOptions{
+				StatusProgressPeriod:   10 * time.Millisecond,
+				Hooks:                  hooks,
+				DisableSharedInformers: tc.disableSharedInformers,
+			})
+
+			if !errors.Is(err, ErrCredentialsExpired) {
+				t.Fatalf("expected ErrCredentialsExpired, got %v\n%s", err, out)
+			}
+			if !strings.Contains(err.Error(), "client credentials expired and no refresh hook provided") {
+				t.Errorf("unexpected error message %q", err)
+			}
+		})
+	}
+}
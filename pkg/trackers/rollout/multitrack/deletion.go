@@ -0,0 +1,31 @@
+package multitrack
+
+import (
+	"github.com/werf/kubedog/pkg/tracker"
+)
+
+const resourceDeletedReason = "resource was deleted during tracking"
+
+// handleResourceDeleted handles the deletion of the tracked resource itself, deletions of the Pods of the controllers
+// during rolling updates are not reported here.
+func (mt *multitracker) handleResourceDeleted(resourcesStates map[string]*multitrackerResourceState, kind string, spec MultitrackSpec) error {
+	state := resourcesStates[resourceKey(spec)]
+	if state.Status == ResourceSucceeded || state.Status == ResourceFailed {
+		mt.displayResourceTrackerMessageF(kind, spec, "deleted")
+		return nil
+	}
+
+	if spec.TreatDeletionAsSuccess {
+		mt.displayResourceTrackerMessageF(kind, spec, "deleted, treated as success")
+		mt.markResourceReady(resourcesStates, kind, spec)
+
+		if mt.isFollowMode {
+			return nil
+		}
+		return tracker.StopTrack
+	}
+
+	mt.displayResourceErrorF(kind, spec, "%s", resourceDeletedReason)
+
+	return mt.handleResourceFailure(resourcesStates, kind, spec, resourceDeletedReason)
+}
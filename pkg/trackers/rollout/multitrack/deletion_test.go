@@ -0,0 +1,92 @@
+package multitrack
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// runTestPodRecreations deletes and creates the pending Pod again until the returned stop function is called,
+// because the fake clientset loses the events between the list and the watch of the tracker.
+func runTestPodRecreations(t *testing.T, kube kubernetes.Interface, namespace, name string) func() {
+	stopRecreations := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for {
+			select {
+			case <-time.After(10 * time.Millisecond):
+			case <-stopRecreations:
+				return
+			}
+
+			if err := kube.CoreV1().Pods(namespace).Delete(context.Background(), name, metav1.DeleteOptions{}); err != nil {
+				t.Errorf("unexpected error deleting Pod %s: %s", name, err)
+				return
+			}
+			if _, err := kube.CoreV1().Pods(namespace).Create(context.Background(), newTestPod(namespace, name), metav1.CreateOptions{}); err != nil {
+				t.Errorf("unexpected error creating Pod %s: %s", name, err)
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(stopRecreations)
+		<-done
+	}
+}
+
+func TestMultitrackResourceDeleted(t *testing.T) {
+	kube := fake.NewSimpleClientset()
+	pods, specs := createTestPods(t, kube, 1)
+
+	stopRecreations := runTestPodRecreations(t, kube, pods[0].Namespace, pods[0].Name)
+	result, out, err := runTestMultitrack(t, kube, MultitrackSpecs{Pods: specs}, MultitrackOptions{})
+	stopRecreations()
+
+	var failedErr *FailedResourcesError
+	if !errors.As(err, &failedErr) {
+		t.Fatalf("expected *FailedResourcesError, got %v\n%s", err, out)
+	}
+	if len(failedErr.Failures) != 1 || failedErr.Failures[0].ID() != "po/ns-0/app-0" || failedErr.Failures[0].Reason != resourceDeletedReason {
+		t.Errorf("expected po/ns-0/app-0 failed with %q reason, got %+v", resourceDeletedReason, failedErr.Failures)
+	}
+
+	resource := findTestResourceResult(result, "po", "app-0")
+	if resource == nil {
+		t.Fatalf("no result of Pod app-0")
+	}
+	if resource.Outcome != ResourceOutcomeFailed {
+		t.Errorf("expected Failed outcome, got %s", resource.Outcome)
+	}
+}
+
+func TestMultitrackResourceDeletedTreatedAsSuccess(t *testing.T) {
+	kube := fake.NewSimpleClientset()
+	pods, specs := createTestPods(t, kube, 1)
+	specs[0].TreatDeletionAsSuccess = true
+
+	stopRecreations := runTestPodRecreations(t, kube, pods[0].Namespace, pods[0].Name)
+	result, out, err := runTestMultitrack(t, kube, MultitrackSpecs{Pods: specs}, MultitrackOptions{})
+	stopRecreations()
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s\n%s", err, out)
+	}
+
+	resource := findTestResourceResult(result, "po", "app-0")
+	if resource == nil {
+		t.Fatalf("no result of Pod app-0")
+	}
+	if resource.Outcome != ResourceOutcomeReady {
+		t.Errorf("expected Ready outcome, got %s", resource.Outcome)
+	}
+}
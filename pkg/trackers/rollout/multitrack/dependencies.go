@@ -0,0 +1,224 @@
+package multitrack
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// specsByKind returns the specs by short kind of the tracked resources.
+func specsByKind(specs MultitrackSpecs) map[string][]MultitrackSpec {
+	return map[string][]MultitrackSpec{
+		"po":      specs.Pods,
+		"deploy":  specs.Deployments,
+		"rs":      specs.ReplicaSets,
+		"sts":     specs.StatefulSets,
+		"ds":      specs.DaemonSets,
+		"job":     specs.Jobs,
+		"generic": specs.Generic,
+	}
+}
+
+// dependencyRef returns the dependency of the spec with the namespace of the spec when the namespace of the dependency is not set.
+func dependencyRef(spec MultitrackSpec, ref ResourceRef) ResourceRef {
+	if ref.Namespace == "" {
+		ref.Namespace = spec.Namespace
+	}
+	return ref
+}
+
+// validateDependsOn checks that the dependencies of all specs are tracked and do not form cycles,
+// so that no resource waits for the dependency which never becomes ready.
+func validateDependsOn(specs MultitrackSpecs) error {
+	kindsSpecs := specsByKind(specs)
+
+	dependencies := make(map[string][]string)
+	for _, kind := range sortedKinds(kindsSpecs) {
+		for _, spec := range kindsSpecs[kind] {
+			id := ResourceRef{Kind: kind, Namespace: spec.Namespace, Name: spec.ResourceName}.String()
+
+			for _, ref := range spec.DependsOn {
+				ref = dependencyRef(spec, ref)
+
+				kindSpecs, isKnownKind := kindsSpecs[ref.Kind]
+				if !isKnownKind {
+					return fmt.Errorf("bad DependsOn of %s: unknown kind %q of %s, expected one of po, deploy, rs, sts, ds, job or generic", id, ref.Kind, ref)
+				}
+
+				isTracked := false
+				for _, s := range kindSpecs {
+					if s.ResourceName == ref.Name && s.Namespace == ref.Namespace {
+						isTracked = true
+						break
+					}
+				}
+				if !isTracked {
+					return fmt.Errorf("bad DependsOn of %s: %s is not tracked", id, ref)
+				}
+
+				dependencies[id] = append(dependencies[id], ref.String())
+			}
+		}
+	}
+
+	if cycle := findDependencyCycle(dependencies); cycle != nil {
+		return fmt.Errorf("bad DependsOn: dependency cycle %s", strings.Join(cycle, " -> "))
+	}
+
+	return nil
+}
+
+func sortedKinds(kindsSpecs map[string][]MultitrackSpec) []string {
+	var kinds []string
+	for kind := range kindsSpecs {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// findDependencyCycle returns the first found cycle of the dependencies graph starting and ending with the same resource, nil when there are no cycles.
+func findDependencyCycle(dependencies map[string][]string) []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	states := make(map[string]int)
+	var path []string
+
+	var visit func(id string) []string
+	visit = func(id string) []string {
+		switch states[id] {
+		case visited:
+			return nil
+		case visiting:
+			for i, pathID := range path {
+				if pathID == id {
+					return append(append([]string(nil), path[i:]...), id)
+				}
+			}
+		}
+
+		states[id] = visiting
+		path = append(path, id)
+
+		for _, dependency := range dependencies[id] {
+			if cycle := visit(dependency); cycle != nil {
+				return cycle
+			}
+		}
+
+		path = path[:len(path)-1]
+		states[id] = visited
+
+		return nil
+	}
+
+	var ids []string
+	for id := range dependencies {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if cycle := visit(id); cycle != nil {
+			return cycle
+		}
+	}
+
+	return nil
+}
+
+// waitForDependencies blocks until the dependencies of the resource are resolved, see resolveDependencies.
+// It returns false when the resource is skipped because of the failed dependency or the tracking is stopped meanwhile.
+func (mt *multitracker) waitForDependencies(kind string, spec MultitrackSpec, mtCtx *multitrackerContext) (bool, error) {
+	mt.mux.Lock()
+	state := mt.resourcesStatesByKind(kind)[resourceKey(spec)]
+	resolved := state.DependenciesResolved
+	mt.mux.Unlock()
+
+	if resolved == nil {
+		return true, nil
+	}
+
+	select {
+	case <-resolved:
+	case <-mtCtx.Context.Done():
+		return false, mtCtx.Context.Err()
+	}
+
+	mt.mux.Lock()
+	defer mt.mux.Unlock()
+
+	return state.Status != ResourceSkipped, nil
+}
+
+// resolveDependencies starts tracking of the resources which dependencies are all ready and skips the resources
+// with the dependency which failed or stopped without becoming ready. It must be called under handlers mutex
+// after the state of some resource changed.
+func (mt *multitracker) resolveDependencies() {
+	for _, kind := range mt.trackedKinds() {
+		for _, key := range sortedSpecsKeys(kind.Specs) {
+			state := kind.States[key]
+			if state.StatusAvailability != ResourceStatusWaitingForDependencies {
+				continue
+			}
+
+			spec := kind.Specs[key]
+
+			isReady := true
+			skippedReason := ""
+			for _, ref := range spec.DependsOn {
+				ref = dependencyRef(spec, ref)
+				dependencyState := mt.resourcesStatesByKind(ref.Kind)[resourceKey(ref.spec())]
+				dependencyID := mt.resourceID(ref.Kind, ref.spec())
+
+				switch {
+				case dependencyState == nil:
+				case dependencyState.Status == ResourceSucceeded:
+					continue
+				case dependencyState.Status == ResourceFailed:
+					skippedReason = fmt.Sprintf("dependency %s failed", dependencyID)
+				case dependencyState.Status == ResourceSkipped:
+					skippedReason = fmt.Sprintf("dependency %s skipped", dependencyID)
+				case !dependencyState.StoppedAt.IsZero():
+					skippedReason = fmt.Sprintf("dependency %s stopped without becoming ready", dependencyID)
+				}
+
+				isReady = false
+				if skippedReason != "" {
+					break
+				}
+			}
+
+			switch {
+			case skippedReason != "":
+				state.Status = ResourceSkipped
+				state.setStatusAvailability(ResourceStatusSkipped, skippedReason)
+				mt.displayMultitrackServiceMessageF("%s skipped: %s\n", mt.resourceID(kind.Kind, spec), skippedReason)
+			case isReady:
+				state.setStatusAvailability(ResourceStatusUnavailable, "")
+				mt.displayResourceTrackerMessageF(kind.Kind, spec, "dependencies are ready, tracking started")
+			default:
+				state.setStatusAvailability(ResourceStatusWaitingForDependencies, mt.formatWaitingForDependencies(spec))
+				continue
+			}
+
+			close(state.DependenciesResolved)
+		}
+	}
+}
+
+// formatWaitingForDependencies returns the dependencies of the resource which are not ready yet.
+func (mt *multitracker) formatWaitingForDependencies(spec MultitrackSpec) string {
+	var ids []string
+	for _, ref := range spec.DependsOn {
+		ref = dependencyRef(spec, ref)
+		if state := mt.resourcesStatesByKind(ref.Kind)[resourceKey(ref.spec())]; state == nil || state.Status != ResourceSucceeded {
+			ids = append(ids, mt.resourceID(ref.Kind, ref.spec()))
+		}
+	}
+	return strings.Join(ids, ", ")
+}
@@ -0,0 +1,156 @@
+package multitrack
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/acarl005/stripansi"
+
+	"github.com/werf/kubedog/pkg/tracker"
+	"github.com/werf/kubedog/pkg/tracker/pod"
+)
+
+// ErrDeployTimeout matches *DeployTimeoutError with errors.Is.
+var ErrDeployTimeout = errors.New("deploy timeout")
+
+// NotReadyResource is the resource which has not become ready within the deploy timeout.
+type NotReadyResource struct {
+	Kind      string
+	Namespace string
+	Name      string
+
+	// LastStatus is the last known status of the resource, e.g. "waiting for: up-to-date 2->3; po/prod/api-x: readiness probe failing (container app)".
+	LastStatus string
+}
+
+// ID returns the resource identifier including namespace, e.g. "deploy/prod/api".
+func (r NotReadyResource) ID() string {
+	return tracker.FormatResourceID(r.Kind, r.Namespace, r.Name)
+}
+
+// DeployTimeoutError is returned by Multitrack when DeployTimeout exceeded, ready resources are not listed.
+type DeployTimeoutError struct {
+	Timeout   time.Duration
+	Resources []NotReadyResource
+}
+
+func (e *DeployTimeoutError) Error() string {
+	lines := []string{fmt.Sprintf("deploy timeout %s exceeded, resources are not ready:", e.Timeout)}
+	for _, resource := range e.Resources {
+		lines = append(lines, fmt.Sprintf("%s: %s", resource.ID(), resource.LastStatus))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (e *DeployTimeoutError) Is(target error) bool {
+	return target == ErrDeployTimeout
+}
+
+func (mt *multitracker) setTimedOut() {
+	mt.mux.Lock()
+	defer mt.mux.Unlock()
+
+	mt.isTimedOut = true
+}
+
+// handleDeployTimeout shows the final status report and not ready resources, it is called when trackers results are not awaited anymore.
+func (mt *multitracker) handleDeployTimeout(timeout time.Duration) error {
+	mt.mux.Lock()
+	defer mt.mux.Unlock()
+
+	if err := mt.displayStatusProgress(false); err != nil {
+		return err
+	}
+
+	err := mt.formatDeployTimeoutError(timeout)
+	mt.displayMultitrackErrorMessageF("%s\n", err.Error())
+
+	return err
+}
+
+func (mt *multitracker) formatDeployTimeoutError(timeout time.Duration) *DeployTimeoutError {
+	err := &DeployTimeoutError{Timeout: timeout}
+
+	for _, kind := range mt.trackedKinds() {
+		for _, name := range sortedSpecsKeys(kind.Specs) {
+			if kind.States[name].Status == ResourceSucceeded {
+				continue
+			}
+
+			err.Resources = append(err.Resources, NotReadyResource{
+				Kind:       kind.Kind,
+				Namespace:  kind.Specs[name].Namespace,
+				Name:       kind.Specs[name].ResourceName,
+				LastStatus: mt.formatResourceLastStatus(kind.Kind, kind.Specs[name].Namespace, name, kind.States[name]),
+			})
+		}
+	}
+
+	return err
+}
+
+// formatResourceLastStatus describes the last known status of the not ready resource in one line.
+func (mt *multitracker) formatResourceLastStatus(kind, namespace, name string, state *multitrackerResourceState) string {
+	var statusGeneration uint64
+	var failedReason string
+	var waitingForMessages []string
+	var pods map[string]pod.PodStatus
+	var newPodsNames []string
+
+	switch kind {
+	case "po":
+		status := mt.PodsStatuses[name]
+		statusGeneration, failedReason = status.StatusGeneration, status.FailedReason
+		if status.StatusIndicator != nil && status.StatusIndicator.Value != "" && failedReason == "" {
+			return podBlockingCause(status)
+		}
+	case "deploy":
+		status := mt.DeploymentsStatuses[name]
+		statusGeneration, failedReason, waitingForMessages = status.StatusGeneration, status.FailedReason, status.WaitingForMessages
+		pods, newPodsNames = status.Pods, status.NewPodsNames
+	case "rs":
+		status := mt.ReplicaSetsStatuses[name]
+		statusGeneration, failedReason, waitingForMessages = status.StatusGeneration, status.FailedReason, status.WaitingForMessages
+		pods, newPodsNames = status.Pods, status.NewPodsNames
+	case "sts":
+		status := mt.StatefulSetsStatuses[name]
+		statusGeneration, failedReason, waitingForMessages = status.StatusGeneration, status.FailedReason, status.WaitingForMessages
+		pods, newPodsNames = status.Pods, status.NewPodsNames
+	case "ds":
+		status := mt.DaemonSetsStatuses[name]
+		statusGeneration, failedReason, waitingForMessages = status.StatusGeneration, status.FailedReason, status.WaitingForMessages
+		pods, newPodsNames = status.Pods, status.NewPodsNames
+	case "job":
+		status := mt.JobsStatuses[name]
+		statusGeneration, failedReason, waitingForMessages = status.StatusGeneration, status.FailedReason, status.WaitingForMessages
+	case "generic":
+		status := mt.GenericStatuses[name]
+		statusGeneration, failedReason, waitingForMessages = status.StatusGeneration, status.FailedReason, status.WaitingForMessages
+	}
+
+	if failedReason == "" {
+		failedReason = state.FailedReason
+	}
+	if statusGeneration == 0 && failedReason == "" {
+		return stripansi.Strip(mt.formatResourceStatusAvailability(state))
+	}
+
+	var parts []string
+	if failedReason != "" {
+		parts = append(parts, fmt.Sprintf("failed: %s", firstLine(failedReason)))
+	}
+	if len(waitingForMessages) > 0 {
+		parts = append(parts, fmt.Sprintf("waiting for: %s", strings.Join(waitingForMessages, ", ")))
+	}
+	for _, blockingPod := range getBlockingPods(pods, newPodsNames) {
+		parts = append(parts, fmt.Sprintf("%s: %s", tracker.FormatResourceID("po", namespace, blockingPod.Name), blockingPod.Cause))
+	}
+
+	if len(parts) == 0 {
+		return "not ready"
+	}
+
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,129 @@
+package multitrack
+
+import (
+	"sync"
+	"time"
+
+	"github.com/werf/logboek/pkg/types"
+
+	"github.com/werf/kubedog/pkg/utils"
+)
+
+// displaySource identifies the source of the displayed output, e.g. logs of a container or service messages of a resource.
+// Output of the source with Header is shown in the section under this header,
+// output of the headerless sources (status reports, errors, multitrack messages) is shown outside of sections.
+type displaySource struct {
+	Header  string
+	Options func(types.LogProcessOptionsInterface)
+}
+
+type displayWrite struct {
+	Source displaySource
+	Do     func()
+}
+
+// displaySerializer performs writes of all sources one by one and owns the state of sections:
+// the section header is emitted exactly when the source of consecutive writes changes,
+// so the logs interrupted by a status report or by the logs of another container are continued under the repeated header.
+// The header is emitted when the write is performed, so with the async output the header
+// can not be dropped or reordered apart from the lines of its source.
+type displaySerializer struct {
+	logger        types.LoggerInterface
+	outputAdapter OutputAdapter
+
+	mux           sync.Mutex
+	currentHeader string
+	logProcess    types.LogProcessInterface
+	isDisplayed   bool
+
+	// queue is set with the async output
+	queue *utils.AsyncQueue
+}
+
+func newDisplaySerializer(logger types.LoggerInterface, outputAdapter OutputAdapter) *displaySerializer {
+	return &displaySerializer{
+		logger:        logger,
+		outputAdapter: outputAdapter,
+	}
+}
+
+// startAsync makes writes queued and performed by a dedicated goroutine, so that a slow output sink does not block tracking.
+// When the queue is full for longer than enqueueTimeout the oldest queued write is dropped.
+// 0 queueSize or enqueueTimeout means the default value of utils.AsyncQueue.
+func (s *displaySerializer) startAsync(queueSize int, enqueueTimeout time.Duration) {
+	s.queue = utils.NewAsyncQueue(queueSize, enqueueTimeout)
+}
+
+// write performs do as the output of the source, do must not depend on the state which may change before it is performed.
+func (s *displaySerializer) write(source displaySource, do func()) {
+	w := displayWrite{Source: source, Do: do}
+
+	if s.queue == nil {
+		s.perform(w)
+		return
+	}
+
+	s.queue.Enqueue(func() { s.perform(w) })
+}
+
+func (s *displaySerializer) perform(w displayWrite) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.switchSource(w.Source)
+	w.Do()
+	s.isDisplayed = true
+}
+
+// displayedBefore returns true when some output has been displayed before the write being performed,
+// it may be called only by the write functions.
+func (s *displaySerializer) displayedBefore() bool {
+	return s.isDisplayed
+}
+
+func (s *displaySerializer) switchSource(source displaySource) {
+	if source.Header == s.currentHeader {
+		return
+	}
+
+	if s.outputAdapter != nil && s.currentHeader != "" {
+		s.outputAdapter.EndSection()
+	}
+	if s.logProcess != nil {
+		s.logProcess.End()
+		s.logProcess = nil
+	}
+	s.currentHeader = source.Header
+
+	if source.Header == "" {
+		return
+	}
+
+	if s.outputAdapter != nil {
+		s.outputAdapter.BeginSection(source.Header)
+		return
+	}
+
+	logProcess := s.logger.Default().LogProcess(source.Header)
+	if source.Options != nil {
+		logProcess.Options(source.Options)
+	}
+	logProcess.Start()
+
+	s.logProcess = logProcess
+}
+
+// flush blocks until all queued writes are performed.
+func (s *displaySerializer) flush() {
+	if s.queue != nil {
+		s.queue.Flush()
+	}
+}
+
+// droppedWrites returns the number of writes dropped because the output sink could not keep up.
+func (s *displaySerializer) droppedWrites() uint64 {
+	if s.queue == nil {
+		return 0
+	}
+	return s.queue.Dropped()
+}
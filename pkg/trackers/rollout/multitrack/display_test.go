@@ -0,0 +1,166 @@
+package multitrack
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// recordingOutputAdapter records the output as "begin <title>", "end" and "line <text>" entries,
+// delay slows down each call to imitate a slow output sink.
+type recordingOutputAdapter struct {
+	delay time.Duration
+
+	mux     sync.Mutex
+	entries []string
+}
+
+func (a *recordingOutputAdapter) record(entry string) {
+	time.Sleep(a.delay)
+
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	a.entries = append(a.entries, entry)
+}
+
+func (a *recordingOutputAdapter) BeginSection(title string) { a.record("begin " + title) }
+func (a *recordingOutputAdapter) EndSection()               { a.record("end") }
+func (a *recordingOutputAdapter) Line(text string)          { a.record("line " + text) }
+
+func (a *recordingOutputAdapter) Entries() []string {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	return append([]string(nil), a.entries...)
+}
+
+// checkTestSections checks that sections are balanced and not empty.
+func checkTestSections(t *testing.T, entries []string) {
+	t.Helper()
+
+	section := ""
+	for i, entry := range entries {
+		switch {
+		case strings.HasPrefix(entry, "begin "):
+			if section != "" {
+				t.Fatalf("entry %d: section %q begun inside section %q", i, entry, section)
+			}
+			if i+1 == len(entries) || !strings.HasPrefix(entries[i+1], "line ") {
+				t.Fatalf("entry %d: empty section %q", i, entry)
+			}
+			section = strings.TrimPrefix(entry, "begin ")
+		case entry == "end":
+			if section == "" {
+				t.Fatalf("entry %d: section ended outside of section", i)
+			}
+			section = ""
+		}
+	}
+}
+
+// TestDisplaySerializerConcurrentWriters writes the logs of two containers and the status reports concurrently
+// through the async queue: the lines of each source keep their order and are shown under the header of their source,
+// the header is repeated exactly when the source changes.
+func TestDisplaySerializerConcurrentWriters(t *testing.T) {
+	const linesPerSource = 200
+
+	adapter := &recordingOutputAdapter{}
+	s := newDisplaySerializer(nil, adapter)
+	s.startAsync(0, time.Second)
+
+	sources := []displaySource{
+		{Header: "po/app-0 logs"},
+		{Header: "po/app-1 logs"},
+		{},
+	}
+
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		wg.Add(1)
+		go func(i int, source displaySource) {
+			defer wg.Done()
+
+			for n := 0; n < linesPerSource; n++ {
+				text := fmt.Sprintf("%d:%d", i, n)
+				s.write(source, func() { adapter.Line(text) })
+			}
+		}(i, source)
+	}
+	wg.Wait()
+	s.flush()
+
+	if dropped := s.droppedWrites(); dropped != 0 {
+		t.Fatalf("expected no dropped writes, got %d", dropped)
+	}
+
+	entries := adapter.Entries()
+	checkTestSections(t, entries)
+
+	next := make([]int, len(sources))
+	section := ""
+	for i, entry := range entries {
+		switch {
+		case strings.HasPrefix(entry, "begin "):
+			section = strings.TrimPrefix(entry, "begin ")
+		case entry == "end":
+			section = ""
+		default:
+			var source, n int
+			if _, err := fmt.Sscanf(entry, "line %d:%d", &source, &n); err != nil {
+				t.Fatalf("entry %d: unexpected entry %q", i, entry)
+			}
+			if n != next[source] {
+				t.Fatalf("entry %d: expected line %d of source %d, got %q", i, next[source], source, entry)
+			}
+			if header := sources[source].Header; header != section {
+				t.Fatalf("entry %d: expected %q shown in section %q, got section %q", i, entry, header, section)
+			}
+			next[source]++
+
+			// The header is not repeated while the source is the same
+			if i > 0 && strings.HasPrefix(entries[i-1], "line ") {
+				continue
+			}
+			if section != "" && entries[i-1] != "begin "+section {
+				t.Fatalf("entry %d: expected %q preceded by the header, got %q", i, entry, entries[i-1])
+			}
+		}
+	}
+
+	for source, n := range next {
+		if n != linesPerSource {
+			t.Errorf("expected %d lines of source %d, got %d", linesPerSource, source, n)
+		}
+	}
+}
+
+// TestMultitrackAsyncOutputFlushed tracks the ready Pod with the slow output adapter:
+// all queued output is written before Multitrack returns.
+func TestMultitrackAsyncOutputFlushed(t *testing.T) {
+	kube := fake.NewSimpleClientset()
+	pods, specs := createTestPods(t, kube, 1)
+	updateTestPodStatus(t, kube, setTestPodReady(pods[0]))
+
+	adapter := &recordingOutputAdapter{delay: time.Millisecond}
+	_, _, err := runTestMultitrack(t, kube, MultitrackSpecs{Pods: specs}, MultitrackOptions{
+		AsyncOutput:   true,
+		OutputAdapter: adapter,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	entries := adapter.Entries()
+	if len(entries) == 0 {
+		t.Fatalf("expected output written before Multitrack returned")
+	}
+	checkTestSections(t, entries)
+
+	time.Sleep(50 * time.Millisecond)
+	if n := len(adapter.Entries()); n != len(entries) {
+		t.Errorf("expected all output written before Multitrack returned, got %d entries after return, %d before:\n%s", n, len(entries), strings.Join(adapter.Entries(), "\n"))
+	}
+}
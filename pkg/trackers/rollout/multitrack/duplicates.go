@@ -0,0 +1,368 @@
+package multitrack
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/werf/kubedog/pkg/tracker"
+)
+
+var failModesStrictness = map[FailMode]int{
+	IgnoreAndContinueDeployProcess:    0,
+	HopeUntilEndOfDeployProcess:       1,
+	FailWholeDeployProcessImmediately: 2,
+}
+
+// handleDuplicateSpecs finds specs of the same kind with the same namespace and name.
+// Duplicates are rejected unless merge is enabled, in which case they are merged into the first occurrence
+// and merge messages are returned. Specs should have default values set.
+func handleDuplicateSpecs(kind string, specs []MultitrackSpec, merge bool) ([]MultitrackSpec, []string, error) {
+	var res []MultitrackSpec
+	var msgs []string
+
+	indexByID := make(map[string]int)
+
+	for _, spec := range specs {
+		key := resourceKey(spec)
+
+		ind, hasKey := indexByID[key]
+		if !hasKey {
+			indexByID[key] = len(res)
+			res = append(res, spec)
+			continue
+		}
+
+		id := tracker.FormatResourceID(kind, spec.Namespace, spec.ResourceName)
+
+		if !merge {
+			return nil, nil, fmt.Errorf("%s is specified more than once, remove duplicates or enable MergeDuplicateSpecs option", id)
+		}
+
+		if field := conflictingSpecsField(res[ind], spec); field != "" {
+			return nil, nil, fmt.Errorf("%s is specified more than once with contradicting %s", id, field)
+		}
+
+		res[ind] = mergeSpecs(res[ind], spec)
+		msgs = append(msgs, fmt.Sprintf("Duplicate %s specs merged: fail mode %s, allowed failures count %s", id, res[ind].FailMode, formatSpecAllowFailuresCount(res[ind])))
+	}
+
+	return res, msgs, nil
+}
+
+// conflictingSpecsField returns the name of the field of the specs which cannot be merged, empty string when the specs can be merged.
+func conflictingSpecsField(a, b MultitrackSpec) string {
+	switch {
+	case a.ExpectFailure != b.ExpectFailure:
+		return "ExpectFailure"
+	case a.ServesWebhook && b.ServesWebhook && webhookServiceName(a) != webhookServiceName(b):
+		return "WebhookServiceName"
+	case a.ReadinessHTTPCheck != nil && b.ReadinessHTTPCheck != nil && *a.ReadinessHTTPCheck != *b.ReadinessHTTPCheck:
+		return "ReadinessHTTPCheck"
+	case a.ExpectedFailureReasonRegex != nil && b.ExpectedFailureReasonRegex != nil && a.ExpectedFailureReasonRegex.String() != b.ExpectedFailureReasonRegex.String():
+		return "ExpectedFailureReasonRegex"
+	case a.SuccessCondition != b.SuccessCondition:
+		return "SuccessCondition"
+	case a.GroupVersionResource != b.GroupVersionResource:
+		return "GroupVersionResource"
+	case genericConditions(a).Ready != genericConditions(b).Ready:
+		return "ReadyCondition"
+	case a.FailedCondition != nil && b.FailedCondition != nil && *a.FailedCondition != *b.FailedCondition:
+		return "FailedCondition"
+	}
+	return ""
+}
+
+// mergeSpecs merges spec b into spec a: the strictest tracking options win and log filters are united.
+func mergeSpecs(a, b MultitrackSpec) MultitrackSpec {
+	res := a
+
+	if b.TrackTerminationMode == WaitUntilResourceReady {
+		res.TrackTerminationMode = WaitUntilResourceReady
+	}
+
+	if failModesStrictness[b.FailMode] > failModesStrictness[a.FailMode] {
+		res.FailMode = b.FailMode
+	}
+
+	res.AllowFailuresCount = minIntPtr(a.AllowFailuresCount, b.AllowFailuresCount)
+	res.AllowFailuresCountPerReplica = minIntPtr(a.AllowFailuresCountPerReplica, b.AllowFailuresCountPerReplica)
+
+	res.FailureThresholdSeconds = new(int)
+	*res.FailureThresholdSeconds = *a.FailureThresholdSeconds
+	if *b.FailureThresholdSeconds < *a.FailureThresholdSeconds {
+		*res.FailureThresholdSeconds = *b.FailureThresholdSeconds
+	}
+
+	// Negative OrdinalStallThresholdSeconds disables the check
+	if a.OrdinalStallThresholdSeconds < 0 || (b.OrdinalStallThresholdSeconds >= 0 && ordinalStallThreshold(b) < ordinalStallThreshold(a)) {
+		res.OrdinalStallThresholdSeconds = b.OrdinalStallThresholdSeconds
+	}
+
+	res.FailOnDeleteUpdateStrategy = a.FailOnDeleteUpdateStrategy || b.FailOnDeleteUpdateStrategy
+	res.StrictDaemonSetAvailability = a.StrictDaemonSetAvailability || b.StrictDaemonSetAvailability
+
+	if a.UnschedulableGracePeriodSeconds < 0 || (b.UnschedulableGracePeriodSeconds >= 0 && unschedulableGracePeriod(b) < unschedulableGracePeriod(a)) {
+		res.UnschedulableGracePeriodSeconds = b.UnschedulableGracePeriodSeconds
+	}
+
+	if b.StabilityWindowSeconds > a.StabilityWindowSeconds {
+		res.StabilityWindowSeconds = b.StabilityWindowSeconds
+	}
+
+	if a.UnstableReadyFlapsCount < 0 || (b.UnstableReadyFlapsCount >= 0 && unstableReadyFlapsCount(b) < unstableReadyFlapsCount(a)) {
+		res.UnstableReadyFlapsCount = b.UnstableReadyFlapsCount
+	}
+
+	// Not set RequiredReadyPodsCount means all Pods should be ready
+	if a.RequiredReadyPodsCount == nil || b.RequiredReadyPodsCount == nil {
+		res.RequiredReadyPodsCount = nil
+	} else if *b.RequiredReadyPodsCount > *a.RequiredReadyPodsCount {
+		res.RequiredReadyPodsCount = b.RequiredReadyPodsCount
+	}
+
+	res.LogRegex = unionRegexps(a.LogRegex, b.LogRegex)
+
+	// Merged resource waits for the dependencies of both specs
+	res.DependsOn = nil
+	for _, ref := range append(append([]ResourceRef(nil), a.DependsOn...), b.DependsOn...) {
+		ref = dependencyRef(a, ref)
+		isDuplicate := false
+		for _, r := range res.DependsOn {
+			if r == ref {
+				isDuplicate = true
+				break
+			}
+		}
+		if !isDuplicate {
+			res.DependsOn = append(res.DependsOn, ref)
+		}
+	}
+
+	if a.LogRegexByContainerName != nil || b.LogRegexByContainerName != nil {
+		containerLogRegex := func(spec MultitrackSpec, containerName string) *regexp.Regexp {
+			if logRegex := spec.LogRegexByContainerName[containerName]; logRegex != nil {
+				return logRegex
+			}
+			return spec.LogRegex
+		}
+
+		res.LogRegexByContainerName = make(map[string]*regexp.Regexp)
+		for _, m := range []map[string]*regexp.Regexp{a.LogRegexByContainerName, b.LogRegexByContainerName} {
+			for containerName := range m {
+				res.LogRegexByContainerName[containerName] = unionRegexps(containerLogRegex(a, containerName), containerLogRegex(b, containerName))
+			}
+		}
+	}
+
+	res.SkipLogs = a.SkipLogs && b.SkipLogs
+
+	// Logs are shown until the latest of the conditions, explicit condition wins over the default one
+	if showLogsUntilDuration[b.ShowLogsUntil] > showLogsUntilDuration[a.ShowLogsUntil] {
+		res.ShowLogsUntil = b.ShowLogsUntil
+	}
+
+	// Container is skipped only when it is skipped by both specs
+	res.SkipLogsForContainers = nil
+	for _, containerName := range a.SkipLogsForContainers {
+		if isElemInArray(b.SkipLogsForContainers, containerName) {
+			res.SkipLogsForContainers = append(res.SkipLogsForContainers, containerName)
+		}
+	}
+
+	// Empty ShowLogsOnlyForContainers means all containers are shown
+	if len(a.ShowLogsOnlyForContainers) == 0 || len(b.ShowLogsOnlyForContainers) == 0 {
+		res.ShowLogsOnlyForContainers = nil
+	} else {
+		res.ShowLogsOnlyForContainers = append([]string{}, a.ShowLogsOnlyForContainers...)
+		for _, containerName := range b.ShowLogsOnlyForContainers {
+			res.ShowLogsOnlyForContainers = appendElemIfNotExist(res.ShowLogsOnlyForContainers, containerName)
+		}
+	}
+
+	// Not set TailLines means the whole log is shown
+	if a.TailLines == nil || b.TailLines == nil {
+		res.TailLines = nil
+	} else if *b.TailLines > *a.TailLines {
+		res.TailLines = b.TailLines
+	}
+	res.MaxLogLinesPerPod = int(mergeLogLimits(int64(a.MaxLogLinesPerPod), int64(b.MaxLogLinesPerPod)))
+	res.MaxLogBytesPerPod = mergeLogLimits(a.MaxLogBytesPerPod, b.MaxLogBytesPerPod)
+
+	res.ShowServiceMessages = a.ShowServiceMessages || b.ShowServiceMessages
+
+	if res.LogLinePrefix == "" {
+		res.LogLinePrefix = b.LogLinePrefix
+	}
+	res.ShowLogTimestamps = a.ShowLogTimestamps || b.ShowLogTimestamps
+
+	// The resource serves the webhook when any of the specs says so, the Service of the webhook is the same then
+	res.ServesWebhook = a.ServesWebhook || b.ServesWebhook
+	if !a.ServesWebhook {
+		res.WebhookServiceName = b.WebhookServiceName
+	}
+	res.WebhookTLSCheck = a.WebhookTLSCheck || b.WebhookTLSCheck
+
+	if res.ReadinessHTTPCheck == nil {
+		res.ReadinessHTTPCheck = b.ReadinessHTTPCheck
+	}
+
+	// The expectation is met later when the resource is expected to fail within the longer time, 0 means until the resource fails
+	if a.WithinSeconds == 0 || b.WithinSeconds == 0 {
+		res.WithinSeconds = 0
+	} else if b.WithinSeconds > a.WithinSeconds {
+		res.WithinSeconds = b.WithinSeconds
+	}
+	if res.ExpectedFailureReasonRegex == nil {
+		res.ExpectedFailureReasonRegex = b.ExpectedFailureReasonRegex
+	}
+
+	// The Job waits for the main containers of both specs, empty MainContainers means the default detection
+	res.MainContainers = append([]string{}, a.MainContainers...)
+	for _, containerName := range b.MainContainers {
+		res.MainContainers = appendElemIfNotExist(res.MainContainers, containerName)
+	}
+	if len(res.MainContainers) == 0 {
+		res.MainContainers = nil
+	}
+	res.TreatMainContainerExitAsJobCompletion = a.TreatMainContainerExitAsJobCompletion && b.TreatMainContainerExitAsJobCompletion
+
+	res.TrackTimeoutSeconds = minIntPtr(a.TrackTimeoutSeconds, b.TrackTimeoutSeconds)
+
+	res.Hooks = mergeHooks(a.Hooks, b.Hooks)
+
+	// The rollout is expected after the latest of the changes
+	if b.ExpectRolloutAfter.After(a.ExpectRolloutAfter) {
+		res.ExpectRolloutAfter = b.ExpectRolloutAfter
+	}
+	res.ExpectRolloutStrict = a.ExpectRolloutStrict || b.ExpectRolloutStrict
+
+	if res.ReadyCondition == nil {
+		res.ReadyCondition = b.ReadyCondition
+	}
+	if res.FailedCondition == nil {
+		res.FailedCondition = b.FailedCondition
+	}
+
+	res.VerifyServiceAccountAnnotations = append([]string{}, a.VerifyServiceAccountAnnotations...)
+	for _, annotation := range b.VerifyServiceAccountAnnotations {
+		res.VerifyServiceAccountAnnotations = appendElemIfNotExist(res.VerifyServiceAccountAnnotations, annotation)
+	}
+	if len(res.VerifyServiceAccountAnnotations) == 0 {
+		res.VerifyServiceAccountAnnotations = nil
+	}
+	res.StrictServiceAccountVerification = a.StrictServiceAccountVerification || b.StrictServiceAccountVerification
+
+	// Certificates expiring within the longest horizon are reported
+	res.VerifyMountedTLSSecrets = a.VerifyMountedTLSSecrets || b.VerifyMountedTLSSecrets
+	if tlsExpiryHorizon(b) > tlsExpiryHorizon(a) {
+		res.TLSExpiryHorizonSeconds = b.TLSExpiryHorizonSeconds
+	}
+	res.StrictTLSSecretVerification = a.StrictTLSSecretVerification || b.StrictTLSSecretVerification
+
+	res.WaitForResourceCreation = a.WaitForResourceCreation || b.WaitForResourceCreation
+	res.ResourceCreationTimeoutSeconds = minIntPtr(a.ResourceCreationTimeoutSeconds, b.ResourceCreationTimeoutSeconds)
+
+	// Deletion is a failure unless both specs treat it as the success
+	res.TreatDeletionAsSuccess = a.TreatDeletionAsSuccess && b.TreatDeletionAsSuccess
+
+	return res
+}
+
+// mergeHooks returns hooks calling the hooks of a followed by the hooks of b, the error of the hook of a stops the call.
+func mergeHooks(a, b *MultitrackHooks) *MultitrackHooks {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+
+	res := &MultitrackHooks{}
+
+	if a.OnResourceReady == nil || b.OnResourceReady == nil {
+		res.OnResourceReady = a.OnResourceReady
+		if res.OnResourceReady == nil {
+			res.OnResourceReady = b.OnResourceReady
+		}
+	} else {
+		res.OnResourceReady = func(kind, namespace, name string, status interface{}) error {
+			if err := a.OnResourceReady(kind, namespace, name, status); err != nil {
+				return err
+			}
+			return b.OnResourceReady(kind, namespace, name, status)
+		}
+	}
+
+	if a.OnResourceFailed == nil || b.OnResourceFailed == nil {
+		res.OnResourceFailed = a.OnResourceFailed
+		if res.OnResourceFailed == nil {
+			res.OnResourceFailed = b.OnResourceFailed
+		}
+	} else {
+		res.OnResourceFailed = func(kind, namespace, name, reason string) error {
+			if err := a.OnResourceFailed(kind, namespace, name, reason); err != nil {
+				return err
+			}
+			return b.OnResourceFailed(kind, namespace, name, reason)
+		}
+	}
+
+	if a.OnPodError == nil || b.OnPodError == nil {
+		res.OnPodError = a.OnPodError
+		if res.OnPodError == nil {
+			res.OnPodError = b.OnPodError
+		}
+	} else {
+		res.OnPodError = func(kind, namespace, name, podName, containerName, message string) error {
+			if err := a.OnPodError(kind, namespace, name, podName, containerName, message); err != nil {
+				return err
+			}
+			return b.OnPodError(kind, namespace, name, podName, containerName, message)
+		}
+	}
+
+	// OnStatusReport of the spec is ignored
+	return res
+}
+
+// mergeLogLimits returns the larger of the log limits, 0 or negative limit means no limit.
+func mergeLogLimits(a, b int64) int64 {
+	if a <= 0 || b <= 0 {
+		return 0
+	}
+	if b > a {
+		return b
+	}
+	return a
+}
+
+// unionRegexps returns regexp matching lines of any of the regexps, nil regexp matches any line.
+func unionRegexps(a, b *regexp.Regexp) *regexp.Regexp {
+	if a == nil || b == nil {
+		return nil
+	}
+
+	if a.String() == b.String() {
+		return a
+	}
+
+	return regexp.MustCompile(fmt.Sprintf("(?:%s)|(?:%s)", a.String(), b.String()))
+}
+
+// minIntPtr returns the copy of the lowest of the set values, nil when none is set.
+func minIntPtr(a, b *int) *int {
+	if a == nil && b == nil {
+		return nil
+	}
+
+	res := new(int)
+	switch {
+	case a == nil:
+		*res = *b
+	case b == nil || *a < *b:
+		*res = *a
+	default:
+		*res = *b
+	}
+	return res
+}
@@ -0,0 +1,542 @@
+package multitrack
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/werf/kubedog/pkg/tracker/generic"
+)
+
+// newTestDuplicateSpec returns the spec of deploy/default/app with default values set, changed by the set function.
+func newTestDuplicateSpec(set func(spec *MultitrackSpec)) MultitrackSpec {
+	spec := MultitrackSpec{ResourceName: "app", Namespace: "default"}
+	if set != nil {
+		set(&spec)
+	}
+	setDefaultSpecValues(&spec)
+	return spec
+}
+
+// testHooksCalls records the calls of the merged hooks.
+var testHooksCalls []string
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func mergeTestDuplicateSpecs(t *testing.T, a, b MultitrackSpec) MultitrackSpec {
+	t.Helper()
+
+	res, _, err := handleDuplicateSpecs("deploy", []MultitrackSpec{a, b}, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(res) != 1 {
+		t.Fatalf("expected 1 merged spec, got %d", len(res))
+	}
+	return res[0]
+}
+
+func TestHandleDuplicateSpecsMerge(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		a, b  func(spec *MultitrackSpec)
+		check func(t *testing.T, spec MultitrackSpec)
+	}{
+		{
+			name: "ServesWebhook of any spec",
+			b: func(spec *MultitrackSpec) {
+				spec.ServesWebhook = true
+				spec.WebhookServiceName = "app-webhook"
+			},
+			check: func(t *testing.T, spec MultitrackSpec) {
+				if !spec.ServesWebhook || spec.WebhookServiceName != "app-webhook" {
+					t.Errorf("expected webhook svc/app-webhook, got ServesWebhook=%v WebhookServiceName=%q", spec.ServesWebhook, spec.WebhookServiceName)
+				}
+			},
+		},
+		{
+			name: "WebhookServiceName of the spec serving webhook",
+			a: func(spec *MultitrackSpec) {
+				spec.ServesWebhook = true
+			},
+			b: func(spec *MultitrackSpec) {
+				spec.WebhookServiceName = "other"
+			},
+			check: func(t *testing.T, spec MultitrackSpec) {
+				if webhookServiceName(spec) != "app" {
+					t.Errorf("expected webhook svc/app, got svc/%s", webhookServiceName(spec))
+				}
+			},
+		},
+		{
+			name: "same default and explicit WebhookServiceName",
+			a: func(spec *MultitrackSpec) {
+				spec.ServesWebhook = true
+			},
+			b: func(spec *MultitrackSpec) {
+				spec.ServesWebhook = true
+				spec.WebhookServiceName = "app"
+			},
+			check: func(t *testing.T, spec MultitrackSpec) {
+				if webhookServiceName(spec) != "app" {
+					t.Errorf("expected webhook svc/app, got svc/%s", webhookServiceName(spec))
+				}
+			},
+		},
+		{
+			name: "WebhookTLSCheck of any spec",
+			a: func(spec *MultitrackSpec) {
+				spec.ServesWebhook = true
+			},
+			b: func(spec *MultitrackSpec) {
+				spec.ServesWebhook = true
+				spec.WebhookTLSCheck = true
+			},
+			check: func(t *testing.T, spec MultitrackSpec) {
+				if !spec.WebhookTLSCheck {
+					t.Errorf("expected WebhookTLSCheck")
+				}
+			},
+		},
+		{
+			name: "ReadinessHTTPCheck of any spec",
+			b: func(spec *MultitrackSpec) {
+				spec.ReadinessHTTPCheck = &ReadinessHTTPCheck{Port: 8080, Path: "/healthz"}
+			},
+			check: func(t *testing.T, spec MultitrackSpec) {
+				if spec.ReadinessHTTPCheck == nil || spec.ReadinessHTTPCheck.Port != 8080 {
+					t.Errorf("expected ReadinessHTTPCheck of port 8080, got %+v", spec.ReadinessHTTPCheck)
+				}
+			},
+		},
+		{
+			name: "longest WithinSeconds",
+			a: func(spec *MultitrackSpec) {
+				spec.ExpectFailure = true
+				spec.WithinSeconds = 30
+			},
+			b: func(spec *MultitrackSpec) {
+				spec.ExpectFailure = true
+				spec.WithinSeconds = 60
+				spec.ExpectedFailureReasonRegex = regexp.MustCompile("denied")
+			},
+			check: func(t *testing.T, spec MultitrackSpec) {
+				if spec.WithinSeconds != 60 {
+					t.Errorf("expected WithinSeconds 60, got %d", spec.WithinSeconds)
+				}
+				if spec.ExpectedFailureReasonRegex == nil || spec.ExpectedFailureReasonRegex.String() != "denied" {
+					t.Errorf("expected ExpectedFailureReasonRegex %q, got %v", "denied", spec.ExpectedFailureReasonRegex)
+				}
+			},
+		},
+		{
+			name: "WithinSeconds until the resource fails",
+			a: func(spec *MultitrackSpec) {
+				spec.ExpectFailure = true
+			},
+			b: func(spec *MultitrackSpec) {
+				spec.ExpectFailure = true
+				spec.WithinSeconds = 60
+			},
+			check: func(t *testing.T, spec MultitrackSpec) {
+				if spec.WithinSeconds != 0 {
+					t.Errorf("expected WithinSeconds 0, got %d", spec.WithinSeconds)
+				}
+			},
+		},
+		{
+			name: "union of MainContainers",
+			a: func(spec *MultitrackSpec) {
+				spec.MainContainers = []string{"main"}
+				spec.TreatMainContainerExitAsJobCompletion = true
+			},
+			b: func(spec *MultitrackSpec) {
+				spec.MainContainers = []string{"migrate", "main"}
+			},
+			check: func(t *testing.T, spec MultitrackSpec) {
+				if strings.Join(spec.MainContainers, ",") != "main,migrate" {
+					t.Errorf("expected MainContainers main,migrate, got %v", spec.MainContainers)
+				}
+				if spec.TreatMainContainerExitAsJobCompletion {
+					t.Errorf("expected TreatMainContainerExitAsJobCompletion of both specs only")
+				}
+			},
+		},
+		{
+			name: "shortest TrackTimeoutSeconds",
+			a: func(spec *MultitrackSpec) {
+				spec.TrackTimeoutSeconds = intPtr(300)
+			},
+			b: func(spec *MultitrackSpec) {
+				spec.TrackTimeoutSeconds = intPtr(60)
+			},
+			check: func(t *testing.T, spec MultitrackSpec) {
+				if spec.TrackTimeoutSeconds == nil || *spec.TrackTimeoutSeconds != 60 {
+					t.Errorf("expected TrackTimeoutSeconds 60, got %v", spec.TrackTimeoutSeconds)
+				}
+			},
+		},
+		{
+			name: "TrackTimeoutSeconds of any spec",
+			b: func(spec *MultitrackSpec) {
+				spec.TrackTimeoutSeconds = intPtr(60)
+			},
+			check: func(t *testing.T, spec MultitrackSpec) {
+				if spec.TrackTimeoutSeconds == nil || *spec.TrackTimeoutSeconds != 60 {
+					t.Errorf("expected TrackTimeoutSeconds 60, got %v", spec.TrackTimeoutSeconds)
+				}
+			},
+		},
+		{
+			name: "Hooks of both specs",
+			a: func(spec *MultitrackSpec) {
+				spec.Hooks = &MultitrackHooks{OnResourceFailed: func(kind, namespace, name, reason string) error {
+					testHooksCalls = append(testHooksCalls, "a")
+					return nil
+				}}
+			},
+			b: func(spec *MultitrackSpec) {
+				spec.Hooks = &MultitrackHooks{
+					OnResourceReady: func(kind, namespace, name string, status interface{}) error {
+						return nil
+					},
+					OnResourceFailed: func(kind, namespace, name, reason string) error {
+						testHooksCalls = append(testHooksCalls, "b")
+						return nil
+					},
+				}
+			},
+			check: func(t *testing.T, spec MultitrackSpec) {
+				testHooksCalls = nil
+				if spec.Hooks == nil || spec.Hooks.OnResourceReady == nil || spec.Hooks.OnResourceFailed == nil {
+					t.Fatalf("expected OnResourceReady and OnResourceFailed hooks, got %+v", spec.Hooks)
+				}
+				if err := spec.Hooks.OnResourceFailed("deploy", "default", "app", "error"); err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if strings.Join(testHooksCalls, ",") != "a,b" {
+					t.Errorf("expected hooks a,b to be called, got %v", testHooksCalls)
+				}
+			},
+		},
+		{
+			name: "latest ExpectRolloutAfter",
+			a: func(spec *MultitrackSpec) {
+				spec.ExpectRolloutAfter = time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+				spec.ExpectRolloutStrict = true
+			},
+			b: func(spec *MultitrackSpec) {
+				spec.ExpectRolloutAfter = time.Date(2020, 1, 1, 13, 0, 0, 0, time.UTC)
+			},
+			check: func(t *testing.T, spec MultitrackSpec) {
+				if !spec.ExpectRolloutAfter.Equal(time.Date(2020, 1, 1, 13, 0, 0, 0, time.UTC)) || !spec.ExpectRolloutStrict {
+					t.Errorf("expected strict rollout after 13:00, got %s strict %v", spec.ExpectRolloutAfter, spec.ExpectRolloutStrict)
+				}
+			},
+		},
+		{
+			name: "FailedCondition of any spec",
+			a: func(spec *MultitrackSpec) {
+				spec.ReadyCondition = &generic.ConditionMatch{Type: "Ready", Status: "True"}
+			},
+			b: func(spec *MultitrackSpec) {
+				spec.FailedCondition = &generic.ConditionMatch{Type: "Failed", Status: "True"}
+			},
+			check: func(t *testing.T, spec MultitrackSpec) {
+				if spec.FailedCondition == nil || spec.FailedCondition.String() != "Failed=True" {
+					t.Errorf("expected FailedCondition Failed=True, got %v", spec.FailedCondition)
+				}
+			},
+		},
+		{
+			name: "union of VerifyServiceAccountAnnotations",
+			a: func(spec *MultitrackSpec) {
+				spec.VerifyServiceAccountAnnotations = []string{"eks.amazonaws.com/role-arn"}
+			},
+			b: func(spec *MultitrackSpec) {
+				spec.VerifyServiceAccountAnnotations = []string{"iam.gke.io/gcp-service-account", "eks.amazonaws.com/role-arn"}
+				spec.StrictServiceAccountVerification = true
+			},
+			check: func(t *testing.T, spec MultitrackSpec) {
+				if strings.Join(spec.VerifyServiceAccountAnnotations, ",") != "eks.amazonaws.com/role-arn,iam.gke.io/gcp-service-account" {
+					t.Errorf("expected both annotations to be verified, got %v", spec.VerifyServiceAccountAnnotations)
+				}
+				if !spec.StrictServiceAccountVerification {
+					t.Errorf("expected StrictServiceAccountVerification")
+				}
+			},
+		},
+		{
+			name: "longest TLSExpiryHorizonSeconds",
+			a: func(spec *MultitrackSpec) {
+				spec.VerifyMountedTLSSecrets = true
+				spec.TLSExpiryHorizonSeconds = 30 * 24 * 3600
+			},
+			b: func(spec *MultitrackSpec) {
+				spec.StrictTLSSecretVerification = true
+			},
+			check: func(t *testing.T, spec MultitrackSpec) {
+				if !spec.VerifyMountedTLSSecrets || !spec.StrictTLSSecretVerification || spec.TLSExpiryHorizonSeconds != 30*24*3600 {
+					t.Errorf("expected strict verification with 30 days horizon, got %+v", spec)
+				}
+			},
+		},
+		{
+			name: "default TLSExpiryHorizonSeconds over only expired certificates",
+			a: func(spec *MultitrackSpec) {
+				spec.VerifyMountedTLSSecrets = true
+				spec.TLSExpiryHorizonSeconds = -1
+			},
+			b: func(spec *MultitrackSpec) {
+				spec.VerifyMountedTLSSecrets = true
+			},
+			check: func(t *testing.T, spec MultitrackSpec) {
+				if spec.TLSExpiryHorizonSeconds != 0 {
+					t.Errorf("expected default horizon, got %d", spec.TLSExpiryHorizonSeconds)
+				}
+			},
+		},
+		{
+			name: "shortest ResourceCreationTimeoutSeconds",
+			a: func(spec *MultitrackSpec) {
+				spec.WaitForResourceCreation = true
+				spec.ResourceCreationTimeoutSeconds = intPtr(60)
+			},
+			b: func(spec *MultitrackSpec) {
+				spec.WaitForResourceCreation = true
+				spec.ResourceCreationTimeoutSeconds = intPtr(30)
+			},
+			check: func(t *testing.T, spec MultitrackSpec) {
+				if !spec.WaitForResourceCreation || spec.ResourceCreationTimeoutSeconds == nil || *spec.ResourceCreationTimeoutSeconds != 30 {
+					t.Errorf("expected resource creation timeout 30, got %v", spec.ResourceCreationTimeoutSeconds)
+				}
+			},
+		},
+		{
+			name: "TreatDeletionAsSuccess of both specs",
+			a: func(spec *MultitrackSpec) {
+				spec.TreatDeletionAsSuccess = true
+			},
+			check: func(t *testing.T, spec MultitrackSpec) {
+				if spec.TreatDeletionAsSuccess {
+					t.Errorf("expected deletion to be the failure")
+				}
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.check(t, mergeTestDuplicateSpecs(t, newTestDuplicateSpec(tc.a), newTestDuplicateSpec(tc.b)))
+		})
+	}
+}
+
+func TestHandleDuplicateSpecsConflict(t *testing.T) {
+	for _, tc := range []struct {
+		field string
+		a, b  func(spec *MultitrackSpec)
+	}{
+		{
+			field: "ExpectFailure",
+			b: func(spec *MultitrackSpec) {
+				spec.ExpectFailure = true
+			},
+		},
+		{
+			field: "WebhookServiceName",
+			a: func(spec *MultitrackSpec) {
+				spec.ServesWebhook = true
+			},
+			b: func(spec *MultitrackSpec) {
+				spec.ServesWebhook = true
+				spec.WebhookServiceName = "app-webhook"
+			},
+		},
+		{
+			field: "ReadinessHTTPCheck",
+			a: func(spec *MultitrackSpec) {
+				spec.ReadinessHTTPCheck = &ReadinessHTTPCheck{Port: 8080, Path: "/healthz"}
+			},
+			b: func(spec *MultitrackSpec) {
+				spec.ReadinessHTTPCheck = &ReadinessHTTPCheck{Port: 8080, Path: "/ready"}
+			},
+		},
+		{
+			field: "ExpectedFailureReasonRegex",
+			a: func(spec *MultitrackSpec) {
+				spec.ExpectFailure = true
+				spec.ExpectedFailureReasonRegex = regexp.MustCompile("denied")
+			},
+			b: func(spec *MultitrackSpec) {
+				spec.ExpectFailure = true
+				spec.ExpectedFailureReasonRegex = regexp.MustCompile("forbidden")
+			},
+		},
+		{
+			field: "SuccessCondition",
+			b: func(spec *MultitrackSpec) {
+				spec.SuccessCondition = SuccessConditionPodsSucceeded
+			},
+		},
+		{
+			field: "GroupVersionResource",
+			a: func(spec *MultitrackSpec) {
+				spec.GroupVersionResource = schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+			},
+			b: func(spec *MultitrackSpec) {
+				spec.GroupVersionResource = schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "issuers"}
+			},
+		},
+		{
+			field: "ReadyCondition",
+			b: func(spec *MultitrackSpec) {
+				spec.ReadyCondition = &generic.ConditionMatch{Type: "Issued", Status: "True"}
+			},
+		},
+		{
+			field: "FailedCondition",
+			a: func(spec *MultitrackSpec) {
+				spec.FailedCondition = &generic.ConditionMatch{Type: "Failed", Status: "True"}
+			},
+			b: func(spec *MultitrackSpec) {
+				spec.FailedCondition = &generic.ConditionMatch{Type: "Degraded", Status: "True"}
+			},
+		},
+	} {
+		t.Run(tc.field, func(t *testing.T) {
+			_, _, err := handleDuplicateSpecs("deploy", []MultitrackSpec{newTestDuplicateSpec(tc.a), newTestDuplicateSpec(tc.b)}, true)
+			if err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if expected := "deploy/default/app is specified more than once with contradicting " + tc.field; err.Error() != expected {
+				t.Errorf("expected error %q, got %q", expected, err)
+			}
+		})
+	}
+}
+
+func TestHandleDuplicateSpecsMessages(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		specs        []MultitrackSpec
+		merge        bool
+		expectedErr  string
+		expectedMsgs []string
+		expectedLen  int
+	}{
+		{
+			name:        "rejected",
+			specs:       []MultitrackSpec{newTestDuplicateSpec(nil), newTestDuplicateSpec(nil)},
+			expectedErr: "sts/default/app is specified more than once, remove duplicates or enable MergeDuplicateSpecs option",
+		},
+		{
+			name: "merged",
+			specs: []MultitrackSpec{
+				newTestDuplicateSpec(nil),
+				newTestDuplicateSpec(func(spec *MultitrackSpec) { spec.AllowFailuresCount = intPtr(3) }),
+			},
+			merge:        true,
+			expectedMsgs: []string{"Duplicate sts/default/app specs merged: fail mode FailWholeDeployProcessImmediately, allowed failures count 1"},
+			expectedLen:  1,
+		},
+		{
+			name: "different namespaces",
+			specs: []MultitrackSpec{
+				newTestDuplicateSpec(nil),
+				newTestDuplicateSpec(func(spec *MultitrackSpec) { spec.Namespace = "staging" }),
+			},
+			expectedLen: 2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			res, msgs, err := handleDuplicateSpecs("sts", tc.specs, tc.merge)
+			if tc.expectedErr != "" {
+				if err == nil || err.Error() != tc.expectedErr {
+					t.Fatalf("expected error %q, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if len(res) != tc.expectedLen {
+				t.Errorf("expected %d specs, got %d", tc.expectedLen, len(res))
+			}
+			if !reflect.DeepEqual(msgs, tc.expectedMsgs) {
+				t.Errorf("expected messages %q, got %q", tc.expectedMsgs, msgs)
+			}
+		})
+	}
+}
+
+// mergedSpecFields are the fields of MultitrackSpec handled by mergeSpecs and conflictingSpecsField,
+// Namespace and ResourceName identify the duplicates.
+var mergedSpecFields = []string{
+	"AllowFailuresCount",
+	"AllowFailuresCountPerReplica",
+	"DependsOn",
+	"ExpectFailure",
+	"ExpectRolloutAfter",
+	"ExpectRolloutStrict",
+	"ExpectedFailureReasonRegex",
+	"FailMode",
+	"FailOnDeleteUpdateStrategy",
+	"FailedCondition",
+	"FailureThresholdSeconds",
+	"GroupVersionResource",
+	"Hooks",
+	"LogLinePrefix",
+	"LogRegex",
+	"LogRegexByContainerName",
+	"MainContainers",
+	"MaxLogBytesPerPod",
+	"MaxLogLinesPerPod",
+	"Namespace",
+	"OrdinalStallThresholdSeconds",
+	"ReadinessHTTPCheck",
+	"ReadyCondition",
+	"RequiredReadyPodsCount",
+	"ResourceCreationTimeoutSeconds",
+	"ResourceName",
+	"ServesWebhook",
+	"ShowLogTimestamps",
+	"ShowLogsOnlyForContainers",
+	"ShowLogsUntil",
+	"ShowServiceMessages",
+	"SkipLogs",
+	"SkipLogsForContainers",
+	"StabilityWindowSeconds",
+	"StrictDaemonSetAvailability",
+	"StrictServiceAccountVerification",
+	"StrictTLSSecretVerification",
+	"SuccessCondition",
+	"TLSExpiryHorizonSeconds",
+	"TailLines",
+	"TrackTerminationMode",
+	"TrackTimeoutSeconds",
+	"TreatDeletionAsSuccess",
+	"TreatMainContainerExitAsJobCompletion",
+	"UnschedulableGracePeriodSeconds",
+	"UnstableReadyFlapsCount",
+	"VerifyMountedTLSSecrets",
+	"VerifyServiceAccountAnnotations",
+	"WaitForResourceCreation",
+	"WebhookServiceName",
+	"WebhookTLSCheck",
+	"WithinSeconds",
+}
+
+func TestMergeSpecsHandlesAllFields(t *testing.T) {
+	specType := reflect.TypeOf(MultitrackSpec{})
+	for i := 0; i < specType.NumField(); i++ {
+		name := specType.Field(i).Name
+		if !isElemInArray(mergedSpecFields, name) {
+			t.Errorf("MultitrackSpec.%s is not merged: handle it in mergeSpecs (the strictest value wins) or in conflictingSpecsField, then add it to mergedSpecFields", name)
+		}
+	}
+}
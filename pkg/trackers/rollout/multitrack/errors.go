@@ -0,0 +1,144 @@
+package multitrack
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/werf/kubedog/pkg/tracker"
+)
+
+// FailureCode is a stable class of the resource failure, suitable for errors grouping.
+type FailureCode string
+
+const (
+	FailureCodeImagePull     FailureCode = "ImagePull"
+	FailureCodeCrashLoop     FailureCode = "CrashLoop"
+	FailureCodeTimeout       FailureCode = "Timeout"
+	FailureCodeQuotaExceeded FailureCode = "QuotaExceeded"
+	FailureCodeUnknown       FailureCode = "Unknown"
+
+	FailureCodeMissingDevicePlugin FailureCode = "MissingDevicePlugin"
+	FailureCodeMissingRuntimeClass FailureCode = "MissingRuntimeClass"
+)
+
+// failureCodesPatterns are checked in order, the first class with a matching pattern wins.
+var failureCodesPatterns = []struct {
+	Code     FailureCode
+	Patterns []string
+}{
+	{FailureCodeMissingDevicePlugin, []string{"device plugin not installed"}},
+	{FailureCodeMissingRuntimeClass, []string{"not configured on any node"}},
+	{FailureCodeImagePull, []string{"imagepullbackoff", "errimagepull", "errimageneverpull", "invalidimagename", "failed to pull image", "pull access denied"}},
+	{FailureCodeCrashLoop, []string{"crashloopbackoff", "back-off restarting failed container"}},
+	{FailureCodeQuotaExceeded, []string{"exceeded quota", "quota exceeded"}},
+	{FailureCodeTimeout, []string{"deadlineexceeded", "deadline exceeded", "timed out", "timeout", "has not completed after"}},
+}
+
+// ClassifyFailedReason maps the free-form failure reason collected during tracking to the FailureCode.
+func ClassifyFailedReason(reason string) FailureCode {
+	reason = strings.ToLower(reason)
+
+	for _, class := range failureCodesPatterns {
+		for _, pattern := range class.Patterns {
+			if strings.Contains(reason, pattern) {
+				return class.Code
+			}
+		}
+	}
+
+	return FailureCodeUnknown
+}
+
+var (
+	// e.g. "FailedScheduling: 0/3 nodes are available: 3 Insufficient nvidia.com/gpu."
+	unsatisfiableExtendedResourceRegex = regexp.MustCompile(`0/(\d+) nodes are available:.*?\b(\d+) Insufficient ([a-z0-9.-]+\.[a-z]+/[A-Za-z0-9._-]*[A-Za-z0-9])`)
+
+	// e.g. `pods "x" is forbidden: pod rejected: RuntimeClass "gvisor" not found` from admission,
+	// or `failed to get sandbox runtime: no runtime for "runsc" is configured` from kubelet.
+	missingRuntimeClassRegexes = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)RuntimeClass "([^"]+)" not found`),
+		regexp.MustCompile(`(?i)runtimeclasses?\.node\.k8s\.io "([^"]+)" not found`),
+		regexp.MustCompile(`(?i)no runtime for "([^"]+)" is configured`),
+		regexp.MustCompile(`(?i)RuntimeHandler "([^"]+)" not supported`),
+	}
+)
+
+// ExplainNonRetryableFailure recognizes failures which will not go away without changes in the cluster or in the resource,
+// such as extended resources which no node offers or unknown runtime class, and returns a targeted reason for them.
+func ExplainNonRetryableFailure(reason string) (string, bool) {
+	if match := unsatisfiableExtendedResourceRegex.FindStringSubmatch(reason); match != nil && match[1] == match[2] {
+		// Nodes having the resource, but not enough of it, make the failure retryable, so only the case when
+		// all nodes lack the resource is recognized. Zero nodes is the different problem.
+		if nodes, _ := strconv.Atoi(match[1]); nodes > 0 {
+			return fmt.Sprintf("no node offers resource %s — device plugin not installed?", match[3]), true
+		}
+	}
+
+	for _, regex := range missingRuntimeClassRegexes {
+		if match := regex.FindStringSubmatch(reason); match != nil {
+			return fmt.Sprintf("runtimeClass '%s' not configured on any node", match[1]), true
+		}
+	}
+
+	return "", false
+}
+
+type ResourceFailure struct {
+	Kind      string
+	Namespace string
+	Name      string
+	// Group is set when GroupByLabel option is set.
+	Group string
+
+	Code   FailureCode
+	Reason string
+
+	// Logs are the last lines of the log of the failing container, see FailedContainerLogLines option.
+	Logs *FailedContainerLogs
+
+	// SuspectedInfrastructure is set for the infrastructural failure of the resource when such failures
+	// of several resources triggered InfrastructureCheck.
+	SuspectedInfrastructure bool
+}
+
+// ID returns the resource identifier including namespace, e.g. "deploy/prod/api".
+func (f ResourceFailure) ID() string {
+	return tracker.FormatResourceID(f.Kind, f.Namespace, f.Name)
+}
+
+// FailedResourcesError is returned by Multitrack when some of the resources failed.
+// Failures are ordered by kind, namespace and name, so the same failure always produces the same message.
+type FailedResourcesError struct {
+	Failures []ResourceFailure
+	// InfrastructureNotes are the readiness of the system workloads, see InfrastructureCheck option.
+	InfrastructureNotes []string
+}
+
+func (e *FailedResourcesError) Error() string {
+	var lines []string
+	for _, failure := range e.Failures {
+		code := string(failure.Code)
+		if failure.SuspectedInfrastructure {
+			code += ", suspected infrastructure"
+		}
+
+		if failure.Group != "" {
+			lines = append(lines, fmt.Sprintf("[%s] %s: %s failed: %s", code, failure.Group, failure.ID(), failure.Reason))
+		} else {
+			lines = append(lines, fmt.Sprintf("[%s] %s failed: %s", code, failure.ID(), failure.Reason))
+		}
+
+		if failure.Logs != nil {
+			lines = append(lines, fmt.Sprintf("  %s", failure.Logs.header(failure.Namespace)))
+			for _, line := range failure.Logs.Lines {
+				lines = append(lines, fmt.Sprintf("    | %s", line))
+			}
+		}
+	}
+	for _, note := range e.InfrastructureNotes {
+		lines = append(lines, fmt.Sprintf("note: %s", note))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,118 @@
+package multitrack
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func TestClassifyFailedReason(t *testing.T) {
+	tests := []struct {
+		reason string
+		code   FailureCode
+	}{
+		{`container/main: ImagePullBackOff: Back-off pulling image "app:1.0"`, FailureCodeImagePull},
+		{`Failed to pull image "private/app": pull access denied`, FailureCodeImagePull},
+		{"container/main: CrashLoopBackOff: back-off 5m0s restarting failed container", FailureCodeCrashLoop},
+		{"BackoffLimitExceeded: Job has reached the specified backoff limit", FailureCodeUnknown},
+		{`ProgressDeadlineExceeded: ReplicaSet "api-7d4b" has timed out progressing.`, FailureCodeTimeout},
+		{"job has not completed after 5m0s", FailureCodeTimeout},
+		{`FailedCreate: pods "api-1" is forbidden: exceeded quota: compute, requested: cpu=2`, FailureCodeQuotaExceeded},
+		{"0/3 nodes are available: 3 Insufficient nvidia.com/gpu — device plugin not installed?", FailureCodeMissingDevicePlugin},
+		{"runtimeClass 'gvisor' not configured on any node", FailureCodeMissingRuntimeClass},
+		// The earlier class wins when the reason matches several ones
+		{"ErrImagePull: timed out waiting for the registry", FailureCodeImagePull},
+		{"", FailureCodeUnknown},
+	}
+
+	for _, tt := range tests {
+		if code := ClassifyFailedReason(tt.reason); code != tt.code {
+			t.Errorf("%q: expected %s, got %s", tt.reason, tt.code, code)
+		}
+	}
+}
+
+// TestFailedResourcesErrorOrder fails the same resources registered in random order:
+// the error always lists them ordered by kind, namespace and name.
+func TestFailedResourcesErrorOrder(t *testing.T) {
+	type failedResource struct {
+		kind      string
+		namespace string
+		name      string
+		reason    string
+	}
+	resources := []failedResource{
+		{"po", "default", "migrate", "Error"},
+		{"deploy", "default", "web", "container/main: CrashLoopBackOff: back-off restarting failed container"},
+		{"deploy", "prod", "api", `ProgressDeadlineExceeded: ReplicaSet "api-7d4b" has timed out progressing.`},
+		{"deploy", "prod", "worker", "Failed to pull image \"worker:2\""},
+		{"job", "batch", "report", "BackoffLimitExceeded"},
+	}
+	expected := "[Unknown] po/default/migrate failed: Error\n" +
+		"[CrashLoop] deploy/default/web failed: container/main: CrashLoopBackOff: back-off restarting failed container\n" +
+		"[Timeout] deploy/prod/api failed: ProgressDeadlineExceeded: ReplicaSet \"api-7d4b\" has timed out progressing.\n" +
+		"[ImagePull] deploy/prod/worker failed: Failed to pull image \"worker:2\"\n" +
+		"[Unknown] job/batch/report failed: BackoffLimitExceeded"
+
+	for seed := int64(0); seed < 10; seed++ {
+		mt := newTestMultitracker()
+		ready := MultitrackSpec{ResourceName: "cache", Namespace: "default"}
+		mt.DeploymentsSpecs[resourceKey(ready)] = ready
+		mt.TrackingDeployments[resourceKey(ready)] = newMultitrackerResourceState(ready)
+		mt.TrackingDeployments[resourceKey(ready)].Status = ResourceSucceeded
+
+		rnd := rand.New(rand.NewSource(seed))
+		for _, i := range rnd.Perm(len(resources)) {
+			resource := resources[i]
+			spec := MultitrackSpec{ResourceName: resource.name, Namespace: resource.namespace}
+			for _, kind := range mt.trackedKinds() {
+				if kind.Kind == resource.kind {
+					kind.Specs[resourceKey(spec)] = spec
+					kind.States[resourceKey(spec)] = newMultitrackerResourceState(spec)
+					kind.States[resourceKey(spec)].Status = ResourceFailed
+					kind.States[resourceKey(spec)].FailedReason = resource.reason
+				}
+			}
+		}
+
+		err := mt.formatFailedTrackingResourcesError()
+
+		var failedErr *FailedResourcesError
+		if !errors.As(err, &failedErr) {
+			t.Fatalf("seed %d: expected *FailedResourcesError, got %T", seed, err)
+		}
+		if len(failedErr.Failures) != len(resources) {
+			t.Fatalf("seed %d: expected %d failures, got %+v", seed, len(resources), failedErr.Failures)
+		}
+		if err.Error() != expected {
+			t.Fatalf("seed %d: expected error:\n%s\ngot:\n%s", seed, expected, err.Error())
+		}
+	}
+}
+
+func TestFailedResourcesErrorDetails(t *testing.T) {
+	err := &FailedResourcesError{
+		Failures: []ResourceFailure{
+			{
+				Kind: "deploy", Namespace: "prod", Name: "api", Group: "payments",
+				Code: FailureCodeCrashLoop, Reason: "container/main: CrashLoopBackOff",
+				Logs: &FailedContainerLogs{PodName: "api-7d4b-x2k", ContainerName: "main", Previous: true, Lines: []string{"starting", "panic: no config"}},
+			},
+			{
+				Kind: "ds", Namespace: "kube-system", Name: "agent",
+				Code: FailureCodeTimeout, Reason: "timed out", SuspectedInfrastructure: true,
+			},
+		},
+		InfrastructureNotes: []string{"ds/kube-system/kube-proxy is not ready: 2/3 ready"},
+	}
+
+	expected := "[CrashLoop] payments: deploy/prod/api failed: container/main: CrashLoopBackOff\n" +
+		"  last 2 lines of po/prod/api-7d4b-x2k container/main logs (previous instance):\n" +
+		"    | starting\n" +
+		"    | panic: no config\n" +
+		"[Timeout, suspected infrastructure] ds/kube-system/agent failed: timed out\n" +
+		"note: ds/kube-system/kube-proxy is not ready: 2/3 ready"
+	if err.Error() != expected {
+		t.Errorf("expected error:\n%s\ngot:\n%s", expected, err.Error())
+	}
+}
@@ -0,0 +1,76 @@
+package multitrack
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/werf/kubedog/pkg/tracker"
+	"github.com/werf/kubedog/pkg/tracker/event"
+)
+
+// Severity is how the events with some reason are treated, see EventSeverityOverrides.
+type Severity = tracker.EventSeverity
+
+const (
+	SeverityIgnore  = tracker.EventSeverityIgnore
+	SeverityWarning = tracker.EventSeverityWarning
+	SeverityFailure = tracker.EventSeverityFailure
+)
+
+func validateEventSeverityOverrides(opts MultitrackOptions) error {
+	for reason, severity := range opts.EventSeverityOverrides {
+		switch severity {
+		case SeverityIgnore, SeverityWarning, SeverityFailure:
+		default:
+			return fmt.Errorf("bad EventSeverityOverrides: unknown severity %q of reason %q, expected %s, %s or %s", severity, reason, SeverityIgnore, SeverityWarning, SeverityFailure)
+		}
+	}
+	return nil
+}
+
+// warnUnknownEventReasons warns about the reasons of EventSeverityOverrides which are not among event.KnownReasons,
+// usually it is a typo.
+func (mt *multitracker) warnUnknownEventReasons(opts MultitrackOptions) {
+	var unknownReasons []string
+	for reason := range opts.EventSeverityOverrides {
+		if !event.IsKnownReason(reason) {
+			unknownReasons = append(unknownReasons, reason)
+		}
+	}
+	if len(unknownReasons) == 0 {
+		return
+	}
+
+	sort.Strings(unknownReasons)
+	mt.displayMultitrackServiceMessageF("%s\n", formatResourceWarning(false, fmt.Sprintf("EventSeverityOverrides contains unknown event reasons: %s", strings.Join(unknownReasons, ", "))))
+}
+
+// eventMsgReason returns the reason of the event message "Reason: message", the message of the Pod event
+// of the controller is prefixed with the Pod name, e.g. "po/prod/web-abc Unhealthy: Readiness probe failed".
+func eventMsgReason(msg string) string {
+	if strings.HasPrefix(msg, "po/") {
+		if ind := strings.Index(msg, " "); ind >= 0 {
+			msg = msg[ind+1:]
+		}
+	}
+
+	if ind := strings.Index(msg, ": "); ind >= 0 {
+		return msg[:ind]
+	}
+	return ""
+}
+
+// displayResourceEventWarning shows the event of Warning severity regardless of ShowServiceMessages.
+func (mt *multitracker) displayResourceEventWarning(resourceKind string, spec MultitrackSpec, eventMsg string) {
+	msg := fmt.Sprintf("%s %s", mt.resourceID(resourceKind, spec), formatResourceWarning(false, fmt.Sprintf("event: %s", eventMsg)))
+
+	mt.display.write(displaySource{}, func() {
+		if mt.outputAdapter != nil {
+			mt.outputAdapter.Line(msg)
+			return
+		}
+
+		mt.logger.Default().LogF("%s\n", msg)
+	})
+}
@@ -0,0 +1,69 @@
+package multitrack
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// EventsAvailability is the result of the detection whether the cluster retains events,
+// events may be garbage collected aggressively (short event TTL) or not recorded at all.
+type EventsAvailability string
+
+const (
+	EventsAvailable   EventsAvailability = "Available"
+	EventsUnavailable EventsAvailability = "Unavailable"
+	// EventsAvailabilityUnknown means the detection request failed, e.g. no access to events, events are considered available then.
+	EventsAvailabilityUnknown EventsAvailability = "Unknown"
+)
+
+const eventsUnavailableMessage = "events unavailable in this cluster"
+
+// detectEventsAvailability lists events of the namespace of the first tracked resource once: the namespace without any events
+// at all means the events are unavailable. The availability is restored as soon as any event of the tracked resources is received.
+func (mt *multitracker) detectEventsAvailability(kube kubernetes.Interface, specs MultitrackSpecs, opts MultitrackOptions) {
+	ctx := opts.ParentContext
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var namespace string
+	for _, kindSpecs := range [][]MultitrackSpec{specs.Deployments, specs.ReplicaSets, specs.StatefulSets, specs.DaemonSets, specs.Jobs, specs.Pods, specs.Generic} {
+		if len(kindSpecs) > 0 {
+			namespace = kindSpecs[0].Namespace
+			break
+		}
+	}
+
+	list, err := kube.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{Limit: 1})
+	switch {
+	case err != nil:
+		mt.eventsAvailability = EventsAvailabilityUnknown
+	case len(list.Items) == 0:
+		mt.eventsAvailability = EventsUnavailable
+	default:
+		mt.eventsAvailability = EventsAvailable
+	}
+
+	if debug() {
+		fmt.Printf("multitrack feature detection: events %s in namespace %q (err: %v)\n", mt.eventsAvailability, namespace, err)
+	}
+
+	if mt.eventsAvailability == EventsUnavailable {
+		mt.displayMultitrackServiceMessageF("No events found in namespace %q: %s (short event TTL or event recording disabled), event messages will not be shown\n", namespace, eventsUnavailableMessage)
+	}
+}
+
+// setEventsReceived marks the events available once an event of the tracked resources is received.
+func (mt *multitracker) setEventsReceived() {
+	if mt.eventsAvailability == EventsUnavailable && debug() {
+		fmt.Printf("multitrack feature detection: events Available after event received\n")
+	}
+	mt.eventsAvailability = EventsAvailable
+}
+
+func (mt *multitracker) isEventsUnavailable() bool {
+	return mt.eventsAvailability == EventsUnavailable
+}
@@ -0,0 +1,74 @@
+package multitrack
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/werf/kubedog/pkg/tracker"
+)
+
+// handleExpectedFailureReadyCondition fails the resource expected to be rejected, because it became ready.
+func (mt *multitracker) handleExpectedFailureReadyCondition(resourcesStates map[string]*multitrackerResourceState, kind string, spec MultitrackSpec) error {
+	reason := fmt.Sprintf("expected %s to be rejected but it became ready", mt.resourceID(kind, spec))
+
+	mt.displayResourceErrorF(kind, spec, "%s", reason)
+
+	resourcesStates[resourceKey(spec)].Status = ResourceFailed
+	resourcesStates[resourceKey(spec)].FailedReason = reason
+
+	return ErrFailWholeDeployProcessImmediately
+}
+
+// handleExpectedFailure meets the expectation of the resource expected to be rejected,
+// unless the failure reason does not match ExpectedFailureReasonRegex.
+func (mt *multitracker) handleExpectedFailure(resourcesStates map[string]*multitrackerResourceState, kind string, spec MultitrackSpec, reason string) error {
+	if spec.ExpectedFailureReasonRegex != nil && !spec.ExpectedFailureReasonRegex.MatchString(reason) {
+		unexpectedReason := fmt.Sprintf("expected %s failure reason to match %q, got: %s", mt.resourceID(kind, spec), spec.ExpectedFailureReasonRegex.String(), reason)
+
+		mt.displayResourceErrorF(kind, spec, "%s", unexpectedReason)
+
+		resourcesStates[resourceKey(spec)].Status = ResourceFailed
+		resourcesStates[resourceKey(spec)].FailedReason = unexpectedReason
+
+		return ErrFailWholeDeployProcessImmediately
+	}
+
+	mt.displayResourceTrackerMessageF(kind, spec, "rejected as expected: %s", reason)
+
+	resourcesStates[resourceKey(spec)].Status = ResourceSucceeded
+	resourcesStates[resourceKey(spec)].FailedReason = reason
+	mt.checkReadyGate()
+
+	return tracker.StopTrack
+}
+
+// runExpectedFailureWindow meets the expectation of the resource expected to be rejected
+// when it has not become ready within WithinSeconds, the resource tracker is stopped then.
+func (mt *multitracker) runExpectedFailureWindow(kind string, spec MultitrackSpec, mtCtx *multitrackerContext) {
+	defer mt.recoverTrackerPanic(kind, spec)
+
+	timer := time.NewTimer(time.Duration(spec.WithinSeconds) * time.Second)
+	defer timer.Stop()
+
+	select {
+	case <-mtCtx.Context.Done():
+		return
+	case <-timer.C:
+	}
+
+	mt.mux.Lock()
+	defer mt.mux.Unlock()
+	defer mt.emitResourceStatusEvent(kind, spec)
+
+	state := mt.resourcesStatesByKind(kind)[resourceKey(spec)]
+	if state.Status == ResourceSucceeded || state.Status == ResourceFailed {
+		return
+	}
+
+	mt.displayResourceTrackerMessageF(kind, spec, "has not become ready within %ds as expected", spec.WithinSeconds)
+
+	state.Status = ResourceSucceeded
+	mt.checkReadyGate()
+
+	mtCtx.CancelFunc()
+}
@@ -0,0 +1,138 @@
+package multitrack
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/werf/kubedog/pkg/utils"
+)
+
+const expectedRolloutCheckTimeout = 5 * time.Second
+
+// checkExpectedRollout is called when the resource becomes ready: if it has never been observed not ready and its latest
+// revision was created before ExpectRolloutAfter, the expected change has not caused a rollout. This is a warning,
+// or a failure which can not be fixed by retries when ExpectRolloutStrict is set.
+func (mt *multitracker) checkExpectedRollout(resourcesStates map[string]*multitrackerResourceState, kind string, spec MultitrackSpec) error {
+	if spec.ExpectRolloutAfter.IsZero() || mt.kube == nil || resourcesStates[resourceKey(spec)].ObservedNotReady {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), expectedRolloutCheckTimeout)
+	defer cancel()
+
+	revisionTime, err := getLatestRevisionTime(ctx, mt.kube, kind, spec.Namespace, spec.ResourceName)
+	if err != nil {
+		mt.displayResourceTrackerMessageF(kind, spec, "unable to check the expected rollout: %s", err)
+		return nil
+	}
+
+	if !revisionTime.Before(spec.ExpectRolloutAfter) {
+		return nil
+	}
+
+	msg := fmt.Sprintf("no new rollout was observed after the expected change: latest revision created at %s, expected after %s", revisionTime.UTC().Format(time.RFC3339), spec.ExpectRolloutAfter.UTC().Format(time.RFC3339))
+
+	if !spec.ExpectRolloutStrict {
+		mt.displayResourceTrackerMessageF(kind, spec, "%s", msg)
+		mt.displayMultitrackErrorMessageF("%s WARNING: %s\n", mt.resourceID(kind, spec), msg)
+		return nil
+	}
+
+	mt.displayResourceErrorF(kind, spec, "%s", msg)
+
+	state := resourcesStates[resourceKey(spec)]
+	res := state.HandleNonRetryableFailure(msg)
+	mt.runResourceFailedHooks(kind, spec, msg)
+
+	if res.Decision == FailureIgnored {
+		mt.displayMultitrackServiceMessageF("%d errors occurred for %s\n", state.FailuresCount, mt.resourceID(kind, spec))
+		return nil
+	}
+
+	mt.displayMultitrackServiceMessageF("No rollout of %s observed: stop tracking immediately!\n", mt.resourceID(kind, spec))
+
+	return ErrFailWholeDeployProcessImmediately
+}
+
+// getLatestRevisionTime returns the creation time of the new ReplicaSet of Deployment, of the update revision of StatefulSet,
+// of the latest revision of DaemonSet or of ReplicaSet itself.
+func getLatestRevisionTime(ctx context.Context, kube kubernetes.Interface, kind, namespace, name string) (time.Time, error) {
+	switch kind {
+	case "deploy":
+		object, err := kube.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		_, _, newRs, err := utils.GetAllReplicaSets(ctx, object, kube)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if newRs == nil {
+			return time.Time{}, fmt.Errorf("new replicaset not found")
+		}
+
+		return newRs.CreationTimestamp.Time, nil
+
+	case "rs":
+		object, err := kube.AppsV1().ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		return object.CreationTimestamp.Time, nil
+
+	case "sts":
+		object, err := kube.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return time.Time{}, err
+		}
+		if object.Status.UpdateRevision == "" {
+			return time.Time{}, fmt.Errorf("update revision is not set")
+		}
+
+		revision, err := kube.AppsV1().ControllerRevisions(namespace).Get(ctx, object.Status.UpdateRevision, metav1.GetOptions{})
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		return revision.CreationTimestamp.Time, nil
+
+	case "ds":
+		object, err := kube.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		selector, err := metav1.LabelSelectorAsSelector(object.Spec.Selector)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		revisions, err := kube.AppsV1().ControllerRevisions(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		var latestRevision int64
+		var res time.Time
+		for _, revision := range revisions.Items {
+			if metav1.IsControlledBy(&revision, object) && revision.Revision > latestRevision {
+				latestRevision = revision.Revision
+				res = revision.CreationTimestamp.Time
+			}
+		}
+		if latestRevision == 0 {
+			return time.Time{}, fmt.Errorf("no controller revisions found")
+		}
+
+		return res, nil
+
+	default:
+		return time.Time{}, fmt.Errorf("expected rollout check is not supported for %s", kind)
+	}
+}
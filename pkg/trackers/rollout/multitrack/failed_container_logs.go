@@ -0,0 +1,102 @@
+package multitrack
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/werf/kubedog/pkg/tracker"
+)
+
+const (
+	defaultFailedContainerLogLines = 50
+	failedContainerLogsTimeout     = 5 * time.Second
+)
+
+// FailedContainerLogs are the last lines of the log of the container, which error failed the resource.
+type FailedContainerLogs struct {
+	PodName       string `json:"pod"`
+	ContainerName string `json:"container"`
+	// Previous is set when the log of the previous instance of the restarted container is captured,
+	// e.g. the current one is waiting in CrashLoopBackOff.
+	Previous bool     `json:"previous,omitempty"`
+	Lines    []string `json:"lines"`
+}
+
+func (l *FailedContainerLogs) header(namespace string) string {
+	instance := ""
+	if l.Previous {
+		instance = " (previous instance)"
+	}
+	return fmt.Sprintf("last %d lines of %s container/%s logs%s:", len(l.Lines), tracker.FormatResourceID("po", namespace, l.PodName), l.ContainerName, instance)
+}
+
+// rememberFailingContainer remembers the container of the last Pod error of the resource, its logs are captured when the resource fails.
+func (mt *multitracker) rememberFailingContainer(kind string, spec MultitrackSpec, podName, containerName string) {
+	if containerName == "" {
+		return
+	}
+
+	state := mt.resourcesStatesByKind(kind)[resourceKey(spec)]
+	state.FailingPodName, state.FailingContainerName = podName, containerName
+}
+
+// captureFailedContainerLogs fetches the last FailedContainerLogLines lines of the log of the failing container of the resource
+// once the resource is considered failed. The request is bounded by failedContainerLogsTimeout, errors are only shown as service messages.
+func (mt *multitracker) captureFailedContainerLogs(kind string, spec MultitrackSpec) {
+	state := mt.resourcesStatesByKind(kind)[resourceKey(spec)]
+	if mt.failedContainerLogLines <= 0 || state.FailedContainerLogs != nil || state.FailingContainerName == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), failedContainerLogsTimeout)
+	defer cancel()
+
+	logs := &FailedContainerLogs{PodName: state.FailingPodName, ContainerName: state.FailingContainerName}
+
+	lines, err := mt.fetchContainerLogTail(ctx, spec.Namespace, logs.PodName, logs.ContainerName, false)
+	if err != nil || len(lines) == 0 {
+		// The container terminated before its log could be read, e.g. it is waiting to be restarted
+		logs.Previous = true
+		lines, err = mt.fetchContainerLogTail(ctx, spec.Namespace, logs.PodName, logs.ContainerName, true)
+	}
+	if err != nil {
+		mt.displayResourceTrackerMessageF(kind, spec, "unable to capture logs of %s container/%s: %s", tracker.FormatResourceID("po", spec.Namespace, logs.PodName), logs.ContainerName, err)
+		return
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	logs.Lines = lines
+	state.FailedContainerLogs = logs
+}
+
+func (mt *multitracker) fetchContainerLogTail(ctx context.Context, namespace, podName, containerName string, previous bool) ([]string, error) {
+	tailLines := int64(mt.failedContainerLogLines)
+
+	readCloser, err := mt.kube.CoreV1().Pods(namespace).GetLogs(podName, &corev1.PodLogOptions{
+		Container: containerName,
+		TailLines: &tailLines,
+		Previous:  previous,
+	}).Stream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer readCloser.Close()
+
+	data, err := ioutil.ReadAll(readCloser)
+	if err != nil {
+		return nil, err
+	}
+
+	text := strings.TrimRight(string(data), "\n")
+	if text == "" {
+		return nil, nil
+	}
+	return strings.Split(text, "\n"), nil
+}
@@ -0,0 +1,274 @@
+package multitrack
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ResourceStatus is the tracking status of the resource.
+type ResourceStatus string
+
+const (
+	ResourceActive            ResourceStatus = "ResourceActive"
+	ResourceSucceeded         ResourceStatus = "ResourceSucceeded"
+	ResourceFailed            ResourceStatus = "ResourceFailed"
+	ResourceHoping            ResourceStatus = "ResourceHoping"
+	ResourceActiveAfterHoping ResourceStatus = "ResourceActiveAfterHoping"
+	// ResourceSkipped means the resource is not tracked because its dependency failed, see DependsOn.
+	ResourceSkipped ResourceStatus = "ResourceSkipped"
+)
+
+// FailureDecision is the outcome of a single failure handled by FailuresStateMachine.
+type FailureDecision string
+
+const (
+	// FailureAllowed means the failure is counted within AllowFailuresCount and tracking continues.
+	FailureAllowed FailureDecision = "Allowed"
+	// FailurePostponed means the failure is not counted until other resources are ready (HopeUntilEndOfDeployProcess).
+	FailurePostponed FailureDecision = "Postponed"
+	// FailureIgnored means the failure is counted, but never fails the resource (IgnoreAndContinueDeployProcess).
+	FailureIgnored FailureDecision = "Ignored"
+	// FailureFatal means allowed failures count is exceeded: the resource is failed and the whole process should fail.
+	FailureFatal FailureDecision = "Fatal"
+)
+
+type FailureResult struct {
+	Decision FailureDecision
+	// ActiveResources are the resources HopeUntilEndOfDeployProcess mode waits for, set for FailurePostponed decision.
+	ActiveResources []string
+}
+
+// FailuresStateMachine implements FailMode policy of a single resource.
+// It does not depend on Kubernetes clients and output, the caller is responsible for reporting decisions.
+type FailuresStateMachine struct {
+	FailMode FailMode
+	// AllowFailuresCount is the number of counted failures tolerated, the next one is fatal.
+	AllowFailuresCount int
+
+	Status        ResourceStatus
+	FailedReason  string
+	FailuresCount int
+	// HopedReason is the reason of the last failure postponed in HopeUntilEndOfDeployProcess mode,
+	// it is cleared when the resource recovers.
+	HopedReason string
+}
+
+func NewFailuresStateMachine(failMode FailMode, allowFailuresCount int) FailuresStateMachine {
+	return FailuresStateMachine{
+		FailMode:           failMode,
+		AllowFailuresCount: allowFailuresCount,
+		Status:             ResourceActive,
+	}
+}
+
+// HandleFailure registers the failure with the specified reason. getActiveResources should return the resources
+// which are still in ResourceActive status, it is called only in HopeUntilEndOfDeployProcess mode.
+func (m *FailuresStateMachine) HandleFailure(reason string, getActiveResources func() []string) FailureResult {
+	switch m.FailMode {
+	case FailWholeDeployProcessImmediately:
+		return m.countFailure(reason)
+
+	case HopeUntilEndOfDeployProcess:
+		for {
+			switch m.Status {
+			case ResourceActive:
+				m.Status = ResourceHoping
+
+			case ResourceHoping:
+				if activeResources := getActiveResources(); len(activeResources) > 0 {
+					m.HopedReason = reason
+					return FailureResult{Decision: FailurePostponed, ActiveResources: activeResources}
+				}
+				m.Status = ResourceActiveAfterHoping
+
+			case ResourceActiveAfterHoping:
+				return m.countFailure(reason)
+
+			default:
+				panic(fmt.Sprintf("unexpected resource status %#v", m.Status))
+			}
+		}
+
+	case IgnoreAndContinueDeployProcess:
+		m.FailuresCount++
+		m.FailedReason = reason
+		return FailureResult{Decision: FailureIgnored}
+
+	default:
+		panic(fmt.Sprintf("bad fail mode %#v", m.FailMode))
+	}
+}
+
+// HandleNonRetryableFailure registers the failure which will not go away by itself: allowed failures count
+// and HopeUntilEndOfDeployProcess mode do not apply, only IgnoreAndContinueDeployProcess mode ignores such a failure.
+func (m *FailuresStateMachine) HandleNonRetryableFailure(reason string) FailureResult {
+	m.FailuresCount++
+	m.FailedReason = reason
+
+	if m.FailMode == IgnoreAndContinueDeployProcess {
+		return FailureResult{Decision: FailureIgnored}
+	}
+
+	m.Status = ResourceFailed
+
+	return FailureResult{Decision: FailureFatal}
+}
+
+// Recover forgets the postponed failure of the resource which has become healthy again, so that the resource
+// is active as if it has not failed: its next failure is postponed again while other resources are active.
+func (m *FailuresStateMachine) Recover() {
+	if m.Status == ResourceHoping || m.Status == ResourceActiveAfterHoping {
+		m.Status = ResourceActive
+	}
+	m.HopedReason = ""
+}
+
+// HandleHopeExpired fails the resource which is still unhealthy with the postponed failure when no other resource is active,
+// the postponed failure is counted as fatal regardless of the allowed failures count.
+func (m *FailuresStateMachine) HandleHopeExpired() FailureResult {
+	m.FailuresCount++
+	m.Status = ResourceFailed
+	m.FailedReason = m.HopedReason
+
+	return FailureResult{Decision: FailureFatal}
+}
+
+func (m *FailuresStateMachine) countFailure(reason string) FailureResult {
+	m.FailuresCount++
+
+	if m.FailuresCount <= m.AllowFailuresCount {
+		return FailureResult{Decision: FailureAllowed}
+	}
+
+	m.Status = ResourceFailed
+	m.FailedReason = reason
+
+	return FailureResult{Decision: FailureFatal}
+}
+
+// startupGracePeriodRemaining returns the remaining time of the startup grace period, 0 when it is over.
+func (mt *multitracker) startupGracePeriodRemaining() time.Duration {
+	remaining := mt.startupGracePeriod - time.Since(mt.startedAt)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// checkFailureThreshold returns true when errors of the resource have persisted for less than FailureThresholdSeconds
+// since the first not counted failure. Expiration of TrackTimeoutSeconds is not an error state and is always counted.
+func checkFailureThreshold(state *multitrackerResourceState, spec MultitrackSpec, reason string) (time.Duration, time.Duration, bool) {
+	if spec.FailureThresholdSeconds == nil || *spec.FailureThresholdSeconds <= 0 || strings.HasPrefix(reason, trackTimeoutExpiredReason) {
+		return 0, 0, false
+	}
+
+	threshold := time.Duration(*spec.FailureThresholdSeconds) * time.Second
+
+	if state.FailingSince.IsZero() {
+		state.FailingSince = time.Now()
+	}
+
+	persisted := time.Since(state.FailingSince)
+	return persisted, threshold, persisted < threshold
+}
+
+// observeFailureRecovery resets FailingSince once the errors seen in the status of the resource are gone,
+// so that the failure after the recovery is given the whole FailureThresholdSeconds again.
+// It must be called under handlers mutex on each status of the resource.
+func (mt *multitracker) observeFailureRecovery(kind string, spec MultitrackSpec) {
+	state := mt.resourcesStatesByKind(kind)[resourceKey(spec)]
+	if state == nil || state.FailingSince.IsZero() {
+		return
+	}
+
+	if mt.isResourceUnhealthy(kind, spec) {
+		state.IsFailingObserved = true
+		return
+	}
+
+	// Failures not reflected in the status (e.g. of events) persist until the resource is ready
+	if state.IsFailingObserved {
+		state.resetFailingSince()
+	}
+}
+
+func (state *multitrackerResourceState) resetFailingSince() {
+	state.FailingSince = time.Time{}
+	state.IsFailingObserved = false
+}
+
+func (mt *multitracker) handleResourceFailure(resourcesStates map[string]*multitrackerResourceState, kind string, spec MultitrackSpec, reason string) error {
+	if spec.ExpectFailure {
+		return mt.handleExpectedFailure(resourcesStates, kind, spec, reason)
+	}
+
+	state := resourcesStates[resourceKey(spec)]
+	if !state.LogCaptureUntil.IsZero() {
+		// The failure is already decided, tracking is stopped once the capture window is over
+		return nil
+	}
+
+	if state.IsShowingLogsUntilEndOfDeploy {
+		// The resource is already ready, its tracker only streams the logs
+		return nil
+	}
+
+	mt.observeInfrastructureFailure(kind, spec, reason)
+
+	if explained, isNonRetryable := ExplainNonRetryableFailure(reason); isNonRetryable {
+		mt.displayResourceErrorF(kind, spec, "%s", explained)
+
+		res := state.HandleNonRetryableFailure(explained)
+		mt.captureFailedContainerLogs(kind, spec)
+		mt.runResourceFailedHooks(kind, spec, explained)
+
+		if res.Decision == FailureIgnored {
+			mt.displayMultitrackServiceMessageF("%d errors occurred for %s\n", state.FailuresCount, mt.resourceID(kind, spec))
+			return nil
+		}
+
+		mt.displayMultitrackServiceMessageF("Non-retryable error occurred for %s: stop tracking immediately!\n", mt.resourceID(kind, spec))
+		return mt.failResourceImmediately(kind, spec)
+	}
+
+	if backPressure, isBackPressure := mt.checkQuotaBackPressure(spec, reason); isBackPressure {
+		mt.displayMultitrackServiceMessageF("Error occurred for %s is not counted: %s\n", mt.resourceID(kind, spec), backPressure)
+		return nil
+	}
+
+	if remaining := mt.startupGracePeriodRemaining(); remaining > 0 {
+		mt.displayMultitrackServiceMessageF("Error occurred for %s is not counted: startup grace period %s remaining\n", mt.resourceID(kind, spec), remaining.Truncate(time.Second))
+		return nil
+	}
+
+	if persisted, threshold, isBelowThreshold := checkFailureThreshold(state, spec, reason); isBelowThreshold {
+		mt.displayMultitrackServiceMessageF("Error occurred for %s is not counted: errors persist for %s of failure threshold %s\n", mt.resourceID(kind, spec), persisted.Truncate(time.Second), threshold)
+		return nil
+	}
+
+	mt.updateAllowFailuresCount(state, kind, spec)
+	res := state.HandleFailure(reason, mt.getActiveResourcesNames)
+
+	switch res.Decision {
+	case FailureAllowed:
+		mt.displayMultitrackServiceMessageF("%d/%d allowed errors occurred for %s: continue tracking\n", state.FailuresCount, state.AllowFailuresCount, mt.resourceID(kind, spec))
+		return nil
+
+	case FailurePostponed:
+		mt.displayMultitrackServiceMessageF("Error occurred for %s, waiting until following resources are ready before counting errors (HopeUntilEndOfDeployProcess fail mode is active): %s\n", mt.resourceID(kind, spec), strings.Join(res.ActiveResources, ", "))
+		return nil
+
+	case FailureIgnored:
+		mt.displayMultitrackServiceMessageF("%d errors occurred for %s\n", state.FailuresCount, mt.resourceID(kind, spec))
+		mt.captureFailedContainerLogs(kind, spec)
+		mt.runResourceFailedHooks(kind, spec, reason)
+		return nil
+
+	default:
+		mt.displayMultitrackServiceMessageF("Allowed failures count for %s exceeded %d errors: stop tracking immediately!\n", mt.resourceID(kind, spec), state.AllowFailuresCount)
+		mt.captureFailedContainerLogs(kind, spec)
+		mt.runResourceFailedHooks(kind, spec, reason)
+		return mt.failResourceImmediately(kind, spec)
+	}
+}
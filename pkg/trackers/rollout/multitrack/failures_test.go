@@ -0,0 +1,403 @@
+package multitrack
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestMultitrackAllowFailuresCount fails the Pod reporting its failure on each status update: the failures within
+// AllowFailuresCount are tolerated, the next one fails the resource, which is listed in the returned error.
+func TestMultitrackAllowFailuresCount(t *testing.T) {
+	for _, allowFailuresCount := range []int{0, 1, 5} {
+		t.Run(fmt.Sprintf("%d", allowFailuresCount), func(t *testing.T) {
+			allowFailuresCount := allowFailuresCount
+
+			kube := fake.NewSimpleClientset()
+			pods, specs := createTestPods(t, kube, 2)
+			specs[0].AllowFailuresCount = &allowFailuresCount
+
+			stopUpdates := runTestPodsUpdates(t, kube, pods, func(i, step int, pod *corev1.Pod) *corev1.Pod {
+				return pod
+			}, func(i int, pod *corev1.Pod) *corev1.Pod {
+				if i == 0 {
+					return setTestPodFailed(pod)
+				}
+				return setTestPodReady(pod)
+			})
+
+			result, out, err := runTestMultitrack(t, kube, MultitrackSpecs{Pods: specs}, MultitrackOptions{})
+			stopUpdates()
+
+			var failedErr *FailedResourcesError
+			if !errors.As(err, &failedErr) {
+				t.Fatalf("expected *FailedResourcesError, got %v\n%s", err, out)
+			}
+			if len(failedErr.Failures) != 1 || failedErr.Failures[0].ID() != "po/ns-0/app-0" || failedErr.Failures[0].Reason != "Error" {
+				t.Errorf("expected po/ns-0/app-0 failed with Error reason, got %+v", failedErr.Failures)
+			}
+
+			resource := findTestResourceResult(result, "po", "app-0")
+			if resource == nil {
+				t.Fatalf("no result of Pod app-0")
+			}
+			if resource.Outcome != ResourceOutcomeFailed {
+				t.Errorf("expected Failed outcome, got %s", resource.Outcome)
+			}
+			if resource.FailuresCount != allowFailuresCount+1 {
+				t.Errorf("expected the resource to fail on %d failure, got %d failures", allowFailuresCount+1, resource.FailuresCount)
+			}
+		})
+	}
+}
+
+var testFailModes = []FailMode{FailWholeDeployProcessImmediately, HopeUntilEndOfDeployProcess, IgnoreAndContinueDeployProcess}
+
+var testAllowFailuresCounts = []int{0, 1, 3}
+
+func repeatDecision(decision FailureDecision, count int) []FailureDecision {
+	var res []FailureDecision
+	for i := 0; i < count; i++ {
+		res = append(res, decision)
+	}
+	return res
+}
+
+func activeResources(names ...string) func() []string {
+	return func() []string { return names }
+}
+
+func TestFailuresStateMachineHandleFailure(t *testing.T) {
+	for _, failMode := range testFailModes {
+		for _, allowFailuresCount := range testAllowFailuresCounts {
+			for _, hasActiveResources := range []bool{false, true} {
+				// Failures are handled until the fatal one and once more for the modes which never fail the resource
+				failuresCount := allowFailuresCount + 2
+
+				var expectedDecisions []FailureDecision
+				var expectedStatus ResourceStatus
+				var expectedFailuresCount int
+				switch {
+				case failMode == IgnoreAndContinueDeployProcess:
+					expectedDecisions = repeatDecision(FailureIgnored, failuresCount)
+					expectedStatus, expectedFailuresCount = ResourceActive, failuresCount
+				case failMode == HopeUntilEndOfDeployProcess && hasActiveResources:
+					expectedDecisions = repeatDecision(FailurePostponed, failuresCount)
+					expectedStatus, expectedFailuresCount = ResourceHoping, 0
+				default:
+					failuresCount = allowFailuresCount + 1
+					expectedDecisions = append(repeatDecision(FailureAllowed, allowFailuresCount), FailureFatal)
+					expectedStatus, expectedFailuresCount = ResourceFailed, failuresCount
+				}
+
+				name := fmt.Sprintf("%s/allow=%d/active=%v", failMode, allowFailuresCount, hasActiveResources)
+				t.Run(name, func(t *testing.T) {
+					getActiveResources := activeResources()
+					if hasActiveResources {
+						getActiveResources = activeResources("deploy/default/app")
+					}
+
+					m := NewFailuresStateMachine(failMode, allowFailuresCount)
+
+					var decisions []FailureDecision
+					for i := 0; i < failuresCount; i++ {
+						res := m.HandleFailure(fmt.Sprintf("error %d", i), getActiveResources)
+						decisions = append(decisions, res.Decision)
+
+						if res.Decision == FailurePostponed && (len(res.ActiveResources) != 1 || res.ActiveResources[0] != "deploy/default/app") {
+							t.Errorf("expected postponed failure to list active resources, got %v", res.ActiveResources)
+						}
+					}
+
+					if fmt.Sprint(decisions) != fmt.Sprint(expectedDecisions) {
+						t.Errorf("expected decisions %v, got %v", expectedDecisions, decisions)
+					}
+					if m.Status != expectedStatus {
+						t.Errorf("expected status %s, got %s", expectedStatus, m.Status)
+					}
+					if m.FailuresCount != expectedFailuresCount {
+						t.Errorf("expected failures count %d, got %d", expectedFailuresCount, m.FailuresCount)
+					}
+
+					lastReason := fmt.Sprintf("error %d", failuresCount-1)
+					switch {
+					case expectedStatus == ResourceHoping:
+						if m.HopedReason != lastReason || m.FailedReason != "" {
+							t.Errorf("expected hoped reason %q and no failed reason, got %q and %q", lastReason, m.HopedReason, m.FailedReason)
+						}
+					case m.FailedReason != lastReason:
+						t.Errorf("expected failed reason %q, got %q", lastReason, m.FailedReason)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestFailuresStateMachineHandleNonRetryableFailure(t *testing.T) {
+	for _, failMode := range testFailModes {
+		for _, allowFailuresCount := range testAllowFailuresCounts {
+			t.Run(fmt.Sprintf("%s/allow=%d", failMode, allowFailuresCount), func(t *testing.T) {
+				m := NewFailuresStateMachine(failMode, allowFailuresCount)
+
+				res := m.HandleNonRetryableFailure("ErrImagePull")
+
+				expectedDecision, expectedStatus := FailureFatal, ResourceFailed
+				if failMode == IgnoreAndContinueDeployProcess {
+					expectedDecision, expectedStatus = FailureIgnored, ResourceActive
+				}
+
+				// Allowed failures count and HopeUntilEndOfDeployProcess mode do not apply
+				if res.Decision != expectedDecision {
+					t.Errorf("expected decision %s, got %s", expectedDecision, res.Decision)
+				}
+				if m.Status != expectedStatus {
+					t.Errorf("expected status %s, got %s", expectedStatus, m.Status)
+				}
+				if m.FailuresCount != 1 || m.FailedReason != "ErrImagePull" {
+					t.Errorf("expected 1 failure with ErrImagePull reason, got %d with %q", m.FailuresCount, m.FailedReason)
+				}
+			})
+		}
+	}
+}
+
+func TestFailuresStateMachineRecover(t *testing.T) {
+	for _, failMode := range testFailModes {
+		for _, allowFailuresCount := range testAllowFailuresCounts {
+			t.Run(fmt.Sprintf("%s/allow=%d", failMode, allowFailuresCount), func(t *testing.T) {
+				m := NewFailuresStateMachine(failMode, allowFailuresCount)
+
+				first := m.HandleFailure("error 1", activeResources("deploy/default/app"))
+				statusBeforeRecover, failuresCountBeforeRecover := m.Status, m.FailuresCount
+
+				m.Recover()
+
+				if m.HopedReason != "" {
+					t.Errorf("expected hoped reason to be forgotten, got %q", m.HopedReason)
+				}
+				// Counted failures are not forgotten
+				if m.FailuresCount != failuresCountBeforeRecover {
+					t.Errorf("expected failures count %d, got %d", failuresCountBeforeRecover, m.FailuresCount)
+				}
+
+				expectedStatus := statusBeforeRecover
+				if failMode == HopeUntilEndOfDeployProcess {
+					expectedStatus = ResourceActive
+				}
+				if m.Status != expectedStatus {
+					t.Errorf("expected status %s, got %s", expectedStatus, m.Status)
+				}
+
+				// The recovered resource handles the next failure as the first one
+				if failMode == HopeUntilEndOfDeployProcess {
+					if second := m.HandleFailure("error 2", activeResources("deploy/default/app")); second.Decision != first.Decision {
+						t.Errorf("expected decision %s after recover, got %s", first.Decision, second.Decision)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestFailuresStateMachineRecoverAfterHoping(t *testing.T) {
+	for _, allowFailuresCount := range []int{1, 3} {
+		t.Run(fmt.Sprintf("allow=%d", allowFailuresCount), func(t *testing.T) {
+			m := NewFailuresStateMachine(HopeUntilEndOfDeployProcess, allowFailuresCount)
+
+			// No other resources are active, the failure is counted
+			if res := m.HandleFailure("error 1", activeResources()); res.Decision != FailureAllowed {
+				t.Fatalf("expected decision %s, got %s", FailureAllowed, res.Decision)
+			}
+			if m.Status != ResourceActiveAfterHoping {
+				t.Fatalf("expected status %s, got %s", ResourceActiveAfterHoping, m.Status)
+			}
+
+			m.Recover()
+
+			if m.Status != ResourceActive {
+				t.Errorf("expected status %s, got %s", ResourceActive, m.Status)
+			}
+			if res := m.HandleFailure("error 2", activeResources("deploy/default/app")); res.Decision != FailurePostponed {
+				t.Errorf("expected decision %s after recover, got %s", FailurePostponed, res.Decision)
+			}
+		})
+	}
+}
+
+func TestFailuresStateMachineHandleHopeExpired(t *testing.T) {
+	for _, allowFailuresCount := range testAllowFailuresCounts {
+		t.Run(fmt.Sprintf("allow=%d", allowFailuresCount), func(t *testing.T) {
+			m := NewFailuresStateMachine(HopeUntilEndOfDeployProcess, allowFailuresCount)
+
+			m.HandleFailure("error 1", activeResources("deploy/default/app"))
+			m.HandleFailure("error 2", activeResources("deploy/default/app"))
+
+			// The postponed failure is fatal regardless of the allowed failures count
+			res := m.HandleHopeExpired()
+
+			if res.Decision != FailureFatal {
+				t.Errorf("expected decision %s, got %s", FailureFatal, res.Decision)
+			}
+			if m.Status != ResourceFailed {
+				t.Errorf("expected status %s, got %s", ResourceFailed, m.Status)
+			}
+			if m.FailuresCount != 1 || m.FailedReason != "error 2" {
+				t.Errorf("expected 1 failure with the last hoped reason, got %d with %q", m.FailuresCount, m.FailedReason)
+			}
+		})
+	}
+}
+
+func TestCheckFailureThreshold(t *testing.T) {
+	for _, tc := range []struct {
+		name                    string
+		failureThresholdSeconds *int
+		failingFor              time.Duration
+		reason                  string
+		expectedBelowThreshold  bool
+	}{
+		{name: "not set", reason: "Error"},
+		{name: "zero", failureThresholdSeconds: intPtr(0), reason: "Error"},
+		{name: "negative", failureThresholdSeconds: intPtr(-1), reason: "Error"},
+		{name: "first error", failureThresholdSeconds: intPtr(60), reason: "Error", expectedBelowThreshold: true},
+		{name: "persisting error", failureThresholdSeconds: intPtr(60), failingFor: 30 * time.Second, reason: "Error", expectedBelowThreshold: true},
+		{name: "persisted error", failureThresholdSeconds: intPtr(60), failingFor: time.Minute, reason: "Error"},
+		{name: "track timeout", failureThresholdSeconds: intPtr(60), reason: trackTimeoutExpiredReason + " after 10s"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			state := &multitrackerResourceState{}
+			if tc.failingFor > 0 {
+				state.FailingSince = time.Now().Add(-tc.failingFor)
+			}
+			spec := MultitrackSpec{ResourceName: "app", Namespace: "default", FailureThresholdSeconds: tc.failureThresholdSeconds}
+
+			_, _, isBelowThreshold := checkFailureThreshold(state, spec, tc.reason)
+			if isBelowThreshold != tc.expectedBelowThreshold {
+				t.Errorf("expected below threshold %v, got %v", tc.expectedBelowThreshold, isBelowThreshold)
+			}
+			if tc.expectedBelowThreshold && state.FailingSince.IsZero() {
+				t.Errorf("expected the time of the first error to be kept")
+			}
+		})
+	}
+}
+
+// TestMultitrackFailureThresholdSeconds fails the Pod only when its errors persist for FailureThresholdSeconds,
+// the transient errors of the Pod which becomes ready are not counted.
+func TestMultitrackFailureThresholdSeconds(t *testing.T) {
+	t.Run("transient errors", func(t *testing.T) {
+		kube := fake.NewSimpleClientset()
+		pods, specs := createTestPods(t, kube, 1)
+		specs[0].AllowFailuresCount = intPtr(0)
+		specs[0].FailureThresholdSeconds = intPtr(3600)
+
+		// The Pod becomes ready once its error is received
+		errorsChan := make(chan struct{}, 1)
+		hooks := &MultitrackHooks{OnPodError: func(kind, namespace, name, podName, containerName, message string) error {
+			select {
+			case errorsChan <- struct{}{}:
+			default:
+			}
+			return nil
+		}}
+		isErrorReceived := false
+
+		stopUpdates := runTestPodsUpdates(t, kube, pods, func(i, step int, pod *corev1.Pod) *corev1.Pod {
+			return setTestPodContainerWaiting(pod, "CrashLoopBackOff")
+		}, func(i int, pod *corev1.Pod) *corev1.Pod {
+			select {
+			case <-errorsChan:
+				isErrorReceived = true
+			default:
+			}
+			if !isErrorReceived {
+				return setTestPodContainerWaiting(pod, "CrashLoopBackOff")
+			}
+			return setTestPodReady(pod)
+		})
+
+		result, out, err := runTestMultitrack(t, kube, MultitrackSpecs{Pods: specs}, MultitrackOptions{Hooks: hooks})
+		stopUpdates()
+
+		if err != nil {
+			t.Fatalf("unexpected error: %s\n%s", err, out)
+		}
+		if !strings.Contains(out, "Error occurred for po/ns-0/app-0 is not counted: errors persist for") {
+			t.Errorf("expected not counted errors in the output:\n%s", out)
+		}
+		if resource := findTestResourceResult(result, "po", "app-0"); resource == nil || resource.FailuresCount != 0 {
+			t.Errorf("expected no failures counted, got %+v", resource)
+		}
+	})
+
+	t.Run("persisting errors", func(t *testing.T) {
+		kube := fake.NewSimpleClientset()
+		pods, specs := createTestPods(t, kube, 1)
+		specs[0].AllowFailuresCount = intPtr(0)
+		specs[0].FailureThresholdSeconds = intPtr(1)
+
+		stopUpdates := runTestPodsUpdates(t, kube, pods, func(i, step int, pod *corev1.Pod) *corev1.Pod {
+			return pod
+		}, func(i int, pod *corev1.Pod) *corev1.Pod {
+			return setTestPodFailed(pod)
+		})
+
+		startedAt := time.Now()
+		result, out, err := runTestMultitrack(t, kube, MultitrackSpecs{Pods: specs}, MultitrackOptions{})
+		stopUpdates()
+
+		if err == nil {
+			t.Fatalf("expected error, got nil\n%s", out)
+		}
+		if elapsed := time.Since(startedAt); elapsed < time.Second {
+			t.Errorf("expected the Pod to fail after the failure threshold 1s, failed after %s", elapsed)
+		}
+		if resource := findTestResourceResult(result, "po", "app-0"); resource == nil || resource.FailuresCount != 1 || resource.Outcome != ResourceOutcomeFailed {
+			t.Errorf("expected the Pod failed with 1 failure, got %+v", resource)
+		}
+	})
+}
+
+// TestMultitrackFailureThresholdAfterRecovery gives the whole FailureThresholdSeconds to the failure after the Pod
+// has recovered from the previous one: the Pod failing twice for less than the threshold becomes ready.
+func TestMultitrackFailureThresholdAfterRecovery(t *testing.T) {
+	kube := fake.NewSimpleClientset()
+	pods, specs := createTestPods(t, kube, 1)
+	specs[0].AllowFailuresCount = intPtr(0)
+	specs[0].FailureThresholdSeconds = intPtr(2)
+
+	// Crash-looping for 1.5s, recovered for 0.5s, crash-looping for 1s, then ready
+	startedAt := time.Now()
+	stopUpdates := runTestPodsUpdates(t, kube, pods, func(i, step int, pod *corev1.Pod) *corev1.Pod {
+		return setTestPodContainerWaiting(pod, "CrashLoopBackOff")
+	}, func(i int, pod *corev1.Pod) *corev1.Pod {
+		switch elapsed := time.Since(startedAt); {
+		case elapsed < 1500*time.Millisecond:
+			return setTestPodContainerWaiting(pod, "CrashLoopBackOff")
+		case elapsed < 2*time.Second:
+			return pod
+		case elapsed < 3*time.Second:
+			return setTestPodContainerWaiting(pod, "CrashLoopBackOff")
+		}
+		return setTestPodReady(pod)
+	})
+
+	result, out, err := runTestMultitrack(t, kube, MultitrackSpecs{Pods: specs}, MultitrackOptions{})
+	stopUpdates()
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s\n%s", err, out)
+	}
+	if elapsed := time.Since(startedAt); elapsed < 3*time.Second {
+		t.Errorf("expected the Pod to be ready after the second failure, ready after %s", elapsed)
+	}
+	if resource := findTestResourceResult(result, "po", "app-0"); resource == nil || resource.FailuresCount != 0 || resource.Outcome != ResourceOutcomeReady {
+		t.Errorf("expected the Pod ready with no failures counted, got %+v", resource)
+	}
+}
@@ -0,0 +1,271 @@
+package multitrack
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MultitrackHooks are called on resources lifecycle events, all callbacks are optional.
+// Hooks are called sequentially in the order of events by a dedicated goroutine, multitrack handlers mutex is not held
+// while user code runs, so hooks may block without stopping trackers. Pending hooks are called before Multitrack returns.
+// An error returned by a hook aborts the whole deploy process, Multitrack returns this error.
+// Hooks receive the kind, namespace and name of the resource, tracker.FormatResourceID formats its identifier.
+type MultitrackHooks struct {
+	// OnResourceReady receives the last status of the resource: pod.PodStatus, deployment.DeploymentStatus, replicaset.ReplicaSetStatus, generic.GenericStatus, etc.
+	OnResourceReady func(kind, namespace, name string, status interface{}) error
+	// OnResourceFailed is called when the resource is considered failed accordingly to its FailMode:
+	// allowed failures count is exceeded or the failure is ignored (IgnoreAndContinueDeployProcess).
+	OnResourceFailed func(kind, namespace, name, reason string) error
+	// OnPodError is called for each error of the container of the resource Pod.
+	OnPodError func(kind, namespace, name, podName, containerName, message string) error
+	// OnStatusReport is called on each status progress report.
+	// Only the hook of MultitrackOptions is called, the one of MultitrackSpec is ignored.
+	OnStatusReport func(snapshot MultitrackSnapshot) error
+}
+
+type MultitrackSnapshot struct {
+	Timestamp time.Time
+	Resources []ResourceStatusEvent
+}
+
+// hooksRunner calls queued hooks in its own goroutine, queueing never blocks, so that hooks can be queued under handlers mutex.
+type hooksRunner struct {
+	mux       sync.Mutex
+	queue     []hookCall
+	err       error
+	isStopped bool
+
+	wakeChan chan struct{}
+	// ErrChan receives the first error returned by a hook, later hooks are not called.
+	ErrChan chan error
+}
+
+type hookCall struct {
+	Call func() error
+	// IsBarrier call is made even after a hook failed.
+	IsBarrier bool
+}
+
+func newHooksRunner() *hooksRunner {
+	r := &hooksRunner{
+		wakeChan: make(chan struct{}, 1),
+		ErrChan:  make(chan error, 1),
+	}
+	go r.run()
+	return r
+}
+
+func (r *hooksRunner) enqueue(call func() error) {
+	r.enqueueCall(hookCall{Call: call})
+}
+
+func (r *hooksRunner) enqueueCall(call hookCall) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if r.isStopped {
+		return
+	}
+
+	r.queue = append(r.queue, call)
+
+	select {
+	case r.wakeChan <- struct{}{}:
+	default:
+	}
+}
+
+func (r *hooksRunner) run() {
+	for range r.wakeChan {
+		for {
+			r.mux.Lock()
+			if len(r.queue) == 0 {
+				r.mux.Unlock()
+				break
+			}
+			call := r.queue[0]
+			r.queue = r.queue[1:]
+			isFailed := r.err != nil
+			r.mux.Unlock()
+
+			if isFailed && !call.IsBarrier {
+				continue
+			}
+
+			if err := call.Call(); err != nil {
+				r.mux.Lock()
+				r.err = err
+				r.mux.Unlock()
+
+				r.ErrChan <- err
+			}
+		}
+	}
+}
+
+// stop waits until all queued hooks are called, hooks queued later are not called.
+// Returns the first error returned by a hook.
+func (r *hooksRunner) stop() error {
+	doneChan := make(chan struct{})
+	r.enqueueCall(hookCall{
+		Call: func() error {
+			close(doneChan)
+			return nil
+		},
+		IsBarrier: true,
+	})
+
+	r.mux.Lock()
+	isStopped := r.isStopped
+	r.mux.Unlock()
+
+	if !isStopped {
+		<-doneChan
+	}
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if !r.isStopped {
+		r.isStopped = true
+		close(r.wakeChan)
+	}
+
+	return r.err
+}
+
+func newMultitrackHooksRunner(specs MultitrackSpecs, opts MultitrackOptions) *hooksRunner {
+	if opts.Hooks != nil {
+		return newHooksRunner()
+	}
+
+	for _, kindSpecs := range [][]MultitrackSpec{specs.Pods, specs.Deployments, specs.ReplicaSets, specs.StatefulSets, specs.DaemonSets, specs.Jobs, specs.Generic} {
+		for _, spec := range kindSpecs {
+			if spec.Hooks != nil {
+				return newHooksRunner()
+			}
+		}
+	}
+
+	return nil
+}
+
+// resourceHooks returns hooks of the spec followed by hooks of the options.
+func (mt *multitracker) resourceHooks(spec MultitrackSpec) []*MultitrackHooks {
+	var res []*MultitrackHooks
+	for _, hooks := range []*MultitrackHooks{spec.Hooks, mt.hooks} {
+		if hooks != nil {
+			res = append(res, hooks)
+		}
+	}
+	return res
+}
+
+func (mt *multitracker) runResourceReadyHooks(kind string, spec MultitrackSpec) {
+	if mt.hooksRunner == nil {
+		return
+	}
+
+	status := mt.resourceStatus(kind, resourceKey(spec))
+	for _, hooks := range mt.resourceHooks(spec) {
+		if hook := hooks.OnResourceReady; hook != nil {
+			mt.hooksRunner.enqueue(func() error {
+				return wrapHookError("OnResourceReady", hook(kind, spec.Namespace, spec.ResourceName, status))
+			})
+		}
+	}
+}
+
+func (mt *multitracker) runResourceFailedHooks(kind string, spec MultitrackSpec, reason string) {
+	if mt.hooksRunner == nil {
+		return
+	}
+
+	for _, hooks := range mt.resourceHooks(spec) {
+		if hook := hooks.OnResourceFailed; hook != nil {
+			mt.hooksRunner.enqueue(func() error {
+				return wrapHookError("OnResourceFailed", hook(kind, spec.Namespace, spec.ResourceName, reason))
+			})
+		}
+	}
+}
+
+func (mt *multitracker) runPodErrorHooks(kind string, spec MultitrackSpec, podName, containerName, message string) {
+	// All Pod errors of the resources pass here
+	mt.recordPodFailureMetrics(kind, spec, message)
+
+	if mt.hooksRunner == nil {
+		return
+	}
+
+	for _, hooks := range mt.resourceHooks(spec) {
+		if hook := hooks.OnPodError; hook != nil {
+			mt.hooksRunner.enqueue(func() error {
+				return wrapHookError("OnPodError", hook(kind, spec.Namespace, spec.ResourceName, podName, containerName, message))
+			})
+		}
+	}
+}
+
+func (mt *multitracker) runStatusReportHooks() {
+	if mt.hooksRunner == nil || mt.hooks == nil || mt.hooks.OnStatusReport == nil {
+		return
+	}
+
+	snapshot := MultitrackSnapshot{Timestamp: time.Now().UTC()}
+	for _, kind := range mt.trackedKinds() {
+		for _, name := range sortedSpecsKeys(kind.Specs) {
+			snapshot.Resources = append(snapshot.Resources, mt.newResourceStatusEvent(kind.Kind, kind.Specs[name]))
+		}
+	}
+
+	hook := mt.hooks.OnStatusReport
+	mt.hooksRunner.enqueue(func() error {
+		return wrapHookError("OnStatusReport", hook(snapshot))
+	})
+}
+
+// stopHooks calls pending hooks, it must be called without handlers mutex held.
+func (mt *multitracker) stopHooks() error {
+	if mt.hooksRunner == nil {
+		return nil
+	}
+	return mt.hooksRunner.stop()
+}
+
+func (mt *multitracker) hooksErrChan() <-chan error {
+	if mt.hooksRunner == nil {
+		return nil
+	}
+	return mt.hooksRunner.ErrChan
+}
+
+// resourceStatus returns the last status of the resource as passed to OnResourceReady hook.
+func (mt *multitracker) resourceStatus(kind, name string) interface{} {
+	switch kind {
+	case "po":
+		return mt.PodsStatuses[name]
+	case "deploy":
+		return mt.DeploymentsStatuses[name]
+	case "rs":
+		return mt.ReplicaSetsStatuses[name]
+	case "sts":
+		return mt.StatefulSetsStatuses[name]
+	case "ds":
+		return mt.DaemonSetsStatuses[name]
+	case "job":
+		return mt.JobsStatuses[name]
+	case "generic":
+		return mt.GenericStatuses[name]
+	default:
+		return nil
+	}
+}
+
+func wrapHookError(hookName string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%s hook failed: %s", hookName, err)
+}
@@ -0,0 +1,111 @@
+package multitrack
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/werf/kubedog/pkg/tracker"
+)
+
+// TestMultitrackHooksStress runs slow hooks of many Pods with the same name in different namespaces while tracking finishes:
+// the hooks tell the Pods apart by namespace, all of them are called before Multitrack returns and none is called after.
+func TestMultitrackHooksStress(t *testing.T) {
+	const podsCount = 20
+	allowFailuresCount := 1000
+
+	kube := fake.NewSimpleClientset()
+
+	var pods []*corev1.Pod
+	var specs []MultitrackSpec
+	for i := 0; i < podsCount; i++ {
+		pod := newTestPod(fmt.Sprintf("ns-%d", i), "app")
+		if _, err := kube.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		pods = append(pods, pod)
+		specs = append(specs, MultitrackSpec{ResourceName: pod.Name, Namespace: pod.Namespace, AllowFailuresCount: &allowFailuresCount})
+	}
+
+	var mux sync.Mutex
+	readyIDs := make(map[string]int)
+	var podErrorsIDs []string
+	callsCount := 0
+
+	hooks := &MultitrackHooks{
+		OnResourceReady: func(kind, namespace, name string, status interface{}) error {
+			time.Sleep(time.Millisecond)
+
+			mux.Lock()
+			defer mux.Unlock()
+			readyIDs[tracker.FormatResourceID(kind, namespace, name)]++
+			callsCount++
+			return nil
+		},
+		OnPodError: func(kind, namespace, name, podName, containerName, message string) error {
+			mux.Lock()
+			defer mux.Unlock()
+			podErrorsIDs = append(podErrorsIDs, tracker.FormatResourceID(kind, namespace, name))
+			callsCount++
+			return nil
+		},
+		OnStatusReport: func(snapshot MultitrackSnapshot) error {
+			mux.Lock()
+			defer mux.Unlock()
+			callsCount++
+			return nil
+		},
+	}
+
+	// Odd Pods report container errors before they become ready
+	stopUpdates := runTestPodsUpdates(t, kube, pods, func(i, step int, pod *corev1.Pod) *corev1.Pod {
+		if i%2 == 1 {
+			return setTestPodContainerWaiting(pod, "CrashLoopBackOff")
+		}
+		return pod
+	}, func(i int, pod *corev1.Pod) *corev1.Pod {
+		return setTestPodReady(pod)
+	})
+
+	_, out, err := runTestMultitrack(t, kube, MultitrackSpecs{Pods: specs}, MultitrackOptions{
+		StatusProgressPeriod: time.Millisecond,
+		Hooks:                hooks,
+	})
+	stopUpdates()
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s\n%s", err, out)
+	}
+
+	mux.Lock()
+	callsCountOnReturn := callsCount
+	for i := 0; i < podsCount; i++ {
+		id := fmt.Sprintf("po/ns-%d/app", i)
+		if readyIDs[id] != 1 {
+			t.Errorf("expected OnResourceReady to be called once for %s, got %d calls", id, readyIDs[id])
+		}
+	}
+	if len(readyIDs) != podsCount {
+		t.Errorf("expected OnResourceReady for %d Pods, got %v", podsCount, readyIDs)
+	}
+	for _, id := range podErrorsIDs {
+		if _, hasKey := readyIDs[id]; !hasKey {
+			t.Errorf("unexpected OnPodError resource %s", id)
+		}
+	}
+	mux.Unlock()
+
+	time.Sleep(50 * time.Millisecond)
+
+	mux.Lock()
+	defer mux.Unlock()
+	if callsCount != callsCountOnReturn {
+		t.Errorf("expected no hooks to be called after Multitrack returned, got %d calls", callsCount-callsCountOnReturn)
+	}
+}
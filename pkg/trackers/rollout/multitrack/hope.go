@@ -0,0 +1,75 @@
+package multitrack
+
+import "github.com/werf/kubedog/pkg/tracker/pod"
+
+// reevaluateHopingResources decides the postponed failures of HopeUntilEndOfDeployProcess resources once no other
+// resource is active: the resources which have recovered meanwhile (e.g. the crash-looping Pod restarted successfully)
+// are tracked further until they are ready, the resources which are still unhealthy are failed.
+// It must be called under handlers mutex after a tracker returned.
+func (mt *multitracker) reevaluateHopingResources() {
+	if mt.finishErr != nil {
+		return
+	}
+
+	for _, kind := range mt.trackedKinds() {
+		for _, key := range sortedSpecsKeys(kind.Specs) {
+			state := kind.States[key]
+			if state.Status != ResourceHoping || kind.Contexts[key] == nil || mt.isResourceUnhealthy(kind.Kind, kind.Specs[key]) {
+				continue
+			}
+
+			mt.displayResourceTrackerMessageF(kind.Kind, kind.Specs[key], "recovered from postponed error: %s", state.HopedReason)
+			state.Recover()
+			state.resetFailingSince()
+		}
+	}
+
+	// Recovered resources are active again, the remaining ones keep hoping until these are done
+	if len(mt.getActiveResourcesNames()) > 0 {
+		return
+	}
+
+	for _, kind := range mt.trackedKinds() {
+		for _, key := range sortedSpecsKeys(kind.Specs) {
+			state := kind.States[key]
+			mtCtx := kind.Contexts[key]
+			if state.Status != ResourceHoping || mtCtx == nil {
+				continue
+			}
+
+			spec := kind.Specs[key]
+			reason := state.HopedReason
+
+			mt.displayMultitrackServiceMessageF("%s is still unhealthy when other resources are done (HopeUntilEndOfDeployProcess fail mode is active): stop tracking immediately!\n", mt.resourceID(kind.Kind, spec))
+			state.HandleHopeExpired()
+			mt.captureFailedContainerLogs(kind.Kind, spec)
+			mt.runResourceFailedHooks(kind.Kind, spec, reason)
+
+			if err := mt.failResourceImmediately(kind.Kind, spec); err != nil {
+				mtCtx.Err = err
+				mtCtx.CancelFunc()
+			}
+		}
+	}
+}
+
+// isResourceUnhealthy returns true when the last status of the resource is failed or some of its Pods have errors.
+// It must be called under handlers mutex.
+func (mt *multitracker) isResourceUnhealthy(kind string, spec MultitrackSpec) bool {
+	if mt.newResourceStatusEvent(kind, spec).Phase == ResourcePhaseFailed {
+		return true
+	}
+
+	pods := mt.resourceChildPods(kind, resourceKey(spec))
+	if kind == "po" {
+		pods = map[string]pod.PodStatus{spec.ResourceName: mt.PodsStatuses[resourceKey(spec)]}
+	}
+
+	for _, podStatus := range pods {
+		if !podStatus.IsDeleted && (podStatus.IsFailed || len(podStatus.ContainersErrors) > 0) {
+			return true
+		}
+	}
+
+	return false
+}
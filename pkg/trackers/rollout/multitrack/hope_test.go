@@ -0,0 +1,154 @@
+package multitrack
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestMultitrackHopingResourceStillUnhealthy fails the Pod with the postponed failure once the other Pod is ready
+// and the failed Pod has not recovered.
+func TestMultitrackHopingResourceStillUnhealthy(t *testing.T) {
+	kube := fake.NewSimpleClientset()
+	pods, specs := createTestPods(t, kube, 2)
+	specs[0].FailMode = HopeUntilEndOfDeployProcess
+	specs[0].AllowFailuresCount = intPtr(0)
+
+	// The second Pod becomes ready only when the failure of the first one is postponed
+	var isHoping int32
+	hooks := &MultitrackHooks{OnStatusReport: func(snapshot MultitrackSnapshot) error {
+		for _, resource := range snapshot.Resources {
+			if resource.ID == "po/ns-0/app-0" && resource.Phase == ResourcePhaseFailed {
+				atomic.StoreInt32(&isHoping, 1)
+			}
+		}
+		return nil
+	}}
+
+	stopUpdates := runTestPodsUpdates(t, kube, pods, func(i, step int, pod *corev1.Pod) *corev1.Pod {
+		return pod
+	}, func(i int, pod *corev1.Pod) *corev1.Pod {
+		if i == 0 {
+			return setTestPodFailed(pod)
+		}
+		if atomic.LoadInt32(&isHoping) == 1 {
+			return setTestPodReady(pod)
+		}
+		return pod
+	})
+
+	result, out, err := runTestMultitrack(t, kube, MultitrackSpecs{Pods: specs}, MultitrackOptions{
+		StatusProgressPeriod: 10 * time.Millisecond,
+		Hooks:                hooks,
+	})
+	stopUpdates()
+
+	var failedErr *FailedResourcesError
+	if !errors.As(err, &failedErr) {
+		t.Fatalf("expected *FailedResourcesError, got %v\n%s", err, out)
+	}
+	if len(failedErr.Failures) != 1 || failedErr.Failures[0].ID() != "po/ns-0/app-0" || failedErr.Failures[0].Reason != "Error" {
+		t.Errorf("expected po/ns-0/app-0 failed with Error reason, got %+v", failedErr.Failures)
+	}
+	if !strings.Contains(out, "po/ns-0/app-0 is still unhealthy when other resources are done") {
+		t.Errorf("expected still unhealthy message in the output:\n%s", out)
+	}
+
+	for _, tc := range []struct {
+		name            string
+		expectedOutcome ResourceOutcome
+	}{
+		{name: "app-0", expectedOutcome: ResourceOutcomeFailed},
+		{name: "app-1", expectedOutcome: ResourceOutcomeReady},
+	} {
+		resource := findTestResourceResult(result, "po", tc.name)
+		if resource == nil {
+			t.Errorf("no result of Pod %s", tc.name)
+		} else if resource.Outcome != tc.expectedOutcome {
+			t.Errorf("Pod %s: expected %s outcome, got %s", tc.name, tc.expectedOutcome, resource.Outcome)
+		}
+	}
+}
+
+// TestMultitrackHopingResourceRecovered tracks the Pod with the postponed failure further once the other Pod is ready,
+// because its container errors are gone: it is ready later and Multitrack succeeds.
+func TestMultitrackHopingResourceRecovered(t *testing.T) {
+	const (
+		stageCrashLooping = iota
+		stageRecovered
+		stageOtherReady
+		stageReady
+	)
+
+	kube := fake.NewSimpleClientset()
+	pods, specs := createTestPods(t, kube, 2)
+	specs[0].FailMode = HopeUntilEndOfDeployProcess
+	specs[0].AllowFailuresCount = intPtr(0)
+	specs[0].ShowServiceMessages = true
+
+	var stage int32
+	setStageAfter := func(from, to int32, next func()) {
+		time.AfterFunc(200*time.Millisecond, func() {
+			if atomic.CompareAndSwapInt32(&stage, from, to) && next != nil {
+				next()
+			}
+		})
+	}
+
+	// The first Pod crash-loops until its failure is postponed and recovers, the second Pod becomes ready
+	// once the recovered status is received, the first Pod becomes ready when the postponed failures have been decided
+	hooks := &MultitrackHooks{
+		OnPodError: func(kind, namespace, name, podName, containerName, message string) error {
+			if name == "app-0" {
+				setStageAfter(stageCrashLooping, stageRecovered, func() {
+					setStageAfter(stageRecovered, stageOtherReady, nil)
+				})
+			}
+			return nil
+		},
+		OnResourceReady: func(kind, namespace, name string, status interface{}) error {
+			if name == "app-1" {
+				setStageAfter(stageOtherReady, stageReady, nil)
+			}
+			return nil
+		},
+	}
+
+	stopUpdates := runTestPodsUpdates(t, kube, pods, func(i, step int, pod *corev1.Pod) *corev1.Pod {
+		return pod
+	}, func(i int, pod *corev1.Pod) *corev1.Pod {
+		switch s := atomic.LoadInt32(&stage); {
+		case i == 0 && s == stageCrashLooping:
+			return setTestPodContainerWaiting(pod, "CrashLoopBackOff")
+		case i == 0 && s == stageReady:
+			return setTestPodReady(pod)
+		case i == 1 && s >= stageOtherReady:
+			return setTestPodReady(pod)
+		}
+		return pod
+	})
+
+	result, out, err := runTestMultitrack(t, kube, MultitrackSpecs{Pods: specs}, MultitrackOptions{Hooks: hooks})
+	stopUpdates()
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s\n%s", err, out)
+	}
+	if !strings.Contains(out, "recovered from postponed error") {
+		t.Errorf("expected recovered message in the output:\n%s", out)
+	}
+
+	for _, name := range []string{"app-0", "app-1"} {
+		resource := findTestResourceResult(result, "po", name)
+		if resource == nil {
+			t.Errorf("no result of Pod %s", name)
+		} else if resource.Outcome != ResourceOutcomeReady {
+			t.Errorf("Pod %s: expected Ready outcome, got %s", name, resource.Outcome)
+		}
+	}
+}
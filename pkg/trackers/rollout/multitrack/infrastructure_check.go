@@ -0,0 +1,210 @@
+package multitrack
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultInfrastructureMinFailedResources = 3
+	defaultInfrastructureWindow             = time.Minute
+	infrastructureSnapshotTimeout           = 5 * time.Second
+)
+
+// InfrastructureCheck enables the heuristic for the failures caused by the cluster infrastructure rather than by the resources:
+// when MinFailedResources resources fail with image pull, DNS or pod network errors within WindowSeconds, the readiness of
+// the system Workloads is checked once and reported in the returned error, e.g. "note: kube-system/coredns 0/2 ready — cluster DNS
+// appears down", and such failures are marked as suspected infrastructure.
+type InfrastructureCheck struct {
+	// MinFailedResources is 3 when 0.
+	MinFailedResources int
+	// WindowSeconds is 60 seconds when 0.
+	WindowSeconds int
+	// Workloads are the Deployments and DaemonSets checked, DefaultInfrastructureWorkloads when empty.
+	// Workloads which do not exist in the cluster are skipped.
+	Workloads []ResourceRef
+}
+
+// DefaultInfrastructureWorkloads are the cluster DNS, kube-proxy and the DaemonSets of the common CNI plugins.
+var DefaultInfrastructureWorkloads = []ResourceRef{
+	{Kind: "deploy", Namespace: "kube-system", Name: "coredns"},
+	{Kind: "deploy", Namespace: "kube-system", Name: "kube-dns"},
+	{Kind: "ds", Namespace: "kube-system", Name: "kube-proxy"},
+	{Kind: "ds", Namespace: "kube-system", Name: "calico-node"},
+	{Kind: "ds", Namespace: "kube-system", Name: "cilium"},
+	{Kind: "ds", Namespace: "kube-system", Name: "kube-flannel-ds"},
+	{Kind: "ds", Namespace: "kube-system", Name: "aws-node"},
+	{Kind: "ds", Namespace: "kube-system", Name: "weave-net"},
+}
+
+// infrastructureComponents name the role of the known system workloads in the notes.
+var infrastructureComponents = map[string]string{
+	"coredns":         "cluster DNS",
+	"kube-dns":        "cluster DNS",
+	"kube-proxy":      "service proxy",
+	"calico-node":     "pod network",
+	"cilium":          "pod network",
+	"kube-flannel-ds": "pod network",
+	"aws-node":        "pod network",
+	"weave-net":       "pod network",
+}
+
+// infrastructureFailurePatterns are DNS and pod network errors, image pull failures are recognized by FailureCodeImagePull.
+var infrastructureFailurePatterns = []string{
+	"no such host",
+	"name resolution",
+	"server misbehaving",
+	"failed to create pod sandbox",
+	"network plugin",
+	"networkplugin",
+	"cni plugin",
+	"cni config",
+}
+
+func validateInfrastructureCheck(opts MultitrackOptions) error {
+	if opts.InfrastructureCheck == nil {
+		return nil
+	}
+
+	for _, ref := range opts.InfrastructureCheck.Workloads {
+		if ref.Kind != "deploy" && ref.Kind != "ds" {
+			return fmt.Errorf("bad InfrastructureCheck workload %s: unsupported kind %q, expected deploy or ds", ref, ref.Kind)
+		}
+		if ref.Namespace == "" || ref.Name == "" {
+			return fmt.Errorf("bad InfrastructureCheck workload %s: namespace and name are required", ref)
+		}
+	}
+
+	return nil
+}
+
+func isInfrastructureFailure(reason string) bool {
+	if ClassifyFailedReason(reason) == FailureCodeImagePull {
+		return true
+	}
+
+	reason = strings.ToLower(reason)
+	for _, pattern := range infrastructureFailurePatterns {
+		if strings.Contains(reason, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// observeInfrastructureFailure takes the snapshot of the system workloads once enough resources failed with infrastructural
+// reasons within the window. The snapshot is taken at most once per run. It must be called under handlers mutex.
+func (mt *multitracker) observeInfrastructureFailure(kind string, spec MultitrackSpec, reason string) {
+	check := mt.infrastructureCheck
+	if check == nil || mt.isInfrastructureSnapshotTaken || mt.kube == nil || !isInfrastructureFailure(reason) {
+		return
+	}
+
+	minFailedResources := check.MinFailedResources
+	if minFailedResources <= 0 {
+		minFailedResources = defaultInfrastructureMinFailedResources
+	}
+	window := time.Duration(check.WindowSeconds) * time.Second
+	if window <= 0 {
+		window = defaultInfrastructureWindow
+	}
+
+	now := time.Now()
+	mt.infrastructureFailures[mt.resourceID(kind, spec)] = now
+
+	failedResources := 0
+	for _, failedAt := range mt.infrastructureFailures {
+		if now.Sub(failedAt) <= window {
+			failedResources++
+		}
+	}
+	if failedResources < minFailedResources {
+		return
+	}
+
+	mt.isInfrastructureSnapshotTaken = true
+	mt.displayMultitrackServiceMessageF("%d resources failed with infrastructural reasons within %s, checking system workloads\n", failedResources, window)
+
+	mt.infrastructureNotes = mt.takeInfrastructureSnapshot(check.Workloads)
+	for _, note := range mt.infrastructureNotes {
+		mt.displayMultitrackServiceMessageF("note: %s\n", note)
+	}
+}
+
+// takeInfrastructureSnapshot returns the readiness of the workloads, e.g. "kube-system/coredns 0/2 ready — cluster DNS appears down".
+// Missing workloads are skipped, the rest of the workloads is not checked without the permissions.
+func (mt *multitracker) takeInfrastructureSnapshot(workloads []ResourceRef) []string {
+	if len(workloads) == 0 {
+		workloads = DefaultInfrastructureWorkloads
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), infrastructureSnapshotTimeout)
+	defer cancel()
+
+	var res []string
+	for _, ref := range workloads {
+		ready, desired, err := mt.getWorkloadReadiness(ctx, ref)
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if apierrors.IsForbidden(err) {
+			mt.displayMultitrackServiceMessageF("System workloads are not checked: %s\n", err)
+			break
+		} else if err != nil {
+			if debug() {
+				fmt.Printf("unable to check system workload %s: %s\n", ref, err)
+			}
+			continue
+		}
+
+		res = append(res, formatInfrastructureNote(ref, ready, desired))
+	}
+
+	return res
+}
+
+func (mt *multitracker) getWorkloadReadiness(ctx context.Context, ref ResourceRef) (int32, int32, error) {
+	switch ref.Kind {
+	case "deploy":
+		object, err := mt.kube.AppsV1().Deployments(ref.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return 0, 0, err
+		}
+
+		desired := int32(1)
+		if object.Spec.Replicas != nil {
+			desired = *object.Spec.Replicas
+		}
+		return object.Status.ReadyReplicas, desired, nil
+	case "ds":
+		object, err := mt.kube.AppsV1().DaemonSets(ref.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return 0, 0, err
+		}
+		return object.Status.NumberReady, object.Status.DesiredNumberScheduled, nil
+	default:
+		return 0, 0, fmt.Errorf("unsupported kind %q", ref.Kind)
+	}
+}
+
+func formatInfrastructureNote(ref ResourceRef, ready, desired int32) string {
+	res := fmt.Sprintf("%s/%s %d/%d ready", ref.Namespace, ref.Name, ready, desired)
+
+	component := infrastructureComponents[ref.Name]
+	if component == "" {
+		component = ref.Name
+	}
+
+	switch {
+	case desired > 0 && ready == 0:
+		res += fmt.Sprintf(" — %s appears down", component)
+	case ready < desired:
+		res += fmt.Sprintf(" — %s degraded", component)
+	}
+
+	return res
+}
@@ -0,0 +1,76 @@
+package multitrack
+
+import (
+	"fmt"
+
+	"github.com/werf/kubedog/pkg/tracker"
+)
+
+// InternalError is returned by Multitrack when a tracker goroutine of the resource panicked,
+// the outcome of the resource is ResourceOutcomeInternalError.
+type InternalError struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Err       *tracker.PanicError
+}
+
+// ID returns the resource identifier including namespace, e.g. "deploy/prod/api".
+func (e *InternalError) ID() string {
+	return tracker.FormatResourceID(e.Kind, e.Namespace, e.Name)
+}
+
+func (e *InternalError) Error() string {
+	return fmt.Sprintf("%s track failed: %s", e.ID(), e.Err)
+}
+
+func (e *InternalError) Unwrap() error {
+	return e.Err
+}
+
+// recoverTrackerPanic must be deferred by the goroutines of the resource tracking, it fails the whole process
+// with *InternalError instead of crashing unless PanicsAreFatal is set.
+func (mt *multitracker) recoverTrackerPanic(kind string, spec MultitrackSpec) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	panicErr := tracker.NewPanicError(recovered, mt.panicsAreFatal)
+
+	mt.mux.Lock()
+	defer mt.mux.Unlock()
+
+	mt.failOnPanic(kind, spec, panicErr)
+}
+
+// callTrackerFunc returns the panic of the tracker func, e.g. of the feed callbacks, as *tracker.PanicError.
+func (mt *multitracker) callTrackerFunc(spec MultitrackSpec, mtCtx *multitrackerContext, trackerFunc func(MultitrackSpec, *multitrackerContext) error) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = tracker.NewPanicError(recovered, mt.panicsAreFatal)
+		}
+	}()
+
+	return trackerFunc(spec, mtCtx)
+}
+
+// failOnPanic marks the resource as failed by the internal error, shows the final status report and fails the whole process,
+// so that the status of other resources is not lost. It must be called under handlers mutex.
+func (mt *multitracker) failOnPanic(kind string, spec MultitrackSpec, panicErr *tracker.PanicError) {
+	state := mt.resourcesStatesByKind(kind)[resourceKey(spec)]
+	state.InternalError = panicErr
+	state.Status = ResourceFailed
+	state.FailedReason = panicErr.Error()
+
+	err := &InternalError{Kind: kind, Namespace: spec.Namespace, Name: spec.ResourceName, Err: panicErr}
+
+	if mt.finishErr == nil {
+		_ = mt.displayStatusProgress(false)
+		mt.displayMultitrackErrorMessageF("%s\n", err)
+		if debug() {
+			fmt.Printf("%s\n", panicErr.Stack)
+		}
+	}
+
+	mt.fail(err)
+}
@@ -0,0 +1,89 @@
+package multitrack
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/werf/kubedog/pkg/tracker"
+)
+
+// panickingOutputAdapter panics once on the first line containing panicOn, it is written from the tracker goroutine
+// of the resource, which reports the resource error.
+type panickingOutputAdapter struct {
+	recordingOutputAdapter
+	panicOn string
+	once    sync.Once
+}
+
+func (a *panickingOutputAdapter) Line(text string) {
+	if strings.Contains(text, a.panicOn) {
+		a.once.Do(func() { panic("output adapter is broken") })
+	}
+	a.recordingOutputAdapter.Line(text)
+}
+
+// TestMultitrackTrackerPanic panics in the tracker goroutine of the failed Pod, while the other Pod is ready:
+// Multitrack returns *InternalError of the Pod instead of crashing and the result of the other Pod is kept.
+func TestMultitrackTrackerPanic(t *testing.T) {
+	kube := fake.NewSimpleClientset()
+	pods, specs := createTestPods(t, kube, 2)
+
+	// The Pod fails only when the other one is ready, so that the result of the ready Pod is known
+	readyChan := make(chan struct{})
+	var readyOnce sync.Once
+	hooks := &MultitrackHooks{OnResourceReady: func(kind, namespace, name string, status interface{}) error {
+		readyOnce.Do(func() { close(readyChan) })
+		return nil
+	}}
+
+	stopUpdates := runTestPodsUpdates(t, kube, pods, func(i, step int, pod *corev1.Pod) *corev1.Pod {
+		return pod
+	}, func(i int, pod *corev1.Pod) *corev1.Pod {
+		if i == 0 {
+			return setTestPodReady(pod)
+		}
+		select {
+		case <-readyChan:
+			return setTestPodFailed(pod)
+		default:
+			return pod
+		}
+	})
+
+	adapter := &panickingOutputAdapter{panicOn: "ns-1/app-1 ERROR"}
+	result, out, err := runTestMultitrack(t, kube, MultitrackSpecs{Pods: specs}, MultitrackOptions{OutputAdapter: adapter, Hooks: hooks})
+	stopUpdates()
+
+	var internalErr *InternalError
+	if !errors.As(err, &internalErr) {
+		t.Fatalf("expected *InternalError, got %v\n%s", err, out)
+	}
+	if internalErr.ID() != "po/ns-1/app-1" {
+		t.Errorf("expected internal error of po/ns-1/app-1, got %s", internalErr.ID())
+	}
+
+	var panicErr *tracker.PanicError
+	if !errors.As(err, &panicErr) || panicErr.Value != "output adapter is broken" {
+		t.Errorf("expected *tracker.PanicError unwrapped, got %v", err)
+	}
+	if expected := "po/ns-1/app-1 track failed: internal error: panic: output adapter is broken"; err.Error() != expected {
+		t.Errorf("expected error %q, got %q", expected, err.Error())
+	}
+
+	if resource := findTestResourceResult(result, "po", "app-0"); resource == nil || resource.Outcome != ResourceOutcomeReady {
+		t.Errorf("expected Ready outcome of app-0, got %+v", resource)
+	}
+	if resource := findTestResourceResult(result, "po", "app-1"); resource == nil || resource.Outcome != ResourceOutcomeInternalError {
+		t.Errorf("expected InternalError outcome of app-1, got %+v", resource)
+	}
+
+	entries := strings.Join(adapter.Entries(), "\n")
+	if !strings.Contains(entries, "po/ns-1/app-1 track failed: internal error: panic: output adapter is broken") {
+		t.Errorf("expected internal error shown:\n%s", entries)
+	}
+}
@@ -0,0 +1,297 @@
+package multitrack
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+type OutputMode string
+
+const (
+	// OutputText is the default human-oriented output with the status progress tables.
+	OutputText OutputMode = "Text"
+	// OutputTextDelta is OutputText with periodic status progress tables showing only the resources whose status
+	// (phase, replicas, conditions, Pods restarts, etc.) changed since the previous report, followed by a one-line summary
+	// of all resources. The first report, reports after a resource failed and the final report are full.
+	OutputTextDelta OutputMode = "TextDelta"
+	// OutputJSONEvents writes newline-delimited JSON events into OutputWriter: an event per resource status change
+	// and a snapshot of all resources instead of each status progress table. Logs and messages are still written as text.
+	OutputJSONEvents OutputMode = "JSONEvents"
+	// OutputJSONChunked writes each status report into OutputWriter as a stream of NDJSON records
+	// instead of a single document, see JSONReportRecord. Logs and messages are still written as text.
+	OutputJSONChunked OutputMode = "JSONChunked"
+	// OutputJSONChunkedDelta is OutputJSONChunked with resource records written only for the resources
+	// whose status changed since the previous report.
+	OutputJSONChunkedDelta OutputMode = "JSONChunkedDelta"
+	// OutputKubectlRolloutStatus writes lines phrased as by kubectl rollout status --watch into OutputWriter
+	// instead of the status progress tables: a line per change of a Deployment, StatefulSet or DaemonSet status.
+	// Logs and messages are still written as text.
+	OutputKubectlRolloutStatus OutputMode = "KubectlRolloutStatus"
+)
+
+type JSONEventType string
+
+const (
+	JSONEventStatus   JSONEventType = "status"
+	JSONEventSnapshot JSONEventType = "snapshot"
+)
+
+// JSONEvent is a single line of the OutputJSONEvents output, Resource is set for the status event,
+// Resources for the snapshot event.
+type JSONEvent struct {
+	Type      JSONEventType         `json:"type"`
+	Timestamp time.Time             `json:"timestamp"`
+	Resource  *ResourceStatusEvent  `json:"resource,omitempty"`
+	Resources []ResourceStatusEvent `json:"resources,omitempty"`
+	// Groups are set for the snapshot event instead of Resources when GroupByLabel option is set.
+	Groups []JSONResourceGroup `json:"groups,omitempty"`
+}
+
+type ResourcePhase string
+
+const (
+	// ResourcePhasePending means the status of the resource has not been received yet.
+	ResourcePhasePending     ResourcePhase = "pending"
+	ResourcePhaseProgressing ResourcePhase = "progressing"
+	ResourcePhaseReady       ResourcePhase = "ready"
+	ResourcePhaseFailed      ResourcePhase = "failed"
+)
+
+type ResourceStatusEvent struct {
+	// ID is the canonical resource identifier, e.g. "deploy/prod/api".
+	ID        string `json:"id"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Group is set when GroupByLabel option is set.
+	Group string        `json:"group,omitempty"`
+	Phase ResourcePhase `json:"phase"`
+
+	Ready        bool   `json:"ready"`
+	Failed       bool   `json:"failed"`
+	FailedReason string `json:"failedReason,omitempty"`
+	// FailedLogs are the last lines of the log of the failing container of the failed resource.
+	FailedLogs *FailedContainerLogs `json:"failedLogs,omitempty"`
+
+	WaitingFor []string `json:"waitingFor,omitempty"`
+	// Reconnects is the number of dropped watches of the resource, which were opened again.
+	Reconnects int `json:"reconnects,omitempty"`
+
+	// Stability is set for the resources with StabilityWindowSeconds once they are ready.
+	Stability *ResourceStability `json:"stability,omitempty"`
+
+	// Replicas is set for Deployments, ReplicaSets, StatefulSets and DaemonSets.
+	Replicas *ReplicasCounts `json:"replicas,omitempty"`
+	// Job is set for Jobs.
+	Job *JobCounts `json:"job,omitempty"`
+	// Pod is set for Pods.
+	Pod *PodCounts `json:"pod,omitempty"`
+	// Conditions are set for Generic resources.
+	Conditions []ResourceCondition `json:"conditions,omitempty"`
+}
+
+type ReplicasCounts struct {
+	Desired   int64 `json:"desired"`
+	Ready     int32 `json:"ready"`
+	UpToDate  int32 `json:"upToDate"`
+	Available int32 `json:"available"`
+}
+
+type JobCounts struct {
+	Active    int32 `json:"active"`
+	Succeeded int32 `json:"succeeded"`
+	Failed    int32 `json:"failed"`
+	// Completions, Percent and EstimatedRemainingSeconds are set for the Job with several completions.
+	Completions               int32 `json:"completions,omitempty"`
+	Percent                   int   `json:"percent,omitempty"`
+	EstimatedRemainingSeconds int64 `json:"estimatedRemainingSeconds,omitempty"`
+	FailedIndexes             []int `json:"failedIndexes,omitempty"`
+}
+
+type ResourceCondition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type PodCounts struct {
+	PodPhase        string `json:"podPhase"`
+	ReadyContainers int32  `json:"readyContainers"`
+	TotalContainers int32  `json:"totalContainers"`
+	Restarts        int32  `json:"restarts"`
+}
+
+// emitResourceStatusEvent writes the status event of the resource when its status changed since the previous event.
+// It is called after each change of the resource status, so it also signals the changes of the resource phase
+// and the recovery from errors.
+func (mt *multitracker) emitResourceStatusEvent(kind string, spec MultitrackSpec) {
+	mt.observeFailureRecovery(kind, spec)
+	mt.notifyResourcePhaseChange(kind, spec)
+	mt.recordResourcePhaseMetrics(kind, spec)
+	mt.traceResourceStatus(kind, spec)
+
+	if mt.output == OutputKubectlRolloutStatus {
+		mt.emitKubectlRolloutStatus(kind, spec)
+		return
+	}
+	if mt.output != OutputJSONEvents {
+		return
+	}
+
+	event := mt.newResourceStatusEvent(kind, spec)
+
+	key := fmt.Sprintf("%s/%s", kind, resourceKey(spec))
+	if prevEvent, hasKey := mt.lastResourcesStatusEvents[key]; hasKey && reflect.DeepEqual(prevEvent, event) {
+		return
+	}
+	mt.lastResourcesStatusEvents[key] = event
+
+	mt.writeJSONEvent(JSONEvent{Type: JSONEventStatus, Timestamp: time.Now().UTC(), Resource: &event})
+}
+
+func (mt *multitracker) emitSnapshotEvent() {
+	event := JSONEvent{Type: JSONEventSnapshot, Timestamp: time.Now().UTC(), Resources: []ResourceStatusEvent{}}
+
+	if mt.groupByLabel != "" {
+		event.Resources = nil
+		event.Groups = mt.newJSONResourceGroups()
+		mt.writeJSONEvent(event)
+		return
+	}
+
+	for _, kind := range mt.trackedKinds() {
+		for _, name := range sortedSpecsKeys(kind.Specs) {
+			event.Resources = append(event.Resources, mt.newResourceStatusEvent(kind.Kind, kind.Specs[name]))
+		}
+	}
+
+	mt.writeJSONEvent(event)
+}
+
+func (mt *multitracker) writeJSONEvent(event JSONEvent) {
+	if err := json.NewEncoder(mt.outputWriter).Encode(event); err != nil && debug() {
+		fmt.Printf("unable to write multitrack %s event: %s\n", event.Type, err)
+	}
+}
+
+func (mt *multitracker) newResourceStatusEvent(kind string, spec MultitrackSpec) ResourceStatusEvent {
+	key := resourceKey(spec)
+	state := mt.resourcesStatesByKind(kind)[key]
+
+	event := ResourceStatusEvent{
+		ID:        mt.resourceID(kind, spec),
+		Kind:      kind,
+		Namespace: spec.Namespace,
+		Name:      spec.ResourceName,
+		Group:     mt.resourceGroup(kind, key),
+	}
+
+	var statusGeneration uint64
+	var isFailed bool
+
+	switch kind {
+	case "po":
+		status := mt.PodsStatuses[key]
+		statusGeneration, isFailed, event.FailedReason = status.StatusGeneration, status.IsFailed, status.FailedReason
+		event.Pod = &PodCounts{
+			PodPhase:        string(status.Phase),
+			ReadyContainers: status.ReadyContainers,
+			TotalContainers: status.TotalContainers,
+			Restarts:        status.Restarts,
+		}
+	case "deploy":
+		status := mt.DeploymentsStatuses[key]
+		statusGeneration, isFailed, event.FailedReason, event.WaitingFor = status.StatusGeneration, status.IsFailed, status.FailedReason, status.WaitingForMessages
+		event.Replicas = &ReplicasCounts{
+			Ready:     status.ReadyReplicas,
+			UpToDate:  status.UpdatedReplicas,
+			Available: status.AvailableReplicas,
+		}
+		if status.ReplicasIndicator != nil {
+			event.Replicas.Desired = int64(status.ReplicasIndicator.TargetValue)
+		}
+	case "rs":
+		status := mt.ReplicaSetsStatuses[key]
+		statusGeneration, isFailed, event.FailedReason, event.WaitingFor = status.StatusGeneration, status.IsFailed, status.FailedReason, status.WaitingForMessages
+		event.Replicas = &ReplicasCounts{
+			Ready:     status.ReadyReplicas,
+			UpToDate:  status.Replicas,
+			Available: status.AvailableReplicas,
+		}
+		if status.ReplicasIndicator != nil {
+			event.Replicas.Desired = int64(status.ReplicasIndicator.TargetValue)
+		}
+	case "sts":
+		status := mt.StatefulSetsStatuses[key]
+		statusGeneration, isFailed, event.FailedReason, event.WaitingFor = status.StatusGeneration, status.IsFailed, status.FailedReason, status.WaitingForMessages
+		event.Replicas = &ReplicasCounts{
+			Ready:     status.ReadyReplicas,
+			UpToDate:  status.UpdatedReplicas,
+			Available: status.ReadyReplicas,
+		}
+		if status.ReplicasIndicator != nil {
+			event.Replicas.Desired = status.ReplicasIndicator.TargetValue
+		}
+	case "ds":
+		status := mt.DaemonSetsStatuses[key]
+		statusGeneration, isFailed, event.FailedReason, event.WaitingFor = status.StatusGeneration, status.IsFailed, status.FailedReason, status.WaitingForMessages
+		event.Replicas = &ReplicasCounts{
+			Desired:   int64(status.DesiredNumberScheduled),
+			Ready:     status.NumberReady,
+			UpToDate:  status.UpdatedNumberScheduled,
+			Available: status.NumberAvailable,
+		}
+	case "job":
+		status := mt.JobsStatuses[key]
+		statusGeneration, isFailed, event.FailedReason, event.WaitingFor = status.StatusGeneration, status.IsFailed, status.FailedReason, status.WaitingForMessages
+		event.Job = &JobCounts{
+			Active:    status.Active,
+			Succeeded: status.Succeeded,
+			Failed:    status.Failed,
+		}
+		if progress := status.Progress; progress != nil {
+			event.Job.Completions = progress.Completions
+			event.Job.Percent = progress.Percent()
+			event.Job.EstimatedRemainingSeconds = int64(progress.EstimatedRemaining.Seconds())
+			event.Job.FailedIndexes = progress.FailedIndexes
+		}
+	case "generic":
+		status := mt.GenericStatuses[key]
+		statusGeneration, isFailed, event.FailedReason, event.WaitingFor = status.StatusGeneration, status.IsFailed, status.FailedReason, status.WaitingForMessages
+		for _, cond := range status.Conditions {
+			event.Conditions = append(event.Conditions, ResourceCondition{
+				Type:    cond.Type,
+				Status:  cond.Status,
+				Reason:  cond.Reason,
+				Message: cond.Message,
+			})
+		}
+	}
+
+	event.Stability = state.Stability.copy()
+	event.Reconnects = state.Connection.Reconnects
+	event.Ready = state.Status == ResourceSucceeded
+	event.Failed = isFailed || state.Status == ResourceFailed
+	if event.FailedReason == "" && event.Failed {
+		event.FailedReason = state.FailedReason
+	}
+	if event.Failed {
+		event.FailedLogs = state.FailedContainerLogs
+	}
+
+	switch {
+	case event.Failed:
+		event.Phase = ResourcePhaseFailed
+	case event.Ready:
+		event.Phase = ResourcePhaseReady
+	case statusGeneration == 0:
+		event.Phase = ResourcePhasePending
+	default:
+		event.Phase = ResourcePhaseProgressing
+	}
+
+	return event
+}
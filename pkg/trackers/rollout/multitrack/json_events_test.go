@@ -0,0 +1,173 @@
+package multitrack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/werf/kubedog/pkg/tracker/deployment"
+	"github.com/werf/kubedog/pkg/tracker/generic"
+	"github.com/werf/kubedog/pkg/tracker/indicators"
+	"github.com/werf/kubedog/pkg/tracker/job"
+	"github.com/werf/kubedog/pkg/tracker/pod"
+)
+
+// newTestJSONEventsMultitracker returns the multitracker in OutputJSONEvents mode tracking the progressing Deployment,
+// the failed Job, the ready Pod and the Generic resource without status yet.
+func newTestJSONEventsMultitracker(output *bytes.Buffer) *multitracker {
+	mt := newTestMultitracker()
+	mt.output = OutputJSONEvents
+	mt.outputWriter = output
+	mt.lastResourcesStatusEvents = make(map[string]ResourceStatusEvent)
+	mt.DeploymentsStatuses = make(map[string]deployment.DeploymentStatus)
+	mt.JobsStatuses = make(map[string]job.JobStatus)
+	mt.PodsStatuses = make(map[string]pod.PodStatus)
+	mt.GenericStatuses = make(map[string]generic.GenericStatus)
+
+	addTestResource := func(kind string, spec MultitrackSpec, status ResourceStatus) {
+		for _, k := range mt.trackedKinds() {
+			if k.Kind == kind {
+				k.Specs[resourceKey(spec)] = spec
+				k.States[resourceKey(spec)] = newMultitrackerResourceState(spec)
+				k.States[resourceKey(spec)].Status = status
+			}
+		}
+	}
+
+	api := MultitrackSpec{ResourceName: "api", Namespace: "prod"}
+	addTestResource("deploy", api, ResourceActive)
+	mt.DeploymentsStatuses[resourceKey(api)] = deployment.DeploymentStatus{
+		DeploymentStatus:   appsv1.DeploymentStatus{ReadyReplicas: 1, UpdatedReplicas: 2, AvailableReplicas: 1},
+		StatusGeneration:   3,
+		ReplicasIndicator:  &indicators.Int32EqualConditionIndicator{Value: 2, TargetValue: 3},
+		WaitingForMessages: []string{"up-to-date 2->3", "available 1->3"},
+	}
+
+	migrate := MultitrackSpec{ResourceName: "migrate", Namespace: "prod"}
+	addTestResource("job", migrate, ResourceFailed)
+	mt.TrackingJobs[resourceKey(migrate)].FailedReason = "BackoffLimitExceeded: Job has reached the specified backoff limit"
+	mt.TrackingJobs[resourceKey(migrate)].FailedContainerLogs = &FailedContainerLogs{PodName: "migrate-x2k", ContainerName: "main", Lines: []string{"panic: no database"}}
+	mt.JobsStatuses[resourceKey(migrate)] = job.JobStatus{
+		JobStatus:        batchv1.JobStatus{Failed: 3},
+		StatusGeneration: 5,
+	}
+
+	worker := MultitrackSpec{ResourceName: "worker", Namespace: "default"}
+	addTestResource("po", worker, ResourceSucceeded)
+	mt.PodsStatuses[resourceKey(worker)] = pod.PodStatus{
+		PodStatus:        corev1.PodStatus{Phase: corev1.PodRunning},
+		StatusGeneration: 2,
+		ReadyContainers:  2,
+		TotalContainers:  2,
+		Restarts:         1,
+		IsReady:          true,
+	}
+
+	addTestResource("generic", MultitrackSpec{ResourceName: "cert", Namespace: "prod"}, ResourceActive)
+
+	return mt
+}
+
+// decodeTestJSONEvents decodes the NDJSON output, timestamps are checked and cleared.
+func decodeTestJSONEvents(t *testing.T, output string) []JSONEvent {
+	t.Helper()
+
+	var events []JSONEvent
+	for _, line := range strings.Split(strings.TrimSuffix(output, "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+
+		var event JSONEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("unable to decode event %q: %s", line, err)
+		}
+		if time.Since(event.Timestamp) > time.Minute || event.Timestamp.Location() != time.UTC {
+			t.Errorf("unexpected timestamp %s of event %q", event.Timestamp, line)
+		}
+		event.Timestamp = time.Time{}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestJSONEventsSnapshot(t *testing.T) {
+	output := &bytes.Buffer{}
+	mt := newTestJSONEventsMultitracker(output)
+	mt.emitSnapshotEvent()
+
+	events := decodeTestJSONEvents(t, output.String())
+	if len(events) != 1 {
+		t.Fatalf("expected a single snapshot event, got:\n%s", output.String())
+	}
+
+	res, err := json.MarshalIndent(events[0], "", "  ")
+	if err != nil {
+		t.Fatalf("unable to encode snapshot: %s", err)
+	}
+	res = append(res, '\n')
+
+	goldenPath := filepath.Join("testdata", "json_events", "snapshot.golden")
+	if *updateGolden {
+		if err := ioutil.WriteFile(goldenPath, res, 0644); err != nil {
+			t.Fatalf("unable to update golden file: %s", err)
+		}
+	}
+
+	expected, err := ioutil.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("unable to read golden file: %s", err)
+	}
+	if string(res) != string(expected) {
+		t.Errorf("snapshot event does not match %s:\n%s", goldenPath, res)
+	}
+}
+
+// TestJSONEventsStatusChanges emits the status event of the Deployment after each handler call:
+// only the changes of its status are written.
+func TestJSONEventsStatusChanges(t *testing.T) {
+	output := &bytes.Buffer{}
+	mt := newTestJSONEventsMultitracker(output)
+	key := resourceKey(MultitrackSpec{ResourceName: "api", Namespace: "prod"})
+	spec := mt.DeploymentsSpecs[key]
+
+	setReady := func(ready int32) {
+		status := mt.DeploymentsStatuses[key]
+		status.ReadyReplicas = ready
+		status.StatusGeneration++
+		mt.DeploymentsStatuses[key] = status
+	}
+
+	mt.emitResourceStatusEvent("deploy", spec)
+	mt.emitResourceStatusEvent("deploy", spec)
+	setReady(2)
+	mt.emitResourceStatusEvent("deploy", spec)
+	setReady(3)
+	mt.TrackingDeployments[key].Status = ResourceSucceeded
+	mt.emitResourceStatusEvent("deploy", spec)
+	mt.emitResourceStatusEvent("deploy", spec)
+
+	events := decodeTestJSONEvents(t, output.String())
+
+	var got []string
+	for _, event := range events {
+		if event.Type != JSONEventStatus || event.Resource == nil || event.Resource.ID != "deploy/prod/api" {
+			t.Fatalf("unexpected event %+v", event)
+		}
+		got = append(got, fmt.Sprintf("%s %d", event.Resource.Phase, event.Resource.Replicas.Ready))
+	}
+
+	expected := []string{"progressing 1", "progressing 2", "ready 3"}
+	if strings.Join(got, ", ") != strings.Join(expected, ", ") {
+		t.Errorf("expected events %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,147 @@
+package multitrack
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+type JSONReportRecordType string
+
+const (
+	JSONReportHeader   JSONReportRecordType = "header"
+	JSONReportResource JSONReportRecordType = "resource"
+	JSONReportFooter   JSONReportRecordType = "footer"
+)
+
+// JSONReportRecord is a single line of the OutputJSONChunked output. Each status report is a header record,
+// a record per resource and a footer record, the footer always terminates the report, even if writing of resource records failed.
+// Every record carries RunID, Seq of the report and Index of the record within the report (header is 0),
+// so the consumer can resume reading from any record.
+type JSONReportRecord struct {
+	Type      JSONReportRecordType `json:"type"`
+	RunID     string               `json:"runId"`
+	Seq       uint64               `json:"seq"`
+	Index     int                  `json:"index"`
+	Timestamp time.Time            `json:"timestamp"`
+
+	// Delta is set in the header and footer of the OutputJSONChunkedDelta report,
+	// which contains records only of the resources changed since the previous report.
+	Delta bool `json:"delta,omitempty"`
+
+	// Resource is set for the resource record.
+	Resource *ResourceStatusEvent `json:"resource,omitempty"`
+
+	// Summary is set for the footer record.
+	Summary *JSONReportSummary `json:"summary,omitempty"`
+}
+
+type JSONReportSummary struct {
+	// Rollup counts all tracked resources, including the ones not reported in the delta report.
+	Rollup JSONReportRollup `json:"rollup"`
+	// ResourceRecords is the number of resource records written in the report.
+	ResourceRecords int `json:"resourceRecords"`
+	// Truncated is set when some resource records of the report could not be written.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+type JSONReportRollup struct {
+	Total       int `json:"total"`
+	Pending     int `json:"pending"`
+	Progressing int `json:"progressing"`
+	Ready       int `json:"ready"`
+	Failed      int `json:"failed"`
+}
+
+func (r *JSONReportRollup) add(phase ResourcePhase) {
+	r.Total++
+
+	switch phase {
+	case ResourcePhasePending:
+		r.Pending++
+	case ResourcePhaseProgressing:
+		r.Progressing++
+	case ResourcePhaseReady:
+		r.Ready++
+	case ResourcePhaseFailed:
+		r.Failed++
+	}
+}
+
+func isJSONChunkedOutput(output OutputMode) bool {
+	return output == OutputJSONChunked || output == OutputJSONChunkedDelta
+}
+
+func newRunID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// emitChunkedReport writes the status report as NDJSON records.
+func (mt *multitracker) emitChunkedReport() {
+	mt.reportSeq++
+
+	isDelta := mt.output == OutputJSONChunkedDelta
+	index := 0
+	newRecord := func(recordType JSONReportRecordType) JSONReportRecord {
+		record := JSONReportRecord{
+			Type:      recordType,
+			RunID:     mt.runID,
+			Seq:       mt.reportSeq,
+			Index:     index,
+			Timestamp: time.Now().UTC(),
+			Delta:     isDelta,
+		}
+		index++
+		return record
+	}
+
+	mt.writeJSONReportRecord(newRecord(JSONReportHeader))
+
+	summary := &JSONReportSummary{}
+	defer func() {
+		footer := newRecord(JSONReportFooter)
+		footer.Summary = summary
+		mt.writeJSONReportRecord(footer)
+	}()
+
+	for _, kind := range mt.trackedKinds() {
+		for _, name := range sortedSpecsKeys(kind.Specs) {
+			event := mt.newResourceStatusEvent(kind.Kind, kind.Specs[name])
+			summary.Rollup.add(event.Phase)
+
+			if summary.Truncated {
+				continue
+			}
+
+			key := fmt.Sprintf("%s/%s", kind.Kind, name)
+			if prevEvent, hasKey := mt.lastReportedResources[key]; isDelta && hasKey && reflect.DeepEqual(prevEvent, event) {
+				continue
+			}
+
+			record := newRecord(JSONReportResource)
+			record.Resource = &event
+			if err := mt.writeJSONReportRecord(record); err != nil {
+				summary.Truncated = true
+				continue
+			}
+
+			mt.lastReportedResources[key] = event
+			summary.ResourceRecords++
+		}
+	}
+}
+
+func (mt *multitracker) writeJSONReportRecord(record JSONReportRecord) error {
+	err := json.NewEncoder(mt.outputWriter).Encode(record)
+	if err != nil && debug() {
+		fmt.Printf("unable to write multitrack report %d %s record: %s\n", record.Seq, record.Type, err)
+	}
+	return err
+}
@@ -0,0 +1,153 @@
+package multitrack
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func decodeTestJSONReportRecords(t *testing.T, output string) []JSONReportRecord {
+	t.Helper()
+
+	var records []JSONReportRecord
+	for _, line := range strings.Split(strings.TrimSuffix(output, "\n"), "\n") {
+		var record JSONReportRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("unable to decode record %q: %s", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// checkTestJSONReport checks that the records are a single report: the header, resource records and the footer
+// with the same RunID and Seq and contiguous indexes. It returns the IDs of the reported resources and the footer summary.
+func checkTestJSONReport(t *testing.T, records []JSONReportRecord, runID string, seq uint64, isDelta bool) ([]string, JSONReportSummary) {
+	t.Helper()
+
+	if len(records) < 2 || records[0].Type != JSONReportHeader || records[len(records)-1].Type != JSONReportFooter {
+		t.Fatalf("report %d: expected header and footer, got %+v", seq, records)
+	}
+
+	var ids []string
+	for i, record := range records {
+		if record.RunID != runID || record.Seq != seq || record.Index != i || record.Delta != isDelta {
+			t.Errorf("report %d: unexpected record %d %+v", seq, i, record)
+		}
+		if record.Type == JSONReportResource {
+			ids = append(ids, record.Resource.ID)
+		}
+	}
+
+	footer := records[len(records)-1]
+	if footer.Summary == nil {
+		t.Fatalf("report %d: expected footer summary", seq)
+	}
+	return ids, *footer.Summary
+}
+
+func TestChunkedReports(t *testing.T) {
+	allIDs := []string{"po/default/worker", "deploy/prod/api", "job/prod/migrate", "generic/prod/cert"}
+	rollup := JSONReportRollup{Total: 4, Pending: 1, Progressing: 1, Ready: 1, Failed: 1}
+
+	for _, output := range []OutputMode{OutputJSONChunked, OutputJSONChunkedDelta} {
+		t.Run(string(output), func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			mt := newTestJSONEventsMultitracker(buf)
+			mt.output = output
+			mt.runID = newRunID()
+			mt.lastReportedResources = make(map[string]ResourceStatusEvent)
+			isDelta := output == OutputJSONChunkedDelta
+
+			apiKey := resourceKey(MultitrackSpec{ResourceName: "api", Namespace: "prod"})
+
+			for seq, step := range []struct {
+				change      func()
+				expectedIDs []string
+			}{
+				{expectedIDs: allIDs},
+				{expectedIDs: nil},
+				{
+					change: func() {
+						status := mt.DeploymentsStatuses[apiKey]
+						status.ReadyReplicas++
+						mt.DeploymentsStatuses[apiKey] = status
+					},
+					expectedIDs: []string{"deploy/prod/api"},
+				},
+			} {
+				if step.change != nil {
+					step.change()
+				}
+				buf.Reset()
+				mt.emitChunkedReport()
+
+				expectedIDs := step.expectedIDs
+				if !isDelta {
+					expectedIDs = allIDs
+				}
+
+				ids, summary := checkTestJSONReport(t, decodeTestJSONReportRecords(t, buf.String()), mt.runID, uint64(seq+1), isDelta)
+				if strings.Join(ids, " ") != strings.Join(expectedIDs, " ") {
+					t.Errorf("report %d: expected resources %q, got %q", seq+1, expectedIDs, ids)
+				}
+				if summary.Rollup != rollup || summary.ResourceRecords != len(expectedIDs) || summary.Truncated {
+					t.Errorf("report %d: unexpected summary %+v", seq+1, summary)
+				}
+			}
+		})
+	}
+}
+
+// failingTestWriter fails the write with the index failedWrite, other writes succeed.
+type failingTestWriter struct {
+	bytes.Buffer
+	writes      int
+	failedWrite int
+}
+
+func (w *failingTestWriter) Write(p []byte) (int, error) {
+	w.writes++
+	if w.writes == w.failedWrite {
+		return 0, errors.New("broken pipe")
+	}
+	return w.Buffer.Write(p)
+}
+
+// TestChunkedReportTruncated fails writing of the second resource record: the report is terminated by the footer
+// counting all resources, records of the rest resources are not written and are reported again in the next delta report.
+func TestChunkedReportTruncated(t *testing.T) {
+	w := &failingTestWriter{failedWrite: 3}
+	mt := newTestJSONEventsMultitracker(nil)
+	mt.outputWriter = w
+	mt.output = OutputJSONChunkedDelta
+	mt.runID = "run"
+	mt.lastReportedResources = make(map[string]ResourceStatusEvent)
+
+	mt.emitChunkedReport()
+
+	records := decodeTestJSONReportRecords(t, w.String())
+	if len(records) != 3 {
+		t.Fatalf("expected header, resource record and footer, got %+v", records)
+	}
+	footer := records[2]
+	if footer.Type != JSONReportFooter || footer.Index != 3 || footer.Summary == nil {
+		t.Fatalf("expected footer with index 3 after the failed record, got %+v", footer)
+	}
+	if footer.Summary.ResourceRecords != 1 || !footer.Summary.Truncated || footer.Summary.Rollup.Total != 4 {
+		t.Errorf("unexpected summary %+v", footer.Summary)
+	}
+
+	w.Reset()
+	mt.emitChunkedReport()
+
+	ids, summary := checkTestJSONReport(t, decodeTestJSONReportRecords(t, w.String()), "run", 2, true)
+	if expected := "deploy/prod/api job/prod/migrate generic/prod/cert"; strings.Join(ids, " ") != expected {
+		t.Errorf("expected not reported resources %q, got %q", expected, ids)
+	}
+	if summary.Truncated {
+		t.Errorf("unexpected truncated report %+v", summary)
+	}
+}
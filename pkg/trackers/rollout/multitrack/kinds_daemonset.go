@@ -0,0 +1,201 @@
+package multitrack
+
+import (
+	"github.com/werf/kubedog/pkg/tracker"
+	"github.com/werf/kubedog/pkg/tracker/daemonset"
+	"github.com/werf/kubedog/pkg/tracker/replicaset"
+	"k8s.io/client-go/kubernetes"
+)
+
+func (mt *multitracker) TrackDaemonSet(kube kubernetes.Interface, spec MultitrackSpec, opts MultitrackOptions) error {
+	feed := daemonset.NewFeed()
+
+	feed.OnAdded(func(isReady bool) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("ds", spec)
+
+		mt.DaemonSetsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.daemonsetAdded(spec, feed, isReady)
+	})
+	feed.OnReady(func() error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("ds", spec)
+
+		mt.DaemonSetsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.daemonsetReady(spec, feed)
+	})
+	feed.OnFailed(func(reason string) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("ds", spec)
+
+		mt.DaemonSetsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.daemonsetFailed(spec, feed, reason)
+	})
+	feed.OnDeleted(func() error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("ds", spec)
+
+		return mt.handleResourceDeleted(mt.TrackingDaemonSets, "ds", spec)
+	})
+	feed.OnEventMsg(func(msg string) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("ds", spec)
+
+		mt.DaemonSetsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.daemonsetEventMsg(spec, feed, msg)
+	})
+	feed.OnAddedReplicaSet(func(rs replicaset.ReplicaSet) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("ds", spec)
+
+		mt.DaemonSetsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.daemonsetAddedReplicaSet(spec, feed, rs)
+	})
+	feed.OnAddedPod(func(pod replicaset.ReplicaSetPod) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("ds", spec)
+
+		mt.DaemonSetsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.daemonsetAddedPod(spec, feed, pod)
+	})
+	feed.OnPodError(func(podError replicaset.ReplicaSetPodError) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("ds", spec)
+
+		mt.DaemonSetsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.daemonsetPodError(spec, feed, podError)
+	})
+	feed.OnPodLogChunk(func(chunk *replicaset.ReplicaSetPodLogChunk) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("ds", spec)
+
+		mt.DaemonSetsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.daemonsetPodLogChunk(spec, feed, chunk)
+	})
+	feed.OnStatus(func(status daemonset.DaemonSetStatus) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("ds", spec)
+
+		mt.DaemonSetsStatuses[resourceKey(spec)] = status
+
+		mt.validateLogsContainers(mt.TrackingDaemonSets, "ds", spec, status.Pods)
+		mt.observeStabilityWindowStatus(mt.TrackingDaemonSets, "ds", spec, status.IsReady)
+		mt.observeFollowedResourceReadiness(mt.TrackingDaemonSets, "ds", spec, status.IsReady)
+
+		if spec.SuccessCondition == SuccessConditionPodsSucceeded {
+			return mt.handlePodsSucceededCondition(mt.TrackingDaemonSets, "ds", spec, status.Pods, status.NewPodsNames, status.DesiredNumberScheduled)
+		}
+
+		return nil
+	})
+
+	if err := feed.Track(spec.ResourceName, spec.Namespace, kube, opts.Options); err != nil {
+		return err
+	}
+
+	return mt.trackPostReadinessChecks(kube, "ds", spec, mt.TrackingDaemonSets, opts)
+}
+
+func (mt *multitracker) daemonsetAdded(spec MultitrackSpec, feed daemonset.Feed, isReady bool) error {
+	if spec.SuccessCondition == SuccessConditionPodsSucceeded {
+		mt.displayResourceTrackerMessageF("ds", spec, "added")
+
+		status := feed.GetStatus()
+		return mt.handlePodsSucceededCondition(mt.TrackingDaemonSets, "ds", spec, status.Pods, status.NewPodsNames, status.DesiredNumberScheduled)
+	}
+
+	if isReady {
+		mt.displayResourceTrackerMessageF("ds", spec, "appears to be READY")
+
+		if err := mt.checkExpectedRollout(mt.TrackingDaemonSets, "ds", spec); err != nil {
+			return err
+		}
+
+		return mt.handlePostponedResourceReadyCondition(mt.TrackingDaemonSets, "ds", spec)
+	}
+
+	mt.TrackingDaemonSets[resourceKey(spec)].ObservedNotReady = true
+	mt.displayResourceTrackerMessageF("ds", spec, "added")
+
+	return nil
+}
+
+func (mt *multitracker) daemonsetReady(spec MultitrackSpec, feed daemonset.Feed) error {
+	if mt.isFollowedResourceReady(mt.TrackingDaemonSets, spec) {
+		return nil
+	}
+
+	if spec.SuccessCondition == SuccessConditionPodsSucceeded {
+		return nil
+	}
+
+	mt.displayResourceTrackerMessageF("ds", spec, "become READY")
+
+	if err := mt.checkExpectedRollout(mt.TrackingDaemonSets, "ds", spec); err != nil {
+		return err
+	}
+
+	return mt.handlePostponedResourceReadyCondition(mt.TrackingDaemonSets, "ds", spec)
+}
+
+func (mt *multitracker) daemonsetFailed(spec MultitrackSpec, feed daemonset.Feed, reason string) error {
+	mt.displayResourceErrorF("ds", spec, "%s", reason)
+
+	return mt.handleResourceFailure(mt.TrackingDaemonSets, "ds", spec, reason)
+}
+
+func (mt *multitracker) daemonsetEventMsg(spec MultitrackSpec, feed daemonset.Feed, msg string) error {
+	mt.displayResourceEventF("ds", spec, "%s", msg)
+	return nil
+}
+
+func (mt *multitracker) daemonsetAddedReplicaSet(spec MultitrackSpec, feed daemonset.Feed, rs replicaset.ReplicaSet) error {
+	mt.displayResourceTrackerMessageF("ds", spec, "rs/%s added", rs.Name)
+	return nil
+}
+
+func (mt *multitracker) daemonsetAddedPod(spec MultitrackSpec, feed daemonset.Feed, pod replicaset.ReplicaSetPod) error {
+	mt.displayResourceTrackerMessageF("ds", spec, "%s added", tracker.FormatResourceID("po", spec.Namespace, pod.Name))
+	return nil
+}
+
+func (mt *multitracker) daemonsetPodError(spec MultitrackSpec, feed daemonset.Feed, podError replicaset.ReplicaSetPodError) error {
+	if mt.isCompletedPodError(mt.TrackingDaemonSets, "ds", spec, mt.DaemonSetsStatuses[resourceKey(spec)].Pods, podError.PodName) {
+		return nil
+	}
+
+	reason := formatPodErrorReason(spec.Namespace, podError.PodName, podError.ContainerError)
+
+	mt.displayResourceErrorF("ds", spec, "%s", reason)
+	mt.runPodErrorHooks("ds", spec, podError.PodName, podError.ContainerName, podError.Message)
+	mt.rememberFailingContainer("ds", spec, podError.PodName, podError.ContainerName)
+
+	return mt.handleResourceFailure(mt.TrackingDaemonSets, "ds", spec, reason)
+}
+
+func (mt *multitracker) daemonsetPodLogChunk(spec MultitrackSpec, feed daemonset.Feed, chunk *replicaset.ReplicaSetPodLogChunk) error {
+	if !mt.isPodLogsShown("ds", spec, mt.DaemonSetsStatuses[resourceKey(spec)].Pods, chunk.PodName) {
+		return nil
+	}
+
+	mt.displayResourceLogChunk("ds", spec, chunk.PodName, chunk.ContainerLogChunk)
+	return nil
+}
@@ -0,0 +1,231 @@
+package multitrack
+
+import (
+	"github.com/werf/kubedog/pkg/tracker"
+	"github.com/werf/kubedog/pkg/tracker/deployment"
+	"github.com/werf/kubedog/pkg/tracker/replicaset"
+	"k8s.io/client-go/kubernetes"
+)
+
+func (mt *multitracker) TrackDeployment(kube kubernetes.Interface, spec MultitrackSpec, opts MultitrackOptions) error {
+	feed := deployment.NewFeed()
+
+	feed.OnAdded(func(isReady bool) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("deploy", spec)
+
+		mt.DeploymentsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.deploymentAdded(spec, feed, isReady)
+	})
+	feed.OnReady(func() error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("deploy", spec)
+
+		mt.DeploymentsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.deploymentReady(spec, feed)
+	})
+	feed.OnFailed(func(reason string) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("deploy", spec)
+
+		mt.DeploymentsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.deploymentFailed(spec, feed, reason)
+	})
+	feed.OnDeleted(func() error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("deploy", spec)
+
+		return mt.handleResourceDeleted(mt.TrackingDeployments, "deploy", spec)
+	})
+	feed.OnEventMsg(func(msg string) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("deploy", spec)
+
+		mt.DeploymentsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.deploymentEventMsg(spec, feed, msg)
+	})
+	feed.OnAddedReplicaSet(func(rs replicaset.ReplicaSet) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("deploy", spec)
+
+		mt.DeploymentsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.deploymentAddedReplicaSet(spec, feed, rs)
+	})
+	feed.OnAddedPod(func(pod replicaset.ReplicaSetPod) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("deploy", spec)
+
+		mt.DeploymentsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.deploymentAddedPod(spec, feed, pod)
+	})
+	feed.OnPodError(func(podError replicaset.ReplicaSetPodError) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("deploy", spec)
+
+		mt.DeploymentsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.deploymentPodError(spec, feed, podError)
+	})
+	feed.OnPodLogChunk(func(chunk *replicaset.ReplicaSetPodLogChunk) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("deploy", spec)
+
+		mt.DeploymentsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.deploymentPodLogChunk(spec, feed, chunk)
+	})
+	feed.OnScaleEvents(func(events []deployment.ScaleEvent) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+
+		mt.TrackingDeployments[resourceKey(spec)].ScaleEvents = events
+		if len(events) > 0 {
+			mt.setEventsReceived()
+		}
+
+		return nil
+	})
+	feed.OnStatus(func(status deployment.DeploymentStatus) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("deploy", spec)
+
+		mt.DeploymentsStatuses[resourceKey(spec)] = status
+
+		mt.validateLogsContainers(mt.TrackingDeployments, "deploy", spec, status.Pods)
+		mt.observeStabilityWindowStatus(mt.TrackingDeployments, "deploy", spec, status.IsReady)
+		mt.observeFollowedResourceReadiness(mt.TrackingDeployments, "deploy", spec, status.IsReady)
+
+		if spec.SuccessCondition == SuccessConditionPodsSucceeded {
+			return mt.handlePodsSucceededCondition(mt.TrackingDeployments, "deploy", spec, status.Pods, status.NewPodsNames, podsSucceededDesired(status.ReplicasIndicator))
+		}
+
+		return nil
+	})
+
+	if err := feed.Track(spec.ResourceName, spec.Namespace, kube, opts.Options); err != nil {
+		return err
+	}
+
+	return mt.trackPostReadinessChecks(kube, "deploy", spec, mt.TrackingDeployments, opts)
+}
+
+func (mt *multitracker) deploymentAdded(spec MultitrackSpec, feed deployment.Feed, isReady bool) error {
+	if spec.SuccessCondition == SuccessConditionPodsSucceeded {
+		mt.displayResourceTrackerMessageF("deploy", spec, "added")
+
+		status := feed.GetStatus()
+		return mt.handlePodsSucceededCondition(mt.TrackingDeployments, "deploy", spec, status.Pods, status.NewPodsNames, podsSucceededDesired(status.ReplicasIndicator))
+	}
+
+	if isReady {
+		mt.displayResourceTrackerMessageF("deploy", spec, "appears to be READY")
+
+		if err := mt.checkExpectedRollout(mt.TrackingDeployments, "deploy", spec); err != nil {
+			return err
+		}
+
+		return mt.handlePostponedResourceReadyCondition(mt.TrackingDeployments, "deploy", spec)
+	}
+
+	mt.TrackingDeployments[resourceKey(spec)].ObservedNotReady = true
+	mt.displayResourceTrackerMessageF("deploy", spec, "added")
+
+	return nil
+}
+
+func (mt *multitracker) deploymentReady(spec MultitrackSpec, feed deployment.Feed) error {
+	if mt.isFollowedResourceReady(mt.TrackingDeployments, spec) {
+		return nil
+	}
+
+	if spec.SuccessCondition == SuccessConditionPodsSucceeded {
+		return nil
+	}
+
+	mt.displayResourceTrackerMessageF("deploy", spec, "become READY")
+
+	if err := mt.checkExpectedRollout(mt.TrackingDeployments, "deploy", spec); err != nil {
+		return err
+	}
+
+	return mt.handlePostponedResourceReadyCondition(mt.TrackingDeployments, "deploy", spec)
+}
+
+func (mt *multitracker) deploymentFailed(spec MultitrackSpec, feed deployment.Feed, reason string) error {
+	mt.displayResourceErrorF("deploy", spec, "%s", reason)
+
+	return mt.handleResourceFailure(mt.TrackingDeployments, "deploy", spec, reason)
+}
+
+func (mt *multitracker) deploymentEventMsg(spec MultitrackSpec, feed deployment.Feed, msg string) error {
+	mt.displayResourceEventF("deploy", spec, "%s", msg)
+	return nil
+}
+
+func (mt *multitracker) deploymentAddedReplicaSet(spec MultitrackSpec, feed deployment.Feed, rs replicaset.ReplicaSet) error {
+	if !rs.IsNew {
+		return nil
+	}
+
+	mt.displayResourceTrackerMessageF("deploy", spec, "rs/%s added", rs.Name)
+
+	return nil
+}
+
+func (mt *multitracker) deploymentAddedPod(spec MultitrackSpec, feed deployment.Feed, pod replicaset.ReplicaSetPod) error {
+	if !pod.ReplicaSet.IsNew {
+		return nil
+	}
+
+	mt.displayResourceTrackerMessageF("deploy", spec, "%s added", tracker.FormatResourceID("po", spec.Namespace, pod.Name))
+
+	return nil
+}
+
+func (mt *multitracker) deploymentPodError(spec MultitrackSpec, feed deployment.Feed, podError replicaset.ReplicaSetPodError) error {
+	if !podError.ReplicaSet.IsNew {
+		return nil
+	}
+
+	if mt.isCompletedPodError(mt.TrackingDeployments, "deploy", spec, mt.DeploymentsStatuses[resourceKey(spec)].Pods, podError.PodName) {
+		return nil
+	}
+
+	reason := formatPodErrorReason(spec.Namespace, podError.PodName, podError.ContainerError)
+
+	mt.displayResourceErrorF("deploy", spec, "%s", reason)
+	mt.runPodErrorHooks("deploy", spec, podError.PodName, podError.ContainerName, podError.Message)
+	mt.rememberFailingContainer("deploy", spec, podError.PodName, podError.ContainerName)
+
+	return mt.handleResourceFailure(mt.TrackingDeployments, "deploy", spec, reason)
+}
+
+func (mt *multitracker) deploymentPodLogChunk(spec MultitrackSpec, feed deployment.Feed, chunk *replicaset.ReplicaSetPodLogChunk) error {
+	if !chunk.ReplicaSet.IsNew {
+		return nil
+	}
+
+	if !mt.isPodLogsShown("deploy", spec, mt.DeploymentsStatuses[resourceKey(spec)].Pods, chunk.PodName) {
+		return nil
+	}
+
+	mt.displayResourceLogChunk("deploy", spec, chunk.PodName, chunk.ContainerLogChunk)
+
+	return nil
+}
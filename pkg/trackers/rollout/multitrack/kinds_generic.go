@@ -0,0 +1,150 @@
+package multitrack
+
+import (
+	"fmt"
+
+	"github.com/werf/kubedog/pkg/tracker/generic"
+	"k8s.io/client-go/kubernetes"
+)
+
+func validateGenericSpecs(specs MultitrackSpecs, opts MultitrackOptions) error {
+	if len(specs.Generic) > 0 && opts.DynamicClient == nil {
+		return fmt.Errorf("DynamicClient option is required to track Generic resources")
+	}
+
+	for _, spec := range specs.Generic {
+		if spec.GroupVersionResource.Version == "" || spec.GroupVersionResource.Resource == "" {
+			return fmt.Errorf("bad generic/%s spec: GroupVersionResource version and resource are required", spec.ResourceName)
+		}
+		if spec.ReadyCondition != nil && (spec.ReadyCondition.Type == "" || spec.ReadyCondition.Status == "") {
+			return fmt.Errorf("bad generic/%s spec: ReadyCondition type and status are required", spec.ResourceName)
+		}
+		if spec.FailedCondition != nil && (spec.FailedCondition.Type == "" || spec.FailedCondition.Status == "") {
+			return fmt.Errorf("bad generic/%s spec: FailedCondition type and status are required", spec.ResourceName)
+		}
+	}
+
+	return nil
+}
+
+func genericConditions(spec MultitrackSpec) generic.Conditions {
+	res := generic.Conditions{
+		Ready:  generic.ConditionMatch{Type: "Ready", Status: "True"},
+		Failed: spec.FailedCondition,
+	}
+	if spec.ReadyCondition != nil {
+		res.Ready = *spec.ReadyCondition
+	}
+	return res
+}
+
+func (mt *multitracker) TrackGeneric(kube kubernetes.Interface, spec MultitrackSpec, opts MultitrackOptions) error {
+	feed := generic.NewFeed()
+
+	feed.OnAdded(func(isReady bool) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("generic", spec)
+
+		mt.GenericStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.genericAdded(spec, feed, isReady)
+	})
+	feed.OnReady(func() error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("generic", spec)
+
+		mt.GenericStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.genericReady(spec, feed)
+	})
+	feed.OnFailed(func(reason string) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("generic", spec)
+
+		mt.GenericStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.genericFailed(spec, feed, reason)
+	})
+	feed.OnDeleted(func() error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("generic", spec)
+
+		return mt.handleResourceDeleted(mt.TrackingGeneric, "generic", spec)
+	})
+	feed.OnEventMsg(func(msg string) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("generic", spec)
+
+		mt.GenericStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.genericEventMsg(spec, feed, msg)
+	})
+	feed.OnStatus(func(status generic.GenericStatus) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("generic", spec)
+
+		mt.GenericStatuses[resourceKey(spec)] = status
+
+		mt.observeFollowedResourceReadiness(mt.TrackingGeneric, "generic", spec, status.IsReady)
+
+		return nil
+	})
+
+	return feed.Track(spec.ResourceName, spec.Namespace, spec.GroupVersionResource, genericConditions(spec), kube, opts.DynamicClient, opts.Options)
+}
+
+func (mt *multitracker) genericAdded(spec MultitrackSpec, feed generic.Feed, isReady bool) error {
+	if isReady {
+		mt.displayResourceTrackerMessageF("generic", spec, "appears to be READY")
+
+		return mt.handleResourceReadyCondition(mt.TrackingGeneric, "generic", spec)
+	}
+
+	mt.displayResourceTrackerMessageF("generic", spec, "added")
+
+	return nil
+}
+
+func (mt *multitracker) genericReady(spec MultitrackSpec, feed generic.Feed) error {
+	if mt.isFollowedResourceReady(mt.TrackingGeneric, spec) {
+		return nil
+	}
+
+	mt.displayResourceTrackerMessageF("generic", spec, "become READY")
+
+	return mt.handleResourceReadyCondition(mt.TrackingGeneric, "generic", spec)
+}
+
+func (mt *multitracker) genericFailed(spec MultitrackSpec, feed generic.Feed, reason string) error {
+	mt.displayResourceErrorF("generic", spec, "%s", reason)
+
+	return mt.handleResourceFailure(mt.TrackingGeneric, "generic", spec, reason)
+}
+
+func (mt *multitracker) genericEventMsg(spec MultitrackSpec, feed generic.Feed, msg string) error {
+	mt.displayResourceEventF("generic", spec, "%s", msg)
+	return nil
+}
+
+// failOnCRDRemoved fails the Generic resource which type has been removed from the cluster, e.g. by the uninstalled operator.
+// Unlike other non-retryable failures it does not stop tracking of other resources: they are not affected by the removed CRD,
+// the failure is reported when all of them are done. It must be called under handlers mutex.
+func (mt *multitracker) failOnCRDRemoved(kind string, spec MultitrackSpec, crdRemovedErr *generic.CRDRemovedError) {
+	reason := crdRemovedErr.Error()
+	state := mt.resourcesStatesByKind(kind)[resourceKey(spec)]
+
+	mt.displayResourceErrorF(kind, spec, "%s", reason)
+
+	res := state.HandleNonRetryableFailure(reason)
+	mt.runResourceFailedHooks(kind, spec, reason)
+
+	if res.Decision == FailureIgnored {
+		mt.displayMultitrackServiceMessageF("%d errors occurred for %s\n", state.FailuresCount, mt.resourceID(kind, spec))
+	}
+}
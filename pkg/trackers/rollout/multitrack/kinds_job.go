@@ -1,8 +1,7 @@
 package multitrack
 
 import (
-	"fmt"
-
+	"github.com/werf/kubedog/pkg/tracker"
 	"github.com/werf/kubedog/pkg/tracker/job"
 	"github.com/werf/kubedog/pkg/tracker/pod"
 	"k8s.io/client-go/kubernetes"
@@ -14,64 +13,81 @@ func (mt *multitracker) TrackJob(kube kubernetes.Interface, spec MultitrackSpec,
 	feed.OnAdded(func() error {
 		mt.mux.Lock()
 		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("job", spec)
 
-		mt.JobsStatuses[spec.ResourceName] = feed.GetStatus()
+		mt.JobsStatuses[resourceKey(spec)] = feed.GetStatus()
 
 		return mt.jobAdded(spec, feed)
 	})
 	feed.OnSucceeded(func() error {
 		mt.mux.Lock()
 		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("job", spec)
 
-		mt.JobsStatuses[spec.ResourceName] = feed.GetStatus()
+		mt.JobsStatuses[resourceKey(spec)] = feed.GetStatus()
 
 		return mt.jobSucceeded(spec, feed)
 	})
 	feed.OnFailed(func(reason string) error {
 		mt.mux.Lock()
 		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("job", spec)
 
-		mt.JobsStatuses[spec.ResourceName] = feed.GetStatus()
+		mt.JobsStatuses[resourceKey(spec)] = feed.GetStatus()
 
 		return mt.jobFailed(spec, feed, reason)
 	})
+	feed.OnDeleted(func() error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("job", spec)
+
+		return mt.handleResourceDeleted(mt.TrackingJobs, "job", spec)
+	})
 	feed.OnEventMsg(func(msg string) error {
 		mt.mux.Lock()
 		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("job", spec)
 
-		mt.JobsStatuses[spec.ResourceName] = feed.GetStatus()
+		mt.JobsStatuses[resourceKey(spec)] = feed.GetStatus()
 
 		return mt.jobEventMsg(spec, feed, msg)
 	})
 	feed.OnAddedPod(func(podName string) error {
 		mt.mux.Lock()
 		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("job", spec)
 
-		mt.JobsStatuses[spec.ResourceName] = feed.GetStatus()
+		mt.JobsStatuses[resourceKey(spec)] = feed.GetStatus()
 
 		return mt.jobAddedPod(spec, feed, podName)
 	})
 	feed.OnPodLogChunk(func(chunk *pod.PodLogChunk) error {
 		mt.mux.Lock()
 		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("job", spec)
 
-		mt.JobsStatuses[spec.ResourceName] = feed.GetStatus()
+		mt.JobsStatuses[resourceKey(spec)] = feed.GetStatus()
 
 		return mt.jobPodLogChunk(spec, feed, chunk)
 	})
 	feed.OnPodError(func(podError pod.PodError) error {
 		mt.mux.Lock()
 		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("job", spec)
 
-		mt.JobsStatuses[spec.ResourceName] = feed.GetStatus()
+		mt.JobsStatuses[resourceKey(spec)] = feed.GetStatus()
 
 		return mt.jobPodError(spec, feed, podError)
 	})
 	feed.OnStatus(func(status job.JobStatus) error {
 		mt.mux.Lock()
 		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("job", spec)
 
-		mt.JobsStatuses[spec.ResourceName] = status
+		mt.JobsStatuses[resourceKey(spec)] = status
+
+		mt.validateLogsContainers(mt.TrackingJobs, "job", spec, status.Pods)
 
 		return nil
 	})
@@ -86,9 +102,16 @@ func (mt *multitracker) jobAdded(spec MultitrackSpec, feed job.Feed) error {
 }
 
 func (mt *multitracker) jobSucceeded(spec MultitrackSpec, feed job.Feed) error {
+	if mt.isFollowedResourceReady(mt.TrackingJobs, spec) {
+		return nil
+	}
+
 	mt.displayResourceTrackerMessageF("job", spec, "succeeded")
+	for _, w := range feed.GetStatus().WarningMessages {
+		mt.displayResourceTrackerMessageF("job", spec, "%s", formatResourceWarning(spec.FailMode == IgnoreAndContinueDeployProcess, w))
+	}
 
-	return mt.handleResourceReadyCondition(mt.TrackingJobs, spec)
+	return mt.handleResourceReadyCondition(mt.TrackingJobs, "job", spec)
 }
 
 func (mt *multitracker) jobFailed(spec MultitrackSpec, feed job.Feed, reason string) error {
@@ -102,19 +125,25 @@ func (mt *multitracker) jobEventMsg(spec MultitrackSpec, feed job.Feed, msg stri
 }
 
 func (mt *multitracker) jobAddedPod(spec MultitrackSpec, feed job.Feed, podName string) error {
-	mt.displayResourceTrackerMessageF("job", spec, "po/%s added", podName)
+	mt.displayResourceTrackerMessageF("job", spec, "%s added", tracker.FormatResourceID("po", spec.Namespace, podName))
 	return nil
 }
 
 func (mt *multitracker) jobPodLogChunk(spec MultitrackSpec, feed job.Feed, chunk *pod.PodLogChunk) error {
-	mt.displayResourceLogChunk("job", spec, podContainerLogChunkHeader(chunk.PodName, chunk.ContainerLogChunk), chunk.ContainerLogChunk)
+	if !mt.isPodLogsShown("job", spec, mt.JobsStatuses[resourceKey(spec)].Pods, chunk.PodName) {
+		return nil
+	}
+
+	mt.displayResourceLogChunk("job", spec, chunk.PodName, chunk.ContainerLogChunk)
 	return nil
 }
 
 func (mt *multitracker) jobPodError(spec MultitrackSpec, feed job.Feed, podError pod.PodError) error {
-	reason := fmt.Sprintf("po/%s container/%s: %s", podError.PodName, podError.ContainerName, podError.Message)
+	reason := formatPodErrorReason(spec.Namespace, podError.PodName, podError.ContainerError)
 
 	mt.displayResourceErrorF("job", spec, "%s", reason)
+	mt.runPodErrorHooks("job", spec, podError.PodName, podError.ContainerName, podError.Message)
+	mt.rememberFailingContainer("job", spec, podError.PodName, podError.ContainerName)
 
 	return mt.handleResourceFailure(mt.TrackingJobs, "job", spec, reason)
 }
@@ -0,0 +1,151 @@
+package multitrack
+
+import (
+	"fmt"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/werf/kubedog/pkg/tracker/pod"
+)
+
+func (mt *multitracker) TrackPod(kube kubernetes.Interface, spec MultitrackSpec, opts MultitrackOptions) error {
+	feed := pod.NewFeed()
+
+	feed.OnAdded(func() error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("po", spec)
+
+		mt.PodsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.podAdded(spec, feed)
+	})
+	feed.OnReady(func() error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("po", spec)
+
+		mt.PodsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.podReady(spec, feed)
+	})
+	feed.OnSucceeded(func() error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("po", spec)
+
+		mt.PodsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.podSucceeded(spec, feed)
+	})
+	feed.OnFailed(func(reason string) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("po", spec)
+
+		mt.PodsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.podFailed(spec, feed, reason)
+	})
+	feed.OnDeleted(func() error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("po", spec)
+
+		mt.PodsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.handleResourceDeleted(mt.TrackingPods, "po", spec)
+	})
+	feed.OnEventMsg(func(msg string) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("po", spec)
+
+		mt.PodsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.podEventMsg(spec, feed, msg)
+	})
+	feed.OnContainerLogChunk(func(chunk *pod.ContainerLogChunk) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("po", spec)
+
+		mt.PodsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.podContainerLogChunk(spec, feed, chunk)
+	})
+	feed.OnContainerError(func(containerError pod.ContainerError) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("po", spec)
+
+		mt.PodsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.podContainerError(spec, feed, containerError)
+	})
+	feed.OnStatus(func(status pod.PodStatus) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("po", spec)
+
+		mt.PodsStatuses[resourceKey(spec)] = status
+
+		mt.validateLogsContainers(mt.TrackingPods, "po", spec, map[string]pod.PodStatus{spec.ResourceName: status})
+		mt.observeStabilityWindowStatus(mt.TrackingPods, "po", spec, status.IsReady)
+		mt.observeFollowedResourceReadiness(mt.TrackingPods, "po", spec, status.IsReady)
+
+		return nil
+	})
+
+	return feed.Track(spec.ResourceName, spec.Namespace, kube, opts.Options)
+}
+
+func (mt *multitracker) podAdded(spec MultitrackSpec, feed pod.Feed) error {
+	mt.displayResourceTrackerMessageF("po", spec, "added")
+	return nil
+}
+
+func (mt *multitracker) podReady(spec MultitrackSpec, feed pod.Feed) error {
+	if mt.isFollowedResourceReady(mt.TrackingPods, spec) {
+		return nil
+	}
+
+	mt.displayResourceTrackerMessageF("po", spec, "become READY")
+
+	return mt.handleResourceReadyCondition(mt.TrackingPods, "po", spec)
+}
+
+func (mt *multitracker) podSucceeded(spec MultitrackSpec, feed pod.Feed) error {
+	mt.displayResourceTrackerMessageF("po", spec, "succeeded")
+
+	return mt.handleResourceReadyCondition(mt.TrackingPods, "po", spec)
+}
+
+func (mt *multitracker) podFailed(spec MultitrackSpec, feed pod.Feed, reason string) error {
+	mt.displayResourceErrorF("po", spec, "%s", reason)
+
+	return mt.handleResourceFailure(mt.TrackingPods, "po", spec, reason)
+}
+
+func (mt *multitracker) podEventMsg(spec MultitrackSpec, feed pod.Feed, msg string) error {
+	mt.displayResourceEventF("po", spec, "%s", msg)
+	return nil
+}
+
+func (mt *multitracker) podContainerLogChunk(spec MultitrackSpec, feed pod.Feed, chunk *pod.ContainerLogChunk) error {
+	mt.displayResourceLogChunk("po", spec, spec.ResourceName, chunk)
+	return nil
+}
+
+func (mt *multitracker) podContainerError(spec MultitrackSpec, feed pod.Feed, containerError pod.ContainerError) error {
+	reason := containerError.Message
+	if containerError.ContainerName != "" {
+		reason = fmt.Sprintf("container/%s: %s", containerError.ContainerName, containerError.Message)
+	}
+
+	mt.displayResourceErrorF("po", spec, "%s", reason)
+	mt.runPodErrorHooks("po", spec, spec.ResourceName, containerError.ContainerName, containerError.Message)
+	mt.rememberFailingContainer("po", spec, spec.ResourceName, containerError.ContainerName)
+
+	return mt.handleResourceFailure(mt.TrackingPods, "po", spec, reason)
+}
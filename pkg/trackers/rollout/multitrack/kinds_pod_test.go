@@ -0,0 +1,84 @@
+package multitrack
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestMultitrackBarePodsRunToCompletion tracks the bare Pod with Never restart policy, which is running and ready
+// for a while: the Ready condition does not make it ready, the tracking ends when the Pod is completed or failed.
+// Containers statuses are not set, because the fake clientset cannot serve the logs of the terminated containers.
+func TestMultitrackBarePodsRunToCompletion(t *testing.T) {
+	tests := []struct {
+		name            string
+		final           func(pod *corev1.Pod) *corev1.Pod
+		expectedOutcome ResourceOutcome
+		expectedMessage string
+		expectedReason  string
+	}{
+		{
+			name: "completed",
+			final: func(pod *corev1.Pod) *corev1.Pod {
+				pod = pod.DeepCopy()
+				pod.Spec.RestartPolicy = corev1.RestartPolicyNever
+				pod.Status.Phase = corev1.PodSucceeded
+				return pod
+			},
+			expectedOutcome: ResourceOutcomeReady,
+			expectedMessage: "Succeeded",
+		},
+		{
+			name:            "failed",
+			final:           setTestPodFailed,
+			expectedOutcome: ResourceOutcomeFailed,
+			expectedMessage: "ERROR: Error",
+			expectedReason:  "Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kube := fake.NewSimpleClientset()
+			pods, specs := createTestPods(t, kube, 1)
+
+			stopUpdates := runTestPodsUpdates(t, kube, pods, func(i, step int, pod *corev1.Pod) *corev1.Pod {
+				pod = setTestPodReady(pod)
+				pod.Spec.RestartPolicy = corev1.RestartPolicyNever
+				return pod
+			}, func(i int, pod *corev1.Pod) *corev1.Pod {
+				return tt.final(pod)
+			})
+
+			result, out, err := runTestMultitrack(t, kube, MultitrackSpecs{Pods: specs}, MultitrackOptions{})
+			stopUpdates()
+
+			if tt.expectedReason == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s\n%s", err, out)
+				}
+			} else {
+				var failedErr *FailedResourcesError
+				if !errors.As(err, &failedErr) {
+					t.Fatalf("expected *FailedResourcesError, got %v\n%s", err, out)
+				}
+				if len(failedErr.Failures) != 1 || failedErr.Failures[0].Reason != tt.expectedReason {
+					t.Errorf("expected po/ns-0/app-0 failed with %q reason, got %+v", tt.expectedReason, failedErr.Failures)
+				}
+			}
+
+			if resource := findTestResourceResult(result, "po", "app-0"); resource == nil || resource.Outcome != tt.expectedOutcome {
+				t.Errorf("expected %s outcome, got %+v", tt.expectedOutcome, resource)
+			}
+			if !strings.Contains(out, tt.expectedMessage) {
+				t.Errorf("expected %q in the output:\n%s", tt.expectedMessage, out)
+			}
+			if strings.Contains(out, "become READY") {
+				t.Errorf("expected the Pod with Never restart policy not reported ready:\n%s", out)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package multitrack
+
+import (
+	"github.com/werf/kubedog/pkg/tracker"
+	"github.com/werf/kubedog/pkg/tracker/replicaset"
+	"k8s.io/client-go/kubernetes"
+)
+
+func (mt *multitracker) TrackReplicaSet(kube kubernetes.Interface, spec MultitrackSpec, opts MultitrackOptions) error {
+	feed := replicaset.NewFeed()
+
+	feed.OnAdded(func(isReady bool) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("rs", spec)
+
+		mt.ReplicaSetsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.replicasetAdded(spec, feed, isReady)
+	})
+	feed.OnReady(func() error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("rs", spec)
+
+		mt.ReplicaSetsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.replicasetReady(spec, feed)
+	})
+	feed.OnFailed(func(reason string) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("rs", spec)
+
+		mt.ReplicaSetsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.replicasetFailed(spec, feed, reason)
+	})
+	feed.OnDeleted(func() error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("rs", spec)
+
+		return mt.handleResourceDeleted(mt.TrackingReplicaSets, "rs", spec)
+	})
+	feed.OnEventMsg(func(msg string) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("rs", spec)
+
+		mt.ReplicaSetsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.replicasetEventMsg(spec, feed, msg)
+	})
+	feed.OnAddedPod(func(pod replicaset.ReplicaSetPod) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("rs", spec)
+
+		mt.ReplicaSetsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.replicasetAddedPod(spec, feed, pod)
+	})
+	feed.OnPodError(func(podError replicaset.ReplicaSetPodError) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("rs", spec)
+
+		mt.ReplicaSetsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.replicasetPodError(spec, feed, podError)
+	})
+	feed.OnPodLogChunk(func(chunk *replicaset.ReplicaSetPodLogChunk) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("rs", spec)
+
+		mt.ReplicaSetsStatuses[resourceKey(spec)] = feed.GetStatus()
+
+		return mt.replicasetPodLogChunk(spec, feed, chunk)
+	})
+	feed.OnStatus(func(status replicaset.ReplicaSetStatus) error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("rs", spec)
+
+		mt.ReplicaSetsStatuses[resourceKey(spec)] = status
+
+		mt.validateLogsContainers(mt.TrackingReplicaSets, "rs", spec, status.Pods)
+		mt.observeStabilityWindowStatus(mt.TrackingReplicaSets, "rs", spec, status.IsReady)
+		mt.observeFollowedResourceReadiness(mt.TrackingReplicaSets, "rs", spec, status.IsReady)
+
+		return nil
+	})
+
+	if err := feed.Track(spec.ResourceName, spec.Namespace, kube, opts.Options); err != nil {
+		return err
+	}
+
+	return mt.trackPostReadinessChecks(kube, "rs", spec, mt.TrackingReplicaSets, opts)
+}
+
+func (mt *multitracker) replicasetAdded(spec MultitrackSpec, feed replicaset.Feed, isReady bool) error {
+	if isReady {
+		mt.displayResourceTrackerMessageF("rs", spec, "appears to be READY")
+
+		if err := mt.checkExpectedRollout(mt.TrackingReplicaSets, "rs", spec); err != nil {
+			return err
+		}
+
+		return mt.handlePostponedResourceReadyCondition(mt.TrackingReplicaSets, "rs", spec)
+	}
+
+	mt.TrackingReplicaSets[resourceKey(spec)].ObservedNotReady = true
+	mt.displayResourceTrackerMessageF("rs", spec, "added")
+
+	return nil
+}
+
+func (mt *multitracker) replicasetReady(spec MultitrackSpec, feed replicaset.Feed) error {
+	if mt.isFollowedResourceReady(mt.TrackingReplicaSets, spec) {
+		return nil
+	}
+
+	mt.displayResourceTrackerMessageF("rs", spec, "become READY")
+
+	if err := mt.checkExpectedRollout(mt.TrackingReplicaSets, "rs", spec); err != nil {
+		return err
+	}
+
+	return mt.handlePostponedResourceReadyCondition(mt.TrackingReplicaSets, "rs", spec)
+}
+
+func (mt *multitracker) replicasetFailed(spec MultitrackSpec, feed replicaset.Feed, reason string) error {
+	mt.displayResourceErrorF("rs", spec, "%s", reason)
+	return mt.handleResourceFailure(mt.TrackingReplicaSets, "rs", spec, reason)
+}
+
+func (mt *multitracker) replicasetEventMsg(spec MultitrackSpec, feed replicaset.Feed, msg string) error {
+	mt.displayResourceEventF("rs", spec, "%s", msg)
+	return nil
+}
+
+func (mt *multitracker) replicasetAddedPod(spec MultitrackSpec, feed replicaset.Feed, pod replicaset.ReplicaSetPod) error {
+	mt.displayResourceTrackerMessageF("rs", spec, "%s added", tracker.FormatResourceID("po", spec.Namespace, pod.Name))
+	return nil
+}
+
+func (mt *multitracker) replicasetPodError(spec MultitrackSpec, feed replicaset.Feed, podError replicaset.ReplicaSetPodError) error {
+	reason := formatPodErrorReason(spec.Namespace, podError.PodName, podError.ContainerError)
+
+	mt.displayResourceErrorF("rs", spec, "%s", reason)
+	mt.runPodErrorHooks("rs", spec, podError.PodName, podError.ContainerName, podError.Message)
+	mt.rememberFailingContainer("rs", spec, podError.PodName, podError.ContainerName)
+
+	return mt.handleResourceFailure(mt.TrackingReplicaSets, "rs", spec, reason)
+}
+
+func (mt *multitracker) replicasetPodLogChunk(spec MultitrackSpec, feed replicaset.Feed, chunk *replicaset.ReplicaSetPodLogChunk) error {
+	if !mt.isPodLogsShown("rs", spec, mt.ReplicaSetsStatuses[resourceKey(spec)].Pods, chunk.PodName) {
+		return nil
+	}
+
+	mt.displayResourceLogChunk("rs", spec, chunk.PodName, chunk.ContainerLogChunk)
+	return nil
+}
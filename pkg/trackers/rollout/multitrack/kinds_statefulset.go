@@ -1,8 +1,7 @@
 package multitrack
 
 import (
-	"fmt"
-
+	"github.com/werf/kubedog/pkg/tracker"
 	"github.com/werf/kubedog/pkg/tracker/replicaset"
 	"github.com/werf/kubedog/pkg/tracker/statefulset"
 	"k8s.io/client-go/kubernetes"
@@ -14,98 +13,137 @@ func (mt *multitracker) TrackStatefulSet(kube kubernetes.Interface, spec Multitr
 	feed.OnAdded(func(isReady bool) error {
 		mt.mux.Lock()
 		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("sts", spec)
 
-		mt.StatefulSetsStatuses[spec.ResourceName] = feed.GetStatus()
+		mt.StatefulSetsStatuses[resourceKey(spec)] = feed.GetStatus()
 
 		return mt.statefulsetAdded(spec, feed, isReady)
 	})
 	feed.OnReady(func() error {
 		mt.mux.Lock()
 		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("sts", spec)
 
-		mt.StatefulSetsStatuses[spec.ResourceName] = feed.GetStatus()
+		mt.StatefulSetsStatuses[resourceKey(spec)] = feed.GetStatus()
 
 		return mt.statefulsetReady(spec, feed)
 	})
 	feed.OnFailed(func(reason string) error {
 		mt.mux.Lock()
 		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("sts", spec)
 
-		mt.StatefulSetsStatuses[spec.ResourceName] = feed.GetStatus()
+		mt.StatefulSetsStatuses[resourceKey(spec)] = feed.GetStatus()
 
 		return mt.statefulsetFailed(spec, feed, reason)
 	})
+	feed.OnDeleted(func() error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("sts", spec)
+
+		return mt.handleResourceDeleted(mt.TrackingStatefulSets, "sts", spec)
+	})
 	feed.OnEventMsg(func(msg string) error {
 		mt.mux.Lock()
 		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("sts", spec)
 
-		mt.StatefulSetsStatuses[spec.ResourceName] = feed.GetStatus()
+		mt.StatefulSetsStatuses[resourceKey(spec)] = feed.GetStatus()
 
 		return mt.statefulsetEventMsg(spec, feed, msg)
 	})
 	feed.OnAddedReplicaSet(func(rs replicaset.ReplicaSet) error {
 		mt.mux.Lock()
 		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("sts", spec)
 
-		mt.StatefulSetsStatuses[spec.ResourceName] = feed.GetStatus()
+		mt.StatefulSetsStatuses[resourceKey(spec)] = feed.GetStatus()
 
 		return mt.statefulsetAddedReplicaSet(spec, feed, rs)
 	})
 	feed.OnAddedPod(func(pod replicaset.ReplicaSetPod) error {
 		mt.mux.Lock()
 		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("sts", spec)
 
-		mt.StatefulSetsStatuses[spec.ResourceName] = feed.GetStatus()
+		mt.StatefulSetsStatuses[resourceKey(spec)] = feed.GetStatus()
 
 		return mt.statefulsetAddedPod(spec, feed, pod)
 	})
 	feed.OnPodError(func(podError replicaset.ReplicaSetPodError) error {
 		mt.mux.Lock()
 		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("sts", spec)
 
-		mt.StatefulSetsStatuses[spec.ResourceName] = feed.GetStatus()
+		mt.StatefulSetsStatuses[resourceKey(spec)] = feed.GetStatus()
 
 		return mt.statefulsetPodError(spec, feed, podError)
 	})
 	feed.OnPodLogChunk(func(chunk *replicaset.ReplicaSetPodLogChunk) error {
 		mt.mux.Lock()
 		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("sts", spec)
 
-		mt.StatefulSetsStatuses[spec.ResourceName] = feed.GetStatus()
+		mt.StatefulSetsStatuses[resourceKey(spec)] = feed.GetStatus()
 
 		return mt.statefulsetPodLogChunk(spec, feed, chunk)
 	})
 	feed.OnStatus(func(status statefulset.StatefulSetStatus) error {
 		mt.mux.Lock()
 		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent("sts", spec)
 
-		mt.StatefulSetsStatuses[spec.ResourceName] = status
+		mt.StatefulSetsStatuses[resourceKey(spec)] = status
+
+		mt.validateLogsContainers(mt.TrackingStatefulSets, "sts", spec, status.Pods)
+		mt.observeStabilityWindowStatus(mt.TrackingStatefulSets, "sts", spec, status.IsReady)
+		mt.observeFollowedResourceReadiness(mt.TrackingStatefulSets, "sts", spec, status.IsReady)
 
 		return nil
 	})
 
-	return feed.Track(spec.ResourceName, spec.Namespace, kube, opts.Options)
+	if err := feed.Track(spec.ResourceName, spec.Namespace, kube, opts.Options); err != nil {
+		return err
+	}
+
+	return mt.trackPostReadinessChecks(kube, "sts", spec, mt.TrackingStatefulSets, opts)
 }
 
 func (mt *multitracker) statefulsetAdded(spec MultitrackSpec, feed statefulset.Feed, isReady bool) error {
 	if isReady {
 		mt.displayResourceTrackerMessageF("sts", spec, "appears to be READY")
 
-		return mt.handleResourceReadyCondition(mt.TrackingStatefulSets, spec)
+		if err := mt.checkExpectedRollout(mt.TrackingStatefulSets, "sts", spec); err != nil {
+			return err
+		}
+
+		return mt.handlePostponedResourceReadyCondition(mt.TrackingStatefulSets, "sts", spec)
 	}
 
+	mt.TrackingStatefulSets[resourceKey(spec)].ObservedNotReady = true
 	mt.displayResourceTrackerMessageF("sts", spec, "added")
 
 	return nil
 }
 
 func (mt *multitracker) statefulsetReady(spec MultitrackSpec, feed statefulset.Feed) error {
+	if mt.isFollowedResourceReady(mt.TrackingStatefulSets, spec) {
+		return nil
+	}
+
 	mt.displayResourceTrackerMessageF("sts", spec, "become READY")
 
-	return mt.handleResourceReadyCondition(mt.TrackingStatefulSets, spec)
+	if err := mt.checkExpectedRollout(mt.TrackingStatefulSets, "sts", spec); err != nil {
+		return err
+	}
+
+	return mt.handlePostponedResourceReadyCondition(mt.TrackingStatefulSets, "sts", spec)
 }
 
 func (mt *multitracker) statefulsetFailed(spec MultitrackSpec, feed statefulset.Feed, reason string) error {
+	reason = mt.withOrdinalStall("sts", spec, reason)
+
 	mt.displayResourceErrorF("sts", spec, "%s", reason)
 	return mt.handleResourceFailure(mt.TrackingStatefulSets, "sts", spec, reason)
 }
@@ -121,26 +159,25 @@ func (mt *multitracker) statefulsetAddedReplicaSet(spec MultitrackSpec, feed sta
 }
 
 func (mt *multitracker) statefulsetAddedPod(spec MultitrackSpec, feed statefulset.Feed, pod replicaset.ReplicaSetPod) error {
-	mt.displayResourceTrackerMessageF("sts", spec, "po/%s added", pod.Name)
+	mt.displayResourceTrackerMessageF("sts", spec, "%s added", tracker.FormatResourceID("po", spec.Namespace, pod.Name))
 	return nil
 }
 
 func (mt *multitracker) statefulsetPodError(spec MultitrackSpec, feed statefulset.Feed, podError replicaset.ReplicaSetPodError) error {
-	reason := fmt.Sprintf("po/%s container/%s: %s", podError.PodName, podError.ContainerName, podError.Message)
+	reason := formatPodErrorReason(spec.Namespace, podError.PodName, podError.ContainerError)
 
 	mt.displayResourceErrorF("sts", spec, "%s", reason)
+	mt.runPodErrorHooks("sts", spec, podError.PodName, podError.ContainerName, podError.Message)
+	mt.rememberFailingContainer("sts", spec, podError.PodName, podError.ContainerName)
 
 	return mt.handleResourceFailure(mt.TrackingStatefulSets, "sts", spec, reason)
 }
 
 func (mt *multitracker) statefulsetPodLogChunk(spec MultitrackSpec, feed statefulset.Feed, chunk *replicaset.ReplicaSetPodLogChunk) error {
-	status := mt.StatefulSetsStatuses[spec.ResourceName]
-	if podStatus, hasKey := status.Pods[chunk.PodName]; hasKey {
-		if podStatus.IsReady {
-			return nil
-		}
+	if !mt.isPodLogsShown("sts", spec, mt.StatefulSetsStatuses[resourceKey(spec)].Pods, chunk.PodName) {
+		return nil
 	}
 
-	mt.displayResourceLogChunk("sts", spec, podContainerLogChunkHeader(chunk.PodName, chunk.ContainerLogChunk), chunk.ContainerLogChunk)
+	mt.displayResourceLogChunk("sts", spec, chunk.PodName, chunk.ContainerLogChunk)
 	return nil
 }
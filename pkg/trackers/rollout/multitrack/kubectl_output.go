@@ -0,0 +1,31 @@
+package multitrack
+
+import "fmt"
+
+// emitKubectlRolloutStatus writes the rollout status of the resource phrased as by kubectl rollout status,
+// when it changed since the previous line. Only Deployments, StatefulSets and DaemonSets are supported by kubectl.
+func (mt *multitracker) emitKubectlRolloutStatus(kind string, spec MultitrackSpec) {
+	var msg string
+	switch kind {
+	case "deploy":
+		msg = mt.DeploymentsStatuses[resourceKey(spec)].RolloutStatusMessage
+	case "sts":
+		msg = mt.StatefulSetsStatuses[resourceKey(spec)].RolloutStatusMessage
+	case "ds":
+		msg = mt.DaemonSetsStatuses[resourceKey(spec)].RolloutStatusMessage
+	}
+
+	if msg == "" {
+		return
+	}
+
+	key := fmt.Sprintf("%s/%s", kind, resourceKey(spec))
+	if mt.lastRolloutStatusMessages[key] == msg {
+		return
+	}
+	mt.lastRolloutStatusMessages[key] = msg
+
+	if _, err := fmt.Fprintln(mt.outputWriter, msg); err != nil && debug() {
+		fmt.Printf("unable to write %s rollout status: %s\n", key, err)
+	}
+}
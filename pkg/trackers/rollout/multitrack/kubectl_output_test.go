@@ -0,0 +1,105 @@
+package multitrack
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/werf/kubedog/pkg/tracker/daemonset"
+	"github.com/werf/kubedog/pkg/tracker/deployment"
+	"github.com/werf/kubedog/pkg/tracker/statefulset"
+)
+
+func int32Ptr(v int32) *int32 {
+	return &v
+}
+
+// TestKubectlRolloutStatusOutput rolls out the Deployment, the StatefulSet and the DaemonSet by the sequences of their objects:
+// a line is written per change of the rollout status of each resource, the same status received again is not repeated.
+func TestKubectlRolloutStatusOutput(t *testing.T) {
+	output := &bytes.Buffer{}
+	mt := newTestMultitracker()
+	mt.output = OutputKubectlRolloutStatus
+	mt.outputWriter = output
+	mt.lastRolloutStatusMessages = make(map[string]string)
+	mt.DeploymentsStatuses = make(map[string]deployment.DeploymentStatus)
+	mt.StatefulSetsStatuses = make(map[string]statefulset.StatefulSetStatus)
+	mt.DaemonSetsStatuses = make(map[string]daemonset.DaemonSetStatus)
+
+	meta := func(name string, generation int64) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: "prod", Generation: generation}
+	}
+	deploy := func(observedGeneration int64, status appsv1.DeploymentStatus) *appsv1.Deployment {
+		status.ObservedGeneration = observedGeneration
+		return &appsv1.Deployment{ObjectMeta: meta("api", 2), Spec: appsv1.DeploymentSpec{Replicas: int32Ptr(3)}, Status: status}
+	}
+	sts := func(strategy appsv1.StatefulSetUpdateStrategyType, status appsv1.StatefulSetStatus) *appsv1.StatefulSet {
+		status.ObservedGeneration = 1
+		return &appsv1.StatefulSet{
+			ObjectMeta: meta("db", 1),
+			Spec:       appsv1.StatefulSetSpec{Replicas: int32Ptr(2), UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: strategy}},
+			Status:     status,
+		}
+	}
+	ds := func(status appsv1.DaemonSetStatus) *appsv1.DaemonSet {
+		status.ObservedGeneration = 1
+		return &appsv1.DaemonSet{
+			ObjectMeta: meta("node-exporter", 1),
+			Spec:       appsv1.DaemonSetSpec{UpdateStrategy: appsv1.DaemonSetUpdateStrategy{Type: appsv1.RollingUpdateDaemonSetStrategyType}},
+			Status:     status,
+		}
+	}
+
+	steps := []interface{}{
+		deploy(1, appsv1.DeploymentStatus{Replicas: 3}),
+		sts(appsv1.RollingUpdateStatefulSetStrategyType, appsv1.StatefulSetStatus{}),
+		deploy(2, appsv1.DeploymentStatus{Replicas: 4, UpdatedReplicas: 1}),
+		ds(appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, UpdatedNumberScheduled: 1}),
+		deploy(2, appsv1.DeploymentStatus{Replicas: 4, UpdatedReplicas: 1}),
+		deploy(2, appsv1.DeploymentStatus{Replicas: 4, UpdatedReplicas: 3}),
+		sts(appsv1.RollingUpdateStatefulSetStrategyType, appsv1.StatefulSetStatus{ReadyReplicas: 1}),
+		deploy(2, appsv1.DeploymentStatus{Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 1}),
+		ds(appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 2}),
+		sts(appsv1.RollingUpdateStatefulSetStrategyType, appsv1.StatefulSetStatus{ReadyReplicas: 2, CurrentReplicas: 2, CurrentRevision: "db-7f9c", UpdateRevision: "db-7f9c"}),
+		ds(appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 3}),
+		deploy(2, appsv1.DeploymentStatus{Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 3}),
+		deploy(2, appsv1.DeploymentStatus{Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 3}),
+		sts(appsv1.OnDeleteStatefulSetStrategyType, appsv1.StatefulSetStatus{ReadyReplicas: 2}),
+	}
+
+	for _, object := range steps {
+		switch object := object.(type) {
+		case *appsv1.Deployment:
+			spec := MultitrackSpec{ResourceName: object.Name, Namespace: object.Namespace}
+			mt.DeploymentsStatuses[resourceKey(spec)] = deployment.NewDeploymentStatus(object, 1, false, "", nil, nil, 0)
+			mt.emitKubectlRolloutStatus("deploy", spec)
+		case *appsv1.StatefulSet:
+			spec := MultitrackSpec{ResourceName: object.Name, Namespace: object.Namespace}
+			mt.StatefulSetsStatuses[resourceKey(spec)] = statefulset.NewStatefulSetStatus(object, 1, false, "", nil, nil, nil, 0)
+			mt.emitKubectlRolloutStatus("sts", spec)
+		case *appsv1.DaemonSet:
+			spec := MultitrackSpec{ResourceName: object.Name, Namespace: object.Namespace}
+			mt.DaemonSetsStatuses[resourceKey(spec)] = daemonset.NewDaemonSetStatus(object, 1, false, "", nil, nil, 0, nil, false)
+			mt.emitKubectlRolloutStatus("ds", spec)
+		}
+	}
+
+	goldenPath := filepath.Join("testdata", "kubectl_output", "rollout_status.golden")
+	if *updateGolden {
+		if err := ioutil.WriteFile(goldenPath, output.Bytes(), 0644); err != nil {
+			t.Fatalf("unable to update golden file: %s", err)
+		}
+	}
+
+	expected, err := ioutil.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("unable to read golden file: %s", err)
+	}
+	if output.String() != string(expected) {
+		t.Errorf("output does not match %s:\n%s", goldenPath, output.String())
+	}
+}
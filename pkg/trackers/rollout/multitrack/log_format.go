@@ -0,0 +1,151 @@
+package multitrack
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"text/template"
+
+	"github.com/werf/kubedog/pkg/display"
+)
+
+// logBlockMaxLines is the number of buffered lines of the container after which its log block is displayed
+// without waiting for the next status report, see LogBlocks.
+const logBlockMaxLines = 1000
+
+// LogLinePrefixData are the fields of the LogLinePrefix template, e.g. "[{{.Resource}} pod/{{.Pod}} ctr/{{.Container}}] ".
+type LogLinePrefixData struct {
+	// Kind is the short kind of the tracked resource: deploy, rs, sts, ds, job or po.
+	Kind string
+	// Resource is the kind and the name of the tracked resource, e.g. deploy/web.
+	Resource string
+	// ID is the canonical identifier of the tracked resource, e.g. deploy/prod/web.
+	ID        string
+	Namespace string
+	Pod       string
+	Container string
+}
+
+type logBlock struct {
+	Source displaySource
+	Lines  []string
+}
+
+func parseLogLinePrefix(text string) (*template.Template, error) {
+	tmpl, err := template.New("LogLinePrefix").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tmpl.Execute(ioutil.Discard, LogLinePrefixData{}); err != nil {
+		return nil, err
+	}
+
+	return tmpl, nil
+}
+
+func validateLogLinePrefixes(specs MultitrackSpecs) error {
+	for _, kindSpecs := range []struct {
+		Kind  string
+		Specs []MultitrackSpec
+	}{
+		{"deploy", specs.Deployments},
+		{"rs", specs.ReplicaSets},
+		{"sts", specs.StatefulSets},
+		{"ds", specs.DaemonSets},
+		{"job", specs.Jobs},
+		{"po", specs.Pods},
+	} {
+		for _, spec := range kindSpecs.Specs {
+			if spec.LogLinePrefix == "" {
+				continue
+			}
+
+			if _, err := parseLogLinePrefix(spec.LogLinePrefix); err != nil {
+				return fmt.Errorf("bad %s/%s spec: invalid LogLinePrefix: %s", kindSpecs.Kind, spec.ResourceName, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// formatLogLinePrefix renders LogLinePrefix of the spec for the container, templates are validated before tracking starts.
+func (mt *multitracker) formatLogLinePrefix(resourceKind string, spec MultitrackSpec, podName, containerName string) string {
+	if spec.LogLinePrefix == "" {
+		return ""
+	}
+
+	tmpl, hasKey := mt.logLinePrefixTemplates[spec.LogLinePrefix]
+	if !hasKey {
+		tmpl, _ = parseLogLinePrefix(spec.LogLinePrefix)
+		mt.logLinePrefixTemplates[spec.LogLinePrefix] = tmpl
+	}
+	if tmpl == nil {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	_ = tmpl.Execute(&buf, LogLinePrefixData{
+		Kind:      resourceKind,
+		Resource:  fmt.Sprintf("%s/%s", resourceKind, spec.ResourceName),
+		ID:        mt.resourceID(resourceKind, spec),
+		Namespace: spec.Namespace,
+		Pod:       podName,
+		Container: containerName,
+	})
+
+	return buf.String()
+}
+
+// formatLogLine prepends the prefix and, with ShowLogTimestamps, the timestamp from the Kubernetes log API to the message.
+func formatLogLine(spec MultitrackSpec, prefix string, logLine display.LogLine) string {
+	if spec.ShowLogTimestamps {
+		return fmt.Sprintf("%s%s %s", prefix, logLine.Timestamp, logLine.Message)
+	}
+	return prefix + logLine.Message
+}
+
+// bufferLogBlock appends the lines to the log block of the source, the block is displayed by flushLogBlocks
+// or right away when it reaches logBlockMaxLines.
+func (mt *multitracker) bufferLogBlock(source displaySource, lines []string) {
+	block, hasKey := mt.logBlocksByHeader[source.Header]
+	if !hasKey {
+		block = &logBlock{Source: source}
+		mt.logBlocksByHeader[source.Header] = block
+		mt.logBlocksHeaders = append(mt.logBlocksHeaders, source.Header)
+	}
+
+	block.Lines = append(block.Lines, lines...)
+
+	if len(block.Lines) >= logBlockMaxLines {
+		mt.displayLogBlock(block)
+		block.Lines = nil
+	}
+}
+
+// flushLogBlocks displays buffered log blocks in the order the containers started logging.
+func (mt *multitracker) flushLogBlocks() {
+	for _, header := range mt.logBlocksHeaders {
+		if block := mt.logBlocksByHeader[header]; len(block.Lines) > 0 {
+			mt.displayLogBlock(block)
+		}
+	}
+
+	mt.logBlocksByHeader = make(map[string]*logBlock)
+	mt.logBlocksHeaders = nil
+}
+
+func (mt *multitracker) displayLogBlock(block *logBlock) {
+	lines := block.Lines
+
+	mt.display.write(block.Source, func() {
+		for _, line := range lines {
+			if mt.outputAdapter != nil {
+				mt.outputAdapter.Line(line)
+			} else {
+				mt.logger.LogF("%s\n", line)
+			}
+		}
+	})
+}
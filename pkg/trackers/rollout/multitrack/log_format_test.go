@@ -0,0 +1,114 @@
+package multitrack
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/werf/kubedog/pkg/display"
+)
+
+func TestFormatLogLine(t *testing.T) {
+	logLine := display.LogLine{Timestamp: "2020-08-04T10:00:01.123456789Z", Message: "listening on :8080"}
+
+	for _, tc := range []struct {
+		kind              string
+		logLinePrefix     string
+		showLogTimestamps bool
+		expected          string
+	}{
+		{kind: "deploy", expected: "listening on :8080"},
+		{kind: "deploy", showLogTimestamps: true, expected: "2020-08-04T10:00:01.123456789Z listening on :8080"},
+		{
+			kind:          "deploy",
+			logLinePrefix: "[{{.Resource}} pod/{{.Pod}} ctr/{{.Container}}] ",
+			expected:      "[deploy/web pod/web-7d4b-x2k ctr/main] listening on :8080",
+		},
+		{
+			kind:              "sts",
+			logLinePrefix:     "{{.ID}} {{.Kind}} {{.Namespace}}: ",
+			showLogTimestamps: true,
+			expected:          "sts/prod/web sts prod: 2020-08-04T10:00:01.123456789Z listening on :8080",
+		},
+	} {
+		mt := newTestMultitracker()
+		mt.logLinePrefixTemplates = make(map[string]*template.Template)
+		spec := MultitrackSpec{ResourceName: "web", Namespace: "prod", LogLinePrefix: tc.logLinePrefix, ShowLogTimestamps: tc.showLogTimestamps}
+
+		prefix := mt.formatLogLinePrefix(tc.kind, spec, "web-7d4b-x2k", "main")
+		if res := formatLogLine(spec, prefix, logLine); res != tc.expected {
+			t.Errorf("%q: expected %q, got %q", tc.logLinePrefix, tc.expected, res)
+		}
+	}
+}
+
+func TestValidateLogLinePrefixes(t *testing.T) {
+	for _, tc := range []struct {
+		logLinePrefix string
+		expectedErr   string
+	}{
+		{logLinePrefix: ""},
+		{logLinePrefix: "[{{.Pod}}/{{.Container}}] "},
+		{logLinePrefix: "[{{.Pod}] ", expectedErr: "bad job/migrate spec: invalid LogLinePrefix: template: LogLinePrefix:1: "},
+		{logLinePrefix: "[{{.Node}}] ", expectedErr: "bad job/migrate spec: invalid LogLinePrefix: template: LogLinePrefix:1:3: executing \"LogLinePrefix\" at <.Node>: can't evaluate field Node in type multitrack.LogLinePrefixData"},
+	} {
+		err := validateLogLinePrefixes(MultitrackSpecs{Jobs: []MultitrackSpec{{ResourceName: "migrate", LogLinePrefix: tc.logLinePrefix}}})
+		switch {
+		case tc.expectedErr == "" && err != nil:
+			t.Errorf("%q: unexpected error: %s", tc.logLinePrefix, err)
+		case tc.expectedErr != "" && (err == nil || !strings.HasPrefix(err.Error(), tc.expectedErr)):
+			t.Errorf("%q: expected error starting with %q, got %v", tc.logLinePrefix, tc.expectedErr, err)
+		}
+	}
+}
+
+// TestLogBlocks buffers the interleaved logs of two containers: each container log is shown as a single block
+// in the order the containers started logging, the block reaching logBlockMaxLines is shown right away.
+func TestLogBlocks(t *testing.T) {
+	mt := newTestMultitracker()
+	mt.logBlocksByHeader = make(map[string]*logBlock)
+	adapter := mt.outputAdapter.(*recordingOutputAdapter)
+
+	web := displaySource{Header: "deploy/web po/web-1 container/main logs"}
+	worker := displaySource{Header: "deploy/worker po/worker-1 container/main logs"}
+
+	mt.bufferLogBlock(worker, []string{"worker 1"})
+	mt.bufferLogBlock(web, []string{"web 1", "web 2"})
+	mt.bufferLogBlock(worker, []string{"worker 2"})
+	if entries := adapter.Entries(); len(entries) != 0 {
+		t.Fatalf("expected log blocks buffered until flush, got %q", entries)
+	}
+
+	mt.flushLogBlocks()
+	mt.flushLogBlocks()
+
+	expected := []string{
+		"begin " + worker.Header, "line worker 1", "line worker 2", "end",
+		"begin " + web.Header, "line web 1", "line web 2",
+	}
+	if entries := adapter.Entries(); strings.Join(entries, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected entries:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(entries, "\n"))
+	}
+
+	var lines []string
+	for i := 0; i < logBlockMaxLines; i++ {
+		lines = append(lines, fmt.Sprintf("web %d", i))
+	}
+	mt.bufferLogBlock(web, lines[:logBlockMaxLines-1])
+	if n := len(adapter.Entries()); n != len(expected) {
+		t.Fatalf("expected the block of %d lines buffered, got %d new entries", logBlockMaxLines-1, n-len(expected))
+	}
+	// The lines are continued in the section of the last shown block
+	mt.bufferLogBlock(web, lines[logBlockMaxLines-1:])
+	if n := len(adapter.Entries()); n != len(expected)+logBlockMaxLines {
+		t.Fatalf("expected the block of %d lines shown right away, got %d new entries", logBlockMaxLines, n-len(expected))
+	}
+
+	// The block shown right away is not shown again on flush
+	mt.flushLogBlocks()
+	checkTestSections(t, adapter.Entries())
+	if n := len(adapter.Entries()); n != len(expected)+logBlockMaxLines {
+		t.Errorf("expected no more entries on flush, got %d", n-len(expected)-logBlockMaxLines)
+	}
+}
@@ -0,0 +1,135 @@
+package multitrack
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/werf/kubedog/pkg/tracker/pod"
+)
+
+// MetricsRecorder receives tracking metrics of the resources, see Metrics option of MultitrackOptions.
+// Package github.com/werf/kubedog/pkg/trackers/rollout/multitrack/metrics implements it with Prometheus collectors,
+// so that multitrack itself does not depend on Prometheus. Methods are called under the multitracker lock and must not block.
+type MetricsRecorder interface {
+	// ResourcePhaseChanged is called when tracking of the resource starts (from is empty), when its phase changes
+	// and when the tracking session ends (to is empty), so that the resources of finished sessions are not counted.
+	ResourcePhaseChanged(resource MetricsResource, from, to ResourcePhase)
+	// ResourceReady is called when the resource becomes ready for the first time with the time since its tracking started.
+	ResourceReady(resource MetricsResource, timeToReady time.Duration)
+	// PodFailure is called on each error of the Pods of the resource, reason is the container waiting reason
+	// (e.g. CrashLoopBackOff), Unschedulable, InitContainerStuck or Other.
+	PodFailure(resource MetricsResource, reason string)
+	// Reconnects is called when the informers of the resource reconnected count more times.
+	Reconnects(resource MetricsResource, count int)
+}
+
+// MetricsResource is the resource the metric is recorded for, Kind is the short kind, e.g. "deploy".
+type MetricsResource struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+type resourceMetricsState struct {
+	Phase   ResourcePhase
+	AddedAt time.Time
+	IsReady bool
+}
+
+func newMetricsResource(kind string, spec MultitrackSpec) MetricsResource {
+	return MetricsResource{Kind: kind, Namespace: spec.Namespace, Name: spec.ResourceName}
+}
+
+// recordResourceAddedMetrics starts recording metrics of the resource, it must be called under handlers mutex.
+func (mt *multitracker) recordResourceAddedMetrics(kind string, spec MultitrackSpec) {
+	if mt.metrics == nil {
+		return
+	}
+
+	key := fmt.Sprintf("%s/%s", kind, resourceKey(spec))
+	mt.resourcesMetricsStates[key] = &resourceMetricsState{Phase: ResourcePhasePending, AddedAt: time.Now()}
+	mt.metrics.ResourcePhaseChanged(newMetricsResource(kind, spec), "", ResourcePhasePending)
+}
+
+// recordResourcePhaseMetrics records the change of the resource phase, it must be called under handlers mutex.
+func (mt *multitracker) recordResourcePhaseMetrics(kind string, spec MultitrackSpec) {
+	if mt.metrics == nil {
+		return
+	}
+
+	state := mt.resourcesMetricsStates[fmt.Sprintf("%s/%s", kind, resourceKey(spec))]
+	if state == nil {
+		return
+	}
+
+	phase := mt.newResourceStatusEvent(kind, spec).Phase
+	if phase == state.Phase {
+		return
+	}
+
+	mt.metrics.ResourcePhaseChanged(newMetricsResource(kind, spec), state.Phase, phase)
+	state.Phase = phase
+
+	if phase == ResourcePhaseReady && !state.IsReady {
+		state.IsReady = true
+		mt.metrics.ResourceReady(newMetricsResource(kind, spec), time.Since(state.AddedAt))
+	}
+}
+
+// releaseResourcesMetrics records the last phases of the resources and removes them from the phase gauges when the session ends.
+func (mt *multitracker) releaseResourcesMetrics() {
+	if mt.metrics == nil {
+		return
+	}
+
+	mt.mux.Lock()
+	defer mt.mux.Unlock()
+
+	for _, kind := range mt.trackedKinds() {
+		for _, key := range sortedSpecsKeys(kind.Specs) {
+			spec := kind.Specs[key]
+			mt.recordResourcePhaseMetrics(kind.Kind, spec)
+
+			metricsKey := fmt.Sprintf("%s/%s", kind.Kind, key)
+			if state := mt.resourcesMetricsStates[metricsKey]; state != nil {
+				mt.metrics.ResourcePhaseChanged(newMetricsResource(kind.Kind, spec), state.Phase, "")
+				delete(mt.resourcesMetricsStates, metricsKey)
+			}
+		}
+	}
+}
+
+// recordPodFailureMetrics records the error of the Pod of the resource, it must be called under handlers mutex.
+func (mt *multitracker) recordPodFailureMetrics(kind string, spec MultitrackSpec, message string) {
+	if mt.metrics == nil {
+		return
+	}
+	mt.metrics.PodFailure(newMetricsResource(kind, spec), podFailureMetricsReason(message))
+}
+
+func (mt *multitracker) recordReconnectsMetrics(kind string, spec MultitrackSpec, count int) {
+	if mt.metrics == nil || count <= 0 {
+		return
+	}
+	mt.metrics.Reconnects(newMetricsResource(kind, spec), count)
+}
+
+// podFailureMetricsReason reduces the Pod error message to the reason with a small set of values,
+// messages contain Pod names and other unique details which would blow up metrics cardinality.
+func podFailureMetricsReason(message string) string {
+	for _, reason := range pod.DefaultContainerFailureReasons {
+		if strings.Contains(message, reason) {
+			return reason
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(message, "unschedulable"):
+		return "Unschedulable"
+	case strings.Contains(message, "has not completed after"):
+		return "InitContainerStuck"
+	}
+
+	return "Other"
+}
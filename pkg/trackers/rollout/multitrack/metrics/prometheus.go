@@ -0,0 +1,146 @@
+// Package metrics implements multitrack.MetricsRecorder with Prometheus collectors.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/werf/kubedog/pkg/tracker/pod"
+	"github.com/werf/kubedog/pkg/trackers/rollout/multitrack"
+)
+
+// otherPodFailureReason is the value of the reason label of the Pod failures with the reasons not known to the recorder.
+const otherPodFailureReason = "Other"
+
+type PrometheusRecorderOptions struct {
+	// Namespace prefixes the names of the metrics, "kubedog" by default.
+	Namespace string
+	// NameLabel adds the resource name label to all metrics. The metrics are labeled only with the kind and namespace
+	// of the resource by default, because names of the resources (e.g. Pods created by Jobs) are unbounded.
+	NameLabel bool
+	// TimeToReadyBuckets of the time to ready histogram in seconds, from 1s to about 68m by default.
+	TimeToReadyBuckets []float64
+}
+
+// PrometheusRecorder exposes the metrics of multitrack sessions:
+//   - <namespace>_multitrack_resources gauge of the resources of the running sessions by phase;
+//   - <namespace>_multitrack_resource_time_to_ready_seconds histogram;
+//   - <namespace>_multitrack_pod_failures_total counter by reason;
+//   - <namespace>_multitrack_reconnects_total counter of the informers reconnects.
+type PrometheusRecorder struct {
+	nameLabel         bool
+	podFailureReasons map[string]bool
+
+	resources   *prometheus.GaugeVec
+	timeToReady *prometheus.HistogramVec
+	podFailures *prometheus.CounterVec
+	reconnects  *prometheus.CounterVec
+}
+
+// NewPrometheusRecorder registers the collectors of the recorder in the registerer, the recorder should be created once
+// and shared by the sessions, e.g. opts.Metrics = recorder.
+func NewPrometheusRecorder(registerer prometheus.Registerer, opts PrometheusRecorderOptions) (*PrometheusRecorder, error) {
+	namespace := opts.Namespace
+	if namespace == "" {
+		namespace = "kubedog"
+	}
+
+	buckets := opts.TimeToReadyBuckets
+	if buckets == nil {
+		buckets = prometheus.ExponentialBuckets(1, 2, 13)
+	}
+
+	labels := []string{"kind", "namespace"}
+	if opts.NameLabel {
+		labels = append(labels, "name")
+	}
+	// withLabel copies labels, appending to labels directly would share its backing array between the metrics
+	withLabel := func(label string) []string {
+		return append(append([]string{}, labels...), label)
+	}
+
+	podFailureReasons := map[string]bool{"Unschedulable": true, "InitContainerStuck": true, otherPodFailureReason: true}
+	for _, reason := range pod.DefaultContainerFailureReasons {
+		podFailureReasons[reason] = true
+	}
+
+	r := &PrometheusRecorder{
+		nameLabel:         opts.NameLabel,
+		podFailureReasons: podFailureReasons,
+		resources: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "multitrack",
+			Name:      "resources",
+			Help:      "Number of the resources tracked by the running multitrack sessions by phase.",
+		}, withLabel("phase")),
+		timeToReady: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "multitrack",
+			Name:      "resource_time_to_ready_seconds",
+			Help:      "Time since tracking of the resource started until it became ready.",
+			Buckets:   buckets,
+		}, labels),
+		podFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "multitrack",
+			Name:      "pod_failures_total",
+			Help:      "Number of the errors of the Pods of the tracked resources by reason.",
+		}, withLabel("reason")),
+		reconnects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "multitrack",
+			Name:      "reconnects_total",
+			Help:      "Number of the reconnects of the informers of the tracked resources.",
+		}, labels),
+	}
+
+	for _, collector := range []prometheus.Collector{r.resources, r.timeToReady, r.podFailures, r.reconnects} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+func (r *PrometheusRecorder) ResourcePhaseChanged(resource multitrack.MetricsResource, from, to multitrack.ResourcePhase) {
+	if from != "" {
+		gauge := r.resources.WithLabelValues(append(r.labelValues(resource), string(from))...)
+		gauge.Dec()
+
+		// Series of the resources of the finished sessions would stay at zero forever with the name label
+		if to == "" && r.nameLabel {
+			r.resources.DeleteLabelValues(append(r.labelValues(resource), string(from))...)
+		}
+	}
+
+	if to != "" {
+		r.resources.WithLabelValues(append(r.labelValues(resource), string(to))...).Inc()
+	}
+}
+
+func (r *PrometheusRecorder) ResourceReady(resource multitrack.MetricsResource, timeToReady time.Duration) {
+	r.timeToReady.WithLabelValues(r.labelValues(resource)...).Observe(timeToReady.Seconds())
+}
+
+// PodFailure counts the failure by reason, the reasons other than the ones multitrack reports (the default container failure reasons,
+// Unschedulable, InitContainerStuck and Other) are counted as Other to keep the cardinality of the reason label bounded.
+func (r *PrometheusRecorder) PodFailure(resource multitrack.MetricsResource, reason string) {
+	if !r.podFailureReasons[reason] {
+		reason = otherPodFailureReason
+	}
+	r.podFailures.WithLabelValues(append(r.labelValues(resource), reason)...).Inc()
+}
+
+func (r *PrometheusRecorder) Reconnects(resource multitrack.MetricsResource, count int) {
+	r.reconnects.WithLabelValues(r.labelValues(resource)...).Add(float64(count))
+}
+
+func (r *PrometheusRecorder) labelValues(resource multitrack.MetricsResource) []string {
+	values := []string{resource.Kind, resource.Namespace}
+	if r.nameLabel {
+		values = append(values, resource.Name)
+	}
+	return values
+}
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/werf/kubedog/pkg/trackers/rollout/multitrack"
+)
+
+func TestPrometheusRecorderLabels(t *testing.T) {
+	for _, tc := range []struct {
+		nameLabel bool
+		expected  map[string][]string
+	}{
+		{
+			nameLabel: false,
+			expected: map[string][]string{
+				"kubedog_multitrack_resources":                      {"kind", "namespace", "phase"},
+				"kubedog_multitrack_resource_time_to_ready_seconds": {"kind", "namespace"},
+				"kubedog_multitrack_pod_failures_total":             {"kind", "namespace", "reason"},
+				"kubedog_multitrack_reconnects_total":               {"kind", "namespace"},
+			},
+		},
+		{
+			nameLabel: true,
+			expected: map[string][]string{
+				"kubedog_multitrack_resources":                      {"kind", "name", "namespace", "phase"},
+				"kubedog_multitrack_resource_time_to_ready_seconds": {"kind", "name", "namespace"},
+				"kubedog_multitrack_pod_failures_total":             {"kind", "name", "namespace", "reason"},
+				"kubedog_multitrack_reconnects_total":               {"kind", "name", "namespace"},
+			},
+		},
+	} {
+		registry := prometheus.NewRegistry()
+		recorder, err := NewPrometheusRecorder(registry, PrometheusRecorderOptions{NameLabel: tc.nameLabel})
+		if err != nil {
+			t.Fatalf("NameLabel=%v: unexpected error: %s", tc.nameLabel, err)
+		}
+
+		resource := multitrack.MetricsResource{Kind: "deploy", Namespace: "default", Name: "app"}
+		recorder.ResourcePhaseChanged(resource, "", multitrack.ResourcePhasePending)
+		recorder.ResourceReady(resource, time.Second)
+		recorder.PodFailure(resource, "CrashLoopBackOff")
+		recorder.Reconnects(resource, 1)
+
+		families := gather(t, registry)
+		for name, expectedLabels := range tc.expected {
+			family, hasKey := families[name]
+			if !hasKey {
+				t.Errorf("NameLabel=%v: metric %s not found", tc.nameLabel, name)
+				continue
+			}
+			if labels := labelNames(family.Metric[0]); !reflect.DeepEqual(labels, expectedLabels) {
+				t.Errorf("NameLabel=%v: metric %s labels %v, expected %v", tc.nameLabel, name, labels, expectedLabels)
+			}
+		}
+	}
+}
+
+func TestPrometheusRecorderPodFailureReason(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	recorder, err := NewPrometheusRecorder(registry, PrometheusRecorderOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	resource := multitrack.MetricsResource{Kind: "deploy", Namespace: "default", Name: "app"}
+	for _, reason := range []string{"CrashLoopBackOff", "Unschedulable", "pod app-1 failed", "pod app-2 failed"} {
+		recorder.PodFailure(resource, reason)
+	}
+
+	reasons := map[string]float64{}
+	for _, metric := range gather(t, registry)["kubedog_multitrack_pod_failures_total"].Metric {
+		for _, label := range metric.Label {
+			if label.GetName() == "reason" {
+				reasons[label.GetValue()] = metric.GetCounter().GetValue()
+			}
+		}
+	}
+
+	expected := map[string]float64{"CrashLoopBackOff": 1, "Unschedulable": 1, "Other": 2}
+	if !reflect.DeepEqual(reasons, expected) {
+		t.Errorf("reasons %v, expected %v", reasons, expected)
+	}
+}
+
+func gather(t *testing.T, registry *prometheus.Registry) map[string]*dto.MetricFamily {
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("unexpected gather error: %s", err)
+	}
+
+	res := make(map[string]*dto.MetricFamily)
+	for _, family := range families {
+		res[family.GetName()] = family
+	}
+	return res
+}
+
+func labelNames(metric *dto.Metric) []string {
+	var res []string
+	for _, label := range metric.Label {
+		res = append(res, label.GetName())
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,121 @@
+package multitrack
+
+import (
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/werf/kubedog/pkg/tracker"
+)
+
+const followTrackerRestartDelay = 5 * time.Second
+
+// Multifollow follows the resources the same way as Multitrack, but never finishes on readiness or failure of the resources:
+// status changes, status reports and logs of all resources are shown until opts.ParentContext is done, then nil is returned.
+// Readiness of the resources is reported each time it changes, e.g. when a new revision rolls out, failures are only reported.
+// Timeouts, ReturnOnReadyResources and the spec options which stop tracking (TrackTimeoutSeconds, ResourceCreationTimeoutSeconds,
+// StabilityWindowSeconds, ExpectFailure, post-readiness checks) and DependsOn are ignored. Status reports are OutputTextDelta unless another Output is set.
+func Multifollow(kube kubernetes.Interface, specs MultitrackSpecs, opts MultitrackOptions) error {
+	for _, kindSpecs := range []*[]MultitrackSpec{&specs.Deployments, &specs.ReplicaSets, &specs.StatefulSets, &specs.DaemonSets, &specs.Jobs, &specs.Pods, &specs.Generic} {
+		followSpecs := make([]MultitrackSpec, 0, len(*kindSpecs))
+		for _, spec := range *kindSpecs {
+			followSpecs = append(followSpecs, newFollowSpec(spec))
+		}
+		*kindSpecs = followSpecs
+	}
+
+	opts.Timeout = 0
+	opts.DeployTimeout = 0
+	opts.ReturnOnReadyResources = nil
+	opts.DetachOnReturn = false
+	if opts.Output == "" || opts.Output == OutputText {
+		opts.Output = OutputTextDelta
+	}
+
+	_, _, err := runMultitrack(kube, specs, opts, true)
+	if opts.ParentContext != nil && opts.ParentContext.Err() != nil && err == opts.ParentContext.Err() {
+		return nil
+	}
+
+	return err
+}
+
+// newFollowSpec returns the spec with the failures ignored and without the options which stop the tracker.
+func newFollowSpec(spec MultitrackSpec) MultitrackSpec {
+	spec.TrackTerminationMode = WaitUntilResourceReady
+	spec.FailMode = IgnoreAndContinueDeployProcess
+	spec.TrackTimeoutSeconds = nil
+	spec.ResourceCreationTimeoutSeconds = nil
+	spec.StabilityWindowSeconds = 0
+	spec.ExpectRolloutStrict = false
+	spec.ExpectFailure = false
+	spec.WithinSeconds = 0
+	spec.ServesWebhook = false
+	spec.ReadinessHTTPCheck = nil
+	spec.VerifyServiceAccountAnnotations = nil
+	spec.VerifyMountedTLSSecrets = false
+	spec.DependsOn = nil
+	return spec
+}
+
+// followResource runs the tracker of the resource again each time it returns until the resource context is done,
+// e.g. after the resource has been deleted and created again. Watches of the running trackers are re-established
+// by their informers from the last seen resourceVersion, so only the tracker which has given up is restarted.
+// The logs of the restarted tracker are shown since the time the previous tracker returned.
+func (mt *multitracker) followResource(kind string, spec MultitrackSpec, mtCtx *multitrackerContext, trackerFunc func(MultitrackSpec, *multitrackerContext) error) error {
+	for {
+		err := mt.callTrackerFunc(spec, mtCtx, trackerFunc)
+		if _, isPanic := err.(*tracker.PanicError); isPanic || mtCtx.Context.Err() != nil {
+			return err
+		}
+
+		stoppedAt := time.Now()
+
+		func() {
+			mt.mux.Lock()
+			defer mt.mux.Unlock()
+
+			if err != nil {
+				mt.displayMultitrackServiceMessageF("%s tracker failed: %s, following again in %s\n", mt.resourceID(kind, spec), err, followTrackerRestartDelay)
+			} else {
+				mt.displayMultitrackServiceMessageF("%s tracker stopped, following again in %s\n", mt.resourceID(kind, spec), followTrackerRestartDelay)
+			}
+		}()
+
+		select {
+		case <-time.After(followTrackerRestartDelay):
+		case <-mtCtx.Context.Done():
+			return mtCtx.Context.Err()
+		}
+
+		mtCtx.LogsFromTime = stoppedAt
+	}
+}
+
+// isFollowedResourceReady returns true for the followed resource which readiness has already been reported,
+// so that the restarted tracker does not report it again.
+func (mt *multitracker) isFollowedResourceReady(resourcesStates map[string]*multitrackerResourceState, spec MultitrackSpec) bool {
+	return mt.isFollowMode && resourcesStates[resourceKey(spec)].Status == ResourceSucceeded
+}
+
+// observeFollowedResourceReadiness reports the changes of the followed resource readiness after it has been ready once,
+// e.g. when a new revision rolls out: trackers report only the statuses of the resource from then on.
+func (mt *multitracker) observeFollowedResourceReadiness(resourcesStates map[string]*multitrackerResourceState, kind string, spec MultitrackSpec, isReady bool) {
+	if !mt.isFollowMode || spec.SuccessCondition != SuccessConditionReady {
+		return
+	}
+
+	state := resourcesStates[resourceKey(spec)]
+
+	switch {
+	case !isReady && state.Status == ResourceSucceeded:
+		state.Status = ResourceActive
+		state.FollowedReadinessLost = true
+		mt.displayResourceTrackerMessageF(kind, spec, "become NOT READY")
+
+	case isReady && state.FollowedReadinessLost:
+		state.FollowedReadinessLost = false
+		mt.displayResourceTrackerMessageF(kind, spec, "become READY")
+		mt.markResourceReady(resourcesStates, kind, spec)
+	}
+}
@@ -4,19 +4,28 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"regexp"
-	"strings"
+	"sort"
 	"sync"
+	"text/template"
 	"time"
 
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 
 	"github.com/werf/logboek/pkg/types"
 
+	"github.com/werf/kubedog/pkg/kube"
 	"github.com/werf/kubedog/pkg/tracker"
 	"github.com/werf/kubedog/pkg/tracker/daemonset"
 	"github.com/werf/kubedog/pkg/tracker/deployment"
+	"github.com/werf/kubedog/pkg/tracker/generic"
 	"github.com/werf/kubedog/pkg/tracker/job"
+	"github.com/werf/kubedog/pkg/tracker/pod"
+	"github.com/werf/kubedog/pkg/tracker/replicaset"
 	"github.com/werf/kubedog/pkg/tracker/statefulset"
 )
 
@@ -35,13 +44,13 @@ const (
 	HopeUntilEndOfDeployProcess       FailMode = "HopeUntilEndOfDeployProcess"
 )
 
-//type DeployCondition string
-//
-//const (
-//	ControllerIsReady DeployCondition = "ControllerIsReady"
-//	PodIsReady        DeployCondition = "PodIsReady"
-//	EndOfDeploy       DeployCondition = "EndOfDeploy"
-//)
+type DeployCondition string
+
+const (
+	ControllerIsReady DeployCondition = "ControllerIsReady"
+	PodIsReady        DeployCondition = "PodIsReady"
+	EndOfDeploy       DeployCondition = "EndOfDeploy"
+)
 
 var (
 	ErrFailWholeDeployProcessImmediately = errors.New("fail whole deploy process immediately")
@@ -49,45 +58,373 @@ var (
 
 type MultitrackSpecs struct {
 	Deployments  []MultitrackSpec
+	ReplicaSets  []MultitrackSpec
 	StatefulSets []MultitrackSpec
 	DaemonSets   []MultitrackSpec
 	Jobs         []MultitrackSpec
+	Pods         []MultitrackSpec
+	// Generic are resources of arbitrary kinds, e.g. custom resources, tracked by their status conditions.
+	// Requires DynamicClient multitrack option.
+	Generic []MultitrackSpec
 }
 
 type MultitrackSpec struct {
 	ResourceName string
 	Namespace    string
 
-	TrackTerminationMode    TrackTerminationMode
-	FailMode                FailMode
-	AllowFailuresCount      *int
+	TrackTerminationMode TrackTerminationMode
+	FailMode             FailMode
+	// AllowFailuresCount is the number of failures tolerated before acting accordingly to FailMode, 1 by default:
+	// 0 means the first failure fails the resource, N means the resource fails on the N+1 failure.
+	AllowFailuresCount *int
+	// AllowFailuresCountPerReplica scales allowed failures count of Deployment, ReplicaSet, StatefulSet or DaemonSet
+	// with its desired replicas, which are re-read when the controller is scaled during tracking, e.g. 1 allows 50 failures
+	// for 50 replicas. Other kinds count as one replica. AllowFailuresCount takes precedence when both are set.
+	AllowFailuresCountPerReplica *int
+	// FailureThresholdSeconds is the time errors of the resource should persist before they are counted as failures,
+	// so that transient errors (e.g. image pull blips) are tolerated until the resource becomes ready.
+	// It also limits the time of the Pods initialization without progress (5 minutes when not set or 0).
+	// Negative value disables both.
 	FailureThresholdSeconds *int
-
+	// OrdinalStallThresholdSeconds is the time the rollout of StatefulSet with OrderedReady Pod management policy may wait
+	// on the same ordinal before the warning naming the blocking Pod is shown, the Pod is also named in the failure reason
+	// of the stalled StatefulSet. 0 means 5 minutes, negative value disables the check.
+	OrdinalStallThresholdSeconds int
+	// FailOnDeleteUpdateStrategy fails StatefulSet with OnDelete update strategy as soon as its Pods are pending the update,
+	// which the controller never does itself. By default the warning is shown and only readiness of the existing Pods is awaited.
+	FailOnDeleteUpdateStrategy bool
+	// StrictDaemonSetAvailability makes DaemonSet ready only when all its desired Pods are updated and available.
+	// By default maxUnavailable Pods of the rolling update are tolerated, and not ready Pods on cordoned or NotReady nodes
+	// are not awaited and do not fail the DaemonSet, they are shown as skipped.
+	StrictDaemonSetAvailability bool
+	// UnschedulableGracePeriodSeconds is the time the Pod of the resource may stay unschedulable (PodScheduled=False
+	// with Unschedulable reason), e.g. while cluster-autoscaler adds nodes, before it is handled as the resource failure.
+	// 0 means 60 seconds, negative value disables the check.
+	UnschedulableGracePeriodSeconds int
+	// StabilityWindowSeconds keeps tracking Deployment, ReplicaSet, StatefulSet, DaemonSet or Pod for the specified time after
+	// it becomes ready: the resource succeeds once the window is over, ready→unready→ready cycles (e.g. container restarts)
+	// within the window are counted. 0 disables the window, it does not apply to the resources with post-readiness checks.
+	StabilityWindowSeconds int
+	// UnstableReadyFlapsCount is the number of ready→unready→ready cycles within StabilityWindowSeconds
+	// from which the outcome of the resource is Unstable, see TreatUnstableAsFailed. 0 means 2, negative value disables it.
+	UnstableReadyFlapsCount int
+
+	// SuccessCondition defines when the resource succeeds, SuccessConditionReady by default.
+	SuccessCondition SuccessCondition
+
+	// Hooks of the resource are called before the hooks of MultitrackOptions, optional.
+	Hooks *MultitrackHooks
+	// TrackTimeoutSeconds limits the time for the resource to become ready since its tracking started,
+	// expiration is handled as the resource failure accordingly to FailMode and AllowFailuresCount.
+	TrackTimeoutSeconds *int
+
+	// WaitForResourceCreation shows that the resource does not exist yet when tracking starts and applies ResourceCreationTimeoutSeconds,
+	// e.g. when manifests are applied asynchronously. Tracking starts once the resource is created in any case.
+	WaitForResourceCreation bool
+	// ResourceCreationTimeoutSeconds limits the time for the resource to be created since its tracking started, expiration
+	// is handled as the resource failure accordingly to FailMode and AllowFailuresCount. Nil means no limit, requires WaitForResourceCreation.
+	ResourceCreationTimeoutSeconds *int
+
+	// TreatDeletionAsSuccess makes the resource deleted during tracking succeed, e.g. a helm hook Job with hook-delete-policy.
+	// By default the deletion is handled as the resource failure "resource was deleted during tracking".
+	TreatDeletionAsSuccess bool
+
+	// ExpectRolloutAfter is the time of the change expected to roll out Deployment, ReplicaSet, StatefulSet or DaemonSet,
+	// e.g. the apply time. When the resource is ready right away, but its latest revision was created before this time,
+	// the warning "no new rollout was observed after the expected change" is shown, or the resource fails with ExpectRolloutStrict.
+	ExpectRolloutAfter  time.Time
+	ExpectRolloutStrict bool
+
+	// LogRegex shows only the log lines matching it, LogRegexByContainerName overrides it for the container,
+	// e.g. regexp.MustCompile("") shows all lines of the container. Each line of multi-line entries is matched separately.
 	LogRegex                *regexp.Regexp
 	LogRegexByContainerName map[string]*regexp.Regexp
 
 	SkipLogs                  bool
 	SkipLogsForContainers     []string
 	ShowLogsOnlyForContainers []string
-	//ShowLogsUntil             DeployCondition TODO
+
+	// TailLines is the number of the last lines of the container log shown when kubedog attaches to the container, nil means all lines.
+	TailLines *int64
+	// MaxLogLinesPerPod and MaxLogBytesPerPod limit the log of each container of the Pod, after the limit is reached
+	// pod.LogOutputTruncatedMessage is shown and the log is not streamed until the container restarts. 0 means no limit.
+	MaxLogLinesPerPod int
+	MaxLogBytesPerPod int64
+	// ShowLogsUntil is the event after which the logs are not shown: PodIsReady stops the logs of each Pod once it is ready,
+	// ControllerIsReady stops the logs of all Pods once the resource is ready and EndOfDeploy streams the logs until the whole
+	// Multitrack call finishes. By default PodIsReady for Deployment, ReplicaSet, StatefulSet and DaemonSet, ControllerIsReady for Job and Pod.
+	ShowLogsUntil DeployCondition
+
+	// LogLinePrefix is the text/template of the prefix of each log line, see LogLinePrefixData,
+	// e.g. "[{{.Resource}} pod/{{.Pod}} ctr/{{.Container}}] ". Prefixed log lines are displayed without section headers.
+	LogLinePrefix string
+	// ShowLogTimestamps prepends the timestamp from the Kubernetes log API to each log line.
+	ShowLogTimestamps bool
 
 	ShowServiceMessages bool
+
+	// ServesWebhook marks Deployment, ReplicaSet, StatefulSet or DaemonSet backing an admission webhook:
+	// the resource is ready only when its Service has ready endpoints (and accepts TLS connections if WebhookTLSCheck is set).
+	ServesWebhook bool
+	// WebhookServiceName is the name of the webhook Service, ResourceName is used by default.
+	WebhookServiceName string
+	WebhookTLSCheck    bool
+
+	// ReadinessHTTPCheck is performed once the Deployment, ReplicaSet, StatefulSet or DaemonSet is ready,
+	// the resource is ready only when the check passes. Requires RestConfig multitrack option.
+	ReadinessHTTPCheck *ReadinessHTTPCheck
+
+	// VerifyServiceAccountAnnotations are checked once the Deployment, ReplicaSet, StatefulSet or DaemonSet is ready:
+	// the service account of its ready Pod should carry these annotations, e.g. "eks.amazonaws.com/role-arn" (IRSA)
+	// or "iam.gke.io/gcp-service-account" (GKE Workload Identity), and the projected token volumes of the Pod should be mounted.
+	// Problems are shown as warnings, StrictServiceAccountVerification handles them as the resource failures accordingly to FailMode.
+	VerifyServiceAccountAnnotations  []string
+	StrictServiceAccountVerification bool
+
+	// VerifyMountedTLSSecrets checks the certificates in tls.crt of the secrets mounted by the Pod template of the ready
+	// Deployment, ReplicaSet, StatefulSet or DaemonSet: expired certificates and the ones expiring within TLSExpiryHorizonSeconds
+	// (7 days when 0, negative value reports only expired certificates) are shown as warnings,
+	// StrictTLSSecretVerification handles them as the resource failures accordingly to FailMode.
+	VerifyMountedTLSSecrets     bool
+	TLSExpiryHorizonSeconds     int
+	StrictTLSSecretVerification bool
+
+	// RequiredReadyPodsCount makes Deployment, StatefulSet or DaemonSet ready as soon as this number of its Pods
+	// of the current revision are ready, even if the rollout is still progressing (e.g. for canary rollouts).
+	// The value not less than the desired replicas count means all Pods should be ready, as well as not set value.
+	RequiredReadyPodsCount *int
+
+	// ExpectFailure inverts the expected outcome: the resource is expected to be rejected and must not become ready.
+	// The expectation is met when the resource fails (with the reason matching ExpectedFailureReasonRegex if set)
+	// or does not become ready within WithinSeconds (0 means until the resource fails).
+	ExpectFailure              bool
+	WithinSeconds              int
+	ExpectedFailureReasonRegex *regexp.Regexp
+
+	// MainContainers of the Job Pods, by default all containers except known sidecars (istio-proxy, linkerd-proxy, etc.).
+	// Job Pods kept running by sidecars after the main containers exited are reported with a warning.
+	MainContainers []string
+	// TreatMainContainerExitAsJobCompletion makes the Job succeeded once the main containers of its Pods exited with zero code
+	// despite sidecars still running, non-zero exit code of the main container fails the Job.
+	TreatMainContainerExitAsJobCompletion bool
+
+	// GroupVersionResource of the Generic resource, e.g. {Group: "cert-manager.io", Version: "v1", Resource: "certificates"}.
+	GroupVersionResource schema.GroupVersionResource
+	// ReadyCondition of the Generic resource status, Ready=True by default.
+	ReadyCondition *generic.ConditionMatch
+	// FailedCondition of the Generic resource status is handled as the resource failure accordingly to FailMode, optional.
+	FailedCondition *generic.ConditionMatch
+
+	// DependsOn are the other tracked resources which must become ready before tracking of this resource starts,
+	// its timeouts and failure thresholds do not run meanwhile. Empty namespace of the reference means the namespace of this resource.
+	// The resource is skipped when its dependency fails.
+	DependsOn []ResourceRef
 }
 
 type MultitrackOptions struct {
 	tracker.Options
+	// StatusProgressPeriod is the period of status progress reports, 5 seconds by default (a second with FastMode).
+	// Negative value disables status progress reports, only the final status progress is shown when tracking is done.
 	StatusProgressPeriod time.Duration
+	// StatusProgressMode controls when status progress is reported, StatusProgressPeriodic by default.
+	// StatusProgressPeriod cannot be set with StatusProgressOnPhaseChange mode.
+	StatusProgressMode StatusProgressMode
+
+	// AsyncOutput enables writing of the output through a bounded queue by a dedicated goroutine,
+	// so that a slow output sink does not block tracking. Output is dropped when the sink cannot keep up,
+	// logs headers are emitted by that goroutine, so they are never dropped apart from the logs lines.
+	AsyncOutput bool
+
+	// OutputAdapter redirects output into the host tool sections instead of logboek, optional.
+	OutputAdapter OutputAdapter
+
+	// Hooks are called on lifecycle events of all resources, optional.
+	Hooks *MultitrackHooks
+
+	// Output is OutputText by default, OutputTextDelta shows only changed resources in periodic status reports.
+	// OutputJSONEvents writes JSON events into OutputWriter (Display or os.Stdout by default),
+	// OutputJSONChunked and OutputJSONChunkedDelta write status reports as NDJSON records.
+	Output       OutputMode
+	OutputWriter io.Writer
+	// RunID is set into every record of OutputJSONChunked reports, random by default.
+	RunID string
+
+	// MergeDuplicateSpecs enables merging of the specs of the same resource: the strictest FailMode,
+	// the lowest AllowFailuresCount and the union of log filters are used. By default duplicates are rejected.
+	MergeDuplicateSpecs bool
+
+	// StartupGracePeriod suppresses failures counting of all resources during the initial period
+	// after Multitrack start, failures are still reported.
+	StartupGracePeriod time.Duration
+
+	// RestConfig is used to port-forward into Pods, required by ReadinessHTTPCheck.
+	RestConfig *rest.Config
+	// RefreshRESTConfig is called when the API server starts rejecting client credentials accepted before, e.g. after
+	// rotation of the projected ServiceAccount token or expiration of the client certificate. When set, the clients
+	// are built from RestConfig and continue tracking with the returned config. Without it Multitrack fails with
+	// ErrCredentialsExpired. RestConfig is required.
+	RefreshRESTConfig func() (*rest.Config, error)
+
+	// DynamicClient is used to watch Generic resources, e.g. kube.DynamicClient.
+	DynamicClient dynamic.Interface
+
+	// QPS and Burst of the client-side rate limiter of the clients built from RestConfig, which is required when they are set.
+	// 0 keeps the limits of RestConfig (client-go defaults are 5 QPS and 10 Burst), negative QPS disables rate limiting.
+	QPS   float32
+	Burst int
+	// DisableSharedInformers makes each tracker open its own watches. By default the trackers of all resources and their Pods
+	// share a single watch per kind and namespace, see tracker.SharedInformers.
+	DisableSharedInformers bool
+
+	// APIUsage is the counter of requests made by kube client, e.g. kube.APIRequests for the clients constructed by kubedog.
+	// When set, the API usage summary is shown when tracking is done and returned in MultitrackResult.
+	APIUsage *kube.APIUsage
+
+	// Metrics receives the metrics of the tracked resources: their phases, time to ready, Pod failures and reconnects,
+	// e.g. metrics.NewPrometheusRecorder. Nil disables metrics.
+	Metrics MetricsRecorder
+
+	// ReturnOnReadyResources makes Multitrack return success as soon as all listed resources are ready.
+	// Failures of other resources before that still follow their FailModes.
+	ReturnOnReadyResources []ResourceRef
+	// DetachOnReturn keeps tracking of the remaining resources in background after the early return,
+	// use MultitrackWithSession to wait for them. By default tracking of the remaining resources is stopped.
+	DetachOnReturn bool
+
+	// DeployTimeout limits the whole Multitrack run regardless of the resources tracking options.
+	// When exceeded, *DeployTimeoutError with not ready resources and their last known statuses is returned.
+	DeployTimeout time.Duration
+
+	// GroupByLabel is the label or annotation key of the live objects (e.g. app.kubernetes.io/instance), which groups
+	// resources in status reports, in the errors and in JSON output. The value is read when tracking starts,
+	// resources without the key fall into the "(ungrouped)" group.
+	GroupByLabel string
+
+	// FailedContainerLogLines is the number of the last lines of the log of the failing container captured when the resource fails,
+	// the lines are included into the returned error, results and JSON output. 0 means 50 lines, negative value disables capturing.
+	FailedContainerLogLines int
+	// PostFailureLogCaptureDuration keeps the log streams of the failed resource open for the specified duration
+	// after the failure is decided, so that the final log lines of the crashing containers are shown before
+	// the error is returned. 0 disables the capture window, it is skipped for resources with SkipLogs.
+	PostFailureLogCaptureDuration time.Duration
+	// MaxConcurrentLogStreams limits the number of the containers log streams open at once by all tracked resources,
+	// 0 means 100 streams, negative value means no limit. When the limit is exceeded, streams of the failing containers
+	// and then of the recently started containers are kept open, other streams are paused with "log stream paused (stream budget)"
+	// line and continue from the paused point once there are free slots. Ignored when tracker.Options.LogStreamsBudget is set.
+	MaxConcurrentLogStreams int
+
+	// WerfAnnotationCompatibility maps the werf tracking annotations of the live objects (werf.io/fail-mode,
+	// werf.io/track-termination-mode, werf.io/log-regex, etc.) onto the spec fields which are not set, see ParseWerfAnnotations.
+	// Each applied annotation is shown as a service message when tracking starts.
+	WerfAnnotationCompatibility bool
+
+	// InfrastructureCheck enables the snapshot of the system workloads when several resources fail
+	// with infrastructural reasons, optional. See InfrastructureCheck.
+	InfrastructureCheck *InfrastructureCheck
+
+	// TreatUnstableAsFailed fails the resources which outcome is Unstable, see StabilityWindowSeconds spec option.
+	// Unstable resources succeed otherwise, their outcome and ready flaps are reported in the result.
+	TreatUnstableAsFailed bool
+
+	// SuppressedEventReasons and SuppressedConditionTypes drop the events and the status conditions of the tracked resources
+	// and their Pods before they are reported or counted as failures, e.g. noisy preemption notices on spot node pools.
+	// Patterns are case-insensitive globs, e.g. "Preempt*". Suppressed counts are shown with KUBEDOG_ROLLOUT_MULTITRACK_DEBUG=1.
+	SuppressedEventReasons   []string
+	SuppressedConditionTypes []string
+
+	// LogBlocks buffers log lines of each container and displays them as a contiguous block under the container header
+	// before each status report (or once the block reaches 1000 lines), so logs of concurrent containers do not interleave.
+	LogBlocks bool
+
+	// StatusVerbosity controls the Pods shown in status progress, StatusVerbosityNormal is used by default.
+	StatusVerbosity StatusVerbosity
+	// Verbosity of the output of this call, VerbosityNormal is used by default. With VerbosityQuiet only the final status report
+	// and failure details are shown, e.g. in CI, VerbosityVerbose adds traces of the watched resources.
+	Verbosity Verbosity
+	// NoColor disables colors of the output of this call regardless of the style of logboek streams,
+	// e.g. when the output of the library is written into a file.
+	NoColor bool
+
+	// SelfCheck validates the internal state of Multitrack after every change: the state of each resource is consistent
+	// with its spec, tracking context and status, counters are not negative. On violation the internal error with
+	// the state dump is shown. It is meant for diagnostics of tracking bugs, always enabled in go test, where violation panics.
+	SelfCheck bool
+
+	// FastMode reduces internal polling intervals for small deploys where tracking latency matters more
+	// than API requests rate: status progress is reported every second by default and post-readiness checks
+	// are repeated more frequently, e.g. the webhook Service is checked every 200ms backing off to 2s
+	// instead of every 2s backing off to 10s, which is up to 5 times more API requests per resource serving webhooks.
+	FastMode bool
 }
 
-func newMultitrackOptions(parentContext context.Context, timeout, statusProgessPeriod time.Duration, logsFromTime time.Time) MultitrackOptions {
+func newMultitrackOptions(parentContext context.Context, spec MultitrackSpec, opts MultitrackOptions) MultitrackOptions {
+	// FailureThresholdSeconds limits the time of the Pods initialization without progress
+	var initContainersStuckTimeout time.Duration
+	if spec.FailureThresholdSeconds != nil {
+		initContainersStuckTimeout = time.Duration(*spec.FailureThresholdSeconds) * time.Second
+	}
+
+	unschedulableTimeout := unschedulableGracePeriod(spec)
+	if unschedulableTimeout == 0 {
+		unschedulableTimeout = -1
+	}
+
+	var requiredReadyPodsCount int
+	if spec.RequiredReadyPodsCount != nil {
+		requiredReadyPodsCount = *spec.RequiredReadyPodsCount
+	}
+
 	return MultitrackOptions{
 		Options: tracker.Options{
-			ParentContext: parentContext,
-			Timeout:       timeout,
-			LogsFromTime:  logsFromTime,
+			ParentContext:           parentContext,
+			Timeout:                 opts.Timeout,
+			LogsFromTime:            opts.LogsFromTime,
+			DeletedPodsHistoryLimit: opts.DeletedPodsHistoryLimit,
+			StringsInterner:         opts.StringsInterner,
+
+			InitContainersStuckTimeout: initContainersStuckTimeout,
+			UnschedulableTimeout:       unschedulableTimeout,
+
+			MainContainers:                        spec.MainContainers,
+			TreatMainContainerExitAsJobCompletion: spec.TreatMainContainerExitAsJobCompletion,
+			FailOnDeleteUpdateStrategy:            spec.FailOnDeleteUpdateStrategy,
+			StrictDaemonSetAvailability:           spec.StrictDaemonSetAvailability,
+
+			RequiredReadyPodsCount: requiredReadyPodsCount,
+
+			EventSeverityOverrides:  opts.EventSeverityOverrides,
+			ContainerFailureReasons: opts.ContainerFailureReasons,
+			Suppressions:            opts.Suppressions,
+			LogStreamsBudget:        opts.LogStreamsBudget,
+			ConnectionRetry:         opts.ConnectionRetry,
+			SharedInformers:         opts.SharedInformers,
+
+			LogsLimits: tracker.LogsLimits{
+				TailLines: spec.TailLines,
+				MaxLines:  spec.MaxLogLinesPerPod,
+				MaxBytes:  spec.MaxLogBytesPerPod,
+			},
+
+			PanicsAreFatal: opts.PanicsAreFatal,
 		},
-		StatusProgressPeriod: statusProgessPeriod,
+		StatusProgressPeriod: opts.StatusProgressPeriod,
+		RestConfig:           opts.RestConfig,
+		DynamicClient:        opts.DynamicClient,
+		FastMode:             opts.FastMode,
+	}
+}
+
+// newSpecMultitrackOptions returns options of the spec tracker, which report the resource status availability into its state.
+func (mt *multitracker) newSpecMultitrackOptions(mtCtx *multitrackerContext, kind string, spec MultitrackSpec, opts MultitrackOptions) MultitrackOptions {
+	resourcesStates := mt.resourcesStatesByKind(kind)
+
+	specOpts := newMultitrackOptions(mtCtx.Context, spec, opts)
+	specOpts.ListObserver = mt.newResourceListObserver(resourcesStates, kind, spec)
+	specOpts.ConnectionRetry.Observer = mt.newResourceConnectionObserver(resourcesStates, kind, spec, opts.ConnectionRetry.Observer)
+	if !mtCtx.LogsFromTime.IsZero() {
+		specOpts.LogsFromTime = mtCtx.LogsFromTime
 	}
+	return specOpts
 }
 
 func setDefaultSpecValues(spec *MultitrackSpec) {
@@ -99,361 +436,349 @@ func setDefaultSpecValues(spec *MultitrackSpec) {
 		spec.FailMode = FailWholeDeployProcessImmediately
 	}
 
-	if spec.AllowFailuresCount == nil {
+	if spec.AllowFailuresCount == nil && spec.AllowFailuresCountPerReplica == nil {
 		spec.AllowFailuresCount = new(int)
 		*spec.AllowFailuresCount = 1
 	}
 
+	if spec.SuccessCondition == "" {
+		spec.SuccessCondition = SuccessConditionReady
+	}
+
 	if spec.FailureThresholdSeconds == nil {
 		spec.FailureThresholdSeconds = new(int)
 		*spec.FailureThresholdSeconds = 0
 	}
 }
 
-func Multitrack(kube kubernetes.Interface, specs MultitrackSpecs, opts MultitrackOptions) error {
-	if len(specs.Deployments)+len(specs.StatefulSets)+len(specs.DaemonSets)+len(specs.Jobs) == 0 {
-		return nil
-	}
-
-	for i := range specs.Deployments {
-		setDefaultSpecValues(&specs.Deployments[i])
-	}
-	for i := range specs.StatefulSets {
-		setDefaultSpecValues(&specs.StatefulSets[i])
-	}
-	for i := range specs.DaemonSets {
-		setDefaultSpecValues(&specs.DaemonSets[i])
-	}
-	for i := range specs.Jobs {
-		setDefaultSpecValues(&specs.Jobs[i])
-	}
-
-	mt := multitracker{
-		DeploymentsSpecs:        make(map[string]MultitrackSpec),
-		DeploymentsContexts:     make(map[string]*multitrackerContext),
-		TrackingDeployments:     make(map[string]*multitrackerResourceState),
-		DeploymentsStatuses:     make(map[string]deployment.DeploymentStatus),
-		PrevDeploymentsStatuses: make(map[string]deployment.DeploymentStatus),
-
-		StatefulSetsSpecs:        make(map[string]MultitrackSpec),
-		StatefulSetsContexts:     make(map[string]*multitrackerContext),
-		TrackingStatefulSets:     make(map[string]*multitrackerResourceState),
-		StatefulSetsStatuses:     make(map[string]statefulset.StatefulSetStatus),
-		PrevStatefulSetsStatuses: make(map[string]statefulset.StatefulSetStatus),
-
-		DaemonSetsSpecs:        make(map[string]MultitrackSpec),
-		DaemonSetsContexts:     make(map[string]*multitrackerContext),
-		TrackingDaemonSets:     make(map[string]*multitrackerResourceState),
-		DaemonSetsStatuses:     make(map[string]daemonset.DaemonSetStatus),
-		PrevDaemonSetsStatuses: make(map[string]daemonset.DaemonSetStatus),
-
-		JobsSpecs:        make(map[string]MultitrackSpec),
-		JobsContexts:     make(map[string]*multitrackerContext),
-		TrackingJobs:     make(map[string]*multitrackerResourceState),
-		JobsStatuses:     make(map[string]job.JobStatus),
-		PrevJobsStatuses: make(map[string]job.JobStatus),
-
-		serviceMessagesByResource: make(map[string][]string),
-	}
-
-	errorChan := make(chan error, 0)
-	doneChan := make(chan struct{}, 0)
+type multitracker struct {
+	DeploymentsSpecs        map[string]MultitrackSpec
+	DeploymentsContexts     map[string]*multitrackerContext
+	TrackingDeployments     map[string]*multitrackerResourceState
+	DeploymentsStatuses     map[string]deployment.DeploymentStatus
+	PrevDeploymentsStatuses map[string]deployment.DeploymentStatus
 
-	var statusProgressChan <-chan time.Time
+	ReplicaSetsSpecs        map[string]MultitrackSpec
+	ReplicaSetsContexts     map[string]*multitrackerContext
+	TrackingReplicaSets     map[string]*multitrackerResourceState
+	ReplicaSetsStatuses     map[string]replicaset.ReplicaSetStatus
+	PrevReplicaSetsStatuses map[string]replicaset.ReplicaSetStatus
 
-	statusProgressPeriod := opts.StatusProgressPeriod
-	if opts.StatusProgressPeriod == 0 {
-		statusProgressPeriod = 5 * time.Second
-	}
+	StatefulSetsSpecs        map[string]MultitrackSpec
+	StatefulSetsContexts     map[string]*multitrackerContext
+	TrackingStatefulSets     map[string]*multitrackerResourceState
+	StatefulSetsStatuses     map[string]statefulset.StatefulSetStatus
+	PrevStatefulSetsStatuses map[string]statefulset.StatefulSetStatus
 
-	if statusProgressPeriod > 0 {
-		statusProgressTicker := time.NewTicker(statusProgressPeriod)
-		defer statusProgressTicker.Stop()
-		statusProgressChan = statusProgressTicker.C
-	} else {
-		statusProgressChan = make(chan time.Time, 0)
-	}
+	DaemonSetsSpecs        map[string]MultitrackSpec
+	DaemonSetsContexts     map[string]*multitrackerContext
+	TrackingDaemonSets     map[string]*multitrackerResourceState
+	DaemonSetsStatuses     map[string]daemonset.DaemonSetStatus
+	PrevDaemonSetsStatuses map[string]daemonset.DaemonSetStatus
 
-	doDisplayStatusProgress := func() error {
-		mt.mux.Lock()
-		defer mt.mux.Unlock()
-		return mt.displayStatusProgress()
-	}
+	JobsSpecs        map[string]MultitrackSpec
+	JobsContexts     map[string]*multitrackerContext
+	TrackingJobs     map[string]*multitrackerResourceState
+	JobsStatuses     map[string]job.JobStatus
+	PrevJobsStatuses map[string]job.JobStatus
 
-	mt.Start(kube, specs, doneChan, errorChan, opts)
+	PodsSpecs        map[string]MultitrackSpec
+	PodsContexts     map[string]*multitrackerContext
+	TrackingPods     map[string]*multitrackerResourceState
+	PodsStatuses     map[string]pod.PodStatus
+	PrevPodsStatuses map[string]pod.PodStatus
 
-	for {
-		select {
-		case <-statusProgressChan:
-			if err := doDisplayStatusProgress(); err != nil {
-				return err
-			}
+	GenericSpecs    map[string]MultitrackSpec
+	GenericContexts map[string]*multitrackerContext
+	TrackingGeneric map[string]*multitrackerResourceState
+	GenericStatuses map[string]generic.GenericStatus
 
-		case <-doneChan:
-			return nil
+	// mux is the handlers mutex: it guards all the maps and fields above and below it. Feed callbacks of the trackers,
+	// timers of the resources and the main loop (status reports, deploy timeout, final result) take it
+	// before any access to the state, so there is no other synchronization of the state.
+	// With SelfCheck option the state invariants are validated on each unlock, see checkStateInvariants.
+	mux                      stateMutex
+	isStateInvariantViolated bool
 
-		case err := <-errorChan:
-			return err
-		}
-	}
+	isTerminating bool
+	// isFollowMode keeps trackers running after the resources are ready or failed, see Multifollow.
+	isFollowMode bool
+
+	// runningTrackers is the number of resources trackers which have not returned yet.
+	runningTrackers int
+	// trackersWG waits for the goroutines of the resources trackers and their timers, see waitTrackers.
+	trackersWG sync.WaitGroup
+	// finishErr is the first error which fails the whole process.
+	finishErr  error
+	finishOnce sync.Once
+	doneChan   chan struct{}
+	errorChan  chan error
+
+	startedAt          time.Time
+	startupGracePeriod time.Duration
+	timeout            time.Duration
+	budget             time.Duration // time budget of the pacing in the status reports, see formatBudgetPacing
+	panicsAreFatal     bool
+	isTimedOut         bool
+
+	returnOnReadyResources []ResourceRef
+	readyGateChan          chan struct{}
+	isReadyGateReached     bool
+
+	// stoppedChan is closed when nobody waits for trackers results anymore.
+	stoppedChan chan struct{}
+
+	kube                       kubernetes.Interface
+	credentialsRefresher       *kube.CredentialsRefresher
+	terminatingPodsByNamespace map[string]terminatingPodsCount
+
+	infrastructureCheck *InfrastructureCheck
+	// infrastructureFailures are the times of the last infrastructural failures by resource ID, see observeInfrastructureFailure.
+	infrastructureFailures        map[string]time.Time
+	isInfrastructureSnapshotTaken bool
+	infrastructureNotes           []string
+
+	statusVerbosity StatusVerbosity
+	verbosity       Verbosity
+	noColor         bool
+	// clusterCapacity explains the stalls of unschedulable Pods, see formatClusterCapacity.
+	clusterCapacity *clusterCapacity
+	// isTLSSecretsCheckForbidden is set once secrets cannot be read with the client permissions, see VerifyMountedTLSSecrets.
+	isTLSSecretsCheckForbidden bool
+
+	// resourcesTraces are the last traced states of kind/key of the resources, see traceResourceStatus
+	resourcesTraces map[string]*resourceTraceState
+
+	hooks       *MultitrackHooks
+	hooksRunner *hooksRunner
+
+	metrics                MetricsRecorder
+	resourcesMetricsStates map[string]*resourceMetricsState
+
+	logger                    types.LoggerInterface
+	outputAdapter             OutputAdapter
+	output                    OutputMode
+	outputWriter              io.Writer
+	lastResourcesStatusEvents map[string]ResourceStatusEvent
+	runID                     string
+	reportSeq                 uint64
+	lastReportedResources     map[string]ResourceStatusEvent
+	lastRolloutStatusMessages map[string]string
+	lastStatusReportDigests   map[string]statusReportDigest
+	// phaseChangedChan is set with StatusProgressOnPhaseChange mode, see notifyResourcePhaseChange
+	phaseChangedChan    chan struct{}
+	lastResourcesPhases map[string]ResourcePhase
+	// statusReportResources are kind/key of the resources shown in the status report being rendered, nil means all
+	statusReportResources     map[string]bool
+	display                   *displaySerializer
+	serviceMessagesByResource map[string][]string
+	// ambiguousNames are kind/name of the resources tracked in several namespaces
+	ambiguousNames map[string]bool
+	groupByLabel   string
+	// resourcesGroups are the groups of kind/key of the resources, see GroupByLabel
+	resourcesGroups map[string]string
+	// eventsAvailability is detected when tracking starts, see detectEventsAvailability
+	eventsAvailability EventsAvailability
+
+	eventSeverityOverrides map[string]Severity
+
+	// failedContainerLogLines is normalized FailedContainerLogLines option
+	failedContainerLogLines       int
+	postFailureLogCaptureDuration time.Duration
+	treatUnstableAsFailed         bool
+
+	logLinePrefixTemplates map[string]*template.Template
+	logBlocks              bool
+	// logBlocksByHeader are the buffered log lines of the containers, see LogBlocks
+	logBlocksByHeader map[string]*logBlock
+	logBlocksHeaders  []string
 }
 
-func (mt *multitracker) Start(kube kubernetes.Interface, specs MultitrackSpecs, doneChan chan struct{}, errorChan chan error, opts MultitrackOptions) {
-	mt.mux.Lock()
-	defer mt.mux.Unlock()
+type multitrackerResourceState struct {
+	FailuresStateMachine
 
-	var wg sync.WaitGroup
+	LogsContainersValidated bool
 
-	for _, spec := range specs.Deployments {
-		mt.DeploymentsContexts[spec.ResourceName] = newMultitrackerContext(opts.ParentContext)
-		mt.DeploymentsSpecs[spec.ResourceName] = spec
-		mt.TrackingDeployments[spec.ResourceName] = newMultitrackerResourceState(spec)
+	// CompletedTargets are the nodes of DaemonSet or the Pods of Deployment which have completed, used by SuccessConditionPodsSucceeded.
+	CompletedTargets map[string]bool
 
-		wg.Add(1)
+	// ObservedNotReady is set once the resource has been reported not ready, used by ExpectRolloutAfter.
+	ObservedNotReady bool
 
-		go mt.runSpecTracker("deploy", spec, mt.DeploymentsContexts[spec.ResourceName], &wg, mt.DeploymentsContexts, doneChan, errorChan, func(spec MultitrackSpec, mtCtx *multitrackerContext) error {
-			return mt.TrackDeployment(kube, spec, newMultitrackOptions(mtCtx.Context, opts.Timeout, opts.StatusProgressPeriod, opts.LogsFromTime))
-		})
-	}
+	// FailingSince is the time of the first failure not yet counted because of FailureThresholdSeconds,
+	// it is reset when the resource becomes ready or its errors are gone.
+	FailingSince time.Time
+	// IsFailingObserved is set once the errors of the resource failing since FailingSince are seen in its status.
+	IsFailingObserved bool
 
-	for _, spec := range specs.StatefulSets {
-		mt.StatefulSetsContexts[spec.ResourceName] = newMultitrackerContext(opts.ParentContext)
-		mt.StatefulSetsSpecs[spec.ResourceName] = spec
-		mt.TrackingStatefulSets[spec.ResourceName] = newMultitrackerResourceState(spec)
+	// OrdinalStallReportedSince is RollingSince of the ordered rollout of StatefulSet, which stall has been reported.
+	OrdinalStallReportedSince time.Time
 
-		wg.Add(1)
+	// FailingPodName and FailingContainerName are of the last Pod error of the resource,
+	// FailedContainerLogs of this container are captured when the resource fails.
+	FailingPodName       string
+	FailingContainerName string
+	FailedContainerLogs  *FailedContainerLogs
+	// LogCaptureUntil is set when the resource has failed and its logs are captured until the time before tracking is stopped,
+	// see PostFailureLogCaptureDuration.
+	LogCaptureUntil time.Time
+	// IsShowingLogsUntilEndOfDeploy is set when the resource is ready and its tracker keeps running only to stream the logs,
+	// see EndOfDeploy. The tracker is cancelled once no other resource holds the Multitrack call.
+	IsShowingLogsUntilEndOfDeploy bool
+	// Stability is set once the resource is ready and its stability window started, see StabilityWindowSeconds.
+	// StabilityUnreadySince is the time the resource became not ready within the window, zero while it is ready.
+	Stability             *ResourceStability
+	StabilityUnreadySince time.Time
 
-		go mt.runSpecTracker("sts", spec, mt.StatefulSetsContexts[spec.ResourceName], &wg, mt.StatefulSetsContexts, doneChan, errorChan, func(spec MultitrackSpec, mtCtx *multitrackerContext) error {
-			return mt.TrackStatefulSet(kube, spec, newMultitrackOptions(mtCtx.Context, opts.Timeout, opts.StatusProgressPeriod, opts.LogsFromTime))
-		})
-	}
+	// ScaleEvents is the scale timeline of Deployment.
+	ScaleEvents []deployment.ScaleEvent
 
-	for _, spec := range specs.DaemonSets {
-		mt.DaemonSetsContexts[spec.ResourceName] = newMultitrackerContext(opts.ParentContext)
-		mt.DaemonSetsSpecs[spec.ResourceName] = spec
-		mt.TrackingDaemonSets[spec.ResourceName] = newMultitrackerResourceState(spec)
+	// InternalError is set when a tracker goroutine of the resource panicked.
+	InternalError *tracker.PanicError
 
-		wg.Add(1)
+	// FirstPacingSample is the progress of the resource when it was first seen, used to estimate its ETA, see formatBudgetPacing.
+	FirstPacingSample *pacingSample
 
-		go mt.runSpecTracker("ds", spec, mt.DaemonSetsContexts[spec.ResourceName], &wg, mt.DaemonSetsContexts, doneChan, errorChan, func(spec MultitrackSpec, mtCtx *multitrackerContext) error {
-			return mt.TrackDaemonSet(kube, spec, newMultitrackOptions(mtCtx.Context, opts.Timeout, opts.StatusProgressPeriod, opts.LogsFromTime))
-		})
-	}
+	// FollowedReadinessLost is set when the followed resource becomes not ready after it has been ready, see Multifollow.
+	FollowedReadinessLost bool
 
-	for _, spec := range specs.Jobs {
-		mt.JobsContexts[spec.ResourceName] = newMultitrackerContext(opts.ParentContext)
-		mt.JobsSpecs[spec.ResourceName] = spec
-		mt.TrackingJobs[spec.ResourceName] = newMultitrackerResourceState(spec)
-
-		wg.Add(1)
-
-		go mt.runSpecTracker("job", spec, mt.JobsContexts[spec.ResourceName], &wg, mt.JobsContexts, doneChan, errorChan, func(spec MultitrackSpec, mtCtx *multitrackerContext) error {
-			return mt.TrackJob(kube, spec, newMultitrackOptions(mtCtx.Context, opts.Timeout, opts.StatusProgressPeriod, opts.LogsFromTime))
-		})
-	}
-
-	if err := mt.applyTrackTerminationMode(); err != nil {
-		errorChan <- fmt.Errorf("unable to apply termination mode: %s", err)
-		return
-	}
+	// StoppedAt is the time the resource tracker returned, e.g. when the resource became ready or failed.
+	StoppedAt time.Time
 
-	go func() {
-		wg.Wait()
+	// HasListSucceeded is set once the list request of the resource succeeded, so that Unauthorized error
+	// afterwards means expired credentials.
+	HasListSucceeded bool
 
-		isAlreadyFailed := func() bool {
-			mt.mux.Lock()
-			defer mt.mux.Unlock()
-			return mt.isFailed
-		}()
-		if isAlreadyFailed {
-			return
-		}
+	StatusAvailability      ResourceStatusAvailability
+	StatusAvailabilitySince time.Time
+	StatusAvailabilityError string
 
-		err := func() error {
-			mt.mux.Lock()
-			defer mt.mux.Unlock()
-			return mt.displayStatusProgress()
-		}()
-		if err != nil {
-			errorChan <- err
-			return
-		}
+	// Connection of the resource informer, see tracker.ConnectionRetry.
+	Connection tracker.ConnectionStats
 
-		if mt.hasFailedTrackingResources() {
-			mt.displayFailedTrackingResourcesServiceMessages()
-			errorChan <- mt.formatFailedTrackingResourcesError()
-		} else {
-			doneChan <- struct{}{}
-		}
-	}()
+	// DependenciesResolved is closed once all DependsOn resources are ready or the resource is skipped, nil without dependencies.
+	DependenciesResolved chan struct{}
 }
 
-func (mt *multitracker) applyTrackTerminationMode() error {
-	if mt.isTerminating {
-		return nil
+func newMultitrackerResourceState(spec MultitrackSpec) *multitrackerResourceState {
+	// Allowed failures count per replica is scaled once the desired replicas are known, see updateAllowFailuresCount
+	allowFailuresCount := 0
+	if spec.AllowFailuresCount != nil {
+		allowFailuresCount = *spec.AllowFailuresCount
+	} else if spec.AllowFailuresCountPerReplica != nil {
+		allowFailuresCount = *spec.AllowFailuresCountPerReplica
 	}
 
-	shouldContinueTracking := func(name string, spec MultitrackSpec) bool {
-		switch spec.TrackTerminationMode {
-		case WaitUntilResourceReady:
-			// There is at least one active context with wait mode,
-			// so continue tracking without stopping any contexts
-			return true
-
-		case NonBlocking:
-			return false
-
-		default:
-			panic(fmt.Sprintf("unknown TrackTerminationMode %#v", spec.TrackTerminationMode))
-		}
+	return &multitrackerResourceState{
+		FailuresStateMachine:    NewFailuresStateMachine(spec.FailMode, allowFailuresCount),
+		StatusAvailability:      ResourceStatusUnavailable,
+		StatusAvailabilitySince: time.Now(),
+		CompletedTargets:        make(map[string]bool),
 	}
+}
 
-	var contextsToStop []*multitrackerContext
+// multitrackerKind groups specs, states and tracking contexts of the tracked resources of the same kind.
+type multitrackerKind struct {
+	Kind     string
+	Specs    map[string]MultitrackSpec
+	States   map[string]*multitrackerResourceState
+	Contexts map[string]*multitrackerContext
+}
 
-	for name, ctx := range mt.DeploymentsContexts {
-		if shouldContinueTracking(name, mt.DeploymentsSpecs[name]) {
-			return nil
-		}
-		contextsToStop = append(contextsToStop, ctx)
+// trackedKinds returns tracked resources grouped by kind in the order used in all reports.
+func (mt *multitracker) trackedKinds() []multitrackerKind {
+	return []multitrackerKind{
+		{"po", mt.PodsSpecs, mt.TrackingPods, mt.PodsContexts},
+		{"deploy", mt.DeploymentsSpecs, mt.TrackingDeployments, mt.DeploymentsContexts},
+		{"rs", mt.ReplicaSetsSpecs, mt.TrackingReplicaSets, mt.ReplicaSetsContexts},
+		{"sts", mt.StatefulSetsSpecs, mt.TrackingStatefulSets, mt.StatefulSetsContexts},
+		{"ds", mt.DaemonSetsSpecs, mt.TrackingDaemonSets, mt.DaemonSetsContexts},
+		{"job", mt.JobsSpecs, mt.TrackingJobs, mt.JobsContexts},
+		{"generic", mt.GenericSpecs, mt.TrackingGeneric, mt.GenericContexts},
 	}
-	for name, ctx := range mt.StatefulSetsContexts {
-		if shouldContinueTracking(name, mt.StatefulSetsSpecs[name]) {
-			return nil
-		}
-		contextsToStop = append(contextsToStop, ctx)
-	}
-	for name, ctx := range mt.DaemonSetsContexts {
-		if shouldContinueTracking(name, mt.DaemonSetsSpecs[name]) {
-			return nil
-		}
-		contextsToStop = append(contextsToStop, ctx)
-	}
-	for name, ctx := range mt.JobsContexts {
-		if shouldContinueTracking(name, mt.JobsSpecs[name]) {
-			return nil
-		}
-		contextsToStop = append(contextsToStop, ctx)
-	}
-
-	mt.isTerminating = true
+}
 
-	for _, ctx := range contextsToStop {
-		ctx.CancelFunc()
+func (mt *multitracker) resourcesStatesByKind(kind string) map[string]*multitrackerResourceState {
+	for _, k := range mt.trackedKinds() {
+		if k.Kind == kind {
+			return k.States
+		}
 	}
-
-	return nil
+	panic(fmt.Sprintf("unknown resource kind %q", kind))
 }
 
-func (mt *multitracker) runSpecTracker(kind string, spec MultitrackSpec, mtCtx *multitrackerContext, wg *sync.WaitGroup, contexts map[string]*multitrackerContext, doneChan chan struct{}, errorChan chan error, trackerFunc func(MultitrackSpec, *multitrackerContext) error) {
-	defer wg.Done()
-
-	err := trackerFunc(spec, mtCtx)
-
-	mt.mux.Lock()
-	defer mt.mux.Unlock()
-
-	delete(contexts, spec.ResourceName)
+// resourceKey identifies the resource in the maps of its kind, resources of the same name may be tracked in different namespaces.
+func resourceKey(spec MultitrackSpec) string {
+	return fmt.Sprintf("%s/%s", spec.Namespace, spec.ResourceName)
+}
 
-	if err == ErrFailWholeDeployProcessImmediately {
-		mt.displayFailedTrackingResourcesServiceMessages()
-		errorChan <- mt.formatFailedTrackingResourcesError()
-		mt.isFailed = true
-		return
-	} else if err == context.Canceled {
-		return
-	} else if err != nil {
-		// unknown error
-		errorChan <- fmt.Errorf("%s/%s track failed: %s", kind, spec.ResourceName, err)
-		mt.isFailed = true
-		return
+// formatResourceName returns the name of the resource for the status tables, qualified with the namespace (e.g. "web (ns: prod)")
+// when resources of the same kind and name are tracked in several namespaces.
+func (mt *multitracker) formatResourceName(kind string, spec MultitrackSpec) string {
+	if mt.ambiguousNames[fmt.Sprintf("%s/%s", kind, spec.ResourceName)] {
+		return fmt.Sprintf("%s (ns: %s)", spec.ResourceName, spec.Namespace)
 	}
+	return spec.ResourceName
+}
 
-	if err := mt.applyTrackTerminationMode(); err != nil {
-		errorChan <- fmt.Errorf("unable to apply termination mode: %s", err)
-		mt.isFailed = true
-		return
+// unschedulableGracePeriod returns UnschedulableGracePeriodSeconds of the spec, 0 means the check is disabled.
+func unschedulableGracePeriod(spec MultitrackSpec) time.Duration {
+	switch {
+	case spec.UnschedulableGracePeriodSeconds < 0:
+		return 0
+	case spec.UnschedulableGracePeriodSeconds == 0:
+		return pod.DefaultUnschedulableTimeout
+	default:
+		return time.Duration(spec.UnschedulableGracePeriodSeconds) * time.Second
 	}
 }
 
-type multitracker struct {
-	DeploymentsSpecs        map[string]MultitrackSpec
-	DeploymentsContexts     map[string]*multitrackerContext
-	TrackingDeployments     map[string]*multitrackerResourceState
-	DeploymentsStatuses     map[string]deployment.DeploymentStatus
-	PrevDeploymentsStatuses map[string]deployment.DeploymentStatus
-
-	StatefulSetsSpecs        map[string]MultitrackSpec
-	StatefulSetsContexts     map[string]*multitrackerContext
-	TrackingStatefulSets     map[string]*multitrackerResourceState
-	StatefulSetsStatuses     map[string]statefulset.StatefulSetStatus
-	PrevStatefulSetsStatuses map[string]statefulset.StatefulSetStatus
-
-	DaemonSetsSpecs        map[string]MultitrackSpec
-	DaemonSetsContexts     map[string]*multitrackerContext
-	TrackingDaemonSets     map[string]*multitrackerResourceState
-	DaemonSetsStatuses     map[string]daemonset.DaemonSetStatus
-	PrevDaemonSetsStatuses map[string]daemonset.DaemonSetStatus
-
-	JobsSpecs        map[string]MultitrackSpec
-	JobsContexts     map[string]*multitrackerContext
-	TrackingJobs     map[string]*multitrackerResourceState
-	JobsStatuses     map[string]job.JobStatus
-	PrevJobsStatuses map[string]job.JobStatus
-
-	mux sync.Mutex
-
-	isFailed      bool
-	isTerminating bool
-
-	displayCalled             bool
-	currentLogProcessHeader   string
-	currentLogProcess         types.LogProcessInterface
-	serviceMessagesByResource map[string][]string
+// formatPodErrorReason returns the failure reason of the Pod error, e.g. "po/prod/api-x container/app: CrashLoopBackOff: ..."
+// or "po/prod/api-x: unschedulable for 2m: 0/12 nodes are available: ..." for the errors of the Pod itself.
+func formatPodErrorReason(namespace, podName string, containerError pod.ContainerError) string {
+	if containerError.ContainerName == "" {
+		return fmt.Sprintf("%s: %s", tracker.FormatResourceID("po", namespace, podName), containerError.Message)
+	}
+	return fmt.Sprintf("%s container/%s: %s", tracker.FormatResourceID("po", namespace, podName), containerError.ContainerName, containerError.Message)
 }
 
-type multitrackerContext struct {
-	Context    context.Context
-	CancelFunc context.CancelFunc
+// resourceID returns the canonical identifier of the resource for the messages, e.g. "deploy/prod/web".
+func (mt *multitracker) resourceID(kind string, spec MultitrackSpec) string {
+	return tracker.FormatResourceID(kind, spec.Namespace, spec.ResourceName)
 }
 
-func newMultitrackerContext(parentContext context.Context) *multitrackerContext {
-	if parentContext == nil {
-		parentContext = context.Background()
+// findAmbiguousNames adds kind/name of the specs with the same name in several namespaces into ambiguousNames.
+func findAmbiguousNames(kind string, specs []MultitrackSpec, ambiguousNames map[string]bool) {
+	namespacesByName := make(map[string]string)
+	for _, spec := range specs {
+		if namespace, hasKey := namespacesByName[spec.ResourceName]; hasKey && namespace != spec.Namespace {
+			ambiguousNames[fmt.Sprintf("%s/%s", kind, spec.ResourceName)] = true
+		}
+		namespacesByName[spec.ResourceName] = spec.Namespace
 	}
-	ctx, cancel := context.WithCancel(parentContext)
-	return &multitrackerContext{Context: ctx, CancelFunc: cancel}
 }
 
-type multitrackerResourceStatus string
-
-const (
-	resourceActive            multitrackerResourceStatus = "resourceActive"
-	resourceSucceeded         multitrackerResourceStatus = "resourceSucceeded"
-	resourceFailed            multitrackerResourceStatus = "resourceFailed"
-	resourceHoping            multitrackerResourceStatus = "resourceHoping"
-	resourceActiveAfterHoping multitrackerResourceStatus = "resourceActiveAfterHoping"
-)
+// sortedSpecsKeys returns keys of the specs ordered by namespace, then by name.
+func sortedSpecsKeys(specs map[string]MultitrackSpec) []string {
+	keys := make([]string, 0, len(specs))
+	for key := range specs {
+		keys = append(keys, key)
+	}
 
-type multitrackerResourceState struct {
-	Status                   multitrackerResourceStatus
-	FailedReason             string
-	FailuresCount            int
-	FailuresCountAfterHoping int
-}
+	sort.Slice(keys, func(i, j int) bool {
+		if specs[keys[i]].Namespace != specs[keys[j]].Namespace {
+			return specs[keys[i]].Namespace < specs[keys[j]].Namespace
+		}
+		return specs[keys[i]].ResourceName < specs[keys[j]].ResourceName
+	})
 
-func newMultitrackerResourceState(spec MultitrackSpec) *multitrackerResourceState {
-	return &multitrackerResourceState{Status: resourceActive}
+	return keys
 }
 
 func (mt *multitracker) hasFailedTrackingResources() bool {
-	for _, states := range []map[string]*multitrackerResourceState{
-		mt.TrackingDeployments,
-		mt.TrackingStatefulSets,
-		mt.TrackingDaemonSets,
-		mt.TrackingJobs,
-	} {
-		for _, state := range states {
-			if state.Status == resourceFailed {
+	for _, kind := range mt.trackedKinds() {
+		for _, state := range kind.States {
+			if state.Status == ResourceFailed {
 				return true
 			}
 		}
@@ -462,128 +787,83 @@ func (mt *multitracker) hasFailedTrackingResources() bool {
 }
 
 func (mt *multitracker) formatFailedTrackingResourcesError() error {
-	msgParts := []string{}
+	err := &FailedResourcesError{InfrastructureNotes: mt.infrastructureNotes}
 
-	for name, state := range mt.TrackingDeployments {
-		if state.Status != resourceFailed {
-			continue
-		}
-		msgParts = append(msgParts, fmt.Sprintf("deploy/%s failed: %s", name, state.FailedReason))
-	}
-	for name, state := range mt.TrackingStatefulSets {
-		if state.Status != resourceFailed {
-			continue
-		}
-		msgParts = append(msgParts, fmt.Sprintf("sts/%s failed: %s", name, state.FailedReason))
-	}
-	for name, state := range mt.TrackingDaemonSets {
-		if state.Status != resourceFailed {
-			continue
-		}
-		msgParts = append(msgParts, fmt.Sprintf("ds/%s failed: %s", name, state.FailedReason))
-	}
-	for name, state := range mt.TrackingJobs {
-		if state.Status != resourceFailed {
-			continue
+	for _, kind := range mt.trackedKinds() {
+		for _, key := range sortedSpecsKeys(kind.Specs) {
+			state := kind.States[key]
+			if state.Status != ResourceFailed {
+				continue
+			}
+
+			err.Failures = append(err.Failures, ResourceFailure{
+				Kind:      kind.Kind,
+				Namespace: kind.Specs[key].Namespace,
+				Name:      kind.Specs[key].ResourceName,
+				Group:     mt.resourceGroup(kind.Kind, key),
+				Code:      ClassifyFailedReason(state.FailedReason),
+				Reason:    state.FailedReason,
+				Logs:      state.FailedContainerLogs,
+
+				SuspectedInfrastructure: mt.isInfrastructureSnapshotTaken && isInfrastructureFailure(state.FailedReason),
+			})
 		}
-		msgParts = append(msgParts, fmt.Sprintf("job/%s failed: %s", name, state.FailedReason))
 	}
 
-	return fmt.Errorf("%s", strings.Join(msgParts, "\n"))
-}
-
-func (mt *multitracker) handleResourceReadyCondition(resourcesStates map[string]*multitrackerResourceState, spec MultitrackSpec) error {
-	resourcesStates[spec.ResourceName].Status = resourceSucceeded
-	return tracker.StopTrack
+	return err
 }
 
-func (mt *multitracker) handleResourceFailure(resourcesStates map[string]*multitrackerResourceState, kind string, spec MultitrackSpec, reason string) error {
-	switch spec.FailMode {
-	case FailWholeDeployProcessImmediately:
-		resourcesStates[spec.ResourceName].FailuresCount++
-
-		if resourcesStates[spec.ResourceName].FailuresCount <= *spec.AllowFailuresCount {
-			mt.displayMultitrackServiceMessageF("%d/%d allowed errors occurred for %s/%s: continue tracking\n", resourcesStates[spec.ResourceName].FailuresCount, *spec.AllowFailuresCount, kind, spec.ResourceName)
-			return nil
-		}
-
-		mt.displayMultitrackServiceMessageF("Allowed failures count for %s/%s exceeded %d errors: stop tracking immediately!\n", kind, spec.ResourceName, *spec.AllowFailuresCount)
-
-		resourcesStates[spec.ResourceName].Status = resourceFailed
-		resourcesStates[spec.ResourceName].FailedReason = reason
-
-		return ErrFailWholeDeployProcessImmediately
-
-	case HopeUntilEndOfDeployProcess:
-
-	handleResourceState:
-		switch resourcesStates[spec.ResourceName].Status {
-		case resourceActive:
-			resourcesStates[spec.ResourceName].Status = resourceHoping
-			goto handleResourceState
-
-		case resourceHoping:
-			activeResourcesNames := mt.getActiveResourcesNames()
-			if len(activeResourcesNames) > 0 {
-				mt.displayMultitrackServiceMessageF("Error occurred for %s/%s, waiting until following resources are ready before counting errors (HopeUntilEndOfDeployProcess fail mode is active): %s\n", kind, spec.ResourceName, strings.Join(activeResourcesNames, ", "))
-				return nil
-			}
-
-			resourcesStates[spec.ResourceName].Status = resourceActiveAfterHoping
-			goto handleResourceState
-
-		case resourceActiveAfterHoping:
-			resourcesStates[spec.ResourceName].FailuresCount++
-
-			if resourcesStates[spec.ResourceName].FailuresCount <= *spec.AllowFailuresCount {
-				mt.displayMultitrackServiceMessageF("%d/%d allowed errors occurred for %s/%s: continue tracking\n", resourcesStates[spec.ResourceName].FailuresCount, *spec.AllowFailuresCount, kind, spec.ResourceName)
-				return nil
-			}
+func (mt *multitracker) handleResourceReadyCondition(resourcesStates map[string]*multitrackerResourceState, kind string, spec MultitrackSpec) error {
+	if spec.ExpectFailure {
+		return mt.handleExpectedFailureReadyCondition(resourcesStates, kind, spec)
+	}
 
-			mt.displayMultitrackServiceMessageF("Allowed failures count for %s/%s exceeded %d errors: stop tracking immediately!\n", kind, spec.ResourceName, *spec.AllowFailuresCount)
+	if !resourcesStates[resourceKey(spec)].LogCaptureUntil.IsZero() || resourcesStates[resourceKey(spec)].IsShowingLogsUntilEndOfDeploy {
+		return nil
+	}
 
-			resourcesStates[spec.ResourceName].Status = resourceFailed
-			resourcesStates[spec.ResourceName].FailedReason = reason
+	if mt.isFollowedResourceReady(resourcesStates, spec) {
+		return nil
+	}
 
-			return ErrFailWholeDeployProcessImmediately
+	if window := stabilityWindow(kind, spec); window > 0 {
+		return mt.startStabilityWindow(resourcesStates, kind, spec, window)
+	}
 
-		default:
-			panic(fmt.Sprintf("%s/%s tracker is in unexpected state %#v", kind, spec.ResourceName, resourcesStates[spec.ResourceName].Status))
-		}
+	mt.markResourceReady(resourcesStates, kind, spec)
 
-	case IgnoreAndContinueDeployProcess:
-		resourcesStates[spec.ResourceName].FailuresCount++
-		mt.displayMultitrackServiceMessageF("%d errors occurred for %s/%s\n", resourcesStates[spec.ResourceName].FailuresCount, kind, spec.ResourceName)
+	if mt.isFollowMode {
 		return nil
+	}
 
-	default:
-		panic(fmt.Sprintf("bad fail mode %#v for resource %s/%s", spec.FailMode, kind, spec.ResourceName))
+	if isLogsShownUntilEndOfDeploy(kind, spec) {
+		return mt.showLogsUntilEndOfDeploy(resourcesStates, kind, spec)
 	}
 
-	return nil
+	return tracker.StopTrack
+}
+
+func (mt *multitracker) markResourceReady(resourcesStates map[string]*multitrackerResourceState, kind string, spec MultitrackSpec) {
+	if hopedReason := resourcesStates[resourceKey(spec)].HopedReason; hopedReason != "" {
+		mt.displayResourceTrackerMessageF(kind, spec, "recovered from postponed error: %s", hopedReason)
+	}
+	resourcesStates[resourceKey(spec)].Recover()
+	resourcesStates[resourceKey(spec)].Status = ResourceSucceeded
+	resourcesStates[resourceKey(spec)].resetFailingSince()
+	mt.resolveDependencies()
+	mt.checkReadyGate()
+	mt.runResourceReadyHooks(kind, spec)
 }
 
 func (mt *multitracker) getActiveResourcesNames() []string {
 	activeResources := []string{}
 
-	for name, state := range mt.TrackingDeployments {
-		if state.Status == resourceActive {
-			activeResources = append(activeResources, fmt.Sprintf("deploy/%s", name))
-		}
-	}
-	for name, state := range mt.TrackingStatefulSets {
-		if state.Status == resourceActive {
-			activeResources = append(activeResources, fmt.Sprintf("sts/%s", name))
-		}
-	}
-	for name, state := range mt.TrackingDaemonSets {
-		if state.Status == resourceActive {
-			activeResources = append(activeResources, fmt.Sprintf("ds/%s", name))
-		}
-	}
-	for name, state := range mt.TrackingJobs {
-		if state.Status == resourceActive {
-			activeResources = append(activeResources, fmt.Sprintf("job/%s", name))
+	for _, kind := range mt.trackedKinds() {
+		for _, key := range sortedSpecsKeys(kind.Specs) {
+			// Resources waiting for dependencies cannot become ready before the hoping ones do
+			if kind.States[key].Status == ResourceActive && kind.States[key].StatusAvailability != ResourceStatusWaitingForDependencies {
+				activeResources = append(activeResources, mt.resourceID(kind.Kind, kind.Specs[key]))
+			}
 		}
 	}
 
@@ -0,0 +1,358 @@
+package multitrack
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/werf/kubedog/pkg/display"
+	"github.com/werf/kubedog/pkg/tracker"
+)
+
+// testMultitrackTimeout fails the test instead of hanging when Multitrack deadlocks.
+const testMultitrackTimeout = 30 * time.Second
+
+// runTestMultitrack runs MultitrackWithResult with the output captured and the options which need a real cluster disabled.
+func runTestMultitrack(t *testing.T, kube kubernetes.Interface, specs MultitrackSpecs, opts MultitrackOptions) (MultitrackResult, string, error) {
+	t.Helper()
+
+	output := &bytes.Buffer{}
+	if opts.Display == nil {
+		opts.Display = display.NewDisplay(output, nil)
+	}
+	if opts.FailedContainerLogLines == 0 {
+		opts.FailedContainerLogLines = -1
+	}
+
+	type multitrackResult struct {
+		result MultitrackResult
+		err    error
+	}
+	done := make(chan multitrackResult, 1)
+	go func() {
+		result, err := MultitrackWithResult(kube, specs, opts)
+		done <- multitrackResult{result: result, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.result, output.String(), res.err
+	case <-time.After(testMultitrackTimeout):
+		t.Fatalf("Multitrack has not returned within %s", testMultitrackTimeout)
+		return MultitrackResult{}, "", nil
+	}
+}
+
+// newTestPod returns the pending Pod. The fake clientset ignores field selectors, so the Pod trackers watching
+// the same namespace receive the events of all its Pods: Pods tracked together should be put in different namespaces.
+func newTestPod(namespace, name string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, CreationTimestamp: metav1.Now()},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyAlways,
+			Containers:    []corev1.Container{{Name: "main", Image: "app"}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodPending},
+	}
+}
+
+// setTestPodReady sets the Ready condition of the Pod, container statuses are not set, so that the Pod tracker
+// does not stream the container logs, which the fake clientset cannot serve.
+func setTestPodReady(pod *corev1.Pod) *corev1.Pod {
+	pod = pod.DeepCopy()
+	pod.Status.Phase = corev1.PodRunning
+	pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+	return pod
+}
+
+func setTestPodFailed(pod *corev1.Pod) *corev1.Pod {
+	pod = pod.DeepCopy()
+	pod.Spec.RestartPolicy = corev1.RestartPolicyNever
+	pod.Status.Phase = corev1.PodFailed
+	pod.Status.Reason = "Error"
+	return pod
+}
+
+func updateTestPodStatus(t *testing.T, kube kubernetes.Interface, pod *corev1.Pod) {
+	if _, err := kube.CoreV1().Pods(pod.Namespace).UpdateStatus(context.Background(), pod, metav1.UpdateOptions{}); err != nil {
+		t.Errorf("unexpected error updating Pod %s: %s", pod.Name, err)
+	}
+}
+
+func setTestPodContainerWaiting(pod *corev1.Pod, reason string) *corev1.Pod {
+	pod = pod.DeepCopy()
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "main",
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason, Message: "back-off restarting failed container"}},
+	}}
+	return pod
+}
+
+// runTestPodsUpdates updates the statuses of the Pods concurrently: each Pod flaps between the statuses returned by flap,
+// then its final status is updated until the returned stop function is called, because the fake clientset
+// loses the events between the list and the watch of the trackers.
+func runTestPodsUpdates(t *testing.T, kube kubernetes.Interface, pods []*corev1.Pod, flap func(i, step int, pod *corev1.Pod) *corev1.Pod, final func(i int, pod *corev1.Pod) *corev1.Pod) func() {
+	stopUpdates := make(chan struct{})
+	var wg sync.WaitGroup
+
+	for i, pod := range pods {
+		wg.Add(1)
+		go func(i int, pod *corev1.Pod) {
+			defer wg.Done()
+
+			for step := 0; step < 10; step++ {
+				updateTestPodStatus(t, kube, flap(i, step, pod))
+				time.Sleep(time.Millisecond)
+			}
+
+			for {
+				updateTestPodStatus(t, kube, final(i, pod))
+
+				select {
+				case <-time.After(10 * time.Millisecond):
+				case <-stopUpdates:
+					return
+				}
+			}
+		}(i, pod)
+	}
+
+	return func() {
+		close(stopUpdates)
+		wg.Wait()
+	}
+}
+
+func createTestPods(t *testing.T, kube kubernetes.Interface, count int) ([]*corev1.Pod, []MultitrackSpec) {
+	var pods []*corev1.Pod
+	var specs []MultitrackSpec
+	for i := 0; i < count; i++ {
+		pod := newTestPod(fmt.Sprintf("ns-%d", i), fmt.Sprintf("app-%d", i))
+		if _, err := kube.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		pods = append(pods, pod)
+		specs = append(specs, MultitrackSpec{ResourceName: pod.Name, Namespace: pod.Namespace})
+	}
+	return pods, specs
+}
+
+// TestMultitrackConcurrentCallbacks runs the trackers of many Pods against one multitracker: their added, status, ready
+// and container error callbacks race with each other and with the periodic status reports, to be run with -race.
+func TestMultitrackConcurrentCallbacks(t *testing.T) {
+	for _, output := range []OutputMode{OutputText, OutputTextDelta, OutputJSONEvents, OutputJSONChunked} {
+		t.Run(string(output), func(t *testing.T) {
+			const podsCount = 20
+			allowFailuresCount := 1000
+
+			kube := fake.NewSimpleClientset()
+			pods, specs := createTestPods(t, kube, podsCount)
+			for i := range specs {
+				specs[i].AllowFailuresCount = &allowFailuresCount
+			}
+
+			// Odd Pods report container errors before they become ready
+			stopUpdates := runTestPodsUpdates(t, kube, pods, func(i, step int, pod *corev1.Pod) *corev1.Pod {
+				if i%2 == 1 {
+					return setTestPodContainerWaiting(pod, "CrashLoopBackOff")
+				}
+				pod = pod.DeepCopy()
+				pod.Status.Message = fmt.Sprintf("step %d", step)
+				return pod
+			}, func(i int, pod *corev1.Pod) *corev1.Pod {
+				return setTestPodReady(pod)
+			})
+
+			result, out, err := runTestMultitrack(t, kube, MultitrackSpecs{Pods: specs}, MultitrackOptions{
+				StatusProgressPeriod: time.Millisecond,
+				Output:               output,
+				OutputWriter:         &bytes.Buffer{},
+			})
+			stopUpdates()
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s\n%s", err, out)
+			}
+
+			for _, spec := range specs {
+				resource := findTestResourceResult(result, "po", spec.ResourceName)
+				if resource == nil {
+					t.Errorf("no result of Pod %s", spec.ResourceName)
+				} else if resource.Outcome != ResourceOutcomeReady {
+					t.Errorf("Pod %s: expected Ready outcome, got %s", spec.ResourceName, resource.Outcome)
+				}
+			}
+		})
+	}
+}
+
+// TestMultitrackConcurrentFailure fails the whole process by one Pod while the callbacks of other Pods keep coming,
+// the error is returned once and the trackers of other Pods do not block on the stopped multitracker.
+func TestMultitrackConcurrentFailure(t *testing.T) {
+	const podsCount = 20
+
+	kube := fake.NewSimpleClientset()
+	pods, specs := createTestPods(t, kube, podsCount)
+	for i := range specs {
+		specs[i].FailMode = FailWholeDeployProcessImmediately
+	}
+
+	stopUpdates := runTestPodsUpdates(t, kube, pods, func(i, step int, pod *corev1.Pod) *corev1.Pod {
+		pod = pod.DeepCopy()
+		pod.Status.Message = fmt.Sprintf("step %d", step)
+		return pod
+	}, func(i int, pod *corev1.Pod) *corev1.Pod {
+		if i == podsCount/2 {
+			return setTestPodFailed(pod)
+		}
+		// Other Pods keep changing their statuses until the process fails
+		pod = pod.DeepCopy()
+		pod.Status.Message = time.Now().String()
+		return pod
+	})
+
+	result, out, err := runTestMultitrack(t, kube, MultitrackSpecs{Pods: specs}, MultitrackOptions{StatusProgressPeriod: time.Millisecond})
+	stopUpdates()
+
+	if err == nil {
+		t.Fatalf("expected error, got nil\n%s", out)
+	}
+
+	failedPod := fmt.Sprintf("app-%d", podsCount/2)
+	for _, spec := range specs {
+		resource := findTestResourceResult(result, "po", spec.ResourceName)
+		if resource == nil {
+			t.Errorf("no result of Pod %s", spec.ResourceName)
+			continue
+		}
+
+		if spec.ResourceName == failedPod {
+			if resource.Outcome != ResourceOutcomeFailed {
+				t.Errorf("Pod %s: expected Failed outcome, got %s", spec.ResourceName, resource.Outcome)
+			}
+		} else if resource.Outcome.IsFailed() {
+			t.Errorf("Pod %s: expected not failed outcome, got %s", spec.ResourceName, resource.Outcome)
+		}
+	}
+}
+
+// TestMultitrackSameNameInDifferentNamespaces tracks Pods with the same name in different namespaces independently:
+// one fails after the other one is ready, the results and the failure are told apart by namespace.
+func TestMultitrackSameNameInDifferentNamespaces(t *testing.T) {
+	kube := fake.NewSimpleClientset()
+
+	var pods []*corev1.Pod
+	var specs []MultitrackSpec
+	for _, namespace := range []string{"ns-ready", "ns-failed"} {
+		pod := newTestPod(namespace, "app")
+		if _, err := kube.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		pods = append(pods, pod)
+		specs = append(specs, MultitrackSpec{ResourceName: pod.Name, Namespace: pod.Namespace})
+	}
+
+	readyChan := make(chan struct{})
+	var readyOnce sync.Once
+	hooks := &MultitrackHooks{OnResourceReady: func(kind, namespace, name string, status interface{}) error {
+		if namespace == "ns-ready" {
+			readyOnce.Do(func() { close(readyChan) })
+		}
+		return nil
+	}}
+
+	// The Pod in ns-failed fails only when the Pod in ns-ready is ready
+	stopUpdates := runTestPodsUpdates(t, kube, pods, func(i, step int, pod *corev1.Pod) *corev1.Pod {
+		return pod
+	}, func(i int, pod *corev1.Pod) *corev1.Pod {
+		if pod.Namespace == "ns-ready" {
+			return setTestPodReady(pod)
+		}
+		select {
+		case <-readyChan:
+			return setTestPodFailed(pod)
+		default:
+			return pod
+		}
+	})
+
+	result, out, err := runTestMultitrack(t, kube, MultitrackSpecs{Pods: specs}, MultitrackOptions{Hooks: hooks})
+	stopUpdates()
+
+	var failedErr *FailedResourcesError
+	if !errors.As(err, &failedErr) {
+		t.Fatalf("expected *FailedResourcesError, got %v\n%s", err, out)
+	}
+	if len(failedErr.Failures) != 1 || failedErr.Failures[0].ID() != "po/ns-failed/app" {
+		t.Errorf("expected po/ns-failed/app failed, got %+v", failedErr.Failures)
+	}
+
+	outcomes := make(map[string]ResourceOutcome)
+	for _, resource := range result.Resources {
+		outcomes[resource.ID()] = resource.Outcome
+	}
+	expectedOutcomes := map[string]ResourceOutcome{
+		"po/ns-ready/app":  ResourceOutcomeReady,
+		"po/ns-failed/app": ResourceOutcomeFailed,
+	}
+	if len(outcomes) != len(expectedOutcomes) {
+		t.Errorf("expected results %v, got %v", expectedOutcomes, outcomes)
+	}
+	for id, expectedOutcome := range expectedOutcomes {
+		if outcomes[id] != expectedOutcome {
+			t.Errorf("%s: expected %s outcome, got %s", id, expectedOutcome, outcomes[id])
+		}
+	}
+}
+
+func findTestResourceResult(result MultitrackResult, kind, name string) *ResourceResult {
+	for i := range result.Resources {
+		if result.Resources[i].Kind == kind && result.Resources[i].Name == name {
+			return &result.Resources[i]
+		}
+	}
+	return nil
+}
+
+// BenchmarkMultitrackReadyDeployment measures end-to-end tracking of the Deployment, which is ready before tracking starts:
+// the readiness is declared on the listed status and the final report is shown right away, not on the next tick.
+func BenchmarkMultitrackReadyDeployment(b *testing.B) {
+	replicas := int32(2)
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default", Generation: 1},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}},
+			Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "api"}}},
+		},
+		Status: appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 2, UpdatedReplicas: 2, ReadyReplicas: 2, AvailableReplicas: 2},
+	}
+
+	for _, fastMode := range []bool{false, true} {
+		b.Run(fmt.Sprintf("FastMode=%v", fastMode), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				kube := fake.NewSimpleClientset(deploy.DeepCopy())
+
+				err := Multitrack(kube, MultitrackSpecs{Deployments: []MultitrackSpec{{ResourceName: "api", Namespace: "default"}}}, MultitrackOptions{
+					Options:                 tracker.Options{Display: display.NewDisplay(ioutil.Discard, nil)},
+					FailedContainerLogLines: -1,
+					FastMode:                fastMode,
+				})
+				if err != nil {
+					b.Fatalf("unexpected error: %s", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package multitrack
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/werf/kubedog/pkg/tracker"
+)
+
+const (
+	defaultOrdinalStallThreshold = 5 * time.Minute
+	ordinalStallCheckPeriod      = 5 * time.Second
+)
+
+// ordinalStallThreshold returns OrdinalStallThresholdSeconds of the spec, 0 means the check is disabled.
+func ordinalStallThreshold(spec MultitrackSpec) time.Duration {
+	switch {
+	case spec.OrdinalStallThresholdSeconds < 0:
+		return 0
+	case spec.OrdinalStallThresholdSeconds == 0:
+		return defaultOrdinalStallThreshold
+	default:
+		return time.Duration(spec.OrdinalStallThresholdSeconds) * time.Second
+	}
+}
+
+// formatOrdinalStall describes the Pod the ordered rollout of StatefulSet has been waiting on for longer than OrdinalStallThresholdSeconds,
+// e.g. "rollout stalled at ordinal 4 for 6m0s: po/prod/db-4: crash-looping: ...", returns empty string when the rollout is not stalled.
+func (mt *multitracker) formatOrdinalStall(spec MultitrackSpec) string {
+	threshold := ordinalStallThreshold(spec)
+	rollout := mt.StatefulSetsStatuses[resourceKey(spec)].OrderedRollout
+	if threshold == 0 || rollout == nil || rollout.RollingSince.IsZero() {
+		return ""
+	}
+
+	stalledFor := time.Since(rollout.RollingSince)
+	if stalledFor < threshold {
+		return ""
+	}
+
+	cause := "not created"
+	if podStatus, hasKey := mt.StatefulSetsStatuses[resourceKey(spec)].Pods[rollout.RollingPodName]; hasKey && !podStatus.IsDeleted {
+		cause = podBlockingCause(podStatus)
+	}
+
+	return fmt.Sprintf("rollout stalled at ordinal %d for %s: %s: %s", rollout.RollingOrdinal, stalledFor.Truncate(time.Second), tracker.FormatResourceID("po", spec.Namespace, rollout.RollingPodName), cause)
+}
+
+// runOrdinalStallCheck warns once per stalled ordinal when the ordered rollout of StatefulSet does not advance past the stall threshold.
+func (mt *multitracker) runOrdinalStallCheck(spec MultitrackSpec, mtCtx *multitrackerContext) {
+	defer mt.recoverTrackerPanic("sts", spec)
+
+	ticker := time.NewTicker(ordinalStallCheckPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-mtCtx.Context.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !mt.checkOrdinalStall(mtCtx.Context, spec) {
+			return
+		}
+	}
+}
+
+// checkOrdinalStall returns true when the rollout should be checked further. The stall of the unschedulable Pod
+// is reported with the cluster capacity, which is collected without holding handlers mutex.
+func (mt *multitracker) checkOrdinalStall(ctx context.Context, spec MultitrackSpec) bool {
+	stall, isUnschedulable, shouldContinue := mt.detectOrdinalStall(spec)
+	if stall == "" {
+		return shouldContinue
+	}
+
+	var capacity *clusterCapacitySummary
+	if isUnschedulable {
+		capacity = mt.clusterCapacity.get(ctx, mt.kube)
+	}
+
+	mt.mux.Lock()
+	defer mt.mux.Unlock()
+
+	if capacityMsg := mt.formatClusterCapacity(capacity); capacityMsg != "" {
+		stall = fmt.Sprintf("%s; %s", stall, capacityMsg)
+	}
+
+	mt.displayResourceTrackerMessageF("sts", spec, "%s", stall)
+	mt.displayMultitrackErrorMessageF("%s WARNING: %s\n", mt.resourceID("sts", spec), stall)
+
+	return true
+}
+
+// detectOrdinalStall returns the stall of the ordered rollout not reported yet and whether the stalled Pod is unschedulable.
+func (mt *multitracker) detectOrdinalStall(spec MultitrackSpec) (string, bool, bool) {
+	mt.mux.Lock()
+	defer mt.mux.Unlock()
+
+	state := mt.TrackingStatefulSets[resourceKey(spec)]
+	if mt.isTerminating || state.Status == ResourceSucceeded || state.Status == ResourceFailed {
+		return "", false, false
+	}
+
+	stall := mt.formatOrdinalStall(spec)
+	if stall == "" {
+		return "", false, true
+	}
+
+	status := mt.StatefulSetsStatuses[resourceKey(spec)]
+	rollingSince := status.OrderedRollout.RollingSince
+	if state.OrdinalStallReportedSince.Equal(rollingSince) {
+		return "", false, true
+	}
+	state.OrdinalStallReportedSince = rollingSince
+
+	return stall, status.Pods[status.OrderedRollout.RollingPodName].UnschedulableMessage != "", true
+}
+
+// withOrdinalStall appends the stall of the ordered rollout of StatefulSet to the failure reason, so that the reason names the blocking Pod.
+func (mt *multitracker) withOrdinalStall(kind string, spec MultitrackSpec, reason string) string {
+	if kind != "sts" {
+		return reason
+	}
+
+	if stall := mt.formatOrdinalStall(spec); stall != "" {
+		return fmt.Sprintf("%s; %s", reason, stall)
+	}
+	return reason
+}
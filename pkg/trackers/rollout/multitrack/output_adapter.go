@@ -0,0 +1,18 @@
+package multitrack
+
+import "strings"
+
+// OutputAdapter allows a host tool to embed multitrack output into its own nested sections.
+// Multitrack opens a section per status report, per failed resource service messages block and per log block,
+// all other output is passed line by line. When no adapter is specified, output goes to logboek as usual.
+type OutputAdapter interface {
+	BeginSection(title string)
+	EndSection()
+	Line(text string)
+}
+
+func outputAdapterLines(adapter OutputAdapter, text string) {
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		adapter.Line(line)
+	}
+}
@@ -0,0 +1,70 @@
+package multitrack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// renderTestOutputAdapterEntries renders the entries of recordingOutputAdapter as the host tool would show nested sections.
+func renderTestOutputAdapterEntries(entries []string) string {
+	var b strings.Builder
+	indent := ""
+	for _, entry := range entries {
+		switch {
+		case strings.HasPrefix(entry, "begin "):
+			b.WriteString(indent + "┌ " + strings.TrimPrefix(entry, "begin ") + "\n")
+			indent += "│ "
+		case entry == "end":
+			indent = strings.TrimSuffix(indent, "│ ")
+			b.WriteString(indent + "└\n")
+		default:
+			b.WriteString(indent + strings.TrimPrefix(entry, "line ") + "\n")
+		}
+	}
+	return b.String()
+}
+
+// TestOutputAdapterSections passes the messages of the multitracker through OutputAdapter: nothing is written into logboek,
+// the service messages of the resource and the failed resources messages are shown in their sections.
+func TestOutputAdapterSections(t *testing.T) {
+	mt := newTestMultitracker()
+	adapter := mt.outputAdapter.(*recordingOutputAdapter)
+
+	spec := MultitrackSpec{ResourceName: "web", Namespace: "prod", ShowServiceMessages: true}
+	mt.DeploymentsSpecs[resourceKey(spec)] = spec
+	mt.TrackingDeployments[resourceKey(spec)] = newMultitrackerResourceState(spec)
+
+	mt.displayMultitrackServiceMessageF("Tracking 2 resources:\n  deploy/prod/web\n  job/prod/migrate\n")
+	mt.displayResourceTrackerMessageF("deploy", spec, "po/prod/web-5d4f-abcde added")
+	mt.displayResourceTrackerMessageF("deploy", spec, "po/prod/web-5d4f-abcde container/main: back-off restarting failed container")
+	mt.displayResourceErrorF("deploy", spec, "container/main: CrashLoopBackOff")
+
+	mt.TrackingDeployments[resourceKey(spec)].Status = ResourceFailed
+	mt.displayFailedTrackingResourcesServiceMessages()
+	mt.display.flush()
+
+	entries := adapter.Entries()
+	checkTestSections(t, entries)
+	res := renderTestOutputAdapterEntries(entries)
+
+	goldenPath := filepath.Join("testdata", "output_adapter", "sections.golden")
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(goldenPath), 0755); err != nil {
+			t.Fatalf("unable to create golden files dir: %s", err)
+		}
+		if err := ioutil.WriteFile(goldenPath, []byte(res), 0644); err != nil {
+			t.Fatalf("unable to update golden file: %s", err)
+		}
+	}
+
+	expected, err := ioutil.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("unable to read golden file: %s", err)
+	}
+	if res != string(expected) {
+		t.Errorf("output does not match %s:\n%s", goldenPath, res)
+	}
+}
@@ -0,0 +1,146 @@
+package multitrack
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/werf/kubedog/pkg/tracker/indicators"
+	"github.com/werf/kubedog/pkg/tracker/pod"
+)
+
+type SuccessCondition string
+
+const (
+	// SuccessConditionReady is the default: the resource succeeds when it becomes ready.
+	SuccessConditionReady SuccessCondition = "Ready"
+	// SuccessConditionPodsSucceeded makes DaemonSet succeed when a Pod completed on every targeted node at least once,
+	// and Deployment when the desired number of Pods completed. Readiness of such a resource is ignored.
+	SuccessConditionPodsSucceeded SuccessCondition = "PodsSucceeded"
+)
+
+func validateSuccessConditions(specs MultitrackSpecs) error {
+	for _, kindSpecs := range []struct {
+		Kind  string
+		Specs []MultitrackSpec
+	}{
+		{"po", specs.Pods},
+		{"rs", specs.ReplicaSets},
+		{"sts", specs.StatefulSets},
+		{"job", specs.Jobs},
+		{"generic", specs.Generic},
+	} {
+		for _, spec := range kindSpecs.Specs {
+			if spec.SuccessCondition == SuccessConditionPodsSucceeded {
+				return fmt.Errorf("bad %s/%s spec: SuccessCondition %s is supported only for DaemonSets and Deployments", kindSpecs.Kind, spec.ResourceName, spec.SuccessCondition)
+			}
+		}
+	}
+
+	return nil
+}
+
+// isPodCompleted returns true when the Pod succeeded or all its containers exited with zero code at least once,
+// containers of DaemonSet Pods are restarted after completion, so the last termination is also considered.
+func isPodCompleted(podStatus pod.PodStatus) bool {
+	if podStatus.Phase == corev1.PodSucceeded {
+		return true
+	}
+
+	if len(podStatus.ContainerStatuses) == 0 {
+		return false
+	}
+
+	for _, cs := range podStatus.ContainerStatuses {
+		switch {
+		case cs.State.Terminated != nil && cs.State.Terminated.ExitCode == 0:
+		case cs.LastTerminationState.Terminated != nil && cs.LastTerminationState.Terminated.ExitCode == 0:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// podCompletionTarget returns the node of the DaemonSet Pod or the name of the Deployment Pod.
+func podCompletionTarget(kind, podName string, podStatus pod.PodStatus) string {
+	if kind == "ds" && podStatus.NodeName != "" {
+		return podStatus.NodeName
+	}
+	return podName
+}
+
+// handlePodsSucceededCondition registers completed Pods of the current revision in the resource state,
+// so that the completion is remembered after the completed Pods are deleted.
+func (mt *multitracker) handlePodsSucceededCondition(resourcesStates map[string]*multitrackerResourceState, kind string, spec MultitrackSpec, pods map[string]pod.PodStatus, newPodsNames []string, desired int32) error {
+	state := resourcesStates[resourceKey(spec)]
+	if state.Status == ResourceSucceeded || state.Status == ResourceFailed {
+		return nil
+	}
+
+	prevCompleted := len(state.CompletedTargets)
+	for _, podName := range newPodsNames {
+		podStatus, hasKey := pods[podName]
+		if !hasKey || !isPodCompleted(podStatus) {
+			continue
+		}
+		state.CompletedTargets[podCompletionTarget(kind, podName, podStatus)] = true
+	}
+
+	if desired == 0 || len(state.CompletedTargets) == prevCompleted {
+		return nil
+	}
+
+	mt.displayResourceTrackerMessageF(kind, spec, "%s", formatPodsSucceededProgress(kind, len(state.CompletedTargets), desired))
+
+	if int32(len(state.CompletedTargets)) < desired {
+		return nil
+	}
+
+	return mt.handleResourceReadyCondition(resourcesStates, kind, spec)
+}
+
+// isCompletedPodError returns true for the error of the Pod which has already completed:
+// containers of completed DaemonSet Pods are restarted and may crash-loop, it is not a failure.
+func (mt *multitracker) isCompletedPodError(resourcesStates map[string]*multitrackerResourceState, kind string, spec MultitrackSpec, pods map[string]pod.PodStatus, podName string) bool {
+	if spec.SuccessCondition != SuccessConditionPodsSucceeded {
+		return false
+	}
+
+	podStatus, hasKey := pods[podName]
+	if !hasKey {
+		return false
+	}
+
+	return isPodCompleted(podStatus) || resourcesStates[resourceKey(spec)].CompletedTargets[podCompletionTarget(kind, podName, podStatus)]
+}
+
+// formatPodsSucceededProgress returns e.g. "pods completed on 38/40 nodes" for DaemonSet or "pods completed 2/3" for Deployment.
+func formatPodsSucceededProgress(kind string, completed int, desired int32) string {
+	if kind == "ds" {
+		return fmt.Sprintf("pods completed on %d/%d nodes", completed, desired)
+	}
+	return fmt.Sprintf("pods completed %d/%d", completed, desired)
+}
+
+// formatWaitingForMessages replaces readiness waiting messages of the resource succeeding by SuccessConditionPodsSucceeded.
+func (mt *multitracker) formatWaitingForMessages(resourcesStates map[string]*multitrackerResourceState, kind string, spec MultitrackSpec, waitingForMessages []string, desired int32) []string {
+	if spec.SuccessCondition != SuccessConditionPodsSucceeded {
+		return waitingForMessages
+	}
+
+	state := resourcesStates[resourceKey(spec)]
+	if state.Status == ResourceSucceeded || desired == 0 {
+		return nil
+	}
+
+	return []string{formatPodsSucceededProgress(kind, len(state.CompletedTargets), desired)}
+}
+
+func podsSucceededDesired(replicasIndicator *indicators.Int32EqualConditionIndicator) int32 {
+	if replicasIndicator == nil {
+		return 0
+	}
+	return replicasIndicator.TargetValue
+}
@@ -0,0 +1,43 @@
+package multitrack
+
+import (
+	"time"
+)
+
+// failResourceImmediately stops tracking of the failed resource and fails the whole process. With PostFailureLogCaptureDuration
+// tracking of the resource, including its log streams, continues until the capture window is over, then the resource
+// tracker is cancelled with ErrFailWholeDeployProcessImmediately, which is handled by runSpecTracker as usual.
+func (mt *multitracker) failResourceImmediately(kind string, spec MultitrackSpec) error {
+	if mt.postFailureLogCaptureDuration <= 0 || spec.SkipLogs || kind == "generic" {
+		return ErrFailWholeDeployProcessImmediately
+	}
+
+	mtCtx := mt.resourceContext(kind, spec)
+	if mtCtx == nil {
+		return ErrFailWholeDeployProcessImmediately
+	}
+
+	state := mt.resourcesStatesByKind(kind)[resourceKey(spec)]
+	state.LogCaptureUntil = time.Now().Add(mt.postFailureLogCaptureDuration)
+
+	mt.displayMultitrackServiceMessageF("Capturing logs of %s for %s before stopping\n", mt.resourceID(kind, spec), mt.postFailureLogCaptureDuration)
+
+	time.AfterFunc(mt.postFailureLogCaptureDuration, func() {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+
+		mtCtx.Err = ErrFailWholeDeployProcessImmediately
+		mtCtx.CancelFunc()
+	})
+
+	return nil
+}
+
+func (mt *multitracker) resourceContext(kind string, spec MultitrackSpec) *multitrackerContext {
+	for _, k := range mt.trackedKinds() {
+		if k.Kind == kind {
+			return k.Contexts[resourceKey(spec)]
+		}
+	}
+	return nil
+}
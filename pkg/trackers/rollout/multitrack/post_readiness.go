@@ -0,0 +1,61 @@
+package multitrack
+
+import (
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/werf/kubedog/pkg/tracker"
+)
+
+func hasPostReadinessChecks(spec MultitrackSpec) bool {
+	// Readiness of the resource expected to be rejected is a failure regardless of the checks
+	return !spec.ExpectFailure && (spec.ServesWebhook || spec.ReadinessHTTPCheck != nil || len(spec.VerifyServiceAccountAnnotations) > 0 || spec.VerifyMountedTLSSecrets)
+}
+
+// handlePostponedResourceReadyCondition postpones readiness of the resources with post-readiness checks:
+// tracker is stopped and the resource becomes ready only after all checks pass.
+func (mt *multitracker) handlePostponedResourceReadyCondition(resourcesStates map[string]*multitrackerResourceState, kind string, spec MultitrackSpec) error {
+	if hasPostReadinessChecks(spec) {
+		return tracker.StopTrack
+	}
+
+	return mt.handleResourceReadyCondition(resourcesStates, kind, spec)
+}
+
+// trackPostReadinessChecks runs checks of the resource which has been reported as ready by its tracker
+// and marks the resource as ready when all of them pass.
+func (mt *multitracker) trackPostReadinessChecks(kube kubernetes.Interface, kind string, spec MultitrackSpec, resourcesStates map[string]*multitrackerResourceState, opts MultitrackOptions) error {
+	if !hasPostReadinessChecks(spec) {
+		return nil
+	}
+
+	if spec.ServesWebhook {
+		if err := mt.trackWebhookService(kube, kind, spec, opts); err != nil {
+			return err
+		}
+	}
+
+	if spec.ReadinessHTTPCheck != nil {
+		if err := mt.trackReadinessHTTPCheck(kube, kind, spec, resourcesStates, opts); err != nil {
+			return err
+		}
+	}
+
+	if len(spec.VerifyServiceAccountAnnotations) > 0 {
+		if err := mt.trackServiceAccountAnnotations(kube, kind, spec, resourcesStates, opts); err != nil {
+			return err
+		}
+	}
+
+	if spec.VerifyMountedTLSSecrets {
+		if err := mt.trackMountedTLSSecrets(kube, kind, spec, resourcesStates, opts); err != nil {
+			return err
+		}
+	}
+
+	mt.mux.Lock()
+	defer mt.mux.Unlock()
+
+	mt.handleResourceReadyCondition(resourcesStates, kind, spec)
+
+	return nil
+}
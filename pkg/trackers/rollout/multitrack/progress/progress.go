@@ -0,0 +1,355 @@
+package progress
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/werf/kubedog/pkg/trackers/rollout/multitrack"
+)
+
+const (
+	defaultMinInterval          = 10 * time.Second
+	defaultMaxDescriptionLength = 140
+)
+
+// State is the state of the deployment as used by GitHub and GitLab deployment status APIs.
+type State string
+
+const (
+	// StatePending means no tracked resource has been seen yet.
+	StatePending    State = "pending"
+	StateInProgress State = "in_progress"
+	StateSuccess    State = "success"
+	StateFailure    State = "failure"
+)
+
+// IsTerminal returns true for StateSuccess and StateFailure.
+func (s State) IsTerminal() bool {
+	return s == StateSuccess || s == StateFailure
+}
+
+// Update is a single deployment status update, e.g. {StateInProgress, "21/34 ready — waiting on sts/kafka (ordinal 4) and job/migrate"}.
+type Update struct {
+	State       State
+	Description string
+}
+
+type Options struct {
+	// MinInterval is the minimal time between progress updates, 10 seconds by default, negative value means no limit.
+	// Progress observed sooner is merged into the update made once the interval passes, terminal updates are made immediately.
+	MinInterval time.Duration
+	// MaxDescriptionLength in characters, 140 by default.
+	MaxDescriptionLength int
+	// ASCII makes descriptions of only ASCII characters, see multitrack.MultitrackResult.ShortSummaryPlain.
+	ASCII bool
+}
+
+// ProgressReporter turns Multitrack status reports and its result into debounced deployment status updates with
+// human-readable descriptions fitting into the length limit. OnUpdate is called sequentially, never with the same
+// update twice in a row, and never after the terminal update.
+type ProgressReporter struct {
+	OnUpdate func(update Update) error
+
+	minInterval          time.Duration
+	maxDescriptionLength int
+	ascii                bool
+	now                  func() time.Time
+	afterFunc            func(d time.Duration, f func()) *time.Timer
+
+	mux          sync.Mutex
+	last         *Update
+	lastAt       time.Time
+	pending      *Update
+	pendingTimer *time.Timer
+	isFinished   bool
+	err          error
+
+	// updateMux serializes OnUpdate calls made by status reports and by the timer of the pending update.
+	updateMux sync.Mutex
+}
+
+func NewProgressReporter(onUpdate func(update Update) error, opts Options) *ProgressReporter {
+	minInterval := opts.MinInterval
+	if minInterval == 0 {
+		minInterval = defaultMinInterval
+	}
+
+	maxDescriptionLength := opts.MaxDescriptionLength
+	if maxDescriptionLength <= 0 {
+		maxDescriptionLength = defaultMaxDescriptionLength
+	}
+
+	return &ProgressReporter{
+		OnUpdate:             onUpdate,
+		minInterval:          minInterval,
+		maxDescriptionLength: maxDescriptionLength,
+		ascii:                opts.ASCII,
+		now:                  time.Now,
+		afterFunc:            time.AfterFunc,
+	}
+}
+
+// Hooks returns MultitrackHooks reporting the progress on each status report, e.g. for MultitrackOptions.Hooks.
+// Call ObserveSnapshot from the own OnStatusReport hook instead when other hooks are used.
+func (r *ProgressReporter) Hooks() *multitrack.MultitrackHooks {
+	return &multitrack.MultitrackHooks{OnStatusReport: r.ObserveSnapshot}
+}
+
+// ObserveSnapshot reports the progress of the status report, the update is postponed until MinInterval passes
+// since the previous one. The error returned by OnUpdate for the postponed update is returned by the next call.
+func (r *ProgressReporter) ObserveSnapshot(snapshot multitrack.MultitrackSnapshot) error {
+	update := Update{State: StateInProgress, Description: r.describeProgress(snapshot.Resources)}
+	if isPending(snapshot.Resources) {
+		update.State = StatePending
+	}
+
+	r.mux.Lock()
+	if err := r.err; err != nil || r.isFinished {
+		r.mux.Unlock()
+		return err
+	}
+
+	if r.last != nil && *r.last == update {
+		r.pending = nil
+		r.mux.Unlock()
+		return nil
+	}
+
+	// State changes are reported right away, so that pending is never merged into in_progress
+	if wait := r.minInterval - r.now().Sub(r.lastAt); r.last != nil && r.last.State == update.State && r.minInterval > 0 && wait > 0 {
+		r.pending = &update
+		if r.pendingTimer == nil {
+			r.pendingTimer = r.afterFunc(wait, r.flushPending)
+		}
+		r.mux.Unlock()
+		return nil
+	}
+
+	r.pending = nil
+	r.markUpdated(update)
+	r.mux.Unlock()
+
+	return r.callOnUpdate(update)
+}
+
+// Finish makes the terminal update: StateSuccess when err of Multitrack is nil, StateFailure otherwise.
+// The description is multitrack.MultitrackResult.ShortSummary, or err itself when the result has no failed resources.
+// The postponed progress update is dropped.
+func (r *ProgressReporter) Finish(result multitrack.MultitrackResult, err error) error {
+	update := Update{State: StateSuccess, Description: r.describeResult(result, err)}
+	if err != nil {
+		update.State = StateFailure
+	}
+
+	r.mux.Lock()
+	if r.isFinished {
+		r.mux.Unlock()
+		return nil
+	}
+	r.isFinished = true
+	r.pending = nil
+	if r.pendingTimer != nil {
+		r.pendingTimer.Stop()
+		r.pendingTimer = nil
+	}
+	r.markUpdated(update)
+	r.mux.Unlock()
+
+	return r.callOnUpdate(update)
+}
+
+func (r *ProgressReporter) flushPending() {
+	r.mux.Lock()
+	r.pendingTimer = nil
+	update := r.pending
+	r.pending = nil
+	if update == nil || r.isFinished || r.err != nil {
+		r.mux.Unlock()
+		return
+	}
+	r.markUpdated(*update)
+	r.mux.Unlock()
+
+	if err := r.callOnUpdate(*update); err != nil {
+		r.mux.Lock()
+		r.err = err
+		r.mux.Unlock()
+	}
+}
+
+// markUpdated must be called with mux held.
+func (r *ProgressReporter) markUpdated(update Update) {
+	r.last = &update
+	r.lastAt = r.now()
+}
+
+func (r *ProgressReporter) callOnUpdate(update Update) error {
+	if r.OnUpdate == nil {
+		return nil
+	}
+
+	r.updateMux.Lock()
+	defer r.updateMux.Unlock()
+
+	return r.OnUpdate(update)
+}
+
+func isPending(resources []multitrack.ResourceStatusEvent) bool {
+	for _, resource := range resources {
+		if resource.Phase != multitrack.ResourcePhasePending {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *ProgressReporter) describeResult(result multitrack.MultitrackResult, err error) string {
+	summary := result.ShortSummary()
+	if r.ascii {
+		summary = result.ShortSummaryPlain()
+	}
+
+	if err != nil {
+		hasFailed := false
+		for _, resource := range result.Resources {
+			if resource.Outcome.IsFailed() || (resource.Outcome == multitrack.ResourceOutcomeUnstable && result.TreatUnstableAsFailed) {
+				hasFailed = true
+				break
+			}
+		}
+		if !hasFailed {
+			summary = strings.TrimSpace(strings.SplitN(err.Error(), "\n", 2)[0])
+		}
+	}
+
+	return truncate(summary, r.maxDescriptionLength, r.ellipsis())
+}
+
+// describeProgress returns e.g. "21/34 ready — waiting on sts/kafka (ordinal 4) and job/migrate". When it does not fit
+// into the length limit, details of the waited resources are dropped, then the resources from the end of the list
+// are replaced with "+N more". Failing resources go first, then progressing ones, then the ones with details.
+func (r *ProgressReporter) describeProgress(resources []multitrack.ResourceStatusEvent) string {
+	var readyCount, failingCount int
+	var waiting []multitrack.ResourceStatusEvent
+	for _, resource := range resources {
+		switch {
+		case resource.Ready:
+			readyCount++
+		case resource.Failed:
+			failingCount++
+			waiting = append(waiting, resource)
+		default:
+			waiting = append(waiting, resource)
+		}
+	}
+
+	sort.SliceStable(waiting, func(i, j int) bool {
+		if pi, pj := waitingPriority(waiting[i]), waitingPriority(waiting[j]); pi != pj {
+			return pi < pj
+		}
+		if di, dj := waitingDetail(waiting[i]) != "", waitingDetail(waiting[j]) != ""; di != dj {
+			return di
+		}
+		return waiting[i].ID < waiting[j].ID
+	})
+
+	head := fmt.Sprintf("%d/%d ready", readyCount, len(resources))
+	if failingCount > 0 {
+		head += fmt.Sprintf(", %d failing", failingCount)
+	}
+	if len(waiting) == 0 {
+		return truncate(head, r.maxDescriptionLength, r.ellipsis())
+	}
+
+	dash := " — "
+	if r.ascii {
+		dash = " - "
+	}
+
+	format := func(shown int, withDetails bool) string {
+		var items []string
+		for _, resource := range waiting[:shown] {
+			item := fmt.Sprintf("%s/%s", resource.Kind, resource.Name)
+			if detail := waitingDetail(resource); withDetails && detail != "" {
+				item += fmt.Sprintf(" (%s)", detail)
+			}
+			items = append(items, item)
+		}
+		if omitted := len(waiting) - shown; omitted > 0 {
+			items = append(items, fmt.Sprintf("+%d more", omitted))
+		}
+		return head + dash + "waiting on " + joinItems(items)
+	}
+
+	for shown := len(waiting); shown >= 1; shown-- {
+		for _, withDetails := range []bool{true, false} {
+			if description := format(shown, withDetails); utf8.RuneCountInString(description) <= r.maxDescriptionLength {
+				return description
+			}
+		}
+	}
+
+	return truncate(format(1, false), r.maxDescriptionLength, r.ellipsis())
+}
+
+func (r *ProgressReporter) ellipsis() string {
+	if r.ascii {
+		return "..."
+	}
+	return "…"
+}
+
+func waitingPriority(resource multitrack.ResourceStatusEvent) int {
+	switch {
+	case resource.Failed:
+		return 0
+	case resource.Phase == multitrack.ResourcePhaseProgressing:
+		return 1
+	default:
+		return 2
+	}
+}
+
+var orderedRolloutOrdinalRegexp = regexp.MustCompile(`^rolling ordinal (\d+) of`)
+
+// waitingDetail returns the short description of what the resource is waiting on, e.g. "ordinal 4" or "ready 3/5".
+func waitingDetail(resource multitrack.ResourceStatusEvent) string {
+	if resource.Failed {
+		return "failing"
+	}
+
+	for _, msg := range resource.WaitingFor {
+		if match := orderedRolloutOrdinalRegexp.FindStringSubmatch(msg); match != nil {
+			return fmt.Sprintf("ordinal %s", match[1])
+		}
+	}
+
+	if resource.Replicas != nil && resource.Replicas.Desired > 0 && int64(resource.Replicas.Ready) < resource.Replicas.Desired {
+		return fmt.Sprintf("ready %d/%d", resource.Replicas.Ready, resource.Replicas.Desired)
+	}
+
+	return ""
+}
+
+// joinItems returns e.g. "a, b and c".
+func joinItems(items []string) string {
+	if len(items) <= 1 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
+
+func truncate(s string, maxLength int, ellipsis string) string {
+	if utf8.RuneCountInString(s) <= maxLength {
+		return s
+	}
+	if maxLength <= utf8.RuneCountInString(ellipsis) {
+		return string([]rune(s)[:maxLength])
+	}
+	return string([]rune(s)[:maxLength-utf8.RuneCountInString(ellipsis)]) + ellipsis
+}
@@ -0,0 +1,232 @@
+package progress
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/werf/kubedog/pkg/trackers/rollout/multitrack"
+)
+
+func testResource(kind, name string, phase multitrack.ResourcePhase) multitrack.ResourceStatusEvent {
+	return multitrack.ResourceStatusEvent{
+		ID:    fmt.Sprintf("%s/prod/%s", kind, name),
+		Kind:  kind,
+		Name:  name,
+		Phase: phase,
+		Ready: phase == multitrack.ResourcePhaseReady,
+	}
+}
+
+func testRolloutResources() []multitrack.ResourceStatusEvent {
+	api := testResource("deploy", "api", multitrack.ResourcePhaseReady)
+
+	kafka := testResource("sts", "kafka", multitrack.ResourcePhaseProgressing)
+	kafka.WaitingFor = []string{"rolling ordinal 4 of 5"}
+
+	migrate := testResource("job", "migrate", multitrack.ResourcePhasePending)
+
+	web := testResource("deploy", "web", multitrack.ResourcePhaseProgressing)
+	web.Replicas = &multitrack.ReplicasCounts{Desired: 3, Ready: 1}
+
+	worker := testResource("po", "worker", multitrack.ResourcePhaseProgressing)
+	worker.Failed = true
+
+	cache := testResource("ds", "cache", multitrack.ResourcePhaseProgressing)
+
+	return []multitrack.ResourceStatusEvent{api, kafka, migrate, web, worker, cache}
+}
+
+func TestDescribeProgress(t *testing.T) {
+	tests := []struct {
+		maxLength int
+		ascii     bool
+		expected  string
+	}{
+		{
+			expected: "1/6 ready, 1 failing — waiting on po/worker (failing), deploy/web (ready 1/3), sts/kafka (ordinal 4), ds/cache and job/migrate",
+		},
+		{
+			ascii:    true,
+			expected: "1/6 ready, 1 failing - waiting on po/worker (failing), deploy/web (ready 1/3), sts/kafka (ordinal 4), ds/cache and job/migrate",
+		},
+		{
+			maxLength: 100,
+			expected:  "1/6 ready, 1 failing — waiting on po/worker, deploy/web, sts/kafka, ds/cache and job/migrate",
+		},
+		{
+			maxLength: 80,
+			expected:  "1/6 ready, 1 failing — waiting on po/worker, deploy/web, sts/kafka and +2 more",
+		},
+		{
+			maxLength: 50,
+			expected:  "1/6 ready, 1 failing — waiting on po/worker and +…",
+		},
+		{
+			maxLength: 50,
+			ascii:     true,
+			expected:  "1/6 ready, 1 failing - waiting on po/worker and...",
+		},
+	}
+
+	for _, tt := range tests {
+		r := NewProgressReporter(nil, Options{MaxDescriptionLength: tt.maxLength, ASCII: tt.ascii})
+		if res := r.describeProgress(testRolloutResources()); res != tt.expected {
+			t.Errorf("max length %d, ascii %t: expected:\n%s\ngot:\n%s", tt.maxLength, tt.ascii, tt.expected, res)
+		}
+	}
+
+	r := NewProgressReporter(nil, Options{})
+	if res := r.describeProgress(testRolloutResources()[:1]); res != "1/1 ready" {
+		t.Errorf("expected description without waited resources, got %q", res)
+	}
+}
+
+// testClock is the clock of ProgressReporter with the timer of the pending update fired by the test.
+type testClock struct {
+	now     time.Time
+	pending func()
+}
+
+func newTestProgressReporter(clock *testClock, updates *[]string) *ProgressReporter {
+	r := NewProgressReporter(func(update Update) error {
+		*updates = append(*updates, fmt.Sprintf("%s: %s", update.State, update.Description))
+		return nil
+	}, Options{MinInterval: 10 * time.Second})
+	r.now = func() time.Time { return clock.now }
+	r.afterFunc = func(d time.Duration, f func()) *time.Timer {
+		clock.pending = f
+		return time.NewTimer(time.Hour)
+	}
+	return r
+}
+
+// TestProgressReporterDebounce reports the progress of the Deployment scaled from 0 to 3 ready replicas:
+// the progress observed within MinInterval is merged into the update made by the timer, state changes
+// and the terminal update are made right away.
+func TestProgressReporterDebounce(t *testing.T) {
+	clock := &testClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	var updates []string
+	r := newTestProgressReporter(clock, &updates)
+
+	snapshot := func(phase multitrack.ResourcePhase, ready int32) multitrack.MultitrackSnapshot {
+		resource := testResource("deploy", "api", phase)
+		resource.Replicas = &multitrack.ReplicasCounts{Desired: 3, Ready: ready}
+		return multitrack.MultitrackSnapshot{Resources: []multitrack.ResourceStatusEvent{resource}}
+	}
+
+	for _, step := range []struct {
+		after     time.Duration
+		snapshot  multitrack.MultitrackSnapshot
+		fireTimer bool
+	}{
+		{snapshot: snapshot(multitrack.ResourcePhasePending, 0)},
+		{after: time.Second, snapshot: snapshot(multitrack.ResourcePhaseProgressing, 0)},
+		{after: time.Second, snapshot: snapshot(multitrack.ResourcePhaseProgressing, 0)},
+		{after: time.Second, snapshot: snapshot(multitrack.ResourcePhaseProgressing, 1)},
+		{after: time.Second, snapshot: snapshot(multitrack.ResourcePhaseProgressing, 2)},
+		{after: 7 * time.Second, fireTimer: true},
+		{after: time.Second, snapshot: snapshot(multitrack.ResourcePhaseProgressing, 1)},
+		{after: time.Second, snapshot: snapshot(multitrack.ResourcePhaseProgressing, 2)},
+	} {
+		clock.now = clock.now.Add(step.after)
+		if step.fireTimer {
+			clock.pending()
+			continue
+		}
+		if err := r.ObserveSnapshot(step.snapshot); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	result := multitrack.MultitrackResult{Resources: []multitrack.ResourceResult{{Kind: "deploy", Namespace: "prod", Name: "api", Outcome: multitrack.ResourceOutcomeReady}}}
+	if err := r.Finish(result, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := r.ObserveSnapshot(snapshot(multitrack.ResourcePhaseProgressing, 1)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := r.Finish(result, errors.New("timed out")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []string{
+		"pending: 0/1 ready — waiting on deploy/api (ready 0/3)",
+		"in_progress: 0/1 ready — waiting on deploy/api (ready 0/3)",
+		"in_progress: 0/1 ready — waiting on deploy/api (ready 2/3)",
+		"success: " + result.ShortSummary(),
+	}
+	if strings.Join(updates, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected updates:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(updates, "\n"))
+	}
+}
+
+// TestProgressReporterOnUpdateError fails the update made by the timer: the error is returned by the next status report,
+// no more updates are made then.
+func TestProgressReporterOnUpdateError(t *testing.T) {
+	clock := &testClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	var calls int
+	r := newTestProgressReporter(clock, nil)
+	r.OnUpdate = func(update Update) error {
+		calls++
+		if calls == 2 {
+			return errors.New("403 Forbidden")
+		}
+		return nil
+	}
+
+	resources := testRolloutResources()
+	if err := r.ObserveSnapshot(multitrack.MultitrackSnapshot{Resources: resources}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := r.ObserveSnapshot(multitrack.MultitrackSnapshot{Resources: resources[1:]}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	clock.now = clock.now.Add(10 * time.Second)
+	clock.pending()
+
+	for i := 0; i < 2; i++ {
+		if err := r.ObserveSnapshot(multitrack.MultitrackSnapshot{Resources: resources}); err == nil || err.Error() != "403 Forbidden" {
+			t.Errorf("expected OnUpdate error, got %v", err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("expected no updates after the error, got %d calls", calls)
+	}
+}
+
+func TestProgressReporterFinishFailure(t *testing.T) {
+	failed := multitrack.MultitrackResult{Resources: []multitrack.ResourceResult{
+		{Kind: "deploy", Namespace: "prod", Name: "api", Outcome: multitrack.ResourceOutcomeReady},
+		{Kind: "job", Namespace: "prod", Name: "migrate", Outcome: multitrack.ResourceOutcomeFailed},
+	}}
+	notFailed := multitrack.MultitrackResult{Resources: failed.Resources[:1]}
+
+	tests := []struct {
+		result   multitrack.MultitrackResult
+		err      error
+		expected Update
+	}{
+		{result: failed, err: errors.New("failed"), expected: Update{State: StateFailure, Description: failed.ShortSummary()}},
+		{result: notFailed, err: errors.New("  timed out\nafter 5m0s"), expected: Update{State: StateFailure, Description: "timed out"}},
+		{result: notFailed, expected: Update{State: StateSuccess, Description: notFailed.ShortSummary()}},
+	}
+
+	for _, tt := range tests {
+		var updates []Update
+		r := NewProgressReporter(func(update Update) error {
+			updates = append(updates, update)
+			return nil
+		}, Options{})
+
+		if err := r.Finish(tt.result, tt.err); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(updates) != 1 || updates[0] != tt.expected || !updates[0].State.IsTerminal() {
+			t.Errorf("expected %+v, got %+v", tt.expected, updates)
+		}
+	}
+}
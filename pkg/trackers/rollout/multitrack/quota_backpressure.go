@@ -0,0 +1,102 @@
+package multitrack
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	terminatingPodsCacheTTL     = 5 * time.Second
+	terminatingPodsCheckTimeout = 5 * time.Second
+)
+
+// quotaUsageRegex matches the usage part of the quota admission error, e.g.
+// "exceeded quota: compute, requested: cpu=500m, used: cpu=3900m, limited: cpu=4000m".
+var quotaUsageRegex = regexp.MustCompile(`used: ([^\s]+), limited: ([^\s]+)`)
+
+type terminatingPodsCount struct {
+	Count     int
+	CheckedAt time.Time
+}
+
+// checkQuotaBackPressure returns the description of the quota back-pressure when the failure is caused by exceeded quota
+// while Pods are still terminating in the namespace of the resource: such a failure is transient and is not counted.
+func (mt *multitracker) checkQuotaBackPressure(spec MultitrackSpec, reason string) (string, bool) {
+	if mt.kube == nil || ClassifyFailedReason(reason) != FailureCodeQuotaExceeded {
+		return "", false
+	}
+
+	terminatingPods := mt.countTerminatingPods(spec.Namespace)
+	if terminatingPods == 0 {
+		return "", false
+	}
+
+	desc := fmt.Sprintf("waiting for quota to free up: %d old pods still terminating", terminatingPods)
+	if usage := formatQuotaUsage(reason); usage != "" {
+		desc = fmt.Sprintf("%s (%s)", desc, usage)
+	}
+
+	return desc, true
+}
+
+// countTerminatingPods is called on every quota failure, so the result is cached for a short period
+// to avoid listing Pods of the namespace on each repeated FailedCreate event.
+func (mt *multitracker) countTerminatingPods(namespace string) int {
+	if cached, hasKey := mt.terminatingPodsByNamespace[namespace]; hasKey && time.Since(cached.CheckedAt) < terminatingPodsCacheTTL {
+		return cached.Count
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), terminatingPodsCheckTimeout)
+	defer cancel()
+
+	count := 0
+	pods, err := mt.kube.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		if debug() {
+			fmt.Printf("unable to list pods in namespace %q to check quota back-pressure: %s\n", namespace, err)
+		}
+	} else {
+		for _, pod := range pods.Items {
+			if pod.DeletionTimestamp != nil {
+				count++
+			}
+		}
+	}
+
+	mt.terminatingPodsByNamespace[namespace] = terminatingPodsCount{Count: count, CheckedAt: time.Now()}
+
+	return count
+}
+
+// formatQuotaUsage returns quota usage from the quota admission error, e.g. "cpu quota 3900m/4000m used".
+func formatQuotaUsage(reason string) string {
+	match := quotaUsageRegex.FindStringSubmatch(reason)
+	if match == nil {
+		return ""
+	}
+
+	limited := map[string]string{}
+	for _, pair := range strings.Split(match[2], ",") {
+		if parts := strings.SplitN(pair, "=", 2); len(parts) == 2 {
+			limited[parts[0]] = parts[1]
+		}
+	}
+
+	var usage []string
+	for _, pair := range strings.Split(match[1], ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		if limit, hasKey := limited[parts[0]]; hasKey {
+			usage = append(usage, fmt.Sprintf("%s quota %s/%s used", parts[0], parts[1], limit))
+		}
+	}
+
+	return strings.Join(usage, ", ")
+}
@@ -0,0 +1,60 @@
+package multitrack
+
+import (
+	"fmt"
+
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func validateClientRateLimits(opts MultitrackOptions) error {
+	if (opts.QPS != 0 || opts.Burst != 0) && opts.RestConfig == nil {
+		return fmt.Errorf("RestConfig option is required to set QPS and Burst of the clients")
+	}
+	if opts.Burst < 0 {
+		return fmt.Errorf("invalid Burst %d: should not be negative", opts.Burst)
+	}
+	return nil
+}
+
+// withClientRateLimits returns the copy of the config with QPS and Burst options applied.
+func withClientRateLimits(config *rest.Config, opts MultitrackOptions) *rest.Config {
+	if opts.QPS == 0 && opts.Burst == 0 {
+		return config
+	}
+
+	config = rest.CopyConfig(config)
+	if opts.QPS != 0 {
+		config.QPS = opts.QPS
+	}
+	if opts.Burst != 0 {
+		config.Burst = opts.Burst
+	}
+	// The rate limiter of the config takes precedence over QPS and Burst
+	config.RateLimiter = nil
+
+	return config
+}
+
+// newRateLimitedClients returns the clients built from RestConfig with QPS and Burst options,
+// DynamicClient is rebuilt only when it is set.
+func newRateLimitedClients(opts MultitrackOptions) (kubernetes.Interface, dynamic.Interface, error) {
+	config := withClientRateLimits(opts.RestConfig, opts)
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create kubernetes client: %s", err)
+	}
+
+	if opts.DynamicClient == nil {
+		return clientset, nil, nil
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create dynamic client: %s", err)
+	}
+
+	return clientset, dynamicClient, nil
+}
@@ -0,0 +1,231 @@
+package multitrack
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/portforward"
+	watchtools "k8s.io/client-go/tools/watch"
+	"k8s.io/client-go/transport/spdy"
+
+	"github.com/werf/kubedog/pkg/tracker"
+)
+
+const (
+	defaultReadinessHTTPCheckTimeout      = 10 * time.Second
+	readinessHTTPCheckRetryPeriod         = 5 * time.Second
+	readinessHTTPCheckRetryPeriodFastMode = 500 * time.Millisecond
+)
+
+// ReadinessHTTPCheck describes HTTP GET request performed through the port-forward to one of the ready Pods of the resource.
+// The check passes when the response status is 2xx.
+type ReadinessHTTPCheck struct {
+	Port int
+	Path string
+	// TimeoutSeconds limits a single check including port-forward setup, 0 means 10 seconds.
+	TimeoutSeconds int
+}
+
+// trackReadinessHTTPCheck performs the readiness HTTP check of the ready resource.
+// Failed check is counted as the resource failure and retried while the failure is tolerated by the spec.
+func (mt *multitracker) trackReadinessHTTPCheck(kube kubernetes.Interface, kind string, spec MultitrackSpec, resourcesStates map[string]*multitrackerResourceState, opts MultitrackOptions) error {
+	check := *spec.ReadinessHTTPCheck
+
+	parentContext := opts.ParentContext
+	if parentContext == nil {
+		parentContext = context.Background()
+	}
+	ctx, cancel := watchtools.ContextWithOptionalTimeout(parentContext, opts.Timeout)
+	defer cancel()
+
+	retryPeriod := readinessHTTPCheckRetryPeriod
+	if opts.FastMode {
+		retryPeriod = readinessHTTPCheckRetryPeriodFastMode
+	}
+
+	func() {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		mt.displayResourceTrackerMessageF(kind, spec, "running readiness HTTP check GET :%d%s", check.Port, check.Path)
+	}()
+
+	for {
+		podName, err := mt.runReadinessHTTPCheck(ctx, kube, kind, spec, check, opts)
+		if err == nil {
+			mt.mux.Lock()
+			defer mt.mux.Unlock()
+
+			mt.displayResourceTrackerMessageF(kind, spec, "readiness HTTP check of %s passed", tracker.FormatResourceID("po", spec.Namespace, podName))
+
+			return nil
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		reason := fmt.Sprintf("readiness HTTP check GET :%d%s failed: %s", check.Port, check.Path, err)
+		if podName != "" {
+			reason = fmt.Sprintf("%s %s", tracker.FormatResourceID("po", spec.Namespace, podName), reason)
+		}
+
+		if err := func() error {
+			mt.mux.Lock()
+			defer mt.mux.Unlock()
+
+			mt.displayResourceErrorF(kind, spec, "%s", reason)
+
+			return mt.handleResourceFailure(resourcesStates, kind, spec, reason)
+		}(); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retryPeriod):
+		}
+	}
+}
+
+// runReadinessHTTPCheck returns the name of the checked Pod, which is empty when there is no Pod to check.
+func (mt *multitracker) runReadinessHTTPCheck(ctx context.Context, kube kubernetes.Interface, kind string, spec MultitrackSpec, check ReadinessHTTPCheck, opts MultitrackOptions) (string, error) {
+	if opts.RestConfig == nil {
+		return "", fmt.Errorf("RestConfig multitrack option is required")
+	}
+
+	timeout := defaultReadinessHTTPCheckTimeout
+	if check.TimeoutSeconds > 0 {
+		timeout = time.Duration(check.TimeoutSeconds) * time.Second
+	}
+	checkCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	podName, err := getReadyPodName(checkCtx, kube, kind, spec.Namespace, spec.ResourceName)
+	if err != nil {
+		return "", err
+	}
+
+	transport, upgrader, err := spdy.RoundTripperFor(opts.RestConfig)
+	if err != nil {
+		return podName, fmt.Errorf("unable to create port-forward transport: %s", err)
+	}
+	req := kube.CoreV1().RESTClient().Post().Resource("pods").Namespace(spec.Namespace).Name(podName).SubResource("portforward")
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, req.URL())
+
+	stopChan := make(chan struct{})
+	readyChan := make(chan struct{})
+	forwarder, err := portforward.NewOnAddresses(dialer, []string{"127.0.0.1"}, []string{fmt.Sprintf("0:%d", check.Port)}, stopChan, readyChan, ioutil.Discard, ioutil.Discard)
+	if err != nil {
+		return podName, fmt.Errorf("unable to create port-forward: %s", err)
+	}
+
+	forwardErrChan := make(chan error, 1)
+	go func() {
+		forwardErrChan <- forwarder.ForwardPorts()
+	}()
+	// Stopping the forwarder closes listeners and streams in any case: on success, on error and on timeout
+	defer close(stopChan)
+
+	select {
+	case <-readyChan:
+	case err := <-forwardErrChan:
+		return podName, fmt.Errorf("port-forward failed: %s", err)
+	case <-checkCtx.Done():
+		return podName, fmt.Errorf("port-forward is not ready after %s", timeout)
+	}
+
+	ports, err := forwarder.GetPorts()
+	if err != nil || len(ports) == 0 {
+		return podName, fmt.Errorf("unable to get forwarded port: %v", err)
+	}
+
+	httpReq, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://127.0.0.1:%d%s", ports[0].Local, check.Path), nil)
+	if err != nil {
+		return podName, err
+	}
+
+	resp, err := http.DefaultClient.Do(httpReq.WithContext(checkCtx))
+	if err != nil {
+		return podName, fmt.Errorf("connection error: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return podName, fmt.Errorf("HTTP status %s", resp.Status)
+	}
+
+	return podName, nil
+}
+
+// getReadyPodName returns the first by name ready Pod of the Deployment, ReplicaSet, StatefulSet or DaemonSet.
+func getReadyPodName(ctx context.Context, kube kubernetes.Interface, kind, namespace, name string) (string, error) {
+	var selector *metav1.LabelSelector
+
+	switch kind {
+	case "deploy":
+		object, err := kube.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		selector = object.Spec.Selector
+	case "rs":
+		object, err := kube.AppsV1().ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		selector = object.Spec.Selector
+	case "sts":
+		object, err := kube.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		selector = object.Spec.Selector
+	case "ds":
+		object, err := kube.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		selector = object.Spec.Selector
+	default:
+		return "", fmt.Errorf("readiness HTTP check is not supported for %s", kind)
+	}
+
+	labelSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return "", err
+	}
+
+	pods, err := kube.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector.String()})
+	if err != nil {
+		return "", err
+	}
+
+	var readyPodsNames []string
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type == corev1.PodReady && cond.Status == corev1.ConditionTrue {
+				readyPodsNames = append(readyPodsNames, pod.Name)
+				break
+			}
+		}
+	}
+
+	if len(readyPodsNames) == 0 {
+		return "", fmt.Errorf("no ready pods found")
+	}
+
+	sort.Strings(readyPodsNames)
+
+	return readyPodsNames[0], nil
+}
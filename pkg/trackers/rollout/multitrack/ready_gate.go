@@ -0,0 +1,102 @@
+package multitrack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/werf/kubedog/pkg/tracker"
+)
+
+// ResourceRef references the tracked resource by short kind (po, deploy, rs, sts, ds, job or generic), namespace and name.
+type ResourceRef struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+func (r ResourceRef) String() string {
+	return tracker.FormatResourceID(r.Kind, r.Namespace, r.Name)
+}
+
+func (r ResourceRef) spec() MultitrackSpec {
+	return MultitrackSpec{ResourceName: r.Name, Namespace: r.Namespace}
+}
+
+func validateReturnOnReadyResources(specs MultitrackSpecs, refs []ResourceRef) error {
+	kindsSpecs := specsByKind(specs)
+
+	for _, ref := range refs {
+		kindSpecs, isKnownKind := kindsSpecs[ref.Kind]
+		if !isKnownKind {
+			return fmt.Errorf("bad ReturnOnReadyResources resource %s: unknown kind %q, expected one of po, deploy, rs, sts, ds, job or generic", ref, ref.Kind)
+		}
+
+		isTracked := false
+		for _, spec := range kindSpecs {
+			if spec.ResourceName == ref.Name && spec.Namespace == ref.Namespace {
+				isTracked = true
+				break
+			}
+		}
+		if !isTracked {
+			return fmt.Errorf("bad ReturnOnReadyResources resource %s: resource is not tracked", ref)
+		}
+	}
+
+	return nil
+}
+
+// checkReadyGate notifies the main loop once all ReturnOnReadyResources are ready.
+func (mt *multitracker) checkReadyGate() {
+	if len(mt.returnOnReadyResources) == 0 || mt.isReadyGateReached {
+		return
+	}
+
+	for _, ref := range mt.returnOnReadyResources {
+		if mt.resourcesStatesByKind(ref.Kind)[resourceKey(ref.spec())].Status != ResourceSucceeded {
+			return
+		}
+	}
+
+	mt.isReadyGateReached = true
+	close(mt.readyGateChan)
+}
+
+// stopTracking cancels trackers of all resources which are still tracked.
+func (mt *multitracker) stopTracking() {
+	mt.mux.Lock()
+	defer mt.mux.Unlock()
+
+	mt.isTerminating = true
+
+	for _, contexts := range []map[string]*multitrackerContext{mt.PodsContexts, mt.DeploymentsContexts, mt.ReplicaSetsContexts, mt.StatefulSetsContexts, mt.DaemonSetsContexts, mt.JobsContexts, mt.GenericContexts} {
+		for _, ctx := range contexts {
+			ctx.CancelFunc()
+		}
+	}
+}
+
+func (mt *multitracker) formatReadyGateMessage(isDetached bool) string {
+	var refs []string
+	for _, ref := range mt.returnOnReadyResources {
+		refs = append(refs, mt.resourceID(ref.Kind, ref.spec()))
+	}
+
+	remaining := 0
+	for _, kind := range mt.trackedKinds() {
+		for _, state := range kind.States {
+			if state.Status != ResourceSucceeded && state.Status != ResourceFailed {
+				remaining++
+			}
+		}
+	}
+
+	switch {
+	case remaining == 0:
+		return fmt.Sprintf("%s ready: returning early", strings.Join(refs, ", "))
+	case isDetached:
+		return fmt.Sprintf("%s ready: returning early, %d resources continue tracking in background", strings.Join(refs, ", "), remaining)
+	default:
+		return fmt.Sprintf("%s ready: returning early, stop tracking of %d resources", strings.Join(refs, ", "), remaining)
+	}
+}
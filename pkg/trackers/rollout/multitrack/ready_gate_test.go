@@ -0,0 +1,146 @@
+package multitrack
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/werf/kubedog/pkg/display"
+)
+
+func TestValidateReturnOnReadyResources(t *testing.T) {
+	specs := MultitrackSpecs{
+		Deployments: []MultitrackSpec{{ResourceName: "api", Namespace: "prod"}},
+		Jobs:        []MultitrackSpec{{ResourceName: "migrate", Namespace: "prod"}},
+	}
+
+	tests := []struct {
+		refs        []ResourceRef
+		expectedErr string
+	}{
+		{refs: nil},
+		{refs: []ResourceRef{{Kind: "deploy", Namespace: "prod", Name: "api"}, {Kind: "job", Namespace: "prod", Name: "migrate"}}},
+		{
+			refs:        []ResourceRef{{Kind: "deployment", Namespace: "prod", Name: "api"}},
+			expectedErr: `bad ReturnOnReadyResources resource deploy/prod/api: unknown kind "deployment", expected one of po, deploy, rs, sts, ds, job or generic`,
+		},
+		{
+			refs:        []ResourceRef{{Kind: "deploy", Namespace: "staging", Name: "api"}},
+			expectedErr: "bad ReturnOnReadyResources resource deploy/staging/api: resource is not tracked",
+		},
+		{
+			refs:        []ResourceRef{{Kind: "deploy", Namespace: "prod", Name: "api"}, {Kind: "job", Namespace: "prod", Name: "api"}},
+			expectedErr: "bad ReturnOnReadyResources resource job/prod/api: resource is not tracked",
+		},
+	}
+
+	for _, tt := range tests {
+		err := validateReturnOnReadyResources(specs, tt.refs)
+		switch {
+		case tt.expectedErr == "" && err != nil:
+			t.Errorf("%v: unexpected error: %s", tt.refs, err)
+		case tt.expectedErr != "" && (err == nil || err.Error() != tt.expectedErr):
+			t.Errorf("%v: expected error %q, got %v", tt.refs, tt.expectedErr, err)
+		}
+	}
+}
+
+// TestMultitrackReturnOnReadyResources makes the first Pod ready while the second one is pending:
+// Multitrack returns once the first Pod is ready, either stopping the second one or tracking it in background.
+func TestMultitrackReturnOnReadyResources(t *testing.T) {
+	for _, detach := range []bool{false, true} {
+		name := "stop tracking"
+		if detach {
+			name = "detach"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			kube := fake.NewSimpleClientset()
+			pods, specs := createTestPods(t, kube, 2)
+
+			var secondReadyMux sync.Mutex
+			isSecondReady := false
+			setSecondReady := func() {
+				secondReadyMux.Lock()
+				defer secondReadyMux.Unlock()
+				isSecondReady = true
+			}
+
+			stopUpdates := runTestPodsUpdates(t, kube, pods, func(i, step int, pod *corev1.Pod) *corev1.Pod {
+				return pod
+			}, func(i int, pod *corev1.Pod) *corev1.Pod {
+				secondReadyMux.Lock()
+				defer secondReadyMux.Unlock()
+				if i == 0 || isSecondReady {
+					return setTestPodReady(pod)
+				}
+				return pod
+			})
+			defer stopUpdates()
+
+			output := &bytes.Buffer{}
+			opts := MultitrackOptions{
+				FailedContainerLogLines: -1,
+				ReturnOnReadyResources:  []ResourceRef{{Kind: "po", Namespace: "ns-0", Name: "app-0"}},
+				DetachOnReturn:          detach,
+			}
+			opts.Display = display.NewDisplay(output, nil)
+
+			result, session, err := MultitrackWithSession(kube, MultitrackSpecs{Pods: specs}, opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if resource := findTestResourceResult(result, "po", "app-0"); resource == nil || resource.Outcome != ResourceOutcomeReady {
+				t.Errorf("expected Ready outcome of app-0, got %+v", resource)
+			}
+			if resource := findTestResourceResult(result, "po", "app-1"); resource == nil || resource.Outcome != ResourceOutcomeNotReady {
+				t.Errorf("expected NotReady outcome of app-1, got %+v", resource)
+			}
+
+			if !detach {
+				if session != nil {
+					t.Errorf("expected no session without DetachOnReturn")
+				}
+				if !strings.Contains(output.String(), "po/ns-0/app-0 ready: returning early, stop tracking of 1 resources") {
+					t.Errorf("expected early return message:\n%s", output.String())
+				}
+				return
+			}
+
+			if session == nil {
+				t.Fatalf("expected session with DetachOnReturn")
+			}
+			setSecondReady()
+
+			waitDone := make(chan struct{})
+			var sessionResult MultitrackResult
+			go func() {
+				defer close(waitDone)
+				sessionResult, err = session.Wait()
+			}()
+			select {
+			case <-waitDone:
+			case <-time.After(testMultitrackTimeout):
+				t.Fatalf("session has not finished within %s", testMultitrackTimeout)
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected session error: %s", err)
+			}
+			for _, name := range []string{"app-0", "app-1"} {
+				if resource := findTestResourceResult(sessionResult, "po", name); resource == nil || resource.Outcome != ResourceOutcomeReady {
+					t.Errorf("expected Ready outcome of %s in session result, got %+v", name, resource)
+				}
+			}
+			if !strings.Contains(output.String(), "po/ns-0/app-0 ready: returning early, 1 resources continue tracking in background") {
+				t.Errorf("expected early return message:\n%s", output.String())
+			}
+		})
+	}
+}
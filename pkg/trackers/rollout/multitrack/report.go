@@ -0,0 +1,1169 @@
+package multitrack
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	"github.com/werf/logboek/pkg/style"
+	"github.com/werf/logboek/pkg/types"
+
+	"github.com/werf/kubedog/pkg/tracker"
+	"github.com/werf/kubedog/pkg/tracker/deployment"
+	"github.com/werf/kubedog/pkg/tracker/event"
+	"github.com/werf/kubedog/pkg/tracker/indicators"
+	"github.com/werf/kubedog/pkg/tracker/pod"
+	"github.com/werf/kubedog/pkg/utils"
+)
+
+var (
+	statusProgressTableRatio    = []float64{.58, .11, .12, .19}
+	statusProgressSubTableRatio = []float64{.40, .15, .20, .25}
+)
+
+// filterLogChunkLines returns the formatted lines of the chunk matching the log regexp of the container or LogRegex of the spec.
+// Each line is matched as a whole, so the empty regexp or the one matching the empty string shows all lines.
+func filterLogChunkLines(spec MultitrackSpec, prefix string, chunk *pod.ContainerLogChunk) []string {
+	var logRegexp *regexp.Regexp
+	if spec.LogRegexByContainerName[chunk.ContainerName] != nil {
+		logRegexp = spec.LogRegexByContainerName[chunk.ContainerName]
+	} else if spec.LogRegex != nil {
+		logRegexp = spec.LogRegex
+	}
+
+	showLines := []string{}
+
+	if logRegexp != nil {
+		for _, logLine := range chunk.LogLines {
+			if logRegexp.MatchString(logLine.Message) || logLine.Message == pod.LogOutputTruncatedMessage {
+				showLines = append(showLines, formatLogLine(spec, prefix, logLine))
+			}
+		}
+	} else {
+		for _, logLine := range chunk.LogLines {
+			showLines = append(showLines, formatLogLine(spec, prefix, logLine))
+		}
+	}
+
+	return showLines
+}
+
+func (mt *multitracker) displayResourceLogChunk(resourceKind string, spec MultitrackSpec, podName string, chunk *pod.ContainerLogChunk) {
+	if spec.SkipLogs || mt.isQuiet() {
+		return
+	}
+
+	if !isContainerLogsShown(spec, chunk.ContainerName) {
+		return
+	}
+
+	prefix := mt.formatLogLinePrefix(resourceKind, spec, podName, chunk.ContainerName)
+	showLines := filterLogChunkLines(spec, prefix, chunk)
+
+	if len(showLines) > 0 {
+		header := fmt.Sprintf("container/%s", chunk.ContainerName)
+		if resourceKind != "po" {
+			header = podContainerLogChunkHeader(spec.Namespace, podName, chunk)
+		}
+
+		source := displaySource{
+			Header: fmt.Sprintf("%s %s logs", mt.resourceID(resourceKind, spec), header),
+			Options: func(options types.LogProcessOptionsInterface) {
+				options.WithoutElapsedTime()
+			},
+		}
+
+		if mt.logBlocks {
+			mt.bufferLogBlock(source, showLines)
+			return
+		}
+
+		// Prefixed lines carry their origin, so they are displayed without sections and interleave with other output
+		if prefix != "" {
+			source = displaySource{}
+		}
+
+		mt.display.write(source, func() {
+			for _, line := range showLines {
+				if mt.outputAdapter != nil {
+					mt.outputAdapter.Line(line)
+				} else {
+					mt.logger.LogF("%s\n", line)
+				}
+			}
+		})
+	}
+}
+
+// isContainerLogsShown applies ShowLogsOnlyForContainers first: when it is set, only listed containers are shown.
+// SkipLogsForContainers then excludes containers from the allowed set, so a container listed in both is skipped.
+func isContainerLogsShown(spec MultitrackSpec, containerName string) bool {
+	if len(spec.ShowLogsOnlyForContainers) > 0 && !isElemInArray(spec.ShowLogsOnlyForContainers, containerName) {
+		return false
+	}
+
+	return !isElemInArray(spec.SkipLogsForContainers, containerName)
+}
+
+// validateLogsContainers warns once per resource about container names from SkipLogsForContainers
+// and ShowLogsOnlyForContainers which are not defined in the spec of the first observed Pod, usually it is a typo.
+func (mt *multitracker) validateLogsContainers(states map[string]*multitrackerResourceState, resourceKind string, spec MultitrackSpec, pods map[string]pod.PodStatus) {
+	if len(spec.SkipLogsForContainers) == 0 && len(spec.ShowLogsOnlyForContainers) == 0 {
+		return
+	}
+
+	state := states[resourceKey(spec)]
+	if state == nil || state.LogsContainersValidated {
+		return
+	}
+
+	for _, podStatus := range pods {
+		if len(podStatus.ContainersNames) == 0 {
+			continue
+		}
+
+		state.LogsContainersValidated = true
+
+		for _, option := range []struct {
+			Name            string
+			ContainersNames []string
+		}{
+			{"SkipLogsForContainers", spec.SkipLogsForContainers},
+			{"ShowLogsOnlyForContainers", spec.ShowLogsOnlyForContainers},
+		} {
+			var unknownContainersNames []string
+			for _, containerName := range option.ContainersNames {
+				if !isElemInArray(podStatus.ContainersNames, containerName) && !isElemInArray(podStatus.InitContainersNames, containerName) {
+					unknownContainersNames = append(unknownContainersNames, containerName)
+				}
+			}
+
+			if len(unknownContainersNames) > 0 {
+				mt.displayResourceTrackerMessageF(resourceKind, spec, "%s", formatResourceWarning(spec.FailMode == IgnoreAndContinueDeployProcess, fmt.Sprintf("%s contains unknown containers: %s", option.Name, strings.Join(unknownContainersNames, ", "))))
+			}
+		}
+
+		return
+	}
+}
+
+// resourceServiceMessagesSource is the source of the resource service messages shown with ShowServiceMessages.
+func (mt *multitracker) resourceServiceMessagesSource(resourceKind string, spec MultitrackSpec) displaySource {
+	return displaySource{
+		Header: fmt.Sprintf("%s service messages", mt.resourceID(resourceKind, spec)),
+		Options: func(options types.LogProcessOptionsInterface) {
+			options.Style(style.Details())
+			options.WithoutElapsedTime()
+		},
+	}
+}
+
+func (mt *multitracker) displayResourceTrackerMessageF(resourceKind string, spec MultitrackSpec, format string, a ...interface{}) {
+	resource := mt.resourceID(resourceKind, spec)
+	msg := fmt.Sprintf(format, a...)
+	mt.serviceMessagesByResource[resource] = append(mt.serviceMessagesByResource[resource], msg)
+
+	if spec.ShowServiceMessages && !mt.isQuiet() {
+		mt.display.write(mt.resourceServiceMessagesSource(resourceKind, spec), func() {
+			if mt.outputAdapter != nil {
+				mt.outputAdapter.Line(msg)
+			} else {
+				mt.logger.Default().LogFDetails("%s\n", msg)
+			}
+		})
+	}
+}
+
+func (mt *multitracker) displayResourceEventF(resourceKind string, spec MultitrackSpec, format string, a ...interface{}) {
+	resource := mt.resourceID(resourceKind, spec)
+	msg := fmt.Sprintf(fmt.Sprintf("event: %s", format), a...)
+	mt.setEventsReceived()
+	mt.serviceMessagesByResource[resource] = append(mt.serviceMessagesByResource[resource], msg)
+
+	if mt.isQuiet() {
+		return
+	}
+
+	if eventMsg := fmt.Sprintf(format, a...); event.ReasonSeverity(eventMsgReason(eventMsg), mt.eventSeverityOverrides) == SeverityWarning {
+		mt.displayResourceEventWarning(resourceKind, spec, eventMsg)
+		return
+	}
+
+	if spec.ShowServiceMessages {
+		mt.display.write(mt.resourceServiceMessagesSource(resourceKind, spec), func() {
+			if mt.outputAdapter != nil {
+				mt.outputAdapter.Line(msg)
+			} else {
+				mt.logger.Default().LogFDetails("%s\n", msg)
+			}
+		})
+	}
+}
+
+// displayResourceWarningF shows the warning of the resource regardless of ShowServiceMessages.
+func (mt *multitracker) displayResourceWarningF(resourceKind string, spec MultitrackSpec, format string, a ...interface{}) {
+	msg := fmt.Sprintf("%s %s", mt.resourceID(resourceKind, spec), formatResourceWarning(false, fmt.Sprintf(format, a...)))
+
+	mt.display.write(displaySource{}, func() {
+		if mt.outputAdapter != nil {
+			mt.outputAdapter.Line(msg)
+			return
+		}
+
+		mt.logger.Default().LogF("%s\n", msg)
+	})
+}
+
+func (mt *multitracker) displayResourceErrorF(resourceKind string, spec MultitrackSpec, format string, a ...interface{}) {
+	msg := fmt.Sprintf(fmt.Sprintf("%s ERROR: %s", mt.resourceID(resourceKind, spec), format), a...)
+
+	mt.display.write(displaySource{}, func() {
+		if mt.outputAdapter != nil {
+			mt.outputAdapter.Line(msg)
+			return
+		}
+
+		mt.logger.Warn().LogF("%s\n", msg)
+	})
+}
+
+func (mt *multitracker) displayFailedTrackingResourcesServiceMessages() {
+	for _, kind := range mt.trackedKinds() {
+		for _, name := range sortedSpecsKeys(kind.Specs) {
+			if kind.States[name].Status != ResourceFailed {
+				continue
+			}
+
+			mt.displayResourceServiceMessages(kind.Kind, kind.Specs[name])
+		}
+	}
+}
+
+func (mt *multitracker) displayResourceServiceMessages(resourceKind string, spec MultitrackSpec) {
+	lines := mt.serviceMessagesByResource[mt.resourceID(resourceKind, spec)]
+	if mt.isEventsUnavailable() {
+		lines = append(lines[:len(lines):len(lines)], fmt.Sprintf("event: %s", eventsUnavailableMessage))
+	}
+
+	if len(lines) > 0 {
+		lines = append([]string(nil), lines...)
+
+		mt.display.write(displaySource{}, func() {
+			if mt.outputAdapter != nil {
+				mt.outputAdapter.BeginSection(fmt.Sprintf("Failed resource %s service messages", mt.resourceID(resourceKind, spec)))
+				for _, line := range lines {
+					mt.outputAdapter.Line(line)
+				}
+				mt.outputAdapter.EndSection()
+
+				return
+			}
+
+			mt.logger.LogOptionalLn()
+
+			mt.logger.Default().LogBlock("Failed resource %s service messages", mt.resourceID(resourceKind, spec)).
+				Options(func(options types.LogBlockOptionsInterface) {
+					options.WithoutLogOptionalLn()
+					options.Style(style.Details())
+				}).
+				Do(func() {
+					for _, line := range lines {
+						mt.logger.Default().LogFDetails("%s\n", line)
+					}
+				})
+
+			mt.logger.LogOptionalLn()
+		})
+	}
+}
+
+func (mt *multitracker) displayMultitrackServiceMessageF(format string, a ...interface{}) {
+	if mt.isQuiet() {
+		return
+	}
+
+	msg := fmt.Sprintf(format, a...)
+
+	mt.display.write(displaySource{}, func() {
+		if mt.outputAdapter != nil {
+			outputAdapterLines(mt.outputAdapter, msg)
+			return
+		}
+
+		mt.logger.Default().LogFHighlight("%s", msg)
+	})
+}
+
+func (mt *multitracker) displayMultitrackErrorMessageF(format string, a ...interface{}) {
+	msg := fmt.Sprintf(format, a...)
+
+	mt.display.write(displaySource{}, func() {
+		if mt.outputAdapter != nil {
+			outputAdapterLines(mt.outputAdapter, msg)
+			return
+		}
+
+		mt.logger.Warn().LogF("%s", msg)
+	})
+}
+
+// renderStatusProgressTables renders the tables of the kinds with resources shown in the status report.
+func (mt *multitracker) renderStatusProgressTables() []string {
+	var tables []string
+	for _, table := range []string{
+		mt.renderPodsStatusProgress(),
+		mt.renderDeploymentsStatusProgress(),
+		mt.renderReplicaSetsStatusProgress(),
+		mt.renderStatefulSetsStatusProgress(),
+		mt.renderDaemonSetsStatusProgress(),
+		mt.renderJobsStatusProgress(),
+		mt.renderGenericStatusProgress(),
+	} {
+		if table != "" {
+			tables = append(tables, table)
+		}
+	}
+	return tables
+}
+
+// displayStatusProgress shows the status report, isPeriodic report may show only changed resources (OutputTextDelta).
+func (mt *multitracker) displayStatusProgress(isPeriodic bool) error {
+	if mt.logBlocks {
+		mt.flushLogBlocks()
+	}
+
+	if mt.output == OutputJSONEvents {
+		mt.emitSnapshotEvent()
+		return nil
+	}
+	if isJSONChunkedOutput(mt.output) {
+		mt.emitChunkedReport()
+		return nil
+	}
+	if mt.output == OutputKubectlRolloutStatus {
+		return nil
+	}
+	// The final report is shown anyway
+	if isPeriodic && mt.isQuiet() {
+		return nil
+	}
+
+	var summary string
+	if mt.output == OutputTextDelta {
+		summary = formatStatusReportSummary(mt.selectStatusReportResources(isPeriodic))
+	}
+
+	var tables []string
+	if remaining := mt.startupGracePeriodRemaining(); remaining > 0 {
+		tables = append(tables, utils.BlueString("grace period: %s remaining", remaining.Truncate(time.Second))+"\n")
+	}
+
+	mt.samplePacing(time.Now())
+	if pacing := mt.formatBudgetPacing(time.Now()); pacing != "" {
+		tables = append(tables, utils.YellowString("%s", pacing)+"\n")
+	}
+	if retries := mt.formatConnectionsRetries(); retries != "" {
+		tables = append(tables, utils.YellowString("%s", retries)+"\n")
+	}
+
+	if mt.groupByLabel != "" {
+		tables = append(tables, mt.renderGroupedStatusProgressTables()...)
+	} else {
+		tables = append(tables, mt.renderStatusProgressTables()...)
+	}
+	if summary != "" {
+		tables = append(tables, summary+"\n")
+	}
+
+	mt.display.write(displaySource{}, func() {
+		if mt.outputAdapter != nil {
+			mt.outputAdapter.BeginSection("Status progress")
+			for _, table := range tables {
+				outputAdapterLines(mt.outputAdapter, table)
+			}
+			mt.outputAdapter.EndSection()
+
+			return
+		}
+
+		if mt.display.displayedBefore() {
+			mt.logger.LogOptionalLn()
+		}
+
+		caption := utils.BoldString("Status progress")
+
+		mt.logger.Default().LogBlock(caption).
+			Options(func(options types.LogBlockOptionsInterface) {
+				options.WithoutLogOptionalLn()
+			}).
+			Do(func() {
+				for _, table := range tables {
+					mt.logger.LogF(table)
+				}
+			})
+
+		mt.logger.LogOptionalLn()
+	})
+
+	return nil
+}
+
+// statusReportPhase returns the phase marking the resource in the status report.
+func statusReportPhase(isReady, isFailed bool) ResourcePhase {
+	switch {
+	case isReady:
+		return ResourcePhaseReady
+	case isFailed:
+		return ResourcePhaseFailed
+	default:
+		return ResourcePhaseProgressing
+	}
+}
+
+func (mt *multitracker) statusReportRenderOptions() StatusReportRenderOptions {
+	return StatusReportRenderOptions{Width: mt.logger.Streams().ContentWidth() - 1, NoColor: mt.noColor}
+}
+
+// renderStatusReportTable renders the table of the kind, returns empty string when the kind has no resources in the status report.
+func (mt *multitracker) renderStatusReportTable(table StatusReportTable) string {
+	if len(table.Rows) == 0 {
+		return ""
+	}
+	return RenderStatusReportTable(table, mt.statusReportRenderOptions())
+}
+
+func (mt *multitracker) renderJobsStatusProgress() string {
+	table := StatusReportTable{
+		Columns:       []string{"JOB", "ACTIVE", "DURATION", "SUCCEEDED/FAILED"},
+		ColumnsRatio:  statusProgressTableRatio,
+		CountersCells: []int{0, 2},
+	}
+
+	for _, name := range mt.statusReportKeys("job", mt.JobsSpecs) {
+		prevStatus := mt.PrevJobsStatuses[name]
+		status := mt.JobsStatuses[name]
+
+		spec := mt.JobsSpecs[name]
+
+		showProgress := status.StatusGeneration > prevStatus.StatusGeneration
+		disableWarningColors := spec.FailMode == IgnoreAndContinueDeployProcess
+
+		row := StatusReportRow{
+			Name:     formatSpecResourceName(mt.formatResourceName("job", spec), spec),
+			Phase:    statusReportPhase(status.IsSucceeded, status.IsFailed),
+			FailMode: spec.FailMode,
+		}
+
+		if status.StatusGeneration == 0 && !status.IsFailed {
+			row.Cells = []string{"-", "-", "-"}
+			row.Messages = []string{mt.formatResourceStatusAvailability(mt.TrackingJobs[name])}
+			table.Rows = append(table.Rows, row)
+			mt.PrevJobsStatuses[name] = status
+			continue
+		}
+
+		succeeded := "-"
+		if status.SucceededIndicator != nil {
+			succeeded = status.SucceededIndicator.FormatTableElem(prevStatus.SucceededIndicator, indicators.FormatTableElemOptions{
+				ShowProgress:         showProgress,
+				DisableWarningColors: disableWarningColors,
+			})
+		}
+
+		row.Cells = []string{fmt.Sprintf("%d", status.Active), status.Duration, strings.Join([]string{succeeded, fmt.Sprintf("%d", status.Failed)}, "/")}
+		if status.IsFailed {
+			row.Messages = append(row.Messages, formatResourceError(disableWarningColors, status.FailedReason))
+		} else {
+			if status.Progress != nil && !status.IsSucceeded {
+				row.Messages = append(row.Messages, utils.BlueString("progress %s", status.Progress))
+			}
+			for _, w := range status.WarningMessages {
+				row.Messages = append(row.Messages, formatResourceWarning(disableWarningColors, w))
+			}
+		}
+
+		if len(status.Pods) > 0 {
+			newPodsNames := []string{}
+			for podName := range status.Pods {
+				newPodsNames = append(newPodsNames, podName)
+			}
+
+			podsTable := mt.childPodsStatusReportTable(prevStatus.Pods, status.Pods, newPodsNames, spec.FailMode, showProgress, disableWarningColors)
+			if len(status.WaitingForMessages) > 0 {
+				podsTable.Footer += "---\n"
+				podsTable.Footer += utils.BlueString("Waiting for: %s", strings.Join(status.WaitingForMessages, ", "))
+			}
+			row.SubTables = append(row.SubTables, podsTable)
+		}
+
+		table.Rows = append(table.Rows, row)
+		mt.PrevJobsStatuses[name] = status
+	}
+
+	return mt.renderStatusReportTable(table)
+}
+
+func (mt *multitracker) renderPodsStatusProgress() string {
+	table := StatusReportTable{
+		Columns:       []string{"POD", "READY", "RESTARTS", "STATUS"},
+		ColumnsRatio:  statusProgressTableRatio,
+		CountersCells: []int{0, 1},
+	}
+
+	for _, name := range mt.statusReportKeys("po", mt.PodsSpecs) {
+		prevStatus := mt.PrevPodsStatuses[name]
+		status := mt.PodsStatuses[name]
+
+		spec := mt.PodsSpecs[name]
+
+		showProgress := status.StatusGeneration > prevStatus.StatusGeneration
+		disableWarningColors := spec.FailMode == IgnoreAndContinueDeployProcess
+
+		row := StatusReportRow{
+			Name:     formatSpecResourceName(mt.formatResourceName("po", spec), spec),
+			Phase:    statusReportPhase(status.IsReady || status.IsSucceeded, status.IsFailed),
+			FailMode: spec.FailMode,
+		}
+
+		if status.StatusGeneration == 0 && !status.IsFailed {
+			row.Cells = []string{"-", "-", "-"}
+			row.Messages = []string{mt.formatResourceStatusAvailability(mt.TrackingPods[name])}
+			table.Rows = append(table.Rows, row)
+			mt.PrevPodsStatuses[name] = status
+			continue
+		}
+
+		ready := fmt.Sprintf("%d/%d", status.ReadyContainers, status.TotalContainers)
+
+		podStatus := "-"
+		if status.StatusIndicator != nil {
+			podStatus = status.StatusIndicator.FormatTableElem(prevStatus.StatusIndicator, indicators.FormatTableElemOptions{
+				ShowProgress:         showProgress,
+				DisableWarningColors: disableWarningColors,
+				IsResourceNew:        true,
+			})
+		}
+
+		row.Cells = []string{ready, fmt.Sprintf("%d", status.Restarts), podStatus}
+		if status.IsFailed {
+			row.Messages = append(row.Messages, formatResourceError(disableWarningColors, status.FailedReason))
+		} else if status.IsSucceeded {
+			if completion := formatPodCompletion(status); completion != "" {
+				row.Messages = append(row.Messages, completion)
+			}
+		} else if initProgress := formatPodInitProgress(status); initProgress != "" {
+			row.Messages = append(row.Messages, initProgress)
+		} else if status.UnschedulableMessage != "" {
+			row.Messages = append(row.Messages, utils.YellowString("unschedulable: %s", firstLine(status.UnschedulableMessage)))
+		}
+
+		table.Rows = append(table.Rows, row)
+		mt.PrevPodsStatuses[name] = status
+	}
+
+	return mt.renderStatusReportTable(table)
+}
+
+func (mt *multitracker) renderStatefulSetsStatusProgress() string {
+	table := StatusReportTable{
+		Columns:       []string{"STATEFULSET", "REPLICAS", "READY", "UP-TO-DATE"},
+		ColumnsRatio:  statusProgressTableRatio,
+		CountersCells: []int{0, 1, 2},
+	}
+
+	for _, name := range mt.statusReportKeys("sts", mt.StatefulSetsSpecs) {
+		prevStatus := mt.PrevStatefulSetsStatuses[name]
+		status := mt.StatefulSetsStatuses[name]
+
+		spec := mt.StatefulSetsSpecs[name]
+
+		showProgress := status.StatusGeneration > prevStatus.StatusGeneration
+		disableWarningColors := spec.FailMode == IgnoreAndContinueDeployProcess
+
+		row := StatusReportRow{
+			Name:     formatSpecResourceName(mt.formatResourceName("sts", spec), spec),
+			Phase:    statusReportPhase(status.IsReady, status.IsFailed),
+			FailMode: spec.FailMode,
+		}
+
+		if status.StatusGeneration == 0 && !status.IsFailed {
+			row.Cells = []string{"-", "-", "-"}
+			row.Messages = []string{mt.formatResourceStatusAvailability(mt.TrackingStatefulSets[name])}
+			table.Rows = append(table.Rows, row)
+			mt.PrevStatefulSetsStatuses[name] = status
+			continue
+		}
+
+		replicas := "-"
+		if status.ReplicasIndicator != nil {
+			replicas = status.ReplicasIndicator.FormatTableElem(prevStatus.ReplicasIndicator, indicators.FormatTableElemOptions{
+				ShowProgress:         showProgress,
+				DisableWarningColors: disableWarningColors,
+				WithTargetValue:      true,
+			})
+		}
+
+		ready := "-"
+		if status.ReadyIndicator != nil {
+			ready = status.ReadyIndicator.FormatTableElem(prevStatus.ReadyIndicator, indicators.FormatTableElemOptions{
+				ShowProgress:         showProgress,
+				DisableWarningColors: disableWarningColors,
+			})
+		}
+
+		uptodate := "-"
+		if status.UpToDateIndicator != nil {
+			uptodate = status.UpToDateIndicator.FormatTableElem(prevStatus.UpToDateIndicator, indicators.FormatTableElemOptions{
+				ShowProgress:         showProgress,
+				DisableWarningColors: disableWarningColors,
+			})
+		}
+
+		row.Cells = []string{replicas, ready, uptodate}
+		if status.IsFailed {
+			row.Messages = append(row.Messages, formatResourceError(disableWarningColors, status.FailedReason))
+		} else {
+			if status.IsReady && status.RequiredReadyPodsMessage != "" {
+				row.Messages = append(row.Messages, status.RequiredReadyPodsMessage)
+			} else if status.IsReady && status.PartitionMessage != "" {
+				row.Messages = append(row.Messages, status.PartitionMessage)
+			}
+			for _, w := range status.WarningMessages {
+				row.Messages = append(row.Messages, formatResourceWarning(disableWarningColors, w))
+			}
+		}
+
+		if len(status.Pods) > 0 {
+			extraMsg := ""
+			if len(status.WaitingForMessages) > 0 {
+				extraMsg += "---\n"
+				extraMsg += utils.BlueString("Waiting for: %s", strings.Join(status.WaitingForMessages, ", "))
+			}
+			extraMsg = mt.appendAllowedFailuresMessage(extraMsg, mt.TrackingStatefulSets, "sts", spec)
+			row.SubTables = mt.controllerPodsStatusReportTables(prevStatus.Pods, status.Pods, status.NewPodsNames, spec.FailMode, showProgress, disableWarningColors, extraMsg)
+		}
+
+		table.Rows = append(table.Rows, row)
+		mt.PrevStatefulSetsStatuses[name] = status
+	}
+
+	return mt.renderStatusReportTable(table)
+}
+
+func (mt *multitracker) renderDaemonSetsStatusProgress() string {
+	table := StatusReportTable{
+		Columns:       []string{"DAEMONSET", "REPLICAS", "AVAILABLE", "UP-TO-DATE"},
+		ColumnsRatio:  statusProgressTableRatio,
+		CountersCells: []int{0, 1, 2},
+	}
+
+	for _, name := range mt.statusReportKeys("ds", mt.DaemonSetsSpecs) {
+		prevStatus := mt.PrevDaemonSetsStatuses[name]
+		status := mt.DaemonSetsStatuses[name]
+
+		spec := mt.DaemonSetsSpecs[name]
+
+		showProgress := status.StatusGeneration > prevStatus.StatusGeneration
+		disableWarningColors := spec.FailMode == IgnoreAndContinueDeployProcess
+
+		row := StatusReportRow{
+			Name:     formatSpecResourceName(mt.formatResourceName("ds", spec), spec),
+			Phase:    statusReportPhase(status.IsReady, status.IsFailed),
+			FailMode: spec.FailMode,
+		}
+
+		if status.StatusGeneration == 0 && !status.IsFailed {
+			row.Cells = []string{"-", "-", "-"}
+			row.Messages = []string{mt.formatResourceStatusAvailability(mt.TrackingDaemonSets[name])}
+			table.Rows = append(table.Rows, row)
+			mt.PrevDaemonSetsStatuses[name] = status
+			continue
+		}
+
+		replicas := "-"
+		if status.ReplicasIndicator != nil {
+			replicas = status.ReplicasIndicator.FormatTableElem(prevStatus.ReplicasIndicator, indicators.FormatTableElemOptions{
+				ShowProgress:         showProgress,
+				DisableWarningColors: disableWarningColors,
+				WithTargetValue:      true,
+			})
+		}
+
+		available := "-"
+		if status.AvailableIndicator != nil {
+			available = status.AvailableIndicator.FormatTableElem(prevStatus.AvailableIndicator, indicators.FormatTableElemOptions{
+				ShowProgress:         showProgress,
+				DisableWarningColors: disableWarningColors,
+			})
+		}
+
+		uptodate := "-"
+		if status.UpToDateIndicator != nil {
+			uptodate = status.UpToDateIndicator.FormatTableElem(prevStatus.UpToDateIndicator, indicators.FormatTableElemOptions{
+				ShowProgress:         showProgress,
+				DisableWarningColors: disableWarningColors,
+			})
+		}
+
+		row.Cells = []string{replicas, available, uptodate}
+		if status.IsFailed {
+			row.Messages = append(row.Messages, formatResourceError(disableWarningColors, status.FailedReason))
+		} else if status.IsReady && status.RequiredReadyPodsMessage != "" {
+			row.Messages = append(row.Messages, status.RequiredReadyPodsMessage)
+		}
+
+		if len(status.Pods) > 0 {
+			podsTable := mt.childPodsStatusReportTable(prevStatus.Pods, status.Pods, status.NewPodsNames, spec.FailMode, showProgress, disableWarningColors)
+			if waitingForMessages := mt.formatWaitingForMessages(mt.TrackingDaemonSets, "ds", spec, status.WaitingForMessages, status.DesiredNumberScheduled); len(waitingForMessages) > 0 {
+				podsTable.Footer += "---\n"
+				podsTable.Footer += utils.BlueString("Waiting for: %s", strings.Join(waitingForMessages, ", "))
+			}
+			podsTable.Footer = mt.appendAllowedFailuresMessage(podsTable.Footer, mt.TrackingDaemonSets, "ds", spec)
+			row.SubTables = append(row.SubTables, podsTable)
+		}
+
+		table.Rows = append(table.Rows, row)
+		mt.PrevDaemonSetsStatuses[name] = status
+	}
+
+	return mt.renderStatusReportTable(table)
+}
+
+func (mt *multitracker) renderDeploymentsStatusProgress() string {
+	table := StatusReportTable{
+		Columns:       []string{"DEPLOYMENT", "REPLICAS", "AVAILABLE", "UP-TO-DATE"},
+		ColumnsRatio:  statusProgressTableRatio,
+		CountersCells: []int{0, 1, 2},
+	}
+
+	for _, name := range mt.statusReportKeys("deploy", mt.DeploymentsSpecs) {
+		prevStatus := mt.PrevDeploymentsStatuses[name]
+		status := mt.DeploymentsStatuses[name]
+		spec := mt.DeploymentsSpecs[name]
+
+		showProgress := status.StatusGeneration > prevStatus.StatusGeneration
+		disableWarningColors := spec.FailMode == IgnoreAndContinueDeployProcess
+
+		row := StatusReportRow{
+			Name:     formatSpecResourceName(mt.formatResourceName("deploy", spec), spec),
+			Phase:    statusReportPhase(status.IsReady, status.IsFailed),
+			FailMode: spec.FailMode,
+		}
+
+		if status.StatusGeneration == 0 && !status.IsFailed {
+			row.Cells = []string{"-", "-", "-"}
+			row.Messages = []string{mt.formatResourceStatusAvailability(mt.TrackingDeployments[name])}
+			table.Rows = append(table.Rows, row)
+			mt.PrevDeploymentsStatuses[name] = status
+			continue
+		}
+
+		replicas := "-"
+		if status.ReplicasIndicator != nil {
+			replicas = status.ReplicasIndicator.FormatTableElem(prevStatus.ReplicasIndicator, indicators.FormatTableElemOptions{
+				ShowProgress:         showProgress,
+				DisableWarningColors: disableWarningColors,
+				WithTargetValue:      true,
+			})
+		}
+
+		available := "-"
+		if status.AvailableIndicator != nil {
+			available = status.AvailableIndicator.FormatTableElem(prevStatus.AvailableIndicator, indicators.FormatTableElemOptions{
+				ShowProgress:         showProgress,
+				DisableWarningColors: disableWarningColors,
+			})
+		}
+
+		uptodate := "-"
+		if status.UpToDateIndicator != nil {
+			uptodate = status.UpToDateIndicator.FormatTableElem(prevStatus.UpToDateIndicator, indicators.FormatTableElemOptions{
+				ShowProgress:         showProgress,
+				DisableWarningColors: disableWarningColors,
+			})
+		}
+
+		row.Cells = []string{replicas, available, uptodate}
+		if status.IsFailed {
+			row.Messages = append(row.Messages, formatResourceError(disableWarningColors, status.FailedReason))
+		} else if status.IsReady && status.RequiredReadyPodsMessage != "" {
+			row.Messages = append(row.Messages, status.RequiredReadyPodsMessage)
+		}
+
+		if len(status.Pods) > 0 {
+			extraMsg := ""
+			if waitingForMessages := mt.formatWaitingForMessages(mt.TrackingDeployments, "deploy", spec, status.WaitingForMessages, podsSucceededDesired(status.ReplicasIndicator)); len(waitingForMessages) > 0 {
+				extraMsg += "---\n"
+				extraMsg += utils.BlueString("Waiting for: %s", strings.Join(waitingForMessages, ", "))
+			}
+			if scaleEvents := mt.TrackingDeployments[name].ScaleEvents; mt.statusVerbosity == StatusVerbosityDetailed && (len(scaleEvents) > 0 || mt.isEventsUnavailable()) {
+				if extraMsg != "" {
+					extraMsg += "\n"
+				}
+				extraMsg += "---\n"
+				if len(scaleEvents) > 0 {
+					extraMsg += utils.BlueString("Scaled: %s", deployment.FormatScaleEvents(scaleEvents))
+				} else {
+					extraMsg += utils.BlueString("Scaled: %s", eventsUnavailableMessage)
+				}
+			}
+			extraMsg = mt.appendAllowedFailuresMessage(extraMsg, mt.TrackingDeployments, "deploy", spec)
+			row.SubTables = mt.controllerPodsStatusReportTables(prevStatus.Pods, status.Pods, status.NewPodsNames, spec.FailMode, showProgress, disableWarningColors, extraMsg)
+		}
+
+		table.Rows = append(table.Rows, row)
+		mt.PrevDeploymentsStatuses[name] = status
+	}
+
+	return mt.renderStatusReportTable(table)
+}
+
+func (mt *multitracker) renderReplicaSetsStatusProgress() string {
+	table := StatusReportTable{
+		Columns:       []string{"REPLICASET", "REPLICAS", "READY", "AVAILABLE"},
+		ColumnsRatio:  statusProgressTableRatio,
+		CountersCells: []int{0, 1, 2},
+	}
+
+	for _, name := range mt.statusReportKeys("rs", mt.ReplicaSetsSpecs) {
+		prevStatus := mt.PrevReplicaSetsStatuses[name]
+		status := mt.ReplicaSetsStatuses[name]
+		spec := mt.ReplicaSetsSpecs[name]
+
+		showProgress := status.StatusGeneration > prevStatus.StatusGeneration
+		disableWarningColors := spec.FailMode == IgnoreAndContinueDeployProcess
+
+		row := StatusReportRow{
+			Name:     formatSpecResourceName(mt.formatResourceName("rs", spec), spec),
+			Phase:    statusReportPhase(status.IsReady, status.IsFailed),
+			FailMode: spec.FailMode,
+		}
+
+		if status.StatusGeneration == 0 && !status.IsFailed {
+			row.Cells = []string{"-", "-", "-"}
+			row.Messages = []string{mt.formatResourceStatusAvailability(mt.TrackingReplicaSets[name])}
+			table.Rows = append(table.Rows, row)
+			mt.PrevReplicaSetsStatuses[name] = status
+			continue
+		}
+
+		replicas := "-"
+		if status.ReplicasIndicator != nil {
+			replicas = status.ReplicasIndicator.FormatTableElem(prevStatus.ReplicasIndicator, indicators.FormatTableElemOptions{
+				ShowProgress:         showProgress,
+				DisableWarningColors: disableWarningColors,
+				WithTargetValue:      true,
+			})
+		}
+
+		ready := "-"
+		if status.ReadyIndicator != nil {
+			ready = status.ReadyIndicator.FormatTableElem(prevStatus.ReadyIndicator, indicators.FormatTableElemOptions{
+				ShowProgress:         showProgress,
+				DisableWarningColors: disableWarningColors,
+			})
+		}
+
+		available := "-"
+		if status.AvailableIndicator != nil {
+			available = status.AvailableIndicator.FormatTableElem(prevStatus.AvailableIndicator, indicators.FormatTableElemOptions{
+				ShowProgress:         showProgress,
+				DisableWarningColors: disableWarningColors,
+			})
+		}
+
+		row.Cells = []string{replicas, ready, available}
+		if status.IsFailed {
+			row.Messages = append(row.Messages, formatResourceError(disableWarningColors, status.FailedReason))
+		}
+
+		if len(status.Pods) > 0 {
+			extraMsg := ""
+			if len(status.WaitingForMessages) > 0 {
+				extraMsg += "---\n"
+				extraMsg += utils.BlueString("Waiting for: %s", strings.Join(status.WaitingForMessages, ", "))
+			}
+			extraMsg = mt.appendAllowedFailuresMessage(extraMsg, mt.TrackingReplicaSets, "rs", spec)
+			row.SubTables = mt.controllerPodsStatusReportTables(prevStatus.Pods, status.Pods, status.NewPodsNames, spec.FailMode, showProgress, disableWarningColors, extraMsg)
+		}
+
+		table.Rows = append(table.Rows, row)
+		mt.PrevReplicaSetsStatuses[name] = status
+	}
+
+	return mt.renderStatusReportTable(table)
+}
+
+var genericConditionsSubTableRatio = []float64{.40, .20, .40}
+
+func (mt *multitracker) renderGenericStatusProgress() string {
+	table := StatusReportTable{
+		Columns:      []string{"GENERIC", "CONDITION", "STATUS", "REASON"},
+		ColumnsRatio: statusProgressTableRatio,
+	}
+
+	for _, name := range mt.statusReportKeys("generic", mt.GenericSpecs) {
+		status := mt.GenericStatuses[name]
+		spec := mt.GenericSpecs[name]
+
+		disableWarningColors := spec.FailMode == IgnoreAndContinueDeployProcess
+		conditions := genericConditions(spec)
+
+		row := StatusReportRow{
+			Name:     formatSpecResourceName(formatGenericResourceName(mt.formatResourceName("generic", spec), spec), spec),
+			Phase:    statusReportPhase(status.IsReady, status.IsFailed),
+			FailMode: spec.FailMode,
+		}
+
+		if status.StatusGeneration == 0 && !status.IsFailed {
+			row.Cells = []string{"-", "-", "-"}
+			row.Messages = []string{mt.formatResourceStatusAvailability(mt.TrackingGeneric[name])}
+			table.Rows = append(table.Rows, row)
+			continue
+		}
+
+		readyStatus, readyReason := "-", "-"
+		if status.ReadyCondition != nil {
+			readyStatus = status.ReadyCondition.Status
+			if status.ReadyCondition.Reason != "" {
+				readyReason = status.ReadyCondition.Reason
+			}
+		}
+		if status.IsReady {
+			readyStatus = utils.GreenString("%s", readyStatus)
+		} else if !disableWarningColors {
+			readyStatus = utils.YellowString("%s", readyStatus)
+		}
+
+		row.Cells = []string{conditions.Ready.Type, readyStatus, readyReason}
+		if status.IsFailed {
+			row.Messages = append(row.Messages, formatResourceError(disableWarningColors, status.FailedReason))
+		}
+
+		conditionsTable := StatusReportTable{ColumnsRatio: genericConditionsSubTableRatio}
+		for _, cond := range status.Conditions {
+			if cond.Type == conditions.Ready.Type {
+				continue
+			}
+
+			conditionRow := StatusReportRow{Name: cond.Type, Cells: []string{cond.Status, cond.Reason}}
+			if !status.IsReady && cond.Message != "" {
+				conditionRow.Messages = append(conditionRow.Messages, cond.Message)
+			}
+			conditionsTable.Rows = append(conditionsTable.Rows, conditionRow)
+		}
+
+		if len(conditionsTable.Rows) > 0 || len(status.WaitingForMessages) > 0 {
+			if len(conditionsTable.Rows) > 0 {
+				conditionsTable.Columns = []string{"CONDITION", "STATUS", "REASON"}
+			}
+			if len(status.WaitingForMessages) > 0 {
+				conditionsTable.Footer += "---\n"
+				conditionsTable.Footer += utils.BlueString("Waiting for: %s", strings.Join(status.WaitingForMessages, ", "))
+			}
+			row.SubTables = append(row.SubTables, conditionsTable)
+		}
+
+		table.Rows = append(table.Rows, row)
+	}
+
+	return mt.renderStatusReportTable(table)
+}
+
+// formatGenericResourceName qualifies the name of the Generic resource with its resource and group, e.g. certificates.cert-manager.io/my-cert.
+func formatGenericResourceName(name string, spec MultitrackSpec) string {
+	resource := spec.GroupVersionResource.Resource
+	if spec.GroupVersionResource.Group != "" {
+		resource = fmt.Sprintf("%s.%s", resource, spec.GroupVersionResource.Group)
+	}
+	return fmt.Sprintf("%s/%s", resource, name)
+}
+
+// childPodsStatusReportTable returns the sub table of the Pods of the controller, the new Pods are marked with their state.
+func (mt *multitracker) childPodsStatusReportTable(prevPods map[string]pod.PodStatus, pods map[string]pod.PodStatus, newPodsNames []string, failMode FailMode, showProgress, disableWarningColors bool) StatusReportTable {
+	table := StatusReportTable{
+		Columns:       []string{"POD", "READY", "RESTARTS", "STATUS"},
+		ColumnsRatio:  statusProgressSubTableRatio,
+		CountersCells: []int{0, 1},
+	}
+
+	// Failed pods go first so that they are not lost among healthy ones, deleted pods go last
+	failedPodsNames := []string{}
+	podsNames := []string{}
+	deletedPodsNames := []string{}
+	for podName, podStatus := range pods {
+		switch {
+		case podStatus.IsDeleted:
+			deletedPodsNames = append(deletedPodsNames, podName)
+		case podStatus.IsFailed:
+			failedPodsNames = append(failedPodsNames, podName)
+		default:
+			podsNames = append(podsNames, podName)
+		}
+	}
+	sort.Strings(failedPodsNames)
+	sort.Strings(podsNames)
+	sort.Strings(deletedPodsNames)
+	podsNames = append(append(failedPodsNames, podsNames...), deletedPodsNames...)
+
+	for _, podName := range podsNames {
+		isPodNew := false
+		for _, newPodName := range newPodsNames {
+			if newPodName == podName {
+				isPodNew = true
+			}
+		}
+
+		prevPodStatus := prevPods[podName]
+		podStatus := pods[podName]
+
+		isReady := false
+		if podStatus.StatusIndicator != nil {
+			isReady = podStatus.StatusIndicator.IsReady()
+		}
+
+		row := StatusReportRow{Name: strings.Join(strings.Split(podName, "-")[1:], "-"), FailMode: failMode}
+		if isPodNew {
+			row.Phase = statusReportPhase(isReady, podStatus.IsFailed)
+		}
+
+		ready := fmt.Sprintf("%d/%d", podStatus.ReadyContainers, podStatus.TotalContainers)
+
+		status := "-"
+		if podStatus.StatusIndicator != nil {
+			status = podStatus.StatusIndicator.FormatTableElem(prevPodStatus.StatusIndicator, indicators.FormatTableElemOptions{
+				ShowProgress:         showProgress,
+				DisableWarningColors: disableWarningColors,
+				IsResourceNew:        isPodNew,
+			})
+		}
+
+		row.Cells = []string{ready, fmt.Sprintf("%d", podStatus.Restarts), status}
+		if podStatus.SkippedReason != "" {
+			row.Messages = append(row.Messages, fmt.Sprintf("skipped: %s", podStatus.SkippedReason))
+		} else if podStatus.IsFailed {
+			row.Messages = append(row.Messages, formatResourceError(disableWarningColors, podStatus.FailedReason))
+		} else if podStatus.IsSucceeded {
+			if completion := formatPodCompletion(podStatus); completion != "" {
+				row.Messages = append(row.Messages, completion)
+			}
+		} else if initProgress := formatPodInitProgress(podStatus); initProgress != "" {
+			row.Messages = append(row.Messages, initProgress)
+		}
+
+		table.Rows = append(table.Rows, row)
+	}
+
+	return table
+}
+
+// formatPodCompletion describes terminated containers of the completed Pod, e.g. "completed in 12s, exit 0".
+func formatPodCompletion(podStatus pod.PodStatus) string {
+	var parts []string
+	for _, containerName := range podStatus.ContainersNames {
+		termination, hasKey := podStatus.ContainersTerminations[containerName]
+		if !hasKey {
+			continue
+		}
+
+		part := fmt.Sprintf("completed in %s, exit %d", duration.HumanDuration(termination.Duration()), termination.ExitCode)
+		if len(podStatus.ContainersNames) > 1 {
+			part = fmt.Sprintf("%s %s", containerName, part)
+		}
+
+		parts = append(parts, part)
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+// formatPodInitProgress describes the Pod initialization, e.g. "Init: 1/3 (running db-wait for 4m10s)"
+// or "Init: 1/3 (failing migrate: CrashLoopBackOff, exit code 1)".
+func formatPodInitProgress(podStatus pod.PodStatus) string {
+	if podStatus.CurrentInitContainer == "" || podStatus.IsDeleted {
+		return ""
+	}
+
+	progress := fmt.Sprintf("Init: %d/%d", podStatus.InitContainersDone, len(podStatus.InitContainersNames))
+	if podStatus.CurrentInitContainerFailure != "" {
+		return fmt.Sprintf("%s (failing %s: %s)", progress, podStatus.CurrentInitContainer, podStatus.CurrentInitContainerFailure)
+	}
+	if podStatus.CurrentInitContainerStartedAt.IsZero() {
+		return fmt.Sprintf("%s (waiting for %s)", progress, podStatus.CurrentInitContainer)
+	}
+
+	return fmt.Sprintf("%s (running %s for %s)", progress, podStatus.CurrentInitContainer, duration.HumanDuration(time.Since(podStatus.CurrentInitContainerStartedAt)))
+}
+
+func formatResourceWarning(disableWarningColors bool, reason string) string {
+	msg := fmt.Sprintf("warning: %s", reason)
+	if disableWarningColors {
+		return msg
+	}
+	return utils.YellowString("%s", msg)
+}
+
+func formatResourceError(disableWarningColors bool, reason string) string {
+	msg := fmt.Sprintf("error: %s", reason)
+	if disableWarningColors {
+		return msg
+	}
+	return utils.RedString("%s", msg)
+}
+
+// formatSpecResourceName marks resources with inverted expectation, so that their rejection is not confused with a failure.
+func formatSpecResourceName(name string, spec MultitrackSpec) string {
+	if spec.ExpectFailure {
+		return fmt.Sprintf("%s (expect failure)", name)
+	}
+	return name
+}
+
+// formatResourceCaption prefixes the caption of the resource with the glyph of its state: ✓ ready, ✗ failed or → progressing,
+// so that the state is visible without colors, and colors the caption accordingly to the fail mode.
+func formatResourceCaption(resourceCaption string, resourceFailMode FailMode, isReady bool, isFailed bool) string {
+	switch {
+	case isReady:
+		resourceCaption = fmt.Sprintf("✓ %s", resourceCaption)
+	case isFailed:
+		resourceCaption = fmt.Sprintf("✗ %s", resourceCaption)
+	default:
+		resourceCaption = fmt.Sprintf("→ %s", resourceCaption)
+	}
+
+	switch resourceFailMode {
+	case FailWholeDeployProcessImmediately:
+		if isReady {
+			return utils.GreenString("%s", resourceCaption)
+		} else if isFailed {
+			return utils.RedString("%s", resourceCaption)
+		} else {
+			return utils.YellowString("%s", resourceCaption)
+		}
+
+	case IgnoreAndContinueDeployProcess:
+		if isReady {
+			return utils.GreenString("%s", resourceCaption)
+		} else {
+			return resourceCaption
+		}
+
+	case HopeUntilEndOfDeployProcess:
+		if isReady {
+			return utils.GreenString("%s", resourceCaption)
+		} else {
+			return utils.YellowString("%s", resourceCaption)
+		}
+
+	default:
+		panic(fmt.Sprintf("unsupported resource fail mode '%s'", resourceFailMode))
+	}
+}
+
+func podContainerLogChunkHeader(namespace, podName string, chunk *pod.ContainerLogChunk) string {
+	return fmt.Sprintf("%s container/%s", tracker.FormatResourceID("po", namespace, podName), chunk.ContainerName)
+}
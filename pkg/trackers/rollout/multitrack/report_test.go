@@ -0,0 +1,129 @@
+package multitrack
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/werf/kubedog/pkg/display"
+	"github.com/werf/kubedog/pkg/tracker/pod"
+)
+
+// TestFilterLogChunkLines pins the lines matching the log regexps as a whole: the regexps with zero-width matches,
+// e.g. the empty one, show the lines, which were hidden when the matched substring was checked instead.
+func TestFilterLogChunkLines(t *testing.T) {
+	chunk := &pod.ContainerLogChunk{
+		ContainerName: "main",
+		LogLines: []display.LogLine{
+			{Message: "starting"},
+			{Message: ""},
+			{Message: "error: connection refused"},
+			{Message: pod.LogOutputTruncatedMessage},
+		},
+	}
+	allLines := []string{"starting", "", "error: connection refused", pod.LogOutputTruncatedMessage}
+
+	for _, tc := range []struct {
+		name                    string
+		noLogRegex              bool
+		logRegex                string
+		logRegexByContainerName map[string]string
+		expected                []string
+	}{
+		{name: "no regexp", noLogRegex: true, expected: allLines},
+		{name: "empty regexp", logRegex: "", expected: allLines},
+		{name: "zero-width match", logRegex: "x*", expected: allLines},
+		{name: "empty lines", logRegex: "^$", expected: []string{"", pod.LogOutputTruncatedMessage}},
+		{name: "substring", logRegex: "error", expected: []string{"error: connection refused", pod.LogOutputTruncatedMessage}},
+		{
+			name:                    "container regexp over LogRegex",
+			logRegex:                "error",
+			logRegexByContainerName: map[string]string{"main": "^start"},
+			expected:                []string{"starting", pod.LogOutputTruncatedMessage},
+		},
+		{
+			name:                    "LogRegex of other containers",
+			logRegex:                "error",
+			logRegexByContainerName: map[string]string{"sidecar": "^start"},
+			expected:                []string{"error: connection refused", pod.LogOutputTruncatedMessage},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := MultitrackSpec{ResourceName: "app", Namespace: "default"}
+			if !tc.noLogRegex {
+				spec.LogRegex = regexp.MustCompile(tc.logRegex)
+			}
+			for containerName, logRegex := range tc.logRegexByContainerName {
+				if spec.LogRegexByContainerName == nil {
+					spec.LogRegexByContainerName = make(map[string]*regexp.Regexp)
+				}
+				spec.LogRegexByContainerName[containerName] = regexp.MustCompile(logRegex)
+			}
+
+			lines := filterLogChunkLines(spec, "", chunk)
+			if strings.Join(lines, "\n") != strings.Join(tc.expected, "\n") || len(lines) != len(tc.expected) {
+				t.Errorf("expected lines %q, got %q", tc.expected, lines)
+			}
+		})
+	}
+}
+
+func TestIsContainerLogsShown(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		showOnly  []string
+		skip      []string
+		container string
+		expected  bool
+	}{
+		{name: "no filters", container: "main", expected: true},
+		{name: "skipped", skip: []string{"istio-proxy"}, container: "istio-proxy", expected: false},
+		{name: "not skipped", skip: []string{"istio-proxy"}, container: "main", expected: true},
+		{name: "shown only", showOnly: []string{"main"}, container: "main", expected: true},
+		{name: "not in shown only", showOnly: []string{"main"}, container: "migrations", expected: false},
+		// SkipLogsForContainers excludes the container from the ShowLogsOnlyForContainers set
+		{name: "shown only and skipped", showOnly: []string{"main", "worker"}, skip: []string{"worker"}, container: "worker", expected: false},
+		{name: "skipped and not in shown only", showOnly: []string{"main"}, skip: []string{"worker"}, container: "main", expected: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := MultitrackSpec{ShowLogsOnlyForContainers: tc.showOnly, SkipLogsForContainers: tc.skip}
+			if shown := isContainerLogsShown(spec, tc.container); shown != tc.expected {
+				t.Errorf("expected logs of %s shown %v, got %v", tc.container, tc.expected, shown)
+			}
+		})
+	}
+}
+
+// TestValidateLogsContainers warns about the unknown containers once, when the first Pod with containers names is observed.
+func TestValidateLogsContainers(t *testing.T) {
+	mt := newTestMultitracker()
+	spec := MultitrackSpec{
+		ResourceName:              "web",
+		Namespace:                 "default",
+		ShowLogsOnlyForContainers: []string{"main", "mian"},
+		SkipLogsForContainers:     []string{"init-db", "sidecar"},
+		FailMode:                  IgnoreAndContinueDeployProcess,
+	}
+	mt.DeploymentsSpecs[resourceKey(spec)] = spec
+	mt.TrackingDeployments[resourceKey(spec)] = newMultitrackerResourceState(spec)
+
+	// Pods without containers names are not known yet
+	mt.validateLogsContainers(mt.TrackingDeployments, "deploy", spec, map[string]pod.PodStatus{"web-1": {}})
+	if msgs := mt.serviceMessagesByResource["deploy/default/web"]; len(msgs) != 0 {
+		t.Fatalf("expected no warnings before containers names are known, got %q", msgs)
+	}
+
+	pods := map[string]pod.PodStatus{
+		"web-1": {ContainersNames: []string{"main", "worker"}, InitContainersNames: []string{"init-db"}},
+	}
+	mt.validateLogsContainers(mt.TrackingDeployments, "deploy", spec, pods)
+	mt.validateLogsContainers(mt.TrackingDeployments, "deploy", spec, pods)
+
+	expected := []string{
+		"warning: SkipLogsForContainers contains unknown containers: sidecar",
+		"warning: ShowLogsOnlyForContainers contains unknown containers: mian",
+	}
+	if msgs := mt.serviceMessagesByResource["deploy/default/web"]; strings.Join(msgs, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected warnings %q, got %q", expected, msgs)
+	}
+}
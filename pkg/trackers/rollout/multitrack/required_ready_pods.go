@@ -0,0 +1,38 @@
+package multitrack
+
+import "fmt"
+
+func validateRequiredReadyPodsCount(specs MultitrackSpecs) error {
+	for _, kindSpecs := range []struct {
+		Kind  string
+		Specs []MultitrackSpec
+	}{
+		{"po", specs.Pods},
+		{"rs", specs.ReplicaSets},
+		{"job", specs.Jobs},
+		{"generic", specs.Generic},
+	} {
+		for _, spec := range kindSpecs.Specs {
+			if spec.RequiredReadyPodsCount != nil {
+				return fmt.Errorf("bad %s/%s spec: RequiredReadyPodsCount is supported only for Deployments, StatefulSets and DaemonSets", kindSpecs.Kind, spec.ResourceName)
+			}
+		}
+	}
+
+	for _, kindSpecs := range []struct {
+		Kind  string
+		Specs []MultitrackSpec
+	}{
+		{"deploy", specs.Deployments},
+		{"sts", specs.StatefulSets},
+		{"ds", specs.DaemonSets},
+	} {
+		for _, spec := range kindSpecs.Specs {
+			if spec.RequiredReadyPodsCount != nil && *spec.RequiredReadyPodsCount <= 0 {
+				return fmt.Errorf("bad %s/%s spec: RequiredReadyPodsCount should be positive, got %d", kindSpecs.Kind, spec.ResourceName, *spec.RequiredReadyPodsCount)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package multitrack
+
+import (
+	"fmt"
+	"time"
+)
+
+const resourceCreationTimeoutExpiredReason = "resource was not created"
+
+func validateResourceCreationTimeouts(specs MultitrackSpecs) error {
+	for _, kindSpecs := range []struct {
+		Kind  string
+		Specs []MultitrackSpec
+	}{
+		{"deploy", specs.Deployments},
+		{"rs", specs.ReplicaSets},
+		{"sts", specs.StatefulSets},
+		{"ds", specs.DaemonSets},
+		{"job", specs.Jobs},
+		{"po", specs.Pods},
+		{"generic", specs.Generic},
+	} {
+		for _, spec := range kindSpecs.Specs {
+			if spec.ResourceCreationTimeoutSeconds != nil && !spec.WaitForResourceCreation {
+				return fmt.Errorf("bad %s/%s spec: ResourceCreationTimeoutSeconds requires WaitForResourceCreation", kindSpecs.Kind, spec.ResourceName)
+			}
+		}
+	}
+
+	return nil
+}
+
+// runResourceCreationTimeout handles the resource not created within ResourceCreationTimeoutSeconds as the resource failure.
+// While the failure is allowed the timeout is restarted until the resource is created.
+func (mt *multitracker) runResourceCreationTimeout(kind string, spec MultitrackSpec, mtCtx *multitrackerContext) {
+	defer mt.recoverTrackerPanic(kind, spec)
+
+	timeout := time.Duration(*spec.ResourceCreationTimeoutSeconds) * time.Second
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-mtCtx.Context.Done():
+			return
+		case <-timer.C:
+		}
+
+		if !mt.handleResourceCreationTimeout(kind, spec, mtCtx) {
+			return
+		}
+
+		timer.Reset(timeout)
+	}
+}
+
+// handleResourceCreationTimeout returns true when the resource creation should be awaited further.
+func (mt *multitracker) handleResourceCreationTimeout(kind string, spec MultitrackSpec, mtCtx *multitrackerContext) bool {
+	mt.mux.Lock()
+	defer mt.mux.Unlock()
+	defer mt.emitResourceStatusEvent(kind, spec)
+
+	resourcesStates := mt.resourcesStatesByKind(kind)
+	state := resourcesStates[resourceKey(spec)]
+	if mt.isTerminating || state.Status == ResourceSucceeded || state.Status == ResourceFailed || mt.isResourceStatusReceived(kind, spec) {
+		return false
+	}
+
+	reason := fmt.Sprintf("%s within %ds", resourceCreationTimeoutExpiredReason, *spec.ResourceCreationTimeoutSeconds)
+	mt.displayResourceErrorF(kind, spec, "%s", reason)
+
+	err := mt.handleResourceFailure(resourcesStates, kind, spec, reason)
+	if err == nil && spec.FailMode != IgnoreAndContinueDeployProcess {
+		return true
+	}
+
+	if err == ErrFailWholeDeployProcessImmediately {
+		mtCtx.Err = err
+	}
+	mtCtx.CancelFunc()
+
+	return false
+}
+
+// isResourceStatusReceived returns true once the tracker has reported the resource, i.e. the resource has been created.
+func (mt *multitracker) isResourceStatusReceived(kind string, spec MultitrackSpec) bool {
+	for _, key := range mt.statusesKeysByKind(kind) {
+		if key == resourceKey(spec) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,218 @@
+package multitrack
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/werf/kubedog/pkg/utils"
+)
+
+// ungroupedResourcesGroup is the group of the resources without GroupByLabel label or annotation,
+// including the resources which do not exist when tracking starts.
+const ungroupedResourcesGroup = "(ungrouped)"
+
+// JSONResourceGroup is the group of resources in the OutputJSONEvents snapshot when GroupByLabel option is set.
+type JSONResourceGroup struct {
+	Name      string                `json:"name"`
+	Rollup    JSONReportRollup      `json:"rollup"`
+	Resources []ResourceStatusEvent `json:"resources"`
+}
+
+// readResourcesGroups reads the GroupByLabel label, or annotation when there is no such label, of the live objects
+// of all specs. The objects are read once when tracking starts, so the group of the resource never changes.
+func (mt *multitracker) readResourcesGroups(kube kubernetes.Interface, dynamicClient dynamic.Interface, specs MultitrackSpecs, opts MultitrackOptions) {
+	ctx := opts.ParentContext
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var mux sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, kindSpecs := range []struct {
+		Kind  string
+		Specs []MultitrackSpec
+	}{
+		{"deploy", specs.Deployments},
+		{"rs", specs.ReplicaSets},
+		{"sts", specs.StatefulSets},
+		{"ds", specs.DaemonSets},
+		{"job", specs.Jobs},
+		{"po", specs.Pods},
+		{"generic", specs.Generic},
+	} {
+		for _, spec := range kindSpecs.Specs {
+			wg.Add(1)
+			go func(kind string, spec MultitrackSpec) {
+				defer wg.Done()
+
+				object, err := getResourceObjectMeta(ctx, kube, dynamicClient, kind, spec)
+				if err != nil {
+					if debug() {
+						fmt.Printf("unable to read group of %s/%s: %s\n", kind, resourceKey(spec), err)
+					}
+					return
+				}
+
+				group, hasKey := object.GetLabels()[mt.groupByLabel]
+				if !hasKey {
+					group = object.GetAnnotations()[mt.groupByLabel]
+				}
+				if group == "" {
+					return
+				}
+
+				mux.Lock()
+				defer mux.Unlock()
+				mt.resourcesGroups[fmt.Sprintf("%s/%s", kind, resourceKey(spec))] = group
+			}(kindSpecs.Kind, spec)
+		}
+	}
+
+	wg.Wait()
+}
+
+func getResourceObjectMeta(ctx context.Context, kube kubernetes.Interface, dynamicClient dynamic.Interface, kind string, spec MultitrackSpec) (metav1.Object, error) {
+	getOptions := metav1.GetOptions{}
+
+	switch kind {
+	case "deploy":
+		return kube.AppsV1().Deployments(spec.Namespace).Get(ctx, spec.ResourceName, getOptions)
+	case "rs":
+		return kube.AppsV1().ReplicaSets(spec.Namespace).Get(ctx, spec.ResourceName, getOptions)
+	case "sts":
+		return kube.AppsV1().StatefulSets(spec.Namespace).Get(ctx, spec.ResourceName, getOptions)
+	case "ds":
+		return kube.AppsV1().DaemonSets(spec.Namespace).Get(ctx, spec.ResourceName, getOptions)
+	case "job":
+		return kube.BatchV1().Jobs(spec.Namespace).Get(ctx, spec.ResourceName, getOptions)
+	case "po":
+		return kube.CoreV1().Pods(spec.Namespace).Get(ctx, spec.ResourceName, getOptions)
+	case "generic":
+		return dynamicClient.Resource(spec.GroupVersionResource).Namespace(spec.Namespace).Get(ctx, spec.ResourceName, getOptions)
+	default:
+		return nil, fmt.Errorf("unknown kind %q", kind)
+	}
+}
+
+// resourceGroup returns the group of the resource, empty when GroupByLabel option is not set.
+func (mt *multitracker) resourceGroup(kind, key string) string {
+	if mt.groupByLabel == "" {
+		return ""
+	}
+	if group, hasKey := mt.resourcesGroups[fmt.Sprintf("%s/%s", kind, key)]; hasKey {
+		return group
+	}
+	return ungroupedResourcesGroup
+}
+
+// resourceGroupsNames returns sorted names of the groups of the tracked resources, ungrouped resources go last.
+func (mt *multitracker) resourceGroupsNames() []string {
+	isGroup := make(map[string]bool)
+	for _, kind := range mt.trackedKinds() {
+		for key := range kind.Specs {
+			isGroup[mt.resourceGroup(kind.Kind, key)] = true
+		}
+	}
+
+	var names []string
+	for name := range isGroup {
+		if name != ungroupedResourcesGroup {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	if isGroup[ungroupedResourcesGroup] {
+		names = append(names, ungroupedResourcesGroup)
+	}
+
+	return names
+}
+
+// groupStatusReportResources returns kind/key of the resources of the group among the resources selected for the status report.
+func (mt *multitracker) groupStatusReportResources(group string, selected map[string]bool) map[string]bool {
+	res := make(map[string]bool)
+	for _, kind := range mt.trackedKinds() {
+		for key := range kind.Specs {
+			id := fmt.Sprintf("%s/%s", kind.Kind, key)
+			if mt.resourceGroup(kind.Kind, key) == group && (selected == nil || selected[id]) {
+				res[id] = true
+			}
+		}
+	}
+	return res
+}
+
+func (mt *multitracker) resourceGroupRollup(group string) JSONReportRollup {
+	var rollup JSONReportRollup
+	for _, kind := range mt.trackedKinds() {
+		for key, spec := range kind.Specs {
+			if mt.resourceGroup(kind.Kind, key) == group {
+				rollup.add(mt.newResourceStatusEvent(kind.Kind, spec).Phase)
+			}
+		}
+	}
+	return rollup
+}
+
+// formatResourceGroupHeading describes the group in the status report, e.g. "payments: 12/14 ready, 1 failed".
+func formatResourceGroupHeading(group string, rollup JSONReportRollup) string {
+	heading := fmt.Sprintf("%s: %d/%d ready", group, rollup.Ready, rollup.Total)
+	if rollup.Failed > 0 {
+		heading = fmt.Sprintf("%s, %d failed", heading, rollup.Failed)
+	}
+	return heading
+}
+
+// renderGroupedStatusProgressTables renders the status progress tables of each group under the group heading.
+// Groups without resources selected for the status report are omitted.
+func (mt *multitracker) renderGroupedStatusProgressTables() []string {
+	selected := mt.statusReportResources
+	defer func() {
+		mt.statusReportResources = selected
+	}()
+
+	var res []string
+	for _, group := range mt.resourceGroupsNames() {
+		mt.statusReportResources = mt.groupStatusReportResources(group, selected)
+
+		tables := mt.renderStatusProgressTables()
+		if len(tables) == 0 {
+			continue
+		}
+
+		res = append(res, utils.BoldString("%s", formatResourceGroupHeading(group, mt.resourceGroupRollup(group)))+"\n")
+		res = append(res, tables...)
+	}
+
+	return res
+}
+
+func (mt *multitracker) newJSONResourceGroups() []JSONResourceGroup {
+	var res []JSONResourceGroup
+	for _, group := range mt.resourceGroupsNames() {
+		jsonGroup := JSONResourceGroup{Name: group, Resources: []ResourceStatusEvent{}}
+
+		for _, kind := range mt.trackedKinds() {
+			for _, key := range sortedSpecsKeys(kind.Specs) {
+				if mt.resourceGroup(kind.Kind, key) != group {
+					continue
+				}
+
+				event := mt.newResourceStatusEvent(kind.Kind, kind.Specs[key])
+				jsonGroup.Rollup.add(event.Phase)
+				jsonGroup.Resources = append(jsonGroup.Resources, event)
+			}
+		}
+
+		res = append(res, jsonGroup)
+	}
+	return res
+}
@@ -0,0 +1,279 @@
+package multitrack
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/werf/kubedog/pkg/kube"
+	"github.com/werf/kubedog/pkg/tracker"
+	"github.com/werf/kubedog/pkg/tracker/deployment"
+)
+
+const shortSummaryMaxLength = 140
+
+type ResourceOutcome string
+
+const (
+	ResourceOutcomeReady    ResourceOutcome = "Ready"
+	ResourceOutcomeFailed   ResourceOutcome = "Failed"
+	ResourceOutcomeIgnored  ResourceOutcome = "Ignored"
+	ResourceOutcomeNotReady ResourceOutcome = "NotReady"
+	// ResourceOutcomeTimedOut means the resource has not become ready until Timeout or DeployTimeout expired.
+	ResourceOutcomeTimedOut ResourceOutcome = "TimedOut"
+	// ResourceOutcomeInternalError means a tracker goroutine of the resource panicked, see InternalError.
+	ResourceOutcomeInternalError ResourceOutcome = "InternalError"
+	// ResourceOutcomeUnstable means the resource flapped between ready and not ready within its stability window,
+	// see StabilityWindowSeconds. It is a failure only with TreatUnstableAsFailed option.
+	ResourceOutcomeUnstable ResourceOutcome = "Unstable"
+	// ResourceOutcomeSkipped means the resource has not been tracked because its dependency failed, see SkippedReason.
+	ResourceOutcomeSkipped ResourceOutcome = "Skipped"
+)
+
+// IsFailed returns true for ResourceOutcomeFailed and ResourceOutcomeInternalError.
+func (o ResourceOutcome) IsFailed() bool {
+	return o == ResourceOutcomeFailed || o == ResourceOutcomeInternalError
+}
+
+type MultitrackResult struct {
+	Duration  time.Duration
+	Resources []ResourceResult
+
+	// APIUsage is set when APIUsage multitrack option is used.
+	APIUsage *kube.APIUsageStats
+
+	// EventsAvailability is whether the cluster retains events, event messages of the resources are missing when unavailable.
+	EventsAvailability EventsAvailability
+
+	// TreatUnstableAsFailed is the multitrack option the result was collected with, Unstable resources are failed then.
+	TreatUnstableAsFailed bool
+}
+
+type ResourceResult struct {
+	Kind      string
+	Namespace string
+	Name      string
+	// Group is set when GroupByLabel option is set.
+	Group string
+
+	Outcome       ResourceOutcome
+	FailedReason  string
+	FailuresCount int
+	// FailedLogs are the last lines of the log of the failing container, see FailedContainerLogLines option.
+	FailedLogs *FailedContainerLogs
+	// SkippedReason is set for ResourceOutcomeSkipped, e.g. "dependency job/migrate failed".
+	SkippedReason string
+
+	// Duration is the time from the start of tracking until the resource tracker stopped,
+	// or until the result was collected for the resource still being tracked.
+	Duration time.Duration
+
+	// ExpectFailure is set for the resources with inverted expectation, Ready outcome means the resource was rejected as expected.
+	ExpectFailure bool
+
+	// StatusAvailability is the last known availability of the resource status,
+	// e.g. ResourceStatusWaitingForCreation for the resource which has never been created.
+	StatusAvailability ResourceStatusAvailability
+
+	// ScaleEvents is the scale timeline of Deployment during tracking, e.g. by HorizontalPodAutoscaler.
+	ScaleEvents []deployment.ScaleEvent
+
+	// Stability is set for the resources with StabilityWindowSeconds once they are ready: the state of the window
+	// and the ready→unready→ready cycles within it.
+	Stability *ResourceStability
+
+	// LastStatus is the last captured status of the resource: pod.PodStatus, deployment.DeploymentStatus,
+	// replicaset.ReplicaSetStatus, generic.GenericStatus, etc.
+	LastStatus interface{}
+}
+
+// ID returns the canonical resource identifier, e.g. "deploy/prod/api".
+func (r ResourceResult) ID() string {
+	return tracker.FormatResourceID(r.Kind, r.Namespace, r.Name)
+}
+
+func (r MultitrackResult) resourcesByOutcome(outcome ResourceOutcome) []ResourceResult {
+	var res []ResourceResult
+	for _, resource := range r.Resources {
+		if resource.Outcome == outcome {
+			res = append(res, resource)
+		}
+	}
+	return res
+}
+
+// ShortSummary returns a one-line summary of the result suitable for commit statuses,
+// e.g. "✓ 34 ready in 6m12s", "✗ 33 ready in 10m0s, 1 timed out"
+// or "✗ 2 failed (deploy/api: ImagePullBackOff; job/migrate: BackoffLimitExceeded), 31 ready".
+// The summary is never longer than 140 characters.
+func (r MultitrackResult) ShortSummary() string {
+	return r.shortSummary("✓", "✗", "…")
+}
+
+// ShortSummaryPlain is the same as ShortSummary, but uses only ASCII characters.
+func (r MultitrackResult) ShortSummaryPlain() string {
+	return r.shortSummary("[OK]", "[FAIL]", "...")
+}
+
+func (r MultitrackResult) shortSummary(okMark, failedMark, ellipsis string) string {
+	failed := append(r.resourcesByOutcome(ResourceOutcomeFailed), r.resourcesByOutcome(ResourceOutcomeInternalError)...)
+	ready := r.resourcesByOutcome(ResourceOutcomeReady)
+	ignored := r.resourcesByOutcome(ResourceOutcomeIgnored)
+	timedOut := r.resourcesByOutcome(ResourceOutcomeTimedOut)
+	notReady := r.resourcesByOutcome(ResourceOutcomeNotReady)
+	unstable := r.resourcesByOutcome(ResourceOutcomeUnstable)
+	skipped := r.resourcesByOutcome(ResourceOutcomeSkipped)
+	if r.TreatUnstableAsFailed {
+		failed = append(failed, unstable...)
+		unstable = nil
+	}
+
+	var tail []string
+	if len(unstable) > 0 {
+		tail = append(tail, fmt.Sprintf("%d unstable", len(unstable)))
+	}
+	if len(ignored) > 0 {
+		tail = append(tail, fmt.Sprintf("%d ignored", len(ignored)))
+	}
+	if len(timedOut) > 0 {
+		tail = append(tail, fmt.Sprintf("%d timed out", len(timedOut)))
+	}
+	if len(notReady) > 0 {
+		tail = append(tail, fmt.Sprintf("%d not ready", len(notReady)))
+	}
+	if len(skipped) > 0 {
+		tail = append(tail, fmt.Sprintf("%d skipped", len(skipped)))
+	}
+
+	if len(failed) == 0 {
+		// Resources which have not become ready fail the rollout as well, though they have no failure details
+		mark := okMark
+		if len(timedOut) > 0 || len(notReady) > 0 {
+			mark = failedMark
+		}
+
+		summary := fmt.Sprintf("%s %d ready in %s", mark, len(ready), r.Duration.Round(time.Second))
+		return truncateSummary(strings.Join(append([]string{summary}, tail...), ", "), ellipsis)
+	}
+
+	// Most severe failures first: resources failed more times, then by kind and name for deterministic order
+	sort.SliceStable(failed, func(i, j int) bool {
+		if failed[i].FailuresCount != failed[j].FailuresCount {
+			return failed[i].FailuresCount > failed[j].FailuresCount
+		}
+		return failed[i].ID() < failed[j].ID()
+	})
+
+	tail = append([]string{fmt.Sprintf("%d ready", len(ready))}, tail...)
+
+	format := func(details []string, omitted int) string {
+		head := fmt.Sprintf("%s %d failed", failedMark, len(failed))
+		if len(details) > 0 || omitted > 0 {
+			if omitted > 0 {
+				details = append(details, fmt.Sprintf("+%d more", omitted))
+			}
+			head = fmt.Sprintf("%s (%s)", head, strings.Join(details, "; "))
+		}
+		return strings.Join(append([]string{head}, tail...), ", ")
+	}
+
+	// Drop failure details from the least severe ones until the summary fits
+	var details []string
+	for _, resource := range failed {
+		details = append(details, fmt.Sprintf("%s: %s", resource.ID(), shortFailedReason(resource.FailedReason, ellipsis)))
+	}
+	for n := len(details); n >= 0; n-- {
+		summary := format(details[:n], len(details)-n)
+		if utf8.RuneCountInString(summary) <= shortSummaryMaxLength {
+			return summary
+		}
+	}
+
+	return truncateSummary(format(nil, 0), ellipsis)
+}
+
+func shortFailedReason(reason, ellipsis string) string {
+	reason = strings.TrimSpace(strings.SplitN(reason, "\n", 2)[0])
+	if utf8.RuneCountInString(reason) > 40 {
+		return string([]rune(reason)[:40-utf8.RuneCountInString(ellipsis)]) + ellipsis
+	}
+	return reason
+}
+
+func truncateSummary(summary, ellipsis string) string {
+	if utf8.RuneCountInString(summary) <= shortSummaryMaxLength {
+		return summary
+	}
+	return string([]rune(summary)[:shortSummaryMaxLength-utf8.RuneCountInString(ellipsis)]) + ellipsis
+}
+
+// finalResult collects the final state of all resources, the result signalled by maybeFinish is not awaited anymore.
+func (mt *multitracker) finalResult() MultitrackResult {
+	close(mt.stoppedChan)
+
+	mt.mux.Lock()
+	defer mt.mux.Unlock()
+
+	return mt.collectResult()
+}
+
+func (mt *multitracker) collectResult() MultitrackResult {
+	res := MultitrackResult{Duration: time.Since(mt.startedAt), EventsAvailability: mt.eventsAvailability, TreatUnstableAsFailed: mt.treatUnstableAsFailed}
+
+	for _, kind := range mt.trackedKinds() {
+		for _, name := range sortedSpecsKeys(kind.Specs) {
+			spec := kind.Specs[name]
+			state := kind.States[name]
+
+			resource := ResourceResult{
+				Kind:          kind.Kind,
+				Namespace:     spec.Namespace,
+				Name:          spec.ResourceName,
+				Group:         mt.resourceGroup(kind.Kind, name),
+				FailedReason:  state.FailedReason,
+				FailuresCount: state.FailuresCount,
+				FailedLogs:    state.FailedContainerLogs,
+				Duration:      resourceDuration(mt.startedAt, state.StoppedAt),
+
+				ExpectFailure:      spec.ExpectFailure,
+				StatusAvailability: state.StatusAvailability,
+				ScaleEvents:        state.ScaleEvents,
+				Stability:          state.Stability.copy(),
+				LastStatus:         mt.resourceStatus(kind.Kind, name),
+			}
+
+			switch {
+			case state.InternalError != nil:
+				resource.Outcome = ResourceOutcomeInternalError
+			case state.Stability != nil && state.Stability.State == StabilityUnstable:
+				resource.Outcome = ResourceOutcomeUnstable
+			case state.Status == ResourceSkipped:
+				resource.Outcome = ResourceOutcomeSkipped
+				resource.SkippedReason = state.StatusAvailabilityError
+			case state.Status == ResourceSucceeded:
+				resource.Outcome = ResourceOutcomeReady
+			case state.Status == ResourceFailed:
+				resource.Outcome = ResourceOutcomeFailed
+			case spec.FailMode == IgnoreAndContinueDeployProcess && state.FailuresCount > 0:
+				resource.Outcome = ResourceOutcomeIgnored
+			case mt.isTimedOut:
+				resource.Outcome = ResourceOutcomeTimedOut
+			default:
+				resource.Outcome = ResourceOutcomeNotReady
+			}
+
+			res.Resources = append(res.Resources, resource)
+		}
+	}
+
+	return res
+}
+
+func resourceDuration(startedAt, stoppedAt time.Time) time.Duration {
+	if stoppedAt.IsZero() {
+		return time.Since(startedAt)
+	}
+	return stoppedAt.Sub(startedAt)
+}
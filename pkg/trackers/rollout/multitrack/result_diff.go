@@ -0,0 +1,287 @@
+package multitrack
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Changes of the resource duration smaller than these are not shown by ResultDiff.String as noise.
+const (
+	significantDurationChangePercent = 10
+	significantDurationChange        = time.Second
+)
+
+// ResultDiff compares two tracking runs: the base run A and the compared run B.
+type ResultDiff struct {
+	DurationA time.Duration
+	DurationB time.Duration
+	// DurationChangePercent is the change of DurationB relative to DurationA, 0 when DurationA is 0.
+	DurationChangePercent float64
+
+	// Resources are the resources tracked in both runs, in the order of run B.
+	Resources []ResourceDiff
+	// Added are the resources tracked only in run B.
+	Added []ResourceResult
+	// Removed are the resources tracked only in run A.
+	Removed []ResourceResult
+}
+
+// ResourceDiff compares results of the same resource in two runs.
+type ResourceDiff struct {
+	Kind      string
+	Namespace string
+	Name      string
+
+	OutcomeA ResourceOutcome
+	OutcomeB ResourceOutcome
+
+	DurationA time.Duration
+	DurationB time.Duration
+	// DurationChangePercent is the change of DurationB relative to DurationA, 0 when DurationA is 0.
+	DurationChangePercent float64
+
+	FailuresCountA int
+	FailuresCountB int
+	FailedReasonA  string
+	FailedReasonB  string
+}
+
+// DiffResults compares the result b with the base result a, resources are matched by kind, namespace and name.
+func DiffResults(a, b MultitrackResult) ResultDiff {
+	diff := ResultDiff{
+		DurationA:             a.Duration,
+		DurationB:             b.Duration,
+		DurationChangePercent: durationChangePercent(a.Duration, b.Duration),
+	}
+
+	resourcesA := make(map[string]ResourceResult)
+	for _, resource := range a.Resources {
+		resourcesA[resource.ID()] = resource
+	}
+
+	matched := make(map[string]bool)
+	for _, resourceB := range b.Resources {
+		key := resourceB.ID()
+
+		resourceA, hasKey := resourcesA[key]
+		if !hasKey {
+			diff.Added = append(diff.Added, resourceB)
+			continue
+		}
+		matched[key] = true
+
+		diff.Resources = append(diff.Resources, ResourceDiff{
+			Kind:                  resourceB.Kind,
+			Namespace:             resourceB.Namespace,
+			Name:                  resourceB.Name,
+			OutcomeA:              resourceA.Outcome,
+			OutcomeB:              resourceB.Outcome,
+			DurationA:             resourceA.Duration,
+			DurationB:             resourceB.Duration,
+			DurationChangePercent: durationChangePercent(resourceA.Duration, resourceB.Duration),
+			FailuresCountA:        resourceA.FailuresCount,
+			FailuresCountB:        resourceB.FailuresCount,
+			FailedReasonA:         resourceA.FailedReason,
+			FailedReasonB:         resourceB.FailedReason,
+		})
+	}
+
+	for _, resourceA := range a.Resources {
+		if !matched[resourceA.ID()] {
+			diff.Removed = append(diff.Removed, resourceA)
+		}
+	}
+
+	return diff
+}
+
+func durationChangePercent(a, b time.Duration) float64 {
+	if a == 0 {
+		return 0
+	}
+	return float64(b-a) / float64(a) * 100
+}
+
+func (d ResourceDiff) resource() ResourceResult {
+	return ResourceResult{Kind: d.Kind, Namespace: d.Namespace, Name: d.Name}
+}
+
+// IsNewFailure returns true when the resource failed in run B, but not in run A.
+func (d ResourceDiff) IsNewFailure() bool {
+	return d.OutcomeB.IsFailed() && !d.OutcomeA.IsFailed()
+}
+
+// IsDurationChangeSignificant returns true when the duration changed by at least 10% and at least a second.
+func (d ResourceDiff) IsDurationChangeSignificant() bool {
+	change := d.DurationB - d.DurationA
+	if change < 0 {
+		change = -change
+	}
+
+	percent := d.DurationChangePercent
+	if percent < 0 {
+		percent = -percent
+	}
+
+	return change >= significantDurationChange && percent >= significantDurationChangePercent
+}
+
+// HasChanges returns true when outcome, failures or significantly the duration of the resource changed.
+func (d ResourceDiff) HasChanges() bool {
+	return d.OutcomeA != d.OutcomeB ||
+		d.FailuresCountA != d.FailuresCountB ||
+		d.FailedReasonA != d.FailedReasonB ||
+		d.IsDurationChangeSignificant()
+}
+
+// String renders the diff in a compact human-readable form, one line per changed, added or removed resource, e.g.:
+//
+//	duration 6m12s -> 8m40s (+40%)
+//	~ sts/prod/kafka: duration 1m0s -> 1m24s (+40%)
+//	~ deploy/prod/api: Ready -> Failed, failures 0 -> 3 (ImagePullBackOff)
+//	+ job/prod/migrate: Ready in 12s
+//	- deploy/prod/old: Ready in 3s
+//
+// Resources without changes are omitted.
+func (d ResultDiff) String() string {
+	lines := []string{fmt.Sprintf("duration %s", formatDurationChange(d.DurationA, d.DurationB, d.DurationChangePercent))}
+
+	for _, resource := range d.Resources {
+		if !resource.HasChanges() {
+			continue
+		}
+
+		var changes []string
+		if resource.OutcomeA != resource.OutcomeB {
+			changes = append(changes, fmt.Sprintf("%s -> %s", resource.OutcomeA, resource.OutcomeB))
+		}
+		if resource.FailuresCountA != resource.FailuresCountB || resource.FailedReasonA != resource.FailedReasonB {
+			failures := fmt.Sprintf("failures %d -> %d", resource.FailuresCountA, resource.FailuresCountB)
+			if resource.FailedReasonB != "" && resource.FailedReasonA != resource.FailedReasonB {
+				failures = fmt.Sprintf("%s (%s)", failures, shortFailedReason(resource.FailedReasonB, "..."))
+			}
+			changes = append(changes, failures)
+		}
+		if resource.IsDurationChangeSignificant() {
+			changes = append(changes, fmt.Sprintf("duration %s", formatDurationChange(resource.DurationA, resource.DurationB, resource.DurationChangePercent)))
+		}
+
+		lines = append(lines, fmt.Sprintf("~ %s: %s", resource.resource().ID(), strings.Join(changes, ", ")))
+	}
+
+	for _, resource := range d.Added {
+		lines = append(lines, fmt.Sprintf("+ %s: %s", resource.ID(), formatResourceResultOutcome(resource)))
+	}
+	for _, resource := range d.Removed {
+		lines = append(lines, fmt.Sprintf("- %s: %s", resource.ID(), formatResourceResultOutcome(resource)))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func formatDurationChange(a, b time.Duration, percent float64) string {
+	res := fmt.Sprintf("%s -> %s", a.Round(time.Second), b.Round(time.Second))
+	if a != 0 {
+		res = fmt.Sprintf("%s (%+.0f%%)", res, percent)
+	}
+	return res
+}
+
+func formatResourceResultOutcome(resource ResourceResult) string {
+	res := fmt.Sprintf("%s in %s", resource.Outcome, resource.Duration.Round(time.Second))
+	if resource.FailedReason != "" {
+		res = fmt.Sprintf("%s (%s)", res, shortFailedReason(resource.FailedReason, "..."))
+	}
+	return res
+}
+
+type jsonResultDiff struct {
+	DurationSecondsA      float64              `json:"durationSecondsA"`
+	DurationSecondsB      float64              `json:"durationSecondsB"`
+	DurationChangePercent float64              `json:"durationChangePercent"`
+	Resources             []jsonResourceDiff   `json:"resources"`
+	Added                 []jsonResourceResult `json:"added"`
+	Removed               []jsonResourceResult `json:"removed"`
+}
+
+type jsonResourceDiff struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+
+	OutcomeA ResourceOutcome `json:"outcomeA"`
+	OutcomeB ResourceOutcome `json:"outcomeB"`
+
+	DurationSecondsA      float64 `json:"durationSecondsA"`
+	DurationSecondsB      float64 `json:"durationSecondsB"`
+	DurationChangePercent float64 `json:"durationChangePercent"`
+
+	FailuresCountA int    `json:"failuresCountA"`
+	FailuresCountB int    `json:"failuresCountB"`
+	FailedReasonA  string `json:"failedReasonA,omitempty"`
+	FailedReasonB  string `json:"failedReasonB,omitempty"`
+
+	HasChanges   bool `json:"hasChanges"`
+	IsNewFailure bool `json:"isNewFailure"`
+}
+
+type jsonResourceResult struct {
+	Kind            string          `json:"kind"`
+	Namespace       string          `json:"namespace,omitempty"`
+	Name            string          `json:"name"`
+	Outcome         ResourceOutcome `json:"outcome"`
+	DurationSeconds float64         `json:"durationSeconds"`
+	FailuresCount   int             `json:"failuresCount"`
+	FailedReason    string          `json:"failedReason,omitempty"`
+}
+
+// MarshalJSON encodes the diff with durations in seconds.
+func (d ResultDiff) MarshalJSON() ([]byte, error) {
+	res := jsonResultDiff{
+		DurationSecondsA:      d.DurationA.Seconds(),
+		DurationSecondsB:      d.DurationB.Seconds(),
+		DurationChangePercent: d.DurationChangePercent,
+		Resources:             []jsonResourceDiff{},
+		Added:                 newJSONResourceResults(d.Added),
+		Removed:               newJSONResourceResults(d.Removed),
+	}
+
+	for _, resource := range d.Resources {
+		res.Resources = append(res.Resources, jsonResourceDiff{
+			Kind:                  resource.Kind,
+			Namespace:             resource.Namespace,
+			Name:                  resource.Name,
+			OutcomeA:              resource.OutcomeA,
+			OutcomeB:              resource.OutcomeB,
+			DurationSecondsA:      resource.DurationA.Seconds(),
+			DurationSecondsB:      resource.DurationB.Seconds(),
+			DurationChangePercent: resource.DurationChangePercent,
+			FailuresCountA:        resource.FailuresCountA,
+			FailuresCountB:        resource.FailuresCountB,
+			FailedReasonA:         resource.FailedReasonA,
+			FailedReasonB:         resource.FailedReasonB,
+			HasChanges:            resource.HasChanges(),
+			IsNewFailure:          resource.IsNewFailure(),
+		})
+	}
+
+	return json.Marshal(res)
+}
+
+func newJSONResourceResults(resources []ResourceResult) []jsonResourceResult {
+	res := []jsonResourceResult{}
+	for _, resource := range resources {
+		res = append(res, jsonResourceResult{
+			Kind:            resource.Kind,
+			Namespace:       resource.Namespace,
+			Name:            resource.Name,
+			Outcome:         resource.Outcome,
+			DurationSeconds: resource.Duration.Seconds(),
+			FailuresCount:   resource.FailuresCount,
+			FailedReason:    resource.FailedReason,
+		})
+	}
+	return res
+}
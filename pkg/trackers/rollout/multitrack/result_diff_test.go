@@ -0,0 +1,118 @@
+package multitrack
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDiffResults(t *testing.T) {
+	a := MultitrackResult{
+		Duration: 6*time.Minute + 12*time.Second,
+		Resources: []ResourceResult{
+			{Kind: "deploy", Namespace: "prod", Name: "api", Outcome: ResourceOutcomeReady, Duration: 40 * time.Second},
+			{Kind: "sts", Namespace: "prod", Name: "kafka", Outcome: ResourceOutcomeReady, Duration: time.Minute},
+			{Kind: "deploy", Namespace: "prod", Name: "web", Outcome: ResourceOutcomeReady, Duration: 20 * time.Second},
+			{Kind: "deploy", Namespace: "prod", Name: "old", Outcome: ResourceOutcomeReady, Duration: 3 * time.Second},
+		},
+	}
+	b := MultitrackResult{
+		Duration: 8*time.Minute + 40*time.Second,
+		Resources: []ResourceResult{
+			{Kind: "sts", Namespace: "prod", Name: "kafka", Outcome: ResourceOutcomeReady, Duration: time.Minute + 24*time.Second},
+			{Kind: "deploy", Namespace: "prod", Name: "api", Outcome: ResourceOutcomeFailed, Duration: 41 * time.Second, FailuresCount: 3, FailedReason: "container/main: ImagePullBackOff: Back-off pulling image \"api:2\""},
+			// The duration changed by 15%, but less than a second
+			{Kind: "deploy", Namespace: "prod", Name: "web", Outcome: ResourceOutcomeReady, Duration: 23 * time.Second},
+			{Kind: "job", Namespace: "prod", Name: "migrate", Outcome: ResourceOutcomeReady, Duration: 12 * time.Second},
+			// The same name in the other namespace is the other resource
+			{Kind: "deploy", Namespace: "staging", Name: "old", Outcome: ResourceOutcomeReady, Duration: 3 * time.Second},
+		},
+	}
+
+	diff := DiffResults(a, b)
+
+	expected := "duration 6m12s -> 8m40s (+40%)\n" +
+		"~ sts/prod/kafka: duration 1m0s -> 1m24s (+40%)\n" +
+		"~ deploy/prod/api: Ready -> Failed, failures 0 -> 3 (container/main: ImagePullBackOff: Bac...)\n" +
+		"~ deploy/prod/web: duration 20s -> 23s (+15%)\n" +
+		"+ job/prod/migrate: Ready in 12s\n" +
+		"+ deploy/staging/old: Ready in 3s\n" +
+		"- deploy/prod/old: Ready in 3s"
+	if diff.String() != expected {
+		t.Errorf("expected diff:\n%s\ngot:\n%s", expected, diff.String())
+	}
+
+	var newFailures []string
+	for _, resource := range diff.Resources {
+		if resource.IsNewFailure() {
+			newFailures = append(newFailures, resource.resource().ID())
+		}
+	}
+	if len(newFailures) != 1 || newFailures[0] != "deploy/prod/api" {
+		t.Errorf("expected new failure of deploy/prod/api, got %q", newFailures)
+	}
+}
+
+func TestResourceDiffHasChanges(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		diff     ResourceDiff
+		expected bool
+	}{
+		{
+			name:     "same",
+			diff:     ResourceDiff{OutcomeA: ResourceOutcomeReady, OutcomeB: ResourceOutcomeReady, DurationA: 10 * time.Second, DurationB: 10 * time.Second},
+			expected: false,
+		},
+		{
+			name:     "faster by 10% and a second",
+			diff:     ResourceDiff{DurationA: 10 * time.Second, DurationB: 9 * time.Second, DurationChangePercent: -10},
+			expected: true,
+		},
+		{
+			name:     "slower by 9%",
+			diff:     ResourceDiff{DurationA: 100 * time.Second, DurationB: 109 * time.Second, DurationChangePercent: 9},
+			expected: false,
+		},
+		{
+			name:     "slower by less than a second",
+			diff:     ResourceDiff{DurationA: 2 * time.Second, DurationB: 2*time.Second + 999*time.Millisecond, DurationChangePercent: 49.95},
+			expected: false,
+		},
+		{
+			name:     "outcome changed",
+			diff:     ResourceDiff{OutcomeA: ResourceOutcomeReady, OutcomeB: ResourceOutcomeTimedOut},
+			expected: true,
+		},
+		{
+			name:     "failures count changed",
+			diff:     ResourceDiff{OutcomeA: ResourceOutcomeReady, OutcomeB: ResourceOutcomeReady, FailuresCountA: 1, FailuresCountB: 2},
+			expected: true,
+		},
+		{
+			name:     "failed reason changed",
+			diff:     ResourceDiff{OutcomeA: ResourceOutcomeFailed, OutcomeB: ResourceOutcomeFailed, FailedReasonA: "ErrImagePull", FailedReasonB: "CrashLoopBackOff"},
+			expected: true,
+		},
+	} {
+		if res := tc.diff.HasChanges(); res != tc.expected {
+			t.Errorf("%s: expected HasChanges %t, got %t", tc.name, tc.expected, res)
+		}
+	}
+}
+
+func TestResultDiffJSON(t *testing.T) {
+	a := MultitrackResult{Resources: []ResourceResult{{Kind: "deploy", Namespace: "prod", Name: "api", Outcome: ResourceOutcomeReady, Duration: 1500 * time.Millisecond}}}
+	b := MultitrackResult{Duration: 2 * time.Second, Resources: []ResourceResult{{Kind: "deploy", Namespace: "prod", Name: "api", Outcome: ResourceOutcomeFailed, Duration: 3 * time.Second, FailuresCount: 1, FailedReason: "CrashLoopBackOff"}}}
+
+	res, err := json.Marshal(DiffResults(a, b))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Empty lists are encoded as arrays, not null, and the duration of the run A without duration has no change percent
+	expected := `{"durationSecondsA":0,"durationSecondsB":2,"durationChangePercent":0,"resources":[{"kind":"deploy","namespace":"prod","name":"api","outcomeA":"Ready","outcomeB":"Failed","durationSecondsA":1.5,"durationSecondsB":3,"durationChangePercent":100,"failuresCountA":0,"failuresCountB":1,"failedReasonB":"CrashLoopBackOff","hasChanges":true,"isNewFailure":true}],"added":[],"removed":[]}`
+	if string(res) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, res)
+	}
+}
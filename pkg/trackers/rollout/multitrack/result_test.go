@@ -0,0 +1,137 @@
+package multitrack
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestShortSummary(t *testing.T) {
+	ready := ResourceResult{Kind: "deploy", Namespace: "prod", Name: "web", Outcome: ResourceOutcomeReady}
+
+	for _, tc := range []struct {
+		name                  string
+		resources             []ResourceResult
+		treatUnstableAsFailed bool
+		expected              string
+		expectedPlain         string
+	}{
+		{
+			name:          "ready",
+			resources:     []ResourceResult{ready, ready},
+			expected:      "✓ 2 ready in 6m12s",
+			expectedPlain: "[OK] 2 ready in 6m12s",
+		},
+		{
+			name:          "ignored",
+			resources:     []ResourceResult{ready, {Kind: "job", Name: "cleanup", Outcome: ResourceOutcomeIgnored}},
+			expected:      "✓ 1 ready in 6m12s, 1 ignored",
+			expectedPlain: "[OK] 1 ready in 6m12s, 1 ignored",
+		},
+		{
+			name:          "timed out",
+			resources:     []ResourceResult{ready, {Kind: "sts", Namespace: "prod", Name: "db", Outcome: ResourceOutcomeTimedOut}},
+			expected:      "✗ 1 ready in 6m12s, 1 timed out",
+			expectedPlain: "[FAIL] 1 ready in 6m12s, 1 timed out",
+		},
+		{
+			name:          "not ready",
+			resources:     []ResourceResult{{Kind: "po", Namespace: "prod", Name: "worker", Outcome: ResourceOutcomeNotReady}},
+			expected:      "✗ 0 ready in 6m12s, 1 not ready",
+			expectedPlain: "[FAIL] 0 ready in 6m12s, 1 not ready",
+		},
+		{
+			name:          "unstable",
+			resources:     []ResourceResult{ready, {Kind: "deploy", Namespace: "prod", Name: "api", Outcome: ResourceOutcomeUnstable}},
+			expected:      "✓ 1 ready in 6m12s, 1 unstable",
+			expectedPlain: "[OK] 1 ready in 6m12s, 1 unstable",
+		},
+		{
+			name: "unstable treated as failed",
+			resources: []ResourceResult{ready, {
+				Kind: "deploy", Namespace: "prod", Name: "api", Outcome: ResourceOutcomeUnstable, FailedReason: "2 ready→unready cycles",
+			}},
+			treatUnstableAsFailed: true,
+			expected:              "✗ 1 failed (deploy/prod/api: 2 ready→unready cycles), 1 ready",
+			expectedPlain:         "[FAIL] 1 failed (deploy/prod/api: 2 ready→unready cycles), 1 ready",
+		},
+		{
+			// Resources failed more times go first
+			name: "failed",
+			resources: []ResourceResult{
+				ready,
+				{Kind: "job", Namespace: "prod", Name: "migrate", Outcome: ResourceOutcomeFailed, FailuresCount: 1, FailedReason: "BackoffLimitExceeded"},
+				{Kind: "deploy", Namespace: "prod", Name: "api", Outcome: ResourceOutcomeFailed, FailuresCount: 3, FailedReason: "ImagePullBackOff\nBack-off pulling image"},
+				{Kind: "sts", Namespace: "prod", Name: "db", Outcome: ResourceOutcomeTimedOut},
+			},
+			expected:      "✗ 2 failed (deploy/prod/api: ImagePullBackOff; job/prod/migrate: BackoffLimitExceeded), 1 ready, 1 timed out",
+			expectedPlain: "[FAIL] 2 failed (deploy/prod/api: ImagePullBackOff; job/prod/migrate: BackoffLimitExceeded), 1 ready, 1 timed out",
+		},
+		{
+			name: "internal error",
+			resources: []ResourceResult{
+				{Kind: "po", Namespace: "prod", Name: "app", Outcome: ResourceOutcomeInternalError, FailedReason: "tracker panicked"},
+			},
+			expected:      "✗ 1 failed (po/prod/app: tracker panicked), 0 ready",
+			expectedPlain: "[FAIL] 1 failed (po/prod/app: tracker panicked), 0 ready",
+		},
+		{
+			// Reasons are cut by runes, not bytes
+			name: "multibyte reason",
+			resources: []ResourceResult{{
+				Kind: "po", Namespace: "prod", Name: "app", Outcome: ResourceOutcomeFailed,
+				FailedReason: "контейнер завершился с ошибкой при запуске приложения",
+			}},
+			expected:      "✗ 1 failed (po/prod/app: контейнер завершился с ошибкой при запу…), 0 ready",
+			expectedPlain: "[FAIL] 1 failed (po/prod/app: контейнер завершился с ошибкой при за...), 0 ready",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			result := MultitrackResult{Duration: 6*time.Minute + 12*time.Second, Resources: tc.resources, TreatUnstableAsFailed: tc.treatUnstableAsFailed}
+
+			if summary := result.ShortSummary(); summary != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, summary)
+			}
+			if summary := result.ShortSummaryPlain(); summary != tc.expectedPlain {
+				t.Errorf("expected plain %q, got %q", tc.expectedPlain, summary)
+			}
+		})
+	}
+}
+
+// TestShortSummaryLength drops the details of the least severe failures until the summary fits into 140 characters.
+func TestShortSummaryLength(t *testing.T) {
+	var resources []ResourceResult
+	for i := 0; i < 6; i++ {
+		resources = append(resources, ResourceResult{
+			Kind: "deploy", Namespace: "production", Name: fmt.Sprintf("service-%d", i), Outcome: ResourceOutcomeFailed,
+			FailuresCount: 10 - i, FailedReason: "ошибка: CrashLoopBackOff",
+		})
+	}
+
+	result := MultitrackResult{Duration: time.Minute, Resources: resources}
+
+	expected := "✗ 6 failed (deploy/production/service-0: ошибка: CrashLoopBackOff; deploy/production/service-1: ошибка: CrashLoopBackOff; +4 more), 0 ready"
+	summary := result.ShortSummary()
+	if summary != expected {
+		t.Errorf("expected %q, got %q", expected, summary)
+	}
+	if n := utf8.RuneCountInString(summary); n > shortSummaryMaxLength {
+		t.Errorf("expected at most %d characters, got %d", shortSummaryMaxLength, n)
+	}
+
+	// Only the count of the failures is left, when no details fit
+	result = MultitrackResult{Duration: time.Minute, Resources: []ResourceResult{{
+		Kind: "deploy", Namespace: strings.Repeat("н", 140), Name: "api", Outcome: ResourceOutcomeFailed, FailedReason: "Error",
+	}}}
+	for i := 0; i < 3; i++ {
+		result.Resources = append(result.Resources, ResourceResult{Kind: "job", Name: fmt.Sprintf("job-%d", i), Outcome: ResourceOutcomeSkipped})
+	}
+
+	expected = "✗ 1 failed (+1 more), 0 ready, 3 skipped"
+	if summary := result.ShortSummary(); summary != expected {
+		t.Errorf("expected %q, got %q", expected, summary)
+	}
+}
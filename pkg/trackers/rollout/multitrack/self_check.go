@@ -0,0 +1,257 @@
+package multitrack
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stateMutex is the handlers mutex of multitracker, onUnlock is called before each unlock, while the state is still guarded.
+type stateMutex struct {
+	sync.Mutex
+	onUnlock func()
+}
+
+func (m *stateMutex) Unlock() {
+	defer m.Mutex.Unlock()
+
+	if m.onUnlock != nil {
+		m.onUnlock()
+	}
+}
+
+// isTestBinary returns true when running by go test, self-check is always enabled then.
+func isTestBinary() bool {
+	return flag.Lookup("test.v") != nil
+}
+
+// enableSelfCheck validates the state after every change made under the handlers mutex, see SelfCheck option.
+func (mt *multitracker) enableSelfCheck() {
+	mt.mux.onUnlock = mt.checkStateInvariants
+}
+
+// checkStateInvariants panics in tests, otherwise it shows the internal error with the state dump.
+// Only the first violation is reported, as the state is not expected to become consistent afterwards.
+func (mt *multitracker) checkStateInvariants() {
+	if mt.isStateInvariantViolated {
+		return
+	}
+
+	violations := mt.stateInvariantsViolations()
+	if len(violations) == 0 {
+		return
+	}
+	mt.isStateInvariantViolated = true
+
+	msg := fmt.Sprintf("multitrack state invariants violated: %s\n%s", strings.Join(violations, "; "), mt.dumpState())
+
+	if isTestBinary() {
+		panic(msg)
+	}
+
+	mt.displayMultitrackErrorMessageF("Internal error: %s\n", msg)
+}
+
+func (mt *multitracker) stateInvariantsViolations() []string {
+	var res []string
+
+	if mt.runningTrackers < 0 {
+		res = append(res, fmt.Sprintf("negative running trackers count %d", mt.runningTrackers))
+	}
+
+	var contextsCount int
+	for _, kind := range mt.trackedKinds() {
+		contextsCount += len(kind.Contexts)
+
+		for key := range kind.Specs {
+			if kind.States[key] == nil {
+				res = append(res, fmt.Sprintf("%s/%s has no state", kind.Kind, key))
+			}
+		}
+
+		for key, state := range kind.States {
+			if _, hasKey := kind.Specs[key]; !hasKey {
+				res = append(res, fmt.Sprintf("%s/%s state has no spec", kind.Kind, key))
+			}
+			if state == nil {
+				continue
+			}
+
+			switch state.Status {
+			case ResourceActive, ResourceSucceeded, ResourceFailed, ResourceHoping, ResourceActiveAfterHoping, ResourceSkipped:
+			default:
+				res = append(res, fmt.Sprintf("%s/%s has unknown status %q", kind.Kind, key, state.Status))
+			}
+
+			if state.Status == ResourceSucceeded && state.InternalError != nil {
+				res = append(res, fmt.Sprintf("%s/%s is ready and failed by internal error", kind.Kind, key))
+			}
+			if state.Status == ResourceFailed && state.FailedReason == "" {
+				res = append(res, fmt.Sprintf("%s/%s is failed without reason", kind.Kind, key))
+			}
+			if state.FailuresCount < 0 {
+				res = append(res, fmt.Sprintf("%s/%s has negative failures count %d", kind.Kind, key, state.FailuresCount))
+			}
+			if state.Stability != nil && state.Stability.FlapsCount < 0 {
+				res = append(res, fmt.Sprintf("%s/%s has negative flaps count %d", kind.Kind, key, state.Stability.FlapsCount))
+			}
+		}
+
+		for key := range kind.Contexts {
+			state := kind.States[key]
+			if state == nil {
+				res = append(res, fmt.Sprintf("%s/%s is tracked without state", kind.Kind, key))
+			} else if !state.StoppedAt.IsZero() {
+				res = append(res, fmt.Sprintf("%s/%s is tracked after its tracker stopped", kind.Kind, key))
+			}
+		}
+
+		for _, key := range mt.statusesKeysByKind(kind.Kind) {
+			if _, hasKey := kind.Specs[key]; !hasKey {
+				res = append(res, fmt.Sprintf("%s/%s status is not tracked", kind.Kind, key))
+			}
+		}
+	}
+
+	if contextsCount != mt.runningTrackers {
+		res = append(res, fmt.Sprintf("running trackers count %d does not match %d tracking contexts", mt.runningTrackers, contextsCount))
+	}
+
+	return res
+}
+
+func (mt *multitracker) statusesKeysByKind(kind string) []string {
+	var res []string
+
+	switch kind {
+	case "po":
+		for key := range mt.PodsStatuses {
+			res = append(res, key)
+		}
+	case "deploy":
+		for key := range mt.DeploymentsStatuses {
+			res = append(res, key)
+		}
+	case "rs":
+		for key := range mt.ReplicaSetsStatuses {
+			res = append(res, key)
+		}
+	case "sts":
+		for key := range mt.StatefulSetsStatuses {
+			res = append(res, key)
+		}
+	case "ds":
+		for key := range mt.DaemonSetsStatuses {
+			res = append(res, key)
+		}
+	case "job":
+		for key := range mt.JobsStatuses {
+			res = append(res, key)
+		}
+	case "generic":
+		for key := range mt.GenericStatuses {
+			res = append(res, key)
+		}
+	}
+
+	sort.Strings(res)
+
+	return res
+}
+
+type multitrackerStateDump struct {
+	RunningTrackers int                     `json:"runningTrackers"`
+	IsTerminating   bool                    `json:"isTerminating"`
+	IsTimedOut      bool                    `json:"isTimedOut"`
+	FinishErr       string                  `json:"finishErr,omitempty"`
+	Resources       []resourceStateDumpItem `json:"resources"`
+}
+
+type resourceStateDumpItem struct {
+	Kind          string         `json:"kind"`
+	Key           string         `json:"key"`
+	HasSpec       bool           `json:"hasSpec"`
+	HasState      bool           `json:"hasState"`
+	HasContext    bool           `json:"hasContext"`
+	HasStatus     bool           `json:"hasStatus"`
+	Status        ResourceStatus `json:"status,omitempty"`
+	FailedReason  string         `json:"failedReason,omitempty"`
+	FailuresCount int            `json:"failuresCount,omitempty"`
+	InternalError string         `json:"internalError,omitempty"`
+	StoppedAt     *time.Time     `json:"stoppedAt,omitempty"`
+}
+
+// dumpState returns the indented JSON of the tracking state of all resources known by any of the maps.
+func (mt *multitracker) dumpState() string {
+	dump := multitrackerStateDump{
+		RunningTrackers: mt.runningTrackers,
+		IsTerminating:   mt.isTerminating,
+		IsTimedOut:      mt.isTimedOut,
+		Resources:       []resourceStateDumpItem{},
+	}
+	if mt.finishErr != nil {
+		dump.FinishErr = mt.finishErr.Error()
+	}
+
+	for _, kind := range mt.trackedKinds() {
+		statusesKeys := mt.statusesKeysByKind(kind.Kind)
+
+		keys := map[string]bool{}
+		for key := range kind.Specs {
+			keys[key] = true
+		}
+		for key := range kind.States {
+			keys[key] = true
+		}
+		for key := range kind.Contexts {
+			keys[key] = true
+		}
+		for _, key := range statusesKeys {
+			keys[key] = true
+		}
+
+		var sortedKeys []string
+		for key := range keys {
+			sortedKeys = append(sortedKeys, key)
+		}
+		sort.Strings(sortedKeys)
+
+		for _, key := range sortedKeys {
+			item := resourceStateDumpItem{Kind: kind.Kind, Key: key}
+			_, item.HasSpec = kind.Specs[key]
+			_, item.HasContext = kind.Contexts[key]
+			for _, statusKey := range statusesKeys {
+				if statusKey == key {
+					item.HasStatus = true
+				}
+			}
+
+			if state := kind.States[key]; state != nil {
+				item.HasState = true
+				item.Status = state.Status
+				item.FailedReason = state.FailedReason
+				item.FailuresCount = state.FailuresCount
+				if state.InternalError != nil {
+					item.InternalError = state.InternalError.Error()
+				}
+				if !state.StoppedAt.IsZero() {
+					stoppedAt := state.StoppedAt
+					item.StoppedAt = &stoppedAt
+				}
+			}
+
+			dump.Resources = append(dump.Resources, item)
+		}
+	}
+
+	data, err := json.MarshalIndent(dump, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("unable to dump state: %s", err)
+	}
+
+	return string(data)
+}
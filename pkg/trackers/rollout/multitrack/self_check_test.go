@@ -0,0 +1,179 @@
+package multitrack
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/werf/kubedog/pkg/tracker"
+	"github.com/werf/kubedog/pkg/tracker/deployment"
+	"github.com/werf/kubedog/pkg/tracker/job"
+)
+
+// newTestSelfCheckMultitracker returns the consistent state: the Deployment is tracked, the tracker of the failed Job is stopped.
+func newTestSelfCheckMultitracker(t *testing.T) *multitracker {
+	t.Helper()
+
+	mt := newTestMultitracker()
+	mt.DeploymentsStatuses = make(map[string]deployment.DeploymentStatus)
+	mt.JobsStatuses = make(map[string]job.JobStatus)
+
+	api := MultitrackSpec{ResourceName: "api", Namespace: "prod"}
+	migrate := MultitrackSpec{ResourceName: "migrate", Namespace: "prod"}
+	for _, add := range []func() (*multitrackerContext, error){
+		func() (*multitrackerContext, error) {
+			return mt.addResource("deploy", mt.DeploymentsSpecs, mt.TrackingDeployments, mt.DeploymentsContexts, api, nil)
+		},
+		func() (*multitrackerContext, error) {
+			return mt.addResource("job", mt.JobsSpecs, mt.TrackingJobs, mt.JobsContexts, migrate, nil)
+		},
+	} {
+		if _, err := add(); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	mt.DeploymentsStatuses[resourceKey(api)] = deployment.DeploymentStatus{}
+	mt.JobsStatuses[resourceKey(migrate)] = job.JobStatus{}
+
+	state := mt.TrackingJobs[resourceKey(migrate)]
+	state.Status = ResourceFailed
+	state.FailedReason = "BackoffLimitExceeded"
+	state.FailuresCount = 1
+	state.StoppedAt = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	delete(mt.JobsContexts, resourceKey(migrate))
+	mt.runningTrackers--
+	mt.trackersWG.Done()
+
+	return mt
+}
+
+func TestStateInvariantsViolations(t *testing.T) {
+	if violations := newTestSelfCheckMultitracker(t).stateInvariantsViolations(); len(violations) != 0 {
+		t.Fatalf("unexpected violations of the consistent state: %q", violations)
+	}
+
+	api := resourceKey(MultitrackSpec{ResourceName: "api", Namespace: "prod"})
+	migrate := resourceKey(MultitrackSpec{ResourceName: "migrate", Namespace: "prod"})
+
+	tests := []struct {
+		name     string
+		corrupt  func(mt *multitracker)
+		expected string
+	}{
+		{
+			name:     "spec without state",
+			corrupt:  func(mt *multitracker) { delete(mt.TrackingJobs, migrate) },
+			expected: "job/prod/migrate has no state",
+		},
+		{
+			name:     "state without spec",
+			corrupt:  func(mt *multitracker) { delete(mt.JobsSpecs, migrate); delete(mt.JobsStatuses, migrate) },
+			expected: "job/prod/migrate state has no spec",
+		},
+		{
+			name:     "unknown status",
+			corrupt:  func(mt *multitracker) { mt.TrackingDeployments[api].Status = "Pending" },
+			expected: `deploy/prod/api has unknown status "Pending"`,
+		},
+		{
+			name: "ready with internal error",
+			corrupt: func(mt *multitracker) {
+				mt.TrackingDeployments[api].Status = ResourceSucceeded
+				mt.TrackingDeployments[api].InternalError = &tracker.PanicError{Value: "boom"}
+			},
+			expected: "deploy/prod/api is ready and failed by internal error",
+		},
+		{
+			name:     "failed without reason",
+			corrupt:  func(mt *multitracker) { mt.TrackingJobs[migrate].FailedReason = "" },
+			expected: "job/prod/migrate is failed without reason",
+		},
+		{
+			name:     "negative failures count",
+			corrupt:  func(mt *multitracker) { mt.TrackingDeployments[api].FailuresCount = -1 },
+			expected: "deploy/prod/api has negative failures count -1",
+		},
+		{
+			name:     "negative flaps count",
+			corrupt:  func(mt *multitracker) { mt.TrackingDeployments[api].Stability = &ResourceStability{FlapsCount: -1} },
+			expected: "deploy/prod/api has negative flaps count -1",
+		},
+		{
+			name:     "tracked after stop",
+			corrupt:  func(mt *multitracker) { mt.TrackingDeployments[api].StoppedAt = time.Now() },
+			expected: "deploy/prod/api is tracked after its tracker stopped",
+		},
+		{
+			name: "status not tracked",
+			corrupt: func(mt *multitracker) {
+				mt.DeploymentsStatuses[resourceKey(MultitrackSpec{ResourceName: "web", Namespace: "prod"})] = deployment.DeploymentStatus{}
+			},
+			expected: "deploy/prod/web status is not tracked",
+		},
+		{
+			name:     "running trackers count",
+			corrupt:  func(mt *multitracker) { mt.runningTrackers = 2 },
+			expected: "running trackers count 2 does not match 1 tracking contexts",
+		},
+	}
+
+	for _, tt := range tests {
+		mt := newTestSelfCheckMultitracker(t)
+		tt.corrupt(mt)
+
+		if violations := mt.stateInvariantsViolations(); len(violations) != 1 || violations[0] != tt.expected {
+			t.Errorf("%s: expected violation %q, got %q", tt.name, tt.expected, violations)
+		}
+	}
+}
+
+func TestDumpState(t *testing.T) {
+	mt := newTestSelfCheckMultitracker(t)
+	mt.isTerminating = true
+	// The context without spec and state is dumped as well
+	mt.GenericContexts["prod/cert"] = &multitrackerContext{}
+
+	res := []byte(mt.dumpState() + "\n")
+
+	goldenPath := filepath.Join("testdata", "self_check", "state_dump.golden")
+	if *updateGolden {
+		if err := ioutil.WriteFile(goldenPath, res, 0644); err != nil {
+			t.Fatalf("unable to update golden file: %s", err)
+		}
+	}
+
+	expected, err := ioutil.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("unable to read golden file: %s", err)
+	}
+	if string(res) != string(expected) {
+		t.Errorf("state dump does not match %s:\n%s", goldenPath, res)
+	}
+}
+
+// TestSelfCheckOnUnlock corrupts the state under the handlers mutex: the violation is reported on unlock by the panic
+// in tests, the mutex is unlocked anyway and the same state is not reported again.
+func TestSelfCheckOnUnlock(t *testing.T) {
+	mt := newTestSelfCheckMultitracker(t)
+	mt.enableSelfCheck()
+
+	corrupt := func() (recovered interface{}) {
+		defer func() { recovered = recover() }()
+
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		mt.runningTrackers = -1
+		return nil
+	}
+
+	recovered := corrupt()
+	msg, ok := recovered.(string)
+	if !ok || !strings.HasPrefix(msg, "multitrack state invariants violated: negative running trackers count -1; running trackers count -1 does not match 1 tracking contexts\n{") {
+		t.Fatalf("expected panic with violations and state dump, got %v", recovered)
+	}
+
+	mt.mux.Lock()
+	mt.mux.Unlock()
+}
@@ -0,0 +1,188 @@
+package multitrack
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+	watchtools "k8s.io/client-go/tools/watch"
+
+	"github.com/werf/kubedog/pkg/tracker"
+)
+
+const (
+	serviceAccountCheckRetryPeriod         = 5 * time.Second
+	serviceAccountCheckRetryPeriodFastMode = 500 * time.Millisecond
+
+	// irsaRoleArnAnnotation makes EKS Pod Identity Webhook inject the projected token volume into the Pods of the service account.
+	irsaRoleArnAnnotation = "eks.amazonaws.com/role-arn"
+)
+
+// trackServiceAccountAnnotations verifies that the service account of the ready Pod of the resource carries
+// VerifyServiceAccountAnnotations and that its projected token volumes are mounted. Problems are shown as warnings,
+// with StrictServiceAccountVerification they are counted as the resource failures and the check is retried while the failure is tolerated.
+func (mt *multitracker) trackServiceAccountAnnotations(kube kubernetes.Interface, kind string, spec MultitrackSpec, resourcesStates map[string]*multitrackerResourceState, opts MultitrackOptions) error {
+	parentContext := opts.ParentContext
+	if parentContext == nil {
+		parentContext = context.Background()
+	}
+	ctx, cancel := watchtools.ContextWithOptionalTimeout(parentContext, opts.Timeout)
+	defer cancel()
+
+	retryPeriod := serviceAccountCheckRetryPeriod
+	if opts.FastMode {
+		retryPeriod = serviceAccountCheckRetryPeriodFastMode
+	}
+
+	func() {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		mt.displayResourceTrackerMessageF(kind, spec, "verifying service account annotations %s", strings.Join(spec.VerifyServiceAccountAnnotations, ", "))
+	}()
+
+	for {
+		podName, serviceAccountName, err := checkServiceAccountAnnotations(ctx, kube, kind, spec)
+		if err == nil {
+			mt.mux.Lock()
+			defer mt.mux.Unlock()
+
+			mt.displayResourceTrackerMessageF(kind, spec, "service account sa/%s of %s verified", serviceAccountName, tracker.FormatResourceID("po", spec.Namespace, podName))
+
+			return nil
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		reason := fmt.Sprintf("service account verification failed: %s", err)
+		if podName != "" {
+			reason = fmt.Sprintf("%s %s", tracker.FormatResourceID("po", spec.Namespace, podName), reason)
+		}
+
+		if !spec.StrictServiceAccountVerification {
+			mt.mux.Lock()
+			defer mt.mux.Unlock()
+
+			mt.displayResourceWarningF(kind, spec, "%s", reason)
+
+			return nil
+		}
+
+		if err := func() error {
+			mt.mux.Lock()
+			defer mt.mux.Unlock()
+
+			mt.displayResourceErrorF(kind, spec, "%s", reason)
+
+			return mt.handleResourceFailure(resourcesStates, kind, spec, reason)
+		}(); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retryPeriod):
+		}
+	}
+}
+
+// checkServiceAccountAnnotations returns the names of the checked Pod and its service account, which are empty when there is no Pod to check.
+func checkServiceAccountAnnotations(ctx context.Context, kube kubernetes.Interface, kind string, spec MultitrackSpec) (string, string, error) {
+	podName, err := getReadyPodName(ctx, kube, kind, spec.Namespace, spec.ResourceName)
+	if err != nil {
+		return "", "", err
+	}
+
+	pod, err := kube.CoreV1().Pods(spec.Namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return podName, "", err
+	}
+
+	serviceAccountName := pod.Spec.ServiceAccountName
+	if serviceAccountName == "" {
+		serviceAccountName = "default"
+	}
+
+	serviceAccount, err := kube.CoreV1().ServiceAccounts(spec.Namespace).Get(ctx, serviceAccountName, metav1.GetOptions{})
+	if err != nil {
+		return podName, serviceAccountName, err
+	}
+
+	var missingAnnotations []string
+	for _, annotation := range spec.VerifyServiceAccountAnnotations {
+		if serviceAccount.Annotations[annotation] == "" {
+			missingAnnotations = append(missingAnnotations, annotation)
+		}
+	}
+	if len(missingAnnotations) > 0 {
+		return podName, serviceAccountName, fmt.Errorf("sa/%s has no annotations %s (annotations applied to the wrong service account?)", serviceAccountName, strings.Join(missingAnnotations, ", "))
+	}
+
+	tokenVolumes := projectedTokenVolumes(pod)
+
+	for _, annotation := range spec.VerifyServiceAccountAnnotations {
+		if annotation != irsaRoleArnAnnotation {
+			continue
+		}
+
+		hasAudienceToken := false
+		for _, volume := range tokenVolumes {
+			for _, source := range volume.Projected.Sources {
+				if source.ServiceAccountToken != nil && source.ServiceAccountToken.Audience != "" {
+					hasAudienceToken = true
+				}
+			}
+		}
+		if !hasAudienceToken {
+			return podName, serviceAccountName, fmt.Errorf("no projected token volume for %s injected into the pod (pod created before the annotation was applied or identity webhook not installed?)", irsaRoleArnAnnotation)
+		}
+	}
+
+	events, err := kube.CoreV1().Events(spec.Namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.Set{"involvedObject.kind": "Pod", "involvedObject.name": podName, "reason": "FailedMount"}.AsSelector().String(),
+	})
+	if err != nil {
+		return podName, serviceAccountName, fmt.Errorf("unable to list events: %s", err)
+	}
+
+	sort.SliceStable(events.Items, func(i, j int) bool {
+		return events.Items[i].LastTimestamp.After(events.Items[j].LastTimestamp.Time)
+	})
+	for _, event := range events.Items {
+		if event.Reason != "FailedMount" || event.InvolvedObject.Name != podName || (event.InvolvedObject.UID != "" && event.InvolvedObject.UID != pod.UID) {
+			continue
+		}
+		for _, volume := range tokenVolumes {
+			if strings.Contains(event.Message, fmt.Sprintf("%q", volume.Name)) {
+				return podName, serviceAccountName, fmt.Errorf("projected token volume %s failed to mount: %s", volume.Name, event.Message)
+			}
+		}
+	}
+
+	return podName, serviceAccountName, nil
+}
+
+// projectedTokenVolumes returns the projected volumes of the Pod with service account tokens.
+func projectedTokenVolumes(pod *corev1.Pod) []corev1.Volume {
+	var res []corev1.Volume
+	for _, volume := range pod.Spec.Volumes {
+		if volume.Projected == nil {
+			continue
+		}
+		for _, source := range volume.Projected.Sources {
+			if source.ServiceAccountToken != nil {
+				res = append(res, volume)
+				break
+			}
+		}
+	}
+	return res
+}
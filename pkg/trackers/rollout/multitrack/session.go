@@ -0,0 +1,899 @@
+package multitrack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/template"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/werf/logboek"
+
+	"github.com/werf/kubedog/pkg/tracker"
+	"github.com/werf/kubedog/pkg/tracker/daemonset"
+	"github.com/werf/kubedog/pkg/tracker/deployment"
+	"github.com/werf/kubedog/pkg/tracker/generic"
+	"github.com/werf/kubedog/pkg/tracker/job"
+	"github.com/werf/kubedog/pkg/tracker/pod"
+	"github.com/werf/kubedog/pkg/tracker/replicaset"
+	"github.com/werf/kubedog/pkg/tracker/statefulset"
+	"github.com/werf/kubedog/pkg/utils"
+)
+
+const defaultMaxConcurrentLogStreams = 100
+
+func Multitrack(kube kubernetes.Interface, specs MultitrackSpecs, opts MultitrackOptions) error {
+	_, err := MultitrackWithResult(kube, specs, opts)
+	return err
+}
+
+// MultitrackWithResult tracks resources the same way as Multitrack and also returns the final state of every tracked resource.
+func MultitrackWithResult(kube kubernetes.Interface, specs MultitrackSpecs, opts MultitrackOptions) (MultitrackResult, error) {
+	res, _, err := MultitrackWithSession(kube, specs, opts)
+	return res, err
+}
+
+// MultitrackSession is the handle of the resources which are still tracked in background
+// after the early return caused by ReturnOnReadyResources with DetachOnReturn option.
+type MultitrackSession struct {
+	doneChan chan struct{}
+	result   MultitrackResult
+	err      error
+}
+
+// Wait blocks until tracking of the background resources is done and returns the final state of all tracked resources.
+func (s *MultitrackSession) Wait() (MultitrackResult, error) {
+	<-s.doneChan
+	return s.result, s.err
+}
+
+// MultitrackWithSession is the same as MultitrackWithResult, but also returns the session handle
+// when tracking of the remaining resources continues in background (DetachOnReturn option), nil otherwise.
+func MultitrackWithSession(kube kubernetes.Interface, specs MultitrackSpecs, opts MultitrackOptions) (MultitrackResult, *MultitrackSession, error) {
+	return runMultitrack(kube, specs, opts, false)
+}
+
+// runMultitrack tracks the resources until they are ready or failed, or follows them until ParentContext is done, see Multifollow.
+func runMultitrack(kube kubernetes.Interface, specs MultitrackSpecs, opts MultitrackOptions, isFollowMode bool) (MultitrackResult, *MultitrackSession, error) {
+	if len(specs.Deployments)+len(specs.ReplicaSets)+len(specs.StatefulSets)+len(specs.DaemonSets)+len(specs.Jobs)+len(specs.Pods)+len(specs.Generic) == 0 {
+		return MultitrackResult{}, nil, nil
+	}
+
+	var werfAnnotationsMsgs []string
+	if opts.WerfAnnotationCompatibility {
+		msgs, err := applyLiveWerfAnnotations(kube, opts.DynamicClient, specs, opts)
+		if err != nil {
+			return MultitrackResult{}, nil, err
+		}
+		werfAnnotationsMsgs = msgs
+	}
+
+	for i := range specs.Deployments {
+		setDefaultSpecValues(&specs.Deployments[i])
+	}
+	for i := range specs.ReplicaSets {
+		setDefaultSpecValues(&specs.ReplicaSets[i])
+	}
+	for i := range specs.StatefulSets {
+		setDefaultSpecValues(&specs.StatefulSets[i])
+	}
+	for i := range specs.DaemonSets {
+		setDefaultSpecValues(&specs.DaemonSets[i])
+	}
+	for i := range specs.Jobs {
+		setDefaultSpecValues(&specs.Jobs[i])
+	}
+	for i := range specs.Pods {
+		setDefaultSpecValues(&specs.Pods[i])
+	}
+	for i := range specs.Generic {
+		setDefaultSpecValues(&specs.Generic[i])
+	}
+
+	var mergeMsgs []string
+	ambiguousNames := make(map[string]bool)
+	for _, kindSpecs := range []struct {
+		Kind  string
+		Specs *[]MultitrackSpec
+	}{
+		{"deploy", &specs.Deployments},
+		{"rs", &specs.ReplicaSets},
+		{"sts", &specs.StatefulSets},
+		{"ds", &specs.DaemonSets},
+		{"job", &specs.Jobs},
+		{"po", &specs.Pods},
+		{"generic", &specs.Generic},
+	} {
+		newSpecs, msgs, err := handleDuplicateSpecs(kindSpecs.Kind, *kindSpecs.Specs, opts.MergeDuplicateSpecs)
+		if err != nil {
+			return MultitrackResult{}, nil, err
+		}
+		*kindSpecs.Specs = newSpecs
+		mergeMsgs = append(mergeMsgs, msgs...)
+
+		findAmbiguousNames(kindSpecs.Kind, newSpecs, ambiguousNames)
+	}
+
+	if err := validateReturnOnReadyResources(specs, opts.ReturnOnReadyResources); err != nil {
+		return MultitrackResult{}, nil, err
+	}
+
+	if err := validateDependsOn(specs); err != nil {
+		return MultitrackResult{}, nil, err
+	}
+
+	if err := validateShowLogsUntil(specs); err != nil {
+		return MultitrackResult{}, nil, err
+	}
+
+	if err := validateSuccessConditions(specs); err != nil {
+		return MultitrackResult{}, nil, err
+	}
+
+	if err := validateGenericSpecs(specs, opts); err != nil {
+		return MultitrackResult{}, nil, err
+	}
+
+	if err := validateRequiredReadyPodsCount(specs); err != nil {
+		return MultitrackResult{}, nil, err
+	}
+
+	if err := validateResourceCreationTimeouts(specs); err != nil {
+		return MultitrackResult{}, nil, err
+	}
+
+	if err := validateRefreshRESTConfig(opts); err != nil {
+		return MultitrackResult{}, nil, err
+	}
+
+	if err := validateClientRateLimits(opts); err != nil {
+		return MultitrackResult{}, nil, err
+	}
+
+	if err := validateLogLinePrefixes(specs); err != nil {
+		return MultitrackResult{}, nil, err
+	}
+
+	if err := validateEventSeverityOverrides(opts); err != nil {
+		return MultitrackResult{}, nil, err
+	}
+
+	if err := validateInfrastructureCheck(opts); err != nil {
+		return MultitrackResult{}, nil, err
+	}
+
+	if err := validateVerbosity(opts); err != nil {
+		return MultitrackResult{}, nil, err
+	}
+
+	if err := validateStatusProgress(opts); err != nil {
+		return MultitrackResult{}, nil, err
+	}
+
+	if opts.Suppressions == nil {
+		suppressions, err := tracker.NewSuppressions(opts.SuppressedEventReasons, opts.SuppressedConditionTypes)
+		if err != nil {
+			return MultitrackResult{}, nil, err
+		}
+		opts.Suppressions = suppressions
+	}
+
+	if opts.LogStreamsBudget == nil {
+		maxConcurrentLogStreams := opts.MaxConcurrentLogStreams
+		if maxConcurrentLogStreams == 0 {
+			maxConcurrentLogStreams = defaultMaxConcurrentLogStreams
+		}
+		opts.LogStreamsBudget = tracker.NewLogStreamsBudget(maxConcurrentLogStreams)
+	}
+
+	mt := multitracker{
+		DeploymentsSpecs:        make(map[string]MultitrackSpec),
+		DeploymentsContexts:     make(map[string]*multitrackerContext),
+		TrackingDeployments:     make(map[string]*multitrackerResourceState),
+		DeploymentsStatuses:     make(map[string]deployment.DeploymentStatus),
+		PrevDeploymentsStatuses: make(map[string]deployment.DeploymentStatus),
+
+		ReplicaSetsSpecs:        make(map[string]MultitrackSpec),
+		ReplicaSetsContexts:     make(map[string]*multitrackerContext),
+		TrackingReplicaSets:     make(map[string]*multitrackerResourceState),
+		ReplicaSetsStatuses:     make(map[string]replicaset.ReplicaSetStatus),
+		PrevReplicaSetsStatuses: make(map[string]replicaset.ReplicaSetStatus),
+
+		StatefulSetsSpecs:        make(map[string]MultitrackSpec),
+		StatefulSetsContexts:     make(map[string]*multitrackerContext),
+		TrackingStatefulSets:     make(map[string]*multitrackerResourceState),
+		StatefulSetsStatuses:     make(map[string]statefulset.StatefulSetStatus),
+		PrevStatefulSetsStatuses: make(map[string]statefulset.StatefulSetStatus),
+
+		DaemonSetsSpecs:        make(map[string]MultitrackSpec),
+		DaemonSetsContexts:     make(map[string]*multitrackerContext),
+		TrackingDaemonSets:     make(map[string]*multitrackerResourceState),
+		DaemonSetsStatuses:     make(map[string]daemonset.DaemonSetStatus),
+		PrevDaemonSetsStatuses: make(map[string]daemonset.DaemonSetStatus),
+
+		JobsSpecs:        make(map[string]MultitrackSpec),
+		JobsContexts:     make(map[string]*multitrackerContext),
+		TrackingJobs:     make(map[string]*multitrackerResourceState),
+		JobsStatuses:     make(map[string]job.JobStatus),
+		PrevJobsStatuses: make(map[string]job.JobStatus),
+
+		PodsSpecs:        make(map[string]MultitrackSpec),
+		PodsContexts:     make(map[string]*multitrackerContext),
+		TrackingPods:     make(map[string]*multitrackerResourceState),
+		PodsStatuses:     make(map[string]pod.PodStatus),
+		PrevPodsStatuses: make(map[string]pod.PodStatus),
+
+		GenericSpecs:    make(map[string]MultitrackSpec),
+		GenericContexts: make(map[string]*multitrackerContext),
+		TrackingGeneric: make(map[string]*multitrackerResourceState),
+		GenericStatuses: make(map[string]generic.GenericStatus),
+
+		serviceMessagesByResource: make(map[string][]string),
+		ambiguousNames:            ambiguousNames,
+		groupByLabel:              opts.GroupByLabel,
+		resourcesGroups:           make(map[string]string),
+
+		eventSeverityOverrides: opts.EventSeverityOverrides,
+
+		failedContainerLogLines:       opts.FailedContainerLogLines,
+		postFailureLogCaptureDuration: opts.PostFailureLogCaptureDuration,
+		treatUnstableAsFailed:         opts.TreatUnstableAsFailed,
+
+		logLinePrefixTemplates: make(map[string]*template.Template),
+		logBlocks:              opts.LogBlocks,
+		logBlocksByHeader:      make(map[string]*logBlock),
+
+		logger:        logboek.DefaultLogger(),
+		outputAdapter: opts.OutputAdapter,
+		output:        opts.Output,
+		outputWriter:  opts.OutputWriter,
+
+		lastResourcesStatusEvents: make(map[string]ResourceStatusEvent),
+		runID:                     opts.RunID,
+		lastReportedResources:     make(map[string]ResourceStatusEvent),
+		lastRolloutStatusMessages: make(map[string]string),
+
+		statusVerbosity: opts.StatusVerbosity,
+		verbosity:       opts.Verbosity,
+		noColor:         opts.NoColor,
+		resourcesTraces: make(map[string]*resourceTraceState),
+		clusterCapacity: &clusterCapacity{},
+
+		hooks:       opts.Hooks,
+		hooksRunner: newMultitrackHooksRunner(specs, opts),
+
+		metrics:                opts.Metrics,
+		resourcesMetricsStates: make(map[string]*resourceMetricsState),
+
+		startedAt:          time.Now(),
+		startupGracePeriod: opts.StartupGracePeriod,
+		panicsAreFatal:     opts.PanicsAreFatal,
+		timeout:            opts.Timeout,
+
+		returnOnReadyResources: opts.ReturnOnReadyResources,
+		readyGateChan:          make(chan struct{}),
+		stoppedChan:            make(chan struct{}),
+
+		kube:                       kube,
+		terminatingPodsByNamespace: make(map[string]terminatingPodsCount),
+
+		infrastructureCheck:    opts.InfrastructureCheck,
+		infrastructureFailures: make(map[string]time.Time),
+
+		isFollowMode: isFollowMode,
+	}
+
+	// The output of the call with Display is separated from the output of other calls, the logger inherits
+	// the settings of the default logger (width, style, prefix)
+	if opts.Display != nil {
+		mt.logger = logboek.NewSubLogger(opts.Display.Out(), opts.Display.Err())
+	}
+	// Colors are stripped from everything written, since the messages are colored through the default logger
+	if opts.NoColor {
+		out, errOut := io.Writer(os.Stdout), io.Writer(os.Stderr)
+		if opts.Display != nil {
+			out, errOut = opts.Display.Out(), opts.Display.Err()
+		}
+		mt.logger = logboek.NewSubLogger(utils.NewNoColorWriter(out), utils.NewNoColorWriter(errOut))
+	}
+	if mt.outputWriter == nil && opts.Display != nil {
+		mt.outputWriter = opts.Display.Out()
+	} else if mt.outputWriter == nil {
+		mt.outputWriter = os.Stdout
+	}
+	if mt.runID == "" {
+		mt.runID = newRunID()
+	}
+	if mt.budget = opts.DeployTimeout; mt.budget <= 0 {
+		mt.budget = opts.Timeout
+	}
+	if mt.failedContainerLogLines == 0 {
+		mt.failedContainerLogLines = defaultFailedContainerLogLines
+	}
+
+	mt.display = newDisplaySerializer(mt.logger, mt.outputAdapter)
+
+	if opts.SelfCheck || isTestBinary() {
+		mt.enableSelfCheck()
+	}
+
+	if opts.AsyncOutput {
+		mt.display.startAsync(0, 0)
+
+		defer func() {
+			mt.display.flush()
+
+			if debug() {
+				fmt.Printf("multitrack async output: dropped %d writes\n", mt.display.droppedWrites())
+			}
+		}()
+	}
+
+	if debug() {
+		defer printSuppressionStats(opts.Suppressions)
+	}
+
+	for _, msg := range werfAnnotationsMsgs {
+		mt.displayMultitrackServiceMessageF("%s\n", msg)
+	}
+	for _, msg := range mergeMsgs {
+		mt.displayMultitrackServiceMessageF("%s\n", msg)
+	}
+
+	mt.warnUnknownEventReasons(opts)
+
+	if opts.StringsInterner == nil {
+		opts.StringsInterner = utils.NewStringsInterner(0)
+	}
+
+	if opts.RefreshRESTConfig != nil {
+		refreshableKube, refreshableDynamicClient, err := mt.newRefreshableClients(opts)
+		if err != nil {
+			return MultitrackResult{}, nil, err
+		}
+		kube, mt.kube = refreshableKube, refreshableKube
+		opts.DynamicClient = refreshableDynamicClient
+	} else if opts.QPS != 0 || opts.Burst != 0 {
+		rateLimitedKube, rateLimitedDynamicClient, err := newRateLimitedClients(opts)
+		if err != nil {
+			return MultitrackResult{}, nil, err
+		}
+		kube, mt.kube = rateLimitedKube, rateLimitedKube
+		opts.DynamicClient = rateLimitedDynamicClient
+	}
+
+	if opts.GroupByLabel != "" {
+		mt.readResourcesGroups(kube, opts.DynamicClient, specs, opts)
+	}
+
+	mt.detectEventsAvailability(kube, specs, opts)
+
+	errorChan := make(chan error, 1)
+	doneChan := make(chan struct{}, 1)
+
+	var statusProgressChan <-chan time.Time
+	stopTimers := func() {}
+
+	statusProgressPeriod := opts.StatusProgressPeriod
+	if opts.StatusProgressPeriod == 0 {
+		statusProgressPeriod = 5 * time.Second
+		if opts.FastMode {
+			statusProgressPeriod = time.Second
+		}
+	}
+
+	if opts.StatusProgressMode == StatusProgressOnPhaseChange {
+		mt.phaseChangedChan = make(chan struct{}, 1)
+		mt.lastResourcesPhases = make(map[string]ResourcePhase)
+	} else if statusProgressPeriod > 0 {
+		statusProgressChan, stopTimers = newStatusProgressTicker(statusProgressPeriod)
+	}
+
+	doDisplayStatusProgress := func() error {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		mt.runStatusReportHooks()
+		return mt.displayStatusProgress(true)
+	}
+
+	if opts.SharedInformers == nil && !opts.DisableSharedInformers {
+		parentContext := opts.ParentContext
+		if parentContext == nil {
+			parentContext = context.Background()
+		}
+		sharedInformersCtx, stopSharedInformers := context.WithCancel(parentContext)
+		opts.SharedInformers = tracker.NewSharedInformers(sharedInformersCtx, kube)
+
+		stopStatusProgress := stopTimers
+		stopTimers = func() {
+			stopStatusProgress()
+			stopSharedInformers()
+		}
+	}
+
+	mt.Start(kube, specs, doneChan, errorChan, opts)
+
+	// Trackers contexts are derived from ParentContext, so its cancellation stops all trackers
+	var parentDoneChan <-chan struct{}
+	if opts.ParentContext != nil {
+		parentDoneChan = opts.ParentContext.Done()
+	}
+
+	var deployTimeoutChan <-chan time.Time
+	if opts.DeployTimeout > 0 {
+		deployTimeoutTimer := time.NewTimer(opts.DeployTimeout - time.Since(mt.startedAt))
+		deployTimeoutChan = deployTimeoutTimer.C
+
+		stopStatusProgress := stopTimers
+		stopTimers = func() {
+			stopStatusProgress()
+			deployTimeoutTimer.Stop()
+		}
+	}
+
+	hooksErrChan := mt.hooksErrChan()
+
+	// wait returns true when readyGateChan is closed before tracking is done
+	wait := func(readyGateChan chan struct{}) (MultitrackResult, bool, error) {
+		for {
+			select {
+			case err := <-hooksErrChan:
+				res := mt.finalResult()
+				mt.stopTracking()
+				return res, false, err
+
+			case <-deployTimeoutChan:
+				mt.setTimedOut()
+				res := mt.finalResult()
+				err := mt.handleDeployTimeout(opts.DeployTimeout)
+				mt.stopTracking()
+				return res, false, err
+
+			case <-statusProgressChan:
+				if err := doDisplayStatusProgress(); err != nil {
+					return mt.finalResult(), false, err
+				}
+
+			case <-mt.phaseChangedChan:
+				if err := doDisplayStatusProgress(); err != nil {
+					return mt.finalResult(), false, err
+				}
+
+			case <-readyGateChan:
+				return MultitrackResult{}, true, nil
+
+			case <-parentDoneChan:
+				// Trackers are stopped by the cancellation, none of them runs after return
+				mt.waitTrackers()
+				return mt.finalResult(), false, opts.ParentContext.Err()
+
+			case <-doneChan:
+				// Cancelled trackers are not failed, so cancellation may be reported as done
+				if parentDoneChan != nil && opts.ParentContext.Err() != nil {
+					return mt.finalResult(), false, opts.ParentContext.Err()
+				}
+				return mt.finalResult(), false, nil
+
+			case err := <-errorChan:
+				return mt.finalResult(), false, err
+			}
+		}
+	}
+
+	res, isReadyGateReached, err := wait(mt.readyGateChan)
+	if !isReadyGateReached {
+		stopTimers()
+		if hooksErr := mt.stopHooks(); hooksErr != nil && err == nil {
+			err = hooksErr
+		}
+		mt.releaseResourcesMetrics()
+		mt.displayAPIUsage(&res, opts)
+		return res, nil, err
+	}
+
+	if !opts.DetachOnReturn {
+		func() {
+			mt.mux.Lock()
+			defer mt.mux.Unlock()
+			mt.displayMultitrackServiceMessageF("%s\n", mt.formatReadyGateMessage(false))
+		}()
+
+		res := mt.finalResult()
+		mt.stopTracking()
+		// Stopped trackers write the final report, none of them runs after return
+		mt.waitTrackers()
+		stopTimers()
+		hooksErr := mt.stopHooks()
+		mt.releaseResourcesMetrics()
+		mt.displayAPIUsage(&res, opts)
+
+		return res, nil, hooksErr
+	}
+
+	session := &MultitrackSession{doneChan: make(chan struct{})}
+
+	go func() {
+		defer close(session.doneChan)
+
+		session.result, _, session.err = wait(nil)
+		stopTimers()
+		if hooksErr := mt.stopHooks(); hooksErr != nil && session.err == nil {
+			session.err = hooksErr
+		}
+		mt.releaseResourcesMetrics()
+		mt.displayAPIUsage(&session.result, opts)
+	}()
+
+	mt.mux.Lock()
+	defer mt.mux.Unlock()
+
+	mt.displayMultitrackServiceMessageF("%s\n", mt.formatReadyGateMessage(true))
+
+	return mt.collectResult(), session, nil
+}
+
+func (mt *multitracker) displayAPIUsage(res *MultitrackResult, opts MultitrackOptions) {
+	if opts.APIUsage == nil {
+		return
+	}
+
+	stats := opts.APIUsage.Stats()
+	res.APIUsage = &stats
+
+	mt.mux.Lock()
+	defer mt.mux.Unlock()
+
+	mt.displayMultitrackServiceMessageF("%s\n", stats)
+
+	if debug() {
+		fmt.Printf("multitrack API usage by resource: %s\n", stats.ResourcesString())
+	}
+}
+
+func (mt *multitracker) Start(kube kubernetes.Interface, specs MultitrackSpecs, doneChan chan struct{}, errorChan chan error, opts MultitrackOptions) {
+	mt.mux.Lock()
+	defer mt.mux.Unlock()
+
+	mt.doneChan = doneChan
+	mt.errorChan = errorChan
+
+	for _, spec := range specs.Deployments {
+		mtCtx, err := mt.addResource("deploy", mt.DeploymentsSpecs, mt.TrackingDeployments, mt.DeploymentsContexts, spec, opts.ParentContext)
+		if err != nil {
+			mt.abortStart(err)
+			return
+		}
+
+		go mt.runSpecTracker("deploy", spec, mtCtx, mt.DeploymentsContexts, func(spec MultitrackSpec, mtCtx *multitrackerContext) error {
+			return mt.TrackDeployment(kube, spec, mt.newSpecMultitrackOptions(mtCtx, "deploy", spec, opts))
+		})
+	}
+
+	for _, spec := range specs.ReplicaSets {
+		mtCtx, err := mt.addResource("rs", mt.ReplicaSetsSpecs, mt.TrackingReplicaSets, mt.ReplicaSetsContexts, spec, opts.ParentContext)
+		if err != nil {
+			mt.abortStart(err)
+			return
+		}
+
+		go mt.runSpecTracker("rs", spec, mtCtx, mt.ReplicaSetsContexts, func(spec MultitrackSpec, mtCtx *multitrackerContext) error {
+			return mt.TrackReplicaSet(kube, spec, mt.newSpecMultitrackOptions(mtCtx, "rs", spec, opts))
+		})
+	}
+
+	for _, spec := range specs.StatefulSets {
+		mtCtx, err := mt.addResource("sts", mt.StatefulSetsSpecs, mt.TrackingStatefulSets, mt.StatefulSetsContexts, spec, opts.ParentContext)
+		if err != nil {
+			mt.abortStart(err)
+			return
+		}
+
+		go mt.runSpecTracker("sts", spec, mtCtx, mt.StatefulSetsContexts, func(spec MultitrackSpec, mtCtx *multitrackerContext) error {
+			return mt.TrackStatefulSet(kube, spec, mt.newSpecMultitrackOptions(mtCtx, "sts", spec, opts))
+		})
+	}
+
+	for _, spec := range specs.DaemonSets {
+		mtCtx, err := mt.addResource("ds", mt.DaemonSetsSpecs, mt.TrackingDaemonSets, mt.DaemonSetsContexts, spec, opts.ParentContext)
+		if err != nil {
+			mt.abortStart(err)
+			return
+		}
+
+		go mt.runSpecTracker("ds", spec, mtCtx, mt.DaemonSetsContexts, func(spec MultitrackSpec, mtCtx *multitrackerContext) error {
+			return mt.TrackDaemonSet(kube, spec, mt.newSpecMultitrackOptions(mtCtx, "ds", spec, opts))
+		})
+	}
+
+	for _, spec := range specs.Jobs {
+		mtCtx, err := mt.addResource("job", mt.JobsSpecs, mt.TrackingJobs, mt.JobsContexts, spec, opts.ParentContext)
+		if err != nil {
+			mt.abortStart(err)
+			return
+		}
+
+		go mt.runSpecTracker("job", spec, mtCtx, mt.JobsContexts, func(spec MultitrackSpec, mtCtx *multitrackerContext) error {
+			return mt.TrackJob(kube, spec, mt.newSpecMultitrackOptions(mtCtx, "job", spec, opts))
+		})
+	}
+
+	for _, spec := range specs.Pods {
+		mtCtx, err := mt.addResource("po", mt.PodsSpecs, mt.TrackingPods, mt.PodsContexts, spec, opts.ParentContext)
+		if err != nil {
+			mt.abortStart(err)
+			return
+		}
+
+		go mt.runSpecTracker("po", spec, mtCtx, mt.PodsContexts, func(spec MultitrackSpec, mtCtx *multitrackerContext) error {
+			return mt.TrackPod(kube, spec, mt.newSpecMultitrackOptions(mtCtx, "po", spec, opts))
+		})
+	}
+
+	for _, spec := range specs.Generic {
+		mtCtx, err := mt.addResource("generic", mt.GenericSpecs, mt.TrackingGeneric, mt.GenericContexts, spec, opts.ParentContext)
+		if err != nil {
+			mt.abortStart(err)
+			return
+		}
+
+		go mt.runSpecTracker("generic", spec, mtCtx, mt.GenericContexts, func(spec MultitrackSpec, mtCtx *multitrackerContext) error {
+			return mt.TrackGeneric(kube, spec, mt.newSpecMultitrackOptions(mtCtx, "generic", spec, opts))
+		})
+	}
+
+	if err := mt.applyTrackTerminationMode(); err != nil {
+		mt.fail(fmt.Errorf("unable to apply termination mode: %s", err))
+		return
+	}
+
+	mt.maybeFinish()
+}
+
+func (mt *multitracker) applyTrackTerminationMode() error {
+	if mt.isTerminating {
+		return nil
+	}
+
+	shouldContinueTracking := func(state *multitrackerResourceState, spec MultitrackSpec) bool {
+		if state.IsShowingLogsUntilEndOfDeploy {
+			return false
+		}
+
+		switch spec.TrackTerminationMode {
+		case WaitUntilResourceReady:
+			// There is at least one active context with wait mode,
+			// so continue tracking without stopping any contexts
+			return true
+
+		case NonBlocking:
+			return false
+
+		default:
+			panic(fmt.Sprintf("unknown TrackTerminationMode %#v", spec.TrackTerminationMode))
+		}
+	}
+
+	var contextsToStop []*multitrackerContext
+
+	for name, ctx := range mt.DeploymentsContexts {
+		if shouldContinueTracking(mt.TrackingDeployments[name], mt.DeploymentsSpecs[name]) {
+			return nil
+		}
+		contextsToStop = append(contextsToStop, ctx)
+	}
+	for name, ctx := range mt.ReplicaSetsContexts {
+		if shouldContinueTracking(mt.TrackingReplicaSets[name], mt.ReplicaSetsSpecs[name]) {
+			return nil
+		}
+		contextsToStop = append(contextsToStop, ctx)
+	}
+	for name, ctx := range mt.StatefulSetsContexts {
+		if shouldContinueTracking(mt.TrackingStatefulSets[name], mt.StatefulSetsSpecs[name]) {
+			return nil
+		}
+		contextsToStop = append(contextsToStop, ctx)
+	}
+	for name, ctx := range mt.DaemonSetsContexts {
+		if shouldContinueTracking(mt.TrackingDaemonSets[name], mt.DaemonSetsSpecs[name]) {
+			return nil
+		}
+		contextsToStop = append(contextsToStop, ctx)
+	}
+	for name, ctx := range mt.JobsContexts {
+		if shouldContinueTracking(mt.TrackingJobs[name], mt.JobsSpecs[name]) {
+			return nil
+		}
+		contextsToStop = append(contextsToStop, ctx)
+	}
+	for name, ctx := range mt.PodsContexts {
+		if shouldContinueTracking(mt.TrackingPods[name], mt.PodsSpecs[name]) {
+			return nil
+		}
+		contextsToStop = append(contextsToStop, ctx)
+	}
+	for name, ctx := range mt.GenericContexts {
+		if shouldContinueTracking(mt.TrackingGeneric[name], mt.GenericSpecs[name]) {
+			return nil
+		}
+		contextsToStop = append(contextsToStop, ctx)
+	}
+
+	mt.isTerminating = true
+
+	for _, ctx := range contextsToStop {
+		ctx.CancelFunc()
+	}
+
+	return nil
+}
+
+// addResource registers the resource before its tracker is started, it is the only place where resources are added.
+// Maps of all kinds are created with the multitracker and the entries of specs and states are never deleted,
+// so the resource state can be indexed by its key at any time after the registration.
+// The resource registered twice is rejected: duplicate specs are merged or rejected by handleDuplicateSpecs beforehand,
+// so the error means specs have not passed through it.
+func (mt *multitracker) addResource(kind string, specs map[string]MultitrackSpec, states map[string]*multitrackerResourceState, contexts map[string]*multitrackerContext, spec MultitrackSpec, parentContext context.Context) (*multitrackerContext, error) {
+	key := resourceKey(spec)
+	if _, hasKey := specs[key]; hasKey {
+		return nil, fmt.Errorf("%s is specified more than once", mt.resourceID(kind, spec))
+	}
+
+	mtCtx := newMultitrackerContext(parentContext)
+
+	contexts[key] = mtCtx
+	specs[key] = spec
+	states[key] = newMultitrackerResourceState(spec)
+	mt.recordResourceAddedMetrics(kind, spec)
+
+	if len(spec.DependsOn) > 0 {
+		states[key].DependenciesResolved = make(chan struct{})
+		states[key].setStatusAvailability(ResourceStatusWaitingForDependencies, mt.formatWaitingForDependencies(spec))
+	}
+
+	mt.runningTrackers++
+	mt.trackersWG.Add(1)
+
+	return mtCtx, nil
+}
+
+// abortStart stops the trackers started before the resource could not be registered and fails the whole process,
+// it must be called under handlers mutex.
+func (mt *multitracker) abortStart(err error) {
+	mt.isTerminating = true
+
+	for _, kind := range mt.trackedKinds() {
+		for _, ctx := range kind.Contexts {
+			ctx.CancelFunc()
+		}
+	}
+
+	mt.fail(fmt.Errorf("unable to start tracking: %w", err))
+}
+
+func (mt *multitracker) runSpecTracker(kind string, spec MultitrackSpec, mtCtx *multitrackerContext, contexts map[string]*multitrackerContext, trackerFunc func(MultitrackSpec, *multitrackerContext) error) {
+	defer mt.trackersWG.Done()
+
+	isResolved, err := mt.waitForDependencies(kind, spec, mtCtx)
+	if isResolved {
+		err = mt.startSpecTracker(kind, spec, mtCtx, trackerFunc)
+	}
+
+	mt.mux.Lock()
+	defer mt.mux.Unlock()
+
+	delete(contexts, resourceKey(spec))
+	mt.runningTrackers--
+	mt.resourcesStatesByKind(kind)[resourceKey(spec)].StoppedAt = time.Now()
+
+	if mtCtx.Err != nil {
+		err = mtCtx.Err
+	}
+
+	var crdRemovedErr *generic.CRDRemovedError
+	if errors.As(err, &crdRemovedErr) {
+		mt.failOnCRDRemoved(kind, spec, crdRemovedErr)
+		err = nil
+	}
+
+	mt.resolveDependencies()
+
+	if err == errStabilityWindowPassed {
+		err = nil
+	}
+
+	// Timeout option expired, not ready resources are reported as timed out
+	if err == context.DeadlineExceeded {
+		mt.isTimedOut = true
+	}
+
+	var connectionLostErr *tracker.ConnectionLostError
+	if panicErr, ok := err.(*tracker.PanicError); ok {
+		mt.failOnPanic(kind, spec, panicErr)
+		return
+	} else if errors.As(err, &connectionLostErr) {
+		mt.failOnConnectionLost(kind, spec, connectionLostErr)
+		return
+	} else if err == ErrFailWholeDeployProcessImmediately {
+		if mt.finishErr == nil {
+			mt.displayFailedTrackingResourcesServiceMessages()
+		}
+		mt.fail(mt.formatFailedTrackingResourcesError())
+		return
+	} else if err == context.Canceled {
+		mt.maybeFinish()
+		return
+	} else if err != nil {
+		// unknown error
+		mt.fail(fmt.Errorf("%s track failed: %s", mt.resourceID(kind, spec), err))
+		return
+	}
+
+	if err := mt.applyTrackTerminationMode(); err != nil {
+		mt.fail(fmt.Errorf("unable to apply termination mode: %s", err))
+		return
+	}
+
+	mt.reevaluateHopingResources()
+	mt.maybeFinish()
+}
+
+// startSpecTracker starts the timers of the resource and runs its tracker until it stops.
+func (mt *multitracker) startSpecTracker(kind string, spec MultitrackSpec, mtCtx *multitrackerContext, trackerFunc func(MultitrackSpec, *multitrackerContext) error) error {
+	if spec.ExpectFailure && spec.WithinSeconds > 0 {
+		mt.goTracked(func() { mt.runExpectedFailureWindow(kind, spec, mtCtx) })
+	}
+
+	if spec.TrackTimeoutSeconds != nil && *spec.TrackTimeoutSeconds > 0 {
+		mt.goTracked(func() { mt.runTrackTimeout(kind, spec, mtCtx) })
+	}
+
+	if spec.WaitForResourceCreation && spec.ResourceCreationTimeoutSeconds != nil && *spec.ResourceCreationTimeoutSeconds > 0 {
+		mt.goTracked(func() { mt.runResourceCreationTimeout(kind, spec, mtCtx) })
+	}
+
+	if kind == "sts" && ordinalStallThreshold(spec) > 0 {
+		mt.goTracked(func() { mt.runOrdinalStallCheck(spec, mtCtx) })
+	}
+
+	if mt.isFollowMode {
+		return mt.followResource(kind, spec, mtCtx, trackerFunc)
+	}
+	return mt.callTrackerFunc(spec, mtCtx, trackerFunc)
+}
+
+// goTracked runs f in the goroutine of the resource tracker, which is waited for by waitTrackers.
+func (mt *multitracker) goTracked(f func()) {
+	mt.trackersWG.Add(1)
+	go func() {
+		defer mt.trackersWG.Done()
+		f()
+	}()
+}
+
+// waitTrackers waits until the goroutines of the stopped resources trackers return, it must be called without handlers mutex held.
+func (mt *multitracker) waitTrackers() {
+	mt.trackersWG.Wait()
+}
+
+type multitrackerContext struct {
+	Context    context.Context
+	CancelFunc context.CancelFunc
+
+	// Err overrides the error of the tracker stopped by CancelFunc, e.g. when TrackTimeoutSeconds expired.
+	Err error
+
+	// LogsFromTime overrides LogsFromTime option of the tracker restarted by Multifollow.
+	LogsFromTime time.Time
+}
+
+func newMultitrackerContext(parentContext context.Context) *multitrackerContext {
+	if parentContext == nil {
+		parentContext = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parentContext)
+	return &multitrackerContext{Context: ctx, CancelFunc: cancel}
+}
@@ -0,0 +1,248 @@
+package multitrack
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestMultitrackParentContextCancelledLeak cancels ParentContext while the Pods are pending:
+// Multitrack returns the context error only when the goroutines of the trackers and their timers returned.
+func TestMultitrackParentContextCancelledLeak(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		kube := fake.NewSimpleClientset()
+		_, specs := createTestPods(t, kube, 5)
+		for i := range specs {
+			specs[i].TrackTimeoutSeconds = intPtr(3600)
+		}
+
+		// Trackers of the previous tests may still be stopping
+		prevGoroutines := trackersGoroutines()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		opts := MultitrackOptions{}
+		opts.ParentContext = ctx
+		_, out, err := runTestMultitrack(t, kube, MultitrackSpecs{Pods: specs}, opts)
+		if err != context.Canceled {
+			t.Fatalf("expected context.Canceled, got %v\n%s", err, out)
+		}
+
+		var leaked []string
+		for id, stack := range trackersGoroutines() {
+			if _, hasKey := prevGoroutines[id]; !hasKey {
+				leaked = append(leaked, stack)
+			}
+		}
+		if len(leaked) > 0 {
+			t.Fatalf("expected no trackers goroutines after return, got:\n%s", strings.Join(leaked, "\n\n"))
+		}
+	}
+}
+
+// trackersGoroutines returns the stacks of the running goroutines of the resources trackers and their timers by goroutine ID.
+func trackersGoroutines() map[string]string {
+	buf := make([]byte, 1<<22)
+	buf = buf[:runtime.Stack(buf, true)]
+
+	res := make(map[string]string)
+	for _, stack := range strings.Split(string(buf), "\n\n") {
+		for _, fn := range []string{"(*multitracker).runSpecTracker", "(*multitracker).runTrackTimeout"} {
+			if strings.Contains(stack, fn) {
+				// Header is "goroutine N [state]:", the state changes
+				res[strings.Fields(stack)[1]] = stack
+				break
+			}
+		}
+	}
+	return res
+}
+
+// newTestMultitracker returns the multitracker with the maps of all kinds and the output recorded by the adapter,
+// its resources are registered and handled directly, no trackers are run.
+func newTestMultitracker() *multitracker {
+	adapter := &recordingOutputAdapter{}
+
+	mt := &multitracker{
+		DeploymentsSpecs:     make(map[string]MultitrackSpec),
+		DeploymentsContexts:  make(map[string]*multitrackerContext),
+		TrackingDeployments:  make(map[string]*multitrackerResourceState),
+		ReplicaSetsSpecs:     make(map[string]MultitrackSpec),
+		ReplicaSetsContexts:  make(map[string]*multitrackerContext),
+		TrackingReplicaSets:  make(map[string]*multitrackerResourceState),
+		StatefulSetsSpecs:    make(map[string]MultitrackSpec),
+		StatefulSetsContexts: make(map[string]*multitrackerContext),
+		TrackingStatefulSets: make(map[string]*multitrackerResourceState),
+		DaemonSetsSpecs:      make(map[string]MultitrackSpec),
+		DaemonSetsContexts:   make(map[string]*multitrackerContext),
+		TrackingDaemonSets:   make(map[string]*multitrackerResourceState),
+		JobsSpecs:            make(map[string]MultitrackSpec),
+		JobsContexts:         make(map[string]*multitrackerContext),
+		TrackingJobs:         make(map[string]*multitrackerResourceState),
+		PodsSpecs:            make(map[string]MultitrackSpec),
+		PodsContexts:         make(map[string]*multitrackerContext),
+		TrackingPods:         make(map[string]*multitrackerResourceState),
+		GenericSpecs:         make(map[string]MultitrackSpec),
+		GenericContexts:      make(map[string]*multitrackerContext),
+		TrackingGeneric:      make(map[string]*multitrackerResourceState),
+
+		serviceMessagesByResource: make(map[string][]string),
+		resourcesGroups:           make(map[string]string),
+		outputAdapter:             adapter,
+		readyGateChan:             make(chan struct{}),
+		stoppedChan:               make(chan struct{}),
+		doneChan:                  make(chan struct{}, 1),
+		errorChan:                 make(chan error, 1),
+	}
+	mt.display = newDisplaySerializer(nil, adapter)
+
+	return mt
+}
+
+// TestMultitrackerResourcesRandomOperations applies random sequences of registration, ready, failure and stop of the tracker
+// to the resources of all kinds, including the registration of the resource once more after its tracker stopped.
+func TestMultitrackerResourcesRandomOperations(t *testing.T) {
+	failModes := []FailMode{IgnoreAndContinueDeployProcess, HopeUntilEndOfDeployProcess, FailWholeDeployProcessImmediately}
+
+	// The sequences should cover all outcomes, so that the invariants are not checked in vain
+	outcomes := make(map[string]int)
+
+	for seed := int64(0); seed < 20; seed++ {
+		rnd := rand.New(rand.NewSource(seed))
+		mt := newTestMultitracker()
+		kinds := mt.trackedKinds()
+
+		// isRunning is set while the tracker of the resource is running, the resource is registered once it has a key
+		isRunning := make(map[string]bool)
+		var ops []string
+
+		for step := 0; step < 200; step++ {
+			kind := kinds[rnd.Intn(len(kinds))]
+			spec := MultitrackSpec{
+				ResourceName:       fmt.Sprintf("app-%d", rnd.Intn(3)),
+				Namespace:          fmt.Sprintf("ns-%d", rnd.Intn(2)),
+				FailMode:           failModes[rnd.Intn(len(failModes))],
+				AllowFailuresCount: intPtr(rnd.Intn(3)),
+			}
+			id := mt.resourceID(kind.Kind, spec)
+			key := resourceKey(spec)
+			_, isRegistered := kind.Specs[key]
+
+			fail := func(format string, a ...interface{}) {
+				t.Fatalf("seed %d: %s\noperations:\n%s", seed, fmt.Sprintf(format, a...), strings.Join(ops, "\n"))
+			}
+
+			stop := func() {
+				delete(kind.Contexts, key)
+				mt.runningTrackers--
+				mt.trackersWG.Done()
+				kind.States[key].StoppedAt = time.Now()
+				isRunning[id] = false
+			}
+
+			switch op := rnd.Intn(4); {
+			case op == 0 || !isRegistered:
+				ops = append(ops, "add "+id)
+
+				prevState := kind.States[key]
+				_, err := mt.addResource(kind.Kind, kind.Specs, kind.States, kind.Contexts, spec, nil)
+				if isRegistered {
+					if err == nil || !strings.Contains(err.Error(), id+" is specified more than once") {
+						fail("expected duplicate error for %s, got %v", id, err)
+					}
+					if kind.States[key] != prevState {
+						fail("state of %s replaced by the duplicate", id)
+					}
+					outcomes["duplicate"]++
+					break
+				}
+				if err != nil {
+					fail("unexpected error: %s", err)
+				}
+				isRunning[id] = true
+
+			case op == 1 && isRunning[id]:
+				ops = append(ops, "ready "+id)
+				mt.markResourceReady(kind.States, kind.Kind, kind.Specs[key])
+				stop()
+
+			case op == 2 && isRunning[id]:
+				ops = append(ops, "fail "+id)
+				if err := mt.handleResourceFailure(kind.States, kind.Kind, kind.Specs[key], "CrashLoopBackOff"); err == ErrFailWholeDeployProcessImmediately {
+					stop()
+				} else if err != nil {
+					fail("unexpected error: %s", err)
+				}
+
+			case op == 3 && isRunning[id]:
+				ops = append(ops, "stop "+id)
+				stop()
+			}
+
+			// Invariants of the registered resources
+			contextsCount := 0
+			for _, k := range kinds {
+				if len(k.Specs) != len(k.States) {
+					fail("%d specs of %s, %d states", len(k.Specs), k.Kind, len(k.States))
+				}
+				for key := range k.Specs {
+					if mt.resourcesStatesByKind(k.Kind)[key] == nil {
+						fail("no state of registered %s %s", k.Kind, key)
+					}
+				}
+				for key := range k.Contexts {
+					if _, hasKey := k.Specs[key]; !hasKey {
+						fail("context of not registered %s %s", k.Kind, key)
+					}
+				}
+				contextsCount += len(k.Contexts)
+			}
+			if contextsCount != mt.runningTrackers {
+				fail("%d running trackers, %d contexts", mt.runningTrackers, contextsCount)
+			}
+
+			var failedErr string
+			if mt.hasFailedTrackingResources() {
+				failedErr = mt.formatFailedTrackingResourcesError().Error()
+			}
+			activeResources := strings.Join(mt.getActiveResourcesNames(), ",")
+			for _, k := range kinds {
+				for key, spec := range k.Specs {
+					id := mt.resourceID(k.Kind, spec)
+					state := k.States[key]
+					outcomes[string(state.Status)]++
+
+					switch state.Status {
+					case ResourceSucceeded:
+						if strings.Contains(failedErr, id+":") || strings.Contains(failedErr, id+" ") {
+							fail("ready %s reported failed: %s", id, failedErr)
+						}
+						if strings.Contains(activeResources+",", id+",") {
+							fail("ready %s reported active", id)
+						}
+					case ResourceFailed:
+						if !strings.Contains(failedErr, id) {
+							fail("failed %s not reported failed: %s", id, failedErr)
+						}
+						if strings.Contains(activeResources+",", id+",") {
+							fail("failed %s reported active", id)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	for _, outcome := range []string{"duplicate", string(ResourceSucceeded), string(ResourceFailed), string(ResourceHoping)} {
+		if outcomes[outcome] == 0 {
+			t.Errorf("expected random operations to result in %s", outcome)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package multitrack
+
+import (
+	"fmt"
+
+	"github.com/werf/kubedog/pkg/tracker/pod"
+)
+
+var showLogsUntilDuration = map[DeployCondition]int{
+	PodIsReady:        1,
+	ControllerIsReady: 2,
+	EndOfDeploy:       3,
+}
+
+// showLogsUntil returns ShowLogsUntil of the spec or its default for the kind.
+func showLogsUntil(kind string, spec MultitrackSpec) DeployCondition {
+	if spec.ShowLogsUntil != "" {
+		return spec.ShowLogsUntil
+	}
+
+	switch kind {
+	case "job", "po":
+		return ControllerIsReady
+	default:
+		return PodIsReady
+	}
+}
+
+func validateShowLogsUntil(specs MultitrackSpecs) error {
+	for _, kindSpecs := range []struct {
+		Kind  string
+		Specs []MultitrackSpec
+	}{
+		{"deploy", specs.Deployments},
+		{"rs", specs.ReplicaSets},
+		{"sts", specs.StatefulSets},
+		{"ds", specs.DaemonSets},
+		{"job", specs.Jobs},
+		{"po", specs.Pods},
+		{"generic", specs.Generic},
+	} {
+		for _, spec := range kindSpecs.Specs {
+			if _, hasKey := showLogsUntilDuration[spec.ShowLogsUntil]; spec.ShowLogsUntil != "" && !hasKey {
+				return fmt.Errorf("bad %s/%s spec: unknown ShowLogsUntil %q, expected one of PodIsReady, ControllerIsReady or EndOfDeploy", kindSpecs.Kind, spec.ResourceName, spec.ShowLogsUntil)
+			}
+		}
+	}
+
+	return nil
+}
+
+// isPodLogsShown returns false for the ready Pod of the resource with PodIsReady, the logs of other Pods are shown
+// until the tracker of the resource is stopped.
+func (mt *multitracker) isPodLogsShown(kind string, spec MultitrackSpec, pods map[string]pod.PodStatus, podName string) bool {
+	if mt.isFollowMode || showLogsUntil(kind, spec) != PodIsReady {
+		return true
+	}
+
+	podStatus, hasKey := pods[podName]
+	return !hasKey || !podStatus.IsReady
+}
+
+// isLogsShownUntilEndOfDeploy returns true when the tracker of the ready resource should keep streaming the logs.
+// The trackers of the resources with post-readiness checks are stopped before the checks, so their logs stop on readiness.
+func isLogsShownUntilEndOfDeploy(kind string, spec MultitrackSpec) bool {
+	return showLogsUntil(kind, spec) == EndOfDeploy && !spec.SkipLogs && kind != "generic" && !hasPostReadinessChecks(spec)
+}
+
+// showLogsUntilEndOfDeploy keeps the tracker of the ready resource running to stream the logs,
+// the tracker is cancelled by applyTrackTerminationMode as soon as no other resource is waited for.
+func (mt *multitracker) showLogsUntilEndOfDeploy(resourcesStates map[string]*multitrackerResourceState, kind string, spec MultitrackSpec) error {
+	resourcesStates[resourceKey(spec)].IsShowingLogsUntilEndOfDeploy = true
+	mt.displayResourceTrackerMessageF(kind, spec, "showing logs until the end of deploy")
+
+	return mt.applyTrackTerminationMode()
+}
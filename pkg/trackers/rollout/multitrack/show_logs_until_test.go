@@ -0,0 +1,129 @@
+package multitrack
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/werf/kubedog/pkg/tracker/pod"
+)
+
+func TestShowLogsUntil(t *testing.T) {
+	tests := []struct {
+		kind     string
+		spec     MultitrackSpec
+		expected DeployCondition
+	}{
+		{kind: "deploy", expected: PodIsReady},
+		{kind: "sts", expected: PodIsReady},
+		{kind: "generic", expected: PodIsReady},
+		{kind: "job", expected: ControllerIsReady},
+		{kind: "po", expected: ControllerIsReady},
+		{kind: "job", spec: MultitrackSpec{ShowLogsUntil: PodIsReady}, expected: PodIsReady},
+		{kind: "deploy", spec: MultitrackSpec{ShowLogsUntil: EndOfDeploy}, expected: EndOfDeploy},
+	}
+
+	for _, tt := range tests {
+		if res := showLogsUntil(tt.kind, tt.spec); res != tt.expected {
+			t.Errorf("%s with %q: expected %q, got %q", tt.kind, tt.spec.ShowLogsUntil, tt.expected, res)
+		}
+	}
+}
+
+func TestValidateShowLogsUntil(t *testing.T) {
+	specs := MultitrackSpecs{
+		Deployments: []MultitrackSpec{{ResourceName: "api", ShowLogsUntil: EndOfDeploy}},
+		Jobs:        []MultitrackSpec{{ResourceName: "migrate"}},
+	}
+	if err := validateShowLogsUntil(specs); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	specs.Jobs[0].ShowLogsUntil = "JobIsComplete"
+	expectedErr := `bad job/migrate spec: unknown ShowLogsUntil "JobIsComplete", expected one of PodIsReady, ControllerIsReady or EndOfDeploy`
+	if err := validateShowLogsUntil(specs); err == nil || err.Error() != expectedErr {
+		t.Errorf("expected error %q, got %v", expectedErr, err)
+	}
+}
+
+func TestIsPodLogsShown(t *testing.T) {
+	pods := map[string]pod.PodStatus{
+		"api-ready":   {IsReady: true},
+		"api-pending": {},
+	}
+
+	tests := []struct {
+		name     string
+		kind     string
+		spec     MultitrackSpec
+		follow   bool
+		podName  string
+		expected bool
+	}{
+		{name: "ready Pod", kind: "deploy", podName: "api-ready", expected: false},
+		{name: "not ready Pod", kind: "deploy", podName: "api-pending", expected: true},
+		{name: "unknown Pod", kind: "deploy", podName: "api-new", expected: true},
+		{name: "ready Pod in follow mode", kind: "deploy", follow: true, podName: "api-ready", expected: true},
+		{name: "ready Pod with ControllerIsReady", kind: "deploy", spec: MultitrackSpec{ShowLogsUntil: ControllerIsReady}, podName: "api-ready", expected: true},
+		{name: "ready Pod of Job by default", kind: "job", podName: "api-ready", expected: true},
+	}
+
+	for _, tt := range tests {
+		mt := &multitracker{isFollowMode: tt.follow}
+		if res := mt.isPodLogsShown(tt.kind, tt.spec, pods, tt.podName); res != tt.expected {
+			t.Errorf("%s: expected %t, got %t", tt.name, tt.expected, res)
+		}
+	}
+}
+
+func TestIsLogsShownUntilEndOfDeploy(t *testing.T) {
+	tests := []struct {
+		name     string
+		kind     string
+		spec     MultitrackSpec
+		expected bool
+	}{
+		{name: "EndOfDeploy", kind: "deploy", spec: MultitrackSpec{ShowLogsUntil: EndOfDeploy}, expected: true},
+		{name: "default", kind: "deploy", expected: false},
+		{name: "skip logs", kind: "deploy", spec: MultitrackSpec{ShowLogsUntil: EndOfDeploy, SkipLogs: true}, expected: false},
+		{name: "generic", kind: "generic", spec: MultitrackSpec{ShowLogsUntil: EndOfDeploy}, expected: false},
+		{name: "post-readiness checks", kind: "deploy", spec: MultitrackSpec{ShowLogsUntil: EndOfDeploy, VerifyMountedTLSSecrets: true}, expected: false},
+		{name: "post-readiness checks of expected failure", kind: "deploy", spec: MultitrackSpec{ShowLogsUntil: EndOfDeploy, VerifyMountedTLSSecrets: true, ExpectFailure: true}, expected: true},
+	}
+
+	for _, tt := range tests {
+		if res := isLogsShownUntilEndOfDeploy(tt.kind, tt.spec); res != tt.expected {
+			t.Errorf("%s: expected %t, got %t", tt.name, tt.expected, res)
+		}
+	}
+}
+
+// TestMultitrackShowLogsUntilEndOfDeploy makes the Pod with EndOfDeploy ready first: its tracker keeps running
+// and is cancelled when the other Pod is ready, Multitrack returns with both Pods ready.
+func TestMultitrackShowLogsUntilEndOfDeploy(t *testing.T) {
+	kube := fake.NewSimpleClientset()
+	pods, specs := createTestPods(t, kube, 2)
+	specs[0].ShowLogsUntil = EndOfDeploy
+
+	stopUpdates := runTestPodsUpdates(t, kube, pods, func(i, step int, pod *corev1.Pod) *corev1.Pod {
+		if i == 0 {
+			return setTestPodReady(pod)
+		}
+		return pod
+	}, func(i int, pod *corev1.Pod) *corev1.Pod {
+		return setTestPodReady(pod)
+	})
+	defer stopUpdates()
+
+	result, _, err := runTestMultitrack(t, kube, MultitrackSpecs{Pods: specs}, MultitrackOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, name := range []string{"app-0", "app-1"} {
+		if resource := findTestResourceResult(result, "po", name); resource == nil || resource.Outcome != ResourceOutcomeReady {
+			t.Errorf("expected Ready outcome of %s, got %+v", name, resource)
+		}
+	}
+}
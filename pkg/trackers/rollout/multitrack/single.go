@@ -0,0 +1,152 @@
+package multitrack
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/werf/kubedog/pkg/display"
+)
+
+// Option configures single resource tracking helpers such as TrackDeploymentUntilReady.
+type Option func(*singleTrackOptions)
+
+type singleTrackOptions struct {
+	Spec    MultitrackSpec
+	Options MultitrackOptions
+}
+
+// WithTimeout limits the whole tracking time of the resource.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *singleTrackOptions) {
+		o.Options.Timeout = timeout
+	}
+}
+
+// WithFailMode sets FailMode of the tracked resource, FailWholeDeployProcessImmediately is used by default.
+func WithFailMode(failMode FailMode) Option {
+	return func(o *singleTrackOptions) {
+		o.Spec.FailMode = failMode
+	}
+}
+
+// WithAllowFailuresCount sets AllowFailuresCount of the tracked resource.
+func WithAllowFailuresCount(count int) Option {
+	return func(o *singleTrackOptions) {
+		o.Spec.AllowFailuresCount = new(int)
+		*o.Spec.AllowFailuresCount = count
+	}
+}
+
+// WithAllowFailuresCountPerReplica sets AllowFailuresCountPerReplica of the tracked resource.
+func WithAllowFailuresCountPerReplica(count int) Option {
+	return func(o *singleTrackOptions) {
+		o.Spec.AllowFailuresCountPerReplica = new(int)
+		*o.Spec.AllowFailuresCountPerReplica = count
+	}
+}
+
+// WithLogs enables or disables streaming of the resource pods logs, logs are shown by default.
+func WithLogs(enabled bool) Option {
+	return func(o *singleTrackOptions) {
+		o.Spec.SkipLogs = !enabled
+	}
+}
+
+// WithLogsFromTime shows only logs written after the specified time.
+func WithLogsFromTime(logsFromTime time.Time) Option {
+	return func(o *singleTrackOptions) {
+		o.Options.LogsFromTime = logsFromTime
+	}
+}
+
+// WithStatusProgressPeriod sets the period of status progress reports, negative value disables reports.
+func WithStatusProgressPeriod(period time.Duration) Option {
+	return func(o *singleTrackOptions) {
+		o.Options.StatusProgressPeriod = period
+	}
+}
+
+// WithStatusProgressOnPhaseChange reports status progress only on changes of the resource phase instead of the periodic reports.
+func WithStatusProgressOnPhaseChange() Option {
+	return func(o *singleTrackOptions) {
+		o.Options.StatusProgressMode = StatusProgressOnPhaseChange
+	}
+}
+
+// WithDisplay writes the output of tracking into the display instead of os.Stdout, e.g. display.NewDisplay(buf, nil) captures it into a buffer.
+func WithDisplay(d *display.Display) Option {
+	return func(o *singleTrackOptions) {
+		o.Options.Display = d
+	}
+}
+
+// WithOutput sets the output mode of status reports and events, the writer is optional, see Output and OutputWriter of MultitrackOptions.
+func WithOutput(output OutputMode, w io.Writer) Option {
+	return func(o *singleTrackOptions) {
+		o.Options.Output = output
+		o.Options.OutputWriter = w
+	}
+}
+
+// TrackDeploymentUntilReady is the recommended way to track a single Deployment.
+// It blocks until the Deployment is ready, fails or ctx is done.
+func TrackDeploymentUntilReady(ctx context.Context, kube kubernetes.Interface, namespace, name string, opts ...Option) error {
+	return trackSingleResource(ctx, kube, namespace, name, opts, func(specs *MultitrackSpecs, spec MultitrackSpec) {
+		specs.Deployments = append(specs.Deployments, spec)
+	})
+}
+
+// TrackStatefulSetUntilReady is the recommended way to track a single StatefulSet.
+// It blocks until the StatefulSet is ready, fails or ctx is done.
+func TrackStatefulSetUntilReady(ctx context.Context, kube kubernetes.Interface, namespace, name string, opts ...Option) error {
+	return trackSingleResource(ctx, kube, namespace, name, opts, func(specs *MultitrackSpecs, spec MultitrackSpec) {
+		specs.StatefulSets = append(specs.StatefulSets, spec)
+	})
+}
+
+// TrackDaemonSetUntilReady is the recommended way to track a single DaemonSet.
+// It blocks until the DaemonSet is ready, fails or ctx is done.
+func TrackDaemonSetUntilReady(ctx context.Context, kube kubernetes.Interface, namespace, name string, opts ...Option) error {
+	return trackSingleResource(ctx, kube, namespace, name, opts, func(specs *MultitrackSpecs, spec MultitrackSpec) {
+		specs.DaemonSets = append(specs.DaemonSets, spec)
+	})
+}
+
+// TrackJobUntilDone is the recommended way to track a single Job.
+// It blocks until the Job succeeds, fails or ctx is done.
+func TrackJobUntilDone(ctx context.Context, kube kubernetes.Interface, namespace, name string, opts ...Option) error {
+	return trackSingleResource(ctx, kube, namespace, name, opts, func(specs *MultitrackSpecs, spec MultitrackSpec) {
+		specs.Jobs = append(specs.Jobs, spec)
+	})
+}
+
+// TrackPodUntilDone is the recommended way to track a single bare Pod.
+// It blocks until the Pod is ready (Always restart policy) or succeeded (Never and OnFailure restart policies),
+// fails or ctx is done.
+func TrackPodUntilDone(ctx context.Context, kube kubernetes.Interface, namespace, name string, opts ...Option) error {
+	return trackSingleResource(ctx, kube, namespace, name, opts, func(specs *MultitrackSpecs, spec MultitrackSpec) {
+		specs.Pods = append(specs.Pods, spec)
+	})
+}
+
+func trackSingleResource(ctx context.Context, kube kubernetes.Interface, namespace, name string, opts []Option, addSpec func(*MultitrackSpecs, MultitrackSpec)) error {
+	o := &singleTrackOptions{
+		Spec: MultitrackSpec{
+			ResourceName: name,
+			Namespace:    namespace,
+		},
+	}
+	o.Options.ParentContext = ctx
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	specs := MultitrackSpecs{}
+	addSpec(&specs, o.Spec)
+
+	return Multitrack(kube, specs, o.Options)
+}
@@ -0,0 +1,225 @@
+package multitrack
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/werf/kubedog/pkg/display"
+)
+
+func TestTrackPodUntilDone(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		final       func(pod *corev1.Pod) *corev1.Pod
+		expectedErr bool
+	}{
+		{name: "ready", final: setTestPodReady},
+		{name: "failed", final: setTestPodFailed, expectedErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			kube := fake.NewSimpleClientset()
+			pods, _ := createTestPods(t, kube, 1)
+
+			stopUpdates := runTestPodsUpdates(t, kube, pods, func(i, step int, pod *corev1.Pod) *corev1.Pod {
+				return pod
+			}, func(i int, pod *corev1.Pod) *corev1.Pod {
+				return tc.final(pod)
+			})
+			defer stopUpdates()
+
+			ctx, cancel := context.WithTimeout(context.Background(), testMultitrackTimeout)
+			defer cancel()
+
+			output := &bytes.Buffer{}
+			events := &bytes.Buffer{}
+			err := TrackPodUntilDone(ctx, kube, pods[0].Namespace, pods[0].Name,
+				WithDisplay(display.NewDisplay(output, nil)),
+				WithOutput(OutputJSONEvents, events),
+				WithStatusProgressPeriod(-1),
+				WithTimeout(time.Minute),
+			)
+
+			if ctx.Err() != nil {
+				t.Fatalf("TrackPodUntilDone has not returned within %s", testMultitrackTimeout)
+			}
+			if tc.expectedErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v\n%s", tc.expectedErr, err, output.String())
+			}
+
+			// Messages are written into the display, status reports are written as JSON events into the output writer
+			if !strings.Contains(output.String(), `namespace "ns-0"`) || strings.Contains(output.String(), `"name":"app-0"`) {
+				t.Errorf("expected text messages of ns-0 in the display, got:\n%s", output.String())
+			}
+			if !strings.Contains(events.String(), `"name":"app-0"`) {
+				t.Errorf("expected JSON events of app-0 in the output writer, got:\n%s", events.String())
+			}
+		})
+	}
+}
+
+// runTestFailureEvents creates a new failure event of the resource every few milliseconds until the returned stop function is called,
+// the events created before the event informer watches are not received.
+func runTestFailureEvents(t *testing.T, kube kubernetes.Interface, namespace, name, kind string) func() {
+	stopEvents := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := 0; ; i++ {
+			event := &corev1.Event{
+				ObjectMeta:     metav1.ObjectMeta{Name: fmt.Sprintf("%s.%d", name, i), Namespace: namespace, UID: types.UID(fmt.Sprintf("%s-%d", name, i))},
+				InvolvedObject: corev1.ObjectReference{Kind: kind, Namespace: namespace, Name: name},
+				Reason:         "FailedCreate",
+				Message:        "pods is forbidden: exceeded quota",
+				Type:           corev1.EventTypeWarning,
+			}
+			if _, err := kube.CoreV1().Events(namespace).Create(context.Background(), event, metav1.CreateOptions{}); err != nil {
+				t.Errorf("unexpected error creating event: %s", err)
+				return
+			}
+
+			select {
+			case <-stopEvents:
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}()
+
+	return func() {
+		close(stopEvents)
+		<-done
+	}
+}
+
+func TestTrackUntilReady(t *testing.T) {
+	replicas := int32(2)
+	backoffLimit := int32(0)
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}
+	template := corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}}}
+	objectMeta := metav1.ObjectMeta{Name: "web", Namespace: "default", Generation: 1}
+
+	// Events are considered unavailable in the namespace without events
+	scheduledEvent := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-0.scheduled", Namespace: "default", UID: "web-0-scheduled"},
+		Reason:     "Scheduled",
+		Message:    "Successfully assigned default/web-0 to node-1",
+	}
+
+	readyDeployment := &appsv1.Deployment{
+		ObjectMeta: objectMeta,
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas, Selector: selector, Template: template},
+		Status:     appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 2, UpdatedReplicas: 2, ReadyReplicas: 2, AvailableReplicas: 2},
+	}
+	stuckDeployment := readyDeployment.DeepCopy()
+	stuckDeployment.Status = appsv1.DeploymentStatus{
+		ObservedGeneration: 1, Replicas: 2, UpdatedReplicas: 1, ReadyReplicas: 1, AvailableReplicas: 1,
+		Conditions: []appsv1.DeploymentCondition{{
+			Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse, Reason: "ProgressDeadlineExceeded",
+			Message: `ReplicaSet "web-5d9c7b" has timed out progressing.`,
+		}},
+	}
+
+	readyStatefulSet := &appsv1.StatefulSet{
+		ObjectMeta: objectMeta,
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas, Selector: selector, Template: template,
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: appsv1.RollingUpdateStatefulSetStrategyType},
+		},
+		Status: appsv1.StatefulSetStatus{
+			ObservedGeneration: 1, Replicas: 2, ReadyReplicas: 2, CurrentReplicas: 2, UpdatedReplicas: 2,
+			CurrentRevision: "web-1", UpdateRevision: "web-1",
+		},
+	}
+	pendingStatefulSet := readyStatefulSet.DeepCopy()
+	pendingStatefulSet.Status = appsv1.StatefulSetStatus{ObservedGeneration: 1, Replicas: 0, CurrentRevision: "web-1", UpdateRevision: "web-1"}
+
+	readyDaemonSet := &appsv1.DaemonSet{
+		ObjectMeta: objectMeta,
+		Spec: appsv1.DaemonSetSpec{
+			Selector: selector, Template: template,
+			UpdateStrategy: appsv1.DaemonSetUpdateStrategy{Type: appsv1.RollingUpdateDaemonSetStrategyType},
+		},
+		Status: appsv1.DaemonSetStatus{
+			ObservedGeneration: 1, DesiredNumberScheduled: 3, CurrentNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberReady: 3, NumberAvailable: 3,
+		},
+	}
+	pendingDaemonSet := readyDaemonSet.DeepCopy()
+	pendingDaemonSet.Status = appsv1.DaemonSetStatus{ObservedGeneration: 1, DesiredNumberScheduled: 3}
+
+	completedJob := &batchv1.Job{
+		ObjectMeta: objectMeta,
+		Spec:       batchv1.JobSpec{BackoffLimit: &backoffLimit, Selector: selector, Template: template},
+		Status: batchv1.JobStatus{
+			Succeeded:  1,
+			Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}},
+		},
+	}
+	failedJob := completedJob.DeepCopy()
+	failedJob.Status = batchv1.JobStatus{
+		Failed:     1,
+		Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded", Message: "Job has reached the specified backoff limit"}},
+	}
+
+	for _, tc := range []struct {
+		name   string
+		object runtime.Object
+		track  func(ctx context.Context, kube kubernetes.Interface, namespace, name string, opts ...Option) error
+		// failureEventsKind is the kind of the resource, which fails by the failure events
+		failureEventsKind string
+		expectedErr       string
+	}{
+		{name: "deployment ready", object: readyDeployment, track: TrackDeploymentUntilReady},
+		{name: "deployment failed", object: stuckDeployment, track: TrackDeploymentUntilReady, expectedErr: "ProgressDeadlineExceeded"},
+		{name: "statefulset ready", object: readyStatefulSet, track: TrackStatefulSetUntilReady},
+		{name: "statefulset failed", object: pendingStatefulSet, track: TrackStatefulSetUntilReady, failureEventsKind: "StatefulSet", expectedErr: "FailedCreate"},
+		{name: "daemonset ready", object: readyDaemonSet, track: TrackDaemonSetUntilReady},
+		{name: "daemonset failed", object: pendingDaemonSet, track: TrackDaemonSetUntilReady, failureEventsKind: "DaemonSet", expectedErr: "FailedCreate"},
+		{name: "job done", object: completedJob, track: TrackJobUntilDone},
+		{name: "job failed", object: failedJob, track: TrackJobUntilDone, expectedErr: "BackoffLimitExceeded"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			kube := fake.NewSimpleClientset(tc.object, scheduledEvent.DeepCopy())
+
+			if tc.failureEventsKind != "" {
+				stopEvents := runTestFailureEvents(t, kube, "default", "web", tc.failureEventsKind)
+				defer stopEvents()
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), testMultitrackTimeout)
+			defer cancel()
+
+			output := &bytes.Buffer{}
+			err := tc.track(ctx, kube, "default", "web",
+				WithDisplay(display.NewDisplay(output, nil)),
+				WithStatusProgressPeriod(-1),
+				WithAllowFailuresCount(0),
+				WithTimeout(time.Minute),
+			)
+
+			if ctx.Err() != nil {
+				t.Fatalf("tracking has not returned within %s\n%s", testMultitrackTimeout, output.String())
+			}
+			if tc.expectedErr == "" && err != nil {
+				t.Fatalf("unexpected error: %s\n%s", err, output.String())
+			}
+			if tc.expectedErr != "" && (err == nil || !strings.Contains(err.Error(), tc.expectedErr)) {
+				t.Fatalf("expected error containing %q, got %v\n%s", tc.expectedErr, err, output.String())
+			}
+		})
+	}
+}
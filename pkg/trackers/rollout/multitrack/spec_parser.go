@@ -0,0 +1,349 @@
+package multitrack
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+
+	"github.com/werf/kubedog/pkg/tracker/generic"
+)
+
+// SerializedSpecs is the stable YAML/JSON form of MultitrackSpecs, see ParseSpecs and MarshalSpecs, e.g.:
+//
+//	deployments:
+//	- name: api
+//	  namespace: prod
+//	  failMode: HopeUntilEndOfDeployProcess
+//	  allowFailuresCount: 3
+//	  skipLogsForContainers: [istio-proxy]
+//	jobs:
+//	- name: migrate
+//	  namespace: prod
+type SerializedSpecs struct {
+	Deployments  []SerializedSpec `json:"deployments,omitempty"`
+	ReplicaSets  []SerializedSpec `json:"replicaSets,omitempty"`
+	StatefulSets []SerializedSpec `json:"statefulSets,omitempty"`
+	DaemonSets   []SerializedSpec `json:"daemonSets,omitempty"`
+	Jobs         []SerializedSpec `json:"jobs,omitempty"`
+	Pods         []SerializedSpec `json:"pods,omitempty"`
+	Generic      []SerializedSpec `json:"generic,omitempty"`
+}
+
+// SerializedSpec is the serializable subset of MultitrackSpec fields, the fields have the same meaning.
+type SerializedSpec struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+
+	TrackTerminationMode         TrackTerminationMode `json:"trackTerminationMode,omitempty"`
+	FailMode                     FailMode             `json:"failMode,omitempty"`
+	AllowFailuresCount           *int                 `json:"allowFailuresCount,omitempty"`
+	AllowFailuresCountPerReplica *int                 `json:"allowFailuresCountPerReplica,omitempty"`
+	FailureThresholdSeconds      *int                 `json:"failureThresholdSeconds,omitempty"`
+	SuccessCondition             SuccessCondition     `json:"successCondition,omitempty"`
+	TrackTimeoutSeconds          *int                 `json:"trackTimeoutSeconds,omitempty"`
+	StabilityWindowSeconds       int                  `json:"stabilityWindowSeconds,omitempty"`
+
+	WaitForResourceCreation        bool `json:"waitForResourceCreation,omitempty"`
+	ResourceCreationTimeoutSeconds *int `json:"resourceCreationTimeoutSeconds,omitempty"`
+	TreatDeletionAsSuccess         bool `json:"treatDeletionAsSuccess,omitempty"`
+
+	LogRegex                  string            `json:"logRegex,omitempty"`
+	LogRegexByContainerName   map[string]string `json:"logRegexByContainerName,omitempty"`
+	SkipLogs                  bool              `json:"skipLogs,omitempty"`
+	SkipLogsForContainers     []string          `json:"skipLogsForContainers,omitempty"`
+	ShowLogsOnlyForContainers []string          `json:"showLogsOnlyForContainers,omitempty"`
+	TailLines                 *int64            `json:"tailLines,omitempty"`
+	ShowLogsUntil             DeployCondition   `json:"showLogsUntil,omitempty"`
+	LogLinePrefix             string            `json:"logLinePrefix,omitempty"`
+	ShowLogTimestamps         bool              `json:"showLogTimestamps,omitempty"`
+	ShowServiceMessages       bool              `json:"showServiceMessages,omitempty"`
+
+	// DependsOn references the resources in the "kind/name" or "kind/namespace/name" form, e.g. "job/migrate".
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// Group, Version and Resource of the Generic resource, e.g. "cert-manager.io", "v1" and "certificates".
+	Group    string `json:"group,omitempty"`
+	Version  string `json:"version,omitempty"`
+	Resource string `json:"resource,omitempty"`
+	// ReadyCondition and FailedCondition of the Generic resource in the "Type=Status" form, e.g. "Ready=True".
+	ReadyCondition  string `json:"readyCondition,omitempty"`
+	FailedCondition string `json:"failedCondition,omitempty"`
+}
+
+// ParseSpecs reads SerializedSpecs YAML or JSON document and returns MultitrackSpecs with default values set.
+// Unknown fields and values are rejected with the error naming the entry and the field, e.g. "deployments[2].failMode: unknown value 'Panic'".
+func ParseSpecs(r io.Reader) (MultitrackSpecs, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return MultitrackSpecs{}, fmt.Errorf("unable to read specs: %s", err)
+	}
+
+	var serialized SerializedSpecs
+	if err := yaml.UnmarshalStrict(data, &serialized); err != nil {
+		return MultitrackSpecs{}, fmt.Errorf("unable to parse specs: %s", err)
+	}
+
+	var specs MultitrackSpecs
+	for _, kind := range []struct {
+		Field      string
+		Serialized []SerializedSpec
+		Specs      *[]MultitrackSpec
+	}{
+		{"deployments", serialized.Deployments, &specs.Deployments},
+		{"replicaSets", serialized.ReplicaSets, &specs.ReplicaSets},
+		{"statefulSets", serialized.StatefulSets, &specs.StatefulSets},
+		{"daemonSets", serialized.DaemonSets, &specs.DaemonSets},
+		{"jobs", serialized.Jobs, &specs.Jobs},
+		{"pods", serialized.Pods, &specs.Pods},
+		{"generic", serialized.Generic, &specs.Generic},
+	} {
+		for i, s := range kind.Serialized {
+			spec, err := s.toSpec(kind.Field == "generic")
+			if err != nil {
+				return MultitrackSpecs{}, fmt.Errorf("%s[%d].%s", kind.Field, i, err)
+			}
+			setDefaultSpecValues(&spec)
+
+			*kind.Specs = append(*kind.Specs, spec)
+		}
+	}
+
+	return specs, nil
+}
+
+// MarshalSpecs returns SerializedSpecs YAML document of the specs, which ParseSpecs reads back.
+// MultitrackSpec fields missing in SerializedSpec are not serialized.
+func MarshalSpecs(specs MultitrackSpecs) ([]byte, error) {
+	serialized := SerializedSpecs{
+		Deployments:  newSerializedSpecs(specs.Deployments),
+		ReplicaSets:  newSerializedSpecs(specs.ReplicaSets),
+		StatefulSets: newSerializedSpecs(specs.StatefulSets),
+		DaemonSets:   newSerializedSpecs(specs.DaemonSets),
+		Jobs:         newSerializedSpecs(specs.Jobs),
+		Pods:         newSerializedSpecs(specs.Pods),
+		Generic:      newSerializedSpecs(specs.Generic),
+	}
+
+	return yaml.Marshal(serialized)
+}
+
+// toSpec returns the error prefixed with the field name, e.g. "failMode: unknown value 'Panic'".
+func (s SerializedSpec) toSpec(isGeneric bool) (MultitrackSpec, error) {
+	if s.Name == "" {
+		return MultitrackSpec{}, fmt.Errorf("name: required")
+	}
+
+	switch s.TrackTerminationMode {
+	case "", WaitUntilResourceReady, NonBlocking:
+	default:
+		return MultitrackSpec{}, fmt.Errorf("trackTerminationMode: unknown value '%s'", s.TrackTerminationMode)
+	}
+
+	if _, hasKey := failModesStrictness[s.FailMode]; s.FailMode != "" && !hasKey {
+		return MultitrackSpec{}, fmt.Errorf("failMode: unknown value '%s'", s.FailMode)
+	}
+
+	if _, hasKey := showLogsUntilDuration[s.ShowLogsUntil]; s.ShowLogsUntil != "" && !hasKey {
+		return MultitrackSpec{}, fmt.Errorf("showLogsUntil: unknown value '%s'", s.ShowLogsUntil)
+	}
+
+	switch s.SuccessCondition {
+	case "", SuccessConditionReady, SuccessConditionPodsSucceeded:
+	default:
+		return MultitrackSpec{}, fmt.Errorf("successCondition: unknown value '%s'", s.SuccessCondition)
+	}
+
+	if s.AllowFailuresCount != nil && *s.AllowFailuresCount < 0 {
+		return MultitrackSpec{}, fmt.Errorf("allowFailuresCount: negative value %d", *s.AllowFailuresCount)
+	}
+
+	if s.AllowFailuresCountPerReplica != nil && *s.AllowFailuresCountPerReplica < 0 {
+		return MultitrackSpec{}, fmt.Errorf("allowFailuresCountPerReplica: negative value %d", *s.AllowFailuresCountPerReplica)
+	}
+
+	spec := MultitrackSpec{
+		ResourceName:                   s.Name,
+		Namespace:                      s.Namespace,
+		TrackTerminationMode:           s.TrackTerminationMode,
+		FailMode:                       s.FailMode,
+		AllowFailuresCount:             s.AllowFailuresCount,
+		AllowFailuresCountPerReplica:   s.AllowFailuresCountPerReplica,
+		FailureThresholdSeconds:        s.FailureThresholdSeconds,
+		SuccessCondition:               s.SuccessCondition,
+		TrackTimeoutSeconds:            s.TrackTimeoutSeconds,
+		StabilityWindowSeconds:         s.StabilityWindowSeconds,
+		WaitForResourceCreation:        s.WaitForResourceCreation,
+		ResourceCreationTimeoutSeconds: s.ResourceCreationTimeoutSeconds,
+		TreatDeletionAsSuccess:         s.TreatDeletionAsSuccess,
+		SkipLogs:                       s.SkipLogs,
+		SkipLogsForContainers:          s.SkipLogsForContainers,
+		ShowLogsOnlyForContainers:      s.ShowLogsOnlyForContainers,
+		TailLines:                      s.TailLines,
+		ShowLogsUntil:                  s.ShowLogsUntil,
+		LogLinePrefix:                  s.LogLinePrefix,
+		ShowLogTimestamps:              s.ShowLogTimestamps,
+		ShowServiceMessages:            s.ShowServiceMessages,
+	}
+
+	if s.LogRegex != "" {
+		logRegex, err := regexp.Compile(s.LogRegex)
+		if err != nil {
+			return MultitrackSpec{}, fmt.Errorf("logRegex: %s", err)
+		}
+		spec.LogRegex = logRegex
+	}
+
+	for _, containerName := range sortedAnnotationsKeys(s.LogRegexByContainerName) {
+		logRegex, err := regexp.Compile(s.LogRegexByContainerName[containerName])
+		if err != nil {
+			return MultitrackSpec{}, fmt.Errorf("logRegexByContainerName.%s: %s", containerName, err)
+		}
+
+		if spec.LogRegexByContainerName == nil {
+			spec.LogRegexByContainerName = make(map[string]*regexp.Regexp)
+		}
+		spec.LogRegexByContainerName[containerName] = logRegex
+	}
+
+	for i, value := range s.DependsOn {
+		ref, err := parseDependency(value)
+		if err != nil {
+			return MultitrackSpec{}, fmt.Errorf("dependsOn[%d]: %s", i, err)
+		}
+		spec.DependsOn = append(spec.DependsOn, ref)
+	}
+
+	if !isGeneric {
+		for _, field := range []struct{ Name, Value string }{
+			{"group", s.Group}, {"version", s.Version}, {"resource", s.Resource},
+			{"readyCondition", s.ReadyCondition}, {"failedCondition", s.FailedCondition},
+		} {
+			if field.Value != "" {
+				return MultitrackSpec{}, fmt.Errorf("%s: supported only for generic resources", field.Name)
+			}
+		}
+		return spec, nil
+	}
+
+	if s.Version == "" {
+		return MultitrackSpec{}, fmt.Errorf("version: required")
+	}
+	if s.Resource == "" {
+		return MultitrackSpec{}, fmt.Errorf("resource: required")
+	}
+	spec.GroupVersionResource = schema.GroupVersionResource{Group: s.Group, Version: s.Version, Resource: s.Resource}
+
+	if s.ReadyCondition != "" {
+		match, err := parseConditionMatch(s.ReadyCondition)
+		if err != nil {
+			return MultitrackSpec{}, fmt.Errorf("readyCondition: %s", err)
+		}
+		spec.ReadyCondition = match
+	}
+
+	if s.FailedCondition != "" {
+		match, err := parseConditionMatch(s.FailedCondition)
+		if err != nil {
+			return MultitrackSpec{}, fmt.Errorf("failedCondition: %s", err)
+		}
+		spec.FailedCondition = match
+	}
+
+	return spec, nil
+}
+
+func newSerializedSpecs(specs []MultitrackSpec) []SerializedSpec {
+	var res []SerializedSpec
+	for _, spec := range specs {
+		res = append(res, newSerializedSpec(spec))
+	}
+	return res
+}
+
+func newSerializedSpec(spec MultitrackSpec) SerializedSpec {
+	s := SerializedSpec{
+		Name:                           spec.ResourceName,
+		Namespace:                      spec.Namespace,
+		TrackTerminationMode:           spec.TrackTerminationMode,
+		FailMode:                       spec.FailMode,
+		AllowFailuresCount:             spec.AllowFailuresCount,
+		AllowFailuresCountPerReplica:   spec.AllowFailuresCountPerReplica,
+		FailureThresholdSeconds:        spec.FailureThresholdSeconds,
+		SuccessCondition:               spec.SuccessCondition,
+		TrackTimeoutSeconds:            spec.TrackTimeoutSeconds,
+		StabilityWindowSeconds:         spec.StabilityWindowSeconds,
+		WaitForResourceCreation:        spec.WaitForResourceCreation,
+		ResourceCreationTimeoutSeconds: spec.ResourceCreationTimeoutSeconds,
+		TreatDeletionAsSuccess:         spec.TreatDeletionAsSuccess,
+		SkipLogs:                       spec.SkipLogs,
+		SkipLogsForContainers:          spec.SkipLogsForContainers,
+		ShowLogsOnlyForContainers:      spec.ShowLogsOnlyForContainers,
+		TailLines:                      spec.TailLines,
+		ShowLogsUntil:                  spec.ShowLogsUntil,
+		LogLinePrefix:                  spec.LogLinePrefix,
+		ShowLogTimestamps:              spec.ShowLogTimestamps,
+		ShowServiceMessages:            spec.ShowServiceMessages,
+		Group:                          spec.GroupVersionResource.Group,
+		Version:                        spec.GroupVersionResource.Version,
+		Resource:                       spec.GroupVersionResource.Resource,
+	}
+
+	if spec.LogRegex != nil {
+		s.LogRegex = spec.LogRegex.String()
+	}
+
+	for containerName, logRegex := range spec.LogRegexByContainerName {
+		if s.LogRegexByContainerName == nil {
+			s.LogRegexByContainerName = make(map[string]string)
+		}
+		s.LogRegexByContainerName[containerName] = logRegex.String()
+	}
+
+	for _, ref := range spec.DependsOn {
+		if ref.Namespace == "" {
+			s.DependsOn = append(s.DependsOn, fmt.Sprintf("%s/%s", ref.Kind, ref.Name))
+		} else {
+			s.DependsOn = append(s.DependsOn, fmt.Sprintf("%s/%s/%s", ref.Kind, ref.Namespace, ref.Name))
+		}
+	}
+
+	if spec.ReadyCondition != nil {
+		s.ReadyCondition = spec.ReadyCondition.String()
+	}
+	if spec.FailedCondition != nil {
+		s.FailedCondition = spec.FailedCondition.String()
+	}
+
+	return s
+}
+
+func parseConditionMatch(value string) (*generic.ConditionMatch, error) {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) == 2 && parts[0] != "" && parts[1] != "" {
+		return &generic.ConditionMatch{Type: parts[0], Status: parts[1]}, nil
+	}
+	return nil, fmt.Errorf("bad value '%s': expected Type=Status, e.g. Ready=True", value)
+}
+
+func parseDependency(value string) (ResourceRef, error) {
+	parts := strings.Split(value, "/")
+	for _, part := range parts {
+		if part == "" {
+			return ResourceRef{}, fmt.Errorf("bad value '%s', expected kind/name or kind/namespace/name", value)
+		}
+	}
+
+	switch len(parts) {
+	case 2:
+		return ResourceRef{Kind: parts[0], Name: parts[1]}, nil
+	case 3:
+		return ResourceRef{Kind: parts[0], Namespace: parts[1], Name: parts[2]}, nil
+	default:
+		return ResourceRef{}, fmt.Errorf("bad value '%s', expected kind/name or kind/namespace/name", value)
+	}
+}
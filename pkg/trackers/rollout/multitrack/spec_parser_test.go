@@ -0,0 +1,158 @@
+package multitrack
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/werf/kubedog/pkg/tracker/generic"
+)
+
+const testSerializedSpecs = `
+deployments:
+- name: api
+  namespace: prod
+  failMode: HopeUntilEndOfDeployProcess
+  allowFailuresCount: 3
+  skipLogsForContainers: [istio-proxy]
+  logRegexByContainerName:
+    main: ^error
+  dependsOn: [job/migrate, sts/prod/db]
+jobs:
+- name: migrate
+  namespace: prod
+  treatDeletionAsSuccess: true
+generic:
+- name: cert
+  namespace: prod
+  group: cert-manager.io
+  version: v1
+  resource: certificates
+  readyCondition: Ready=True
+`
+
+func TestParseSpecs(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		data string
+	}{
+		{name: "YAML", data: testSerializedSpecs},
+		{
+			name: "JSON",
+			data: `{
+				"deployments": [{"name": "api", "namespace": "prod", "failMode": "HopeUntilEndOfDeployProcess", "allowFailuresCount": 3,
+					"skipLogsForContainers": ["istio-proxy"], "logRegexByContainerName": {"main": "^error"}, "dependsOn": ["job/migrate", "sts/prod/db"]}],
+				"jobs": [{"name": "migrate", "namespace": "prod", "treatDeletionAsSuccess": true}],
+				"generic": [{"name": "cert", "namespace": "prod", "group": "cert-manager.io", "version": "v1", "resource": "certificates", "readyCondition": "Ready=True"}]
+			}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			specs, err := ParseSpecs(strings.NewReader(tc.data))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if len(specs.Deployments) != 1 || len(specs.Jobs) != 1 || len(specs.Generic) != 1 || len(specs.Pods) != 0 {
+				t.Fatalf("expected 1 Deployment, 1 Job and 1 Generic spec, got %+v", specs)
+			}
+
+			deploy := specs.Deployments[0]
+			if deploy.ResourceName != "api" || deploy.Namespace != "prod" || deploy.FailMode != HopeUntilEndOfDeployProcess {
+				t.Errorf("unexpected Deployment spec %+v", deploy)
+			}
+			if deploy.AllowFailuresCount == nil || *deploy.AllowFailuresCount != 3 {
+				t.Errorf("expected allowFailuresCount 3, got %v", deploy.AllowFailuresCount)
+			}
+			if !reflect.DeepEqual(deploy.SkipLogsForContainers, []string{"istio-proxy"}) {
+				t.Errorf("expected skipLogsForContainers [istio-proxy], got %q", deploy.SkipLogsForContainers)
+			}
+			if logRegex := deploy.LogRegexByContainerName["main"]; logRegex == nil || logRegex.String() != "^error" {
+				t.Errorf("expected logRegexByContainerName main ^error, got %v", deploy.LogRegexByContainerName)
+			}
+			expectedDependsOn := []ResourceRef{{Kind: "job", Name: "migrate"}, {Kind: "sts", Namespace: "prod", Name: "db"}}
+			if !reflect.DeepEqual(deploy.DependsOn, expectedDependsOn) {
+				t.Errorf("expected dependsOn %+v, got %+v", expectedDependsOn, deploy.DependsOn)
+			}
+
+			// Default values are set like by Multitrack
+			job := specs.Jobs[0]
+			if !job.TreatDeletionAsSuccess || job.FailMode != FailWholeDeployProcessImmediately || job.TrackTerminationMode != WaitUntilResourceReady {
+				t.Errorf("unexpected Job spec %+v", job)
+			}
+			if job.AllowFailuresCount == nil || *job.AllowFailuresCount != 1 {
+				t.Errorf("expected default allowFailuresCount 1, got %v", job.AllowFailuresCount)
+			}
+
+			cert := specs.Generic[0]
+			expectedGVR := schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+			if cert.GroupVersionResource != expectedGVR {
+				t.Errorf("expected %s, got %s", expectedGVR, cert.GroupVersionResource)
+			}
+			if cert.ReadyCondition == nil || *cert.ReadyCondition != (generic.ConditionMatch{Type: "Ready", Status: "True"}) {
+				t.Errorf("expected readyCondition Ready=True, got %v", cert.ReadyCondition)
+			}
+		})
+	}
+}
+
+// TestMarshalSpecs reads back the marshalled specs as they were parsed.
+func TestMarshalSpecs(t *testing.T) {
+	specs, err := ParseSpecs(strings.NewReader(testSerializedSpecs))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	data, err := MarshalSpecs(specs)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	parsedSpecs, err := ParseSpecs(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatalf("unexpected error parsing marshalled specs: %s\n%s", err, data)
+	}
+
+	parsedData, err := MarshalSpecs(parsedSpecs)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(parsedData) != string(data) {
+		t.Errorf("expected specs:\n%s\ngot:\n%s", data, parsedData)
+	}
+
+	if len(parsedSpecs.Deployments) != 1 || len(parsedSpecs.Deployments[0].DependsOn) != 2 || parsedSpecs.Generic[0].ReadyCondition == nil {
+		t.Errorf("unexpected specs read back %+v", parsedSpecs)
+	}
+}
+
+func TestParseSpecsErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		data          string
+		expectedError string
+	}{
+		{name: "unknown field", data: "deployments:\n- name: api\n  failModes: x", expectedError: `unknown field "failModes"`},
+		{name: "unknown kind", data: "cronJobs:\n- name: api", expectedError: `unknown field "cronJobs"`},
+		{name: "no name", data: "pods:\n- namespace: prod", expectedError: "pods[0].name: required"},
+		{name: "unknown failMode", data: "deployments:\n- name: a\n- name: b\n- name: c\n  failMode: Panic", expectedError: "deployments[2].failMode: unknown value 'Panic'"},
+		{name: "unknown trackTerminationMode", data: "jobs:\n- name: a\n  trackTerminationMode: Never", expectedError: "jobs[0].trackTerminationMode: unknown value 'Never'"},
+		{name: "unknown successCondition", data: "deployments:\n- name: a\n  successCondition: Done", expectedError: "deployments[0].successCondition: unknown value 'Done'"},
+		{name: "negative allowFailuresCount", data: "pods:\n- name: a\n  allowFailuresCount: -1", expectedError: "pods[0].allowFailuresCount: negative value -1"},
+		{name: "bad logRegex", data: "pods:\n- name: a\n  logRegex: '('", expectedError: "pods[0].logRegex: error parsing regexp"},
+		{name: "bad logRegexByContainerName", data: "pods:\n- name: a\n  logRegexByContainerName: {main: '('}", expectedError: "pods[0].logRegexByContainerName.main: error parsing regexp"},
+		{name: "bad dependsOn", data: "pods:\n- name: a\n  dependsOn: [job]", expectedError: "pods[0].dependsOn[0]: bad value 'job'"},
+		{name: "generic fields of Deployment", data: "deployments:\n- name: a\n  readyCondition: Ready=True", expectedError: "deployments[0].readyCondition: supported only for generic resources"},
+		{name: "generic without version", data: "generic:\n- name: a\n  resource: certificates", expectedError: "generic[0].version: required"},
+		{name: "generic without resource", data: "generic:\n- name: a\n  version: v1", expectedError: "generic[0].resource: required"},
+		{name: "bad readyCondition", data: "generic:\n- name: a\n  version: v1\n  resource: certificates\n  readyCondition: Ready", expectedError: "generic[0].readyCondition: bad value 'Ready'"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseSpecs(strings.NewReader(tc.data))
+			if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+				t.Errorf("expected error %q, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
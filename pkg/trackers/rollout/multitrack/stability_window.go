@@ -0,0 +1,187 @@
+package multitrack
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const defaultUnstableReadyFlapsCount = 2
+
+// errStabilityWindowPassed stops the tracker of the resource which stability window is over, it is not an error.
+var errStabilityWindowPassed = errors.New("stability window passed")
+
+type StabilityState string
+
+const (
+	// StabilityWatching means the resource is ready and its stability window is not over yet.
+	StabilityWatching StabilityState = "Watching"
+	StabilityStable   StabilityState = "Stable"
+	// StabilityUnstable means the resource became not ready and recovered UnstableReadyFlapsCount times or more
+	// within its stability window.
+	StabilityUnstable StabilityState = "Unstable"
+)
+
+// ReadyFlap is a single ready→unready→ready cycle of the resource within its stability window.
+type ReadyFlap struct {
+	UnreadyAt time.Time `json:"unreadyAt"`
+	ReadyAt   time.Time `json:"readyAt"`
+}
+
+// ResourceStability is the readiness of the resource within its stability window, see StabilityWindowSeconds.
+type ResourceStability struct {
+	State           StabilityState `json:"state"`
+	WindowStartedAt time.Time      `json:"windowStartedAt"`
+	WindowEndsAt    time.Time      `json:"windowEndsAt"`
+	FlapsCount      int            `json:"flapsCount"`
+	Flaps           []ReadyFlap    `json:"flaps,omitempty"`
+}
+
+func (s *ResourceStability) copy() *ResourceStability {
+	if s == nil {
+		return nil
+	}
+	res := *s
+	res.Flaps = append([]ReadyFlap(nil), s.Flaps...)
+	return &res
+}
+
+// stabilityWindow returns StabilityWindowSeconds of the spec, 0 means the resource succeeds as soon as it is ready.
+// Resources with post-readiness checks are not watched, as their trackers are stopped before the checks.
+func stabilityWindow(kind string, spec MultitrackSpec) time.Duration {
+	switch kind {
+	case "deploy", "rs", "sts", "ds", "po":
+	default:
+		return 0
+	}
+
+	if spec.StabilityWindowSeconds <= 0 || spec.SuccessCondition == SuccessConditionPodsSucceeded || hasPostReadinessChecks(spec) {
+		return 0
+	}
+
+	return time.Duration(spec.StabilityWindowSeconds) * time.Second
+}
+
+// unstableReadyFlapsCount returns UnstableReadyFlapsCount of the spec, 0 means the resource is never unstable.
+func unstableReadyFlapsCount(spec MultitrackSpec) int {
+	switch {
+	case spec.UnstableReadyFlapsCount < 0:
+		return 0
+	case spec.UnstableReadyFlapsCount == 0:
+		return defaultUnstableReadyFlapsCount
+	default:
+		return spec.UnstableReadyFlapsCount
+	}
+}
+
+// startStabilityWindow keeps tracking the ready resource until its stability window is over instead of stopping the tracker.
+// Ready notifications of the resource already being watched are ignored, readiness is observed by observeStabilityWindowStatus.
+func (mt *multitracker) startStabilityWindow(resourcesStates map[string]*multitrackerResourceState, kind string, spec MultitrackSpec, window time.Duration) error {
+	state := resourcesStates[resourceKey(spec)]
+	if state.Stability != nil {
+		return nil
+	}
+
+	mtCtx := mt.resourceContext(kind, spec)
+	if mtCtx == nil {
+		mt.markResourceReady(resourcesStates, kind, spec)
+		return nil
+	}
+
+	now := time.Now()
+	state.Stability = &ResourceStability{State: StabilityWatching, WindowStartedAt: now, WindowEndsAt: now.Add(window)}
+
+	mt.displayResourceTrackerMessageF(kind, spec, "watching stability for %s", window)
+
+	time.AfterFunc(window, func() {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		defer mt.emitResourceStatusEvent(kind, spec)
+
+		mt.finishStabilityWindow(resourcesStates, kind, spec, mtCtx)
+	})
+
+	return nil
+}
+
+// observeStabilityWindowStatus counts ready→unready→ready cycles of the resource within its stability window.
+// The window which is over while the resource is not ready is finished once the resource is ready again.
+func (mt *multitracker) observeStabilityWindowStatus(resourcesStates map[string]*multitrackerResourceState, kind string, spec MultitrackSpec, isReady bool) {
+	state := resourcesStates[resourceKey(spec)]
+	if state.Stability == nil || state.Stability.State != StabilityWatching {
+		return
+	}
+
+	now := time.Now()
+
+	if !isReady {
+		if state.StabilityUnreadySince.IsZero() {
+			state.StabilityUnreadySince = now
+			mt.displayResourceTrackerMessageF(kind, spec, "become NOT READY within stability window")
+		}
+		return
+	}
+
+	if state.StabilityUnreadySince.IsZero() {
+		return
+	}
+
+	state.Stability.Flaps = append(state.Stability.Flaps, ReadyFlap{UnreadyAt: state.StabilityUnreadySince, ReadyAt: now})
+	state.Stability.FlapsCount = len(state.Stability.Flaps)
+	state.StabilityUnreadySince = time.Time{}
+
+	mt.displayResourceTrackerMessageF(kind, spec, "become READY again (%d flaps within stability window)", state.Stability.FlapsCount)
+
+	if !now.Before(state.Stability.WindowEndsAt) {
+		mt.finishStabilityWindow(resourcesStates, kind, spec, mt.resourceContext(kind, spec))
+	}
+}
+
+// finishStabilityWindow marks the resource as ready or unstable and stops its tracker. Unstable resource fails
+// with TreatUnstableAsFailed, only IgnoreAndContinueDeployProcess fail mode ignores such a failure.
+func (mt *multitracker) finishStabilityWindow(resourcesStates map[string]*multitrackerResourceState, kind string, spec MultitrackSpec, mtCtx *multitrackerContext) {
+	state := resourcesStates[resourceKey(spec)]
+	if mtCtx == nil || state.Stability.State != StabilityWatching || !state.StabilityUnreadySince.IsZero() {
+		return
+	}
+	if state.Status == ResourceSucceeded || state.Status == ResourceFailed || !state.LogCaptureUntil.IsZero() {
+		return
+	}
+
+	flapsCount := state.Stability.FlapsCount
+	if threshold := unstableReadyFlapsCount(spec); threshold == 0 || flapsCount < threshold {
+		state.Stability.State = StabilityStable
+		mt.displayResourceTrackerMessageF(kind, spec, "is stable (%d flaps within stability window)", flapsCount)
+		mt.markResourceReady(resourcesStates, kind, spec)
+
+		mtCtx.Err = errStabilityWindowPassed
+		mtCtx.CancelFunc()
+		return
+	}
+
+	state.Stability.State = StabilityUnstable
+	reason := fmt.Sprintf("unstable: %d ready flaps within stability window %s", flapsCount, state.Stability.WindowEndsAt.Sub(state.Stability.WindowStartedAt))
+
+	if mt.treatUnstableAsFailed {
+		mt.displayResourceErrorF(kind, spec, "%s", reason)
+
+		if res := state.HandleNonRetryableFailure(reason); res.Decision == FailureFatal {
+			mt.captureFailedContainerLogs(kind, spec)
+			mt.runResourceFailedHooks(kind, spec, reason)
+			mt.displayMultitrackServiceMessageF("Unstable %s is treated as failed: stop tracking immediately!\n", mt.resourceID(kind, spec))
+
+			if err := mt.failResourceImmediately(kind, spec); err != nil {
+				mtCtx.Err = err
+				mtCtx.CancelFunc()
+			}
+			return
+		}
+	} else {
+		mt.displayResourceTrackerMessageF(kind, spec, "is UNSTABLE: %s", reason)
+	}
+
+	mt.markResourceReady(resourcesStates, kind, spec)
+
+	mtCtx.Err = errStabilityWindowPassed
+	mtCtx.CancelFunc()
+}
@@ -0,0 +1,131 @@
+package multitrack
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStabilityWindow(t *testing.T) {
+	tests := []struct {
+		kind     string
+		spec     MultitrackSpec
+		expected time.Duration
+	}{
+		{kind: "deploy", spec: MultitrackSpec{StabilityWindowSeconds: 60}, expected: time.Minute},
+		{kind: "po", spec: MultitrackSpec{StabilityWindowSeconds: 5}, expected: 5 * time.Second},
+		{kind: "deploy", expected: 0},
+		{kind: "job", spec: MultitrackSpec{StabilityWindowSeconds: 60}, expected: 0},
+		{kind: "generic", spec: MultitrackSpec{StabilityWindowSeconds: 60}, expected: 0},
+		{kind: "deploy", spec: MultitrackSpec{StabilityWindowSeconds: 60, SuccessCondition: SuccessConditionPodsSucceeded}, expected: 0},
+		{kind: "deploy", spec: MultitrackSpec{StabilityWindowSeconds: 60, ServesWebhook: true}, expected: 0},
+	}
+
+	for _, tt := range tests {
+		if res := stabilityWindow(tt.kind, tt.spec); res != tt.expected {
+			t.Errorf("%s %+v: expected %s, got %s", tt.kind, tt.spec, tt.expected, res)
+		}
+	}
+
+	for flaps, expected := range map[int]int{-1: 0, 0: defaultUnstableReadyFlapsCount, 3: 3} {
+		if res := unstableReadyFlapsCount(MultitrackSpec{UnstableReadyFlapsCount: flaps}); res != expected {
+			t.Errorf("UnstableReadyFlapsCount %d: expected %d, got %d", flaps, expected, res)
+		}
+	}
+}
+
+// TestStabilityWindowFlaps watches the ready Deployment during the window, which is long enough not to be over in the test,
+// observes the readiness changes and finishes the window as its timer does.
+func TestStabilityWindowFlaps(t *testing.T) {
+	tests := []struct {
+		name                  string
+		flapsCount            int
+		unstableFlapsCount    int
+		treatUnstableAsFailed bool
+		expectedState         StabilityState
+		expectedStatus        ResourceStatus
+		expectedErr           error
+	}{
+		{name: "no flaps", expectedState: StabilityStable, expectedStatus: ResourceSucceeded, expectedErr: errStabilityWindowPassed},
+		{name: "single flap", flapsCount: 1, expectedState: StabilityStable, expectedStatus: ResourceSucceeded, expectedErr: errStabilityWindowPassed},
+		{name: "two flaps", flapsCount: 2, expectedState: StabilityUnstable, expectedStatus: ResourceSucceeded, expectedErr: errStabilityWindowPassed},
+		{name: "two flaps, unstable disabled", flapsCount: 2, unstableFlapsCount: -1, expectedState: StabilityStable, expectedStatus: ResourceSucceeded, expectedErr: errStabilityWindowPassed},
+		{name: "single flap, threshold 1", flapsCount: 1, unstableFlapsCount: 1, expectedState: StabilityUnstable, expectedStatus: ResourceSucceeded, expectedErr: errStabilityWindowPassed},
+		{name: "two flaps, treated as failed", flapsCount: 2, treatUnstableAsFailed: true, expectedState: StabilityUnstable, expectedStatus: ResourceFailed, expectedErr: ErrFailWholeDeployProcessImmediately},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mt := newTestMultitracker()
+			mt.treatUnstableAsFailed = tt.treatUnstableAsFailed
+
+			spec := MultitrackSpec{ResourceName: "api", Namespace: "prod", StabilityWindowSeconds: 3600, UnstableReadyFlapsCount: tt.unstableFlapsCount}
+			mtCtx, err := mt.addResource("deploy", mt.DeploymentsSpecs, mt.TrackingDeployments, mt.DeploymentsContexts, spec, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if err := mt.startStabilityWindow(mt.TrackingDeployments, "deploy", spec, stabilityWindow("deploy", spec)); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			state := mt.TrackingDeployments[resourceKey(spec)]
+			if state.Stability == nil || state.Stability.State != StabilityWatching || state.Stability.WindowEndsAt.Sub(state.Stability.WindowStartedAt) != time.Hour {
+				t.Fatalf("expected watching stability for 1h, got %+v", state.Stability)
+			}
+
+			for i := 0; i < tt.flapsCount; i++ {
+				mt.observeStabilityWindowStatus(mt.TrackingDeployments, "deploy", spec, false)
+				mt.observeStabilityWindowStatus(mt.TrackingDeployments, "deploy", spec, false)
+				mt.observeStabilityWindowStatus(mt.TrackingDeployments, "deploy", spec, true)
+				mt.observeStabilityWindowStatus(mt.TrackingDeployments, "deploy", spec, true)
+			}
+			if state.Stability.FlapsCount != tt.flapsCount || len(state.Stability.Flaps) != tt.flapsCount {
+				t.Errorf("expected %d flaps, got %+v", tt.flapsCount, state.Stability)
+			}
+
+			mt.finishStabilityWindow(mt.TrackingDeployments, "deploy", spec, mtCtx)
+
+			if state.Stability.State != tt.expectedState || state.Status != tt.expectedStatus {
+				t.Errorf("expected %s %s, got %s %s", tt.expectedState, tt.expectedStatus, state.Stability.State, state.Status)
+			}
+			if mtCtx.Err != tt.expectedErr {
+				t.Errorf("expected tracker stopped with %v, got %v", tt.expectedErr, mtCtx.Err)
+			}
+		})
+	}
+}
+
+// TestStabilityWindowOverWhileNotReady keeps the window open while the resource is not ready at its end:
+// the resource becomes ready again with one more flap and the window is finished right away.
+func TestStabilityWindowOverWhileNotReady(t *testing.T) {
+	mt := newTestMultitracker()
+
+	spec := MultitrackSpec{ResourceName: "worker", Namespace: "prod", StabilityWindowSeconds: 3600, UnstableReadyFlapsCount: 1}
+	mtCtx, err := mt.addResource("po", mt.PodsSpecs, mt.TrackingPods, mt.PodsContexts, spec, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := mt.startStabilityWindow(mt.TrackingPods, "po", spec, stabilityWindow("po", spec)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	state := mt.TrackingPods[resourceKey(spec)]
+
+	mt.observeStabilityWindowStatus(mt.TrackingPods, "po", spec, false)
+	state.Stability.WindowEndsAt = time.Now()
+	mt.finishStabilityWindow(mt.TrackingPods, "po", spec, mtCtx)
+
+	if state.Stability.State != StabilityWatching || state.Status == ResourceSucceeded || mtCtx.Err != nil {
+		t.Fatalf("expected window kept open while not ready, got %+v %s %v", state.Stability, state.Status, mtCtx.Err)
+	}
+
+	mt.observeStabilityWindowStatus(mt.TrackingPods, "po", spec, true)
+
+	if state.Stability.State != StabilityUnstable || state.Stability.FlapsCount != 1 || state.Status != ResourceSucceeded || mtCtx.Err != errStabilityWindowPassed {
+		t.Errorf("expected unstable ready resource after the flap, got %+v %s %v", state.Stability, state.Status, mtCtx.Err)
+	}
+
+	copied := state.Stability.copy()
+	copied.Flaps[0].ReadyAt = time.Time{}
+	if state.Stability.Flaps[0].ReadyAt.IsZero() {
+		t.Errorf("expected copy of the stability not sharing the flaps")
+	}
+}
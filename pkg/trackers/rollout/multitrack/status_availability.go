@@ -0,0 +1,90 @@
+package multitrack
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	"github.com/werf/kubedog/pkg/tracker"
+	"github.com/werf/kubedog/pkg/utils"
+)
+
+// ResourceStatusAvailability explains why the status of the resource is not shown yet.
+type ResourceStatusAvailability string
+
+const (
+	// ResourceStatusUnavailable means that no data has been received from the informer yet.
+	ResourceStatusUnavailable ResourceStatusAvailability = "Unavailable"
+	// ResourceStatusWaitingForCreation means that the resource does not exist yet and kubedog waits for its creation.
+	ResourceStatusWaitingForCreation ResourceStatusAvailability = "WaitingForCreation"
+	// ResourceStatusConnecting means that the informer failed to list the resource and retries.
+	ResourceStatusConnecting ResourceStatusAvailability = "Connecting"
+	// ResourceStatusAvailable means that the resource exists and its status is received.
+	ResourceStatusAvailable ResourceStatusAvailability = "Available"
+	// ResourceStatusWaitingForDependencies means that tracking of the resource is not started until its dependencies are ready, see DependsOn.
+	ResourceStatusWaitingForDependencies ResourceStatusAvailability = "WaitingForDependencies"
+	// ResourceStatusSkipped means that the resource is not tracked because its dependency failed.
+	ResourceStatusSkipped ResourceStatusAvailability = "Skipped"
+)
+
+func (mt *multitracker) newResourceListObserver(resourcesStates map[string]*multitrackerResourceState, kind string, spec MultitrackSpec) tracker.ListObserver {
+	return func(resourceFound bool, err error) {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+
+		state := resourcesStates[resourceKey(spec)]
+
+		if isCredentialsExpiredError(state, err) {
+			mt.fail(mt.formatCredentialsExpiredError())
+			return
+		}
+		if err == nil {
+			state.HasListSucceeded = true
+		}
+		mt.traceResourceList(kind, spec, resourceFound, err)
+
+		switch {
+		case err != nil:
+			state.setStatusAvailability(ResourceStatusConnecting, err.Error())
+		case resourceFound:
+			state.setStatusAvailability(ResourceStatusAvailable, "")
+		default:
+			if spec.WaitForResourceCreation && state.StatusAvailability == ResourceStatusUnavailable {
+				mt.displayResourceTrackerMessageF(kind, spec, "waiting for resource to be created")
+			}
+			state.setStatusAvailability(ResourceStatusWaitingForCreation, "")
+		}
+	}
+}
+
+// setStatusAvailability keeps the time of the availability change, so that the time spent in the same state is shown.
+func (state *multitrackerResourceState) setStatusAvailability(availability ResourceStatusAvailability, errMsg string) {
+	if state.StatusAvailability != availability {
+		state.StatusAvailabilitySince = time.Now()
+	}
+	state.StatusAvailability = availability
+	state.StatusAvailabilityError = errMsg
+}
+
+// formatResourceStatusAvailability describes the resource without received status,
+// e.g. "waiting for resource to be created (37s, timeout 5m)".
+func (mt *multitracker) formatResourceStatusAvailability(state *multitrackerResourceState) string {
+	elapsed := duration.HumanDuration(time.Since(state.StatusAvailabilitySince))
+
+	switch state.StatusAvailability {
+	case ResourceStatusWaitingForCreation:
+		if mt.timeout > 0 {
+			return utils.BlueString("waiting for resource to be created (%s, timeout %s)", elapsed, duration.HumanDuration(mt.timeout))
+		}
+		return utils.BlueString("waiting for resource to be created (%s)", elapsed)
+	case ResourceStatusWaitingForDependencies:
+		return utils.BlueString("waiting for dependencies: %s (%s)", state.StatusAvailabilityError, elapsed)
+	case ResourceStatusSkipped:
+		return utils.YellowString("skipped: %s", state.StatusAvailabilityError)
+	case ResourceStatusConnecting:
+		return utils.YellowString("%s", fmt.Sprintf("connecting (retrying after error: %s)", state.StatusAvailabilityError))
+	default:
+		return "status unavailable (no data received yet)"
+	}
+}
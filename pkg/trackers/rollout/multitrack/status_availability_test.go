@@ -0,0 +1,146 @@
+package multitrack
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testListResult struct {
+	found bool
+	err   error
+}
+
+// TestResourceListObserver feeds the sequences of the informer list results to the observer of the resource:
+// the availability follows the last result and its time is kept while the availability does not change.
+func TestResourceListObserver(t *testing.T) {
+	refused := errors.New("connection refused")
+
+	tests := []struct {
+		name                    string
+		waitForResourceCreation bool
+		lists                   []testListResult
+
+		expectedAvailability ResourceStatusAvailability
+		expectedError        string
+		expectedSinceChanges int
+		expectedMessages     []string
+	}{
+		{
+			name:                 "found",
+			lists:                []testListResult{{found: true}},
+			expectedAvailability: ResourceStatusAvailable,
+			expectedSinceChanges: 1,
+		},
+		{
+			name:                 "not found without waiting for creation",
+			lists:                []testListResult{{}, {}},
+			expectedAvailability: ResourceStatusWaitingForCreation,
+			expectedSinceChanges: 1,
+		},
+		{
+			name:                    "waiting for creation is reported once",
+			waitForResourceCreation: true,
+			lists:                   []testListResult{{}, {}, {}},
+			expectedAvailability:    ResourceStatusWaitingForCreation,
+			expectedSinceChanges:    1,
+			expectedMessages:        []string{"waiting for resource to be created"},
+		},
+		{
+			name:                 "created after waiting",
+			lists:                []testListResult{{}, {found: true}},
+			expectedAvailability: ResourceStatusAvailable,
+			expectedSinceChanges: 2,
+		},
+		{
+			name:                 "list retried",
+			lists:                []testListResult{{err: refused}, {err: errors.New("i/o timeout")}},
+			expectedAvailability: ResourceStatusConnecting,
+			expectedError:        "i/o timeout",
+			expectedSinceChanges: 1,
+		},
+		{
+			name:                 "connected after retries",
+			lists:                []testListResult{{err: refused}, {err: refused}, {found: true}},
+			expectedAvailability: ResourceStatusAvailable,
+			expectedSinceChanges: 2,
+		},
+		{
+			name:                 "connection lost after found",
+			lists:                []testListResult{{found: true}, {err: refused}},
+			expectedAvailability: ResourceStatusConnecting,
+			expectedError:        "connection refused",
+			expectedSinceChanges: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mt := newTestMultitracker()
+			spec := MultitrackSpec{ResourceName: "web", Namespace: "default", WaitForResourceCreation: tt.waitForResourceCreation}
+			if _, err := mt.addResource("deploy", mt.DeploymentsSpecs, mt.TrackingDeployments, mt.DeploymentsContexts, spec, nil); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			state := mt.TrackingDeployments[resourceKey(spec)]
+			observe := mt.newResourceListObserver(mt.TrackingDeployments, "deploy", spec)
+
+			sinceChanges := 0
+			since := state.StatusAvailabilitySince
+			for _, list := range tt.lists {
+				// The time of the availability change is distinguishable from the previous one
+				time.Sleep(time.Millisecond)
+
+				observe(list.found, list.err)
+				if !state.StatusAvailabilitySince.Equal(since) {
+					sinceChanges++
+					since = state.StatusAvailabilitySince
+				}
+			}
+
+			if state.StatusAvailability != tt.expectedAvailability {
+				t.Errorf("expected %s availability, got %s", tt.expectedAvailability, state.StatusAvailability)
+			}
+			if state.StatusAvailabilityError != tt.expectedError {
+				t.Errorf("expected availability error %q, got %q", tt.expectedError, state.StatusAvailabilityError)
+			}
+			if sinceChanges != tt.expectedSinceChanges {
+				t.Errorf("expected availability time changed %d times, got %d", tt.expectedSinceChanges, sinceChanges)
+			}
+			if messages := mt.serviceMessagesByResource["deploy/default/web"]; strings.Join(messages, "\n") != strings.Join(tt.expectedMessages, "\n") {
+				t.Errorf("expected service messages %q, got %q", tt.expectedMessages, messages)
+			}
+		})
+	}
+}
+
+func TestFormatResourceStatusAvailability(t *testing.T) {
+	tests := []struct {
+		availability ResourceStatusAvailability
+		err          string
+		timeout      time.Duration
+		expected     string
+	}{
+		{availability: ResourceStatusUnavailable, expected: "status unavailable (no data received yet)"},
+		{availability: ResourceStatusWaitingForCreation, expected: "waiting for resource to be created (37s)"},
+		{availability: ResourceStatusWaitingForCreation, timeout: 5 * time.Minute, expected: "waiting for resource to be created (37s, timeout 5m)"},
+		{availability: ResourceStatusWaitingForDependencies, err: "deploy/db", expected: "waiting for dependencies: deploy/db (37s)"},
+		{availability: ResourceStatusSkipped, err: "dependency deploy/db failed", expected: "skipped: dependency deploy/db failed"},
+		{availability: ResourceStatusConnecting, err: "connection refused", expected: "connecting (retrying after error: connection refused)"},
+	}
+
+	for _, tt := range tests {
+		mt := newTestMultitracker()
+		mt.timeout = tt.timeout
+		state := &multitrackerResourceState{
+			StatusAvailability:      tt.availability,
+			StatusAvailabilityError: tt.err,
+			StatusAvailabilitySince: time.Now().Add(-37 * time.Second),
+		}
+
+		// The message may be colored
+		if res := mt.formatResourceStatusAvailability(state); !strings.Contains(res, tt.expected) {
+			t.Errorf("%s: expected %q, got %q", tt.availability, tt.expected, res)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package multitrack
+
+import (
+	"fmt"
+	"time"
+)
+
+// StatusProgressMode controls when status progress is reported, see StatusProgressMode option of MultitrackOptions.
+type StatusProgressMode string
+
+const (
+	// StatusProgressPeriodic reports status progress every StatusProgressPeriod, it is the default mode.
+	StatusProgressPeriodic StatusProgressMode = "Periodic"
+	// StatusProgressOnPhaseChange reports status progress only when the phase of some resource changes
+	// (e.g. it becomes ready or fails), changes during a report are coalesced into the next one.
+	StatusProgressOnPhaseChange StatusProgressMode = "OnPhaseChange"
+)
+
+// newStatusProgressTicker returns the channel of the periodic status progress reports and the function stopping them,
+// it is replaced in tests.
+var newStatusProgressTicker = func(period time.Duration) (<-chan time.Time, func()) {
+	ticker := time.NewTicker(period)
+	return ticker.C, ticker.Stop
+}
+
+func validateStatusProgress(opts MultitrackOptions) error {
+	switch opts.StatusProgressMode {
+	case "", StatusProgressPeriodic:
+		return nil
+	case StatusProgressOnPhaseChange:
+		if opts.StatusProgressPeriod > 0 {
+			return fmt.Errorf("StatusProgressPeriod %s cannot be set with StatusProgressMode %s", opts.StatusProgressPeriod, opts.StatusProgressMode)
+		}
+		return nil
+	default:
+		return fmt.Errorf("bad StatusProgressMode %q, expected %s or %s", opts.StatusProgressMode, StatusProgressPeriodic, StatusProgressOnPhaseChange)
+	}
+}
+
+// notifyResourcePhaseChange signals phaseChangedChan when the phase of the resource changed since the previous call.
+// It is used to report status progress with StatusProgressOnPhaseChange mode.
+// Signals are coalesced, so a burst of changes produces a single status report.
+func (mt *multitracker) notifyResourcePhaseChange(kind string, spec MultitrackSpec) {
+	if mt.phaseChangedChan == nil {
+		return
+	}
+
+	key := fmt.Sprintf("%s/%s", kind, resourceKey(spec))
+	phase := mt.newResourceStatusEvent(kind, spec).Phase
+
+	prevPhase, hasKey := mt.lastResourcesPhases[key]
+	if !hasKey {
+		prevPhase = ResourcePhasePending
+	}
+	mt.lastResourcesPhases[key] = phase
+	if prevPhase == phase {
+		return
+	}
+
+	select {
+	case mt.phaseChangedChan <- struct{}{}:
+	default:
+	}
+}
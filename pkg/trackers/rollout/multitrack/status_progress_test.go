@@ -0,0 +1,197 @@
+package multitrack
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// setTestStatusProgressTicker replaces the ticker of the periodic status progress reports, returns the function restoring it.
+func setTestStatusProgressTicker(newTicker func(period time.Duration) (<-chan time.Time, func())) func() {
+	prevNewTicker := newStatusProgressTicker
+	newStatusProgressTicker = newTicker
+	return func() {
+		newStatusProgressTicker = prevNewTicker
+	}
+}
+
+// statusReportsHooks returns the hooks signaling each status progress report into the returned channel.
+func statusReportsHooks() (*MultitrackHooks, chan struct{}) {
+	reports := make(chan struct{}, 100)
+	return &MultitrackHooks{
+		OnStatusReport: func(snapshot MultitrackSnapshot) error {
+			reports <- struct{}{}
+			return nil
+		},
+	}, reports
+}
+
+func TestMultitrackStatusProgressPeriodic(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		period         time.Duration
+		fastMode       bool
+		expectedPeriod time.Duration
+	}{
+		{name: "default", expectedPeriod: 5 * time.Second},
+		{name: "default with FastMode", fastMode: true, expectedPeriod: time.Second},
+		{name: "explicit", period: 3 * time.Second, expectedPeriod: 3 * time.Second},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			const ticksCount = 3
+
+			ticks := make(chan time.Time)
+			periods := make(chan time.Duration, 1)
+			defer setTestStatusProgressTicker(func(period time.Duration) (<-chan time.Time, func()) {
+				periods <- period
+				return ticks, func() {}
+			})()
+
+			kube := fake.NewSimpleClientset()
+			pods, specs := createTestPods(t, kube, 1)
+			hooks, reports := statusReportsHooks()
+
+			// The Pod is pending until each tick is reported
+			stopTicks := make(chan struct{})
+			stopUpdates := make(chan func(), 1)
+			go func() {
+				for i := 0; i < ticksCount; i++ {
+					select {
+					case ticks <- time.Now():
+					case <-stopTicks:
+						return
+					}
+					<-reports
+				}
+				stopUpdates <- runTestPodsUpdates(t, kube, pods, func(i, step int, pod *corev1.Pod) *corev1.Pod {
+					return pod
+				}, func(i int, pod *corev1.Pod) *corev1.Pod {
+					return setTestPodReady(pod)
+				})
+			}()
+
+			_, out, err := runTestMultitrack(t, kube, MultitrackSpecs{Pods: specs}, MultitrackOptions{
+				StatusProgressPeriod: tc.period,
+				FastMode:             tc.fastMode,
+				Hooks:                hooks,
+			})
+			close(stopTicks)
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s\n%s", err, out)
+			}
+			(<-stopUpdates)()
+
+			if period := <-periods; period != tc.expectedPeriod {
+				t.Errorf("expected period %s, got %s", tc.expectedPeriod, period)
+			}
+			if len(reports) != 0 {
+				t.Errorf("expected %d status reports by ticks, got %d more", ticksCount, len(reports))
+			}
+		})
+	}
+}
+
+// TestMultitrackStatusProgressOnPhaseChange reports status progress when the first Pod becomes ready
+// while the second one is pending, no periodic reports are made.
+func TestMultitrackStatusProgressOnPhaseChange(t *testing.T) {
+	defer setTestStatusProgressTicker(func(period time.Duration) (<-chan time.Time, func()) {
+		t.Errorf("unexpected periodic status progress every %s", period)
+		return nil, func() {}
+	})()
+
+	kube := fake.NewSimpleClientset()
+	pods, specs := createTestPods(t, kube, 2)
+	hooks, reports := statusReportsHooks()
+
+	stopFirstUpdates := runTestPodsUpdates(t, kube, pods[:1], func(i, step int, pod *corev1.Pod) *corev1.Pod {
+		return pod
+	}, func(i int, pod *corev1.Pod) *corev1.Pod {
+		return setTestPodReady(pod)
+	})
+
+	stopSecondUpdates := make(chan func(), 1)
+	go func() {
+		<-reports
+		stopSecondUpdates <- runTestPodsUpdates(t, kube, pods[1:], func(i, step int, pod *corev1.Pod) *corev1.Pod {
+			return pod
+		}, func(i int, pod *corev1.Pod) *corev1.Pod {
+			return setTestPodReady(pod)
+		})
+	}()
+
+	_, out, err := runTestMultitrack(t, kube, MultitrackSpecs{Pods: specs}, MultitrackOptions{
+		StatusProgressMode: StatusProgressOnPhaseChange,
+		Hooks:              hooks,
+	})
+	stopFirstUpdates()
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s\n%s", err, out)
+	}
+	(<-stopSecondUpdates)()
+}
+
+func TestMultitrackStatusProgressDisabled(t *testing.T) {
+	defer setTestStatusProgressTicker(func(period time.Duration) (<-chan time.Time, func()) {
+		t.Errorf("unexpected periodic status progress every %s", period)
+		return nil, func() {}
+	})()
+
+	kube := fake.NewSimpleClientset()
+	pods, specs := createTestPods(t, kube, 2)
+	hooks, reports := statusReportsHooks()
+
+	stopUpdates := runTestPodsUpdates(t, kube, pods, func(i, step int, pod *corev1.Pod) *corev1.Pod {
+		if i == 1 {
+			return setTestPodReady(pod)
+		}
+		return pod
+	}, func(i int, pod *corev1.Pod) *corev1.Pod {
+		return setTestPodReady(pod)
+	})
+
+	_, out, err := runTestMultitrack(t, kube, MultitrackSpecs{Pods: specs}, MultitrackOptions{
+		StatusProgressPeriod: -1,
+		Hooks:                hooks,
+	})
+	stopUpdates()
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s\n%s", err, out)
+	}
+	if len(reports) != 0 {
+		t.Errorf("expected no status reports, got %d", len(reports))
+	}
+}
+
+func TestMultitrackStatusProgressValidation(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		opts          MultitrackOptions
+		expectedError string
+	}{
+		{
+			name:          "bad mode",
+			opts:          MultitrackOptions{StatusProgressMode: "Never"},
+			expectedError: `bad StatusProgressMode "Never"`,
+		},
+		{
+			name:          "period with OnPhaseChange mode",
+			opts:          MultitrackOptions{StatusProgressMode: StatusProgressOnPhaseChange, StatusProgressPeriod: time.Second},
+			expectedError: "StatusProgressPeriod 1s cannot be set with StatusProgressMode OnPhaseChange",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			specs := MultitrackSpecs{Pods: []MultitrackSpec{{ResourceName: "app", Namespace: "default"}}}
+
+			_, _, err := runTestMultitrack(t, fake.NewSimpleClientset(), specs, tc.opts)
+			if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+				t.Errorf("expected error %q, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package multitrack
+
+import (
+	"fmt"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/werf/kubedog/pkg/tracker/pod"
+)
+
+// statusReportDigest is the part of the resource status which matters for OutputTextDelta status reports:
+// the resource is shown in the periodic report only when its digest changed since the previous report.
+type statusReportDigest struct {
+	Event ResourceStatusEvent
+	Pods  map[string]podStatusReportDigest
+}
+
+type podStatusReportDigest struct {
+	Phase           corev1.PodPhase
+	ReadyContainers int32
+	Restarts        int32
+	IsReady         bool
+	IsFailed        bool
+	IsDeleted       bool
+}
+
+func (mt *multitracker) newStatusReportDigest(kind string, spec MultitrackSpec) statusReportDigest {
+	digest := statusReportDigest{
+		Event: mt.newResourceStatusEvent(kind, spec),
+		Pods:  make(map[string]podStatusReportDigest),
+	}
+
+	for podName, podStatus := range mt.resourceChildPods(kind, resourceKey(spec)) {
+		digest.Pods[podName] = podStatusReportDigest{
+			Phase:           podStatus.Phase,
+			ReadyContainers: podStatus.ReadyContainers,
+			Restarts:        podStatus.Restarts,
+			IsReady:         podStatus.IsReady,
+			IsFailed:        podStatus.IsFailed,
+			IsDeleted:       podStatus.IsDeleted,
+		}
+	}
+
+	return digest
+}
+
+func (mt *multitracker) resourceChildPods(kind, key string) map[string]pod.PodStatus {
+	switch kind {
+	case "deploy":
+		return mt.DeploymentsStatuses[key].Pods
+	case "rs":
+		return mt.ReplicaSetsStatuses[key].Pods
+	case "sts":
+		return mt.StatefulSetsStatuses[key].Pods
+	case "ds":
+		return mt.DaemonSetsStatuses[key].Pods
+	case "job":
+		return mt.JobsStatuses[key].Pods
+	default:
+		return nil
+	}
+}
+
+// selectStatusReportResources sets the resources shown in the OutputTextDelta status report: the ones changed since
+// the previous report. The first report, the report after some resource failed and the not periodic (final) report are full.
+// Returned rollup counts all resources.
+func (mt *multitracker) selectStatusReportResources(isPeriodic bool) JSONReportRollup {
+	var rollup JSONReportRollup
+
+	isFull := !isPeriodic || mt.lastStatusReportDigests == nil
+	changed := make(map[string]bool)
+	digests := make(map[string]statusReportDigest)
+
+	for _, kind := range mt.trackedKinds() {
+		for key, spec := range kind.Specs {
+			digest := mt.newStatusReportDigest(kind.Kind, spec)
+			rollup.add(digest.Event.Phase)
+
+			id := fmt.Sprintf("%s/%s", kind.Kind, key)
+			if prevDigest, hasKey := mt.lastStatusReportDigests[id]; !hasKey || !reflect.DeepEqual(prevDigest, digest) {
+				changed[id] = true
+				if digest.Event.Phase == ResourcePhaseFailed {
+					isFull = true
+				}
+			}
+
+			digests[id] = digest
+		}
+	}
+
+	mt.lastStatusReportDigests = digests
+
+	if isFull {
+		mt.statusReportResources = nil
+	} else {
+		mt.statusReportResources = changed
+	}
+
+	return rollup
+}
+
+// statusReportKeys returns sorted keys of the specs of the kind shown in the status report.
+func (mt *multitracker) statusReportKeys(kind string, specs map[string]MultitrackSpec) []string {
+	keys := sortedSpecsKeys(specs)
+	if mt.statusReportResources == nil {
+		return keys
+	}
+
+	var res []string
+	for _, key := range keys {
+		if mt.statusReportResources[fmt.Sprintf("%s/%s", kind, key)] {
+			res = append(res, key)
+		}
+	}
+	return res
+}
+
+// formatStatusReportSummary describes all resources in one line, e.g. "12/30 ready, 1 failed, 17 progressing".
+func formatStatusReportSummary(rollup JSONReportRollup) string {
+	return fmt.Sprintf("%d/%d ready, %d failed, %d progressing", rollup.Ready, rollup.Total, rollup.Failed, rollup.Progressing+rollup.Pending)
+}
@@ -0,0 +1,155 @@
+package multitrack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/acarl005/stripansi"
+
+	"github.com/werf/kubedog/pkg/utils"
+)
+
+// StatusReportTable is the snapshot of the status report table of one kind of resources or of the sub table
+// under the resource, e.g. its Pods, which is rendered by RenderStatusReportTable.
+type StatusReportTable struct {
+	// Columns are the headers of the table, the sub table without columns is rendered without the header.
+	Columns []string
+	// ColumnsRatio are the widths of the columns relative to the width of the table.
+	ColumnsRatio []float64
+	// CountersCells are the indexes of the cells which are counters (e.g. replicas), they are right aligned into the columns.
+	CountersCells []int
+	Rows          []StatusReportRow
+	// Footer is shown next to the sub table, e.g. "Waiting for: ...".
+	Footer string
+}
+
+// StatusReportRow is the resource in the status report table.
+type StatusReportRow struct {
+	Name string
+	// Phase marks the row with the glyph and the color of the phase: ✓ ready, ✗ failed or → progressing.
+	// The row without phase is not marked, it is indented in the table with marked rows, e.g. Pod of the old revision.
+	Phase ResourcePhase
+	// FailMode of the resource changes colors: not ready resources with IgnoreAndContinueDeployProcess are not colored,
+	// failed resources with HopeUntilEndOfDeployProcess are yellow.
+	FailMode FailMode
+	// Cells are the values of the columns following the name, e.g. replicas "2/3".
+	Cells []string
+	// Messages are shown under the row, e.g. the error or warnings of the resource.
+	Messages  []string
+	SubTables []StatusReportTable
+}
+
+type StatusReportRenderOptions struct {
+	// Width of the table in characters.
+	Width int
+	// NoColor renders the table without colors, including the colors of the counters and messages.
+	NoColor bool
+}
+
+// RenderStatusReportTable renders the snapshot of the status report table. The result depends only on the snapshot,
+// the options and the style of the logboek streams when NoColor is not set.
+func RenderStatusReportTable(table StatusReportTable, opts StatusReportRenderOptions) string {
+	t := utils.NewTable(table.ColumnsRatio...)
+	t.SetWidth(opts.Width)
+	t.Header(stringsToInterfaces(table.Columns)...)
+
+	for _, row := range alignStatusReportCounters(table) {
+		t.Row(renderStatusReportRow(row, hasMarkedStatusReportRows(table.Rows))...)
+
+		for _, subTable := range row.SubTables {
+			renderStatusReportSubTable(&t, subTable)
+		}
+	}
+
+	res := t.Render()
+	if opts.NoColor {
+		res = stripansi.Strip(res)
+	}
+	return res
+}
+
+func renderStatusReportSubTable(t *utils.Table, table StatusReportTable) {
+	st := t.SubTable(table.ColumnsRatio...)
+	if len(table.Columns) > 0 {
+		st.Header(stringsToInterfaces(table.Columns)...)
+	}
+
+	isMarked := hasMarkedStatusReportRows(table.Rows)
+
+	var rows [][]interface{}
+	for _, row := range alignStatusReportCounters(table) {
+		rows = append(rows, renderStatusReportRow(row, isMarked))
+	}
+	st.Rows(rows...)
+
+	if table.Footer != "" {
+		st.Commit(table.Footer)
+	} else {
+		st.Commit()
+	}
+}
+
+func renderStatusReportRow(row StatusReportRow, isMarked bool) []interface{} {
+	name := row.Name
+	if row.Phase != "" {
+		name = formatResourceCaption(name, row.FailMode, row.Phase == ResourcePhaseReady, row.Phase == ResourcePhaseFailed)
+	} else if isMarked {
+		// Unmarked names are aligned with the names after the glyphs
+		name = fmt.Sprintf("  %s", name)
+	}
+
+	values := []interface{}{name}
+	values = append(values, stringsToInterfaces(row.Cells)...)
+	values = append(values, stringsToInterfaces(row.Messages)...)
+	return values
+}
+
+func hasMarkedStatusReportRows(rows []StatusReportRow) bool {
+	for _, row := range rows {
+		if row.Phase != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// alignStatusReportCounters right aligns the counters of the rows to the widest counter of the column,
+// e.g. " 2/3" above "10/10", so that they are compared at a glance.
+func alignStatusReportCounters(table StatusReportTable) []StatusReportRow {
+	if len(table.CountersCells) == 0 {
+		return table.Rows
+	}
+
+	widths := make(map[int]int)
+	for _, row := range table.Rows {
+		for _, i := range table.CountersCells {
+			if i < len(row.Cells) && visibleWidth(row.Cells[i]) > widths[i] {
+				widths[i] = visibleWidth(row.Cells[i])
+			}
+		}
+	}
+
+	res := make([]StatusReportRow, len(table.Rows))
+	for i, row := range table.Rows {
+		res[i] = row
+		res[i].Cells = append([]string{}, row.Cells...)
+		for _, j := range table.CountersCells {
+			if j < len(row.Cells) {
+				res[i].Cells[j] = strings.Repeat(" ", widths[j]-visibleWidth(row.Cells[j])) + row.Cells[j]
+			}
+		}
+	}
+	return res
+}
+
+func visibleWidth(s string) int {
+	return len([]rune(stripansi.Strip(s)))
+}
+
+func stringsToInterfaces(values []string) []interface{} {
+	var res []interface{}
+	for _, value := range values {
+		res = append(res, value)
+	}
+	return res
+}
@@ -0,0 +1,150 @@
+package multitrack
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/werf/logboek"
+
+	"github.com/werf/kubedog/pkg/utils"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files of the tests")
+
+func testStatusReportTable() StatusReportTable {
+	return StatusReportTable{
+		Columns:       []string{"DEPLOYMENT", "REPLICAS", "AVAILABLE", "UP-TO-DATE"},
+		ColumnsRatio:  statusProgressTableRatio,
+		CountersCells: []int{0, 1, 2},
+		Rows: []StatusReportRow{
+			{
+				Name:     "api",
+				Phase:    ResourcePhaseReady,
+				FailMode: FailWholeDeployProcessImmediately,
+				Cells:    []string{"10/10", "10", "10"},
+			},
+			{
+				Name:     "web",
+				Phase:    ResourcePhaseProgressing,
+				FailMode: FailWholeDeployProcessImmediately,
+				Cells:    []string{"2/3", "1", "2"},
+				SubTables: []StatusReportTable{{
+					Columns:       []string{"POD", "READY", "RESTARTS", "STATUS"},
+					ColumnsRatio:  statusProgressSubTableRatio,
+					CountersCells: []int{0, 1},
+					Rows: []StatusReportRow{
+						{Name: "5d4f-abcde", Phase: ResourcePhaseReady, FailMode: FailWholeDeployProcessImmediately, Cells: []string{"1/1", "0", "Running"}},
+						{Name: "5d4f-fghij", Phase: ResourcePhaseProgressing, FailMode: FailWholeDeployProcessImmediately, Cells: []string{"0/1", "12", "ContainerCreating"}},
+						{Name: "7c9b-klmno", Cells: []string{"1/1", "0", "Running"}},
+					},
+					Footer: "---\n" + utils.BlueString("Waiting for: replicas 2->3"),
+				}},
+			},
+			{
+				Name:     "worker",
+				Phase:    ResourcePhaseFailed,
+				FailMode: FailWholeDeployProcessImmediately,
+				Cells:    []string{"0/1", "0", "0"},
+				Messages: []string{formatResourceError(false, "container/main: CrashLoopBackOff")},
+			},
+			{
+				Name:     "cron",
+				Phase:    ResourcePhaseFailed,
+				FailMode: IgnoreAndContinueDeployProcess,
+				Cells:    []string{"-", "-", "-"},
+				Messages: []string{formatResourceError(true, "ImagePullBackOff")},
+			},
+		},
+	}
+}
+
+func TestRenderStatusReportTable(t *testing.T) {
+	isStyleEnabled := logboek.Streams().IsStyleEnabled()
+	defer func() {
+		if isStyleEnabled {
+			logboek.Streams().EnableStyle()
+		} else {
+			logboek.Streams().DisableStyle()
+		}
+	}()
+
+	for _, tc := range []struct {
+		name           string
+		isStyleEnabled bool
+		noColor        bool
+	}{
+		{name: "color", isStyleEnabled: true},
+		{name: "no_color", isStyleEnabled: true, noColor: true},
+		{name: "no_style", isStyleEnabled: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.isStyleEnabled {
+				logboek.Streams().EnableStyle()
+			} else {
+				logboek.Streams().DisableStyle()
+			}
+
+			// The snapshot is built with the style of the case, since the messages are colored by the caller
+			res := RenderStatusReportTable(testStatusReportTable(), StatusReportRenderOptions{Width: 100, NoColor: tc.noColor})
+
+			goldenPath := filepath.Join("testdata", "status_report", tc.name+".golden")
+			// The tables are rendered the same with NoColor and without the style
+			if tc.name == "no_style" {
+				goldenPath = filepath.Join("testdata", "status_report", "no_color.golden")
+			}
+
+			if *updateGolden && tc.name != "no_style" {
+				if err := ioutil.WriteFile(goldenPath, []byte(res), 0644); err != nil {
+					t.Fatalf("unable to update golden file: %s", err)
+				}
+			}
+
+			expected, err := ioutil.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("unable to read golden file: %s", err)
+			}
+			if res != string(expected) {
+				t.Errorf("status report does not match %s:\n%s", goldenPath, res)
+			}
+		})
+	}
+}
+
+func TestMultitrackNoColor(t *testing.T) {
+	isStyleEnabled := logboek.Streams().IsStyleEnabled()
+	defer func() {
+		if isStyleEnabled {
+			logboek.Streams().EnableStyle()
+		} else {
+			logboek.Streams().DisableStyle()
+		}
+	}()
+	logboek.Streams().EnableStyle()
+
+	for _, noColor := range []bool{false, true} {
+		kube := fake.NewSimpleClientset()
+		pods, specs := createTestPods(t, kube, 1)
+
+		stopUpdates := runTestPodsUpdates(t, kube, pods, func(i, step int, pod *corev1.Pod) *corev1.Pod {
+			return pod
+		}, func(i int, pod *corev1.Pod) *corev1.Pod {
+			return setTestPodReady(pod)
+		})
+
+		_, out, err := runTestMultitrack(t, kube, MultitrackSpecs{Pods: specs}, MultitrackOptions{NoColor: noColor})
+		stopUpdates()
+
+		if err != nil {
+			t.Fatalf("NoColor=%v: unexpected error: %s", noColor, err)
+		}
+		if hasColors := strings.Contains(out, "\x1b["); hasColors == noColor {
+			t.Errorf("NoColor=%v: expected colors %v in the output:\n%s", noColor, !noColor, out)
+		}
+	}
+}
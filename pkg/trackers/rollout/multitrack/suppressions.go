@@ -0,0 +1,40 @@
+package multitrack
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/werf/kubedog/pkg/tracker"
+)
+
+// printSuppressionStats prints the numbers of the events and the conditions dropped by SuppressedEventReasons
+// and SuppressedConditionTypes, so that suppressed ones do not disappear silently.
+func printSuppressionStats(suppressions *tracker.Suppressions) {
+	if suppressions == nil {
+		return
+	}
+
+	stats := suppressions.Stats()
+	fmt.Printf("multitrack suppressed events: %s\n", formatSuppressionCounts(stats.Events))
+	fmt.Printf("multitrack suppressed conditions: %s\n", formatSuppressionCounts(stats.Conditions))
+}
+
+// formatSuppressionCounts returns e.g. "Preempted=3, TerminationByKubelet=1" or "none".
+func formatSuppressionCounts(counts map[string]int) string {
+	if len(counts) == 0 {
+		return "none"
+	}
+
+	var keys []string
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%d", key, counts[key]))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,264 @@
+package multitrack
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/kubernetes"
+	watchtools "k8s.io/client-go/tools/watch"
+)
+
+const (
+	defaultTLSExpiryHorizon            = 7 * 24 * time.Hour
+	tlsSecretsCheckRetryPeriod         = 5 * time.Second
+	tlsSecretsCheckRetryPeriodFastMode = 500 * time.Millisecond
+)
+
+// tlsExpiryHorizon returns TLSExpiryHorizonSeconds of the spec, 0 means only expired certificates are reported.
+func tlsExpiryHorizon(spec MultitrackSpec) time.Duration {
+	switch {
+	case spec.TLSExpiryHorizonSeconds < 0:
+		return 0
+	case spec.TLSExpiryHorizonSeconds == 0:
+		return defaultTLSExpiryHorizon
+	default:
+		return time.Duration(spec.TLSExpiryHorizonSeconds) * time.Second
+	}
+}
+
+// trackMountedTLSSecrets verifies the certificates of the TLS secrets mounted by the Pod template of the ready resource.
+// Problems are shown as warnings, with StrictTLSSecretVerification they are counted as the resource failures and the check
+// is retried while the failure is tolerated, e.g. until the certificate is renewed. When secrets cannot be read
+// with the client permissions, the check is skipped for all resources with a single notice.
+func (mt *multitracker) trackMountedTLSSecrets(kube kubernetes.Interface, kind string, spec MultitrackSpec, resourcesStates map[string]*multitrackerResourceState, opts MultitrackOptions) error {
+	parentContext := opts.ParentContext
+	if parentContext == nil {
+		parentContext = context.Background()
+	}
+	ctx, cancel := watchtools.ContextWithOptionalTimeout(parentContext, opts.Timeout)
+	defer cancel()
+
+	retryPeriod := tlsSecretsCheckRetryPeriod
+	if opts.FastMode {
+		retryPeriod = tlsSecretsCheckRetryPeriodFastMode
+	}
+
+	if isForbidden := func() bool {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+
+		if mt.isTLSSecretsCheckForbidden {
+			return true
+		}
+		mt.displayResourceTrackerMessageF(kind, spec, "verifying mounted TLS secrets")
+
+		return false
+	}(); isForbidden {
+		return nil
+	}
+
+	for {
+		secrets, err := checkMountedTLSSecrets(ctx, kube, kind, spec, time.Now())
+		if apierrors.IsForbidden(err) {
+			mt.mux.Lock()
+			defer mt.mux.Unlock()
+
+			if !mt.isTLSSecretsCheckForbidden {
+				mt.isTLSSecretsCheckForbidden = true
+				mt.displayMultitrackServiceMessageF("Mounted TLS secrets are not verified: %s\n", err)
+			}
+
+			return nil
+		}
+
+		if err == nil {
+			mt.mux.Lock()
+			defer mt.mux.Unlock()
+
+			if len(secrets) == 0 {
+				mt.displayResourceTrackerMessageF(kind, spec, "no mounted TLS secrets")
+			} else {
+				mt.displayResourceTrackerMessageF(kind, spec, "mounted TLS secrets verified: %s", strings.Join(secrets, ", "))
+			}
+
+			return nil
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		reason := fmt.Sprintf("mounted TLS secrets verification failed: %s", err)
+
+		if !spec.StrictTLSSecretVerification {
+			mt.mux.Lock()
+			defer mt.mux.Unlock()
+
+			mt.displayResourceWarningF(kind, spec, "%s", reason)
+
+			return nil
+		}
+
+		if err := func() error {
+			mt.mux.Lock()
+			defer mt.mux.Unlock()
+
+			mt.displayResourceErrorF(kind, spec, "%s", reason)
+
+			return mt.handleResourceFailure(resourcesStates, kind, spec, reason)
+		}(); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retryPeriod):
+		}
+	}
+}
+
+// checkMountedTLSSecrets returns the checked secrets with tls.crt, e.g. "secret/api-tls". Secrets which do not exist are skipped,
+// the error of reading the secret is returned as is, so that the caller can tell the missing permissions.
+func checkMountedTLSSecrets(ctx context.Context, kube kubernetes.Interface, kind string, spec MultitrackSpec, now time.Time) ([]string, error) {
+	template, err := getPodTemplate(ctx, kube, kind, spec.Namespace, spec.ResourceName)
+	if err != nil {
+		return nil, err
+	}
+
+	var checked, problems []string
+	for _, secretName := range mountedSecretsNames(template.Spec) {
+		secret, err := kube.CoreV1().Secrets(spec.Namespace).Get(ctx, secretName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return checked, err
+		}
+
+		cert, hasKey := secret.Data[corev1.TLSCertKey]
+		if !hasKey {
+			continue
+		}
+
+		checked = append(checked, fmt.Sprintf("secret/%s", secretName))
+		problems = append(problems, checkTLSCertificates(secretName, cert, now, tlsExpiryHorizon(spec))...)
+	}
+
+	if len(problems) > 0 {
+		return checked, errors.New(strings.Join(problems, "; "))
+	}
+
+	return checked, nil
+}
+
+// checkTLSCertificates returns the problems of the PEM certificates of tls.crt, the whole chain is checked.
+func checkTLSCertificates(secretName string, data []byte, now time.Time, horizon time.Duration) []string {
+	var res []string
+
+	hasCertificates := false
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		hasCertificates = true
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			res = append(res, fmt.Sprintf("secret/%s %s: %s", secretName, corev1.TLSCertKey, err))
+			continue
+		}
+
+		switch {
+		case now.After(cert.NotAfter):
+			res = append(res, fmt.Sprintf("secret/%s certificate %q expired on %s (%s ago)", secretName, certificateName(cert), cert.NotAfter.UTC().Format(time.RFC3339), duration.HumanDuration(now.Sub(cert.NotAfter))))
+		case horizon > 0 && cert.NotAfter.Before(now.Add(horizon)):
+			res = append(res, fmt.Sprintf("secret/%s certificate %q expires on %s (in %s)", secretName, certificateName(cert), cert.NotAfter.UTC().Format(time.RFC3339), duration.HumanDuration(cert.NotAfter.Sub(now))))
+		}
+	}
+
+	if !hasCertificates {
+		res = append(res, fmt.Sprintf("secret/%s %s has no PEM certificates", secretName, corev1.TLSCertKey))
+	}
+
+	return res
+}
+
+func certificateName(cert *x509.Certificate) string {
+	if cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName
+	}
+	if len(cert.DNSNames) > 0 {
+		return cert.DNSNames[0]
+	}
+	return cert.SerialNumber.String()
+}
+
+// mountedSecretsNames returns the sorted names of the secrets of secret and projected volumes.
+func mountedSecretsNames(podSpec corev1.PodSpec) []string {
+	names := make(map[string]bool)
+	for _, volume := range podSpec.Volumes {
+		if volume.Secret != nil {
+			names[volume.Secret.SecretName] = true
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.Secret != nil {
+					names[source.Secret.Name] = true
+				}
+			}
+		}
+	}
+
+	var res []string
+	for name := range names {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func getPodTemplate(ctx context.Context, kube kubernetes.Interface, kind, namespace, name string) (*corev1.PodTemplateSpec, error) {
+	switch kind {
+	case "deploy":
+		object, err := kube.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &object.Spec.Template, nil
+	case "rs":
+		object, err := kube.AppsV1().ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &object.Spec.Template, nil
+	case "sts":
+		object, err := kube.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &object.Spec.Template, nil
+	case "ds":
+		object, err := kube.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &object.Spec.Template, nil
+	default:
+		return nil, fmt.Errorf("mounted TLS secrets verification is not supported for %s", kind)
+	}
+}
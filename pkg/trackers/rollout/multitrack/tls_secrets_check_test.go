@@ -0,0 +1,210 @@
+package multitrack
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var testTLSNow = time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// newTestCertificatePEM returns the self-signed PEM certificate valid until notAfter.
+func newTestCertificatePEM(t *testing.T, commonName string, dnsNames []string, notAfter time.Time) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate key: %s", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     dnsNames,
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("unable to create certificate: %s", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestCheckTLSCertificates(t *testing.T) {
+	valid := newTestCertificatePEM(t, "api.example.com", nil, testTLSNow.Add(90*24*time.Hour))
+	expiring := newTestCertificatePEM(t, "", []string{"web.example.com"}, testTLSNow.Add(3*24*time.Hour))
+	expired := newTestCertificatePEM(t, "", nil, testTLSNow.Add(-2*time.Hour))
+	key := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: []byte("key")})
+
+	tests := []struct {
+		name     string
+		data     []byte
+		horizon  time.Duration
+		expected []string
+	}{
+		{name: "valid", data: valid, horizon: defaultTLSExpiryHorizon},
+		{name: "key is skipped", data: append(append([]byte{}, key...), valid...), horizon: defaultTLSExpiryHorizon},
+		{
+			name:     "expiring within horizon",
+			data:     expiring,
+			horizon:  defaultTLSExpiryHorizon,
+			expected: []string{`secret/tls certificate "web.example.com" expires on 2020-06-04T12:00:00Z (in 3d)`},
+		},
+		{name: "expiring after horizon", data: expiring, horizon: 24 * time.Hour},
+		{name: "expiring without horizon", data: expiring, horizon: 0},
+		{
+			name:     "expired intermediate",
+			data:     append(append([]byte{}, valid...), expired...),
+			horizon:  0,
+			expected: []string{`secret/tls certificate "42" expired on 2020-06-01T10:00:00Z (120m ago)`},
+		},
+		{name: "no certificates", data: key, expected: []string{"secret/tls tls.crt has no PEM certificates"}},
+		{name: "not PEM", data: []byte("not a certificate"), expected: []string{"secret/tls tls.crt has no PEM certificates"}},
+	}
+
+	for _, tt := range tests {
+		res := checkTLSCertificates("tls", tt.data, testTLSNow, tt.horizon)
+		if strings.Join(res, "\n") != strings.Join(tt.expected, "\n") {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, res)
+		}
+	}
+
+	for seconds, expected := range map[int]time.Duration{-1: 0, 0: defaultTLSExpiryHorizon, 3600: time.Hour} {
+		if res := tlsExpiryHorizon(MultitrackSpec{TLSExpiryHorizonSeconds: seconds}); res != expected {
+			t.Errorf("TLSExpiryHorizonSeconds %d: expected %s, got %s", seconds, expected, res)
+		}
+	}
+}
+
+// newTestTLSDeployment creates the Deployment mounting the secrets by the secret volume and the projected volume,
+// the secret of the projected volume is also mounted by the secret volume.
+func newTestTLSDeployment(t *testing.T, kube *fake.Clientset, secrets ...*corev1.Secret) {
+	t.Helper()
+
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "prod"},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Volumes: []corev1.Volume{
+			{Name: "tls", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "api-tls"}}},
+			{Name: "config", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "api-config"}}},
+			{Name: "missing", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "api-missing"}}},
+			{Name: "projected", VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{Sources: []corev1.VolumeProjection{
+				{Secret: &corev1.SecretProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "ca-tls"}}},
+				{Secret: &corev1.SecretProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "api-tls"}}},
+			}}}},
+		}}}},
+	}
+	if _, err := kube.AppsV1().Deployments("prod").Create(context.Background(), deploy, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, secret := range secrets {
+		if _, err := kube.CoreV1().Secrets("prod").Create(context.Background(), secret, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+}
+
+func TestCheckMountedTLSSecrets(t *testing.T) {
+	kube := fake.NewSimpleClientset()
+	newTestTLSDeployment(t, kube,
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "api-tls", Namespace: "prod"}, Data: map[string][]byte{corev1.TLSCertKey: newTestCertificatePEM(t, "api", nil, testTLSNow.Add(time.Hour))}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "ca-tls", Namespace: "prod"}, Data: map[string][]byte{corev1.TLSCertKey: newTestCertificatePEM(t, "ca", nil, testTLSNow.Add(time.Hour))}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "api-config", Namespace: "prod"}, Data: map[string][]byte{"config.yaml": []byte("{}")}},
+	)
+
+	spec := MultitrackSpec{ResourceName: "api", Namespace: "prod"}
+	secrets, err := checkMountedTLSSecrets(context.Background(), kube, "deploy", spec, testTLSNow)
+
+	expectedErr := `secret/api-tls certificate "api" expires on 2020-06-01T13:00:00Z (in 60m); secret/ca-tls certificate "ca" expires on 2020-06-01T13:00:00Z (in 60m)`
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("expected error %q, got %v", expectedErr, err)
+	}
+	if strings.Join(secrets, " ") != "secret/api-tls secret/ca-tls" {
+		t.Errorf("unexpected checked secrets %q", secrets)
+	}
+
+	spec.TLSExpiryHorizonSeconds = -1
+	if _, err := checkMountedTLSSecrets(context.Background(), kube, "deploy", spec, testTLSNow); err != nil {
+		t.Errorf("unexpected error without expiry horizon: %s", err)
+	}
+
+	if _, err := checkMountedTLSSecrets(context.Background(), kube, "job", spec, testTLSNow); err == nil || err.Error() != "mounted TLS secrets verification is not supported for job" {
+		t.Errorf("expected not supported kind error, got %v", err)
+	}
+}
+
+func TestTrackMountedTLSSecrets(t *testing.T) {
+	expiredSecret := func() *corev1.Secret {
+		return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "api-tls", Namespace: "prod"}, Data: map[string][]byte{corev1.TLSCertKey: newTestCertificatePEM(t, "api", nil, time.Now().Add(-time.Hour))}}
+	}
+
+	tests := []struct {
+		name              string
+		secrets           []*corev1.Secret
+		isForbidden       bool
+		expectedMessages  string
+		expectedForbidden bool
+		expectedWarning   bool
+	}{
+		{name: "no TLS secrets", expectedMessages: "verifying mounted TLS secrets, no mounted TLS secrets"},
+		{name: "expired", secrets: []*corev1.Secret{expiredSecret()}, expectedMessages: "verifying mounted TLS secrets", expectedWarning: true},
+		{name: "forbidden", isForbidden: true, expectedMessages: "verifying mounted TLS secrets", expectedForbidden: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kube := fake.NewSimpleClientset()
+			newTestTLSDeployment(t, kube, tt.secrets...)
+			if tt.isForbidden {
+				kube.PrependReactor("get", "secrets", func(k8stesting.Action) (bool, runtime.Object, error) {
+					return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "secrets"}, "api-tls", nil)
+				})
+			}
+
+			mt := newTestMultitracker()
+			spec := MultitrackSpec{ResourceName: "api", Namespace: "prod", VerifyMountedTLSSecrets: true}
+			if _, err := mt.addResource("deploy", mt.DeploymentsSpecs, mt.TrackingDeployments, mt.DeploymentsContexts, spec, nil); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if err := mt.trackMountedTLSSecrets(kube, "deploy", spec, mt.TrackingDeployments, MultitrackOptions{}); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if msgs := strings.Join(mt.serviceMessagesByResource["deploy/prod/api"], ", "); msgs != tt.expectedMessages {
+				t.Errorf("expected messages %q, got %q", tt.expectedMessages, msgs)
+			}
+			if mt.isTLSSecretsCheckForbidden != tt.expectedForbidden {
+				t.Errorf("expected forbidden check %t, got %t", tt.expectedForbidden, mt.isTLSSecretsCheckForbidden)
+			}
+
+			// Problems are warnings without StrictTLSSecretVerification, the resource does not fail
+			isWarned := strings.Contains(strings.Join(mt.outputAdapter.(*recordingOutputAdapter).Entries(), "\n"), `mounted TLS secrets verification failed: secret/api-tls certificate "api" expired on`)
+			if isWarned != tt.expectedWarning {
+				t.Errorf("unexpected warning of the expired certificate: %q", mt.outputAdapter.(*recordingOutputAdapter).Entries())
+			}
+			if state := mt.TrackingDeployments[resourceKey(spec)]; state.Status == ResourceFailed {
+				t.Errorf("unexpected failed resource")
+			}
+		})
+	}
+}
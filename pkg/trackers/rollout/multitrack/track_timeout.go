@@ -0,0 +1,60 @@
+package multitrack
+
+import (
+	"time"
+)
+
+const trackTimeoutExpiredReason = "track timeout expired"
+
+// runTrackTimeout handles expiration of the resource TrackTimeoutSeconds as the resource failure, so that FailMode
+// and AllowFailuresCount apply. While the failure is allowed the timeout is restarted, each expiration counts as one failure.
+func (mt *multitracker) runTrackTimeout(kind string, spec MultitrackSpec, mtCtx *multitrackerContext) {
+	defer mt.recoverTrackerPanic(kind, spec)
+
+	timeout := time.Duration(*spec.TrackTimeoutSeconds) * time.Second
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-mtCtx.Context.Done():
+			return
+		case <-timer.C:
+		}
+
+		if !mt.handleTrackTimeout(kind, spec, mtCtx) {
+			return
+		}
+
+		timer.Reset(timeout)
+	}
+}
+
+// handleTrackTimeout returns true when the resource should be tracked further.
+func (mt *multitracker) handleTrackTimeout(kind string, spec MultitrackSpec, mtCtx *multitrackerContext) bool {
+	mt.mux.Lock()
+	defer mt.mux.Unlock()
+	defer mt.emitResourceStatusEvent(kind, spec)
+
+	resourcesStates := mt.resourcesStatesByKind(kind)
+	state := resourcesStates[resourceKey(spec)]
+	if mt.isTerminating || state.Status == ResourceSucceeded || state.Status == ResourceFailed {
+		return false
+	}
+
+	mt.displayResourceErrorF(kind, spec, "%s after %ds", trackTimeoutExpiredReason, *spec.TrackTimeoutSeconds)
+
+	err := mt.handleResourceFailure(resourcesStates, kind, spec, mt.withOrdinalStall(kind, spec, trackTimeoutExpiredReason))
+	if err == nil && spec.FailMode != IgnoreAndContinueDeployProcess {
+		return true
+	}
+
+	// The resource is dropped: the tracker returns context.Canceled and the error of the timeout is reported instead.
+	if err == ErrFailWholeDeployProcessImmediately {
+		mtCtx.Err = err
+	}
+	mtCtx.CancelFunc()
+
+	return false
+}
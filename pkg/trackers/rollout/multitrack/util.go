@@ -20,3 +20,12 @@ func deleteElemFromArray(arr []string, elemToDelete string) []string {
 
 	return newArr
 }
+
+func isElemInArray(arr []string, elem string) bool {
+	for _, e := range arr {
+		if e == elem {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+package multitrack
+
+import (
+	"fmt"
+
+	"github.com/werf/kubedog/pkg/tracker/generic"
+)
+
+// Verbosity controls the amount of the displayed output of the Multitrack call, see Verbosity option of MultitrackOptions.
+type Verbosity string
+
+const (
+	// VerbosityQuiet shows only the final status report, errors and the service messages of the failed resources:
+	// no periodic status reports, logs, events and service messages.
+	VerbosityQuiet Verbosity = "Quiet"
+	// VerbosityNormal is the default output.
+	VerbosityNormal Verbosity = "Normal"
+	// VerbosityVerbose additionally traces the received list results and status updates of the resources
+	// and the transitions of their phases and status conditions, regardless of ShowServiceMessages.
+	VerbosityVerbose Verbosity = "Verbose"
+)
+
+func validateVerbosity(opts MultitrackOptions) error {
+	switch opts.Verbosity {
+	case "", VerbosityQuiet, VerbosityNormal, VerbosityVerbose:
+		return nil
+	default:
+		return fmt.Errorf("bad Verbosity %q, expected %s, %s or %s", opts.Verbosity, VerbosityQuiet, VerbosityNormal, VerbosityVerbose)
+	}
+}
+
+func (mt *multitracker) isQuiet() bool {
+	return mt.verbosity == VerbosityQuiet
+}
+
+type resourceTraceState struct {
+	StatusGeneration uint64
+	Phase            ResourcePhase
+	Conditions       map[string]generic.Condition
+}
+
+// displayResourceTraceF shows the trace of the resource with VerbosityVerbose, it must be called under handlers mutex.
+func (mt *multitracker) displayResourceTraceF(resourceKind string, spec MultitrackSpec, format string, a ...interface{}) {
+	if mt.verbosity != VerbosityVerbose {
+		return
+	}
+
+	msg := fmt.Sprintf(fmt.Sprintf("trace: %s", format), a...)
+
+	mt.display.write(mt.resourceServiceMessagesSource(resourceKind, spec), func() {
+		if mt.outputAdapter != nil {
+			mt.outputAdapter.Line(msg)
+		} else {
+			mt.logger.Default().LogFDetails("%s\n", msg)
+		}
+	})
+}
+
+// traceResourceList traces the result of the list request of the resource informer.
+func (mt *multitracker) traceResourceList(kind string, spec MultitrackSpec, resourceFound bool, err error) {
+	switch {
+	case err != nil:
+		mt.displayResourceTraceF(kind, spec, "list failed: %s", err)
+	case resourceFound:
+		mt.displayResourceTraceF(kind, spec, "list: resource found")
+	default:
+		mt.displayResourceTraceF(kind, spec, "list: resource not found")
+	}
+}
+
+// traceResourceStatus traces the received status update of the resource, the change of its phase
+// and the transitions of its status conditions since the previous call. It must be called under handlers mutex.
+func (mt *multitracker) traceResourceStatus(kind string, spec MultitrackSpec) {
+	if mt.verbosity != VerbosityVerbose {
+		return
+	}
+
+	key := fmt.Sprintf("%s/%s", kind, resourceKey(spec))
+	state := mt.resourcesTraces[key]
+	if state == nil {
+		state = &resourceTraceState{Phase: ResourcePhasePending, Conditions: make(map[string]generic.Condition)}
+		mt.resourcesTraces[key] = state
+	}
+
+	statusGeneration, conditions, hasStatus := mt.resourceStatusConditions(kind, resourceKey(spec))
+	if hasStatus && statusGeneration != state.StatusGeneration {
+		state.StatusGeneration = statusGeneration
+		mt.displayResourceTraceF(kind, spec, "status update #%d received", statusGeneration)
+	}
+
+	for _, cond := range conditions {
+		prevCond, hasPrev := state.Conditions[cond.Type]
+		if hasPrev && prevCond.Status == cond.Status && prevCond.Reason == cond.Reason {
+			continue
+		}
+		state.Conditions[cond.Type] = cond
+
+		prevStatus := "<none>"
+		if hasPrev {
+			prevStatus = prevCond.Status
+		}
+
+		msg := fmt.Sprintf("condition %s: %s -> %s", cond.Type, prevStatus, cond.Status)
+		if cond.Reason != "" {
+			msg += fmt.Sprintf(" (%s)", cond.Reason)
+		}
+		if cond.Message != "" {
+			msg += fmt.Sprintf(": %s", cond.Message)
+		}
+		mt.displayResourceTraceF(kind, spec, "%s", msg)
+	}
+
+	if phase := mt.newResourceStatusEvent(kind, spec).Phase; phase != state.Phase {
+		mt.displayResourceTraceF(kind, spec, "phase: %s -> %s", state.Phase, phase)
+		state.Phase = phase
+	}
+}
+
+// resourceStatusConditions returns the status generation and the raw status conditions from the last status of the resource,
+// false when the status is not received yet.
+func (mt *multitracker) resourceStatusConditions(kind, key string) (uint64, []generic.Condition, bool) {
+	var conditions []generic.Condition
+	add := func(condType, status, reason, message string) {
+		conditions = append(conditions, generic.Condition{Type: condType, Status: status, Reason: reason, Message: message})
+	}
+
+	switch kind {
+	case "deploy":
+		status, hasStatus := mt.DeploymentsStatuses[key]
+		for _, c := range status.Conditions {
+			add(string(c.Type), string(c.Status), c.Reason, c.Message)
+		}
+		return status.StatusGeneration, conditions, hasStatus
+	case "rs":
+		status, hasStatus := mt.ReplicaSetsStatuses[key]
+		for _, c := range status.Conditions {
+			add(string(c.Type), string(c.Status), c.Reason, c.Message)
+		}
+		return status.StatusGeneration, conditions, hasStatus
+	case "sts":
+		status, hasStatus := mt.StatefulSetsStatuses[key]
+		for _, c := range status.Conditions {
+			add(string(c.Type), string(c.Status), c.Reason, c.Message)
+		}
+		return status.StatusGeneration, conditions, hasStatus
+	case "ds":
+		status, hasStatus := mt.DaemonSetsStatuses[key]
+		for _, c := range status.Conditions {
+			add(string(c.Type), string(c.Status), c.Reason, c.Message)
+		}
+		return status.StatusGeneration, conditions, hasStatus
+	case "job":
+		status, hasStatus := mt.JobsStatuses[key]
+		for _, c := range status.Conditions {
+			add(string(c.Type), string(c.Status), c.Reason, c.Message)
+		}
+		return status.StatusGeneration, conditions, hasStatus
+	case "po":
+		status, hasStatus := mt.PodsStatuses[key]
+		for _, c := range status.Conditions {
+			add(string(c.Type), string(c.Status), c.Reason, c.Message)
+		}
+		return status.StatusGeneration, conditions, hasStatus
+	case "generic":
+		status, hasStatus := mt.GenericStatuses[key]
+		return status.StatusGeneration, status.Conditions, hasStatus
+	}
+
+	return 0, nil, false
+}
@@ -0,0 +1,127 @@
+package multitrack
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	watchtools "k8s.io/client-go/tools/watch"
+)
+
+// The webhook Service is checked with backoff: the period doubles after each failed check up to the max period,
+// each check makes 1 API request (3 with WebhookTLSCheck).
+const (
+	webhookServiceCheckPeriod            = 2 * time.Second
+	webhookServiceCheckMaxPeriod         = 10 * time.Second
+	webhookServiceCheckPeriodFastMode    = 200 * time.Millisecond
+	webhookServiceCheckMaxPeriodFastMode = 2 * time.Second
+)
+
+// webhookServiceName returns the name of the webhook Service of the resource, ResourceName by default.
+func webhookServiceName(spec MultitrackSpec) string {
+	if spec.WebhookServiceName == "" {
+		return spec.ResourceName
+	}
+	return spec.WebhookServiceName
+}
+
+// trackWebhookService waits until the Service of the ready resource serving webhooks has ready endpoints
+// and optionally accepts TLS connections through the apiserver proxy.
+func (mt *multitracker) trackWebhookService(kube kubernetes.Interface, kind string, spec MultitrackSpec, opts MultitrackOptions) error {
+	if !spec.ServesWebhook {
+		return nil
+	}
+
+	serviceName := webhookServiceName(spec)
+
+	parentContext := opts.ParentContext
+	if parentContext == nil {
+		parentContext = context.Background()
+	}
+	ctx, cancel := watchtools.ContextWithOptionalTimeout(parentContext, opts.Timeout)
+	defer cancel()
+
+	func() {
+		mt.mux.Lock()
+		defer mt.mux.Unlock()
+		mt.displayResourceTrackerMessageF(kind, spec, "waiting for webhook svc/%s", serviceName)
+	}()
+
+	checkPeriod, maxCheckPeriod := webhookServiceCheckPeriod, webhookServiceCheckMaxPeriod
+	if opts.FastMode {
+		checkPeriod, maxCheckPeriod = webhookServiceCheckPeriodFastMode, webhookServiceCheckMaxPeriodFastMode
+	}
+
+	var lastCheckErr error
+	for {
+		lastCheckErr = checkWebhookService(ctx, kube, spec.Namespace, serviceName, spec.WebhookTLSCheck)
+		if lastCheckErr == nil {
+			mt.mux.Lock()
+			defer mt.mux.Unlock()
+
+			mt.displayResourceTrackerMessageF(kind, spec, "webhook svc/%s is serving", serviceName)
+
+			return nil
+		}
+
+		timer := time.NewTimer(checkPeriod)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			if ctx.Err() == context.Canceled {
+				return ctx.Err()
+			}
+			return fmt.Errorf("webhook svc/%s is not serving: %s", serviceName, lastCheckErr)
+		case <-timer.C:
+		}
+
+		checkPeriod *= 2
+		if checkPeriod > maxCheckPeriod {
+			checkPeriod = maxCheckPeriod
+		}
+	}
+}
+
+func checkWebhookService(ctx context.Context, kube kubernetes.Interface, namespace, serviceName string, tlsCheck bool) error {
+	endpoints, err := kube.CoreV1().Endpoints(namespace).Get(ctx, serviceName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("endpoints check failed: %s", err)
+	}
+
+	hasReadyAddresses := false
+	for _, subset := range endpoints.Subsets {
+		if len(subset.Addresses) > 0 {
+			hasReadyAddresses = true
+			break
+		}
+	}
+	if !hasReadyAddresses {
+		return fmt.Errorf("endpoints check failed: no ready endpoints")
+	}
+
+	if !tlsCheck {
+		return nil
+	}
+
+	service, err := kube.CoreV1().Services(namespace).Get(ctx, serviceName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("tls check failed: %s", err)
+	}
+	if len(service.Spec.Ports) == 0 {
+		return fmt.Errorf("tls check failed: service has no ports")
+	}
+
+	port := fmt.Sprintf("%d", service.Spec.Ports[0].Port)
+	_, err = kube.CoreV1().Services(namespace).ProxyGet("https", serviceName, port, "/", nil).DoRaw(ctx)
+	if statusErr, ok := err.(*apierrors.StatusError); ok && statusErr.ErrStatus.Code < 500 {
+		// Any response from the webhook server itself means TLS connection succeeded
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("tls check failed: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,411 @@
+package multitrack
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// werf tracking annotations mapped onto MultitrackSpec fields, see WerfAnnotationCompatibility.
+const (
+	WerfTrackTerminationModeAnnotation      = "werf.io/track-termination-mode"
+	WerfFailModeAnnotation                  = "werf.io/fail-mode"
+	WerfFailuresAllowedPerReplicaAnnotation = "werf.io/failures-allowed-per-replica"
+	WerfLogRegexAnnotation                  = "werf.io/log-regex"
+	// WerfLogRegexForAnnotationPrefix is followed by the container name, e.g. "werf.io/log-regex-for-app".
+	WerfLogRegexForAnnotationPrefix         = "werf.io/log-regex-for-"
+	WerfSkipLogsAnnotation                  = "werf.io/skip-logs"
+	WerfSkipLogsForContainersAnnotation     = "werf.io/skip-logs-for-containers"
+	WerfShowLogsOnlyForContainersAnnotation = "werf.io/show-logs-only-for-containers"
+	WerfShowServiceMessagesAnnotation       = "werf.io/show-service-messages"
+	// WerfFailureThresholdSecondsAnnotation is not used by werf itself, it maps onto FailureThresholdSeconds.
+	WerfFailureThresholdSecondsAnnotation = "werf.io/failure-threshold-seconds"
+
+	// werfExternalDependencyAnnotationSuffix follows the dependency name in the key of the external dependency annotations,
+	// e.g. "db.external-dependency.werf.io/resource: statefulset/postgres" and "db.external-dependency.werf.io/namespace: infra".
+	werfExternalDependencyAnnotationSuffix = ".external-dependency.werf.io/"
+)
+
+// WerfAnnotations are the werf tracking annotations of a resource in typed form, nil and empty fields are not annotated.
+type WerfAnnotations struct {
+	TrackTerminationMode      TrackTerminationMode
+	FailMode                  FailMode
+	FailuresAllowedPerReplica *int
+	FailureThresholdSeconds   *int
+	LogRegex                  *regexp.Regexp
+	LogRegexByContainerName   map[string]*regexp.Regexp
+	SkipLogs                  *bool
+	SkipLogsForContainers     []string
+	ShowLogsOnlyForContainers []string
+	ShowServiceMessages       *bool
+
+	// ExternalDependencies have no kubedog counterpart, they are parsed for the callers ordering their own deploy steps.
+	ExternalDependencies []WerfExternalDependency
+
+	// Unknown are the keys of the other werf annotations, which are ignored.
+	Unknown []string
+}
+
+// WerfExternalDependency is the resource the annotated resource depends on, e.g. "statefulset/postgres" in namespace "infra".
+// Empty Namespace means the namespace of the annotated resource.
+type WerfExternalDependency struct {
+	Name      string
+	Resource  string
+	Namespace string
+}
+
+// ParseWerfAnnotations returns the werf tracking annotations among the annotations of a manifest or a live object.
+func ParseWerfAnnotations(annotations map[string]string) (WerfAnnotations, error) {
+	var res WerfAnnotations
+	dependencies := make(map[string]*WerfExternalDependency)
+
+	for _, key := range sortedAnnotationsKeys(annotations) {
+		value := annotations[key]
+
+		switch {
+		case key == WerfTrackTerminationModeAnnotation:
+			switch mode := TrackTerminationMode(value); mode {
+			case WaitUntilResourceReady, NonBlocking:
+				res.TrackTerminationMode = mode
+			default:
+				return WerfAnnotations{}, fmt.Errorf("bad %s annotation %q: expected %s or %s", key, value, WaitUntilResourceReady, NonBlocking)
+			}
+
+		case key == WerfFailModeAnnotation:
+			if _, hasKey := failModesStrictness[FailMode(value)]; !hasKey {
+				return WerfAnnotations{}, fmt.Errorf("bad %s annotation %q: expected %s, %s or %s", key, value, FailWholeDeployProcessImmediately, HopeUntilEndOfDeployProcess, IgnoreAndContinueDeployProcess)
+			}
+			res.FailMode = FailMode(value)
+
+		case key == WerfFailuresAllowedPerReplicaAnnotation:
+			count, err := strconv.Atoi(value)
+			if err != nil || count < 0 {
+				return WerfAnnotations{}, fmt.Errorf("bad %s annotation %q: expected non-negative integer", key, value)
+			}
+			res.FailuresAllowedPerReplica = &count
+
+		case key == WerfFailureThresholdSecondsAnnotation:
+			seconds, err := strconv.Atoi(value)
+			if err != nil {
+				return WerfAnnotations{}, fmt.Errorf("bad %s annotation %q: expected integer", key, value)
+			}
+			res.FailureThresholdSeconds = &seconds
+
+		case key == WerfLogRegexAnnotation:
+			logRegex, err := regexp.Compile(value)
+			if err != nil {
+				return WerfAnnotations{}, fmt.Errorf("bad %s annotation %q: %s", key, value, err)
+			}
+			res.LogRegex = logRegex
+
+		case strings.HasPrefix(key, WerfLogRegexForAnnotationPrefix):
+			logRegex, err := regexp.Compile(value)
+			if err != nil {
+				return WerfAnnotations{}, fmt.Errorf("bad %s annotation %q: %s", key, value, err)
+			}
+			if res.LogRegexByContainerName == nil {
+				res.LogRegexByContainerName = make(map[string]*regexp.Regexp)
+			}
+			res.LogRegexByContainerName[strings.TrimPrefix(key, WerfLogRegexForAnnotationPrefix)] = logRegex
+
+		case key == WerfSkipLogsAnnotation, key == WerfShowServiceMessagesAnnotation:
+			flag, err := strconv.ParseBool(value)
+			if err != nil {
+				return WerfAnnotations{}, fmt.Errorf("bad %s annotation %q: expected true or false", key, value)
+			}
+			if key == WerfSkipLogsAnnotation {
+				res.SkipLogs = &flag
+			} else {
+				res.ShowServiceMessages = &flag
+			}
+
+		case key == WerfSkipLogsForContainersAnnotation:
+			res.SkipLogsForContainers = splitAnnotationList(value)
+
+		case key == WerfShowLogsOnlyForContainersAnnotation:
+			res.ShowLogsOnlyForContainers = splitAnnotationList(value)
+
+		case strings.Contains(key, werfExternalDependencyAnnotationSuffix):
+			parts := strings.SplitN(key, werfExternalDependencyAnnotationSuffix, 2)
+			dependency, hasKey := dependencies[parts[0]]
+			if !hasKey {
+				dependency = &WerfExternalDependency{Name: parts[0]}
+				dependencies[parts[0]] = dependency
+			}
+			switch parts[1] {
+			case "resource":
+				dependency.Resource = value
+			case "namespace":
+				dependency.Namespace = value
+			default:
+				res.Unknown = append(res.Unknown, key)
+			}
+
+		case strings.HasPrefix(key, "werf.io/") || strings.Contains(key, ".werf.io/"):
+			res.Unknown = append(res.Unknown, key)
+		}
+	}
+
+	for _, name := range sortedDependenciesNames(dependencies) {
+		dependency := dependencies[name]
+		if dependency.Resource == "" {
+			return WerfAnnotations{}, fmt.Errorf("bad external dependency %q annotations: %sresource annotation is required", name, name+werfExternalDependencyAnnotationSuffix)
+		}
+		res.ExternalDependencies = append(res.ExternalDependencies, *dependency)
+	}
+
+	return res, nil
+}
+
+// ApplyToSpec sets the spec fields which are not set from the annotations, fields set in the spec take precedence.
+// AllowFailuresCount is FailuresAllowedPerReplica multiplied by the replicas of the resource, as werf does.
+// The returned messages describe each annotation applied or ignored.
+func (a WerfAnnotations) ApplyToSpec(spec *MultitrackSpec, replicas int) []string {
+	var msgs []string
+	mapped := func(key, value, field string) {
+		msgs = append(msgs, fmt.Sprintf("%s=%q mapped to %s", key, value, field))
+	}
+	ignored := func(key, field string) {
+		msgs = append(msgs, fmt.Sprintf("%s ignored: %s is set in the spec", key, field))
+	}
+
+	if a.TrackTerminationMode != "" {
+		if spec.TrackTerminationMode == "" {
+			spec.TrackTerminationMode = a.TrackTerminationMode
+			mapped(WerfTrackTerminationModeAnnotation, string(a.TrackTerminationMode), "TrackTerminationMode")
+		} else {
+			ignored(WerfTrackTerminationModeAnnotation, "TrackTerminationMode")
+		}
+	}
+
+	if a.FailMode != "" {
+		if spec.FailMode == "" {
+			spec.FailMode = a.FailMode
+			mapped(WerfFailModeAnnotation, string(a.FailMode), "FailMode")
+		} else {
+			ignored(WerfFailModeAnnotation, "FailMode")
+		}
+	}
+
+	if a.FailuresAllowedPerReplica != nil {
+		if spec.AllowFailuresCount == nil && spec.AllowFailuresCountPerReplica == nil {
+			if replicas < 1 {
+				replicas = 1
+			}
+			spec.AllowFailuresCount = new(int)
+			*spec.AllowFailuresCount = *a.FailuresAllowedPerReplica * replicas
+			mapped(WerfFailuresAllowedPerReplicaAnnotation, strconv.Itoa(*a.FailuresAllowedPerReplica), fmt.Sprintf("AllowFailuresCount %d (%d replicas)", *spec.AllowFailuresCount, replicas))
+		} else if spec.AllowFailuresCount != nil {
+			ignored(WerfFailuresAllowedPerReplicaAnnotation, "AllowFailuresCount")
+		} else {
+			ignored(WerfFailuresAllowedPerReplicaAnnotation, "AllowFailuresCountPerReplica")
+		}
+	}
+
+	if a.FailureThresholdSeconds != nil {
+		if spec.FailureThresholdSeconds == nil {
+			spec.FailureThresholdSeconds = new(int)
+			*spec.FailureThresholdSeconds = *a.FailureThresholdSeconds
+			mapped(WerfFailureThresholdSecondsAnnotation, strconv.Itoa(*a.FailureThresholdSeconds), "FailureThresholdSeconds")
+		} else {
+			ignored(WerfFailureThresholdSecondsAnnotation, "FailureThresholdSeconds")
+		}
+	}
+
+	if a.LogRegex != nil {
+		if spec.LogRegex == nil {
+			spec.LogRegex = a.LogRegex
+			mapped(WerfLogRegexAnnotation, a.LogRegex.String(), "LogRegex")
+		} else {
+			ignored(WerfLogRegexAnnotation, "LogRegex")
+		}
+	}
+
+	for _, containerName := range sortedRegexpsKeys(a.LogRegexByContainerName) {
+		key := WerfLogRegexForAnnotationPrefix + containerName
+		if spec.LogRegexByContainerName[containerName] != nil {
+			ignored(key, fmt.Sprintf("LogRegexByContainerName[%q]", containerName))
+			continue
+		}
+		if spec.LogRegexByContainerName == nil {
+			spec.LogRegexByContainerName = make(map[string]*regexp.Regexp)
+		}
+		spec.LogRegexByContainerName[containerName] = a.LogRegexByContainerName[containerName]
+		mapped(key, a.LogRegexByContainerName[containerName].String(), fmt.Sprintf("LogRegexByContainerName[%q]", containerName))
+	}
+
+	if a.SkipLogs != nil && *a.SkipLogs && !spec.SkipLogs {
+		spec.SkipLogs = true
+		mapped(WerfSkipLogsAnnotation, "true", "SkipLogs")
+	}
+
+	if len(a.SkipLogsForContainers) > 0 {
+		if len(spec.SkipLogsForContainers) == 0 {
+			spec.SkipLogsForContainers = a.SkipLogsForContainers
+			mapped(WerfSkipLogsForContainersAnnotation, strings.Join(a.SkipLogsForContainers, ","), "SkipLogsForContainers")
+		} else {
+			ignored(WerfSkipLogsForContainersAnnotation, "SkipLogsForContainers")
+		}
+	}
+
+	if len(a.ShowLogsOnlyForContainers) > 0 {
+		if len(spec.ShowLogsOnlyForContainers) == 0 {
+			spec.ShowLogsOnlyForContainers = a.ShowLogsOnlyForContainers
+			mapped(WerfShowLogsOnlyForContainersAnnotation, strings.Join(a.ShowLogsOnlyForContainers, ","), "ShowLogsOnlyForContainers")
+		} else {
+			ignored(WerfShowLogsOnlyForContainersAnnotation, "ShowLogsOnlyForContainers")
+		}
+	}
+
+	if a.ShowServiceMessages != nil && *a.ShowServiceMessages && !spec.ShowServiceMessages {
+		spec.ShowServiceMessages = true
+		mapped(WerfShowServiceMessagesAnnotation, "true", "ShowServiceMessages")
+	}
+
+	return msgs
+}
+
+// applyLiveWerfAnnotations maps the werf annotations of the live objects onto the specs before the defaults are set.
+// Resources which do not exist yet are tracked with their specs as is.
+func applyLiveWerfAnnotations(kube kubernetes.Interface, dynamicClient dynamic.Interface, specs MultitrackSpecs, opts MultitrackOptions) ([]string, error) {
+	ctx := opts.ParentContext
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var mux sync.Mutex
+	var wg sync.WaitGroup
+	var msgs []string
+	var errs []string
+
+	for _, kindSpecs := range []struct {
+		Kind  string
+		Specs []MultitrackSpec
+	}{
+		{"deploy", specs.Deployments},
+		{"rs", specs.ReplicaSets},
+		{"sts", specs.StatefulSets},
+		{"ds", specs.DaemonSets},
+		{"job", specs.Jobs},
+		{"po", specs.Pods},
+		{"generic", specs.Generic},
+	} {
+		if kindSpecs.Kind == "generic" && dynamicClient == nil {
+			// Generic specs without the dynamic client are rejected by validateGenericSpecs
+			continue
+		}
+
+		for i := range kindSpecs.Specs {
+			wg.Add(1)
+			go func(kind string, spec *MultitrackSpec) {
+				defer wg.Done()
+
+				id := fmt.Sprintf("%s/%s", kind, resourceKey(*spec))
+
+				object, err := getResourceObjectMeta(ctx, kube, dynamicClient, kind, *spec)
+				if err != nil {
+					if debug() {
+						fmt.Printf("unable to read werf annotations of %s: %s\n", id, err)
+					}
+					return
+				}
+
+				annotations, err := ParseWerfAnnotations(object.GetAnnotations())
+				if err != nil {
+					mux.Lock()
+					defer mux.Unlock()
+					errs = append(errs, fmt.Sprintf("%s: %s", id, err))
+					return
+				}
+
+				if debug() {
+					for _, key := range annotations.Unknown {
+						fmt.Printf("unknown werf annotation %s of %s ignored\n", key, id)
+					}
+					for _, dependency := range annotations.ExternalDependencies {
+						fmt.Printf("external dependency %s (%s) of %s is not tracked\n", dependency.Name, dependency.Resource, id)
+					}
+				}
+
+				replicas := 1
+				switch object := object.(type) {
+				case *appsv1.Deployment:
+					if object.Spec.Replicas != nil {
+						replicas = int(*object.Spec.Replicas)
+					}
+				case *appsv1.StatefulSet:
+					if object.Spec.Replicas != nil {
+						replicas = int(*object.Spec.Replicas)
+					}
+				case *appsv1.ReplicaSet:
+					if object.Spec.Replicas != nil {
+						replicas = int(*object.Spec.Replicas)
+					}
+				}
+
+				specMsgs := annotations.ApplyToSpec(spec, replicas)
+
+				mux.Lock()
+				defer mux.Unlock()
+				for _, msg := range specMsgs {
+					msgs = append(msgs, fmt.Sprintf("%s: %s", id, msg))
+				}
+			}(kindSpecs.Kind, &kindSpecs.Specs[i])
+		}
+	}
+
+	wg.Wait()
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return nil, fmt.Errorf("bad werf annotations:\n%s", strings.Join(errs, "\n"))
+	}
+
+	sort.Strings(msgs)
+	return msgs, nil
+}
+
+func splitAnnotationList(value string) []string {
+	var res []string
+	for _, elem := range strings.Split(value, ",") {
+		if elem = strings.TrimSpace(elem); elem != "" {
+			res = append(res, elem)
+		}
+	}
+	return res
+}
+
+func sortedAnnotationsKeys(annotations map[string]string) []string {
+	var res []string
+	for key := range annotations {
+		res = append(res, key)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func sortedDependenciesNames(dependencies map[string]*WerfExternalDependency) []string {
+	var res []string
+	for name := range dependencies {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func sortedRegexpsKeys(regexps map[string]*regexp.Regexp) []string {
+	var res []string
+	for key := range regexps {
+		res = append(res, key)
+	}
+	sort.Strings(res)
+	return res
+}
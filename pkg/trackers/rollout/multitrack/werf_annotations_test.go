@@ -0,0 +1,180 @@
+package multitrack
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseWerfAnnotations(t *testing.T) {
+	annotations, err := ParseWerfAnnotations(map[string]string{
+		"werf.io/track-termination-mode":           "NonBlocking",
+		"werf.io/fail-mode":                        "HopeUntilEndOfDeployProcess",
+		"werf.io/failures-allowed-per-replica":     "2",
+		"werf.io/failure-threshold-seconds":        "-1",
+		"werf.io/log-regex":                        "ERROR",
+		"werf.io/log-regex-for-proxy":              "^upstream",
+		"werf.io/skip-logs":                        "false",
+		"werf.io/skip-logs-for-containers":         "istio-proxy, ,linkerd",
+		"werf.io/show-service-messages":            "true",
+		"werf.io/weight":                           "10",
+		"db.external-dependency.werf.io/resource":  "statefulset/postgres",
+		"db.external-dependency.werf.io/namespace": "infra",
+		"mq.external-dependency.werf.io/resource":  "deployment/rabbitmq",
+		"mq.external-dependency.werf.io/timeout":   "5m",
+		"helm.sh/hook":                             "pre-install",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if annotations.TrackTerminationMode != NonBlocking || annotations.FailMode != HopeUntilEndOfDeployProcess {
+		t.Errorf("unexpected modes %q %q", annotations.TrackTerminationMode, annotations.FailMode)
+	}
+	if *annotations.FailuresAllowedPerReplica != 2 || *annotations.FailureThresholdSeconds != -1 {
+		t.Errorf("unexpected failures %d %d", *annotations.FailuresAllowedPerReplica, *annotations.FailureThresholdSeconds)
+	}
+	if annotations.LogRegex.String() != "ERROR" || len(annotations.LogRegexByContainerName) != 1 || annotations.LogRegexByContainerName["proxy"].String() != "^upstream" {
+		t.Errorf("unexpected log regexes %v %v", annotations.LogRegex, annotations.LogRegexByContainerName)
+	}
+	if *annotations.SkipLogs || !*annotations.ShowServiceMessages || annotations.ShowLogsOnlyForContainers != nil {
+		t.Errorf("unexpected flags %+v", annotations)
+	}
+	if strings.Join(annotations.SkipLogsForContainers, " ") != "istio-proxy linkerd" {
+		t.Errorf("unexpected containers %q", annotations.SkipLogsForContainers)
+	}
+
+	expectedDependencies := []WerfExternalDependency{
+		{Name: "db", Resource: "statefulset/postgres", Namespace: "infra"},
+		{Name: "mq", Resource: "deployment/rabbitmq"},
+	}
+	if len(annotations.ExternalDependencies) != len(expectedDependencies) {
+		t.Fatalf("expected dependencies %+v, got %+v", expectedDependencies, annotations.ExternalDependencies)
+	}
+	for i := range expectedDependencies {
+		if annotations.ExternalDependencies[i] != expectedDependencies[i] {
+			t.Errorf("expected dependency %+v, got %+v", expectedDependencies[i], annotations.ExternalDependencies[i])
+		}
+	}
+
+	if expected := "mq.external-dependency.werf.io/timeout werf.io/weight"; strings.Join(annotations.Unknown, " ") != expected {
+		t.Errorf("expected unknown %q, got %q", expected, annotations.Unknown)
+	}
+}
+
+func TestParseWerfAnnotationsErrors(t *testing.T) {
+	for key, value := range map[string]string{
+		"werf.io/track-termination-mode":       "Blocking",
+		"werf.io/fail-mode":                    "Retry",
+		"werf.io/failures-allowed-per-replica": "-1",
+		"werf.io/failure-threshold-seconds":    "1m",
+		"werf.io/log-regex":                    "(",
+		"werf.io/log-regex-for-app":            "[",
+		"werf.io/skip-logs":                    "yes",
+		"werf.io/show-service-messages":        "on",
+	} {
+		_, err := ParseWerfAnnotations(map[string]string{key: value})
+		if err == nil || !strings.HasPrefix(err.Error(), "bad "+key+" annotation") {
+			t.Errorf("%s=%q: expected error of the annotation, got %v", key, value, err)
+		}
+	}
+
+	_, err := ParseWerfAnnotations(map[string]string{"db.external-dependency.werf.io/namespace": "infra"})
+	expectedErr := `bad external dependency "db" annotations: db.external-dependency.werf.io/resource annotation is required`
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("expected error %q, got %v", expectedErr, err)
+	}
+}
+
+func TestWerfAnnotationsApplyToSpec(t *testing.T) {
+	annotations, err := ParseWerfAnnotations(map[string]string{
+		"werf.io/fail-mode":                     "IgnoreAndContinueDeployProcess",
+		"werf.io/failures-allowed-per-replica":  "1",
+		"werf.io/log-regex":                     "ERROR",
+		"werf.io/log-regex-for-app":             "panic",
+		"werf.io/log-regex-for-proxy":           "upstream",
+		"werf.io/skip-logs":                     "true",
+		"werf.io/show-logs-only-for-containers": "app",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	spec := MultitrackSpec{
+		FailMode:                HopeUntilEndOfDeployProcess,
+		LogRegexByContainerName: map[string]*regexp.Regexp{"proxy": regexp.MustCompile("502")},
+	}
+	msgs := annotations.ApplyToSpec(&spec, 3)
+
+	expectedMsgs := []string{
+		`werf.io/fail-mode ignored: FailMode is set in the spec`,
+		`werf.io/failures-allowed-per-replica="1" mapped to AllowFailuresCount 3 (3 replicas)`,
+		`werf.io/log-regex="ERROR" mapped to LogRegex`,
+		`werf.io/log-regex-for-app="panic" mapped to LogRegexByContainerName["app"]`,
+		`werf.io/log-regex-for-proxy ignored: LogRegexByContainerName["proxy"] is set in the spec`,
+		`werf.io/skip-logs="true" mapped to SkipLogs`,
+		`werf.io/show-logs-only-for-containers="app" mapped to ShowLogsOnlyForContainers`,
+	}
+	if strings.Join(msgs, "\n") != strings.Join(expectedMsgs, "\n") {
+		t.Errorf("expected messages:\n%s\ngot:\n%s", strings.Join(expectedMsgs, "\n"), strings.Join(msgs, "\n"))
+	}
+
+	if spec.FailMode != HopeUntilEndOfDeployProcess || *spec.AllowFailuresCount != 3 || !spec.SkipLogs || spec.LogRegexByContainerName["proxy"].String() != "502" {
+		t.Errorf("unexpected spec %+v", spec)
+	}
+}
+
+// TestApplyLiveWerfAnnotations reads the annotations of the existing Deployment, the Deployment which does not exist yet
+// is tracked with its spec as is.
+func TestApplyLiveWerfAnnotations(t *testing.T) {
+	kube := fake.NewSimpleClientset()
+	object := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "api",
+			Namespace:   "prod",
+			Annotations: map[string]string{"werf.io/failures-allowed-per-replica": "2", "werf.io/track-termination-mode": "NonBlocking"},
+		},
+		Spec: appsv1.DeploymentSpec{Replicas: int32Ptr(2)},
+	}
+	if _, err := kube.AppsV1().Deployments("prod").Create(context.Background(), object, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	specs := MultitrackSpecs{Deployments: []MultitrackSpec{
+		{ResourceName: "api", Namespace: "prod"},
+		{ResourceName: "web", Namespace: "prod"},
+	}}
+	msgs, err := applyLiveWerfAnnotations(kube, nil, specs, MultitrackOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expectedMsgs := []string{
+		`deploy/prod/api: werf.io/failures-allowed-per-replica="2" mapped to AllowFailuresCount 4 (2 replicas)`,
+		`deploy/prod/api: werf.io/track-termination-mode="NonBlocking" mapped to TrackTerminationMode`,
+	}
+	if strings.Join(msgs, "\n") != strings.Join(expectedMsgs, "\n") {
+		t.Errorf("expected messages:\n%s\ngot:\n%s", strings.Join(expectedMsgs, "\n"), strings.Join(msgs, "\n"))
+	}
+
+	if api := specs.Deployments[0]; api.TrackTerminationMode != NonBlocking || api.AllowFailuresCount == nil || *api.AllowFailuresCount != 4 {
+		t.Errorf("unexpected spec of api %+v", api)
+	}
+	if web := specs.Deployments[1]; web.TrackTerminationMode != "" || web.AllowFailuresCount != nil {
+		t.Errorf("unexpected spec of web %+v", web)
+	}
+
+	object.Annotations = map[string]string{"werf.io/fail-mode": "Retry"}
+	if _, err := kube.AppsV1().Deployments("prod").Update(context.Background(), object, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	_, err = applyLiveWerfAnnotations(kube, nil, specs, MultitrackOptions{})
+	if err == nil || !strings.HasPrefix(err.Error(), "bad werf annotations:\ndeploy/prod/api: bad werf.io/fail-mode annotation \"Retry\"") {
+		t.Errorf("expected bad annotation error, got %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package multitrack
+
+import (
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// PrepareSpecsFromManifests builds the specs of the trackable objects among the manifests (Deployments, ReplicaSets,
+// StatefulSets, DaemonSets, Jobs and Pods, typed or unstructured) with the werf annotations mapped onto the spec fields,
+// see ParseWerfAnnotations. Other objects are skipped, malformed annotations of all objects are returned as a single error.
+func PrepareSpecsFromManifests(objs []runtime.Object) (MultitrackSpecs, error) {
+	var specs MultitrackSpecs
+	var errs []string
+
+	for _, obj := range objs {
+		kind, replicas := manifestTrackedKind(obj)
+		if kind == "" {
+			continue
+		}
+
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return MultitrackSpecs{}, fmt.Errorf("unable to access %s manifest metadata: %s", kind, err)
+		}
+
+		spec := MultitrackSpec{ResourceName: accessor.GetName(), Namespace: accessor.GetNamespace()}
+		id := fmt.Sprintf("%s/%s", kind, resourceKey(spec))
+
+		annotations, err := ParseWerfAnnotations(accessor.GetAnnotations())
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", id, err))
+			continue
+		}
+
+		msgs := annotations.ApplyToSpec(&spec, replicas)
+		if debug() {
+			for _, msg := range msgs {
+				fmt.Printf("%s: %s\n", id, msg)
+			}
+		}
+
+		switch kind {
+		case "deploy":
+			specs.Deployments = append(specs.Deployments, spec)
+		case "rs":
+			specs.ReplicaSets = append(specs.ReplicaSets, spec)
+		case "sts":
+			specs.StatefulSets = append(specs.StatefulSets, spec)
+		case "ds":
+			specs.DaemonSets = append(specs.DaemonSets, spec)
+		case "job":
+			specs.Jobs = append(specs.Jobs, spec)
+		case "po":
+			specs.Pods = append(specs.Pods, spec)
+		}
+	}
+
+	if len(errs) > 0 {
+		return MultitrackSpecs{}, fmt.Errorf("bad werf annotations:\n%s", strings.Join(errs, "\n"))
+	}
+
+	return specs, nil
+}
+
+// manifestTrackedKind returns the short kind of the trackable object and its replicas, empty kind for other objects.
+func manifestTrackedKind(obj runtime.Object) (string, int) {
+	switch obj := obj.(type) {
+	case *appsv1.Deployment:
+		return "deploy", replicasOrOne(obj.Spec.Replicas)
+	case *appsv1.ReplicaSet:
+		return "rs", replicasOrOne(obj.Spec.Replicas)
+	case *appsv1.StatefulSet:
+		return "sts", replicasOrOne(obj.Spec.Replicas)
+	case *appsv1.DaemonSet:
+		return "ds", 1
+	case *batchv1.Job:
+		return "job", 1
+	case *corev1.Pod:
+		return "po", 1
+	case *unstructured.Unstructured:
+		// Replicas are float64 when the manifest is decoded by the plain JSON or YAML decoder, not by the unstructured scheme
+		replicas := 1
+		if value, found, err := unstructured.NestedFieldNoCopy(obj.Object, "spec", "replicas"); err == nil && found {
+			switch value := value.(type) {
+			case int64:
+				replicas = int(value)
+			case float64:
+				replicas = int(value)
+			}
+		}
+
+		switch obj.GroupVersionKind().GroupKind().String() {
+		case "Deployment.apps":
+			return "deploy", replicas
+		case "ReplicaSet.apps":
+			return "rs", replicas
+		case "StatefulSet.apps":
+			return "sts", replicas
+		case "DaemonSet.apps":
+			return "ds", 1
+		case "Job.batch":
+			return "job", 1
+		case "Pod":
+			return "po", 1
+		}
+	}
+
+	return "", 0
+}
+
+func replicasOrOne(replicas *int32) int {
+	if replicas == nil {
+		return 1
+	}
+	return int(*replicas)
+}
@@ -0,0 +1,139 @@
+package multitrack
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// readTestManifests decodes the multi-document YAML into unstructured objects, as rendered manifests are usually read.
+func readTestManifests(t *testing.T, path string) []runtime.Object {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("unable to open manifests: %s", err)
+	}
+	defer file.Close()
+
+	var objs []runtime.Object
+	decoder := yaml.NewYAMLOrJSONDecoder(file, 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("unable to decode manifest: %s", err)
+		}
+		objs = append(objs, obj)
+	}
+	return objs
+}
+
+// formatTestSpecs returns the kind, the name and the fields mapped from werf annotations of each spec.
+func formatTestSpecs(specs MultitrackSpecs) []string {
+	var res []string
+	for _, kindSpecs := range []struct {
+		Kind  string
+		Specs []MultitrackSpec
+	}{
+		{"deploy", specs.Deployments},
+		{"rs", specs.ReplicaSets},
+		{"sts", specs.StatefulSets},
+		{"ds", specs.DaemonSets},
+		{"job", specs.Jobs},
+		{"po", specs.Pods},
+	} {
+		for _, spec := range kindSpecs.Specs {
+			fields := []string{fmt.Sprintf("%s/%s/%s", kindSpecs.Kind, spec.Namespace, spec.ResourceName)}
+			if spec.FailMode != "" {
+				fields = append(fields, fmt.Sprintf("FailMode=%s", spec.FailMode))
+			}
+			if spec.TrackTerminationMode != "" {
+				fields = append(fields, fmt.Sprintf("TrackTerminationMode=%s", spec.TrackTerminationMode))
+			}
+			if spec.AllowFailuresCount != nil {
+				fields = append(fields, fmt.Sprintf("AllowFailuresCount=%d", *spec.AllowFailuresCount))
+			}
+			for name, logRegex := range spec.LogRegexByContainerName {
+				fields = append(fields, fmt.Sprintf("LogRegexByContainerName[%s]=%s", name, logRegex))
+			}
+			if spec.SkipLogs {
+				fields = append(fields, "SkipLogs")
+			}
+			if len(spec.ShowLogsOnlyForContainers) > 0 {
+				fields = append(fields, fmt.Sprintf("ShowLogsOnlyForContainers=%s", strings.Join(spec.ShowLogsOnlyForContainers, ",")))
+			}
+			if spec.ShowServiceMessages {
+				fields = append(fields, "ShowServiceMessages")
+			}
+			res = append(res, strings.Join(fields, " "))
+		}
+	}
+	return res
+}
+
+func TestPrepareSpecsFromManifests(t *testing.T) {
+	objs := readTestManifests(t, filepath.Join("testdata", "werf_manifests", "release.yaml"))
+
+	// Typed objects and the objects decoded by the unstructured scheme with int64 replicas are accepted as well
+	objs = append(objs,
+		&unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "StatefulSet",
+			"metadata":   map[string]interface{}{"name": "zookeeper", "namespace": "prod", "annotations": map[string]interface{}{"werf.io/failures-allowed-per-replica": "1"}},
+			"spec":       map[string]interface{}{"replicas": int64(5)},
+		}},
+		&appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: "node-exporter", Namespace: "monitoring", Annotations: map[string]string{"werf.io/failures-allowed-per-replica": "1"}}},
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "legacy", Namespace: "prod", Annotations: map[string]string{"werf.io/failures-allowed-per-replica": "1"}}, Spec: appsv1.ReplicaSetSpec{Replicas: int32Ptr(4)}},
+	)
+
+	specs, err := PrepareSpecsFromManifests(objs)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []string{
+		"deploy/prod/api FailMode=HopeUntilEndOfDeployProcess AllowFailuresCount=6 LogRegexByContainerName[app]=ERROR|FATAL",
+		"rs/prod/legacy AllowFailuresCount=4",
+		"sts/prod/kafka TrackTerminationMode=NonBlocking SkipLogs",
+		"sts/prod/zookeeper AllowFailuresCount=5",
+		"ds/monitoring/node-exporter AllowFailuresCount=1",
+		"job/prod/migrate ShowLogsOnlyForContainers=migrate ShowServiceMessages",
+		"po/prod/debug",
+	}
+	if res := formatTestSpecs(specs); strings.Join(res, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected specs:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(res, "\n"))
+	}
+}
+
+func TestPrepareSpecsFromManifestsErrors(t *testing.T) {
+	objs := readTestManifests(t, filepath.Join("testdata", "werf_manifests", "release.yaml"))
+	for _, obj := range objs {
+		obj := obj.(*unstructured.Unstructured)
+		switch obj.GetName() {
+		case "api":
+			obj.SetAnnotations(map[string]string{"werf.io/failures-allowed-per-replica": "-1"})
+		case "debug":
+			obj.SetAnnotations(map[string]string{"werf.io/skip-logs": "sometimes"})
+		}
+	}
+
+	_, err := PrepareSpecsFromManifests(objs)
+
+	expectedErr := "bad werf annotations:\n" +
+		`deploy/prod/api: bad werf.io/failures-allowed-per-replica annotation "-1": expected non-negative integer` + "\n" +
+		`po/prod/debug: bad werf.io/skip-logs annotation "sometimes": expected true or false`
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("expected error:\n%s\ngot:\n%v", expectedErr, err)
+	}
+}
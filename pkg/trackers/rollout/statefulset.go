@@ -5,7 +5,6 @@ import (
 
 	"k8s.io/client-go/kubernetes"
 
-	"github.com/werf/kubedog/pkg/display"
 	"github.com/werf/kubedog/pkg/tracker"
 	"github.com/werf/kubedog/pkg/tracker/replicaset"
 	"github.com/werf/kubedog/pkg/tracker/statefulset"
@@ -19,36 +18,36 @@ func TrackStatefulSetTillReady(name, namespace string, kube kubernetes.Interface
 
 	feed.OnAdded(func(isReady bool) error {
 		if isReady {
-			fmt.Fprintf(display.Out, "# sts/%s appears to be ready\n", name)
+			opts.Display.OutF("# sts/%s appears to be ready\n", name)
 			return tracker.StopTrack
 		}
 
-		fmt.Fprintf(display.Out, "# sts/%s added\n", name)
+		opts.Display.OutF("# sts/%s added\n", name)
 		return nil
 	})
 	feed.OnReady(func() error {
-		fmt.Fprintf(display.Out, "# sts/%s become READY\n", name)
+		opts.Display.OutF("# sts/%s become READY\n", name)
 		return tracker.StopTrack
 	})
 	feed.OnFailed(func(reason string) error {
-		fmt.Fprintf(display.Out, "# sts/%s FAIL: %s\n", name, reason)
+		opts.Display.OutF("# sts/%s FAIL: %s\n", name, reason)
 		return tracker.ResourceErrorf("failed: %s", reason)
 	})
 	feed.OnEventMsg(func(msg string) error {
-		fmt.Fprintf(display.Out, "# sts/%s event: %s\n", name, msg)
+		opts.Display.OutF("# sts/%s event: %s\n", name, msg)
 		return nil
 	})
 	feed.OnAddedPod(func(pod replicaset.ReplicaSetPod) error {
-		fmt.Fprintf(display.Out, "# sts/%s po/%s added\n", name, pod.Name)
+		opts.Display.OutF("# sts/%s po/%s added\n", name, pod.Name)
 		return nil
 	})
 	feed.OnPodError(func(podError replicaset.ReplicaSetPodError) error {
-		fmt.Fprintf(display.Out, "# sts/%s %s %s error: %s\n", name, podError.PodName, podError.ContainerName, podError.Message)
+		opts.Display.OutF("# sts/%s %s %s error: %s\n", name, podError.PodName, podError.ContainerName, podError.Message)
 		return tracker.ResourceErrorf("sts/%s %s %s failed: %s", name, podError.PodName, podError.ContainerName, podError.Message)
 	})
 	feed.OnPodLogChunk(func(chunk *replicaset.ReplicaSetPodLogChunk) error {
 		header := fmt.Sprintf("po/%s %s", chunk.PodName, chunk.ContainerName)
-		display.OutputLogLines(header, chunk.LogLines)
+		opts.Display.OutputLogLines(header, chunk.LogLines)
 		return nil
 	})
 
@@ -58,7 +57,7 @@ func TrackStatefulSetTillReady(name, namespace string, kube kubernetes.Interface
 		case *tracker.ResourceError:
 			return e
 		default:
-			fmt.Fprintf(display.Err, "error tracking StatefulSet `%s` in namespace `%s`: %s\n", name, namespace, err)
+			opts.Display.ErrF("error tracking StatefulSet `%s` in namespace `%s`: %s\n", name, namespace, err)
 		}
 	}
 	return err
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultAsyncQueueSize           = 1000
+	DefaultAsyncQueueEnqueueTimeout = 100 * time.Millisecond
+)
+
+// AsyncQueue decouples producers from a slow consumer: Enqueue puts the task into a bounded queue,
+// which is drained by a dedicated goroutine performing the tasks one by one in the order they were enqueued.
+// When the queue is full for longer than enqueueTimeout the oldest queued task is dropped, so Enqueue never blocks for long.
+type AsyncQueue struct {
+	queue          chan func()
+	enqueueTimeout time.Duration
+
+	mux      sync.Mutex
+	flushing sync.WaitGroup
+	dropped  uint64
+}
+
+// NewAsyncQueue creates AsyncQueue and starts its goroutine.
+// 0 queueSize or enqueueTimeout means default value.
+func NewAsyncQueue(queueSize int, enqueueTimeout time.Duration) *AsyncQueue {
+	if queueSize == 0 {
+		queueSize = DefaultAsyncQueueSize
+	}
+	if enqueueTimeout == 0 {
+		enqueueTimeout = DefaultAsyncQueueEnqueueTimeout
+	}
+
+	q := &AsyncQueue{
+		queue:          make(chan func(), queueSize),
+		enqueueTimeout: enqueueTimeout,
+	}
+
+	go q.run()
+
+	return q
+}
+
+func (q *AsyncQueue) run() {
+	for task := range q.queue {
+		task()
+		q.flushing.Done()
+	}
+}
+
+func (q *AsyncQueue) Enqueue(task func()) {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+
+	q.flushing.Add(1)
+
+	select {
+	case q.queue <- task:
+		return
+	default:
+	}
+
+	timer := time.NewTimer(q.enqueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case q.queue <- task:
+		return
+	case <-timer.C:
+	}
+
+	// Queue is still full: drop the oldest task to make room for the new one.
+	// The goroutine may have drained the queue in the meantime, so dropping is optional.
+	select {
+	case <-q.queue:
+		q.flushing.Done()
+		atomic.AddUint64(&q.dropped, 1)
+	default:
+	}
+
+	q.queue <- task
+}
+
+// Flush blocks until all queued tasks are performed.
+func (q *AsyncQueue) Flush() {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+
+	q.flushing.Wait()
+}
+
+// Dropped returns the number of tasks dropped because the consumer could not keep up.
+func (q *AsyncQueue) Dropped() uint64 {
+	return atomic.LoadUint64(&q.dropped)
+}
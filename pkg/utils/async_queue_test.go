@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAsyncQueueOrder(t *testing.T) {
+	q := NewAsyncQueue(10, time.Second)
+
+	var mux sync.Mutex
+	var performed []int
+	for i := 0; i < 100; i++ {
+		i := i
+		q.Enqueue(func() {
+			mux.Lock()
+			defer mux.Unlock()
+			performed = append(performed, i)
+		})
+	}
+	q.Flush()
+
+	mux.Lock()
+	defer mux.Unlock()
+
+	if len(performed) != 100 {
+		t.Fatalf("expected 100 performed tasks after flush, got %d", len(performed))
+	}
+	for i, n := range performed {
+		if n != i {
+			t.Fatalf("expected tasks performed in the order of enqueueing, got %v", performed)
+		}
+	}
+	if q.Dropped() != 0 {
+		t.Errorf("expected no dropped tasks, got %d", q.Dropped())
+	}
+}
+
+// TestAsyncQueueDropOldest blocks the queue goroutine on the first task: once the queue is full,
+// each next task waits for enqueueTimeout and replaces the oldest queued one.
+func TestAsyncQueueDropOldest(t *testing.T) {
+	q := NewAsyncQueue(2, 10*time.Millisecond)
+
+	var mux sync.Mutex
+	var performed []string
+	perform := func(name string) func() {
+		return func() {
+			mux.Lock()
+			defer mux.Unlock()
+			performed = append(performed, name)
+		}
+	}
+
+	unblock := make(chan struct{})
+	started := make(chan struct{})
+	q.Enqueue(func() {
+		close(started)
+		<-unblock
+	})
+	<-started
+
+	startedAt := time.Now()
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		q.Enqueue(perform(name))
+	}
+	if elapsed := time.Since(startedAt); elapsed < 30*time.Millisecond {
+		t.Errorf("expected each task enqueued into the full queue to wait for the enqueue timeout, enqueued in %s", elapsed)
+	}
+
+	close(unblock)
+	q.Flush()
+
+	mux.Lock()
+	defer mux.Unlock()
+
+	if !reflect.DeepEqual(performed, []string{"d", "e"}) {
+		t.Errorf("expected the newest tasks performed, got %v", performed)
+	}
+	if q.Dropped() != 3 {
+		t.Errorf("expected 3 dropped tasks, got %d", q.Dropped())
+	}
+}
+
+// TestAsyncQueueFlush waits for the slow tasks from several producers.
+func TestAsyncQueueFlush(t *testing.T) {
+	q := NewAsyncQueue(0, 0)
+
+	var mux sync.Mutex
+	performed := 0
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				q.Enqueue(func() {
+					time.Sleep(time.Millisecond)
+					mux.Lock()
+					defer mux.Unlock()
+					performed++
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	q.Flush()
+
+	mux.Lock()
+	defer mux.Unlock()
+
+	if performed != 30 {
+		t.Errorf("expected 30 performed tasks after flush, got %d", performed)
+	}
+}
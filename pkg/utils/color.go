@@ -1,6 +1,9 @@
 package utils
 
 import (
+	"io"
+
+	"github.com/acarl005/stripansi"
 	"github.com/fatih/color"
 
 	"github.com/werf/logboek"
@@ -34,3 +37,19 @@ func colorStyle(attrs ...color.Attribute) *style.Style {
 func colorString(style *style.Style, format string, a ...interface{}) string {
 	return logboek.Colorize(style, format, a...)
 }
+
+// NewNoColorWriter returns the writer which strips colors from the text written into w.
+func NewNoColorWriter(w io.Writer) io.Writer {
+	return noColorWriter{w: w}
+}
+
+type noColorWriter struct {
+	w io.Writer
+}
+
+func (w noColorWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, stripansi.Strip(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
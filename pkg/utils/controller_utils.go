@@ -74,6 +74,12 @@ func ControllerAccessor(controller interface{}) ControllerMetadata {
 			Spec:       c.Spec.Template.Spec,
 		}
 		w.labelSelector = c.Spec.Selector
+	case *appsv1.ReplicaSet:
+		w.replicaSetTemplate = corev1.PodTemplateSpec{
+			ObjectMeta: c.Spec.Template.ObjectMeta,
+			Spec:       c.Spec.Template.Spec,
+		}
+		w.labelSelector = c.Spec.Selector
 	case *batchv1.Job:
 		w.replicaSetTemplate = corev1.PodTemplateSpec{
 			ObjectMeta: c.Spec.Template.ObjectMeta,
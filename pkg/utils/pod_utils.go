@@ -1,6 +1,9 @@
 package utils
 
-import corev1 "k8s.io/api/core/v1"
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
 
 func GetPodReplicaSetName(pod *corev1.Pod) string {
 	for _, ref := range pod.OwnerReferences {
@@ -10,3 +13,14 @@ func GetPodReplicaSetName(pod *corev1.Pod) string {
 	}
 	return ""
 }
+
+// IsPodOfReplicaSet returns true when the Pod is owned by the ReplicaSet and has the same pod-template-hash label,
+// so that the Pods of the previous revisions of Deployment are told apart from the Pods of its new ReplicaSet.
+func IsPodOfReplicaSet(pod *corev1.Pod, rs *appsv1.ReplicaSet) bool {
+	if GetPodReplicaSetName(pod) != rs.Name {
+		return false
+	}
+
+	hash, hasHash := rs.Labels[appsv1.DefaultDeploymentUniqueLabelKey]
+	return !hasHash || pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey] == hash
+}
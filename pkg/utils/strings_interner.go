@@ -0,0 +1,45 @@
+package utils
+
+import "sync"
+
+const DefaultStringsInternerLimit = 10000
+
+// StringsInterner deduplicates frequently repeated strings (reasons, messages, image and node names)
+// stored in the statuses of many Pods. Interner stops storing new strings when the limit is reached.
+type StringsInterner struct {
+	mux     sync.Mutex
+	strings map[string]string
+	limit   int
+}
+
+// NewStringsInterner creates interner, 0 limit means DefaultStringsInternerLimit.
+func NewStringsInterner(limit int) *StringsInterner {
+	if limit == 0 {
+		limit = DefaultStringsInternerLimit
+	}
+
+	return &StringsInterner{
+		strings: make(map[string]string),
+		limit:   limit,
+	}
+}
+
+// Intern returns the stored string equal to s if any. Nil interner returns s as is.
+func (i *StringsInterner) Intern(s string) string {
+	if i == nil || s == "" {
+		return s
+	}
+
+	i.mux.Lock()
+	defer i.mux.Unlock()
+
+	if interned, hasKey := i.strings[s]; hasKey {
+		return interned
+	}
+
+	if len(i.strings) < i.limit {
+		i.strings[s] = s
+	}
+
+	return s
+}
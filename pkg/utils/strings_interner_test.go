@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+// newTestString returns the copy of s which does not share memory with s, like the strings of decoded objects.
+func newTestString(s string) string {
+	return string([]byte(s))
+}
+
+func isSameString(a, b string) bool {
+	return (*reflect.StringHeader)(unsafe.Pointer(&a)).Data == (*reflect.StringHeader)(unsafe.Pointer(&b)).Data
+}
+
+func TestStringsInternerLimit(t *testing.T) {
+	interner := NewStringsInterner(2)
+
+	first := newTestString("CrashLoopBackOff")
+	if s := interner.Intern(first); !isSameString(s, first) {
+		t.Fatalf("expected the first string returned as is")
+	}
+	interner.Intern(newTestString("ImagePullBackOff"))
+
+	// The limit is reached, new strings are returned as is and not stored
+	errImagePull := newTestString("ErrImagePull")
+	if s := interner.Intern(errImagePull); !isSameString(s, errImagePull) {
+		t.Errorf("expected the string over the limit returned as is")
+	}
+	if s := interner.Intern(newTestString("ErrImagePull")); isSameString(s, errImagePull) {
+		t.Errorf("expected the string over the limit not stored")
+	}
+
+	// Stored strings are still deduplicated
+	if s := interner.Intern(newTestString("CrashLoopBackOff")); !isSameString(s, first) || s != "CrashLoopBackOff" {
+		t.Errorf("expected the stored string returned")
+	}
+
+	if n := len(interner.strings); n != 2 {
+		t.Errorf("expected 2 stored strings, got %d", n)
+	}
+}
+
+func TestStringsInternerDefaults(t *testing.T) {
+	if limit := NewStringsInterner(0).limit; limit != DefaultStringsInternerLimit {
+		t.Errorf("expected default limit %d, got %d", DefaultStringsInternerLimit, limit)
+	}
+
+	interner := NewStringsInterner(1)
+	if s := interner.Intern(""); s != "" || len(interner.strings) != 0 {
+		t.Errorf("expected empty string not stored")
+	}
+
+	// Nil interner is used when interning is disabled
+	var nilInterner *StringsInterner
+	message := newTestString(strings.Repeat("back-off restarting failed container ", 3))
+	if s := nilInterner.Intern(message); !isSameString(s, message) {
+		t.Errorf("expected nil interner to return the string as is")
+	}
+}
@@ -239,8 +239,13 @@ func (t *Table) getWidth() int {
 	}
 }
 
+// IsTerminal returns true when f is a terminal, e.g. to enable colors only for os.Stdout which is not redirected.
+func IsTerminal(f *os.File) bool {
+	return terminal.IsTerminal(int(f.Fd()))
+}
+
 func terminalWidth() int {
-	if terminal.IsTerminal(int(os.Stdout.Fd())) {
+	if IsTerminal(os.Stdout) {
 		w, _, err := terminal.GetSize(int(os.Stdout.Fd()))
 		if err != nil {
 			panic(fmt.Sprintf("get terminal size failed: %s", err))